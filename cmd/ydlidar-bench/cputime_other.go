@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "time"
+
+// cpuTime is unknown on this platform, the report leaves it out.
+func cpuTime() time.Duration {
+	return -1
+}
@@ -0,0 +1,89 @@
+// Command ydlidar-bench runs the lidar for a while and reports the throughput, the latency from
+// the arrival of a packet on the transport to its delivery on the Packets channel, the CPU
+// time spent and the allocations, to validate the driver on small boards such as the
+// Raspberry Pi Zero.
+//
+//	ydlidar-bench [-port device] [-duration 10s] [-scans]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"time"
+
+	"ydlidarg2/ydlidar"
+)
+
+func main() {
+	port := flag.String("port", "", "serial port or tcp://host:port of the lidar, auto-detected if empty")
+	duration := flag.Duration("duration", 10*time.Second, "how long to scan")
+	scans := flag.Bool("scans", false, "also assemble revolutions, as most applications do")
+	flag.Parse()
+
+	report, err := run(*port, *duration, *scans)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	report.print(os.Stdout)
+}
+
+func run(port string, duration time.Duration, scans bool) (*report, error) {
+	// The driver logs every packet, which would be benchmarked along with it.
+	log.SetOutput(io.Discard)
+
+	var opts []ydlidar.Option
+	if scans {
+		opts = append(opts, ydlidar.WithScans(2))
+	}
+	lidar, err := ydlidar.Connect(port, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer lidar.Close()
+
+	r := &report{}
+	var before runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	cpuBefore := cpuTime()
+	start := time.Now()
+
+	if err = lidar.StartScan(); err != nil {
+		return nil, err
+	}
+	timeout := time.After(duration)
+loop:
+	for {
+		select {
+		case packet := <-lidar.Packets:
+			if packet.Error != nil {
+				r.errors++
+				continue
+			}
+			r.addPacket(len(packet.Distances), time.Since(packet.Received))
+		case <-lidar.Scans:
+			r.scans++
+		case <-timeout:
+			break loop
+		}
+	}
+	if err = lidar.StopScan(); err != nil {
+		return nil, err
+	}
+
+	r.elapsed = time.Since(start)
+	r.cpu = cpuTime() - cpuBefore
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	r.allocs = after.Mallocs - before.Mallocs
+	r.allocBytes = after.TotalAlloc - before.TotalAlloc
+	r.gcs = after.NumGC - before.NumGC
+	r.metrics = lidar.Metrics()
+	r.stages = lidar.Latency()
+	return r, nil
+}
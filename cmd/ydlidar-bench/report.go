@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"ydlidarg2/ydlidar"
+)
+
+// report is the outcome of a benchmark run.
+type report struct {
+	elapsed    time.Duration
+	packets    int
+	points     int
+	errors     int
+	scans      int
+	latencies  []time.Duration // Arrival on the transport to delivery on the channel, per packet.
+	cpu        time.Duration   // Process CPU time, negative if unknown.
+	allocs     uint64
+	allocBytes uint64
+	gcs        uint32
+	metrics    ydlidar.Metrics
+	stages     *ydlidar.LatencyHistogram // Time spent in each stage of the scan loop.
+}
+
+// addPacket records a delivered packet.
+func (r *report) addPacket(points int, latency time.Duration) {
+	r.packets++
+	r.points += points
+	r.latencies = append(r.latencies, latency)
+}
+
+// percentile returns the latency below which p percent of the packets were delivered.
+func (r *report) percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), r.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	i := int(p / 100 * float64(len(sorted)-1))
+	return sorted[i]
+}
+
+// perPacket divides a total by the number of packets, 0 without packets.
+func (r *report) perPacket(total float64) float64 {
+	if r.packets == 0 {
+		return 0
+	}
+	return total / float64(r.packets)
+}
+
+func (r *report) print(w io.Writer) error {
+	seconds := r.elapsed.Seconds()
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Duration:\t%v\n", r.elapsed.Round(time.Millisecond))
+	fmt.Fprintf(tw, "Packets:\t%v (%.1f/s)\n", r.packets, float64(r.packets)/seconds)
+	fmt.Fprintf(tw, "Points:\t%v (%.0f/s)\n", r.points, float64(r.points)/seconds)
+	if r.scans > 0 {
+		fmt.Fprintf(tw, "Revolutions:\t%v (%.1f/s)\n", r.scans, float64(r.scans)/seconds)
+	}
+	fmt.Fprintf(tw, "Errors:\t%v packets, %v checksum failures, %v dropped\n", r.errors, r.metrics.ChecksumFailures, r.metrics.DroppedPackets)
+	fmt.Fprintf(tw, "Latency:\tp50 %v, p95 %v, p99 %v, max %v\n", r.percentile(50), r.percentile(95), r.percentile(99), r.percentile(100))
+	if r.stages != nil {
+		for _, stage := range ydlidar.Stages {
+			latency := r.stages.Stage(stage)
+			fmt.Fprintf(tw, "  %v:\tmean %v, p99 below %v\n", stage, latency.Mean(), bucketBound(latency.Quantile(0.99)))
+		}
+	}
+	if r.cpu >= 0 {
+		fmt.Fprintf(tw, "CPU:\t%v (%.1f%% of a core, %.1fµs per packet)\n", r.cpu.Round(time.Millisecond),
+			100*r.cpu.Seconds()/seconds, r.perPacket(float64(r.cpu.Microseconds())))
+	}
+	fmt.Fprintf(tw, "Allocations:\t%v (%.1f per packet), %v bytes (%.0f per packet), %v GCs\n",
+		r.allocs, r.perPacket(float64(r.allocs)), r.allocBytes, r.perPacket(float64(r.allocBytes)), r.gcs)
+	return tw.Flush()
+}
+
+// bucketBound formats a histogram bucket bound, -1 being unbounded.
+func bucketBound(bound time.Duration) string {
+	if bound < 0 {
+		return "∞"
+	}
+	return bound.String()
+}
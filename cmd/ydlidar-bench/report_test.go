@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"ydlidarg2/ydlidar"
+)
+
+func TestReportPercentile(t *testing.T) {
+	r := &report{}
+	assert.Zero(t, r.percentile(50))
+
+	for i := 10; i >= 1; i-- {
+		r.addPacket(40, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 400, r.points)
+	assert.Equal(t, 5*time.Millisecond, r.percentile(50))
+	assert.Equal(t, 10*time.Millisecond, r.percentile(100))
+	assert.Equal(t, time.Millisecond, r.percentile(0))
+}
+
+func TestReportPrint(t *testing.T) {
+	r := &report{elapsed: 2 * time.Second, cpu: 500 * time.Millisecond, allocs: 20}
+	for i := 0; i < 10; i++ {
+		r.addPacket(40, time.Millisecond)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.print(&buf))
+	assert.Contains(t, buf.String(), "Packets:      10 (5.0/s)\n")
+	assert.Contains(t, buf.String(), "Points:       400 (200/s)\n")
+	assert.Contains(t, buf.String(), "(25.0% of a core, 50000.0µs per packet)")
+	assert.Contains(t, buf.String(), "20 (2.0 per packet)")
+	assert.NotContains(t, buf.String(), "parse:")
+
+	r.stages = &ydlidar.LatencyHistogram{}
+	t0 := time.Now()
+	r.stages.ObserveStage(ydlidar.StageParse, t0, t0.Add(800*time.Microsecond))
+	r.stages.ObserveStage(ydlidar.StagePublish, t0, t0.Add(2*time.Second))
+	buf.Reset()
+	assert.NoError(t, r.print(&buf))
+	assert.Contains(t, buf.String(), "mean 800µs, p99 below 1ms\n")
+	assert.Contains(t, buf.String(), "mean 2s, p99 below ∞\n")
+}
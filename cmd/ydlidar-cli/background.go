@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/motion"
+)
+
+// runBackground implements the background command: it learns the static scene, which must
+// be clear of anything moving, and saves it for the motion detector and the safety monitor.
+func runBackground(port *string, args []string) error {
+	flags := flag.NewFlagSet("background", flag.ContinueOnError)
+	revolutions := flags.Int("n", 50, "revolutions to average")
+	resolution := flags.Float64("resolution", 0.5, "width of the angular bins in degrees")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: ydlidar-cli background [-n 50] [-resolution 0.5] background.json")
+	}
+
+	lidar, err := connect(port, ydlidar.WithScans(16))
+	if err != nil {
+		return err
+	}
+	defer lidar.Close()
+
+	background, err := motion.Learn(lidar, *revolutions, *resolution)
+	if err != nil {
+		return err
+	}
+	if err = background.Save(flags.Arg(0)); err != nil {
+		return err
+	}
+	log.Printf("Learned %v revolutions into %v", background.Revolutions(), flags.Arg(0))
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/conformance"
+)
+
+// runConformance implements the conformance command.
+func runConformance(port *string, args []string) error {
+	lidar, err := connect(port, ydlidar.WithScans(4))
+	if err != nil {
+		return err
+	}
+	defer lidar.Close()
+
+	m := conformance.Run(lidar)
+	if err = m.Print(os.Stdout); err != nil {
+		return err
+	}
+	if !m.Passed() {
+		return fmt.Errorf("conformance checks failed")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/export"
+	"ydlidarg2/ydlidar/render"
+	"ydlidarg2/ydlidar/rosbag"
+	"ydlidarg2/ydlidar/ydlog"
+)
+
+// runConvert implements the convert command: it converts a ydlog file to CSV or PCD, or
+// renders it to a PNG or SVG image, picking the format from the output extension, or to a
+// rosbag2 bag for an output without an extension.
+func runConvert(_ *string, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: ydlidar-cli convert log.ydlog output.csv|output.pcd|output.png|output.svg|bagdir")
+	}
+	in, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	log, err := ydlog.NewReader(in)
+	if err != nil {
+		return err
+	}
+
+	if filepath.Ext(args[1]) == "" {
+		return convertBag(log, args[1])
+	}
+
+	out, err := os.Create(args[1])
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	switch ext := filepath.Ext(args[1]); ext {
+	case ".csv":
+		w := export.NewCSVWriter(out)
+		for i := 0; i < log.Len(); i++ {
+			scan, err := log.Scan(i)
+			if err != nil {
+				return err
+			}
+			if err = w.WriteScan(scan); err != nil {
+				return err
+			}
+		}
+	case ".pcd", ".png", ".svg":
+		scans := make([]ydlidar.Scan, log.Len())
+		for i := range scans {
+			if scans[i], err = log.Scan(i); err != nil {
+				return err
+			}
+		}
+		switch ext {
+		case ".pcd":
+			err = export.WritePCD(out, scans)
+		case ".png":
+			err = render.PNG(out, scans, render.WithRangeRings(1000))
+		case ".svg":
+			err = render.SVG(out, scans, render.WithRangeRings(1000))
+		}
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown output format %q, want .csv, .pcd, .png or .svg", ext)
+	}
+	return out.Close()
+}
+
+// convertBag writes the log to a rosbag2 bag in the directory.
+func convertBag(log *ydlog.Reader, dir string) error {
+	bag, err := rosbag.Create(dir)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < log.Len(); i++ {
+		scan, err := log.Scan(i)
+		if err != nil {
+			bag.Close()
+			return err
+		}
+		if err = bag.WriteScan(scan); err != nil {
+			bag.Close()
+			return err
+		}
+	}
+	return bag.Close()
+}
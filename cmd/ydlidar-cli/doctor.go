@@ -0,0 +1,340 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+
+	"go.bug.st/serial"
+	"ydlidarg2/ydlidar"
+)
+
+// lidarBaud is the baud rate of the G2, the only one the driver talks.
+const lidarBaud = 230400
+
+// probeBauds are the baud rates tried when the lidar doesn't answer at lidarBaud, those of
+// the other YDLidar models.
+var probeBauds = []int{lidarBaud, 115200, 128000, 512000}
+
+// checkStatus is the outcome of a doctor check.
+type checkStatus int
+
+const (
+	checkPass checkStatus = iota
+	checkWarn
+	checkFail
+	checkSkip
+)
+
+func (s checkStatus) String() string {
+	return [...]string{"PASS", "WARN", "FAIL", "SKIP"}[s]
+}
+
+// checkResult is a line of the doctor report.
+type checkResult struct {
+	name   string
+	status checkStatus
+	detail string
+	hint   string // How to fix a warning or a failure.
+}
+
+// doctor runs the checks in order, each needing the previous ones to pass.
+type doctor struct {
+	port     *string
+	duration time.Duration // Length of the test scan.
+	results  []checkResult
+}
+
+// runDoctor implements the doctor command: it prints the report and fails if a check failed.
+func runDoctor(port *string, args []string) error {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	duration := flags.Duration("duration", 3*time.Second, "length of the test scan")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	d := &doctor{port: port, duration: *duration}
+	d.run()
+	d.print(os.Stdout)
+	if d.failed() {
+		return fmt.Errorf("the lidar failed the checks, see the hints above")
+	}
+	return nil
+}
+
+// run runs the checks, skipping the ones after a failure.
+func (d *doctor) run() {
+	if *simulate {
+		d.skip("simulated lidar", "port", "permissions", "baud rate")
+	} else {
+		path, ok := d.checkPort()
+		if !ok {
+			d.skip("no port", "permissions", "baud rate", "device info", "health", "scan")
+			return
+		}
+		if !d.checkPermissions(path) {
+			d.skip("port not accessible", "baud rate", "device info", "health", "scan")
+			return
+		}
+		if !d.checkBaud(path) {
+			d.skip("no answer from the lidar", "device info", "health", "scan")
+			return
+		}
+		d.port = &path
+	}
+
+	lidar, err := connect(d.port, ydlidar.WithScans(16))
+	var healthErr *ydlidar.HealthError
+	if errors.As(err, &healthErr) {
+		// Connecting checks the health once the device info answered.
+		d.add(checkResult{name: "device info", status: checkPass, detail: "answered"})
+		d.add(healthResult(healthErr))
+		d.skip("device not ready", "scan")
+		return
+	}
+	if err != nil {
+		d.add(checkResult{name: "device info", status: checkFail, detail: err.Error(),
+			hint: "the lidar answered the probe but not the driver: unplug it, wait 5 seconds and plug it back"})
+		d.skip("not connected", "health", "scan")
+		return
+	}
+	defer lidar.Close()
+
+	if !d.checkDeviceInfo(lidar) || !d.checkHealth(lidar) {
+		d.skip("device not ready", "scan")
+		return
+	}
+	d.checkScan(lidar)
+}
+
+// checkPort finds the port of the lidar, the one given with -port or the auto-detected one.
+func (d *doctor) checkPort() (string, bool) {
+	if d.port != nil {
+		// COM ports aren't files.
+		if _, err := os.Stat(*d.port); err != nil && runtime.GOOS != "windows" {
+			d.add(checkResult{name: "port", status: checkFail, detail: err.Error(),
+				hint: "check the -port name, or leave it out to auto-detect the lidar"})
+			return "", false
+		}
+		d.add(checkResult{name: "port", status: checkPass, detail: *d.port})
+		return *d.port, true
+	}
+
+	candidates, err := ydlidar.CandidatePorts()
+	if err != nil {
+		d.add(checkResult{name: "port", status: checkFail, detail: err.Error(), hint: "serial ports can't be listed on this system"})
+		return "", false
+	}
+	if len(candidates) == 0 {
+		d.add(checkResult{name: "port", status: checkFail, detail: "no USB serial port found",
+			hint: "plug the lidar in and check its adapter shows up (dmesg on Linux, the CP210x driver on Windows and macOS)"})
+		return "", false
+	}
+	path := candidates[len(candidates)-1]
+	result := checkResult{name: "port", status: checkPass, detail: path}
+	if len(candidates) > 1 {
+		result.status = checkWarn
+		result.detail = fmt.Sprintf("%v, auto-detected among %v", path, candidates)
+		result.hint = "several USB serial ports, pass -port to pick the lidar"
+	}
+	d.add(result)
+	return path, true
+}
+
+// checkBaud probes the device info at the baud rate of the G2, then at those of the other
+// models, to tell a silent device from a model the driver doesn't support.
+func (d *doctor) checkBaud(path string) bool {
+	for _, baud := range probeBauds {
+		ok, err := probeDeviceInfo(path, baud)
+		if err != nil {
+			d.add(checkResult{name: "baud rate", status: checkFail, detail: err.Error(),
+				hint: "the port is busy or gone: close the programs using it, on Linux ModemManager and brltty grab USB serial ports"})
+			return false
+		}
+		if !ok {
+			continue
+		}
+		if baud != lidarBaud {
+			d.add(checkResult{name: "baud rate", status: checkFail, detail: fmt.Sprintf("answered at %v baud", baud),
+				hint: fmt.Sprintf("this driver supports the G2 at %v baud, the device is another YDLidar model", lidarBaud)})
+			return false
+		}
+		d.add(checkResult{name: "baud rate", status: checkPass, detail: fmt.Sprintf("%v baud", baud)})
+		return true
+	}
+	d.add(checkResult{name: "baud rate", status: checkFail, detail: fmt.Sprintf("no answer at %v baud", probeBauds),
+		hint: "check the cable and the power: the G2 needs 5V at 500mA or more, plug the second USB power input of the adapter board"})
+	return false
+}
+
+// probeDeviceInfo sends the device info command at the baud rate and reports whether a
+// response header came back.
+func probeDeviceInfo(path string, baud int) (bool, error) {
+	port, err := serial.Open(path, &serial.Mode{BaudRate: baud, DataBits: 8, Parity: serial.NoParity, StopBits: serial.OneStopBit})
+	if err != nil {
+		return false, err
+	}
+	defer port.Close()
+	if err = port.SetReadTimeout(100 * time.Millisecond); err != nil {
+		return false, err
+	}
+	// Stop a scan left running and drop its bytes.
+	if _, err = port.Write([]byte{0xA5, 0x65}); err != nil {
+		return false, err
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err = port.ResetInputBuffer(); err != nil {
+		return false, err
+	}
+	if _, err = port.Write([]byte{0xA5, 0x90}); err != nil {
+		return false, err
+	}
+
+	var response []byte
+	buf := make([]byte, 64)
+	deadline := time.Now().Add(300 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		n, err := port.Read(buf)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return false, err
+		}
+		response = append(response, buf[:n]...)
+		for i := 0; i+1 < len(response); i++ {
+			if response[i] == 0xA5 && response[i+1] == 0x5A {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// checkDeviceInfo reads the device info.
+func (d *doctor) checkDeviceInfo(lidar *ydlidar.YDLidar) bool {
+	info, err := lidar.DeviceInfo()
+	if err != nil {
+		d.add(checkResult{name: "device info", status: checkFail, detail: err.Error(),
+			hint: "unplug the lidar, wait 5 seconds and plug it back"})
+		return false
+	}
+	d.add(checkResult{name: "device info", status: checkPass,
+		detail: fmt.Sprintf("%v, firmware %v.%v, hardware %v, serial %v", info.ModelName, info.FirmwareMajor, info.FirmwareMinor, info.Hardware, info.SerialNumber)})
+	return true
+}
+
+// checkHealth reads the health report.
+func (d *doctor) checkHealth(lidar *ydlidar.YDLidar) bool {
+	_, err := lidar.HealthInfo()
+	var healthErr *ydlidar.HealthError
+	switch {
+	case errors.As(err, &healthErr):
+		d.add(healthResult(healthErr))
+		return false
+	case err != nil:
+		d.add(checkResult{name: "health", status: checkFail, detail: err.Error(), hint: "unplug the lidar, wait 5 seconds and plug it back"})
+		return false
+	}
+	d.add(checkResult{name: "health", status: checkPass, detail: "OK"})
+	return true
+}
+
+// healthResult reports the error of the device.
+func healthResult(err *ydlidar.HealthError) checkResult {
+	return checkResult{name: "health", status: checkFail, detail: err.Error(),
+		hint: "power cycle the lidar, an error that persists needs the unit serviced"}
+}
+
+// checkScan scans for the duration and checks the rotation and the returns.
+func (d *doctor) checkScan(lidar *ydlidar.YDLidar) {
+	if err := lidar.StartScan(); err != nil {
+		d.add(checkResult{name: "scan", status: checkFail, detail: err.Error(),
+			hint: "the motor may not spin: check the power, the G2 needs 5V at 500mA or more"})
+		return
+	}
+	go func() {
+		for range lidar.Packets {
+		}
+	}()
+
+	var scans []ydlidar.Scan
+	timeout := time.After(d.duration)
+collect:
+	for {
+		select {
+		case scan := <-lidar.Scans:
+			scans = append(scans, scan)
+		case <-timeout:
+			break collect
+		}
+	}
+	if err := lidar.StopScan(); err != nil {
+		d.add(checkResult{name: "scan", status: checkFail, detail: err.Error()})
+		return
+	}
+	d.add(evaluateScans(scans, d.duration))
+}
+
+// evaluateScans checks the revolutions of the test scan.
+func evaluateScans(scans []ydlidar.Scan, duration time.Duration) checkResult {
+	if len(scans) == 0 {
+		return checkResult{name: "scan", status: checkFail, detail: fmt.Sprintf("no revolution in %v", duration),
+			hint: "the motor may not spin: check the power, the G2 needs 5V at 500mA or more"}
+	}
+	var frequency, points, dropout float64
+	var checksumFailures uint64
+	for _, scan := range scans {
+		frequency += scan.Frequency
+		points += float64(scan.Stats.Points)
+		dropout += scan.Stats.DropoutRatio
+		checksumFailures += scan.Stats.ChecksumFailures
+	}
+	n := float64(len(scans))
+	frequency, points, dropout = frequency/n, points/n, dropout/n
+	result := checkResult{name: "scan", status: checkPass,
+		detail: fmt.Sprintf("%v revolutions at %.1fHz, %.0f points, %.0f%% dropouts, %v checksum failures",
+			len(scans), frequency, points, dropout*100, checksumFailures)}
+	switch {
+	case checksumFailures > uint64(len(scans)):
+		result.status = checkWarn
+		result.hint = "frequent checksum failures: use a shorter or shielded USB cable, away from the motor wires"
+	case dropout > 0.5:
+		result.status = checkWarn
+		result.hint = "most samples have no return: clean the optical window and check nothing covers the lidar"
+	}
+	return result
+}
+
+// add appends a result to the report.
+func (d *doctor) add(result checkResult) {
+	d.results = append(d.results, result)
+}
+
+// skip reports the checks as skipped for the reason.
+func (d *doctor) skip(reason string, names ...string) {
+	for _, name := range names {
+		d.add(checkResult{name: name, status: checkSkip, detail: reason})
+	}
+}
+
+// failed reports whether a check failed.
+func (d *doctor) failed() bool {
+	for _, result := range d.results {
+		if result.status == checkFail {
+			return true
+		}
+	}
+	return false
+}
+
+// print writes the report, a line per check followed by the hint of the ones not passing.
+func (d *doctor) print(w io.Writer) {
+	for _, result := range d.results {
+		fmt.Fprintf(w, "%-4v  %-12v %v\n", result.status, result.name, result.detail)
+		if result.hint != "" {
+			fmt.Fprintf(w, "      %-12v hint: %v\n", "", result.hint)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package main
+
+// checkPermissions has nothing to check on this platform, opening the port tells.
+func (d *doctor) checkPermissions(path string) bool {
+	d.add(checkResult{name: "permissions", status: checkSkip, detail: "not checked on this platform"})
+	return true
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+func TestDoctorSimulated(t *testing.T) {
+	*simulate = true
+	defer func() { *simulate = false }()
+
+	d := &doctor{duration: 500 * time.Millisecond}
+	d.run()
+	require.Len(t, d.results, 6)
+	assert.False(t, d.failed())
+	for _, result := range d.results[:3] {
+		assert.Equal(t, checkSkip, result.status)
+	}
+	assert.Contains(t, d.results[3].detail, "G2")
+	assert.Equal(t, "scan", d.results[5].name)
+	assert.Equal(t, checkPass, d.results[5].status, d.results[5].detail)
+}
+
+func TestEvaluateScans(t *testing.T) {
+	result := evaluateScans(nil, 3*time.Second)
+	assert.Equal(t, checkFail, result.status)
+	assert.NotEmpty(t, result.hint)
+
+	scans := []ydlidar.Scan{
+		{Frequency: 10, Stats: ydlidar.ScanStats{Points: 500, DropoutRatio: 0.1}},
+		{Frequency: 10, Stats: ydlidar.ScanStats{Points: 500, DropoutRatio: 0.1, ChecksumFailures: 1}},
+	}
+	result = evaluateScans(scans, 3*time.Second)
+	assert.Equal(t, checkPass, result.status)
+	assert.Equal(t, "2 revolutions at 10.0Hz, 500 points, 10% dropouts, 1 checksum failures", result.detail)
+
+	scans[0].Stats.DropoutRatio, scans[1].Stats.DropoutRatio = 0.8, 0.9
+	assert.Equal(t, checkWarn, evaluateScans(scans, 3*time.Second).status)
+	scans[0].Stats.ChecksumFailures = 5
+	assert.Contains(t, evaluateScans(scans, 3*time.Second).hint, "cable")
+}
+
+func TestDoctorReport(t *testing.T) {
+	d := &doctor{}
+	d.add(checkResult{name: "port", status: checkPass, detail: "/dev/ttyUSB0"})
+	d.add(checkResult{name: "permissions", status: checkFail, detail: "permission denied", hint: "join dialout"})
+	d.skip("port not accessible", "baud rate")
+	assert.True(t, d.failed())
+
+	var out bytes.Buffer
+	d.print(&out)
+	assert.Equal(t, ""+
+		"PASS  port         /dev/ttyUSB0\n"+
+		"FAIL  permissions  permission denied\n"+
+		"                   hint: join dialout\n"+
+		"SKIP  baud rate    port not accessible\n", out.String())
+}
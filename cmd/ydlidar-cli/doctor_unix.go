@@ -0,0 +1,34 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"syscall"
+)
+
+// checkPermissions checks the user can read and write the port, which usually takes being
+// in the group owning it, dialout on most Linux distributions.
+func (d *doctor) checkPermissions(path string) bool {
+	const readWrite = 0x4 | 0x2 // R_OK | W_OK
+	err := syscall.Access(path, readWrite)
+	if err == nil {
+		d.add(checkResult{name: "permissions", status: checkPass, detail: "read and write"})
+		return true
+	}
+
+	result := checkResult{name: "permissions", status: checkFail, detail: fmt.Sprintf("%v: %v", path, err),
+		hint: "run as a user allowed to use the port"}
+	if info, statErr := os.Stat(path); statErr == nil {
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			if group, groupErr := user.LookupGroupId(fmt.Sprint(stat.Gid)); groupErr == nil {
+				result.detail = fmt.Sprintf("%v: %v, owned by group %v", path, err, group.Name)
+				result.hint = fmt.Sprintf("add yourself to the group with: sudo usermod -aG %v $USER, then log out and back in", group.Name)
+			}
+		}
+	}
+	d.add(result)
+	return false
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/eol"
+)
+
+// runEOL implements the eol command: it prints the JSON report and fails if the unit didn't pass.
+func runEOL(port *string, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: ydlidar-cli eol thresholds.yaml")
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	var thresholds eol.Thresholds
+	if err = yaml.Unmarshal(data, &thresholds); err != nil {
+		return fmt.Errorf("invalid thresholds %v: %v", args[0], err)
+	}
+
+	lidar, err := connect(port, ydlidar.WithScans(16))
+	if err != nil {
+		return err
+	}
+	defer lidar.Close()
+
+	report, err := eol.Run(lidar, thresholds)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(report); err != nil {
+		return err
+	}
+	if !report.Passed {
+		return fmt.Errorf("unit failed the end-of-line test")
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Command ydlidar-cli runs maintenance and test operations against a YDLidar.
+//
+//	ydlidar-cli [-port /dev/ttyUSB0] [-simulate] <command> [arguments]
+//
+// With -simulate the commands run against a simulated lidar in a furnished room, for demos
+// without hardware.
+//
+// Commands:
+//
+//	script file.yaml   run the sequence of operations described in the file
+//	eol thresholds.yaml run the end-of-line test and print the JSON report
+//	conformance         exercise every command and decoder and print the conformance matrix
+//	convert log out     convert a ydlog file to CSV, PCD or a PNG or SVG image, picked from the
+//	                    output extension, or to a rosbag2 bag directory for an output without extension
+//	serve [-http addr]  serve the live web view on /, the stream on /ws and the metrics on /metrics
+//	background out.json learn the static scene, clear of anything moving, for motion detection
+//	doctor [-duration 3s] check the port, its permissions, the baud rate, the device info, the
+//	                    health and a test scan, and print what to fix
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+	"ydlidarg2/ydlidar/sim"
+)
+
+// command is a ydlidar-cli sub command.
+type command struct {
+	usage string
+	run   func(port *string, args []string) error
+}
+
+var commands = map[string]command{
+	"script":      {usage: "script file.yaml", run: runScript},
+	"eol":         {usage: "eol thresholds.yaml", run: runEOL},
+	"conformance": {usage: "conformance", run: runConformance},
+	"convert":     {usage: "convert log.ydlog output.csv|output.pcd|output.png|output.svg|bagdir", run: runConvert},
+	"serve":       {usage: "serve [-http :8080]", run: runServe},
+	"background":  {usage: "background [-n 50] [-resolution 0.5] background.json", run: runBackground},
+	"doctor":      {usage: "doctor [-duration 3s]", run: runDoctor},
+}
+
+// simulate replaces the lidar with a simulated one, see connect.
+var simulate = flag.Bool("simulate", false, "run against a simulated lidar instead of the device")
+
+func main() {
+	port := flag.String("port", "", "serial port of the lidar, auto-detected if empty")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	var devicePort *string
+	if *port != "" {
+		devicePort = port
+	}
+
+	if err := cmd.run(devicePort, flag.Args()[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ydlidar-cli [-port device] [-simulate] <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %v\n", cmd.usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nflags:")
+	flag.PrintDefaults()
+}
+
+// connect connects to the lidar on the port, or to a simulated lidar with -simulate.
+func connect(port *string, opts ...ydlidar.Option) (*ydlidar.YDLidar, error) {
+	if !*simulate {
+		return ydlidar.InitAndConnectToDevice(port, opts...)
+	}
+	// A 6 x 4 m room with a cupboard along a wall and a pillar.
+	env := sim.Room(6000, 4000).Add(
+		sim.Box(geom.Point{X: -1500, Y: 1700}, 1200, 600),
+		sim.Box(geom.Point{X: 1500, Y: -800}, 300, 300),
+	)
+	device := sim.NewPort(&sim.Device{Environment: env, Pose: geom.Pose2D{X: -500, Y: 200}, Noise: 8})
+	device.Realtime = true
+	return ydlidar.ConnectTransport(device, opts...)
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"ydlidarg2/ydlidar"
+)
+
+// Script is a sequence of operations run against the device, eg. for a test station:
+//
+//	steps:
+//	  - action: set-frequency
+//	    hz: 10
+//	  - action: warm-up
+//	    duration: 5s
+//	  - action: record
+//	    duration: 30s
+//	    on_error: retry
+//	    retries: 2
+//	  - action: export
+//	    output: run.csv
+//	  - action: reboot
+//	    on_error: continue
+type Script struct {
+	Steps []Step `yaml:"steps"`
+}
+
+// Step is one operation of a script.
+type Step struct {
+	Action   string        `yaml:"action"`   // One of the stepActions keys.
+	Hz       float64       `yaml:"hz"`       // set-frequency: target scan frequency.
+	Duration time.Duration `yaml:"duration"` // warm-up, record, sleep: how long.
+	Output   string        `yaml:"output"`   // export: destination file.
+	OnError  string        `yaml:"on_error"` // abort (default), continue or retry.
+	Retries  int           `yaml:"retries"`  // retry: attempts after the first failure.
+}
+
+// Error policies of a step.
+const (
+	abortOnError    = "abort"
+	continueOnError = "continue"
+	retryOnError    = "retry"
+)
+
+// scriptRun is the state shared by the steps of a script.
+type scriptRun struct {
+	lidar    *ydlidar.YDLidar
+	recorded []ydlidar.PointCloudData
+}
+
+// stepActions maps the action names to their implementation.
+var stepActions = map[string]func(*scriptRun, Step) error{
+	"device-info":   (*scriptRun).deviceInfo,
+	"health":        (*scriptRun).health,
+	"set-frequency": (*scriptRun).setFrequency,
+	"warm-up":       (*scriptRun).warmUp,
+	"record":        (*scriptRun).record,
+	"export":        (*scriptRun).export,
+	"reboot":        (*scriptRun).reboot,
+	"sleep":         (*scriptRun).sleep,
+}
+
+// runScript implements the script command.
+func runScript(port *string, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: ydlidar-cli script file.yaml")
+	}
+	script, err := loadScript(args[0])
+	if err != nil {
+		return err
+	}
+
+	lidar, err := connect(port)
+	if err != nil {
+		return err
+	}
+	defer lidar.Close()
+
+	return script.Run(&scriptRun{lidar: lidar})
+}
+
+// loadScript reads and validates a script file.
+func loadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	script := &Script{}
+	if err = yaml.Unmarshal(data, script); err != nil {
+		return nil, fmt.Errorf("invalid script %v: %v", path, err)
+	}
+
+	for i, step := range script.Steps {
+		if _, ok := stepActions[step.Action]; !ok {
+			return nil, fmt.Errorf("step %v: unknown action %q", i+1, step.Action)
+		}
+		switch step.OnError {
+		case "", abortOnError, continueOnError, retryOnError:
+		default:
+			return nil, fmt.Errorf("step %v: unknown error policy %q", i+1, step.OnError)
+		}
+	}
+	return script, nil
+}
+
+// Run executes the steps in order, applying the error policy of each step.
+func (s *Script) Run(run *scriptRun) error {
+	for i, step := range s.Steps {
+		attempts := 1
+		if step.OnError == retryOnError {
+			attempts += step.Retries
+		}
+
+		var err error
+		for attempt := 1; attempt <= attempts; attempt++ {
+			log.Printf("Step %v/%v: %v (attempt %v/%v)", i+1, len(s.Steps), step.Action, attempt, attempts)
+			if err = stepActions[step.Action](run, step); err == nil {
+				break
+			}
+			log.Printf("Step %v failed: %v", i+1, err)
+		}
+
+		if err != nil && step.OnError != continueOnError {
+			return fmt.Errorf("step %v (%v) failed: %v", i+1, step.Action, err)
+		}
+	}
+	return nil
+}
+
+func (r *scriptRun) deviceInfo(Step) error {
+	info, err := r.lidar.DeviceInfo()
+	if err != nil {
+		return err
+	}
+	log.Print(info)
+	return nil
+}
+
+func (r *scriptRun) health(Step) error {
+	health, err := r.lidar.HealthInfo()
+	if err != nil {
+		return err
+	}
+	log.Print(health)
+	return nil
+}
+
+func (r *scriptRun) setFrequency(step Step) error {
+	hz, err := r.lidar.SetMotorSpeed(step.Hz)
+	if err != nil {
+		return err
+	}
+	log.Printf("Scan frequency set to %vHz", hz)
+	return nil
+}
+
+// warmUp scans for the duration of the step, discarding the data.
+func (r *scriptRun) warmUp(step Step) error {
+	return r.scan(step.Duration, nil)
+}
+
+// record scans for the duration of the step, keeping the points for export.
+func (r *scriptRun) record(step Step) error {
+	r.recorded = r.recorded[:0]
+	err := r.scan(step.Duration, func(packet ydlidar.Packet) {
+		r.recorded = append(r.recorded, ydlidar.GetPointCloud(packet)...)
+	})
+	log.Printf("Recorded %v points", len(r.recorded))
+	return err
+}
+
+// scan runs the scan for duration, handing the packets to keep if not nil.
+func (r *scriptRun) scan(duration time.Duration, keep func(ydlidar.Packet)) error {
+	if err := r.lidar.StartMotor(); err != nil {
+		return err
+	}
+	if err := r.lidar.StartScan(); err != nil {
+		return err
+	}
+
+	deadline := time.After(duration)
+	for {
+		select {
+		case packet := <-r.lidar.Packets:
+			if packet.Error != nil {
+				r.lidar.StopScan()
+				return packet.Error
+			}
+			if keep != nil {
+				keep(packet)
+			}
+		case <-deadline:
+			return r.lidar.StopScan()
+		}
+	}
+}
+
+// export writes the recorded points as CSV.
+func (r *scriptRun) export(step Step) error {
+	if step.Output == "" {
+		return fmt.Errorf("export: output is required")
+	}
+	file, err := os.Create(step.Output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if err = w.Write([]string{"angle", "distance", "intensity"}); err != nil {
+		return err
+	}
+	for _, point := range r.recorded {
+		err = w.Write([]string{
+			strconv.FormatFloat(point.Angle, 'f', 3, 64),
+			strconv.FormatFloat(point.Dist, 'f', 0, 64),
+			strconv.Itoa(point.Intensity),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err = w.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func (r *scriptRun) reboot(Step) error {
+	return r.lidar.Reboot()
+}
+
+func (r *scriptRun) sleep(step Step) error {
+	time.Sleep(step.Duration)
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeScript(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "script.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadScript(t *testing.T) {
+	script, err := loadScript(writeScript(t, `
+steps:
+  - action: warm-up
+    duration: 5s
+  - action: record
+    duration: 30s
+    on_error: retry
+    retries: 2
+  - action: export
+    output: run.csv
+`))
+	require.NoError(t, err)
+	require.Len(t, script.Steps, 3)
+	assert.Equal(t, 5*time.Second, script.Steps[0].Duration)
+	assert.Equal(t, retryOnError, script.Steps[1].OnError)
+	assert.Equal(t, 2, script.Steps[1].Retries)
+
+	_, err = loadScript(writeScript(t, "steps:\n  - action: dance\n"))
+	assert.Error(t, err)
+
+	_, err = loadScript(writeScript(t, "steps:\n  - action: reboot\n    on_error: ignore\n"))
+	assert.Error(t, err)
+}
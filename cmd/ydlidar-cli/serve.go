@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/metrics"
+	"ydlidarg2/ydlidar/web"
+)
+
+// runServe implements the serve command: it scans and serves the web view and the metrics.
+func runServe(port *string, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("http", ":8080", "address to serve the web view on")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	server := web.NewServer()
+	lidar, err := connect(port, ydlidar.WithScans(1), ydlidar.WithScanProcessor(server))
+	if err != nil {
+		return err
+	}
+	defer lidar.Close()
+
+	registry := metrics.NewRegistry()
+	registry.Register("lidar", lidar)
+	server.Handle("/metrics", registry)
+
+	if err = lidar.StartScan(); err != nil {
+		return err
+	}
+	defer lidar.StopScan()
+	// The server gets the revolutions as a processor, the channels only need draining.
+	go func() {
+		for {
+			select {
+			case <-lidar.Packets:
+			case <-lidar.Scans:
+			}
+		}
+	}()
+
+	// Shut down cleanly on an interrupt, the deferred calls stop the scan and close the lidar.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	httpServer := &http.Server{Addr: *addr, Handler: server}
+	go func() {
+		<-ctx.Done()
+		httpServer.Shutdown(context.Background())
+	}()
+
+	log.Printf("Serving the web view on %v", *addr)
+	if err = httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+// Command ydlidar-view shows a live top-down radar view of the lidar scan in the terminal,
+// for field debugging without a GUI.
+//
+//	ydlidar-view [-port device] [-range meters]
+//
+// Keys: space pauses, + and - zoom, q or Esc quits.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"ydlidarg2/ydlidar"
+)
+
+// palette colors the points from low to high intensity.
+var palette = []tcell.Color{tcell.ColorBlue, tcell.ColorTeal, tcell.ColorGreen, tcell.ColorYellow, tcell.ColorRed}
+
+func main() {
+	port := flag.String("port", "", "serial port of the lidar, auto-detected if empty")
+	rangeM := flag.Float64("range", 8, "initial range shown in meters")
+	maxIntensity := flag.Int("max-intensity", 1023, "intensity mapped to the hottest color")
+	flag.Parse()
+
+	var devicePort *string
+	if *port != "" {
+		devicePort = port
+	}
+	if err := run(devicePort, *rangeM*1000, *maxIntensity); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(port *string, rangeMM float64, maxIntensity int) error {
+	// The driver logs every packet, which would scribble over the screen.
+	log.SetOutput(io.Discard)
+
+	lidar, err := ydlidar.InitAndConnectToDevice(port, ydlidar.WithScans(2))
+	if err != nil {
+		return err
+	}
+	defer lidar.Close()
+	if err = lidar.StartScan(); err != nil {
+		return err
+	}
+	defer lidar.StopScan()
+	// Nobody reads the packets, the revolutions are enough.
+	go func() {
+		for range lidar.Packets {
+		}
+	}()
+
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return err
+	}
+	if err = screen.Init(); err != nil {
+		return err
+	}
+	defer screen.Fini()
+
+	events := make(chan tcell.Event)
+	go func() {
+		for {
+			events <- screen.PollEvent()
+		}
+	}()
+
+	var scan ydlidar.Scan
+	paused := false
+	redraw := time.NewTicker(100 * time.Millisecond)
+	defer redraw.Stop()
+	for {
+		select {
+		case s := <-lidar.Scans:
+			if !paused {
+				scan = s
+			}
+		case ev := <-events:
+			switch ev := ev.(type) {
+			case *tcell.EventKey:
+				switch {
+				case ev.Key() == tcell.KeyEscape || ev.Key() == tcell.KeyCtrlC || ev.Rune() == 'q':
+					return nil
+				case ev.Rune() == ' ':
+					paused = !paused
+				case ev.Rune() == '+' || ev.Rune() == '=':
+					rangeMM = math.Max(rangeMM/1.5, 250)
+				case ev.Rune() == '-':
+					rangeMM = math.Min(rangeMM*1.5, 64000)
+				}
+			case *tcell.EventResize:
+				screen.Sync()
+			}
+		case <-redraw.C:
+			draw(screen, scan, rangeMM, maxIntensity, paused)
+		}
+	}
+}
+
+// draw renders the scan and the status line.
+func draw(screen tcell.Screen, scan ydlidar.Scan, rangeMM float64, maxIntensity int, paused bool) {
+	width, height := screen.Size()
+	if height < 2 {
+		return
+	}
+	r := newRadar(width, height-1, rangeMM, ringSpacing(rangeMM))
+	r.render(scan)
+
+	screen.Clear()
+	ring := tcell.StyleDefault.Foreground(tcell.ColorGray)
+	for y := 0; y < r.height; y++ {
+		for x := 0; x < r.width; x++ {
+			c := r.at(x, y)
+			style := ring
+			if c.point {
+				style = tcell.StyleDefault.Foreground(intensityColor(c.intensity, maxIntensity))
+			}
+			screen.SetContent(x, y, c.Rune(), nil, style)
+		}
+	}
+
+	status := fmt.Sprintf(" rev #%v  %v points  range %.1fm  rings %.2fm  [space] pause [+/-] zoom [q] quit",
+		scan.Seq, len(scan.Points), rangeMM/1000, ringSpacing(rangeMM)/1000)
+	if paused {
+		status = " PAUSED" + status
+	}
+	for x, ch := range []rune(status) {
+		screen.SetContent(x, height-1, ch, nil, tcell.StyleDefault.Reverse(true))
+	}
+	screen.Show()
+}
+
+// ringSpacing returns a round distance giving about four rings within the range.
+func ringSpacing(rangeMM float64) float64 {
+	for _, spacing := range []float64{250, 500, 1000, 2000, 5000, 10000} {
+		if rangeMM/spacing <= 4 {
+			return spacing
+		}
+	}
+	return 20000
+}
+
+// intensityColor maps the intensity onto the palette.
+func intensityColor(intensity, maxIntensity int) tcell.Color {
+	i := intensity * len(palette) / (maxIntensity + 1)
+	if i >= len(palette) {
+		i = len(palette) - 1
+	}
+	return palette[i]
+}
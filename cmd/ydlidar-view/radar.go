@@ -0,0 +1,109 @@
+package main
+
+import (
+	"math"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Each terminal cell is a braille character of 2×4 dots, which are close to square on most
+// terminal fonts.
+const (
+	dotsX = 2
+	dotsY = 4
+
+	brailleBase = 0x2800
+)
+
+// brailleBits maps the dot at column x and row y of a cell to its bit in the braille pattern.
+var brailleBits = [dotsX][dotsY]rune{
+	{0x01, 0x02, 0x04, 0x40},
+	{0x08, 0x10, 0x20, 0x80},
+}
+
+// cell is one rendered terminal cell.
+type cell struct {
+	dots      rune // Braille bit pattern, 0 if empty.
+	ring      bool // Some of the dots are range rings.
+	point     bool // Some of the dots are scan points.
+	intensity int  // Highest intensity of the scan points in the cell.
+}
+
+// Rune returns the braille character of the cell, a space if empty.
+func (c cell) Rune() rune {
+	if c.dots == 0 {
+		return ' '
+	}
+	return brailleBase + c.dots
+}
+
+// radar renders a top-down view of a scan: the lidar is in the center, 0° points up and
+// angles grow counter clockwise.
+type radar struct {
+	width, height int     // Size in cells.
+	rangeMM       float64 // Distance shown from the center to the closest edge.
+	ringMM        float64 // Distance between range rings, 0 for none.
+	cells         []cell
+}
+
+// newRadar returns a radar of width × height cells.
+func newRadar(width, height int, rangeMM, ringMM float64) *radar {
+	return &radar{width: width, height: height, rangeMM: rangeMM, ringMM: ringMM, cells: make([]cell, width*height)}
+}
+
+// at returns the cell at column x and row y.
+func (r *radar) at(x, y int) cell {
+	return r.cells[y*r.width+x]
+}
+
+// render draws the range rings and the points of the scan.
+func (r *radar) render(scan ydlidar.Scan) {
+	for i := range r.cells {
+		r.cells[i] = cell{}
+	}
+	if r.ringMM > 0 {
+		for dist := r.ringMM; dist <= r.rangeMM*math.Sqrt2; dist += r.ringMM {
+			// One dot per degree is enough to close the rings at terminal resolution.
+			for angle := 0.0; angle < 360; angle++ {
+				if c := r.plot(geom.FromPolar(angle, dist)); c != nil {
+					c.ring = true
+				}
+			}
+		}
+	}
+	for _, point := range scan.Points {
+		if !scan.IsReturn(point.Dist) {
+			continue
+		}
+		if c := r.plot(geom.FromPolar(point.Angle, point.Dist)); c != nil {
+			c.point = true
+			if point.Intensity > c.intensity {
+				c.intensity = point.Intensity
+			}
+		}
+	}
+}
+
+// plot sets the dot at p, in millimeters in the lidar frame, and returns its cell.
+// Points off screen are ignored.
+func (r *radar) plot(p geom.Point) *cell {
+	w, h := r.width*dotsX, r.height*dotsY
+	scale := float64(min(w, h)) / 2 / r.rangeMM
+	// X forward is up, Y left is left.
+	x := int(math.Round(float64(w)/2 - p.Y*scale))
+	y := int(math.Round(float64(h)/2 - p.X*scale))
+	if x < 0 || y < 0 || x >= w || y >= h {
+		return nil
+	}
+	c := &r.cells[(y/dotsY)*r.width+x/dotsX]
+	c.dots |= brailleBits[x%dotsX][y%dotsY]
+	return c
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
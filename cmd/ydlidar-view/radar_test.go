@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"ydlidarg2/ydlidar"
+)
+
+func TestRadarRender(t *testing.T) {
+	// 10×5 cells is 20×20 dots, 1000mm from the center to the edge.
+	r := newRadar(10, 5, 1000, 0)
+	r.render(ydlidar.Scan{Points: []ydlidar.PointCloudData{
+		{Angle: 0, Dist: 900, Intensity: 50},  // Straight ahead, near the top edge.
+		{Angle: 90, Dist: 900, Intensity: 80}, // Left, near the left edge.
+		{Angle: 180, Dist: 5000},              // Off screen.
+		{Angle: 270},                          // Dropout.
+	}})
+
+	// Ahead: dot x 10, y 1, in cell 5, 0 as the left column, second row.
+	ahead := r.at(5, 0)
+	assert.True(t, ahead.point)
+	assert.Equal(t, 50, ahead.intensity)
+	assert.Equal(t, rune(brailleBase+0x02), ahead.Rune())
+
+	// Left: dot x 1, y 10, in cell 0, 2 as the right column, third row.
+	left := r.at(0, 2)
+	assert.Equal(t, 80, left.intensity)
+	assert.Equal(t, rune(brailleBase+0x20), left.Rune())
+
+	points := 0
+	for _, c := range r.cells {
+		if c.point {
+			points++
+		}
+	}
+	assert.Equal(t, 2, points)
+}
+
+func TestRadarRings(t *testing.T) {
+	r := newRadar(10, 5, 1000, 500)
+	r.render(ydlidar.Scan{})
+	assert.True(t, r.at(5, 1).ring, "inner ring crosses above the center")
+	assert.False(t, r.at(5, 2).point)
+	assert.Equal(t, ' ', r.at(0, 0).Rune(), "the rings don't reach the corners")
+}
+
+func TestRingSpacing(t *testing.T) {
+	assert.Equal(t, 2000.0, ringSpacing(8000))
+	assert.Equal(t, 250.0, ringSpacing(1000))
+}
@@ -1,23 +1,43 @@
-// Uses mock serial interface to simulate lidar.
+// Uses a simulated lidar to print the points of a room to stdout.
 package main
 
 import (
-	"fmt"
 	"log"
-	. "ydlidar/ydlidar"
+	"os"
+	"os/signal"
+	"syscall"
+
+	. "ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/sim"
 )
 
 func main() {
+	port := sim.NewPort(&sim.Device{Environment: sim.Room(5000, 3000), Noise: 5})
+	port.Realtime = true
 
-	// TODO read in from config file with option to remain nil
-	var devicePort *string
-
-	lidar, err := InitAndConnectToDevice(devicePort)
+	lidar, err := ConnectTransport(port)
 	if err != nil {
 		log.Panic(err)
 	}
-	lidar.StartScan()
+	defer lidar.Close()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
 
+	if err = lidar.StartScan(); err != nil {
+		log.Panic(err)
+	}
 
+	for {
+		var packet Packet
+		select {
+		case packet = <-lidar.Packets:
+		case <-interrupt:
+			log.Println("Interrupted, closing the lidar")
+			return
+		}
+		for _, v := range GetPointCloud(packet) {
+			log.Printf("Angle: %v Dist: %v Intensity: %v", v.Angle, v.Dist, v.Intensity)
+		}
 	}
 }
@@ -2,6 +2,9 @@ package main
 
 import (
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	. "ydlidarg2/ydlidar"
 )
 
@@ -11,17 +14,27 @@ func main() {
 	var devicePort *string
 
 	lidar, err := InitAndConnectToDevice(devicePort)
-	defer lidar.StopScan()
-
 	if err != nil {
 		log.Panic(err)
 	}
+	defer lidar.Close()
 
-	go lidar.StartScan()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+
+	if err = lidar.StartScan(); err != nil {
+		log.Panic(err)
+	}
 
 	// Loop to read data from channel
 	for {
-		packet := <-lidar.Packets
+		var packet Packet
+		select {
+		case packet = <-lidar.Packets:
+		case <-interrupt:
+			log.Println("Interrupted, closing the lidar")
+			return
+		}
 		for _, v := range GetPointCloud(packet) {
 			// print the packet
 			log.Printf("Angle: %v Dist: %v Intensity: %v", v.Angle, v.Dist, v.Intensity)
@@ -0,0 +1,127 @@
+// Package annotate attaches labeled regions to recorded scans for ground-truth labeling.
+// Labels are stored in a JSON sidecar file next to the recording.
+package annotate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// sidecarSuffix is appended to the recording path to name the labels file.
+const sidecarSuffix = ".labels.json"
+
+// Region is a labeled area of a scan, either an angle range or a polygon.
+type Region struct {
+	Tag string `json:"tag"`
+
+	// MinAngle and MaxAngle bound an angle range in degrees. A range with MinAngle > MaxAngle
+	// wraps through 0°. Ignored when Polygon is set.
+	MinAngle float64 `json:"minAngle,omitempty"`
+	MaxAngle float64 `json:"maxAngle,omitempty"`
+
+	// Polygon vertices as x, y in millimeters, x pointing at 0°.
+	Polygon [][2]float64 `json:"polygon,omitempty"`
+}
+
+// Contains reports whether the point lies inside the region.
+func (r Region) Contains(point ydlidar.PointCloudData) bool {
+	if len(r.Polygon) > 0 {
+		polygon := make([]geom.Point, len(r.Polygon))
+		for i, vertex := range r.Polygon {
+			polygon[i] = geom.Point{X: vertex[0], Y: vertex[1]}
+		}
+		return geom.PointInPolygon(geom.FromPolar(point.Angle, point.Dist), polygon)
+	}
+	return geom.InAngleRange(point.Angle, r.MinAngle, r.MaxAngle)
+}
+
+// Annotation holds the regions labeled on one scan of the recording.
+type Annotation struct {
+	Seq     uint64   `json:"seq"` // Scan.Seq of the labeled revolution.
+	Regions []Region `json:"regions"`
+}
+
+// Set is the collection of annotations of one recording.
+type Set struct {
+	Recording   string       `json:"recording"`
+	Annotations []Annotation `json:"annotations"`
+}
+
+// New returns an empty annotation set for the recording.
+func New(recording string) *Set {
+	return &Set{Recording: recording}
+}
+
+// Add labels a region of the scan with sequence number seq.
+func (s *Set) Add(seq uint64, region Region) {
+	for i := range s.Annotations {
+		if s.Annotations[i].Seq == seq {
+			s.Annotations[i].Regions = append(s.Annotations[i].Regions, region)
+			return
+		}
+	}
+	s.Annotations = append(s.Annotations, Annotation{Seq: seq, Regions: []Region{region}})
+	sort.Slice(s.Annotations, func(i, j int) bool { return s.Annotations[i].Seq < s.Annotations[j].Seq })
+}
+
+// Regions returns the regions labeled on the scan with sequence number seq.
+func (s *Set) Regions(seq uint64) []Region {
+	i := sort.Search(len(s.Annotations), func(i int) bool { return s.Annotations[i].Seq >= seq })
+	if i < len(s.Annotations) && s.Annotations[i].Seq == seq {
+		return s.Annotations[i].Regions
+	}
+	return nil
+}
+
+// Label returns the tag of every point of the scan, "" for unlabeled points.
+// When regions overlap the region added last wins.
+func (s *Set) Label(scan ydlidar.Scan) []string {
+	labels := make([]string, len(scan.Points))
+	for _, region := range s.Regions(scan.Seq) {
+		for i, point := range scan.Points {
+			if region.Contains(point) {
+				labels[i] = region.Tag
+			}
+		}
+	}
+	return labels
+}
+
+// SidecarPath returns the path of the labels file stored alongside the recording.
+func SidecarPath(recording string) string {
+	return recording + sidecarSuffix
+}
+
+// Save writes the set next to its recording.
+func (s *Set) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(SidecarPath(s.Recording), data, 0o644)
+}
+
+// Load reads the annotations stored alongside the recording.
+// A recording without labels returns an empty set.
+func Load(recording string) (*Set, error) {
+	data, err := os.ReadFile(SidecarPath(recording))
+	if os.IsNotExist(err) {
+		return New(recording), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Set{}
+	if err = json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid labels file %v: %v", SidecarPath(recording), err)
+	}
+	s.Recording = recording
+	sort.Slice(s.Annotations, func(i, j int) bool { return s.Annotations[i].Seq < s.Annotations[j].Seq })
+	return s, nil
+}
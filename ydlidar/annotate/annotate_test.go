@@ -0,0 +1,30 @@
+package annotate
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+func TestLabelAndRoundTrip(t *testing.T) {
+	recording := filepath.Join(t.TempDir(), "run1.log")
+	set := New(recording)
+	set.Add(3, Region{Tag: "door", MinAngle: 350, MaxAngle: 10})
+	set.Add(3, Region{Tag: "box", Polygon: [][2]float64{{900, -100}, {1100, -100}, {1100, 100}, {900, 100}}})
+
+	scan := ydlidar.Scan{Seq: 3, Points: []ydlidar.PointCloudData{
+		{Angle: 355, Dist: 3000},
+		{Angle: 0, Dist: 1000},
+		{Angle: 90, Dist: 1000},
+	}}
+	assert.Equal(t, []string{"door", "box", ""}, set.Label(scan))
+	assert.Equal(t, []string{"", "", ""}, set.Label(ydlidar.Scan{Seq: 4, Points: scan.Points}))
+
+	require.NoError(t, set.Save())
+	loaded, err := Load(recording)
+	require.NoError(t, err)
+	assert.Equal(t, set, loaded)
+}
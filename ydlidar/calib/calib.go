@@ -0,0 +1,114 @@
+// Package calib normalizes the raw intensity of the lidar into a reflectivity comparable
+// across distances and incidence angles.
+//
+// Raw intensity falls with distance and with the angle between the beam and the surface. A
+// Table holds the intensity returned by a reference target hit head on at a range of
+// distances; the reflectivity of a point is its intensity over the reference intensity at
+// its distance, corrected for its incidence angle with a Lambertian model, times the
+// reflectivity of the reference target. Tables are loaded from JSON or fitted from a
+// capture of a flat wall.
+package calib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"ydlidarg2/ydlidar"
+)
+
+// Sample is the intensity returned by the reference target at a distance.
+type Sample struct {
+	Distance  float64 `json:"distance"`  // Millimeters.
+	Intensity float64 `json:"intensity"` // Mean raw intensity.
+}
+
+// Table is an intensity calibration. It is a ydlidar.ScanProcessor setting Scan.Reflectivity.
+type Table struct {
+	Reference float64  `json:"reference"` // Reflectivity of the reference target, 1 if unset.
+	Samples   []Sample `json:"samples"`   // Reference intensity by increasing distance.
+
+	// MaxIncidence is the largest incidence angle in degrees corrected for, beyond it the
+	// correction is clamped. 0 disables the incidence correction.
+	MaxIncidence float64 `json:"maxIncidence,omitempty"`
+}
+
+// ErrEmptyTable is returned for a table without samples.
+var ErrEmptyTable = errors.New("calib: empty calibration table")
+
+// Load reads a table from a JSON file.
+func Load(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t := &Table{}
+	if err = json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("invalid calibration table %v: %v", path, err)
+	}
+	if len(t.Samples) == 0 {
+		return nil, ErrEmptyTable
+	}
+	sortSamples(t.Samples)
+	return t, nil
+}
+
+// sortSamples orders the samples by increasing distance.
+func sortSamples(samples []Sample) {
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Distance < samples[j].Distance })
+}
+
+// Save writes the table to a JSON file.
+func (t *Table) Save(path string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ProcessScan sets the reflectivity of every point, 0 for dropouts.
+func (t *Table) ProcessScan(scan *ydlidar.Scan) error {
+	if len(t.Samples) == 0 {
+		return ErrEmptyTable
+	}
+	reflectivity := make([]float64, len(scan.Points))
+	for i, point := range scan.Points {
+		if !scan.IsReturn(point.Dist) {
+			continue
+		}
+		r := float64(point.Intensity) / t.expected(point.Dist)
+		if t.MaxIncidence > 0 {
+			incidence := math.Min(Incidence(scan.Points, i), t.MaxIncidence)
+			r /= math.Cos(incidence * math.Pi / 180)
+		}
+		reflectivity[i] = float64(r * t.reference())
+	}
+	scan.Reflectivity = reflectivity
+	return nil
+}
+
+// expected returns the reference intensity at dist, interpolated between the samples and
+// held constant past the ends of the table.
+func (t *Table) expected(dist float64) float64 {
+	samples := t.Samples
+	i := sort.Search(len(samples), func(i int) bool { return samples[i].Distance >= dist })
+	switch {
+	case i == 0:
+		return samples[0].Intensity
+	case i == len(samples):
+		return samples[len(samples)-1].Intensity
+	}
+	a, b := samples[i-1], samples[i]
+	return a.Intensity + (b.Intensity-a.Intensity)*(dist-a.Distance)/(b.Distance-a.Distance)
+}
+
+func (t *Table) reference() float64 {
+	if t.Reference == 0 {
+		return 1
+	}
+	return t.Reference
+}
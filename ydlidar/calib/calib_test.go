@@ -0,0 +1,66 @@
+package calib
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+// wall returns the scan of a flat wall at x = dist, with an intensity falling with the
+// square of the distance and the cosine of the incidence angle.
+func wall(dist float64) ydlidar.Scan {
+	var scan ydlidar.Scan
+	for angle := -40.0; angle <= 40; angle++ {
+		rad := angle * math.Pi / 180
+		d := dist / math.Cos(rad)
+		intensity := 1e9 / (d * d) * math.Cos(rad)
+		scan.Points = append(scan.Points, ydlidar.PointCloudData{Angle: float64(angle), Dist: float64(d), Intensity: int(intensity)})
+	}
+	return scan
+}
+
+func TestIncidence(t *testing.T) {
+	scan := wall(1000)
+	assert.InDelta(t, 0, Incidence(scan.Points, 40), 0.01)
+	assert.InDelta(t, 30, Incidence(scan.Points, 70), 0.01)
+	assert.Equal(t, 0.0, Incidence(scan.Points, 0), "no neighbor on one side")
+}
+
+func TestFitAndNormalize(t *testing.T) {
+	var capture []ydlidar.Scan
+	for dist := 500.0; dist <= 4000; dist += 250 {
+		capture = append(capture, wall(dist))
+	}
+	table, err := Fit(capture, FitOptions{BinWidth: 100, MaxIncidence: 2, MinPoints: 3, Reference: 0.8})
+	require.NoError(t, err)
+	table.MaxIncidence = 60
+
+	path := filepath.Join(t.TempDir(), "calib.json")
+	require.NoError(t, table.Save(path))
+	table, err = Load(path)
+	require.NoError(t, err)
+
+	// The same wall at a distance between the samples reads as the reference reflectivity
+	// whatever the distance and incidence, except at the ends and around the dropout where
+	// the incidence can't be estimated.
+	scan := wall(1125)
+	scan.Points[10].Dist = 0
+	require.NoError(t, table.ProcessScan(&scan))
+	require.Len(t, scan.Reflectivity, len(scan.Points))
+	assert.Equal(t, float64(0), scan.Reflectivity[10])
+	for i, r := range scan.Reflectivity {
+		if i > 0 && i < len(scan.Points)-1 && (i < 9 || i > 11) {
+			assert.InDelta(t, 0.8, r, 0.05, "point %v", i)
+		}
+	}
+}
+
+func TestEmptyTable(t *testing.T) {
+	_, err := Fit(nil, FitOptions{BinWidth: 100})
+	assert.ErrorIs(t, err, ErrEmptyTable)
+	assert.ErrorIs(t, (&Table{}).ProcessScan(&ydlidar.Scan{}), ErrEmptyTable)
+}
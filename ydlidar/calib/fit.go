@@ -0,0 +1,76 @@
+package calib
+
+import (
+	"math"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Incidence returns the angle in degrees between the beam of point i and the normal of the
+// surface it hit, estimated from its neighbors. Points without two valid neighbors are
+// assumed to be hit head on.
+func Incidence(points []ydlidar.PointCloudData, i int) float64 {
+	if i == 0 || i == len(points)-1 || !ydlidar.IsReturn(points[i-1].Dist, 0) || !ydlidar.IsReturn(points[i+1].Dist, 0) {
+		return 0
+	}
+	prev := geom.FromPolar(float64(points[i-1].Angle), float64(points[i-1].Dist))
+	next := geom.FromPolar(float64(points[i+1].Angle), float64(points[i+1].Dist))
+	beam := geom.FromPolar(points[i].Angle, 1)
+	surface := next.Sub(prev)
+	length := math.Hypot(surface.X, surface.Y)
+	if length == 0 {
+		return 0
+	}
+	// The beam is along the normal when it is perpendicular to the surface.
+	cos := math.Abs(beam.X*surface.Y-beam.Y*surface.X) / length
+	return math.Acos(math.Min(cos, 1)) * 180 / math.Pi
+}
+
+// FitOptions controls how a table is fitted from a wall capture.
+type FitOptions struct {
+	BinWidth     float64 // Width of the distance bins in millimeters.
+	MaxIncidence float64 // Points hitting the wall further from head on, in degrees, are ignored.
+	MinPoints    int     // Bins with fewer points are left out of the table.
+	Reference    float64 // Reflectivity of the wall.
+}
+
+// Fit builds a table from scans of a flat wall of uniform reflectivity recorded at a range
+// of distances, eg. while walking the lidar towards the wall. Points close to head on are
+// binned by distance and the mean intensity of each bin becomes a sample.
+func Fit(scans []ydlidar.Scan, opts FitOptions) (*Table, error) {
+	type bin struct {
+		dist, intensity float64
+		n               int
+	}
+	bins := map[int]*bin{}
+	for _, scan := range scans {
+		for i, point := range scan.Points {
+			if !scan.IsReturn(point.Dist) || Incidence(scan.Points, i) > opts.MaxIncidence {
+				continue
+			}
+			key := int(point.Dist / opts.BinWidth)
+			b := bins[key]
+			if b == nil {
+				b = &bin{}
+				bins[key] = b
+			}
+			b.dist += point.Dist
+			b.intensity += float64(point.Intensity)
+			b.n++
+		}
+	}
+
+	t := &Table{Reference: opts.Reference}
+	for _, b := range bins {
+		if b.n < opts.MinPoints || b.intensity == 0 {
+			continue
+		}
+		t.Samples = append(t.Samples, Sample{Distance: b.dist / float64(b.n), Intensity: b.intensity / float64(b.n)})
+	}
+	if len(t.Samples) == 0 {
+		return nil, ErrEmptyTable
+	}
+	sortSamples(t.Samples)
+	return t, nil
+}
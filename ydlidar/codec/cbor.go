@@ -0,0 +1,162 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"ydlidarg2/ydlidar"
+)
+
+// CBOR encodes in CBOR, RFC 8949.
+var CBOR Codec = cborCodec{}
+
+// CBOR major types.
+const (
+	cborUint   = 0 << 5
+	cborNegInt = 1 << 5
+	cborArray  = 4 << 5
+	cborFalse  = 7<<5 | 20
+	cborTrue   = 7<<5 | 21
+)
+
+type cborCodec struct{}
+
+func (cborCodec) Name() string { return "cbor" }
+
+func (cborCodec) Encode(scan ydlidar.Scan) []byte {
+	return encodeScan(&cborWriter{}, scan)
+}
+
+func (cborCodec) Decode(data []byte) (ydlidar.Scan, error) {
+	return decodeScan(&cborReader{data: data})
+}
+
+type cborWriter struct {
+	buf []byte
+}
+
+// head appends the head of an item, the major type and its argument in the fewest bytes.
+func (w *cborWriter) head(major byte, v uint64) {
+	switch {
+	case v < 24:
+		w.buf = append(w.buf, major|byte(v))
+	case v <= 0xFF:
+		w.buf = append(w.buf, major|24, byte(v))
+	case v <= 0xFFFF:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, major|25), uint16(v))
+	case v <= 0xFFFFFFFF:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, major|26), uint32(v))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, major|27), v)
+	}
+}
+
+func (w *cborWriter) uint(v uint64) { w.head(cborUint, v) }
+
+func (w *cborWriter) int(v int64) {
+	if v < 0 {
+		w.head(cborNegInt, uint64(-1-v))
+		return
+	}
+	w.head(cborUint, uint64(v))
+}
+
+func (w *cborWriter) bool(b bool) {
+	if b {
+		w.buf = append(w.buf, cborTrue)
+	} else {
+		w.buf = append(w.buf, cborFalse)
+	}
+}
+
+func (w *cborWriter) array(n int) { w.head(cborArray, uint64(n)) }
+
+func (w *cborWriter) bytes() []byte { return w.buf }
+
+type cborReader struct {
+	data []byte
+}
+
+// head reads the head of an item, returning its major type and argument.
+func (r *cborReader) head() (byte, uint64, error) {
+	if len(r.data) == 0 {
+		return 0, 0, fmt.Errorf("%w: unexpected end", ErrMalformed)
+	}
+	major, info := r.data[0]&0xE0, r.data[0]&0x1F
+	if major == 7<<5 {
+		r.data = r.data[1:]
+		return major, uint64(info), nil
+	}
+	size := 0
+	switch {
+	case info < 24:
+		r.data = r.data[1:]
+		return major, uint64(info), nil
+	case info <= 27:
+		size = 1 << (info - 24)
+	default:
+		return 0, 0, fmt.Errorf("%w: unsupported CBOR item %#x", ErrMalformed, r.data[0])
+	}
+	if len(r.data) < 1+size {
+		return 0, 0, fmt.Errorf("%w: unexpected end", ErrMalformed)
+	}
+	var v uint64
+	for _, b := range r.data[1 : 1+size] {
+		v = v<<8 | uint64(b)
+	}
+	r.data = r.data[1+size:]
+	return major, v, nil
+}
+
+// expect reads the head of an item of the major type.
+func (r *cborReader) expect(major byte, what string) (uint64, error) {
+	m, v, err := r.head()
+	if err != nil {
+		return 0, err
+	}
+	if m != major {
+		return 0, fmt.Errorf("%w: expected %v, got major type %v", ErrMalformed, what, m>>5)
+	}
+	return v, nil
+}
+
+func (r *cborReader) uint() (uint64, error) { return r.expect(cborUint, "unsigned integer") }
+
+func (r *cborReader) int() (int64, error) {
+	m, v, err := r.head()
+	if err != nil {
+		return 0, err
+	}
+	switch m {
+	case cborUint:
+		return int64(v), nil
+	case cborNegInt:
+		return -1 - int64(v), nil
+	}
+	return 0, fmt.Errorf("%w: expected integer, got major type %v", ErrMalformed, m>>5)
+}
+
+func (r *cborReader) bool() (bool, error) {
+	v, err := r.expect(7<<5, "boolean")
+	if err != nil {
+		return false, err
+	}
+	if v != cborFalse&0x1F && v != cborTrue&0x1F {
+		return false, fmt.Errorf("%w: expected boolean, got simple value %v", ErrMalformed, v)
+	}
+	return v == cborTrue&0x1F, nil
+}
+
+func (r *cborReader) array() (int, error) {
+	n, err := r.expect(cborArray, "array")
+	if err != nil {
+		return 0, err
+	}
+	// Every element takes at least a byte, a larger count is corrupt.
+	if n > uint64(len(r.data)) {
+		return 0, fmt.Errorf("%w: array of %v elements in %v bytes", ErrMalformed, n, len(r.data))
+	}
+	return int(n), nil
+}
+
+func (r *cborReader) done() bool { return len(r.data) == 0 }
@@ -0,0 +1,96 @@
+package codec
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+func testScan() ydlidar.Scan {
+	scan := ydlidar.Scan{Seq: 300, Start: time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC), Partial: true}
+	for i := 0; i < 480; i++ {
+		// Angles going down across 0° give negative deltas.
+		angle := float64(359*100-i*75) / 100
+		if angle < 0 {
+			angle += 360
+		}
+		scan.Points = append(scan.Points, ydlidar.PointCloudData{Angle: angle, Dist: float64(i * 100), Intensity: i % 300})
+	}
+	return scan
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, codec := range []Codec{CBOR, MessagePack} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			scan := testScan()
+			decoded, err := codec.Decode(codec.Encode(scan))
+			require.NoError(t, err)
+			assert.Equal(t, scan, decoded)
+		})
+	}
+}
+
+func TestRoundTripMeters(t *testing.T) {
+	scan := ydlidar.Scan{Units: ydlidar.Meters, Points: []ydlidar.PointCloudData{{Angle: 12.345, Dist: 1.2345}}}
+	for _, codec := range []Codec{CBOR, MessagePack} {
+		decoded, err := codec.Decode(codec.Encode(scan))
+		require.NoError(t, err)
+		assert.Equal(t, ydlidar.Meters, decoded.Units)
+		assert.True(t, decoded.Start.IsZero())
+		// Rounded to 0.01° and 1mm.
+		assert.InDelta(t, 12.35, decoded.Points[0].Angle, 1e-9, codec.Name())
+		assert.InDelta(t, 1.235, decoded.Points[0].Dist, 1e-9, codec.Name())
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	scan := ydlidar.Scan{Seq: 1, Points: []ydlidar.PointCloudData{{Angle: 1, Dist: 1000, Intensity: 5}, {Angle: 0.5}}}
+	assert.Equal(t, []byte{
+		0x87, 0x01, 0x00, 0xF4, 0x00,
+		0x82, 0x18, 0x64, 0x38, 0x31, // 100, -50
+		0x82, 0x19, 0x03, 0xE8, 0x00, // 1000, 0
+		0x82, 0x05, 0x00,
+	}, CBOR.Encode(scan))
+	assert.Equal(t, []byte{
+		0x97, 0x01, 0x00, 0xC2, 0x00,
+		0x92, 0x64, 0xD0, 0xCE, // 100, -50
+		0x92, 0xCD, 0x03, 0xE8, 0x00, // 1000, 0
+		0x92, 0x05, 0x00,
+	}, MessagePack.Encode(scan))
+}
+
+func TestSmallerThanJSON(t *testing.T) {
+	scan := testScan()
+	points := make([][3]float32, len(scan.Points))
+	for i, point := range scan.Points {
+		points[i] = [3]float32{float32(point.Angle), float32(point.Dist), float32(point.Intensity)}
+	}
+	text, err := json.Marshal(points)
+	require.NoError(t, err)
+
+	for _, codec := range []Codec{CBOR, MessagePack} {
+		assert.Less(t, len(codec.Encode(scan))*2, len(text), codec.Name())
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	for _, codec := range []Codec{CBOR, MessagePack} {
+		data := codec.Encode(testScan())
+		for _, bad := range [][]byte{nil, {0x00}, data[:len(data)-1], append(data, 0)} {
+			_, err := codec.Decode(bad)
+			assert.ErrorIs(t, err, ErrMalformed, codec.Name())
+		}
+	}
+}
+
+func TestByName(t *testing.T) {
+	codec, ok := ByName("msgpack")
+	assert.True(t, ok)
+	assert.Equal(t, MessagePack, codec)
+	_, ok = ByName("json")
+	assert.False(t, ok)
+}
@@ -0,0 +1,201 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"ydlidarg2/ydlidar"
+)
+
+// MessagePack encodes in MessagePack.
+var MessagePack Codec = msgpackCodec{}
+
+// MessagePack format bytes.
+const (
+	msgpackFalse   = 0xC2
+	msgpackTrue    = 0xC3
+	msgpackUint8   = 0xCC
+	msgpackUint16  = 0xCD
+	msgpackUint32  = 0xCE
+	msgpackUint64  = 0xCF
+	msgpackInt8    = 0xD0
+	msgpackInt16   = 0xD1
+	msgpackInt32   = 0xD2
+	msgpackInt64   = 0xD3
+	msgpackArray16 = 0xDC
+	msgpackArray32 = 0xDD
+)
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return "msgpack" }
+
+func (msgpackCodec) Encode(scan ydlidar.Scan) []byte {
+	return encodeScan(&msgpackWriter{}, scan)
+}
+
+func (msgpackCodec) Decode(data []byte) (ydlidar.Scan, error) {
+	return decodeScan(&msgpackReader{data: data})
+}
+
+type msgpackWriter struct {
+	buf []byte
+}
+
+func (w *msgpackWriter) uint(v uint64) {
+	switch {
+	case v <= 0x7F:
+		w.buf = append(w.buf, byte(v))
+	case v <= 0xFF:
+		w.buf = append(w.buf, msgpackUint8, byte(v))
+	case v <= 0xFFFF:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, msgpackUint16), uint16(v))
+	case v <= 0xFFFFFFFF:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, msgpackUint32), uint32(v))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, msgpackUint64), v)
+	}
+}
+
+func (w *msgpackWriter) int(v int64) {
+	switch {
+	case v >= 0:
+		w.uint(uint64(v))
+	case v >= -32:
+		w.buf = append(w.buf, byte(v))
+	case v >= math.MinInt8:
+		w.buf = append(w.buf, msgpackInt8, byte(v))
+	case v >= math.MinInt16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, msgpackInt16), uint16(v))
+	case v >= math.MinInt32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, msgpackInt32), uint32(v))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, msgpackInt64), uint64(v))
+	}
+}
+
+func (w *msgpackWriter) bool(b bool) {
+	if b {
+		w.buf = append(w.buf, msgpackTrue)
+	} else {
+		w.buf = append(w.buf, msgpackFalse)
+	}
+}
+
+func (w *msgpackWriter) array(n int) {
+	switch {
+	case n <= 15:
+		w.buf = append(w.buf, 0x90|byte(n))
+	case n <= 0xFFFF:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, msgpackArray16), uint16(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, msgpackArray32), uint32(n))
+	}
+}
+
+func (w *msgpackWriter) bytes() []byte { return w.buf }
+
+type msgpackReader struct {
+	data []byte
+}
+
+// next returns the format byte and the size big endian bytes following it.
+func (r *msgpackReader) next(size int) (byte, uint64, error) {
+	if len(r.data) < 1+size {
+		return 0, 0, fmt.Errorf("%w: unexpected end", ErrMalformed)
+	}
+	format := r.data[0]
+	var v uint64
+	for _, b := range r.data[1 : 1+size] {
+		v = v<<8 | uint64(b)
+	}
+	r.data = r.data[1+size:]
+	return format, v, nil
+}
+
+// sizes is the size of the value following the integer and array format bytes.
+var sizes = map[byte]int{
+	msgpackUint8: 1, msgpackUint16: 2, msgpackUint32: 4, msgpackUint64: 8,
+	msgpackInt8: 1, msgpackInt16: 2, msgpackInt32: 4, msgpackInt64: 8,
+	msgpackArray16: 2, msgpackArray32: 4,
+}
+
+// peekSize returns the size of the value following the next format byte.
+func (r *msgpackReader) peekSize() int {
+	if len(r.data) == 0 {
+		return 0
+	}
+	return sizes[r.data[0]]
+}
+
+func (r *msgpackReader) int() (int64, error) {
+	format, v, err := r.next(r.peekSize())
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case format <= 0x7F:
+		return int64(format), nil
+	case format >= 0xE0:
+		return int64(int8(format)), nil
+	case format == msgpackUint8, format == msgpackUint16, format == msgpackUint32, format == msgpackUint64:
+		return int64(v), nil
+	case format == msgpackInt8:
+		return int64(int8(v)), nil
+	case format == msgpackInt16:
+		return int64(int16(v)), nil
+	case format == msgpackInt32:
+		return int64(int32(v)), nil
+	case format == msgpackInt64:
+		return int64(v), nil
+	}
+	return 0, fmt.Errorf("%w: expected integer, got format %#x", ErrMalformed, format)
+}
+
+func (r *msgpackReader) uint() (uint64, error) {
+	if len(r.data) > 0 && r.data[0] == msgpackUint64 {
+		_, v, err := r.next(8)
+		return v, err
+	}
+	v, err := r.int()
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 {
+		return 0, fmt.Errorf("%w: expected unsigned integer, got %v", ErrMalformed, v)
+	}
+	return uint64(v), nil
+}
+
+func (r *msgpackReader) bool() (bool, error) {
+	format, _, err := r.next(0)
+	if err != nil {
+		return false, err
+	}
+	if format != msgpackFalse && format != msgpackTrue {
+		return false, fmt.Errorf("%w: expected boolean, got format %#x", ErrMalformed, format)
+	}
+	return format == msgpackTrue, nil
+}
+
+func (r *msgpackReader) array() (int, error) {
+	format, v, err := r.next(r.peekSize())
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case format&0xF0 == 0x90:
+		v = uint64(format & 0x0F)
+	case format == msgpackArray16, format == msgpackArray32:
+	default:
+		return 0, fmt.Errorf("%w: expected array, got format %#x", ErrMalformed, format)
+	}
+	// Every element takes at least a byte, a larger count is corrupt.
+	if v > uint64(len(r.data)) {
+		return 0, fmt.Errorf("%w: array of %v elements in %v bytes", ErrMalformed, v, len(r.data))
+	}
+	return int(v), nil
+}
+
+func (r *msgpackReader) done() bool { return len(r.data) == 0 }
@@ -0,0 +1,106 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"ydlidarg2/ydlidar"
+)
+
+var (
+	// Distances encodes the angles and the distances only, as 16 bit integers: 4 bytes a
+	// point. The decoded intensities are 0.
+	Distances Codec = projectionCodec{ydlidar.DistanceOnly}
+
+	// Intensities encodes the angles and the intensities only, as 16 bit integers: 4 bytes a
+	// point. The decoded distances are 0, dropouts.
+	Intensities Codec = projectionCodec{ydlidar.IntensityOnly}
+)
+
+// projectionHeaderSize is the size of the header of the projection layout.
+const projectionHeaderSize = 22
+
+// projectionCodec encodes one measurement of the points in a fixed width little endian
+// layout, simpler to decode than CBOR on a microcontroller:
+//
+//	projection   uint8, ydlidar.DistanceOnly or ydlidar.IntensityOnly
+//	units        uint8, ydlidar.Unit of the decoded distances
+//	partial      uint8, 1 if the revolution was cut short
+//	seq          uint64, revolution number
+//	start        int64, start of the revolution in Unix nanoseconds, 0 if unknown
+//	points       uint16, number of points
+//	per point    uint16 angle in hundredths of a degree, uint16 distance in whole millimeters,
+//	             0 for a dropout and 65535 beyond, or intensity
+type projectionCodec struct {
+	projection ydlidar.Projection
+}
+
+func (c projectionCodec) Name() string {
+	if c.projection == ydlidar.IntensityOnly {
+		return "intensities"
+	}
+	return "distances"
+}
+
+func (c projectionCodec) Encode(scan ydlidar.Scan) []byte {
+	b := make([]byte, projectionHeaderSize, projectionHeaderSize+4*len(scan.Points))
+	b[0] = byte(c.projection)
+	b[1] = byte(scan.Units)
+	if scan.Partial {
+		b[2] = 1
+	}
+	binary.LittleEndian.PutUint64(b[3:], scan.Seq)
+	if !scan.Start.IsZero() {
+		binary.LittleEndian.PutUint64(b[11:], uint64(scan.Start.UnixNano()))
+	}
+	binary.LittleEndian.PutUint16(b[19:], uint16(len(scan.Points)))
+	// b[21] is reserved.
+
+	for _, point := range scan.Points {
+		b = binary.LittleEndian.AppendUint16(b, uint16(math.Round(point.Angle*100)))
+		var value float64
+		switch {
+		case c.projection == ydlidar.IntensityOnly:
+			value = float64(point.Intensity)
+		case scan.IsReturn(point.Dist):
+			value = math.Round(scan.Units.ToMillimeters(point.Dist))
+		}
+		b = binary.LittleEndian.AppendUint16(b, uint16(math.Min(value, math.MaxUint16)))
+	}
+	return b
+}
+
+func (c projectionCodec) Decode(data []byte) (ydlidar.Scan, error) {
+	var scan ydlidar.Scan
+	if len(data) < projectionHeaderSize {
+		return scan, fmt.Errorf("%w: %v bytes, shorter than the header", ErrMalformed, len(data))
+	}
+	if ydlidar.Projection(data[0]) != c.projection {
+		return scan, fmt.Errorf("%w: projection %v, expected %v", ErrMalformed, ydlidar.Projection(data[0]), c.projection)
+	}
+	scan.Units = ydlidar.Unit(data[1])
+	scan.Partial = data[2] == 1
+	scan.Seq = binary.LittleEndian.Uint64(data[3:])
+	if start := int64(binary.LittleEndian.Uint64(data[11:])); start != 0 {
+		scan.Start = time.Unix(0, start).UTC()
+	}
+	n := int(binary.LittleEndian.Uint16(data[19:]))
+	if len(data) != projectionHeaderSize+4*n {
+		return scan, fmt.Errorf("%w: %v bytes for %v points", ErrMalformed, len(data), n)
+	}
+
+	scan.Points = make([]ydlidar.PointCloudData, n)
+	for i := range scan.Points {
+		point := data[projectionHeaderSize+4*i:]
+		scan.Points[i].Angle = float64(binary.LittleEndian.Uint16(point)) / 100
+		value := binary.LittleEndian.Uint16(point[2:])
+		if c.projection == ydlidar.IntensityOnly {
+			scan.Points[i].Intensity = int(value)
+		} else {
+			scan.Points[i].Dist = scan.Units.FromMillimeters(float64(value))
+		}
+	}
+	return scan, nil
+}
@@ -0,0 +1,72 @@
+package codec
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+func TestProjectionRoundTrip(t *testing.T) {
+	scan := testScan()
+	distances, intensities := testScan(), testScan()
+	for i := range scan.Points {
+		distances.Points[i].Intensity = 0
+		intensities.Points[i].Dist = 0
+	}
+
+	decoded, err := Distances.Decode(Distances.Encode(scan))
+	require.NoError(t, err)
+	assert.Equal(t, distances, decoded)
+	decoded, err = Intensities.Decode(Intensities.Encode(scan))
+	require.NoError(t, err)
+	assert.Equal(t, intensities, decoded)
+}
+
+func TestProjectionEncoding(t *testing.T) {
+	scan := ydlidar.Scan{Seq: 1, Points: []ydlidar.PointCloudData{
+		{Angle: 1, Dist: 1000, Intensity: 5},
+		{Angle: 0.5, Dist: math.NaN()},
+		{Angle: 2, Dist: 70000},
+	}, Invalid: math.NaN()}
+	assert.Equal(t, []byte{
+		0x01, 0x00, 0x00,
+		0x01, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0,
+		0x03, 0x00, 0x00,
+		0x64, 0x00, 0xE8, 0x03, // 1°, 1000mm
+		0x32, 0x00, 0x00, 0x00, // 0.5°, dropout
+		0xC8, 0x00, 0xFF, 0xFF, // 2°, saturated
+	}, Distances.Encode(scan))
+}
+
+func TestProjectionSmallerThanJSON(t *testing.T) {
+	scan := testScan()
+	points := make([][3]float32, len(scan.Points))
+	for i, point := range scan.Points {
+		points[i] = [3]float32{float32(point.Angle), float32(point.Dist), float32(point.Intensity)}
+	}
+	text, err := json.Marshal(points)
+	require.NoError(t, err)
+
+	for _, codec := range []Codec{Distances, Intensities} {
+		assert.Less(t, len(codec.Encode(scan))*10, len(text)*4, codec.Name())
+	}
+}
+
+func TestProjectionDecodeMalformed(t *testing.T) {
+	data := Distances.Encode(testScan())
+	for _, bad := range [][]byte{nil, data[:projectionHeaderSize-1], data[:len(data)-1], append(data, 0)} {
+		_, err := Distances.Decode(bad)
+		assert.ErrorIs(t, err, ErrMalformed)
+	}
+	_, err := Intensities.Decode(data)
+	assert.ErrorIs(t, err, ErrMalformed, "decoding distances as intensities")
+
+	codec, ok := ByName("intensities")
+	assert.True(t, ok)
+	assert.Equal(t, Intensities, codec)
+}
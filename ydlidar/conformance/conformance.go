@@ -0,0 +1,197 @@
+// Package conformance exercises every command and decoder path implemented by the driver
+// against a live device or an emulator and reports a capability/conformance matrix.
+// Contributors adding support for a model run it to see what works out of the box:
+//
+//	YDLIDAR_PORT=/dev/ttyUSB0 go test ./ydlidar/conformance -v
+//
+// or with the CLI: ydlidar-cli -port /dev/ttyUSB0 conformance
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"ydlidarg2/ydlidar"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	Pass        Status = "PASS"
+	Fail        Status = "FAIL"
+	Unsupported Status = "UNSUPPORTED"
+	Skipped     Status = "SKIPPED"
+)
+
+// Result is one row of the matrix.
+type Result struct {
+	Check  string
+	Status Status
+	Detail string
+}
+
+// Matrix is the outcome of every check, in the order they ran.
+type Matrix struct {
+	Device  string
+	Results []Result
+}
+
+// Passed reports whether no check failed. Unsupported and skipped checks don't fail the matrix.
+func (m *Matrix) Passed() bool {
+	for _, r := range m.Results {
+		if r.Status == Fail {
+			return false
+		}
+	}
+	return true
+}
+
+// Print writes the matrix as an aligned table.
+func (m *Matrix) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Device:\t%v\n\n", m.Device)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tDETAIL")
+	for _, r := range m.Results {
+		fmt.Fprintf(tw, "%v\t%v\t%v\n", r.Check, r.Status, r.Detail)
+	}
+	return tw.Flush()
+}
+
+// check is one conformance check. It returns a detail string for the matrix.
+type check struct {
+	name string
+	run  func(*ydlidar.YDLidar) (string, error)
+	// needs names a check that must pass first.
+	needs string
+}
+
+var checks = []check{
+	{name: "device_info", run: deviceInfo},
+	{name: "health", run: health},
+	{name: "motor_start", run: func(l *ydlidar.YDLidar) (string, error) { return "DTR raised", l.StartMotor() }},
+	{name: "scan_frequency_get", run: getFrequency},
+	{name: "ranging_frequency_get", run: getRangingFrequency},
+	{name: "scan_start", run: func(l *ydlidar.YDLidar) (string, error) { return "scan response header valid", l.StartScan() }},
+	{name: "scan_packets", run: packets, needs: "scan_start"},
+	{name: "scan_revolutions", run: revolutions, needs: "scan_start"},
+	{name: "scan_stop", run: func(l *ydlidar.YDLidar) (string, error) { return "", l.StopScan() }, needs: "scan_start"},
+	{name: "scan_restart", run: restart},
+	{name: "motor_stop", run: func(l *ydlidar.YDLidar) (string, error) { return "DTR lowered", l.StopMotor() }},
+}
+
+// Run runs every check against the lidar, which must be created with ydlidar.WithScans.
+func Run(lidar *ydlidar.YDLidar) *Matrix {
+	m := &Matrix{Device: "unknown"}
+	status := map[string]Status{}
+
+	for _, c := range checks {
+		result := Result{Check: c.name}
+		switch {
+		case c.needs != "" && status[c.needs] != Pass:
+			result.Status, result.Detail = Skipped, c.needs+" did not pass"
+		default:
+			detail, err := c.run(lidar)
+			switch {
+			case errors.Is(err, ydlidar.ErrUnsupportedByModel), errors.Is(err, ydlidar.ErrUnsupportedByTransport):
+				result.Status, result.Detail = Unsupported, err.Error()
+			case err != nil:
+				result.Status, result.Detail = Fail, err.Error()
+			default:
+				result.Status, result.Detail = Pass, detail
+			}
+			if c.name == "device_info" && err == nil {
+				m.Device = detail
+			}
+		}
+		status[c.name] = result.Status
+		m.Results = append(m.Results, result)
+	}
+
+	// Leave the device idle whatever failed.
+	lidar.StopScan()
+	return m
+}
+
+func deviceInfo(l *ydlidar.YDLidar) (string, error) {
+	info, err := l.DeviceInfo()
+	if err != nil {
+		return "", err
+	}
+	return info.String(), nil
+}
+
+func health(l *ydlidar.YDLidar) (string, error) {
+	report, err := l.HealthInfo()
+	if err != nil {
+		return "", err
+	}
+	return report.String(), nil
+}
+
+func getFrequency(l *ydlidar.YDLidar) (string, error) {
+	hz, err := l.MotorSpeed()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%.2fHz", hz), nil
+}
+
+func getRangingFrequency(l *ydlidar.YDLidar) (string, error) {
+	khz, err := l.RangingFrequency()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%vkHz", khz), nil
+}
+
+// packets waits for decoded point cloud packets and validates their shape.
+func packets(l *ydlidar.YDLidar) (string, error) {
+	timeout := time.After(3 * time.Second)
+	samples := 0
+	for received := 0; received < 10; {
+		select {
+		case packet := <-l.Packets:
+			if packet.Error != nil {
+				return "", packet.Error
+			}
+			if len(packet.Angles) != len(packet.Distances) || len(packet.Intensities) != len(packet.Distances) {
+				return "", fmt.Errorf("misaligned packet: %v angles, %v distances, %v intensities", len(packet.Angles), len(packet.Distances), len(packet.Intensities))
+			}
+			samples += len(packet.Distances)
+			received++
+		case <-timeout:
+			return "", fmt.Errorf("only %v packets in 3s", received)
+		}
+	}
+	return fmt.Sprintf("10 packets, %v samples", samples), nil
+}
+
+// revolutions waits for assembled revolutions, which exercises the zero packet path.
+func revolutions(l *ydlidar.YDLidar) (string, error) {
+	if l.Scans == nil {
+		return "", fmt.Errorf("lidar not created WithScans")
+	}
+	timeout := time.After(3 * time.Second)
+	var points []int
+	for len(points) < 2 {
+		select {
+		case <-l.Packets:
+		case scan := <-l.Scans:
+			points = append(points, len(scan.Points))
+		case <-timeout:
+			return "", fmt.Errorf("only %v revolutions in 3s", len(points))
+		}
+	}
+	return fmt.Sprintf("revolutions of %v points", points), nil
+}
+
+func restart(l *ydlidar.YDLidar) (string, error) {
+	if err := l.Restart(); err != nil {
+		return "", err
+	}
+	return "stopped and started again", l.StopScan()
+}
@@ -0,0 +1,30 @@
+package conformance
+
+import (
+	"os"
+	"testing"
+
+	"ydlidarg2/ydlidar"
+)
+
+// TestDevice runs the matrix against the device at YDLIDAR_PORT, which can be an emulator's PTY.
+func TestDevice(t *testing.T) {
+	port := os.Getenv("YDLIDAR_PORT")
+	if port == "" {
+		t.Skip("set YDLIDAR_PORT to run the conformance checks against a device")
+	}
+
+	lidar, err := ydlidar.InitAndConnectToDevice(&port, ydlidar.WithScans(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lidar.Close()
+
+	m := Run(lidar)
+	m.Print(os.Stdout)
+	for _, r := range m.Results {
+		if r.Status == Fail {
+			t.Errorf("%v: %v", r.Check, r.Detail)
+		}
+	}
+}
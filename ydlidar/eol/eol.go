@@ -0,0 +1,234 @@
+// Package eol is a manufacturing end-of-line test: it scans for a while and validates the unit
+// against configurable thresholds, producing a machine-readable pass/fail report.
+//
+// The wall flatness check expects the unit mounted in a fixture facing a flat wall at a
+// known distance.
+package eol
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Thresholds configure the test. Zero values disable the matching check.
+type Thresholds struct {
+	Duration time.Duration `json:"duration" yaml:"duration"` // How long to scan, 10s if zero.
+
+	MinFrequency      float64 `json:"minFrequency" yaml:"min_frequency"`            // Hz.
+	MaxFrequency      float64 `json:"maxFrequency" yaml:"max_frequency"`            // Hz.
+	MaxFrequencyStdev float64 `json:"maxFrequencyStdev" yaml:"max_frequency_stdev"` // Hz, frequency stability.
+	MinPointsPerScan  float64 `json:"minPointsPerScan" yaml:"min_points_per_scan"`  // Mean valid points per revolution.
+	MaxChecksumRate   float64 `json:"maxChecksumRate" yaml:"max_checksum_rate"`     // Ratio of packets failing validation.
+
+	FixtureAngle     float64 `json:"fixtureAngle" yaml:"fixture_angle"`          // Direction of the wall normal in degrees.
+	FixtureWidth     float64 `json:"fixtureWidth" yaml:"fixture_width"`          // Half width in degrees of the wall window.
+	FixtureDistance  float64 `json:"fixtureDistance" yaml:"fixture_distance"`    // Distance of the wall in mm.
+	MaxDistanceError float64 `json:"maxDistanceError" yaml:"max_distance_error"` // mm.
+	MaxWallResidual  float64 `json:"maxWallResidual" yaml:"max_wall_residual"`   // RMS distance of the wall points to the fitted line in mm.
+}
+
+// Check is the result of one threshold.
+type Check struct {
+	Name     string  `json:"name"`
+	Value    float64 `json:"value"`
+	Limit    float64 `json:"limit"`
+	Passed   bool    `json:"passed"`
+	Comments string  `json:"comments,omitempty"`
+}
+
+// Report is the outcome of the test.
+type Report struct {
+	Device  string    `json:"device"`
+	Start   time.Time `json:"start"`
+	Scans   int       `json:"scans"`
+	Packets int       `json:"packets"`
+	Checks  []Check   `json:"checks"`
+	Passed  bool      `json:"passed"`
+}
+
+// Run scans for the configured duration and checks the data against the thresholds.
+// The lidar must be created with ydlidar.WithScans and not be scanning.
+func Run(lidar *ydlidar.YDLidar, thresholds Thresholds) (*Report, error) {
+	if lidar.Scans == nil {
+		return nil, fmt.Errorf("eol: the lidar must be created WithScans")
+	}
+	duration := thresholds.Duration
+	if duration == 0 {
+		duration = 10 * time.Second
+	}
+
+	report := &Report{Start: time.Now()}
+	if info, err := lidar.DeviceInfo(); err == nil {
+		report.Device = info.String()
+	}
+
+	failuresBefore := lidar.ChecksumFailures()
+	if err := lidar.StartScan(); err != nil {
+		return nil, err
+	}
+
+	var scans []ydlidar.Scan
+	deadline := time.After(duration)
+	for running := true; running; {
+		select {
+		case packet := <-lidar.Packets:
+			if packet.Error != nil {
+				lidar.StopScan()
+				return nil, packet.Error
+			}
+			report.Packets++
+		case scan := <-lidar.Scans:
+			scans = append(scans, scan)
+		case <-deadline:
+			running = false
+		}
+	}
+	if err := lidar.StopScan(); err != nil {
+		return nil, err
+	}
+
+	report.Scans = len(scans)
+	report.evaluate(scans, int(lidar.ChecksumFailures()-failuresBefore), thresholds)
+	return report, nil
+}
+
+// evaluate fills in the checks of the report.
+func (r *Report) evaluate(scans []ydlidar.Scan, checksumFailures int, t Thresholds) {
+	mean, stdev := frequency(scans)
+	if t.MinFrequency > 0 {
+		r.add(Check{Name: "min_frequency", Value: mean, Limit: t.MinFrequency, Passed: mean >= t.MinFrequency})
+	}
+	if t.MaxFrequency > 0 {
+		r.add(Check{Name: "max_frequency", Value: mean, Limit: t.MaxFrequency, Passed: mean > 0 && mean <= t.MaxFrequency})
+	}
+	if t.MaxFrequencyStdev > 0 {
+		r.add(Check{Name: "frequency_stability", Value: stdev, Limit: t.MaxFrequencyStdev, Passed: len(scans) > 2 && stdev <= t.MaxFrequencyStdev})
+	}
+
+	if t.MinPointsPerScan > 0 {
+		density := pointDensity(scans)
+		r.add(Check{Name: "point_density", Value: density, Limit: t.MinPointsPerScan, Passed: density >= t.MinPointsPerScan})
+	}
+
+	if t.MaxChecksumRate > 0 {
+		rate := 0.0
+		if total := r.Packets + checksumFailures; total > 0 {
+			rate = float64(checksumFailures) / float64(total)
+		}
+		r.add(Check{Name: "checksum_rate", Value: rate, Limit: t.MaxChecksumRate, Passed: r.Packets > 0 && rate <= t.MaxChecksumRate})
+	}
+
+	if t.FixtureDistance > 0 {
+		dist, residual, n := wallFit(scans, t.FixtureAngle, t.FixtureWidth)
+		comments := fmt.Sprintf("%v wall points", n)
+		if t.MaxDistanceError > 0 {
+			e := math.Abs(dist - t.FixtureDistance)
+			r.add(Check{Name: "wall_distance", Value: e, Limit: t.MaxDistanceError, Passed: n >= 3 && e <= t.MaxDistanceError, Comments: comments})
+		}
+		if t.MaxWallResidual > 0 {
+			r.add(Check{Name: "wall_flatness", Value: residual, Limit: t.MaxWallResidual, Passed: n >= 3 && residual <= t.MaxWallResidual, Comments: comments})
+		}
+	}
+
+	r.Passed = true
+	for _, check := range r.Checks {
+		r.Passed = r.Passed && check.Passed
+	}
+}
+
+func (r *Report) add(check Check) {
+	r.Checks = append(r.Checks, check)
+}
+
+// frequency returns the mean and standard deviation of the rotation frequency measured
+// from the start times of consecutive complete revolutions.
+func frequency(scans []ydlidar.Scan) (mean, stdev float64) {
+	var freqs []float64
+	for i := 1; i < len(scans); i++ {
+		if scans[i].Partial || scans[i].Seq != scans[i-1].Seq+1 {
+			continue
+		}
+		if period := scans[i].Start.Sub(scans[i-1].Start).Seconds(); period > 0 {
+			freqs = append(freqs, 1/period)
+		}
+	}
+	return meanStdev(freqs)
+}
+
+// pointDensity returns the mean number of valid points per complete revolution.
+func pointDensity(scans []ydlidar.Scan) float64 {
+	var counts []float64
+	for _, scan := range scans {
+		if scan.Partial {
+			continue
+		}
+		valid := 0
+		for _, point := range scan.Points {
+			if scan.IsReturn(point.Dist) {
+				valid++
+			}
+		}
+		counts = append(counts, float64(valid))
+	}
+	mean, _ := meanStdev(counts)
+	return mean
+}
+
+// wallFit fits a line through the points in the fixture window and returns its distance to
+// the origin, the RMS residual of the points and the number of points used.
+func wallFit(scans []ydlidar.Scan, angle, width float64) (dist, residual float64, n int) {
+	var xs, ys []float64
+	for _, scan := range scans {
+		for _, point := range scan.Points {
+			offset := geom.AngleDiff(point.Angle, angle)
+			if !scan.IsReturn(point.Dist) || math.Abs(offset) > width {
+				continue
+			}
+			p := geom.FromPolar(point.Angle, point.Dist)
+			xs = append(xs, p.X)
+			ys = append(ys, p.Y)
+		}
+	}
+	n = len(xs)
+	if n < 3 {
+		return 0, 0, n
+	}
+
+	// Total least squares: the line normal is the direction of least variance.
+	mx, _ := meanStdev(xs)
+	my, _ := meanStdev(ys)
+	var sxx, syy, sxy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		sxx += dx * dx
+		syy += dy * dy
+		sxy += dx * dy
+	}
+	theta := 0.5 * math.Atan2(2*sxy, sxx-syy)
+	nx, ny := -math.Sin(theta), math.Cos(theta)
+
+	var sum float64
+	for i := range xs {
+		d := (xs[i]-mx)*nx + (ys[i]-my)*ny
+		sum += d * d
+	}
+	return math.Abs(mx*nx + my*ny), math.Sqrt(sum / float64(n)), n
+}
+
+func meanStdev(values []float64) (mean, stdev float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		stdev += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(stdev / float64(len(values)))
+}
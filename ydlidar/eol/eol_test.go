@@ -0,0 +1,49 @@
+package eol
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"ydlidarg2/ydlidar"
+)
+
+// wallScan returns a revolution facing a flat wall at dist mm along 0°.
+func wallScan(seq uint64, start time.Time, dist float64) ydlidar.Scan {
+	scan := ydlidar.Scan{Seq: seq, Start: start}
+	for angle := -20.0; angle <= 20; angle++ {
+		r := dist / math.Cos(angle*math.Pi/180)
+		scan.Points = append(scan.Points, ydlidar.PointCloudData{Angle: float64(angle), Dist: float64(r), Intensity: 500})
+	}
+	return scan
+}
+
+func TestEvaluate(t *testing.T) {
+	start := time.Now()
+	var scans []ydlidar.Scan
+	for i := 0; i < 10; i++ {
+		scans = append(scans, wallScan(uint64(i+1), start.Add(time.Duration(i)*100*time.Millisecond), 1000))
+	}
+
+	report := &Report{Packets: 990}
+	report.evaluate(scans, 10, Thresholds{
+		MinFrequency:      9,
+		MaxFrequency:      11,
+		MaxFrequencyStdev: 0.5,
+		MinPointsPerScan:  40,
+		MaxChecksumRate:   0.02,
+		FixtureAngle:      0,
+		FixtureWidth:      15,
+		FixtureDistance:   1000,
+		MaxDistanceError:  5,
+		MaxWallResidual:   2,
+	})
+
+	assert.True(t, report.Passed, "%+v", report.Checks)
+	assert.Len(t, report.Checks, 7)
+
+	report = &Report{Packets: 900}
+	report.evaluate(scans, 100, Thresholds{MaxChecksumRate: 0.02})
+	assert.False(t, report.Passed)
+}
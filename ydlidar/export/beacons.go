@@ -0,0 +1,148 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"ydlidarg2/ydlidar"
+)
+
+// Beacon is a cluster of bright returns close in angle, eg. a retroreflective landmark.
+type Beacon struct {
+	Angle     float64 `json:"a"` // Mean bearing in degrees, in [0, 360).
+	Dist      float64 `json:"d"` // Mean distance in millimeters.
+	Width     float64 `json:"w"` // Angle in degrees between the first and last points.
+	Intensity int     `json:"i"` // Peak intensity.
+	Points    int     `json:"n"` // Number of points.
+}
+
+// beaconFrame is the line written per revolution.
+type beaconFrame struct {
+	Time    time.Time `json:"t"`
+	Seq     uint64    `json:"seq"`
+	Partial bool      `json:"partial,omitempty"`
+	Beacons []Beacon  `json:"b"`
+}
+
+// BeaconWriter writes the beacons of every revolution for landmark based localization, as
+// JSON Lines with one compact object per revolution, written even without beacons:
+//
+//	{"t":"2024-01-02T03:04:05Z","seq":12,"b":[{"a":91.25,"d":1523.5,"w":1.5,"i":840,"n":6}]}
+//
+// The beacons are the returns at or above MinIntensity, clustered by angle. It is a
+// ydlidar.ScanProcessor so it can record a running scan.
+type BeaconWriter struct {
+	MinIntensity int     // Intensity of a beacon return.
+	AngleGap     float64 // Angle in degrees between bright returns that starts a new beacon, 1 if 0.
+	MinPoints    int     // Minimum number of points of a beacon, smaller clusters are dropped.
+
+	mu  sync.Mutex
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewBeaconWriter returns a writer of the beacons at or above minIntensity.
+func NewBeaconWriter(w io.Writer, minIntensity int) *BeaconWriter {
+	buf := bufio.NewWriter(w)
+	return &BeaconWriter{MinIntensity: minIntensity, MinPoints: 1, buf: buf, enc: json.NewEncoder(buf)}
+}
+
+// ProcessScan writes the beacons of the scan, see WriteScan.
+func (w *BeaconWriter) ProcessScan(scan *ydlidar.Scan) error {
+	return w.WriteScan(*scan)
+}
+
+// WriteScan writes the beacons of the revolution.
+func (w *BeaconWriter) WriteScan(scan ydlidar.Scan) error {
+	frame := beaconFrame{Time: scan.Start, Seq: scan.Seq, Partial: scan.Partial, Beacons: w.Beacons(scan)}
+	if frame.Beacons == nil {
+		frame.Beacons = []Beacon{}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.enc.Encode(frame); err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
+
+// beaconPoint is a bright return.
+type beaconPoint struct {
+	angle, dist float64
+	intensity   int
+}
+
+// Beacons returns the beacons of the scan ordered by angle. A beacon across 0° is joined.
+func (w *BeaconWriter) Beacons(scan ydlidar.Scan) []Beacon {
+	gap := w.AngleGap
+	if gap <= 0 {
+		gap = 1
+	}
+	var points []beaconPoint
+	for _, point := range scan.Points {
+		if scan.IsReturn(point.Dist) && point.Intensity >= w.MinIntensity {
+			angle := math.Mod(point.Angle, 360)
+			if angle < 0 {
+				angle += 360
+			}
+			points = append(points, beaconPoint{angle, scan.Units.ToMillimeters(point.Dist), point.Intensity})
+		}
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].angle < points[j].angle })
+
+	var clusters [][]beaconPoint
+	start := 0
+	for i := 1; i <= len(points); i++ {
+		if i == len(points) || points[i].angle-points[i-1].angle > gap {
+			clusters = append(clusters, points[start:i])
+			start = i
+		}
+	}
+	if last := len(clusters) - 1; last > 0 && clusters[0][0].angle+360-clusters[last][len(clusters[last])-1].angle <= gap {
+		// The last cluster continues past 360° into the first one.
+		joined := make([]beaconPoint, 0, len(clusters[last])+len(clusters[0]))
+		for _, p := range clusters[last] {
+			p.angle -= 360
+			joined = append(joined, p)
+		}
+		clusters[0] = append(joined, clusters[0]...)
+		clusters = clusters[:last]
+	}
+
+	var beacons []Beacon
+	for _, cluster := range clusters {
+		if len(cluster) < w.MinPoints {
+			continue
+		}
+		b := Beacon{Points: len(cluster), Width: cluster[len(cluster)-1].angle - cluster[0].angle}
+		for _, p := range cluster {
+			b.Angle += p.angle
+			b.Dist += p.dist
+			if p.intensity > b.Intensity {
+				b.Intensity = p.intensity
+			}
+		}
+		n := float64(len(cluster))
+		b.Angle = math.Mod(b.Angle/n+360, 360)
+		b.Angle, b.Dist, b.Width = round(b.Angle, 100), round(b.Dist/n, 10), round(b.Width, 100)
+		if b.Angle >= 360 {
+			b.Angle -= 360
+		}
+		beacons = append(beacons, b)
+	}
+	sort.Slice(beacons, func(i, j int) bool { return beacons[i].Angle < beacons[j].Angle })
+	return beacons
+}
+
+// round rounds x to 1/scale, keeping the JSON short.
+func round(x, scale float64) float64 {
+	return math.Round(x*scale) / scale
+}
@@ -0,0 +1,61 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+func beaconScan() ydlidar.Scan {
+	return ydlidar.Scan{
+		Seq:   7,
+		Start: t0,
+		Units: ydlidar.Millimeters,
+		Points: []ydlidar.PointCloudData{
+			{Angle: 359.5, Dist: 2000, Intensity: 900}, // Joined with the beacon past 0°.
+			{Angle: 0.25, Dist: 2010, Intensity: 950},
+			{Angle: 45, Dist: 1000, Intensity: 100},
+			{Angle: 90, Dist: 1500, Intensity: 800},
+			{Angle: 90.5, Dist: 1510, Intensity: 820},
+			{Angle: 91, Dist: 0, Intensity: 900},     // Dropout.
+			{Angle: 180, Dist: 3000, Intensity: 700}, // Single point.
+		},
+	}
+}
+
+func TestBeacons(t *testing.T) {
+	w := NewBeaconWriter(nil, 500)
+	beacons := w.Beacons(beaconScan())
+	require.Len(t, beacons, 3)
+	assert.Equal(t, Beacon{Angle: 90.25, Dist: 1505, Width: 0.5, Intensity: 820, Points: 2}, beacons[0])
+	assert.Equal(t, Beacon{Angle: 180, Dist: 3000, Intensity: 700, Points: 1}, beacons[1])
+	assert.Equal(t, Beacon{Angle: 359.88, Dist: 2005, Width: 0.75, Intensity: 950, Points: 2}, beacons[2])
+
+	w.MinPoints = 2
+	assert.Len(t, w.Beacons(beaconScan()), 2)
+	w.AngleGap = 0.4
+	assert.Empty(t, w.Beacons(beaconScan()), "the points 0.5° apart are single point beacons")
+
+	w.MinIntensity = 1000
+	assert.Empty(t, w.Beacons(beaconScan()))
+}
+
+func TestBeaconWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewBeaconWriter(&buf, 750)
+	scan := beaconScan()
+	require.NoError(t, w.ProcessScan(&scan))
+	scan.Seq, scan.Partial, scan.Points = 8, true, nil
+	require.NoError(t, w.WriteScan(scan))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"t":"2024-01-02T03:04:05Z","seq":7,"b":[
+		{"a":90.25,"d":1505,"w":0.5,"i":820,"n":2},
+		{"a":359.88,"d":2005,"w":0.75,"i":950,"n":2}]}`, lines[0])
+	assert.JSONEq(t, `{"t":"2024-01-02T03:04:05Z","seq":8,"partial":true,"b":[]}`, lines[1])
+}
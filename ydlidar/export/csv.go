@@ -0,0 +1,64 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"ydlidarg2/ydlidar"
+)
+
+// CSVWriter writes points as CSV with a header row.
+type CSVWriter struct {
+	*frameWriter
+}
+
+// NewCSVWriter returns a writer streaming CSV to w. The header is written with the first frame.
+func NewCSVWriter(w io.Writer, opts ...Option) *CSVWriter {
+	return &CSVWriter{newFrameWriter(&csvEncoder{w: csv.NewWriter(w)}, opts)}
+}
+
+type csvEncoder struct {
+	w      *csv.Writer
+	header bool
+}
+
+// distancePrecision is the number of decimals of the distances, down to the millimeter.
+var distancePrecision = map[ydlidar.Unit]int{
+	ydlidar.Millimeters: 0,
+	ydlidar.Meters:      3,
+}
+
+func (e *csvEncoder) encode(r row) error {
+	if !e.header {
+		e.header = true
+		header := []string{"time", "frame", "angle"}
+		if r.projection.KeepsDistance() {
+			header = append(header, "distance")
+		}
+		if r.projection.KeepsIntensity() {
+			header = append(header, "intensity")
+		}
+		if err := e.w.Write(append(header, "flags")); err != nil {
+			return err
+		}
+	}
+	fields := []string{
+		r.Time.Format(time.RFC3339Nano),
+		strconv.FormatUint(r.Frame, 10),
+		strconv.FormatFloat(r.Angle, 'f', 3, 64),
+	}
+	if r.projection.KeepsDistance() {
+		fields = append(fields, strconv.FormatFloat(r.Distance, 'f', distancePrecision[r.units], 64))
+	}
+	if r.projection.KeepsIntensity() {
+		fields = append(fields, strconv.Itoa(r.Intensity))
+	}
+	return e.w.Write(append(fields, strconv.Itoa(int(r.Flags))))
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
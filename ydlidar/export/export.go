@@ -0,0 +1,181 @@
+// Package export streams lidar points to pandas-friendly files: CSV with a header row and
+// JSON Lines with one object per point. Both writers emit the same columns:
+//
+//	time       arrival time of the packet or start of the revolution, RFC 3339 with nanoseconds
+//	frame      packet or revolution number, see Framing
+//	angle      degrees
+//	distance   in the unit of the lidar, see ydlidar.WithUnits, for a dropout the invalid value
+//	           of ydlidar.WithInvalidValue, 0 by default and null in JSON for NaN and +Inf
+//	intensity  raw intensity
+//	flags      bit set of Flags
+//
+// WithProjection drops the distance or intensity column, for the consumers needing only one.
+//
+// WritePCD writes scans as a point cloud for PCL based tools. BeaconWriter writes only the
+// bright landmarks of every revolution for landmark based localization.
+package export
+
+import (
+	"time"
+
+	"ydlidarg2/ydlidar"
+)
+
+// Framing selects how the points passed to WritePacket are grouped into frames.
+type Framing int
+
+const (
+	// PerPacket makes every packet its own frame.
+	PerPacket Framing = iota
+
+	// PerRevolution groups the packets between two zero packets into a frame, numbered like
+	// Scan.Seq, with the time of the zero packet that started it.
+	PerRevolution
+)
+
+// Flags describe a point.
+type Flags int
+
+const (
+	FlagZero      Flags = 1 << iota // The point comes from a zero packet.
+	FlagDropout                     // No return, the distance is the invalid value.
+	FlagPartial                     // The revolution was cut short by the end of the scan.
+	FlagSaturated                   // Beyond the rated range of the model, see ydlidar.WithSaturation.
+)
+
+// Option configures a writer.
+type Option func(*frameWriter)
+
+// WithFraming sets the framing of WritePacket, PerPacket by default.
+func WithFraming(framing Framing) Option {
+	return func(w *frameWriter) {
+		w.framing = framing
+	}
+}
+
+// WithProjection keeps only the columns of the projection, all of them by default.
+func WithProjection(projection ydlidar.Projection) Option {
+	return func(w *frameWriter) {
+		w.projection = projection
+	}
+}
+
+// row is one exported point.
+type row struct {
+	Time      time.Time `json:"time"`
+	Frame     uint64    `json:"frame"`
+	Angle     float64   `json:"angle"`
+	Distance  float64   `json:"distance"` // In the unit of the packet or scan.
+	Intensity int       `json:"intensity"`
+	Flags     Flags     `json:"flags"`
+
+	units      ydlidar.Unit
+	projection ydlidar.Projection
+}
+
+// encoder writes rows in a file format.
+type encoder interface {
+	encode(row) error
+	flush() error
+}
+
+// frameWriter groups points into frames and hands them to the encoder.
+type frameWriter struct {
+	enc        encoder
+	framing    Framing
+	projection ydlidar.Projection
+	frame      uint64
+	start      time.Time // Time of the current revolution.
+	pending    []row     // Points of the current revolution, PerRevolution only.
+}
+
+func newFrameWriter(enc encoder, opts []Option) *frameWriter {
+	w := &frameWriter{enc: enc}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// WritePacket writes the points of a packet received at t.
+func (w *frameWriter) WritePacket(t time.Time, packet ydlidar.Packet) error {
+	zero := packet.PacketType == 1
+	if w.framing == PerPacket {
+		w.frame++
+		return w.writeFrame(packetRows(t, w.frame, packet, zero))
+	}
+
+	if zero {
+		// The previous revolution is complete.
+		if err := w.writeFrame(w.pending); err != nil {
+			return err
+		}
+		w.frame++
+		w.start, w.pending = t, w.pending[:0]
+	}
+	if w.frame == 0 {
+		// Packets before the first zero packet belong to no revolution.
+		return nil
+	}
+	w.pending = append(w.pending, packetRows(w.start, w.frame, packet, zero)...)
+	return nil
+}
+
+// WriteScan writes an assembled revolution as one frame numbered by its Seq.
+func (w *frameWriter) WriteScan(scan ydlidar.Scan) error {
+	rows := make([]row, len(scan.Points))
+	for i, point := range scan.Points {
+		rows[i] = row{Time: scan.Start, Frame: scan.Seq, Angle: point.Angle, Distance: point.Dist, Intensity: point.Intensity, units: scan.Units}
+		if !scan.IsReturn(point.Dist) {
+			rows[i].Flags |= FlagDropout
+		}
+		if scan.Partial {
+			rows[i].Flags |= FlagPartial
+		}
+		if point.Saturated {
+			rows[i].Flags |= FlagSaturated
+		}
+	}
+	return w.writeFrame(rows)
+}
+
+// Flush writes the unfinished revolution flagged partial and flushes the underlying writer.
+func (w *frameWriter) Flush() error {
+	for i := range w.pending {
+		w.pending[i].Flags |= FlagPartial
+	}
+	if err := w.writeFrame(w.pending); err != nil {
+		return err
+	}
+	w.pending = w.pending[:0]
+	return nil
+}
+
+func (w *frameWriter) writeFrame(rows []row) error {
+	for _, r := range rows {
+		r.projection = w.projection
+		if err := w.enc.encode(r); err != nil {
+			return err
+		}
+	}
+	return w.enc.flush()
+}
+
+// packetRows returns the rows of the points of a packet.
+func packetRows(t time.Time, frame uint64, packet ydlidar.Packet, zero bool) []row {
+	points := ydlidar.GetPointCloud(packet)
+	rows := make([]row, len(points))
+	for i, point := range points {
+		rows[i] = row{Time: t, Frame: frame, Angle: point.Angle, Distance: point.Dist, Intensity: point.Intensity, units: packet.Units}
+		if zero {
+			rows[i].Flags |= FlagZero
+		}
+		if !packet.IsReturn(point.Dist) {
+			rows[i].Flags |= FlagDropout
+		}
+		if point.Saturated {
+			rows[i].Flags |= FlagSaturated
+		}
+	}
+	return rows
+}
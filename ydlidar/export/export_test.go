@@ -0,0 +1,142 @@
+package export
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+var t0 = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func zeroPacket() ydlidar.Packet {
+	return ydlidar.Packet{PacketType: 1, Angles: []float64{0}, Distances: []float64{0}, Intensities: []int{0}}
+}
+
+func dataPacket(angle float64) ydlidar.Packet {
+	return ydlidar.Packet{Angles: []float64{angle, angle + 1}, Distances: []float64{1000, 0}, Intensities: []int{50, 0}}
+}
+
+func TestCSVPerPacket(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf)
+	require.NoError(t, w.WritePacket(t0, dataPacket(10)))
+	require.NoError(t, w.WritePacket(t0.Add(time.Millisecond), zeroPacket()))
+
+	assert.Equal(t, strings.Join([]string{
+		"time,frame,angle,distance,intensity,flags",
+		"2024-01-02T03:04:05Z,1,10.000,1000,50,0",
+		"2024-01-02T03:04:05Z,1,11.000,0,0,2",
+		"2024-01-02T03:04:05.001Z,2,0.000,0,0,3",
+	}, "\n")+"\n", buf.String())
+}
+
+func TestJSONLPerRevolution(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONLWriter(&buf, WithFraming(PerRevolution))
+	// The packet before the first zero packet is dropped.
+	require.NoError(t, w.WritePacket(t0, dataPacket(350)))
+	require.NoError(t, w.WritePacket(t0, zeroPacket()))
+	require.NoError(t, w.WritePacket(t0.Add(time.Millisecond), dataPacket(10)))
+	assert.Empty(t, buf.String(), "the revolution is written when complete")
+
+	require.NoError(t, w.WritePacket(t0.Add(100*time.Millisecond), zeroPacket()))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.JSONEq(t, `{"time":"2024-01-02T03:04:05Z","frame":1,"angle":0,"distance":0,"intensity":0,"flags":3}`, lines[0])
+	assert.JSONEq(t, `{"time":"2024-01-02T03:04:05Z","frame":1,"angle":10,"distance":1000,"intensity":50,"flags":0}`, lines[1])
+
+	require.NoError(t, w.Flush())
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.JSONEq(t, `{"time":"2024-01-02T03:04:05.1Z","frame":2,"angle":0,"distance":0,"intensity":0,"flags":7}`, lines[3])
+}
+
+func TestWriteScan(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf)
+	scan := ydlidar.Scan{Seq: 7, Start: t0, Partial: true, Points: []ydlidar.PointCloudData{{Angle: 1, Dist: 500, Intensity: 9}}}
+	require.NoError(t, w.WriteScan(scan))
+	assert.Contains(t, buf.String(), "2024-01-02T03:04:05Z,7,1.000,500,9,4\n")
+}
+
+func TestWritePCD(t *testing.T) {
+	var buf bytes.Buffer
+	scan := ydlidar.Scan{Points: []ydlidar.PointCloudData{{Angle: 90, Dist: 2000, Intensity: 9}, {Angle: 91}}}
+	require.NoError(t, WritePCD(&buf, []ydlidar.Scan{scan}))
+	assert.Contains(t, buf.String(), "WIDTH 1\nHEIGHT 1\n")
+	assert.True(t, strings.HasSuffix(buf.String(), "DATA ascii\n0.0000 2.0000 0 9\n"))
+}
+
+func TestExportMeters(t *testing.T) {
+	scan := ydlidar.Scan{Seq: 1, Start: t0, Units: ydlidar.Meters, Points: []ydlidar.PointCloudData{{Angle: 90, Dist: 2.5, Intensity: 9}}}
+
+	var buf bytes.Buffer
+	require.NoError(t, NewCSVWriter(&buf).WriteScan(scan))
+	assert.Contains(t, buf.String(), ",1,90.000,2.500,9,0\n")
+
+	buf.Reset()
+	require.NoError(t, WritePCD(&buf, []ydlidar.Scan{scan}))
+	assert.True(t, strings.HasSuffix(buf.String(), "DATA ascii\n0.0000 2.5000 0 9\n"))
+}
+
+func TestInvalidValues(t *testing.T) {
+	scan := ydlidar.Scan{Seq: 1, Start: t0, Invalid: math.NaN(), Points: []ydlidar.PointCloudData{
+		{Angle: 10, Dist: 1000, Intensity: 50},
+		{Angle: 11, Dist: math.NaN()},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, NewJSONLWriter(&buf).WriteScan(scan))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"time":"2024-01-02T03:04:05Z","frame":1,"angle":11,"distance":null,"intensity":0,"flags":2}`, lines[1])
+
+	// Max range substitution: the distance is written, the flag tells the dropout.
+	scan.Invalid = 12000
+	scan.Points[1].Dist = 12000
+	buf.Reset()
+	require.NoError(t, NewCSVWriter(&buf).WriteScan(scan))
+	assert.Contains(t, buf.String(), "2024-01-02T03:04:05Z,1,11.000,12000,0,2\n")
+
+	scan.Invalid = math.Inf(1)
+	scan.Points[1].Dist = math.Inf(1)
+	buf.Reset()
+	require.NoError(t, NewCSVWriter(&buf).WriteScan(scan))
+	assert.Contains(t, buf.String(), "2024-01-02T03:04:05Z,1,11.000,+Inf,0,2\n")
+
+	buf.Reset()
+	require.NoError(t, WritePCD(&buf, []ydlidar.Scan{scan}))
+	assert.Contains(t, buf.String(), "POINTS 1\n")
+}
+
+func TestProjection(t *testing.T) {
+	scan := ydlidar.Scan{Seq: 1, Start: t0, Invalid: math.NaN(), Points: []ydlidar.PointCloudData{
+		{Angle: 10, Dist: 1000, Intensity: 50},
+		{Angle: 11, Dist: math.NaN()},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, NewCSVWriter(&buf, WithProjection(ydlidar.IntensityOnly)).WriteScan(scan))
+	assert.Equal(t, strings.Join([]string{
+		"time,frame,angle,intensity,flags",
+		"2024-01-02T03:04:05Z,1,10.000,50,0",
+		"2024-01-02T03:04:05Z,1,11.000,0,2",
+	}, "\n")+"\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, NewJSONLWriter(&buf, WithProjection(ydlidar.DistanceOnly)).WriteScan(scan))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"time":"2024-01-02T03:04:05Z","frame":1,"angle":10,"distance":1000,"flags":0}`, lines[0])
+	assert.JSONEq(t, `{"time":"2024-01-02T03:04:05Z","frame":1,"angle":11,"distance":null,"flags":2}`, lines[1])
+
+	buf.Reset()
+	require.NoError(t, NewJSONLWriter(&buf, WithProjection(ydlidar.IntensityOnly)).WriteScan(scan))
+	assert.JSONEq(t, `{"time":"2024-01-02T03:04:05Z","frame":1,"angle":10,"intensity":50,"flags":0}`, strings.Split(buf.String(), "\n")[0])
+}
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+
+	"ydlidarg2/ydlidar"
+)
+
+// JSONLWriter writes points as JSON Lines, one object per point.
+type JSONLWriter struct {
+	*frameWriter
+}
+
+// NewJSONLWriter returns a writer streaming JSON Lines to w.
+func NewJSONLWriter(w io.Writer, opts ...Option) *JSONLWriter {
+	buf := bufio.NewWriter(w)
+	return &JSONLWriter{newFrameWriter(&jsonlEncoder{buf: buf, enc: json.NewEncoder(buf)}, opts)}
+}
+
+type jsonlEncoder struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+// distanceRow and intensityRow are the rows of the projections, without the other column.
+type (
+	distanceRow struct {
+		Time     time.Time `json:"time"`
+		Frame    uint64    `json:"frame"`
+		Angle    float64   `json:"angle"`
+		Distance *float64  `json:"distance"` // Null for NaN and infinity.
+		Flags    Flags     `json:"flags"`
+	}
+	intensityRow struct {
+		Time      time.Time `json:"time"`
+		Frame     uint64    `json:"frame"`
+		Angle     float64   `json:"angle"`
+		Intensity int       `json:"intensity"`
+		Flags     Flags     `json:"flags"`
+	}
+)
+
+func (e *jsonlEncoder) encode(r row) error {
+	switch r.projection {
+	case ydlidar.DistanceOnly:
+		projected := distanceRow{Time: r.Time, Frame: r.Frame, Angle: r.Angle, Flags: r.Flags}
+		if !math.IsNaN(r.Distance) && !math.IsInf(r.Distance, 0) {
+			projected.Distance = &r.Distance
+		}
+		return e.enc.Encode(projected)
+	case ydlidar.IntensityOnly:
+		return e.enc.Encode(intensityRow{Time: r.Time, Frame: r.Frame, Angle: r.Angle, Intensity: r.Intensity, Flags: r.Flags})
+	}
+
+	if math.IsNaN(r.Distance) || math.IsInf(r.Distance, 0) {
+		// JSON has no NaN nor infinity, the distance of the dropout is null.
+		type plain row
+		return e.enc.Encode(struct {
+			plain
+			Distance *float64 `json:"distance"`
+		}{plain: plain(r)})
+	}
+	return e.enc.Encode(r)
+}
+
+func (e *jsonlEncoder) flush() error {
+	return e.buf.Flush()
+}
@@ -0,0 +1,34 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// WritePCD writes the points of the scans as a single ASCII point cloud in the PCD v0.7 format,
+// with x, y and z in meters and the intensity. Dropouts are left out.
+func WritePCD(w io.Writer, scans []ydlidar.Scan) error {
+	var points []ydlidar.PointCloudData
+	for _, scan := range scans {
+		for _, point := range scan.Points {
+			if scan.IsReturn(point.Dist) {
+				point.Dist = scan.Units.ToMillimeters(point.Dist) / 1000
+				points = append(points, point)
+			}
+		}
+	}
+
+	buf := bufio.NewWriter(w)
+	fmt.Fprintf(buf, "# .PCD v0.7 - Point Cloud Data file format\n"+
+		"VERSION 0.7\nFIELDS x y z intensity\nSIZE 4 4 4 4\nTYPE F F F F\nCOUNT 1 1 1 1\n"+
+		"WIDTH %v\nHEIGHT 1\nVIEWPOINT 0 0 0 1 0 0 0\nPOINTS %v\nDATA ascii\n", len(points), len(points))
+	for _, point := range points {
+		p := geom.FromPolar(point.Angle, point.Dist)
+		fmt.Fprintf(buf, "%.4f %.4f 0 %v\n", p.X, p.Y, point.Intensity)
+	}
+	return buf.Flush()
+}
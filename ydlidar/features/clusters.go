@@ -0,0 +1,118 @@
+package features
+
+import (
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Cluster is a group of nearby scan points, eg. a leg or an object around the robot.
+type Cluster struct {
+	Centroid geom.Point // Mean of the points in millimeters, in the lidar frame.
+	Min, Max geom.Point // Corners of the axis aligned bounding box.
+	Points   int        // Number of points in the cluster.
+	Indices  []int      // Indices of the points in Scan.Points.
+}
+
+// Clusterer groups the points of a scan with DBSCAN: a point with at least MinPoints
+// neighbors within Eps, itself included, is a core point, clusters are the sets of points
+// reachable through core points, and the remaining points are noise.
+type Clusterer struct {
+	Eps       float64 // Neighborhood radius in millimeters.
+	MinPoints int     // Minimum neighborhood size of a core point.
+}
+
+// Clusters returns the clusters found in the scan ordered by their first point. Dropouts and
+// noise points don't belong to any cluster.
+func (c *Clusterer) Clusters(scan ydlidar.Scan) []Cluster {
+	var points []geom.Point
+	var indices []int
+	for i, point := range scan.Points {
+		if !scan.IsReturn(point.Dist) {
+			continue
+		}
+		points = append(points, geom.FromPolar(point.Angle, point.Dist))
+		indices = append(indices, i)
+	}
+
+	const (
+		unvisited = 0
+		noise     = -1
+	)
+	// labels holds the cluster number of each point, starting at 1.
+	labels := make([]int, len(points))
+	clusters := 0
+	for i := range points {
+		if labels[i] != unvisited {
+			continue
+		}
+		neighbors := c.neighbors(points, i)
+		if len(neighbors) < c.MinPoints {
+			labels[i] = noise
+			continue
+		}
+
+		clusters++
+		labels[i] = clusters
+		for queue := neighbors; len(queue) > 0; queue = queue[1:] {
+			j := queue[0]
+			if labels[j] == noise {
+				// Border point, reachable but not core.
+				labels[j] = clusters
+			}
+			if labels[j] != unvisited {
+				continue
+			}
+			labels[j] = clusters
+			if more := c.neighbors(points, j); len(more) >= c.MinPoints {
+				queue = append(queue, more...)
+			}
+		}
+	}
+
+	result := make([]Cluster, clusters)
+	for i, label := range labels {
+		if label == noise {
+			continue
+		}
+		cluster, p := &result[label-1], points[i]
+		if cluster.Points == 0 {
+			cluster.Min, cluster.Max = p, p
+		}
+		cluster.Points++
+		cluster.Indices = append(cluster.Indices, indices[i])
+		cluster.Centroid.X += p.X
+		cluster.Centroid.Y += p.Y
+		cluster.Min = geom.Point{X: min(cluster.Min.X, p.X), Y: min(cluster.Min.Y, p.Y)}
+		cluster.Max = geom.Point{X: max(cluster.Max.X, p.X), Y: max(cluster.Max.Y, p.Y)}
+	}
+	for i := range result {
+		result[i].Centroid.X /= float64(result[i].Points)
+		result[i].Centroid.Y /= float64(result[i].Points)
+	}
+	return result
+}
+
+// neighbors returns the indices of the points within Eps of points[i], i included.
+func (c *Clusterer) neighbors(points []geom.Point, i int) []int {
+	var neighbors []int
+	for j, p := range points {
+		if p.Dist(points[i]) <= c.Eps {
+			neighbors = append(neighbors, j)
+		}
+	}
+	return neighbors
+}
+
+func min(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
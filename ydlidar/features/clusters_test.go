@@ -0,0 +1,34 @@
+package features
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+func TestClusters(t *testing.T) {
+	var scan ydlidar.Scan
+	// Two legs at 1 m, 0° and 20°, a lone point at 90° and a dropout.
+	for _, angle := range []float64{-1, 0, 1, 19, 20, 21} {
+		scan.Points = append(scan.Points, ydlidar.PointCloudData{Angle: angle, Dist: 1000})
+	}
+	scan.Points = append(scan.Points,
+		ydlidar.PointCloudData{Angle: 90, Dist: 1000},
+		ydlidar.PointCloudData{Angle: 91},
+	)
+
+	c := &Clusterer{Eps: 30, MinPoints: 3}
+	clusters := c.Clusters(scan)
+	require.Len(t, clusters, 2)
+
+	assert.Equal(t, 3, clusters[0].Points)
+	assert.Equal(t, []int{0, 1, 2}, clusters[0].Indices)
+	assert.InDelta(t, 999.9, clusters[0].Centroid.X, 0.1)
+	assert.InDelta(t, 0, clusters[0].Centroid.Y, 0.1)
+	assert.InDelta(t, -17.45, clusters[0].Min.Y, 0.01)
+	assert.InDelta(t, 17.45, clusters[0].Max.Y, 0.01)
+
+	assert.Equal(t, []int{3, 4, 5}, clusters[1].Indices)
+}
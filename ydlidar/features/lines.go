@@ -0,0 +1,121 @@
+// Package features extracts geometric features from assembled scans: wall line segments
+// for corridor following and docking, clusters of nearby points for tracking objects, and
+// retroreflectors marking a charging dock.
+package features
+
+import (
+	"math"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Line is a wall segment fitted to consecutive scan points.
+type Line struct {
+	geom.Segment         // Endpoints in millimeters, in the lidar frame.
+	Points       int     // Number of points supporting the line.
+	Error        float64 // RMS distance of the supporting points to the line in millimeters.
+}
+
+// LineExtractor finds line segments in a scan using split-and-merge: points are cut into
+// runs at range gaps, each run is split recursively at the point furthest from the line
+// through its ends (iterative end point fit), then neighboring collinear segments are merged.
+type LineExtractor struct {
+	Threshold float64 // Maximum distance in millimeters of a point to its line before the line is split.
+	Gap       float64 // Distance in millimeters between consecutive points that starts a new run.
+	MinPoints int     // Minimum number of points of a line, shorter ones are dropped.
+	MinLength float64 // Minimum length of a line in millimeters, shorter ones are dropped.
+}
+
+// Lines returns the segments found in the scan, in scan order. Dropouts are ignored.
+func (e *LineExtractor) Lines(scan ydlidar.Scan) []Line {
+	var lines []Line
+	for _, run := range e.runs(scan) {
+		lines = append(lines, e.merge(e.split(run, nil))...)
+	}
+
+	kept := lines[:0]
+	for _, line := range lines {
+		if line.Points >= e.MinPoints && line.Len() >= e.MinLength {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
+
+// runs converts the returns of the scan to cartesian and cuts them at range gaps.
+func (e *LineExtractor) runs(scan ydlidar.Scan) [][]geom.Point {
+	var runs [][]geom.Point
+	var run []geom.Point
+	for _, point := range scan.Points {
+		if !scan.IsReturn(point.Dist) {
+			continue
+		}
+		p := geom.FromPolar(point.Angle, point.Dist)
+		if len(run) > 0 && e.Gap > 0 && p.Dist(run[len(run)-1]) > e.Gap {
+			runs = append(runs, run)
+			run = nil
+		}
+		run = append(run, p)
+	}
+	if len(run) > 0 {
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// split appends the lines fitted to the points to lines.
+func (e *LineExtractor) split(points []geom.Point, lines []Line) []Line {
+	if len(points) < 2 {
+		return lines
+	}
+	seg := geom.Segment{A: points[0], B: points[len(points)-1]}
+	furthest, max := 0, 0.0
+	for i, p := range points[1 : len(points)-1] {
+		if d := seg.LineDistance(p); d > max {
+			furthest, max = i+1, d
+		}
+	}
+	if max <= e.Threshold {
+		return append(lines, fit(points))
+	}
+	// The furthest point ends the first half and starts the second.
+	lines = e.split(points[:furthest+1], lines)
+	return e.split(points[furthest:], lines)
+}
+
+// merge joins consecutive lines sharing an endpoint whose union still fits within the threshold.
+func (e *LineExtractor) merge(lines []Line) []Line {
+	if len(lines) < 2 {
+		return lines
+	}
+	merged := []Line{lines[0]}
+	for _, line := range lines[1:] {
+		last := &merged[len(merged)-1]
+		if last.B != line.A {
+			merged = append(merged, line)
+			continue
+		}
+		seg := geom.Segment{A: last.A, B: line.B}
+		if seg.LineDistance(last.B) > e.Threshold {
+			merged = append(merged, line)
+			continue
+		}
+		// Recompute the error over both sets of points, the shared endpoint counted once.
+		n := last.Points + line.Points - 1
+		sq := last.Error*last.Error*float64(last.Points) + line.Error*line.Error*float64(line.Points)
+		*last = Line{Segment: seg, Points: n, Error: math.Sqrt(sq / float64(n))}
+	}
+	return merged
+}
+
+// fit returns the line through the ends of the points with the RMS distance of all points to it.
+func fit(points []geom.Point) Line {
+	seg := geom.Segment{A: points[0], B: points[len(points)-1]}
+	sq := 0.0
+	for _, p := range points {
+		d := seg.LineDistance(p)
+		sq += d * d
+	}
+	return Line{Segment: seg, Points: len(points), Error: math.Sqrt(sq / float64(len(points)))}
+}
@@ -0,0 +1,67 @@
+package features
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// wallScan returns the scan of a corner: a wall at x = 1000 from y = -1000 to 1000 and a
+// wall at y = 1000 from x = 1000 to -1000, sampled every degree, with a dropout.
+func wallScan() ydlidar.Scan {
+	var scan ydlidar.Scan
+	for angle := -45.0; angle <= 135; angle++ {
+		rad := angle * math.Pi / 180
+		dist := 1000 / math.Cos(rad)
+		if angle > 45 {
+			dist = 1000 / math.Sin(rad)
+		}
+		scan.Points = append(scan.Points, ydlidar.PointCloudData{Angle: float64(angle), Dist: float64(dist)})
+	}
+	scan.Points[20].Dist = 0
+	return scan
+}
+
+func TestLines(t *testing.T) {
+	e := &LineExtractor{Threshold: 10, Gap: 200, MinPoints: 5}
+	lines := e.Lines(wallScan())
+	require.Len(t, lines, 2)
+
+	assert.InDelta(t, 1000, lines[0].A.X, 1)
+	assert.InDelta(t, -1000, lines[0].A.Y, 1)
+	assert.InDelta(t, 1000, lines[0].B.X, 1)
+	assert.InDelta(t, 1000, lines[0].B.Y, 1)
+	assert.Equal(t, 90, lines[0].Points)
+	assert.Less(t, lines[0].Error, 0.1)
+
+	assert.InDelta(t, -1000, lines[1].B.X, 1)
+	assert.InDelta(t, 1000, lines[1].B.Y, 1)
+}
+
+func TestLinesGapAndMinLength(t *testing.T) {
+	scan := wallScan()
+	// Move part of the first wall back so the run is cut at the gap.
+	for i := 60; i < 70; i++ {
+		scan.Points[i].Dist += 500
+	}
+	e := &LineExtractor{Threshold: 10, Gap: 200, MinLength: 500}
+	for _, line := range e.Lines(scan) {
+		assert.GreaterOrEqual(t, line.Len(), 500.0)
+	}
+}
+
+func TestMergeCollinear(t *testing.T) {
+	e := &LineExtractor{Threshold: 1}
+	a, b, c := geom.Point{X: 0, Y: 0}, geom.Point{X: 1, Y: 0}, geom.Point{X: 2, Y: 0.1}
+	lines := e.merge([]Line{
+		{Segment: geom.Segment{A: a, B: b}, Points: 3},
+		{Segment: geom.Segment{A: b, B: c}, Points: 3},
+	})
+	require.Len(t, lines, 1)
+	assert.Equal(t, 5, lines[0].Points)
+	assert.Equal(t, c, lines[0].B)
+}
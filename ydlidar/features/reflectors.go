@@ -0,0 +1,177 @@
+package features
+
+import (
+	"math"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Reflector is a run of high intensity returns, eg. a strip of retroreflective tape on a
+// charging dock.
+type Reflector struct {
+	Center    geom.Point // Mean of the points in millimeters, in the lidar frame.
+	Angle     float64    // Bearing of the center in degrees.
+	Dist      float64    // Distance of the center in millimeters.
+	Width     float64    // Distance between the first and last points in millimeters.
+	Intensity float64    // Mean intensity of the points.
+	Indices   []int      // Indices of the points in Scan.Points.
+}
+
+// ReflectorDetector finds the reflectors in a scan: runs of consecutive returns at or above
+// MinIntensity, cut where two neighbors are further apart than Gap.
+type ReflectorDetector struct {
+	MinIntensity int     // Intensity of a retroreflector return, well above the diffuse surfaces around.
+	Gap          float64 // Distance in millimeters between consecutive points that starts a new reflector.
+	MinPoints    int     // Minimum number of points of a reflector, smaller ones are dropped.
+	MinWidth     float64 // Width range in millimeters, 0 for no limit.
+	MaxWidth     float64
+}
+
+// Reflectors returns the reflectors found in the scan, in scan order. A reflector across the
+// start of the revolution is joined. Dropouts are ignored.
+func (d *ReflectorDetector) Reflectors(scan ydlidar.Scan) []Reflector {
+	var runs [][]int
+	var run []int
+	var last geom.Point
+	valid := 0
+	wraps := false // The first run starts at the first return of the revolution.
+	for i, point := range scan.Points {
+		if !scan.IsReturn(point.Dist) {
+			continue
+		}
+		p := geom.FromPolar(point.Angle, scan.Units.ToMillimeters(point.Dist))
+		bright := point.Intensity >= d.MinIntensity
+		if len(run) > 0 && (!bright || p.Dist(last) > d.Gap) {
+			runs = append(runs, run)
+			run = nil
+		}
+		if bright {
+			if valid == 0 {
+				wraps = true
+			}
+			run = append(run, i)
+		}
+		last = p
+		valid++
+	}
+	if len(run) > 0 {
+		if len(runs) > 0 && wraps && d.close(scan, run[len(run)-1], runs[0][0]) {
+			runs[0] = append(run, runs[0]...)
+		} else {
+			runs = append(runs, run)
+		}
+	}
+
+	var reflectors []Reflector
+	for _, run := range runs {
+		if reflector, ok := d.reflector(scan, run); ok {
+			reflectors = append(reflectors, reflector)
+		}
+	}
+	return reflectors
+}
+
+// close reports whether the points i and j are within Gap.
+func (d *ReflectorDetector) close(scan ydlidar.Scan, i, j int) bool {
+	a, b := scan.Points[i], scan.Points[j]
+	return geom.FromPolar(a.Angle, scan.Units.ToMillimeters(a.Dist)).Dist(geom.FromPolar(b.Angle, scan.Units.ToMillimeters(b.Dist))) <= d.Gap
+}
+
+// reflector summarizes the run, if it passes the size limits.
+func (d *ReflectorDetector) reflector(scan ydlidar.Scan, run []int) (Reflector, bool) {
+	if len(run) < d.MinPoints {
+		return Reflector{}, false
+	}
+	r := Reflector{Indices: run}
+	var first, last geom.Point
+	for k, i := range run {
+		point := scan.Points[i]
+		p := geom.FromPolar(point.Angle, scan.Units.ToMillimeters(point.Dist))
+		if k == 0 {
+			first = p
+		}
+		last = p
+		r.Center.X += p.X
+		r.Center.Y += p.Y
+		r.Intensity += float64(point.Intensity)
+	}
+	n := float64(len(run))
+	r.Center.X /= n
+	r.Center.Y /= n
+	r.Intensity /= n
+	r.Angle, r.Dist = r.Center.Polar()
+	r.Width = first.Dist(last)
+	if r.Width < d.MinWidth || (d.MaxWidth > 0 && r.Width > d.MaxWidth) {
+		return Reflector{}, false
+	}
+	return r, true
+}
+
+// Dock is a docking target made of reflectors in a row.
+type Dock struct {
+	Reflectors []Reflector // Reflectors of the pattern, in pattern order.
+	Center     geom.Point  // Middle of the outer reflectors in millimeters, in the lidar frame.
+	Angle      float64     // Bearing of the center in degrees.
+	Dist       float64     // Distance of the center in millimeters.
+	Heading    float64     // Direction in degrees the dock faces, towards the lidar. Facing the lidar squarely it is Angle+180.
+	Error      float64     // RMS difference in millimeters between the pattern and the measured spacings.
+}
+
+// DockPattern describes a dock by the distances between the centers of its consecutive
+// reflectors, from its right to its left as seen facing it. An asymmetric pattern tells
+// the dock from its mirror image and from other reflective objects.
+type DockPattern struct {
+	Spacings  []float64 // Center to center distances in millimeters.
+	Tolerance float64   // Maximum difference in millimeters of each measured spacing.
+}
+
+// Find returns the best match of the pattern among the reflectors, as returned by
+// Reflectors.
+func (p DockPattern) Find(reflectors []Reflector) (Dock, bool) {
+	n := len(p.Spacings) + 1
+	if n < 2 || len(reflectors) < n {
+		return Dock{}, false
+	}
+	var best Dock
+	found := false
+	// Counter clockwise order sweeps the dock from its right to its left.
+	for start := range reflectors {
+		candidate := make([]Reflector, n)
+		for k := range candidate {
+			candidate[k] = reflectors[(start+k)%len(reflectors)]
+		}
+		if dock, ok := p.match(candidate); ok && (!found || dock.Error < best.Error) {
+			best, found = dock, true
+		}
+	}
+	return best, found
+}
+
+// match checks the spacings of the candidate reflectors.
+func (p DockPattern) match(candidate []Reflector) (Dock, bool) {
+	var sum float64
+	for k, spacing := range p.Spacings {
+		diff := candidate[k].Center.Dist(candidate[k+1].Center) - spacing
+		if math.Abs(diff) > p.Tolerance {
+			return Dock{}, false
+		}
+		sum += diff * diff
+	}
+	right, left := candidate[0].Center, candidate[len(candidate)-1].Center
+	// The reflectors sweep counter clockwise around the lidar.
+	if right.X*left.Y-right.Y*left.X <= 0 {
+		return Dock{}, false
+	}
+
+	dock := Dock{
+		Reflectors: candidate,
+		Center:     geom.Point{X: (right.X + left.X) / 2, Y: (right.Y + left.Y) / 2},
+		Error:      math.Sqrt(sum / float64(len(p.Spacings))),
+	}
+	dock.Angle, dock.Dist = dock.Center.Polar()
+	// The normal to the row, from right to left turned counter clockwise, points at the lidar.
+	row := left.Sub(right)
+	dock.Heading, _ = geom.Point{X: -row.Y, Y: row.X}.Polar()
+	return dock, true
+}
@@ -0,0 +1,68 @@
+package features
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+// dockScan is a revolution in a room 2 m around, facing a dock 1 m in front of the lidar with
+// 2 cm strips of tape centered 60 mm to the right, in front and 90 mm to the left.
+func dockScan() ydlidar.Scan {
+	var scan ydlidar.Scan
+	for angle := 0.0; angle < 360; angle += 0.25 {
+		point := ydlidar.PointCloudData{Angle: angle, Dist: 2000, Intensity: 100}
+		rad := angle * math.Pi / 180
+		if math.Cos(rad) > 0.98 {
+			point.Dist = 1000 / math.Cos(rad)
+			y := 1000 * math.Tan(rad)
+			for _, strip := range []float64{-60, 0, 90} {
+				if math.Abs(y-strip) <= 10 {
+					point.Intensity = 1000
+				}
+			}
+		}
+		scan.Points = append(scan.Points, point)
+	}
+	return scan
+}
+
+func TestReflectors(t *testing.T) {
+	d := &ReflectorDetector{MinIntensity: 500, Gap: 20, MinPoints: 2}
+	reflectors := d.Reflectors(dockScan())
+	require.Len(t, reflectors, 3)
+
+	// The strip in front is joined across the start of the revolution.
+	assert.InDelta(t, 1000, reflectors[0].Center.X, 1)
+	assert.InDelta(t, 0, reflectors[0].Center.Y, 3)
+	assert.InDelta(t, 1000, reflectors[0].Dist, 1)
+	assert.InDelta(t, 20, reflectors[0].Width, 5)
+	assert.Equal(t, 1000.0, reflectors[0].Intensity)
+	assert.InDelta(t, 90, reflectors[1].Center.Y, 3)
+	assert.InDelta(t, math.Atan2(90, 1000)*180/math.Pi, reflectors[1].Angle, 0.2)
+	assert.InDelta(t, -60, reflectors[2].Center.Y, 3)
+
+	d.MaxWidth = 10
+	assert.Empty(t, d.Reflectors(dockScan()))
+}
+
+func TestDockPattern(t *testing.T) {
+	d := &ReflectorDetector{MinIntensity: 500, Gap: 20, MinPoints: 2}
+	reflectors := d.Reflectors(dockScan())
+
+	dock, ok := DockPattern{Spacings: []float64{60, 90}, Tolerance: 10}.Find(reflectors)
+	require.True(t, ok)
+	require.Len(t, dock.Reflectors, 3)
+	assert.InDelta(t, -60, dock.Reflectors[0].Center.Y, 3)
+	assert.InDelta(t, 1000, dock.Center.X, 1)
+	assert.InDelta(t, 15, dock.Center.Y, 3)
+	assert.InDelta(t, 180, dock.Heading, 0.5)
+	assert.Less(t, dock.Error, 5.0)
+
+	// The mirror image is not the dock.
+	_, ok = DockPattern{Spacings: []float64{90, 60}, Tolerance: 10}.Find(reflectors)
+	assert.False(t, ok)
+}
@@ -0,0 +1,87 @@
+package filters
+
+import "ydlidarg2/ydlidar"
+
+// Dropout fills dropouts, runs of samples without a return (distance 0), by interpolating
+// linearly between the valid samples on either side. Many costmap algorithms prefer a
+// plausible surface over a hole. Filled samples are flagged Synthetic.
+//
+// Runs longer than MaxGap samples, runs at the ends of the packet or scan and runs between
+// neighbors further apart than MaxJump, likely an edge rather than a dark surface, are kept.
+type Dropout struct {
+	MaxGap  int     // Longest run of dropouts filled, 1 if zero.
+	MaxJump float64 // Largest distance difference in millimeters between the neighbors, 0 for any.
+}
+
+// Filter fills the dropouts of the packet, implementing ydlidar.Filter.
+func (d Dropout) Filter(packet *ydlidar.Packet) {
+	filled := d.Fill(packet.Distances, packet.Intensities)
+	if filled == nil {
+		return
+	}
+	if packet.Synthetic == nil {
+		packet.Synthetic = make([]bool, len(packet.Distances))
+	}
+	for i, f := range filled {
+		packet.Synthetic[i] = packet.Synthetic[i] || f
+	}
+}
+
+// ProcessScan fills the dropouts of the revolution, implementing ydlidar.ScanProcessor.
+func (d Dropout) ProcessScan(scan *ydlidar.Scan) error {
+	dists := make([]float64, len(scan.Points))
+	intensities := make([]int, len(scan.Points))
+	for i, point := range scan.Points {
+		dists[i], intensities[i] = point.Dist, point.Intensity
+	}
+	for i, f := range d.Fill(dists, intensities) {
+		if f {
+			scan.Points[i].Dist, scan.Points[i].Intensity = dists[i], intensities[i]
+			scan.Points[i].Synthetic = true
+		}
+	}
+	return nil
+}
+
+// Fill interpolates the distances and intensities of the dropouts in place, intensities may
+// be nil. Returns which samples were filled, nil if none were.
+func (d Dropout) Fill(dists []float64, intensities []int) []bool {
+	maxGap := d.MaxGap
+	if maxGap <= 0 {
+		maxGap = 1
+	}
+
+	var filled []bool
+	for i := 0; i < len(dists); i++ {
+		if dists[i] > 0 {
+			continue
+		}
+		// The run of dropouts is [i, end).
+		end := i
+		for end < len(dists) && dists[end] <= 0 {
+			end++
+		}
+		left, right := i-1, end
+		gap := end - i
+		i = end
+		if left < 0 || right >= len(dists) || gap > maxGap {
+			continue
+		}
+		if jump := dists[right] - dists[left]; d.MaxJump > 0 && (jump > d.MaxJump || -jump > d.MaxJump) {
+			continue
+		}
+
+		if filled == nil {
+			filled = make([]bool, len(dists))
+		}
+		for j := left + 1; j < right; j++ {
+			t := float64(j-left) / float64(right-left)
+			dists[j] = dists[left] + t*(dists[right]-dists[left])
+			if j < len(intensities) && right < len(intensities) {
+				intensities[j] = intensities[left] + int(t*float64(intensities[right]-intensities[left])+0.5)
+			}
+			filled[j] = true
+		}
+	}
+	return filled
+}
@@ -0,0 +1,49 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"ydlidarg2/ydlidar"
+)
+
+func TestDropoutFill(t *testing.T) {
+	dists := []float64{0, 1000, 0, 0, 1300, 0, 0, 0, 1000, 2000, 0, 5000, 0}
+	intensities := []int{0, 100, 0, 0, 130, 0, 0, 0, 100, 100, 0, 100, 0}
+
+	filled := Dropout{MaxGap: 2, MaxJump: 1000}.Fill(dists, intensities)
+
+	// The leading and trailing dropouts, the run of 3 and the jump of 3000mm are kept.
+	assert.Equal(t, []float64{0, 1000, 1100, 1200, 1300, 0, 0, 0, 1000, 2000, 0, 5000, 0}, dists)
+	assert.Equal(t, []int{0, 100, 110, 120, 130, 0, 0, 0, 100, 100, 0, 100, 0}, intensities)
+	assert.Equal(t, []bool{false, false, true, true, false, false, false, false, false, false, false, false, false}, filled)
+
+	assert.Nil(t, Dropout{}.Fill([]float64{1000, 1001}, nil))
+}
+
+func TestDropoutFilter(t *testing.T) {
+	packet := ydlidar.Packet{
+		Angles:      []float64{1, 2, 3},
+		Distances:   []float64{1000, 0, 2000},
+		Intensities: []int{10, 0, 20},
+	}
+
+	Dropout{}.Filter(&packet)
+
+	assert.Equal(t, []float64{1000, 1500, 2000}, packet.Distances)
+	assert.Equal(t, []bool{false, true, false}, packet.Synthetic)
+	points := ydlidar.GetPointCloud(packet)
+	assert.True(t, points[1].Synthetic)
+	assert.Equal(t, 15, points[1].Intensity)
+}
+
+func TestDropoutProcessScan(t *testing.T) {
+	scan := ydlidar.Scan{Points: []ydlidar.PointCloudData{
+		{Angle: 1, Dist: 1000}, {Angle: 2}, {Angle: 3, Dist: 1200},
+	}}
+
+	assert.NoError(t, Dropout{}.ProcessScan(&scan))
+	assert.Equal(t, float64(1100), scan.Points[1].Dist)
+	assert.True(t, scan.Points[1].Synthetic)
+	assert.False(t, scan.Points[0].Synthetic)
+}
@@ -0,0 +1,113 @@
+// Package filters holds optional filter stages plugged into the lidar with
+// ydlidar.WithFilter (per packet) or ydlidar.WithScanProcessor (per revolution).
+package filters
+
+import (
+	"math"
+	"sort"
+
+	"ydlidarg2/ydlidar"
+)
+
+// Outlier removes isolated points, typically dust or sunlight artifacts, whose distance
+// differs from every neighboring angular sample by more than a threshold.
+//
+// The threshold is either fixed (Threshold, in millimeters) or, when KSigma is set,
+// KSigma times the standard deviation of the neighbor differences over the packet or scan.
+// Dropouts (distance 0) are never removed and don't count as neighbors.
+type Outlier struct {
+	Threshold float64 // Fixed threshold in millimeters.
+	KSigma    float64 // Adaptive threshold in standard deviations, overrides Threshold when > 0.
+	Window    int     // Valid neighbors considered on each side, 1 if zero.
+}
+
+// Filter drops the outliers of the packet, implementing ydlidar.Filter.
+func (o Outlier) Filter(packet *ydlidar.Packet) {
+	outliers := o.Outliers(packet.Distances)
+	packet.Keep(func(i int) bool { return !outliers[i] })
+}
+
+// ProcessScan drops the outliers of the revolution, implementing ydlidar.ScanProcessor.
+func (o Outlier) ProcessScan(scan *ydlidar.Scan) error {
+	dists := make([]float64, len(scan.Points))
+	for i, point := range scan.Points {
+		dists[i] = point.Dist
+	}
+	outliers := o.Outliers(dists)
+
+	kept := 0
+	for i, point := range scan.Points {
+		if outliers[i] {
+			continue
+		}
+		scan.Points[kept] = point
+		if scan.Labels != nil {
+			scan.Labels[kept] = scan.Labels[i]
+		}
+		kept++
+	}
+	scan.Points = scan.Points[:kept]
+	if scan.Labels != nil {
+		scan.Labels = scan.Labels[:kept]
+	}
+	return nil
+}
+
+// Outliers flags the samples whose distance differs from all their valid neighbors by more than the threshold.
+func (o Outlier) Outliers(dists []float64) []bool {
+	window := o.Window
+	if window <= 0 {
+		window = 1
+	}
+
+	// Smallest difference to a valid neighbor, NaN if the sample has none.
+	nearest := make([]float64, len(dists))
+	var diffs []float64
+	for i, d := range dists {
+		nearest[i] = math.NaN()
+		if d <= 0 {
+			continue
+		}
+		for _, step := range []int{-1, 1} {
+			// Walk to the window closest valid samples on this side, skipping dropouts.
+			for j, found := i+step, 0; j >= 0 && j < len(dists) && found < window; j += step {
+				if dists[j] <= 0 {
+					continue
+				}
+				found++
+				diff := math.Abs(float64(d - dists[j]))
+				if math.IsNaN(nearest[i]) || diff < nearest[i] {
+					nearest[i] = diff
+				}
+			}
+		}
+		if !math.IsNaN(nearest[i]) {
+			diffs = append(diffs, nearest[i])
+		}
+	}
+
+	threshold := o.Threshold
+	if o.KSigma > 0 {
+		threshold = o.KSigma * robustSigma(diffs)
+	}
+
+	outliers := make([]bool, len(dists))
+	if threshold <= 0 {
+		return outliers
+	}
+	for i, diff := range nearest {
+		outliers[i] = diff > threshold
+	}
+	return outliers
+}
+
+// robustSigma estimates the standard deviation from the median absolute value,
+// so the outliers being searched for don't inflate the threshold.
+func robustSigma(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return 1.4826 * sorted[len(sorted)/2]
+}
@@ -0,0 +1,29 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"ydlidarg2/ydlidar"
+)
+
+func TestOutlierThreshold(t *testing.T) {
+	packet := ydlidar.Packet{
+		Angles:      []float64{1, 2, 3, 4, 5, 6},
+		Distances:   []float64{1000, 1010, 300, 1020, 0, 1030},
+		Intensities: []int{1, 2, 3, 4, 5, 6},
+	}
+
+	Outlier{Threshold: 100}.Filter(&packet)
+
+	assert.Equal(t, []float64{1000, 1010, 1020, 0, 1030}, packet.Distances)
+	assert.Equal(t, []int{1, 2, 4, 5, 6}, packet.Intensities)
+}
+
+func TestOutlierKSigma(t *testing.T) {
+	dists := []float64{1000, 1004, 998, 1003, 1500, 1001, 999, 1002}
+
+	outliers := Outlier{KSigma: 5}.Outliers(dists)
+
+	assert.Equal(t, []bool{false, false, false, false, true, false, false, false}, outliers)
+}
@@ -0,0 +1,123 @@
+// Package geom holds the 2D geometry helpers used to work with lidar points: polar and
+// cartesian conversion, angle normalization, segment intersection, point-in-polygon and
+// 2D poses.
+//
+// Angles are in degrees, counter clockwise, with 0° along the X axis, matching the lidar
+// output. Distances are in whatever unit the caller uses, millimeters for raw lidar data.
+package geom
+
+import "math"
+
+// Point is a cartesian point.
+type Point struct {
+	X, Y float64
+}
+
+// FromPolar returns the point at angle degrees and distance dist from the origin.
+func FromPolar(angle, dist float64) Point {
+	rad := angle * math.Pi / 180
+	return Point{X: dist * math.Cos(rad), Y: dist * math.Sin(rad)}
+}
+
+// Polar returns the angle in [0, 360) degrees and the distance of the point from the origin.
+func (p Point) Polar() (angle, dist float64) {
+	return NormalizeAngle(math.Atan2(p.Y, p.X) * 180 / math.Pi), math.Hypot(p.X, p.Y)
+}
+
+// Sub returns p - q.
+func (p Point) Sub(q Point) Point {
+	return Point{X: p.X - q.X, Y: p.Y - q.Y}
+}
+
+// Dist returns the distance between p and q.
+func (p Point) Dist(q Point) float64 {
+	return math.Hypot(p.X-q.X, p.Y-q.Y)
+}
+
+// cross returns the z component of the cross product of p and q.
+func (p Point) cross(q Point) float64 {
+	return p.X*q.Y - p.Y*q.X
+}
+
+// NormalizeAngle wraps the angle to [0, 360) degrees.
+func NormalizeAngle(angle float64) float64 {
+	angle = math.Mod(angle, 360)
+	if angle < 0 {
+		angle += 360
+	}
+	return angle
+}
+
+// AngleDiff returns a - b wrapped to [-180, 180) degrees.
+func AngleDiff(a, b float64) float64 {
+	return NormalizeAngle(a-b+180) - 180
+}
+
+// InAngleRange reports whether angle lies between from and to degrees, inclusive.
+// A range with from > to wraps through 0°, eg. 350 to 10.
+func InAngleRange(angle, from, to float64) bool {
+	angle, from, to = NormalizeAngle(angle), NormalizeAngle(from), NormalizeAngle(to)
+	if from <= to {
+		return angle >= from && angle <= to
+	}
+	return angle >= from || angle <= to
+}
+
+// Segment is the line segment between A and B.
+type Segment struct {
+	A, B Point
+}
+
+// Len returns the length of the segment.
+func (s Segment) Len() float64 {
+	return s.A.Dist(s.B)
+}
+
+// Intersect returns the point where the segments cross. Parallel and collinear
+// segments don't intersect.
+func (s Segment) Intersect(o Segment) (Point, bool) {
+	r, q := s.B.Sub(s.A), o.B.Sub(o.A)
+	denom := r.cross(q)
+	if denom == 0 {
+		return Point{}, false
+	}
+	ao := o.A.Sub(s.A)
+	t, u := ao.cross(q)/denom, ao.cross(r)/denom
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return Point{}, false
+	}
+	return Point{X: s.A.X + t*r.X, Y: s.A.Y + t*r.Y}, true
+}
+
+// Distance returns the distance from p to the closest point of the segment.
+func (s Segment) Distance(p Point) float64 {
+	d := s.B.Sub(s.A)
+	l2 := d.X*d.X + d.Y*d.Y
+	if l2 == 0 {
+		return p.Dist(s.A)
+	}
+	t := math.Max(0, math.Min(1, ((p.X-s.A.X)*d.X+(p.Y-s.A.Y)*d.Y)/l2))
+	return p.Dist(Point{X: s.A.X + t*d.X, Y: s.A.Y + t*d.Y})
+}
+
+// LineDistance returns the distance from p to the infinite line through the segment.
+func (s Segment) LineDistance(p Point) float64 {
+	l := s.Len()
+	if l == 0 {
+		return p.Dist(s.A)
+	}
+	return math.Abs(s.B.Sub(s.A).cross(p.Sub(s.A))) / l
+}
+
+// PointInPolygon reports whether p lies inside the polygon, using ray casting.
+// The polygon is closed implicitly, its last vertex connects to the first.
+func PointInPolygon(p Point, polygon []Point) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,71 @@
+package geom
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolar(t *testing.T) {
+	p := FromPolar(90, 1000)
+	assert.InDelta(t, 0, p.X, 1e-9)
+	assert.InDelta(t, 1000, p.Y, 1e-9)
+
+	angle, dist := Point{X: 0, Y: -2}.Polar()
+	assert.InDelta(t, 270, angle, 1e-9)
+	assert.InDelta(t, 2, dist, 1e-9)
+}
+
+func TestAngles(t *testing.T) {
+	assert.Equal(t, 350.0, NormalizeAngle(-10))
+	assert.Equal(t, 10.0, NormalizeAngle(730))
+	assert.Equal(t, -20.0, AngleDiff(350, 10))
+	assert.Equal(t, 20.0, AngleDiff(10, 350))
+	assert.True(t, InAngleRange(5, 350, 10))
+	assert.True(t, InAngleRange(-5, 350, 10))
+	assert.False(t, InAngleRange(180, 350, 10))
+	assert.True(t, InAngleRange(180, 170, 190))
+}
+
+func TestSegments(t *testing.T) {
+	s := Segment{Point{0, 0}, Point{2, 2}}
+
+	p, ok := s.Intersect(Segment{Point{0, 2}, Point{2, 0}})
+	assert.True(t, ok)
+	assert.Equal(t, Point{1, 1}, p)
+
+	_, ok = s.Intersect(Segment{Point{3, 0}, Point{3, 5}})
+	assert.False(t, ok)
+	_, ok = s.Intersect(Segment{Point{1, 0}, Point{3, 2}})
+	assert.False(t, ok)
+
+	assert.InDelta(t, 1, Segment{Point{0, 0}, Point{2, 0}}.Distance(Point{1, 1}), 1e-9)
+	assert.InDelta(t, 5, Segment{Point{0, 0}, Point{2, 0}}.Distance(Point{5, 4}), 1e-9)
+	assert.InDelta(t, 4, Segment{Point{0, 0}, Point{2, 0}}.LineDistance(Point{5, 4}), 1e-9)
+}
+
+func TestPointInPolygon(t *testing.T) {
+	square := []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+
+	assert.True(t, PointInPolygon(Point{5, 5}, square))
+	assert.False(t, PointInPolygon(Point{15, 5}, square))
+	assert.False(t, PointInPolygon(Point{-1, -1}, square))
+}
+
+func TestPose2D(t *testing.T) {
+	robot := Pose2D{X: 1000, Y: 2000, Heading: 90}
+	p := robot.Apply(Point{X: 100, Y: 0})
+	assert.InDelta(t, 1000, p.X, 1e-9)
+	assert.InDelta(t, 2100, p.Y, 1e-9)
+
+	// A lidar mounted 200 mm behind the center of the robot, facing backwards.
+	lidar := robot.Compose(Pose2D{X: -200, Heading: 180})
+	assert.InDelta(t, 1000, lidar.X, 1e-9)
+	assert.InDelta(t, 1800, lidar.Y, 1e-9)
+	assert.Equal(t, 270.0, lidar.Heading)
+
+	origin := lidar.Compose(lidar.Inverse())
+	assert.InDelta(t, 0, origin.X, 1e-9)
+	assert.InDelta(t, 0, origin.Y, 1e-9)
+	assert.InDelta(t, 0, AngleDiff(origin.Heading, 0), 1e-9)
+}
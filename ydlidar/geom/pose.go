@@ -0,0 +1,34 @@
+package geom
+
+import "math"
+
+// Pose2D is a position and heading in the plane, eg. the pose of the lidar in the world
+// frame given by the odometry of the robot.
+type Pose2D struct {
+	X, Y    float64 // Position.
+	Heading float64 // Degrees counter clockwise from the X axis.
+}
+
+// Position returns the position of the pose.
+func (p Pose2D) Position() Point {
+	return Point{X: p.X, Y: p.Y}
+}
+
+// Apply returns the point q, given in the frame of the pose, in the frame the pose is in.
+func (p Pose2D) Apply(q Point) Point {
+	sin, cos := math.Sincos(p.Heading * math.Pi / 180)
+	return Point{X: p.X + q.X*cos - q.Y*sin, Y: p.Y + q.X*sin + q.Y*cos}
+}
+
+// Compose returns the pose q, given in the frame of p, in the frame p is in. Eg. the world
+// pose of the lidar is robotPose.Compose(lidarMount).
+func (p Pose2D) Compose(q Pose2D) Pose2D {
+	position := p.Apply(q.Position())
+	return Pose2D{X: position.X, Y: position.Y, Heading: NormalizeAngle(p.Heading + q.Heading)}
+}
+
+// Inverse returns the pose of the origin in the frame of p.
+func (p Pose2D) Inverse() Pose2D {
+	sin, cos := math.Sincos(p.Heading * math.Pi / 180)
+	return Pose2D{X: -p.X*cos - p.Y*sin, Y: p.X*sin - p.Y*cos, Heading: NormalizeAngle(-p.Heading)}
+}
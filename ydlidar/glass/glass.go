@@ -0,0 +1,105 @@
+// Package glass detects glass walls and other transparent obstacles in a scan.
+//
+// A laser hitting glass at near-normal incidence reflects straight back and shows up as a
+// narrow intensity spike, while at other incidence angles the beam passes through or is
+// deflected away and the lidar reports dropouts. The Detector looks for spikes surrounded
+// by dropouts, reports them as panes and can fill the dropouts with synthetic points lying
+// on the plane of the pane so costmaps see an obstacle.
+package glass
+
+import (
+	"math"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Label is set in Scan.Labels on the synthetic points injected by the detector.
+const Label = "glass"
+
+// Pane is a detected glass surface.
+type Pane struct {
+	Angle      float64 // Angle of the spike, the normal of the pane, in degrees.
+	Dist       float64 // Distance of the pane along its normal in millimeters.
+	StartAngle float64 // First angle covered by the surrounding dropouts.
+	EndAngle   float64 // Last angle covered by the surrounding dropouts.
+	Dropouts   int     // Number of dropout samples around the spike.
+}
+
+// Detector is a ydlidar.ScanProcessor detecting glass panes.
+type Detector struct {
+	SpikeIntensity int       // Minimum intensity of a spike.
+	Window         float64   // Half width in degrees of the window checked for dropouts around a spike.
+	DropoutRatio   float64   // Minimum ratio of dropouts in the window, eg. 0.6.
+	Inject         bool      // Fill the dropouts with synthetic points on the pane.
+	Panes          chan Pane // Detected panes, sent without blocking. Optional.
+}
+
+// ProcessScan detects panes, reports them and injects the synthetic points if enabled.
+func (d *Detector) ProcessScan(scan *ydlidar.Scan) error {
+	for _, pane := range d.Detect(*scan) {
+		if d.Inject {
+			inject(scan, pane)
+		}
+		if d.Panes != nil {
+			select {
+			case d.Panes <- pane:
+			default:
+			}
+		}
+	}
+	return nil
+}
+
+// Detect returns the panes found in the scan.
+func (d *Detector) Detect(scan ydlidar.Scan) []Pane {
+	var panes []Pane
+	points := scan.Points
+	for i, spike := range points {
+		if !scan.IsReturn(spike.Dist) || spike.Intensity < d.SpikeIntensity {
+			continue
+		}
+
+		pane := Pane{Angle: spike.Angle, Dist: spike.Dist, StartAngle: spike.Angle, EndAngle: spike.Angle}
+		total := 0
+		for j, point := range points {
+			offset := geom.AngleDiff(point.Angle, spike.Angle)
+			if j == i || math.Abs(offset) > d.Window {
+				continue
+			}
+			total++
+			if scan.IsReturn(point.Dist) {
+				continue
+			}
+			pane.Dropouts++
+			if offset < geom.AngleDiff(pane.StartAngle, spike.Angle) {
+				pane.StartAngle = point.Angle
+			}
+			if offset > geom.AngleDiff(pane.EndAngle, spike.Angle) {
+				pane.EndAngle = point.Angle
+			}
+		}
+
+		if total > 0 && float64(pane.Dropouts)/float64(total) >= d.DropoutRatio {
+			panes = append(panes, pane)
+		}
+	}
+	return panes
+}
+
+// inject replaces the dropouts covered by the pane with points on its plane:
+// a ray θ degrees off the normal meets the plane at Dist / cos(θ).
+func inject(scan *ydlidar.Scan, pane Pane) {
+	if scan.Labels == nil {
+		scan.Labels = make([]string, len(scan.Points))
+	}
+	start, end := geom.AngleDiff(pane.StartAngle, pane.Angle), geom.AngleDiff(pane.EndAngle, pane.Angle)
+	for i, point := range scan.Points {
+		offset := geom.AngleDiff(point.Angle, pane.Angle)
+		if scan.IsReturn(point.Dist) || offset < start || offset > end {
+			continue
+		}
+		scan.Points[i].Dist = pane.Dist / math.Cos(offset*math.Pi/180)
+		scan.Labels[i] = Label
+	}
+}
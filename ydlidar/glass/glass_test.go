@@ -0,0 +1,32 @@
+package glass
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+func TestDetectAndInject(t *testing.T) {
+	scan := &ydlidar.Scan{}
+	for angle := float64(-10); angle <= 10; angle++ {
+		scan.Points = append(scan.Points, ydlidar.PointCloudData{Angle: angle})
+	}
+	// Spike straight ahead at 2 m, a wall with normal returns further away.
+	scan.Points[10] = ydlidar.PointCloudData{Angle: 0, Dist: 2000, Intensity: 1000}
+	scan.Points = append(scan.Points, ydlidar.PointCloudData{Angle: 90, Dist: 1500, Intensity: 200})
+
+	d := &Detector{SpikeIntensity: 900, Window: 10, DropoutRatio: 0.6, Inject: true}
+	panes := d.Detect(*scan)
+	require.Len(t, panes, 1)
+	assert.Equal(t, float64(-10), panes[0].StartAngle)
+	assert.Equal(t, float64(10), panes[0].EndAngle)
+	assert.Equal(t, 20, panes[0].Dropouts)
+
+	require.NoError(t, d.ProcessScan(scan))
+	assert.InDelta(t, 2000/0.98481, scan.Points[0].Dist, 0.5)
+	assert.Equal(t, Label, scan.Labels[0])
+	assert.Equal(t, "", scan.Labels[10])
+	assert.Equal(t, float64(1500), scan.Points[21].Dist)
+}
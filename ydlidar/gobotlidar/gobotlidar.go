@@ -0,0 +1,184 @@
+// Package gobotlidar plugs the lidar into gobot robots: Adaptor is the gobot connection to
+// the device and Driver the gobot device publishing the revolutions on the event bus.
+//
+//	adaptor := gobotlidar.NewAdaptor("/dev/ttyUSB0")
+//	lidar := gobotlidar.NewDriver(adaptor)
+//	work := func() {
+//		lidar.On(gobotlidar.ScanEvent, func(data interface{}) {
+//			scan := data.(ydlidar.Scan)
+//			...
+//		})
+//	}
+//	robot := gobot.NewRobot("bot", []gobot.Connection{adaptor}, []gobot.Device{lidar}, work)
+//	robot.Start()
+package gobotlidar
+
+import (
+	"fmt"
+	"sync"
+
+	"gobot.io/x/gobot/v2"
+	"ydlidarg2/ydlidar"
+)
+
+// Events published by the Driver.
+const (
+	// ScanEvent carries every assembled revolution, a ydlidar.Scan.
+	ScanEvent = "scan"
+	// StatusEvent carries the connection events, a ydlidar.StatusEvent.
+	StatusEvent = "status"
+	// ErrorEvent carries the errors of the scan loop, an error.
+	ErrorEvent = "error"
+)
+
+// Adaptor is the gobot connection to a lidar.
+type Adaptor struct {
+	name   string
+	target string
+	opts   []ydlidar.Option
+
+	mu    sync.Mutex
+	lidar *ydlidar.YDLidar
+}
+
+// NewAdaptor returns the adaptor of the lidar at target, a serial port, tcp://host:port or
+// empty to auto-detect, see ydlidar.Connect. The options are passed on to the lidar.
+func NewAdaptor(target string, opts ...ydlidar.Option) *Adaptor {
+	return &Adaptor{name: gobot.DefaultName("YDLidar"), target: target, opts: opts}
+}
+
+// NewAdaptorFromLidar returns the adaptor of a lidar already connected, eg. with options
+// only known at run time. Finalize closes it.
+func NewAdaptorFromLidar(lidar *ydlidar.YDLidar) *Adaptor {
+	return &Adaptor{name: gobot.DefaultName("YDLidar"), lidar: lidar}
+}
+
+// Name returns the name of the adaptor.
+func (a *Adaptor) Name() string { return a.name }
+
+// SetName sets the name of the adaptor.
+func (a *Adaptor) SetName(name string) { a.name = name }
+
+// Connect connects to the lidar.
+func (a *Adaptor) Connect() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lidar != nil {
+		return nil
+	}
+	lidar, err := ydlidar.Connect(a.target, a.opts...)
+	if err != nil {
+		return err
+	}
+	a.lidar = lidar
+	return nil
+}
+
+// Finalize stops the lidar and closes the connection.
+func (a *Adaptor) Finalize() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lidar == nil {
+		return nil
+	}
+	err := a.lidar.Close()
+	a.lidar = nil
+	return err
+}
+
+// Lidar returns the connected lidar, nil before Connect.
+func (a *Adaptor) Lidar() *ydlidar.YDLidar {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lidar
+}
+
+// Driver is the gobot device of a lidar. Start scans and publishes the revolutions as
+// ScanEvent, Halt stops the scan.
+type Driver struct {
+	gobot.Eventer
+	name       string
+	connection *Adaptor
+
+	mu           sync.Mutex
+	subscription *ydlidar.Subscription
+	stop         chan struct{}
+	done         chan struct{}
+}
+
+// NewDriver returns the driver of the lidar connected by the adaptor.
+func NewDriver(a *Adaptor) *Driver {
+	d := &Driver{Eventer: gobot.NewEventer(), name: gobot.DefaultName("YDLidar"), connection: a}
+	d.AddEvent(ScanEvent)
+	d.AddEvent(StatusEvent)
+	d.AddEvent(ErrorEvent)
+	return d
+}
+
+// Name returns the name of the driver.
+func (d *Driver) Name() string { return d.name }
+
+// SetName sets the name of the driver.
+func (d *Driver) SetName(name string) { d.name = name }
+
+// Connection returns the adaptor.
+func (d *Driver) Connection() gobot.Connection { return d.connection }
+
+// Start starts the scan and the publication of the events.
+func (d *Driver) Start() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.subscription != nil {
+		return nil
+	}
+	lidar := d.connection.Lidar()
+	if lidar == nil {
+		return fmt.Errorf("%v: adaptor %v not connected", d.name, d.connection.Name())
+	}
+
+	d.subscription = lidar.OnScan(func(scan ydlidar.Scan) { d.Publish(ScanEvent, scan) })
+	if err := lidar.StartScan(); err != nil {
+		d.subscription.Unsubscribe()
+		d.subscription = nil
+		return err
+	}
+
+	// The revolutions come from the subscription, the channels only carry the errors and
+	// the status events, and have to be drained.
+	d.stop, d.done = make(chan struct{}), make(chan struct{})
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		for {
+			select {
+			case packet := <-lidar.Packets:
+				if packet.Error != nil {
+					d.Publish(ErrorEvent, packet.Error)
+				}
+			case event := <-lidar.Status:
+				d.Publish(StatusEvent, event)
+			case <-stop:
+				return
+			}
+		}
+	}(d.stop, d.done)
+	return nil
+}
+
+// Halt stops the scan and the publication of the events.
+func (d *Driver) Halt() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.subscription == nil {
+		return nil
+	}
+	d.subscription.Unsubscribe()
+	d.subscription = nil
+
+	var err error
+	if lidar := d.connection.Lidar(); lidar != nil {
+		err = lidar.StopScan()
+	}
+	close(d.stop)
+	<-d.done
+	return err
+}
@@ -0,0 +1,104 @@
+package gobotlidar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gobot.io/x/gobot/v2"
+	"ydlidarg2/ydlidar"
+)
+
+// Compile time checks of the gobot interfaces.
+var (
+	_ gobot.Adaptor = (*Adaptor)(nil)
+	_ gobot.Driver  = (*Driver)(nil)
+	_ gobot.Eventer = (*Driver)(nil)
+)
+
+// scanningPort answers the scan command then sends revolutions of 40 samples forever.
+type scanningPort struct {
+	mu     sync.Mutex
+	output bytes.Buffer
+}
+
+func newScanningPort() *scanningPort {
+	p := &scanningPort{}
+	p.output.Write([]byte{0xA5, 0x5A, 0x05, 0x00, 0x00, 0x40, ydlidar.ScanTypeCode})
+	return p
+}
+
+func (p *scanningPort) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.output.Len() == 0 {
+		time.Sleep(time.Millisecond)
+		p.output.Write(scanPacket(0x01, nil))
+		p.output.Write(scanPacket(0x00, make([][3]byte, 40)))
+	}
+	return p.output.Read(b)
+}
+
+func (p *scanningPort) Write(b []byte) (int, error)        { return len(b), nil }
+func (p *scanningPort) SetReadTimeout(time.Duration) error { return nil }
+func (p *scanningPort) Close() error                       { return nil }
+
+// scanPacket encodes a scan packet of 3 byte samples from 0° to 180°.
+func scanPacket(ct byte, samples [][3]byte) []byte {
+	if ct == 0x01 {
+		samples = [][3]byte{{}}
+	}
+	fsa, lsa := uint16(0x0001), uint16(180*64<<1|1)
+	cs := 0x55AA ^ (uint16(len(samples))<<8 | uint16(ct)) ^ fsa ^ lsa
+	for _, sample := range samples {
+		cs ^= uint16(sample[0]) ^ (uint16(sample[2])<<8 | uint16(sample[1]))
+	}
+	b := []byte{0xAA, 0x55, ct, byte(len(samples))}
+	b = binary.LittleEndian.AppendUint16(b, fsa)
+	b = binary.LittleEndian.AppendUint16(b, lsa)
+	b = binary.LittleEndian.AppendUint16(b, cs)
+	for _, sample := range samples {
+		b = append(b, sample[:]...)
+	}
+	return b
+}
+
+func TestDriverNotConnected(t *testing.T) {
+	driver := NewDriver(NewAdaptor("/dev/null"))
+	assert.Error(t, driver.Start())
+	assert.NoError(t, driver.Halt())
+	assert.Contains(t, driver.Events(), ScanEvent)
+}
+
+func TestDriverPublishesScans(t *testing.T) {
+	lidar := ydlidar.NewLidar(newScanningPort(), ydlidar.WithSampleDecoder(ydlidar.IntensityDecoder{}))
+	adaptor := NewAdaptorFromLidar(lidar)
+	driver := NewDriver(adaptor)
+	assert.Same(t, adaptor, driver.Connection())
+	require.NoError(t, adaptor.Connect())
+
+	scans := make(chan ydlidar.Scan, 1)
+	require.NoError(t, driver.On(ScanEvent, func(data interface{}) {
+		select {
+		case scans <- data.(ydlidar.Scan):
+		default:
+		}
+	}))
+	require.NoError(t, driver.Start())
+
+	select {
+	case scan := <-scans:
+		assert.Len(t, scan.Points, 40)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no revolution published")
+	}
+
+	require.NoError(t, driver.Halt())
+	assert.False(t, lidar.IsScanning())
+	require.NoError(t, adaptor.Finalize())
+	assert.Nil(t, adaptor.Lidar())
+}
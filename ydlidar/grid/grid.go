@@ -0,0 +1,173 @@
+// Package grid integrates lidar scans into a 2D occupancy grid using log-odds updates:
+// the cell hit by a return becomes more likely occupied, the cells the beam crossed
+// on its way (found by ray tracing) more likely free.
+package grid
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Default log-odds parameters.
+const (
+	DefaultHit  = 0.85
+	DefaultMiss = -0.4
+	DefaultMax  = 5.0
+)
+
+// Grid is an occupancy grid. Cell (0, 0) is the bottom left corner.
+type Grid struct {
+	Width, Height int        // Size in cells.
+	Resolution    float64    // Size of a cell in millimeters.
+	Origin        geom.Point // World position of the bottom left corner in millimeters.
+
+	Hit  float64 // Log-odds added to the cell of a return.
+	Miss float64 // Log-odds added to the cells a beam crossed.
+	Max  float64 // Log-odds are clamped to [-Max, Max] so the map can still change.
+
+	logOdds []float64
+}
+
+// New returns an unknown grid of width x height cells of resolution millimeters,
+// centered on the world origin.
+func New(width, height int, resolution float64) *Grid {
+	return &Grid{
+		Width:      width,
+		Height:     height,
+		Resolution: resolution,
+		Origin:     geom.Point{X: -float64(width) * resolution / 2, Y: -float64(height) * resolution / 2},
+		Hit:        DefaultHit,
+		Miss:       DefaultMiss,
+		Max:        DefaultMax,
+		logOdds:    make([]float64, width*height),
+	}
+}
+
+// Cell returns the cell containing the world point, ok is false outside the grid.
+func (g *Grid) Cell(p geom.Point) (x, y int, ok bool) {
+	x = int(math.Floor((p.X - g.Origin.X) / g.Resolution))
+	y = int(math.Floor((p.Y - g.Origin.Y) / g.Resolution))
+	return x, y, g.inside(x, y)
+}
+
+func (g *Grid) inside(x, y int) bool {
+	return x >= 0 && y >= 0 && x < g.Width && y < g.Height
+}
+
+// LogOdds returns the log-odds of the cell, 0 is unknown.
+func (g *Grid) LogOdds(x, y int) float64 {
+	if !g.inside(x, y) {
+		return 0
+	}
+	return g.logOdds[y*g.Width+x]
+}
+
+// Probability returns the occupancy probability of the cell, 0.5 is unknown.
+func (g *Grid) Probability(x, y int) float64 {
+	return 1 - 1/(1+math.Exp(g.LogOdds(x, y)))
+}
+
+// Integrate adds a scan taken by a sensor at the world origin facing along the X axis.
+func (g *Grid) Integrate(scan ydlidar.Scan) {
+	g.IntegrateFrom(geom.Point{}, 0, scan)
+}
+
+// IntegrateFrom adds a scan taken by a sensor at position facing heading degrees.
+// Dropouts are skipped as they don't tell where the beam stopped.
+func (g *Grid) IntegrateFrom(position geom.Point, heading float64, scan ydlidar.Scan) {
+	x0, y0, _ := g.Cell(position)
+	for _, point := range scan.Points {
+		if !scan.IsReturn(point.Dist) {
+			continue
+		}
+		p := geom.FromPolar(point.Angle+heading, point.Dist)
+		x1, y1, _ := g.Cell(geom.Point{X: position.X + p.X, Y: position.Y + p.Y})
+
+		g.trace(x0, y0, x1, y1)
+		g.update(x1, y1, g.Hit)
+	}
+}
+
+// IntegratePose adds a scan taken by a sensor at the world pose, see IntegrateFrom.
+func (g *Grid) IntegratePose(pose geom.Pose2D, scan ydlidar.Scan) {
+	g.IntegrateFrom(pose.Position(), pose.Heading, scan)
+}
+
+// trace lowers the log-odds of the cells from (x0, y0) up to, not including, (x1, y1) using Bresenham's line.
+func (g *Grid) trace(x0, y0, x1, y1 int) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	e := dx + dy
+	for x, y := x0, y0; x != x1 || y != y1; {
+		g.update(x, y, g.Miss)
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x += sx
+		} else {
+			e += dx
+			y += sy
+		}
+	}
+}
+
+func (g *Grid) update(x, y int, delta float64) {
+	if !g.inside(x, y) {
+		return
+	}
+	i := y*g.Width + x
+	g.logOdds[i] = math.Max(-g.Max, math.Min(g.Max, g.logOdds[i]+delta))
+}
+
+// Image returns the grid as a grayscale image in the ROS map_server convention:
+// white free, black occupied, gray unknown, north up.
+func (g *Grid) Image() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, g.Width, g.Height))
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			img.SetGray(x, g.Height-1-y, color.Gray{Y: uint8(math.Round(255 * (1 - g.Probability(x, y))))})
+		}
+	}
+	return img
+}
+
+// WritePNG encodes the grid image as PNG.
+func (g *Grid) WritePNG(w io.Writer) error {
+	return png.Encode(w, g.Image())
+}
+
+// WritePGM encodes the grid image as a binary PGM, the format of ROS map_server.
+func (g *Grid) WritePGM(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := fmt.Fprintf(bw, "P5\n# resolution %v mm\n%d %d\n255\n", g.Resolution, g.Width, g.Height); err != nil {
+		return err
+	}
+	if _, err := bw.Write(g.Image().Pix); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,44 @@
+package grid
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+func TestIntegrate(t *testing.T) {
+	g := New(100, 100, 50)
+	scan := ydlidar.Scan{Points: []ydlidar.PointCloudData{{Angle: 0, Dist: 1000}, {Angle: 90, Dist: 0}}}
+	for i := 0; i < 3; i++ {
+		g.Integrate(scan)
+	}
+
+	// The sensor sits in cell (50, 50), the return 20 cells to the right.
+	assert.Greater(t, g.Probability(70, 50), 0.9)
+	assert.Less(t, g.Probability(60, 50), 0.3)
+	assert.Less(t, g.Probability(50, 50), 0.3)
+	assert.Equal(t, 0.5, g.Probability(50, 60))
+	assert.Equal(t, 0.5, g.Probability(71, 50))
+
+	var b bytes.Buffer
+	require.NoError(t, g.WritePGM(&b))
+	assert.True(t, bytes.HasPrefix(b.Bytes(), []byte("P5\n")))
+	assert.Equal(t, bytes.Index(b.Bytes(), []byte("255\n"))+4+100*100, b.Len())
+
+	b.Reset()
+	require.NoError(t, g.WritePNG(&b))
+	assert.NotZero(t, b.Len())
+}
+
+func TestIntegratePose(t *testing.T) {
+	g := New(100, 100, 50)
+	// A sensor 500 mm up facing left sees the return 1 m further up.
+	scan := ydlidar.Scan{Points: []ydlidar.PointCloudData{{Angle: 0, Dist: 1000}}}
+	g.IntegratePose(geom.Pose2D{Y: 500, Heading: 90}, scan)
+	assert.Greater(t, g.Probability(50, 80), 0.5)
+	assert.Less(t, g.Probability(50, 70), 0.5)
+}
@@ -0,0 +1,84 @@
+package gs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// frameWidth is the number of samples of a frame, the pixels of the sensor.
+const frameWidth = 160
+
+// maxRange is the maximum range of the GS2 in mm, the angle of a dropout is that of its pixel
+// at this distance.
+const maxRange = 300
+
+// Geometry of the GS2 from its development manual: the offset in mm of the sensor from the
+// laser, and the tilt in degrees of the two halves of the sensor from the optical axis.
+const (
+	sensorX    = 1.22
+	sensorY    = 5.315
+	sensorTilt = 22.5
+)
+
+// Calibration are the parameters of a module mapping the pixels to angles, read from the
+// module by Connect. The left half of the sensor has its own K and B, the right half its own.
+type Calibration struct {
+	K0, B0 float64 // Left half.
+	K1, B1 float64 // Right half.
+	Bias   float64 // Tilt correction in degrees.
+}
+
+// parseCalibration decodes the answer to the get parameters command: K0, B0, K1 and B1 in
+// 1/10000 as unsigned 16 bit integers, then the bias in 0.1° as a signed byte.
+func parseCalibration(data []byte) (Calibration, error) {
+	if len(data) < 9 {
+		return Calibration{}, fmt.Errorf("%w: parameters expected 9 bytes got %v", ydlidar.ErrShortRead, len(data))
+	}
+	value := func(i int) float64 { return float64(binary.LittleEndian.Uint16(data[i:])) / 10000 }
+	return Calibration{
+		K0:   value(0),
+		B0:   value(2),
+		K1:   value(4),
+		B1:   value(6),
+		Bias: float64(int8(data[8])) / 10,
+	}, nil
+}
+
+// point returns the angle in degrees within [0, 360) and the distance from the laser in mm of
+// the sample of the pixel at the raw distance. The two halves of the sensor mirror each other
+// about the optical axis, 0°. A dropout keeps the distance 0.
+func (c Calibration) point(pixel int, raw float64) (angle, dist float64) {
+	side, k, b, u := 1.0, c.K0, c.B0, float64(frameWidth/2-pixel)
+	if pixel >= frameWidth/2 {
+		side, k, b, u = -1, c.K1, c.B1, float64(pixel-frameWidth/2)
+	}
+	measured := raw
+	if raw <= 0 {
+		measured = maxRange
+	}
+
+	// Angle of the ray of the pixel from the axis of its half, linear for the modules
+	// calibrated in degrees, B above 1, otherwise through the tangent.
+	theta := k*u - b
+	if b <= 1 {
+		theta = math.Atan(theta) * 180 / math.Pi
+	}
+	theta *= side
+	tilt := (sensorTilt + c.Bias) * side
+
+	d := (measured - sensorX) / math.Cos((tilt-theta)*math.Pi/180)
+	sinTilt, cosTilt := math.Sincos(tilt * math.Pi / 180)
+	sinTheta, cosTheta := math.Sincos(theta * math.Pi / 180)
+	x := cosTilt*d*cosTheta + sinTilt*d*sinTheta + sensorX
+	y := -sinTilt*d*cosTheta + cosTilt*d*sinTheta - side*sensorY
+
+	angle, dist = geom.Point{X: x, Y: y}.Polar()
+	if raw <= 0 {
+		dist = 0
+	}
+	return angle, dist
+}
@@ -0,0 +1,168 @@
+// Package gs drives the YDLidar GS series, the short range solid state lidars such as the
+// GS2. Unlike the spinning models a GS module takes its 160 samples at once, like a camera
+// frame, and up to three modules share a serial link, each answering at its own address.
+//
+// The package has its own command set but shares the rest with package ydlidar: the link is
+// a ydlidar.Transport, the frames go through the ydlidar.Filter stages and come out as
+// ydlidar.Scan, so the exporters and the analysis packages take them as they are.
+//
+//	lidar, err := gs.Open("/dev/ttyUSB0")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer lidar.Close()
+//	if err = lidar.StartScan(); err != nil {
+//		log.Fatal(err)
+//	}
+//	for frame := range lidar.Frames {
+//		fmt.Println(frame.Module, len(frame.Scan.Points))
+//	}
+package gs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"ydlidarg2/ydlidar"
+)
+
+// Command types, the type of the responses is that of their command.
+const (
+	cmdGetAddress    = 0x60
+	cmdGetParameters = 0x61
+	cmdGetVersion    = 0x62
+	cmdScan          = 0x63
+	cmdStop          = 0x64
+	cmdReset         = 0x67
+)
+
+// Module addresses. The modules of a chain take the addresses in order, a command to
+// broadcastAddress goes to all of them.
+const (
+	broadcastAddress = 0x00
+	Module1          = 0x01
+	Module2          = 0x02
+	Module3          = 0x04
+)
+
+// maxModules is the number of modules a link can chain.
+const maxModules = 3
+
+const (
+	// frameHeaderSize is the sync word, the address, the type and the data size.
+	frameHeaderSize = 8
+
+	// maxFrameData bounds the data size so a corrupted header doesn't stall the link.
+	maxFrameData = 1024
+)
+
+// syncWord starts every frame in both directions.
+var syncWord = [4]byte{0xA5, 0xA5, 0xA5, 0xA5}
+
+// frame is a command or response on the link: the sync word, the address of the module, the
+// type, the size of the data in little endian, the data and a checksum, the sum of the bytes
+// from the address to the end of the data.
+type frame struct {
+	address  byte
+	typeCode byte
+	data     []byte
+}
+
+// checksum returns the checksum of the frame.
+func (f frame) checksum() byte {
+	sum := f.address + f.typeCode + byte(len(f.data)) + byte(len(f.data)>>8)
+	for _, b := range f.data {
+		sum += b
+	}
+	return sum
+}
+
+// encode returns the frame as sent on the link.
+func (f frame) encode() []byte {
+	b := make([]byte, 0, frameHeaderSize+len(f.data)+1)
+	b = append(b, syncWord[:]...)
+	b = append(b, f.address, f.typeCode)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(f.data)))
+	b = append(b, f.data...)
+	return append(b, f.checksum())
+}
+
+// frameReader cuts the bytes read from the link into frames, skipping the bytes before a sync
+// word.
+type frameReader struct {
+	port ydlidar.Transport
+	buf  []byte
+	read []byte // Read buffer, reused.
+}
+
+func newFrameReader(port ydlidar.Transport) *frameReader {
+	return &frameReader{port: port, read: make([]byte, 1024)}
+}
+
+// next returns the next frame within the timeout. A frame failing its checksum returns
+// ydlidar.ErrChecksum and is skipped, the next call resynchronizes on the following sync word.
+func (r *frameReader) next(timeout time.Duration) (frame, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		f, ok, err := r.parse()
+		if ok || err != nil {
+			return f, err
+		}
+		if time.Now().After(deadline) {
+			return frame{}, fmt.Errorf("%w: no frame within %v", ydlidar.ErrTimeout, timeout)
+		}
+		n, err := r.port.Read(r.read)
+		if err != nil {
+			return frame{}, err
+		}
+		r.buf = append(r.buf, r.read[:n]...)
+	}
+}
+
+// parse takes a frame off the buffer, ok is false if it holds none in full yet.
+func (r *frameReader) parse() (f frame, ok bool, err error) {
+	start := indexSync(r.buf)
+	if start < 0 {
+		// Keep the bytes that could start a sync word.
+		if keep := len(syncWord) - 1; len(r.buf) > keep {
+			r.buf = append(r.buf[:0], r.buf[len(r.buf)-keep:]...)
+		}
+		return frame{}, false, nil
+	}
+	r.buf = r.buf[start:]
+	if len(r.buf) < frameHeaderSize {
+		return frame{}, false, nil
+	}
+	size := int(binary.LittleEndian.Uint16(r.buf[6:]))
+	if size > maxFrameData {
+		r.buf = r.buf[1:]
+		return frame{}, false, fmt.Errorf("%w: frame of %v bytes", ydlidar.ErrBadHeader, size)
+	}
+	if len(r.buf) < frameHeaderSize+size+1 {
+		return frame{}, false, nil
+	}
+	f = frame{address: r.buf[4], typeCode: r.buf[5], data: append([]byte(nil), r.buf[frameHeaderSize:frameHeaderSize+size]...)}
+	want := r.buf[frameHeaderSize+size]
+	if f.checksum() != want {
+		r.buf = r.buf[1:]
+		return frame{}, false, fmt.Errorf("%w: expected %02X got %02X", ydlidar.ErrChecksum, want, f.checksum())
+	}
+	r.buf = r.buf[frameHeaderSize+size+1:]
+	return f, true, nil
+}
+
+// reset drops the buffered bytes.
+func (r *frameReader) reset() {
+	r.buf = r.buf[:0]
+}
+
+// indexSync returns the index of the first sync word in b, -1 if none.
+func indexSync(b []byte) int {
+	for i := 0; i+len(syncWord) <= len(b); i++ {
+		if b[i] == syncWord[0] && b[i+1] == syncWord[1] && b[i+2] == syncWord[2] && b[i+3] == syncWord[3] {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,197 @@
+package gs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+// chain plays GS modules at the addresses: it answers the commands and sends a frame of every
+// module per read while scanning.
+type chain struct {
+	addresses []byte
+	dist      uint16 // Raw distance of the samples.
+
+	mu       sync.Mutex
+	output   bytes.Buffer
+	written  []frame
+	scanning bool
+	closed   bool
+}
+
+var parameters = []byte{0x2D, 0x00, 0x2C, 0x01, 0x2D, 0x00, 0x2C, 0x01, 0xFB} // K 0.0045, B 0.03, bias -0.5°.
+
+func (c *chain) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, ydlidar.ErrTimeout
+	}
+	if c.output.Len() == 0 && c.scanning {
+		for _, address := range c.addresses {
+			data := make([]byte, 2, 2+2*frameWidth)
+			binary.LittleEndian.PutUint16(data, 42)
+			for i := 0; i < frameWidth; i++ {
+				data = binary.LittleEndian.AppendUint16(data, 100<<9|c.dist)
+			}
+			c.output.Write(frame{address: address, typeCode: cmdScan, data: data}.encode())
+		}
+	}
+	if c.output.Len() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	return c.output.Read(b)
+}
+
+func (c *chain) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r := &frameReader{buf: append([]byte(nil), b...)}
+	f, ok, err := r.parse()
+	if !ok || err != nil {
+		return len(b), nil
+	}
+	c.written = append(c.written, f)
+	for _, address := range c.addresses {
+		if f.address != broadcastAddress && f.address != address {
+			continue
+		}
+		switch f.typeCode {
+		case cmdGetAddress:
+			c.output.Write(frame{address: address, typeCode: cmdGetAddress}.encode())
+		case cmdGetParameters:
+			c.output.Write(frame{address: address, typeCode: cmdGetParameters, data: parameters}.encode())
+		case cmdGetVersion:
+			version := []byte{2, 5, 1, 2, 0, 2, 1, 0, 3, 1, 4, 0, 0, 0, 0, 0, 0, 4, 2}
+			c.output.Write(frame{address: address, typeCode: cmdGetVersion, data: version}.encode())
+		case cmdScan:
+			c.scanning = true
+			c.output.Write(frame{address: address, typeCode: cmdScan}.encode())
+		case cmdStop:
+			c.scanning = false
+		}
+	}
+	return len(b), nil
+}
+
+func (c *chain) SetReadTimeout(time.Duration) error { return nil }
+
+func (c *chain) ResetInputBuffer() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.output.Reset()
+	return nil
+}
+
+func (c *chain) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func TestFrameEncoding(t *testing.T) {
+	encoded := frame{address: Module2, typeCode: cmdGetParameters, data: []byte{1, 2}}.encode()
+	assert.Equal(t, []byte{0xA5, 0xA5, 0xA5, 0xA5, 0x02, 0x61, 0x02, 0x00, 0x01, 0x02, 0x68}, encoded)
+
+	// Garbage before the frame is skipped, a corrupted frame reported and skipped.
+	corrupted := append([]byte(nil), encoded...)
+	corrupted[8] ^= 0xFF
+	r := &frameReader{buf: append(append([]byte{0x00, 0xA5, 0x13}, corrupted...), encoded...)}
+	_, _, err := r.parse()
+	assert.ErrorIs(t, err, ydlidar.ErrChecksum)
+	f, ok, err := r.parse()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, frame{address: Module2, typeCode: cmdGetParameters, data: []byte{1, 2}}, f)
+
+	r = &frameReader{buf: encoded[:len(encoded)-1]}
+	_, ok, err = r.parse()
+	assert.False(t, ok)
+	assert.NoError(t, err)
+}
+
+func TestCalibration(t *testing.T) {
+	c, err := parseCalibration(parameters)
+	require.NoError(t, err)
+	assert.Equal(t, Calibration{K0: 0.0045, B0: 0.03, K1: 0.0045, B1: 0.03, Bias: -0.5}, c)
+
+	_, err = parseCalibration(parameters[:4])
+	assert.ErrorIs(t, err, ydlidar.ErrShortRead)
+
+	// The halves mirror each other about the axis.
+	c = Calibration{K0: 0.0045, B0: 0.03, K1: 0.0045, B1: 0.03}
+	left, leftDist := c.point(0, 100)
+	right, rightDist := c.point(frameWidth, 100)
+	assert.InDelta(t, 360-left, right, 1e-9)
+	assert.InDelta(t, leftDist, rightDist, 1e-9)
+	assert.Greater(t, leftDist, 90.0)
+
+	// The angles sweep the field of view in order across the left half.
+	previous := math.Inf(1)
+	for pixel := 0; pixel < frameWidth/2; pixel++ {
+		angle, _ := c.point(pixel, 100)
+		assert.Less(t, angle, previous, pixel)
+		previous = angle
+	}
+
+	angle, dist := c.point(10, 0)
+	assert.Zero(t, dist, "dropout")
+	farAngle, _ := c.point(10, maxRange)
+	assert.Equal(t, farAngle, angle)
+}
+
+func TestConnect(t *testing.T) {
+	port := &chain{addresses: []byte{Module1, Module2}, dist: 120}
+	lidar, err := Connect(port)
+	require.NoError(t, err)
+	defer lidar.Close()
+
+	require.Len(t, lidar.Modules, 2)
+	assert.Equal(t, byte(Module2), lidar.Modules[1].Address)
+	assert.Equal(t, Calibration{K0: 0.0045, B0: 0.03, K1: 0.0045, B1: 0.03, Bias: -0.5}, lidar.Modules[0].Calibration)
+	assert.Equal(t, Version{Hardware: 2, FirmwareMajor: 1, FirmwareMinor: 5, Serial: "2021031400000042"}, lidar.Modules[0].Version)
+	assert.Equal(t, byte(cmdStop), port.written[0].typeCode, "a stale scan is stopped first")
+}
+
+func TestConnectNoModule(t *testing.T) {
+	_, err := Connect(&chain{})
+	assert.ErrorIs(t, err, ErrNoModule)
+}
+
+func TestScan(t *testing.T) {
+	port := &chain{addresses: []byte{Module1, Module2}, dist: 120}
+	dropped := 0
+	lidar, err := Connect(port, WithFrames(1), WithFilter(ydlidar.FilterFunc(func(packet *ydlidar.Packet) {
+		packet.Keep(func(i int) bool { return i%2 == 0 })
+		dropped += frameWidth / 2
+	})))
+	require.NoError(t, err)
+	require.NoError(t, lidar.StartScan())
+	assert.ErrorIs(t, lidar.StartScan(), ydlidar.ErrScanRunning)
+
+	seen := map[byte]uint64{}
+	for len(seen) < 2 || seen[Module1] < 2 {
+		frame := <-lidar.Frames
+		require.NoError(t, frame.Error)
+		seen[frame.Module] = frame.Scan.Seq
+		assert.Equal(t, uint16(42), frame.Ambient)
+		require.Len(t, frame.Scan.Points, frameWidth/2)
+		point := frame.Scan.Points[10]
+		assert.Equal(t, 100, point.Intensity)
+		assert.InDelta(t, 120, point.Dist, 10)
+		assert.True(t, frame.Scan.IsReturn(point.Dist))
+	}
+	require.NoError(t, lidar.StopScan())
+	require.NoError(t, lidar.StopScan(), "idempotent")
+	assert.NotZero(t, dropped)
+	assert.Equal(t, byte(cmdStop), port.written[len(port.written)-1].typeCode)
+	require.NoError(t, lidar.Close())
+}
@@ -0,0 +1,357 @@
+package gs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"go.bug.st/serial"
+	"ydlidarg2/ydlidar"
+)
+
+// ErrNoModule is returned by Connect when no module answered.
+var ErrNoModule = errors.New("gs: no module answered")
+
+// serialMode is the line setting of the GS2: 921600 baud, 8N1.
+var serialMode = serial.Mode{
+	BaudRate: 921600,
+	DataBits: 8,
+	Parity:   serial.NoParity,
+	StopBits: serial.OneStopBit,
+}
+
+const (
+	// responseTimeout is how long a module takes to answer a command.
+	responseTimeout = 300 * time.Millisecond
+
+	// frameTimeout is how long the scan loop waits for a frame before reporting it.
+	frameTimeout = time.Second
+
+	// readTimeout is the read timeout set on the transport.
+	readTimeout = 10 * time.Millisecond
+)
+
+// Version is the answer of a module to the get version command.
+type Version struct {
+	Hardware      byte
+	FirmwareMajor byte
+	FirmwareMinor byte
+	Serial        string
+}
+
+// parseVersion decodes the hardware version, the firmware minor and major versions and the
+// serial number of 16 digits.
+func parseVersion(data []byte) (Version, error) {
+	if len(data) < 19 {
+		return Version{}, fmt.Errorf("%w: version expected 19 bytes got %v", ydlidar.ErrShortRead, len(data))
+	}
+	var digits strings.Builder
+	for _, digit := range data[3:19] {
+		digits.WriteByte('0' + digit%10)
+	}
+	return Version{Hardware: data[0], FirmwareMinor: data[1], FirmwareMajor: data[2], Serial: digits.String()}, nil
+}
+
+// Module is a module on the link.
+type Module struct {
+	Address     byte // Module1, Module2 or Module3.
+	Calibration Calibration
+	Version     Version
+}
+
+// Frame is a frame of a module, the 160 samples of its field of view taken at once.
+type Frame struct {
+	Module  byte         // Address of the module.
+	Ambient uint16       // Ambient light level reported with the frame.
+	Scan    ydlidar.Scan // The samples as a scan, numbered per module, Start and End at the arrival.
+	Error   error        // Error if the frame couldn't be read, the other fields are then unset.
+}
+
+// Option configures a Lidar.
+type Option func(*Lidar)
+
+// WithFilter adds a filter stage run on every frame, as a ydlidar.Packet, before it is
+// delivered.
+func WithFilter(filter ydlidar.Filter) Option {
+	return func(l *Lidar) {
+		l.filters = append(l.filters, filter)
+	}
+}
+
+// WithFrames sets the size of the Frames channel, 16 by default.
+func WithFrames(buffer int) Option {
+	return func(l *Lidar) {
+		l.Frames = make(chan Frame, buffer)
+	}
+}
+
+// Lidar is a chain of GS modules on a link.
+type Lidar struct {
+	Modules []Module   // Modules found by Connect, in address order.
+	Frames  chan Frame // Frames of all the modules while scanning.
+
+	port    ydlidar.Transport
+	reader  *frameReader
+	filters []ydlidar.Filter
+	seq     map[byte]uint64 // Frames per module.
+
+	mu   sync.Mutex
+	stop chan struct{} // Closed to stop the scan loop, nil when not scanning.
+	done chan struct{} // Closed when the scan loop exited.
+}
+
+// Open opens the serial port of the lidar and connects to it.
+func Open(name string, opts ...Option) (*Lidar, error) {
+	port, err := serial.Open(name, &serialMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %v: %w", name, err)
+	}
+	lidar, err := Connect(port, opts...)
+	if err != nil {
+		port.Close()
+		return nil, err
+	}
+	return lidar, nil
+}
+
+// Connect stops a scan left running, finds the modules on the link and reads their
+// calibration and version. ErrNoModule is returned if none answered.
+func Connect(port ydlidar.Transport, opts ...Option) (*Lidar, error) {
+	lidar := &Lidar{
+		Frames: make(chan Frame, 16),
+		port:   port,
+		reader: newFrameReader(port),
+		seq:    make(map[byte]uint64),
+	}
+	for _, opt := range opts {
+		opt(lidar)
+	}
+	if err := port.SetReadTimeout(readTimeout); err != nil {
+		return nil, err
+	}
+	if err := lidar.halt(); err != nil {
+		return nil, err
+	}
+
+	addresses, err := lidar.addresses()
+	if err != nil {
+		return nil, err
+	}
+	for _, address := range addresses {
+		module := Module{Address: address}
+		data, err := lidar.query(address, cmdGetParameters)
+		if err != nil {
+			return nil, fmt.Errorf("module %v parameters: %w", address, err)
+		}
+		if module.Calibration, err = parseCalibration(data); err != nil {
+			return nil, fmt.Errorf("module %v parameters: %w", address, err)
+		}
+		if data, err = lidar.query(address, cmdGetVersion); err != nil {
+			return nil, fmt.Errorf("module %v version: %w", address, err)
+		}
+		if module.Version, err = parseVersion(data); err != nil {
+			return nil, fmt.Errorf("module %v version: %w", address, err)
+		}
+		lidar.Modules = append(lidar.Modules, module)
+	}
+	return lidar, nil
+}
+
+// send writes a command.
+func (l *Lidar) send(address, typeCode byte, data []byte) error {
+	_, err := l.port.Write(frame{address: address, typeCode: typeCode, data: data}.encode())
+	return err
+}
+
+// halt stops the modules and drops what they sent.
+func (l *Lidar) halt() error {
+	if err := l.send(broadcastAddress, cmdStop, nil); err != nil {
+		return err
+	}
+	time.Sleep(readTimeout)
+	if resetter, ok := l.port.(ydlidar.InputResetter); ok {
+		if err := resetter.ResetInputBuffer(); err != nil {
+			return err
+		}
+	}
+	l.reader.reset()
+	return nil
+}
+
+// addresses broadcasts the get address command and collects the answers until a module
+// stays silent for the response timeout.
+func (l *Lidar) addresses() ([]byte, error) {
+	if err := l.send(broadcastAddress, cmdGetAddress, nil); err != nil {
+		return nil, err
+	}
+	var addresses []byte
+	for len(addresses) < maxModules {
+		f, err := l.reader.next(responseTimeout)
+		if err != nil {
+			if ydlidar.IsTransient(err) {
+				continue
+			}
+			break
+		}
+		if f.typeCode == cmdGetAddress {
+			addresses = append(addresses, f.address)
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, ErrNoModule
+	}
+	return addresses, nil
+}
+
+// query sends a command to a module and returns the data of its answer.
+func (l *Lidar) query(address, typeCode byte) ([]byte, error) {
+	if err := l.send(address, typeCode, nil); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(responseTimeout)
+	for {
+		f, err := l.reader.next(time.Until(deadline))
+		if err != nil {
+			return nil, err
+		}
+		if f.address == address && f.typeCode == typeCode {
+			return f.data, nil
+		}
+	}
+}
+
+// StartScan starts the modules and reads their frames on a new goroutine, sent on Frames.
+func (l *Lidar) StartScan() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop != nil {
+		return ydlidar.ErrScanRunning
+	}
+	if err := l.send(broadcastAddress, cmdScan, nil); err != nil {
+		return fmt.Errorf("failed to start scan: %w", err)
+	}
+	l.stop, l.done = make(chan struct{}), make(chan struct{})
+	go l.scanLoop(l.stop, l.done)
+	return nil
+}
+
+// StopScan stops the scan loop and the modules. Safe to call without a running scan.
+func (l *Lidar) StopScan() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop == nil {
+		return nil
+	}
+	close(l.stop)
+	<-l.done
+	l.stop, l.done = nil, nil
+	return l.halt()
+}
+
+// Close stops scanning and closes the port.
+func (l *Lidar) Close() error {
+	stopErr := l.StopScan()
+	if err := l.port.Close(); err != nil {
+		return err
+	}
+	return stopErr
+}
+
+// scanLoop reads the frames until stop is closed. A link silent for frameTimeout is reported
+// with ydlidar.ErrTimeout.
+func (l *Lidar) scanLoop(stop, done chan struct{}) {
+	defer close(done)
+	last := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		// Short reads keep the loop responsive to stop.
+		f, err := l.reader.next(readTimeout)
+		switch {
+		case errors.Is(err, ydlidar.ErrTimeout):
+			if time.Since(last) < frameTimeout {
+				continue
+			}
+			last = time.Now()
+			err = fmt.Errorf("%w: no frame within %v", ydlidar.ErrTimeout, frameTimeout)
+		case err == nil:
+			last = time.Now()
+		}
+		if err != nil {
+			log.Printf("GS frame: %v", err)
+			if !l.deliver(stop, Frame{Error: err}) {
+				return
+			}
+			continue
+		}
+		// The modules acknowledge the scan command with an empty frame.
+		if f.typeCode != cmdScan || len(f.data) != 2+2*frameWidth {
+			continue
+		}
+		if !l.deliver(stop, l.decode(f, last)) {
+			return
+		}
+	}
+}
+
+// deliver sends the frame, false if the scan was stopped first.
+func (l *Lidar) deliver(stop chan struct{}, frame Frame) bool {
+	select {
+	case l.Frames <- frame:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// decode converts a scan frame of a module: the ambient light, then a sample of 16 bits per
+// pixel, the distance in mm in the low 9 bits and the quality in the high 7 bits.
+func (l *Lidar) decode(f frame, received time.Time) Frame {
+	calibration := l.calibration(f.address)
+	packet := ydlidar.Packet{
+		NumDistanceSamples: frameWidth,
+		Angles:             make([]float64, frameWidth),
+		Distances:          make([]float64, frameWidth),
+		Intensities:        make([]int, frameWidth),
+		Units:              ydlidar.Millimeters,
+		Received:           received,
+	}
+	for i := 0; i < frameWidth; i++ {
+		sample := binary.LittleEndian.Uint16(f.data[2+2*i:])
+		packet.Angles[i], packet.Distances[i] = calibration.point(i, float64(sample&0x01FF))
+		packet.Intensities[i] = int(sample >> 9)
+	}
+	for _, filter := range l.filters {
+		filter.Filter(&packet)
+	}
+
+	l.seq[f.address]++
+	return Frame{
+		Module:  f.address,
+		Ambient: binary.LittleEndian.Uint16(f.data),
+		Scan: ydlidar.Scan{
+			Seq:    l.seq[f.address],
+			Points: ydlidar.GetPointCloud(packet),
+			Start:  received,
+			End:    received,
+			Units:  ydlidar.Millimeters,
+		},
+	}
+}
+
+// calibration returns the calibration of the module, the zero value if unknown.
+func (l *Lidar) calibration(address byte) Calibration {
+	for _, module := range l.Modules {
+		if module.Address == address {
+			return module.Calibration
+		}
+	}
+	return Calibration{}
+}
@@ -0,0 +1,119 @@
+// Package infer runs a machine learning model over assembled scans to classify the points,
+// eg. to filter out returns from glass. The package doesn't bind to an inference runtime:
+// wrap an ONNX runtime session (or any other) in a Model and plug the Classifier into the
+// lidar with ydlidar.WithScanProcessor.
+package infer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Model runs inference on a feature tensor and returns the output tensor, flattened row major.
+type Model interface {
+	Run(features []float32, shape []int64) ([]float32, error)
+}
+
+var (
+	// ErrNoModel is returned by ProcessScan when the Classifier has no Model.
+	ErrNoModel = errors.New("infer: classifier without a model")
+
+	// ErrNoClasses is returned by ProcessScan when the Classifier has no Classes.
+	ErrNoClasses = errors.New("infer: classifier without classes")
+)
+
+// Classifier builds a range/intensity image of the scan, runs the model over it and labels
+// every point with the class of its angular bin.
+//
+// The input tensor has shape [1, 2, Bins]: normalized ranges then normalized intensities,
+// bin i covering angles [i*360/Bins, (i+1)*360/Bins). The output must hold len(Classes)
+// scores per bin, shape [1, Bins, len(Classes)].
+type Classifier struct {
+	Model        Model
+	Classes      []string // Class names in the order of the model output.
+	Bins         int      // Angular resolution of the image, 360 if zero.
+	MaxRange     float64  // Distance mapped to 1 in millimeters, 12000 if zero.
+	MaxIntensity float64  // Intensity mapped to 1, 1023 if zero.
+}
+
+// ProcessScan labels the points of the scan, implementing ydlidar.ScanProcessor. It returns
+// ErrNoModel or ErrNoClasses if the classifier lacks them.
+func (c *Classifier) ProcessScan(scan *ydlidar.Scan) error {
+	if c.Model == nil {
+		return ErrNoModel
+	}
+	if len(c.Classes) == 0 {
+		return ErrNoClasses
+	}
+	bins := c.bins()
+	features := c.Features(*scan)
+
+	output, err := c.Model.Run(features, []int64{1, 2, int64(bins)})
+	if err != nil {
+		return err
+	}
+	if len(output) != bins*len(c.Classes) {
+		return fmt.Errorf("model output has %v values, expected %v", len(output), bins*len(c.Classes))
+	}
+
+	binClass := make([]string, bins)
+	for bin := range binClass {
+		scores := output[bin*len(c.Classes) : (bin+1)*len(c.Classes)]
+		best := 0
+		for class, score := range scores {
+			if score > scores[best] {
+				best = class
+			}
+		}
+		binClass[bin] = c.Classes[best]
+	}
+
+	scan.Labels = make([]string, len(scan.Points))
+	for i, point := range scan.Points {
+		scan.Labels[i] = binClass[binOf(point.Angle, bins)]
+	}
+	return nil
+}
+
+// Features returns the range/intensity image of the scan fed to the model. When several
+// points fall in the same bin the closest one is kept, empty bins are 0.
+func (c *Classifier) Features(scan ydlidar.Scan) []float32 {
+	bins := c.bins()
+	maxRange, maxIntensity := c.MaxRange, c.MaxIntensity
+	if maxRange == 0 {
+		maxRange = 12000
+	}
+	if maxIntensity == 0 {
+		maxIntensity = 1023
+	}
+
+	features := make([]float32, 2*bins)
+	for _, point := range scan.Points {
+		if !scan.IsReturn(point.Dist) {
+			continue
+		}
+		bin := binOf(point.Angle, bins)
+		dist := float32(math.Min(scan.Units.ToMillimeters(point.Dist)/maxRange, 1))
+		if features[bin] == 0 || dist < features[bin] {
+			features[bin] = dist
+			features[bins+bin] = float32(math.Min(float64(point.Intensity)/maxIntensity, 1))
+		}
+	}
+	return features
+}
+
+func (c *Classifier) bins() int {
+	if c.Bins <= 0 {
+		return 360
+	}
+	return c.Bins
+}
+
+// binOf returns the angular bin of an angle in degrees.
+func binOf(angle float64, bins int) int {
+	return int(geom.NormalizeAngle(angle)/360*float64(bins)) % bins
+}
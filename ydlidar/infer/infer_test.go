@@ -0,0 +1,56 @@
+package infer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+// nearModel scores "near" for every bin with a range under 0.5, "far" otherwise.
+type nearModel struct{}
+
+func (nearModel) Run(features []float32, shape []int64) ([]float32, error) {
+	bins := int(shape[2])
+	output := make([]float32, 0, bins*2)
+	for _, r := range features[:bins] {
+		if r > 0 && r < 0.5 {
+			output = append(output, 1, 0)
+		} else {
+			output = append(output, 0, 1)
+		}
+	}
+	return output, nil
+}
+
+func TestClassifierLabelsPoints(t *testing.T) {
+	c := &Classifier{Model: nearModel{}, Classes: []string{"near", "far"}, Bins: 4}
+	scan := &ydlidar.Scan{Points: []ydlidar.PointCloudData{
+		{Angle: 10, Dist: 1000},
+		{Angle: 100, Dist: 11000},
+		{Angle: 359, Dist: 2000},
+	}}
+
+	require.NoError(t, c.ProcessScan(scan))
+	assert.Equal(t, []string{"near", "far", "near"}, scan.Labels)
+}
+
+func TestClassifierIncomplete(t *testing.T) {
+	scan := &ydlidar.Scan{Points: []ydlidar.PointCloudData{{Angle: 10, Dist: 1000}}}
+
+	c := &Classifier{Classes: []string{"near", "far"}}
+	assert.ErrorIs(t, c.ProcessScan(scan), ErrNoModel)
+
+	// A model scoring no class for any bin matches the empty Classes.
+	c = &Classifier{Model: emptyModel{}}
+	assert.ErrorIs(t, c.ProcessScan(scan), ErrNoClasses)
+	assert.Nil(t, scan.Labels)
+}
+
+// emptyModel returns no scores.
+type emptyModel struct{}
+
+func (emptyModel) Run([]float32, []int64) ([]float32, error) {
+	return nil, nil
+}
@@ -0,0 +1,125 @@
+package ydlidar
+
+import (
+	"sync"
+	"time"
+)
+
+// Accumulator keeps the points of the last revolutions, for trails in a view or a denser
+// cloud for mapping. It is a ScanProcessor, eg. given to WithScanProcessor, and is safe to
+// read with Snapshot from other goroutines while it is fed.
+type Accumulator struct {
+	window      time.Duration
+	revolutions int
+	now         func() time.Time
+
+	mu    sync.Mutex
+	ring  []accumulated // Revolutions kept, oldest first from head.
+	head  int
+	count int
+}
+
+// accumulated is a revolution kept by the Accumulator.
+type accumulated struct {
+	at     time.Time
+	points []PointCloudData
+}
+
+// NewAccumulator returns an accumulator keeping the revolutions of the last window, and at
+// most the last revolutions. Either can be 0 for no limit, not both.
+func NewAccumulator(window time.Duration, revolutions int) *Accumulator {
+	if window <= 0 && revolutions <= 0 {
+		revolutions = 1
+	}
+	return &Accumulator{window: window, revolutions: revolutions, now: time.Now}
+}
+
+// ProcessScan adds the revolution, see Add.
+func (a *Accumulator) ProcessScan(scan *Scan) error {
+	a.Add(*scan)
+	return nil
+}
+
+// Add keeps the points of the revolution, dropping the oldest revolution once the limit is
+// reached. The points are copied and can be modified afterwards.
+func (a *Accumulator) Add(scan Scan) {
+	at := scan.End
+	if at.IsZero() {
+		at = a.now()
+	}
+	entry := accumulated{at: at, points: append([]PointCloudData(nil), scan.Points...)}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expire()
+	if a.revolutions > 0 && a.count == a.revolutions {
+		a.ring[a.head] = accumulated{}
+		a.head = (a.head + 1) % len(a.ring)
+		a.count--
+	}
+	if a.count == len(a.ring) {
+		a.grow()
+	}
+	a.ring[(a.head+a.count)%len(a.ring)] = entry
+	a.count++
+}
+
+// grow doubles the ring, keeping the revolutions in order.
+func (a *Accumulator) grow() {
+	size := 2 * len(a.ring)
+	if size == 0 {
+		size = 4
+	}
+	if a.revolutions > 0 && size > a.revolutions {
+		size = a.revolutions
+	}
+	ring := make([]accumulated, size)
+	for i := 0; i < a.count; i++ {
+		ring[i] = a.ring[(a.head+i)%len(a.ring)]
+	}
+	a.ring, a.head = ring, 0
+}
+
+// expire drops the revolutions older than the window.
+func (a *Accumulator) expire() {
+	if a.window <= 0 {
+		return
+	}
+	oldest := a.now().Add(-a.window)
+	for a.count > 0 && a.ring[a.head].at.Before(oldest) {
+		a.ring[a.head] = accumulated{}
+		a.head = (a.head + 1) % len(a.ring)
+		a.count--
+	}
+}
+
+// Snapshot returns a copy of the points kept, oldest revolution first.
+func (a *Accumulator) Snapshot() []PointCloudData {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expire()
+	n := 0
+	for i := 0; i < a.count; i++ {
+		n += len(a.ring[(a.head+i)%len(a.ring)].points)
+	}
+	points := make([]PointCloudData, 0, n)
+	for i := 0; i < a.count; i++ {
+		points = append(points, a.ring[(a.head+i)%len(a.ring)].points...)
+	}
+	return points
+}
+
+// Len returns the number of revolutions kept.
+func (a *Accumulator) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expire()
+	return a.count
+}
+
+// Reset drops every revolution kept.
+func (a *Accumulator) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.ring, a.head, a.count = nil, 0, 0
+}
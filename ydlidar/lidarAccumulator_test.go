@@ -0,0 +1,88 @@
+package ydlidar
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func accumulatorScan(at time.Time, angles ...float64) Scan {
+	scan := Scan{End: at}
+	for _, angle := range angles {
+		scan.Points = append(scan.Points, PointCloudData{Angle: angle, Dist: 1000})
+	}
+	return scan
+}
+
+func angles(points []PointCloudData) []float64 {
+	var out []float64
+	for _, point := range points {
+		out = append(out, point.Angle)
+	}
+	return out
+}
+
+func TestAccumulatorRevolutions(t *testing.T) {
+	a := NewAccumulator(0, 3)
+	t0 := time.Now()
+	for i := 0; i < 5; i++ {
+		a.Add(accumulatorScan(t0, float64(i), float64(i)+0.5))
+	}
+	assert.Equal(t, 3, a.Len())
+	assert.Equal(t, []float64{2, 2.5, 3, 3.5, 4, 4.5}, angles(a.Snapshot()))
+
+	a.Reset()
+	assert.Empty(t, a.Snapshot())
+}
+
+func TestAccumulatorWindow(t *testing.T) {
+	a := NewAccumulator(time.Second, 0)
+	t0 := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	now := t0
+	a.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		now = t0.Add(time.Duration(i) * 200 * time.Millisecond)
+		a.Add(accumulatorScan(now, float64(i)))
+	}
+	// At 1.8s the revolutions from 0.8s are kept.
+	assert.Equal(t, []float64{4, 5, 6, 7, 8, 9}, angles(a.Snapshot()))
+
+	// Without new revolutions the trail fades.
+	now = now.Add(900 * time.Millisecond)
+	assert.Equal(t, []float64{9}, angles(a.Snapshot()))
+	now = now.Add(200 * time.Millisecond)
+	assert.Equal(t, 0, a.Len())
+}
+
+func TestAccumulatorCopies(t *testing.T) {
+	a := NewAccumulator(0, 1)
+	scan := accumulatorScan(time.Now(), 1)
+	assert.NoError(t, a.ProcessScan(&scan))
+	scan.Points[0].Angle = 2
+	snapshot := a.Snapshot()
+	snapshot[0].Dist = 0
+	assert.Equal(t, []PointCloudData{{Angle: 1, Dist: 1000}}, a.Snapshot())
+}
+
+func TestAccumulatorConcurrent(t *testing.T) {
+	a := NewAccumulator(time.Minute, 50)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			a.Add(accumulatorScan(time.Now(), float64(i)))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			assert.LessOrEqual(t, len(a.Snapshot()), 50)
+		}
+	}()
+	wg.Wait()
+	assert.Equal(t, 50, a.Len())
+}
@@ -0,0 +1,22 @@
+package ydlidar
+
+import (
+	"fmt"
+)
+
+// SetAngleRange restricts the output to the samples from min to max degrees, eg. the half
+// facing forward. A range with min > max wraps through 0°, one covering 360° or more keeps
+// every sample. The samples are masked in the driver as if by WithAngleMask, the device still
+// sends them all: none of the supported models documents a command restricting the range.
+// It applies immediately, even while scanning.
+func (lidar *YDLidar) SetAngleRange(min, max float64) error {
+	if min < -360 || min > 360 || max < -360 || max > 360 {
+		return fmt.Errorf("angle range %v° to %v° out of bounds", min, max)
+	}
+	if max-min >= 360 {
+		lidar.angleRange.Store(nil)
+		return nil
+	}
+	lidar.angleRange.Store(&angleMask{from: min, to: max})
+	return nil
+}
@@ -0,0 +1,54 @@
+package ydlidar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func angleRangePacket() Packet {
+	packet := Packet{
+		Angles:      []float64{0, 90, 180, 270, 350},
+		Distances:   []float64{1000, 1000, 1000, 1000, 1000},
+		Intensities: []int{1, 2, 3, 4, 5},
+	}
+	packet.NumDistanceSamples = len(packet.Distances)
+	return packet
+}
+
+func TestSetAngleRangeDriver(t *testing.T) {
+	lidar := NewLidar(&fakePort{})
+	lidar.model = 15
+
+	require.NoError(t, lidar.SetAngleRange(80, 190))
+	packet := angleRangePacket()
+	lidar.applyFilters(&packet)
+	assert.Equal(t, []float64{90, 180}, packet.Angles)
+	assert.Equal(t, []int{2, 3}, packet.Intensities)
+
+	// Wrapping through 0°.
+	require.NoError(t, lidar.SetAngleRange(-20, 10))
+	packet = angleRangePacket()
+	lidar.applyFilters(&packet)
+	assert.Equal(t, []float64{0, 350}, packet.Angles)
+
+	require.NoError(t, lidar.SetAngleRange(0, 360))
+	packet = angleRangePacket()
+	lidar.applyFilters(&packet)
+	assert.Len(t, packet.Angles, 5)
+
+	assert.Error(t, lidar.SetAngleRange(0, 720))
+}
+
+func TestSetAngleRangeScanning(t *testing.T) {
+	// The range is masked in the driver, so it changes while scanning.
+	lidar := NewLidar(&fakePort{})
+	lidar.model = 15
+	lidar.setState(stateScanning)
+
+	require.NoError(t, lidar.SetAngleRange(80, 190))
+	packet := angleRangePacket()
+	lidar.applyFilters(&packet)
+	assert.Equal(t, []float64{90, 180}, packet.Angles)
+}
@@ -0,0 +1,137 @@
+package ydlidar
+
+import (
+	"log"
+	"time"
+)
+
+// PartialScanPolicy decides what happens to the unfinished revolution when the scan is stopped.
+type PartialScanPolicy int
+
+const (
+	// DiscardPartialScan drops the unfinished revolution. This is the default.
+	DiscardPartialScan PartialScanPolicy = iota
+
+	// FlushPartialScan sends the unfinished revolution on the Scans channel flagged Partial.
+	FlushPartialScan
+)
+
+// scanAssembler collects the packets of a revolution. Points received before the
+// first zero packet belong to an incomplete revolution and are ignored.
+type scanAssembler struct {
+	units     Unit
+	order     ScanOrder
+	current   Scan
+	started   bool
+	seq       uint64
+	checksums checksumCounter
+}
+
+// startRevolution completes the current revolution and starts a new one.
+// Returns the completed revolution, if there was one.
+func (a *scanAssembler) startRevolution() (Scan, bool) {
+	now := time.Now()
+	completed, ok := a.current, a.started && len(a.current.Points) > 0
+	failures := a.checksums.since()
+	if ok {
+		completed.Frequency = 1 / now.Sub(completed.Start).Seconds()
+		completed.Points = normalizeAngles(completed.Points, a.order)
+		completed.Stats = newScanStats(completed.Points)
+		completed.Stats.ChecksumFailures = failures
+	}
+
+	a.seq++
+	a.started = true
+	a.current = Scan{Seq: a.seq, Start: now, Units: a.units}
+
+	return completed, ok
+}
+
+// add appends the points of the packet to the current revolution.
+func (a *scanAssembler) add(packet Packet) {
+	if !a.started || packet.Error != nil {
+		return
+	}
+	a.current.Points = append(a.current.Points, GetPointCloud(packet)...)
+	a.current.End = time.Now()
+}
+
+// partial returns the unfinished revolution flagged Partial, if it has any points.
+func (a *scanAssembler) partial() (Scan, bool) {
+	scan := a.current
+	scan.Partial = true
+	scan.Points = normalizeAngles(scan.Points, a.order)
+	scan.Stats = newScanStats(scan.Points)
+	scan.Stats.ChecksumFailures = a.checksums.since()
+	return scan, a.started && len(scan.Points) > 0
+}
+
+// ScanProcessor is a stage run on every assembled revolution, eg. a classifier labeling the points.
+type ScanProcessor interface {
+	ProcessScan(scan *Scan) error
+}
+
+// processScan runs the scan processors. A failing processor is logged and the scan delivered as is.
+func (lidar *YDLidar) processScan(scan *Scan) {
+	for _, processor := range lidar.processors {
+		if err := processor.ProcessScan(scan); err != nil {
+			log.Printf("Scan processor failed on revolution #%v: %v", scan.Seq, err)
+		}
+	}
+}
+
+// assembling reports whether the packets are assembled into revolutions, for the Scans
+// channel, the scan subscribers or LatestScan.
+func (lidar *YDLidar) assembling() bool {
+	return lidar.Scans != nil || lidar.scanSubscribers.active() || lidar.latest.enabled
+}
+
+// sendScan delivers the revolution on the Scans channel, to the subscribers and to LatestScan.
+// Returns false if the scan was stopped before it could be delivered.
+func (lidar *YDLidar) sendScan(scan Scan) bool {
+	if !lidar.assembling() {
+		return true
+	}
+	lidar.processScan(&scan)
+	lidar.markInvalidScan(&scan)
+	lidar.latest.publish(&scan)
+	if !lidar.scanSubscribers.publish(scan, lidar.Stop) {
+		return false
+	}
+	if lidar.Scans == nil {
+		return true
+	}
+	select {
+	case lidar.Scans <- scan:
+		return true
+	case <-lidar.Stop:
+		return false
+	}
+}
+
+// flushPartialScan applies the partial scan policy once the scan loop exits. The revolution is
+// only delivered if the Scans channel has room, stopping never waits on the consumer.
+func (lidar *YDLidar) flushPartialScan(a *scanAssembler) {
+	if (lidar.Scans == nil && !lidar.scanSubscribers.active()) || lidar.partial != FlushPartialScan {
+		return
+	}
+	scan, ok := a.partial()
+	if !ok {
+		return
+	}
+	lidar.processScan(&scan)
+	lidar.markInvalidScan(&scan)
+	// Blocking subscribers are only served if they have room, like the channel.
+	stopped := make(chan struct{})
+	close(stopped)
+	lidar.scanSubscribers.publish(scan, stopped)
+	if lidar.Scans == nil {
+		return
+	}
+	select {
+	case lidar.Scans <- scan:
+	default:
+		lidar.metrics.dropped.Add(1)
+		log.Printf("Scans channel full, dropping partial revolution #%v", scan.Seq)
+	}
+}
@@ -0,0 +1,40 @@
+package ydlidar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPacket(angles ...float64) Packet {
+	packet := Packet{Angles: angles}
+	for range angles {
+		packet.Distances = append(packet.Distances, 1000)
+		packet.Intensities = append(packet.Intensities, 100)
+	}
+	return packet
+}
+
+func TestAssemblerRevolutions(t *testing.T) {
+	a := &scanAssembler{}
+
+	// Points before the first zero packet are dropped.
+	a.add(testPacket(350, 355))
+	_, ok := a.startRevolution()
+	assert.False(t, ok)
+
+	a.add(testPacket(1, 2))
+	a.add(testPacket(3))
+	scan, ok := a.startRevolution()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), scan.Seq)
+	assert.Len(t, scan.Points, 3)
+	assert.False(t, scan.Partial)
+
+	a.add(testPacket(4))
+	scan, ok = a.partial()
+	assert.True(t, ok)
+	assert.True(t, scan.Partial)
+	assert.Equal(t, uint64(2), scan.Seq)
+	assert.Len(t, scan.Points, 1)
+}
@@ -0,0 +1,101 @@
+package ydlidar
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"ydlidarg2/ydlidar/protocol"
+)
+
+// responseHeader is the start sign of a command response, 0xA5 0x5A on the wire.
+const responseHeader = protocol.ResponseSign
+
+// responseHeaderSize is the size of a command response header.
+const responseHeaderSize = protocol.ResponseHeaderSize
+
+// AuxKind identifies the kind of AuxEvent.
+type AuxKind int
+
+const (
+	// AuxHealth a health response arrived in the scan stream, see AuxEvent.Health.
+	AuxHealth AuxKind = iota
+
+	// AuxDeviceInfo a device info response arrived in the scan stream, see AuxEvent.DeviceInfo.
+	AuxDeviceInfo
+
+	// AuxResponse any other command response, see AuxEvent.TypeCode and AuxEvent.Payload.
+	AuxResponse
+)
+
+// String returns the name of the kind.
+func (k AuxKind) String() string {
+	switch k {
+	case AuxHealth:
+		return "Health"
+	case AuxDeviceInfo:
+		return "DeviceInfo"
+	case AuxResponse:
+		return "Response"
+	}
+	return fmt.Sprintf("AuxKind(%d)", int(k))
+}
+
+// AuxEvent is a decoded non-scan packet found in the scan stream, eg. a notification some
+// firmwares send while scanning or the answer to a command sent mid-scan.
+type AuxEvent struct {
+	Kind       AuxKind
+	TypeCode   byte          // Type code of the response header.
+	Payload    []byte        // Raw payload following the response header.
+	Health     *HealthStatus // Set for AuxHealth.
+	DeviceInfo *DeviceInfo   // Set for AuxDeviceInfo.
+	Time       time.Time
+}
+
+// RawPacket is a packet the scan loop couldn't make sense of, with the bytes preserved for debugging.
+type RawPacket struct {
+	Bytes  []byte
+	Reason string
+	Time   time.Time
+}
+
+// handleResponse decodes a command response found in the scan stream, its header and its
+// payload.
+func (lidar *YDLidar) handleResponse(header, payload []byte) {
+	lidar.link.frames.Add(1)
+	size := len(payload)
+	event := AuxEvent{Kind: AuxResponse, TypeCode: header[6], Payload: append([]byte(nil), payload...), Time: time.Now()}
+	switch {
+	case event.TypeCode == HealthTypeCode && size >= 3:
+		status := newHealthStatus(event.Payload)
+		status.Time = event.Time
+		event.Kind, event.Health = AuxHealth, &status
+	case event.TypeCode == InfoTypeCode && size >= 20:
+		event.Kind, event.DeviceInfo = AuxDeviceInfo, newDeviceInfo(event.Payload)
+	}
+	lidar.emitAux(event)
+}
+
+// emitAux sends the event on the Aux channel, dropping it if the channel is full or disabled.
+func (lidar *YDLidar) emitAux(event AuxEvent) {
+	log.Printf("Auxiliary packet: %v, type code %X", event.Kind, event.TypeCode)
+	select {
+	case lidar.Aux <- event:
+	default:
+		if lidar.Aux != nil {
+			lidar.metrics.dropped.Add(1)
+		}
+	}
+}
+
+// emitDiagnostic sends a copy of the raw bytes on the Diagnostics channel, dropping them if the
+// channel is full or disabled.
+func (lidar *YDLidar) emitDiagnostic(raw []byte, reason string) {
+	select {
+	case lidar.Diagnostics <- RawPacket{Bytes: append([]byte(nil), raw...), Reason: reason, Time: time.Now()}:
+	default:
+		if lidar.Diagnostics != nil {
+			lidar.metrics.dropped.Add(1)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package ydlidar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuxPackets(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port, WithAuxPackets(4))
+
+	port.queue(scanResponseHeader...)
+	// A health response with a warning, then 10 bytes of noise.
+	port.queue(0xA5, 0x5A, 0x03, 0x00, 0x00, 0x00, HealthTypeCode, 0x01, 0x02, 0x80)
+	port.queue(0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A)
+	require.NoError(t, lidar.StartScan())
+	defer lidar.StopScan()
+
+	select {
+	case event := <-lidar.Aux:
+		assert.Equal(t, AuxHealth, event.Kind)
+		require.NotNil(t, event.Health)
+		assert.Equal(t, SeverityWarning, event.Health.Severity)
+		assert.Equal(t, uint16(0x8002), event.Health.Code)
+	case <-time.After(time.Second):
+		t.Fatal("no aux event")
+	}
+
+	select {
+	case raw := <-lidar.Diagnostics:
+		assert.Equal(t, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A}, raw.Bytes)
+	case <-time.After(time.Second):
+		t.Fatal("no diagnostic")
+	}
+}
@@ -0,0 +1,69 @@
+package ydlidar
+
+// OverflowPolicy decides what happens to a packet when the Packets channel is full.
+type OverflowPolicy int
+
+const (
+	// Block waits for the consumer. A stalled consumer stalls the serial reads and the
+	// stream corrupts once the OS buffer overflows.
+	Block OverflowPolicy = iota
+
+	// DropOldest discards the oldest buffered packet to make room, the consumer always
+	// gets the most recent data.
+	DropOldest
+
+	// DropNewest discards the packet that doesn't fit, the consumer gets the buffered
+	// data in order with a gap.
+	DropNewest
+)
+
+// String returns the name of the policy.
+func (p OverflowPolicy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case DropOldest:
+		return "drop-oldest"
+	case DropNewest:
+		return "drop-newest"
+	}
+	return "unknown"
+}
+
+// DroppedPackets returns the number of packets discarded by the overflow policy.
+func (lidar *YDLidar) DroppedPackets() uint64 {
+	return lidar.metrics.droppedPackets.Load()
+}
+
+// sendPacket delivers the packet on the Packets channel according to the overflow policy.
+// Returns false if the scan was stopped. Error packets are never dropped.
+func (lidar *YDLidar) sendPacket(packet Packet) bool {
+	if lidar.overflow == Block || packet.Error != nil {
+		select {
+		case lidar.Packets <- packet:
+			return true
+		case <-lidar.Stop:
+			return false
+		}
+	}
+
+	for {
+		select {
+		case lidar.Packets <- packet:
+			return true
+		case <-lidar.Stop:
+			return false
+		default:
+		}
+
+		lidar.metrics.droppedPackets.Add(1)
+		if lidar.overflow == DropNewest {
+			return true
+		}
+		// Make room, the consumer may have emptied the channel meanwhile.
+		select {
+		case <-lidar.Packets:
+		default:
+		}
+	}
+}
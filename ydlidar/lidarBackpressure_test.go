@@ -0,0 +1,30 @@
+package ydlidar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverflowPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy OverflowPolicy
+		want   []int
+	}{
+		{DropOldest, []int{4, 5}},
+		{DropNewest, []int{1, 2}},
+	} {
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			lidar := NewLidar(&fakePort{}, WithPacketBuffer(2, tc.policy))
+			for i := 1; i <= 5; i++ {
+				require.True(t, lidar.sendPacket(Packet{NumDistanceSamples: i}))
+			}
+
+			got := []int{(<-lidar.Packets).NumDistanceSamples, (<-lidar.Packets).NumDistanceSamples}
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, uint64(3), lidar.DroppedPackets())
+			assert.Equal(t, uint64(3), lidar.Metrics().DroppedPackets)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package ydlidar
+
+import "time"
+
+const (
+	// bulkReadSize is the size of the reads of the scan loop from the transport. At 230400
+	// baud it holds about 180ms of packets, so a read returns whatever arrived since the last
+	// one: a read every few dozen packets rather than two per packet.
+	bulkReadSize = 4096
+
+	// smallBulkReadSize is the read size of the small profile.
+	smallBulkReadSize = 512
+)
+
+// readAhead reads the scan stream ahead of the packets into the StreamDecoder of the scan loop,
+// which frames the packets out of the bytes read.
+type readAhead struct {
+	size int // Size of the reads from the transport, 0 reads the bytes the decoder misses only.
+}
+
+// fill reads from the transport within the timeout and writes what it got to the decoder,
+// the read buffer taken from the pool of the lidar. Like a read of the transport it returns
+// what it got when the read gets nothing within the timeout or fails.
+func (r *readAhead) fill(lidar *YDLidar, dec *StreamDecoder, timeout time.Duration) (int, error) {
+	size := r.size
+	if size <= 0 {
+		size = dec.need
+	}
+	if size <= 0 {
+		size = scanPacketHeaderSize
+	}
+	if err := lidar.setTimeout(timeout); err != nil {
+		return 0, err
+	}
+	buf := lidar.buffers.get(size)
+	defer lidar.buffers.put(buf)
+	n, err := lidar.read(buf)
+	dec.Write(buf[:n])
+	return n, err
+}
@@ -0,0 +1,99 @@
+package ydlidar
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingPort counts the reads of the transport.
+type countingPort struct {
+	*fakePort
+	reads int64
+}
+
+func (p *countingPort) Read(b []byte) (int, error) {
+	atomic.AddInt64(&p.reads, 1)
+	return p.fakePort.Read(b)
+}
+
+// streamingLidar returns a lidar scanning an endless stream of revolutions, reading the
+// transport by reads of readSize bytes, 0 for the direct reads.
+func streamingLidar(t testing.TB, readSize int) (*YDLidar, *countingPort) {
+	revolution := revolutionBytes()
+	port := &countingPort{fakePort: &fakePort{refill: func() []byte { return revolution }}}
+	port.queue(scanResponseHeader...)
+	lidar := NewLidar(port)
+	lidar.ahead.size = readSize
+	require.NoError(t, lidar.StartScan())
+	return lidar, port
+}
+
+func TestBulkReads(t *testing.T) {
+	reads := func(readSize int) int64 {
+		lidar, port := streamingLidar(t, readSize)
+		for i := 0; i < 120; i++ {
+			packet := <-lidar.Packets
+			require.NoError(t, packet.Error)
+			assert.Equal(t, 1000.0, packet.Distances[0])
+		}
+		require.NoError(t, lidar.StopScan())
+		assert.Zero(t, lidar.Stats().ChecksumFailures)
+		return atomic.LoadInt64(&port.reads)
+	}
+
+	direct, bulk := reads(0), reads(bulkReadSize)
+	assert.Less(t, bulk*10, direct, "direct %v reads, bulk %v reads", direct, bulk)
+}
+
+func TestReadAheadFill(t *testing.T) {
+	// The direct reads get the bytes the decoder misses, the bulk reads whatever came.
+	revolution := revolutionBytes()
+	for _, tc := range []struct {
+		size  int
+		reads []int
+	}{
+		{0, []int{10, 3, 10, 120}},
+		{64, []int{64, 64, 64}},
+	} {
+		port := &fakePort{}
+		port.queue(revolution...)
+		lidar := NewLidar(port)
+		r := readAhead{size: tc.size}
+		dec := lidar.streamDecoder()
+		for _, want := range tc.reads {
+			for {
+				if _, err := dec.nextFrame(); err != nil {
+					require.ErrorIs(t, err, ErrNeedMore)
+					break
+				}
+			}
+			n, err := r.fill(lidar, dec, defaultTimeouts.Sample)
+			require.NoError(t, err)
+			assert.Equal(t, want, n, "read size %v", tc.size)
+		}
+	}
+}
+
+// BenchmarkScanReads compares the direct reads of the headers and samples, before, with the
+// bulk reads, after, per packet scanned.
+func BenchmarkScanReads(b *testing.B) {
+	for _, bench := range []struct {
+		name     string
+		readSize int
+	}{{"Direct", 0}, {"Bulk", bulkReadSize}, {"Small", smallBulkReadSize}} {
+		b.Run(bench.name, func(b *testing.B) {
+			lidar, port := streamingLidar(b, bench.readSize)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				<-lidar.Packets
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(atomic.LoadInt64(&port.reads))/float64(b.N), "reads/packet")
+			require.NoError(b, lidar.StopScan())
+		})
+	}
+}
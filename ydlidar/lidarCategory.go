@@ -0,0 +1,99 @@
+package ydlidar
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+)
+
+// PacketCategory is the kind of a packet of the scan stream, told by its first bytes.
+type PacketCategory int
+
+const (
+	// CategoryPointCloud a scan packet of samples, bit 0 of its CT byte clear. The packets of
+	// all the framings, G series or T-mini, are classified alike.
+	CategoryPointCloud PacketCategory = iota
+
+	// CategoryZero the zero packet starting a revolution, bit 0 of its CT byte set.
+	CategoryZero
+
+	// CategoryResponse a command response, 0xA5 0x5A, eg. a notification or the answer to a
+	// command sent while scanning. Decoded on the Aux channel, see WithAuxPackets.
+	CategoryResponse
+
+	// CategoryUnknown anything else, eg. line noise or all zeros after a glitch, skipped up to
+	// the next packet header. The bytes go to the Diagnostics channel and the raw tap.
+	CategoryUnknown
+
+	packetCategoryCount
+)
+
+// String returns the name of the category.
+func (c PacketCategory) String() string {
+	switch c {
+	case CategoryPointCloud:
+		return "PointCloud"
+	case CategoryZero:
+		return "Zero"
+	case CategoryResponse:
+		return "Response"
+	case CategoryUnknown:
+		return "Unknown"
+	}
+	return fmt.Sprintf("PacketCategory(%d)", int(c))
+}
+
+// ClassifyPacket returns the category of the packet starting with header, the bytes read as
+// a scan packet header.
+func ClassifyPacket(header []byte) PacketCategory {
+	if len(header) < 2 {
+		return CategoryUnknown
+	}
+	if _, ok := codecFor(header); ok {
+		if len(header) < 3 {
+			return CategoryUnknown
+		}
+		if header[2]&0x01 != 0 {
+			return CategoryZero
+		}
+		return CategoryPointCloud
+	}
+	if binary.LittleEndian.Uint16(header) == responseHeader {
+		return CategoryResponse
+	}
+	return CategoryUnknown
+}
+
+// mayStartPacket reports whether b starts with a scan packet header or a response start sign,
+// or with the first byte of one.
+func mayStartPacket(b []byte) bool {
+	starts := []uint16{responseHeader}
+	for _, codec := range frameCodecs {
+		starts = append(starts, codec.header)
+	}
+	for _, start := range starts {
+		if b[0] == byte(start) && (len(b) == 1 || b[1] == byte(start>>8)) {
+			return true
+		}
+	}
+	return false
+}
+
+// countPacket counts a packet of the category read by the scan loop.
+func (lidar *YDLidar) countPacket(category PacketCategory) {
+	if category >= 0 && category < packetCategoryCount {
+		lidar.metrics.categories[category].Add(1)
+	}
+}
+
+// handleUnknownPacket hands the bytes of a packet of no known category to the Diagnostics
+// channel and the raw tap.
+func (lidar *YDLidar) handleUnknownPacket(raw []byte) {
+	log.Printf("Unknown packet, %X", raw)
+	lidar.emitDiagnostic(raw, "unknown packet header")
+	if lidar.rawTap != nil {
+		if _, err := lidar.rawTap.Write(raw); err != nil {
+			log.Printf("Raw tap write failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package ydlidar
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyPacket(t *testing.T) {
+	tests := []struct {
+		header []byte
+		want   PacketCategory
+	}{
+		{[]byte{0xAA, 0x55, 0x00, 0x28}, CategoryPointCloud},
+		{[]byte{0xAA, 0x55, 0x8F, 0x01}, CategoryZero},
+		{[]byte{0xA5, 0x5A, 0x03, 0x00, 0x00, 0x00, HealthTypeCode}, CategoryResponse},
+		{make([]byte, 10), CategoryUnknown},
+		{[]byte{0xAA, 0x55}, CategoryUnknown},
+		{nil, CategoryUnknown},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, ClassifyPacket(test.header), "%X", test.header)
+	}
+	assert.Equal(t, "Zero", CategoryZero.String())
+}
+
+func TestPacketCategories(t *testing.T) {
+	var tap bytes.Buffer
+	port := &fakePort{}
+	lidar := NewLidar(port, WithAuxPackets(4), WithRawTap(&tap))
+
+	// 7 bytes of noise, the next header is out of step with the 10 byte headers.
+	noise := []byte{0x00, 0x13, 0x37, 0x00, 0x42, 0x00, 0x07}
+	port.queue(scanResponseHeader...)
+	port.queue(encodeScanPacket(0x01, 0x0001, 0x0001, [][3]byte{{0, 0, 0}})...)
+	port.queue(0xA5, 0x5A, 0x03, 0x00, 0x00, 0x00, HealthTypeCode, 0x00, 0x00, 0x00)
+	port.queue(noise...)
+	port.queue(encodeScanPacket(0x00, 0x0001, 0x0201, [][3]byte{{100, byte(1000 & 0x3F << 2), byte(1000 >> 6)}})...)
+	require.NoError(t, lidar.StartScan())
+	packet := <-lidar.Packets
+	require.NoError(t, lidar.StopScan())
+	require.NoError(t, packet.Error)
+	assert.Equal(t, []float64{1000}, packet.Distances)
+	assert.True(t, packet.IsZeroStart)
+
+	metrics := lidar.Metrics()
+	assert.Equal(t, uint64(1), metrics.Packets)
+	assert.Equal(t, uint64(1), metrics.ZeroPackets)
+	assert.Equal(t, uint64(1), metrics.ResponsePackets)
+	assert.Equal(t, uint64(1), metrics.UnknownPackets)
+
+	select {
+	case raw := <-lidar.Diagnostics:
+		assert.Equal(t, noise, raw.Bytes)
+		assert.Equal(t, "unknown packet header", raw.Reason)
+	case <-time.After(time.Second):
+		t.Fatal("no diagnostic")
+	}
+	assert.True(t, bytes.Contains(tap.Bytes(), append(noise, 0xAA, 0x55, 0x00)), "the unknown bytes are tapped in stream order")
+}
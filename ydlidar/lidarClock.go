@@ -0,0 +1,209 @@
+package ydlidar
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// clockWindow is the number of events the clock models are fitted on, about 6 seconds of
+// zero packets at 10Hz.
+const clockWindow = 64
+
+// ClockModel is the fit of the arrival times of a periodic event on the host clock: the zero
+// packets, once per revolution, or the pulses of a PPS source. The event of index k is
+// expected at Origin + k*Period, the arrivals deviate from it by the latency jitter of the
+// link, USB polling and scheduling.
+type ClockModel struct {
+	Samples int           // Events in the fit, the fit is valid from 2.
+	Origin  time.Time     // Fitted host time of the event of index 0: the offset of the event timeline.
+	Period  time.Duration // Fitted period on the host clock.
+	Skew    float64       // Drift of the fitted period from the nominal one in ppm, 0 without a nominal period.
+	Jitter  time.Duration // RMS deviation of the arrivals from the fit.
+	Last    int64         // Index of the last event.
+}
+
+// Valid reports whether the model has a fit.
+func (m ClockModel) Valid() bool {
+	return m.Samples >= 2 && m.Period > 0
+}
+
+// At returns the fitted host time of the event of the index.
+func (m ClockModel) At(index int64) time.Time {
+	return m.Origin.Add(time.Duration(index) * m.Period)
+}
+
+// Correct returns the fitted host time of the event closest to arrival, eg. the time of the
+// zero crossing of the revolution whose zero packet arrived then, free of the latency
+// jitter. Arrival is returned unchanged without a fit.
+func (m ClockModel) Correct(arrival time.Time) time.Time {
+	if !m.Valid() {
+		return arrival
+	}
+	return m.At(int64(math.Round(float64(arrival.Sub(m.Origin)) / float64(m.Period))))
+}
+
+// ClockModel returns the fit of the arrivals of the zero packets of the running scan. The
+// nominal period is that of the frequency set with SetMotorSpeed or read with MotorSpeed.
+// The model is reset when a scan starts, it is invalid before two zero packets arrived.
+func (lidar *YDLidar) ClockModel() ClockModel {
+	if m := lidar.frequency.clock.Load(); m != nil {
+		return *m
+	}
+	return ClockModel{}
+}
+
+// observeClock adds the arrival of a zero packet to the clock model.
+func (lidar *YDLidar) observeClock(now time.Time) {
+	f := &lidar.frequency
+	f.cadence.nominal = 0
+	if commanded := math.Float64frombits(f.commanded.Load()); commanded > 0 {
+		f.cadence.nominal = time.Duration(float64(time.Second) / commanded)
+	}
+	f.cadence.add(now)
+	m := f.cadence.model()
+	f.clock.Store(&m)
+}
+
+// PPSClock fits the host times of the pulses of a PPS source, eg. the edges of a GPS receiver
+// timestamped by a GPIO interrupt, to convert the host timestamps, eg. the Received time of
+// the packets, to the PPS timeline. It is safe for concurrent use.
+type PPSClock struct {
+	mu     sync.Mutex
+	fit    cadenceFit
+	second time.Time // Second of the pulse of index 0.
+}
+
+// NewPPSClock returns a clock fitting pulses every second.
+func NewPPSClock() *PPSClock {
+	return &PPSClock{fit: cadenceFit{nominal: time.Second}}
+}
+
+// Pulse adds a pulse received at the host time t. The first pulse is taken for the whole
+// second closest to t, the host clock must be within half a second, eg. set by NTP.
+func (c *PPSClock) Pulse(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.fit.indexes) == 0 {
+		c.second = t.Round(time.Second)
+	}
+	c.fit.add(t)
+}
+
+// Model returns the fit of the pulses, its Skew is the drift of the host clock in ppm.
+func (c *PPSClock) Model() ClockModel {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fit.model()
+}
+
+// ToPPS converts the host time t to the PPS timeline. t is returned unchanged before two
+// pulses were received.
+func (c *PPSClock) ToPPS(t time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := c.fit.model()
+	if !m.Valid() {
+		return t
+	}
+	seconds := float64(t.Sub(m.Origin)) / float64(m.Period)
+	return c.second.Add(time.Duration(seconds * float64(time.Second)))
+}
+
+// Reset forgets the pulses, eg. when the PPS source lost its fix.
+func (c *PPSClock) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fit.reset()
+}
+
+// cadenceFit fits the arrivals of a periodic event to a line by least squares over the last
+// clockWindow events. An event is indexed from the previous one at the estimated period, so
+// a missed event doesn't shift the following ones.
+type cadenceFit struct {
+	nominal time.Duration // Expected period, 0 if unknown.
+	base    time.Time     // Arrival of the event of index 0.
+	indexes []int64
+	offsets []float64 // Seconds since base.
+}
+
+// reset forgets the events.
+func (f *cadenceFit) reset() {
+	f.indexes, f.offsets = f.indexes[:0], f.offsets[:0]
+}
+
+// add adds an event arrived at t.
+func (f *cadenceFit) add(t time.Time) {
+	if len(f.indexes) == 0 {
+		f.base = t
+		f.indexes = append(f.indexes, 0)
+		f.offsets = append(f.offsets, 0)
+		return
+	}
+	offset := t.Sub(f.base).Seconds()
+	last := len(f.indexes) - 1
+	elapsed := offset - f.offsets[last]
+	period := f.nominal.Seconds()
+	if m := f.model(); m.Valid() {
+		period = m.Period.Seconds()
+	}
+	step := int64(1)
+	if period > 0 {
+		if n := int64(math.Round(elapsed / period)); n > 1 {
+			step = n
+		}
+	}
+	if len(f.indexes) == clockWindow {
+		f.indexes, f.offsets = append(f.indexes[:0], f.indexes[1:]...), append(f.offsets[:0], f.offsets[1:]...)
+		last--
+	}
+	f.indexes = append(f.indexes, f.indexes[last]+step)
+	f.offsets = append(f.offsets, offset)
+}
+
+// model returns the least squares fit of the events.
+func (f *cadenceFit) model() ClockModel {
+	m := ClockModel{Samples: len(f.indexes)}
+	if m.Samples < 2 {
+		m.Origin = f.base
+		return m
+	}
+	m.Last = f.indexes[m.Samples-1]
+
+	var meanIndex, meanOffset float64
+	for i, index := range f.indexes {
+		meanIndex += float64(index)
+		meanOffset += f.offsets[i]
+	}
+	meanIndex /= float64(m.Samples)
+	meanOffset /= float64(m.Samples)
+	var covariance, variance float64
+	for i, index := range f.indexes {
+		di := float64(index) - meanIndex
+		covariance += di * (f.offsets[i] - meanOffset)
+		variance += di * di
+	}
+	if variance == 0 {
+		return m
+	}
+	period := covariance / variance
+	origin := meanOffset - period*meanIndex
+
+	var squares float64
+	for i, index := range f.indexes {
+		residual := f.offsets[i] - (origin + period*float64(index))
+		squares += residual * residual
+	}
+	m.Origin = f.base.Add(seconds(origin))
+	m.Period = seconds(period)
+	m.Jitter = seconds(math.Sqrt(squares / float64(m.Samples)))
+	if f.nominal > 0 {
+		m.Skew = (period/f.nominal.Seconds() - 1) * 1e6
+	}
+	return m
+}
+
+// seconds converts seconds to a duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
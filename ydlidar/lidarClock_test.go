@@ -0,0 +1,84 @@
+package ydlidar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCadenceFit(t *testing.T) {
+	// Revolutions every 100.01ms on the host clock, 100ppm slower than the nominal 10Hz,
+	// arriving 2 to 5ms late. The revolution 7 is missed.
+	start := time.Unix(1700000000, 0)
+	period := 100010 * time.Microsecond
+	delays := []time.Duration{2, 5, 3, 4, 2, 5}
+	f := cadenceFit{nominal: 100 * time.Millisecond}
+	for k := 0; k < 100; k++ {
+		if k == 7 {
+			continue
+		}
+		f.add(start.Add(time.Duration(k)*period + delays[k%len(delays)]*time.Millisecond))
+	}
+
+	m := f.model()
+	require.True(t, m.Valid())
+	assert.Equal(t, clockWindow, m.Samples)
+	assert.Equal(t, int64(99), m.Last)
+	assert.InDelta(t, float64(period), float64(m.Period), float64(20*time.Microsecond))
+	assert.InDelta(t, 100, m.Skew, 20)
+	assert.InDelta(t, float64(1200*time.Microsecond), float64(m.Jitter), float64(300*time.Microsecond))
+
+	// The corrected arrival of a revolution is within the delays of its zero crossing.
+	arrival := start.Add(90*period + 5*time.Millisecond)
+	corrected := m.Correct(arrival)
+	assert.InDelta(t, float64(start.Add(90*period+3500*time.Microsecond).UnixNano()), float64(corrected.UnixNano()), float64(time.Millisecond))
+	assert.Equal(t, m.At(90), corrected)
+
+	f.reset()
+	assert.False(t, f.model().Valid())
+	assert.Equal(t, arrival, ClockModel{}.Correct(arrival))
+}
+
+func TestPPSClock(t *testing.T) {
+	// The host clock is 200ms ahead and runs 50ppm fast.
+	second := time.Unix(1700000000, 0)
+	host := func(at time.Time) time.Time {
+		elapsed := at.Sub(second)
+		return at.Add(200*time.Millisecond + elapsed/20000)
+	}
+	c := NewPPSClock()
+	assert.Equal(t, second, c.ToPPS(second), "unchanged without pulses")
+	for s := 0; s < 30; s++ {
+		c.Pulse(host(second.Add(time.Duration(s) * time.Second)))
+	}
+
+	m := c.Model()
+	assert.InDelta(t, 50, m.Skew, 1)
+	at := second.Add(25*time.Second + 300*time.Millisecond)
+	assert.InDelta(t, float64(at.UnixNano()), float64(c.ToPPS(host(at)).UnixNano()), float64(time.Microsecond))
+
+	c.Reset()
+	assert.False(t, c.Model().Valid())
+}
+
+func TestLidarClockModel(t *testing.T) {
+	port := &fakePort{}
+	port.queue(scanResponseHeader...)
+	port.refill = revolutionBytes
+	lidar := NewLidar(port)
+	assert.False(t, lidar.ClockModel().Valid())
+
+	require.NoError(t, lidar.StartScan())
+	for i := 0; i < 36; i++ {
+		<-lidar.Packets
+	}
+	require.NoError(t, lidar.StopScan())
+	m := lidar.ClockModel()
+	assert.GreaterOrEqual(t, m.Samples, 3)
+	assert.Zero(t, m.Skew, "no nominal frequency")
+
+	lidar.resetFrequency()
+	assert.Zero(t, lidar.ClockModel().Samples, "reset by a new scan")
+}
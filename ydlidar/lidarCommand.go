@@ -0,0 +1,138 @@
+package ydlidar
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"ydlidarg2/ydlidar/protocol"
+)
+
+// commandQueue holds the commands waiting for the port. The first caller finding it idle
+// runs the queue, the others wait for their turn.
+type commandQueue struct {
+	mu      sync.Mutex
+	pending []*commandRequest
+	running bool
+}
+
+// commandRequest is a queued command and where its result goes.
+type commandRequest struct {
+	run  func() error
+	done chan error
+}
+
+// command runs a command, writing to the port and reading the response, once the commands
+// queued before it ran. A running scan is paused: the scan loop stopped and the buffers
+// flushed so the response isn't mixed with scan packets. Commands queued meanwhile run
+// during the same pause, then the scan resumes. Failing to resume is reported to every
+// command of the pause that succeeded.
+func (lidar *YDLidar) command(run func() error) error {
+	request := &commandRequest{run: run, done: make(chan error, 1)}
+	q := &lidar.commands
+	q.mu.Lock()
+	q.pending = append(q.pending, request)
+	if q.running {
+		q.mu.Unlock()
+		return <-request.done
+	}
+	q.running = true
+	q.mu.Unlock()
+
+	lidar.runCommands()
+	return <-request.done
+}
+
+// runCommands runs the queued commands until the queue is empty, holding the port.
+func (lidar *YDLidar) runCommands() {
+	lidar.portMu.Lock()
+	defer lidar.portMu.Unlock()
+
+	q := &lidar.commands
+	paused := false
+	var answered []*commandRequest // Succeeded during the pause, told if resuming fails.
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			if !paused {
+				q.running = false
+				q.mu.Unlock()
+				return
+			}
+			q.mu.Unlock()
+
+			// The queue is empty, resume before giving up the port.
+			err := lidar.startScan()
+			for _, request := range answered {
+				if err != nil {
+					request.done <- fmt.Errorf("failed to resume scan: %w", err)
+				} else {
+					request.done <- nil
+				}
+			}
+			paused, answered = false, nil
+			continue
+		}
+		request := q.pending[0]
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+
+		if !paused && lidar.IsScanning() {
+			log.Printf("Pausing scan for a command")
+			if err := lidar.stopScan(); err != nil {
+				request.done <- fmt.Errorf("failed to pause scan: %w", err)
+				continue
+			}
+			// Stopping flushed the scan packets, the response is the first thing read.
+			paused = true
+		}
+
+		err := request.run()
+		if paused && err == nil {
+			answered = append(answered, request)
+			continue
+		}
+		request.done <- err
+	}
+}
+
+// RawCommand sends a command of package protocol the driver has no method for and returns
+// the header and the data of its response, queued and pausing a running scan like the other
+// commands. The commands changing the scan state, protocol.CmdStartScan, CmdStopScan and
+// CmdRestart, are refused: StartScan, StopScan and Reboot keep the driver in step with them.
+func (lidar *YDLidar) RawCommand(command byte) (protocol.ResponseHeader, []byte, error) {
+	switch command {
+	case protocol.CmdStartScan, protocol.CmdStopScan, protocol.CmdRestart:
+		return protocol.ResponseHeader{}, nil, fmt.Errorf("ydlidar: command %#02x changes the scan state, use its method", command)
+	}
+
+	var header protocol.ResponseHeader
+	var data []byte
+	err := lidar.command(func() error {
+		if _, err := lidar.SerialPort.Write(protocol.Command(command)); err != nil {
+			return err
+		}
+		size, typeCode, mode, err := lidar.readInfoHeader()
+		if err != nil {
+			return err
+		}
+		if mode != protocol.ModeSingle {
+			return fmt.Errorf("%w: command %#02x answered with a continuous response", ErrBadHeader, command)
+		}
+		header = protocol.ResponseHeader{Length: uint32(size), Mode: mode, TypeCode: typeCode}
+
+		data = make([]byte, size)
+		n, err := lidar.readInfo(data)
+		if err != nil {
+			return fmt.Errorf("failed to read serial: %w", err)
+		}
+		if n != len(data) {
+			return fmt.Errorf("%w: response expected %v bytes got %v", ErrShortRead, len(data), n)
+		}
+		return nil
+	})
+	if err != nil {
+		return protocol.ResponseHeader{}, nil, err
+	}
+	return header, data, nil
+}
@@ -0,0 +1,114 @@
+package ydlidar
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar/protocol"
+	"ydlidarg2/ydlidar/sim"
+)
+
+// scanningSim returns a lidar scanning a simulated G2, with its packets drained until the
+// test ends.
+func scanningSim(t *testing.T) *YDLidar {
+	lidar := NewLidar(sim.NewPort(&sim.Device{Environment: sim.Room(4000, 3000), Serial: "2021031400000042"}))
+	require.NoError(t, lidar.StartScan())
+	stop := make(chan struct{})
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		for {
+			select {
+			case <-lidar.Packets:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		<-drained
+		lidar.Close()
+	})
+	return lidar
+}
+
+func TestCommandPausesScan(t *testing.T) {
+	lidar := scanningSim(t)
+	time.Sleep(20 * time.Millisecond)
+
+	info, err := lidar.DeviceInfo()
+	require.NoError(t, err)
+	assert.Equal(t, "2021031400000042", info.SerialNumber)
+	assert.True(t, lidar.IsScanning(), "resumed")
+
+	hz, err := lidar.SetMotorSpeed(10)
+	require.NoError(t, err)
+	assert.InDelta(t, 10, hz, frequencyTolerance)
+	assert.True(t, lidar.IsScanning())
+
+	packets := lidar.Metrics().Packets
+	require.Eventually(t, func() bool { return lidar.Metrics().Packets > packets }, time.Second, time.Millisecond)
+}
+
+func TestCommandQueue(t *testing.T) {
+	lidar := scanningSim(t)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := lidar.HealthInfo()
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := lidar.MotorSpeed()
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.True(t, lidar.IsScanning())
+	assert.False(t, lidar.commands.running)
+}
+
+func TestStopScanWaitsForCommands(t *testing.T) {
+	lidar := scanningSim(t)
+
+	done := make(chan error)
+	go func() {
+		_, err := lidar.SetMotorSpeed(6)
+		done <- err
+	}()
+	time.Sleep(time.Millisecond)
+	require.NoError(t, lidar.StopScan())
+	require.NoError(t, <-done)
+	assert.False(t, lidar.IsScanning(), "the scan stays stopped once the command resumed it")
+}
+
+func TestRawCommand(t *testing.T) {
+	lidar := scanningSim(t)
+
+	header, data, err := lidar.RawCommand(protocol.CmdDeviceInfo)
+	require.NoError(t, err)
+	assert.Equal(t, protocol.ResponseHeader{Length: 20, Mode: protocol.ModeSingle, TypeCode: protocol.TypeDeviceInfo}, header)
+	assert.Len(t, data, 20)
+	assert.True(t, lidar.IsScanning(), "resumed")
+
+	_, data, err = lidar.RawCommand(protocol.CmdHealth)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0}, data)
+
+	_, _, err = lidar.RawCommand(protocol.CmdStopScan)
+	assert.Error(t, err)
+	assert.True(t, lidar.IsScanning())
+}
@@ -0,0 +1,72 @@
+package ydlidar
+
+// SampleDecoder decodes the samples of a scan packet. The sample layout depends on the model:
+// models reporting intensity send 3 bytes per sample, the others 2.
+type SampleDecoder interface {
+	// SampleSize returns the number of bytes per sample.
+	SampleSize() int
+
+	// Decode returns the distances in millimeters and the intensities of the samples in data,
+	// a whole number of samples. Models without intensity report an intensity of 0.
+	Decode(data []byte) (distances []float64, intensities []int)
+}
+
+// IntensityDecoder decodes 3 byte samples: an intensity byte followed by a little endian word
+// holding the 2 high bits of the intensity in its low bits and the distance in its high 14 bits.
+// Used by the G2.
+type IntensityDecoder struct{}
+
+// SampleSize returns 3.
+func (IntensityDecoder) SampleSize() int { return 3 }
+
+// Decode decodes the 3 byte samples.
+func (d IntensityDecoder) Decode(data []byte) ([]float64, []int) {
+	samples := make([][]byte, len(data)/d.SampleSize())
+	intensities := calculateIntensities(data, samples, d.SampleSize())
+	distances := calculateDistances(data, samples, d.SampleSize())
+	return distances, intensities
+}
+
+// DistanceDecoder decodes 2 byte samples: a little endian word holding the distance in
+// quarters of a millimeter. Used by the models without intensity such as the X4 and the G4.
+type DistanceDecoder struct{}
+
+// SampleSize returns 2.
+func (DistanceDecoder) SampleSize() int { return 2 }
+
+// Decode decodes the 2 byte samples.
+func (d DistanceDecoder) Decode(data []byte) ([]float64, []int) {
+	n := len(data) / d.SampleSize()
+	distances := make([]float64, n)
+	for i := range distances {
+		distances[i] = float64(uint16(data[2*i])|uint16(data[2*i+1])<<8) / 4
+	}
+	return distances, make([]int, n)
+}
+
+// decoderFor returns the decoder of the model, the G2 layout for unknown models.
+func decoderFor(model byte) SampleDecoder {
+	if spec, ok := models[model]; ok {
+		return spec.decoder
+	}
+	return IntensityDecoder{}
+}
+
+// WithSampleDecoder forces the sample layout instead of selecting it from the model reported
+// by DeviceInfo, eg. for a firmware with the intensity output switched off. It also turns off
+// the detection of the layout by the scan loop, which otherwise switches between the 2 and 3
+// byte samples when the packets only match their check code with the other size.
+func WithSampleDecoder(decoder SampleDecoder) Option {
+	return func(lidar *YDLidar) {
+		lidar.decoder = decoder
+		lidar.fixedDecoder = true
+	}
+}
+
+// sampleDecoder returns the decoder used by the scan loop.
+func (lidar *YDLidar) sampleDecoder() SampleDecoder {
+	if lidar.decoder == nil {
+		return IntensityDecoder{}
+	}
+	return lidar.decoder
+}
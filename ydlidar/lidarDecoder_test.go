@@ -0,0 +1,91 @@
+package ydlidar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeDistancePacket encodes a scan packet of 2 byte samples, distances in quarters of a millimeter.
+func encodeDistancePacket(fsa, lsa uint16, samples []uint16) []byte {
+	cs := 0x55AA ^ uint16(len(samples))<<8 ^ fsa ^ lsa
+	for _, sample := range samples {
+		cs ^= sample
+	}
+
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, pointCloudHeader{
+		PacketHeader:   0x55AA,
+		SampleQuantity: uint8(len(samples)),
+		StartAngle:     fsa,
+		EndAngle:       lsa,
+		CheckCode:      cs,
+	})
+	binary.Write(&b, binary.LittleEndian, samples)
+	return b.Bytes()
+}
+
+func TestIntensityDecoder(t *testing.T) {
+	// 1000mm with intensity 100, 250mm with intensity 0x2FF.
+	data := []byte{
+		100, byte(1000 & 0x3F << 2), byte(1000 >> 6),
+		0xFF, byte(250&0x3F<<2 | 0x2), byte(250 >> 6),
+	}
+
+	distances, intensities := IntensityDecoder{}.Decode(data)
+	assert.Equal(t, []float64{1000, 250}, distances)
+	assert.Equal(t, []int{100, 0x2FF}, intensities)
+}
+
+func TestDistanceDecoder(t *testing.T) {
+	data := []byte{0xA0, 0x0F, 0x02, 0x00}
+
+	distances, intensities := DistanceDecoder{}.Decode(data)
+	assert.Equal(t, []float64{1000, 0.5}, distances)
+	assert.Equal(t, []int{0, 0}, intensities)
+}
+
+func TestDecoderFromDeviceInfo(t *testing.T) {
+	for model, want := range map[byte]SampleDecoder{6: DistanceDecoder{}, 15: IntensityDecoder{}} {
+		port := &fakePort{}
+		lidar := NewLidar(port)
+		port.queue(0xA5, 0x5A, 0x14, 0x00, 0x00, 0x00, InfoTypeCode)
+		port.queue(append([]byte{model}, make([]byte, 19)...)...)
+
+		_, err := lidar.DeviceInfo()
+		require.NoError(t, err)
+		assert.Equal(t, want, lidar.sampleDecoder(), "model %v", model)
+	}
+
+	// A forced decoder isn't replaced.
+	port := &fakePort{}
+	lidar := NewLidar(port, WithSampleDecoder(DistanceDecoder{}))
+	port.queue(0xA5, 0x5A, 0x14, 0x00, 0x00, 0x00, InfoTypeCode)
+	port.queue(append([]byte{15}, make([]byte, 19)...)...)
+	_, err := lidar.DeviceInfo()
+	require.NoError(t, err)
+	assert.Equal(t, DistanceDecoder{}, lidar.sampleDecoder())
+}
+
+func TestScanDistanceOnlyModel(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port, WithSampleDecoder(DistanceDecoder{}))
+
+	port.queue(scanResponseHeader...)
+	require.NoError(t, lidar.StartScan())
+	defer lidar.StopScan()
+	port.queue(encodeDistancePacket(0x0001|90<<7, 0x0001|100<<7, []uint16{4000, 2000, 1000})...)
+
+	select {
+	case packet := <-lidar.Packets:
+		require.NoError(t, packet.Error)
+		assert.Equal(t, []float64{1000, 500, 250}, packet.Distances)
+		assert.Equal(t, []int{0, 0, 0}, packet.Intensities)
+	case <-time.After(time.Second):
+		t.Fatal("no packet")
+	}
+}
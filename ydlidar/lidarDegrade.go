@@ -0,0 +1,197 @@
+package ydlidar
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+const (
+	// linkCheckInterval is the period over which WithLinkDegradation measures the failure
+	// rate.
+	linkCheckInterval = 2 * time.Second
+
+	// linkMinPackets is the number of packets a measurement needs, fewer mean the scan is
+	// stopped or stalled, left to the watchdog.
+	linkMinPackets = 20
+
+	// linkRecoverChecks is the number of consecutive measurements within the rate needed to
+	// leave the degraded mode.
+	linkRecoverChecks = 3
+)
+
+// ModeSetter is implemented by transports that can change their line settings, such as a
+// serial.Port. The baud rate fallback of WithLinkDegradation needs it.
+type ModeSetter interface {
+	SetMode(mode *serial.Mode) error
+}
+
+// linkDegradation holds the settings of WithLinkDegradation.
+type linkDegradation struct {
+	maxRate  float64
+	bauds    []int
+	interval time.Duration
+	degraded atomic.Bool
+}
+
+// WithLinkDegradation keeps the lidar going on a noisy link. Every couple of seconds the
+// failure rate, the share of the scan packets failing their check code or lost to the stream
+// falling out of step with the packet headers, is compared with maxRate, eg. 0.05:
+//
+//   - The first time it is exceeded the scan frequency is lowered by 1Hz, on the models
+//     supporting it, which lowers the sample rate, and the stream is flushed and
+//     resynchronized by pausing the scan.
+//   - If it is still exceeded the link is degraded: a DegradedLink event is sent on the
+//     Status channel, and the port switched to the next of the fallback baud rates, if any
+//     are given and the transport is a ModeSetter. Only useful for devices or bridges set to
+//     that rate, a reconnection opens the port at the default rate again.
+//
+// The link recovers after a few measurements within the rate, with a LinkRecovered event
+// when it was degraded. The scan frequency isn't restored.
+func WithLinkDegradation(maxRate float64, fallbackBauds ...int) Option {
+	return func(lidar *YDLidar) {
+		lidar.degradation = &linkDegradation{maxRate: maxRate, bauds: fallbackBauds, interval: linkCheckInterval}
+	}
+}
+
+// LinkDegraded reports whether the failures persist despite the slower scan, see
+// WithLinkDegradation.
+func (lidar *YDLidar) LinkDegraded() bool {
+	return lidar.degradation != nil && lidar.degradation.degraded.Load()
+}
+
+// linkAction is what the link monitor does after a measurement.
+type linkAction int
+
+const (
+	linkKeep    linkAction = iota // Nothing to do.
+	linkSlow                      // Slow the scan down and resynchronize.
+	linkDegrade                   // Report the degraded link and fall back to a lower baud rate.
+	linkRecover                   // Report the recovered link.
+)
+
+// linkMonitor turns the successive link counters into actions.
+type linkMonitor struct {
+	maxRate  float64
+	last     LinkStats
+	slowed   bool // The scan was slowed down.
+	degraded bool // The link was reported degraded since.
+	clean    int  // Consecutive measurements within the rate.
+}
+
+// check measures the failure rate since the last counters. A desynchronized stream loses
+// packets to the bytes skipped up to the next header rather than failing their check code,
+// each desync counts as a failure.
+func (m *linkMonitor) check(stats LinkStats) (linkAction, float64) {
+	failures := stats.ChecksumFailures - m.last.ChecksumFailures + stats.Desyncs - m.last.Desyncs
+	packets := failures + stats.Frames - m.last.Frames
+	m.last = stats
+	if packets < linkMinPackets {
+		return linkKeep, 0
+	}
+	rate := float64(failures) / float64(packets)
+
+	if rate <= m.maxRate {
+		m.clean++
+		if m.clean < linkRecoverChecks || !m.slowed {
+			return linkKeep, rate
+		}
+		m.slowed = false
+		if m.degraded {
+			m.degraded = false
+			return linkRecover, rate
+		}
+		return linkKeep, rate
+	}
+
+	m.clean = 0
+	if !m.slowed {
+		m.slowed = true
+		return linkSlow, rate
+	}
+	m.degraded = true
+	return linkDegrade, rate
+}
+
+// monitorLink measures the failure rate of the scan until Close.
+func (lidar *YDLidar) monitorLink() {
+	defer lidar.monitors.Done()
+	ticker := time.NewTicker(lidar.degradation.interval)
+	defer ticker.Stop()
+
+	monitor := linkMonitor{maxRate: lidar.degradation.maxRate, last: lidar.Stats()}
+	bauds := lidar.degradation.bauds
+	for {
+		select {
+		case <-ticker.C:
+		case <-lidar.quit:
+			return
+		}
+		if !lidar.IsScanning() {
+			monitor.last = lidar.Stats()
+			continue
+		}
+
+		action, rate := monitor.check(lidar.Stats())
+		switch action {
+		case linkSlow:
+			log.Printf("Failure rate %.1f%%, slowing the scan and resynchronizing", rate*100)
+			if err := lidar.slowAndResync(); err != nil {
+				log.Printf("Failed to slow the scan: %v", err)
+			}
+
+		case linkDegrade:
+			cause := fmt.Errorf("%w: %.1f%% of the scan packets failed their check code or were out of step", ErrChecksum, rate*100)
+			changed := !lidar.degradation.degraded.Swap(true)
+			if len(bauds) > 0 {
+				baud := bauds[0]
+				bauds = bauds[1:]
+				if err := lidar.setBaudRate(baud); err != nil {
+					log.Printf("Failed to fall back to %v baud: %v", baud, err)
+				} else {
+					cause = fmt.Errorf("%w, fell back to %v baud", cause, baud)
+					changed = true
+				}
+			}
+			if changed {
+				log.Printf("Link degraded: %v", cause)
+				lidar.emitStatus(StatusEvent{Type: DegradedLink, Err: cause})
+			}
+
+		case linkRecover:
+			lidar.degradation.degraded.Store(false)
+			log.Printf("Link recovered")
+			lidar.emitStatus(StatusEvent{Type: LinkRecovered})
+		}
+		// The packets lost to the pause aren't counted against the next measurement.
+		monitor.last = lidar.Stats()
+	}
+}
+
+// slowAndResync lowers the scan frequency by a 1Hz step if the model supports it. Pausing the
+// scan for it flushes the stream, which is resynchronized when the scan resumes.
+func (lidar *YDLidar) slowAndResync() error {
+	return lidar.command(func() error {
+		if lidar.checkFrequencyCommand() != nil {
+			return nil
+		}
+		_, err := lidar.frequencyCommand(decreaseFrequencyLarge)
+		return err
+	})
+}
+
+// setBaudRate switches the port to the baud rate, the scan paused meanwhile.
+func (lidar *YDLidar) setBaudRate(baud int) error {
+	return lidar.command(func() error {
+		setter, ok := lidar.SerialPort.(ModeSetter)
+		if !ok {
+			return ErrUnsupportedByTransport
+		}
+		mode := serialMode
+		mode.BaudRate = baud
+		return setter.SetMode(&mode)
+	})
+}
@@ -0,0 +1,161 @@
+package ydlidar
+
+import (
+	"bytes"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.bug.st/serial"
+
+	"ydlidarg2/ydlidar/sim"
+)
+
+// noisyPort corrupts the scan stream of a simulated device while noisy. The bulk reads of the
+// scan loop get a byte flipped every 100, the short reads of the command responses are left
+// alone.
+type noisyPort struct {
+	*sim.Port
+	noisy atomic.Bool
+
+	mu    sync.Mutex
+	bauds []int
+}
+
+func (p *noisyPort) Read(b []byte) (int, error) {
+	n, err := p.Port.Read(b)
+	if p.noisy.Load() && len(b) >= smallBulkReadSize {
+		for i := 50; i < n; i += 100 {
+			b[i] ^= 0x5A
+		}
+	}
+	return n, err
+}
+
+func (p *noisyPort) SetMode(mode *serial.Mode) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bauds = append(p.bauds, mode.BaudRate)
+	return nil
+}
+
+// misalignedPort puts 3 stray bytes in front of every scan packet header of a simulated
+// device while misaligned: the packets pass their check code but the headers are out of step.
+// Like noisyPort it leaves the command responses alone.
+type misalignedPort struct {
+	*sim.Port
+	misaligned atomic.Bool
+	pending    []byte
+}
+
+func (p *misalignedPort) Read(b []byte) (int, error) {
+	if len(b) < smallBulkReadSize {
+		p.pending = nil
+		return p.Port.Read(b)
+	}
+	if len(p.pending) == 0 {
+		n, err := p.Port.Read(b)
+		if !p.misaligned.Load() || err != nil {
+			return n, err
+		}
+		p.pending = bytes.ReplaceAll(b[:n], []byte{0xAA, 0x55}, []byte{0x13, 0x37, 0x42, 0xAA, 0x55})
+	}
+	n := copy(b, p.pending)
+	p.pending = p.pending[n:]
+	return n, nil
+}
+
+func TestLinkMonitor(t *testing.T) {
+	m := linkMonitor{maxRate: 0.05}
+	var stats LinkStats
+	measure := func(frames, failures uint64) linkAction {
+		stats.Frames += frames
+		stats.ChecksumFailures += failures
+		action, _ := m.check(stats)
+		return action
+	}
+
+	assert.Equal(t, linkKeep, measure(100, 2))
+	assert.Equal(t, linkKeep, measure(5, 5), "too few packets to tell")
+	assert.Equal(t, linkSlow, measure(90, 10))
+	assert.Equal(t, linkDegrade, measure(90, 10))
+	assert.Equal(t, linkDegrade, measure(90, 10))
+	assert.Equal(t, linkKeep, measure(100, 0))
+	assert.Equal(t, linkKeep, measure(100, 0))
+	assert.Equal(t, linkRecover, measure(100, 0))
+	assert.Equal(t, linkKeep, measure(100, 0))
+
+	// The packets lost to desyncs are failures too.
+	stats.Desyncs += 10
+	assert.Equal(t, linkSlow, measure(90, 0))
+	stats.Desyncs += 10
+	assert.Equal(t, linkDegrade, measure(90, 0))
+	for i := 0; i < linkRecoverChecks; i++ {
+		measure(100, 0)
+	}
+	assert.False(t, m.slowed)
+
+	// Recovering from the slowed scan alone is silent.
+	assert.Equal(t, linkSlow, measure(90, 10))
+	for i := 0; i < linkRecoverChecks; i++ {
+		assert.Equal(t, linkKeep, measure(100, 0))
+	}
+	assert.False(t, m.slowed)
+}
+
+func TestLinkDegradation(t *testing.T) {
+	device := &sim.Device{Environment: sim.Room(4000, 3000), Frequency: 10}
+	port := &noisyPort{Port: sim.NewPort(device)}
+	lidar := NewLidar(port, WithLinkDegradation(0.05, 115200), func(lidar *YDLidar) {
+		lidar.degradation.interval = 50 * time.Millisecond
+	})
+	require.NoError(t, lidar.StartScan())
+	go func() {
+		for range lidar.Packets {
+		}
+	}()
+	defer lidar.Close()
+
+	port.noisy.Store(true)
+	event := nextStatus(t, lidar)
+	assert.Equal(t, DegradedLink, event.Type)
+	assert.ErrorIs(t, event.Err, ErrChecksum)
+	assert.Contains(t, event.Err.Error(), "115200 baud")
+	assert.True(t, lidar.LinkDegraded())
+	assert.Equal(t, 9.0, math.Float64frombits(lidar.frequency.commanded.Load()), "slowed down first")
+	port.mu.Lock()
+	assert.Equal(t, []int{115200}, port.bauds)
+	port.mu.Unlock()
+
+	port.noisy.Store(false)
+	assert.Equal(t, LinkRecovered, nextStatus(t, lidar).Type)
+	assert.False(t, lidar.LinkDegraded())
+	assert.True(t, lidar.IsScanning())
+}
+
+func TestLinkDegradationMisaligned(t *testing.T) {
+	device := &sim.Device{Environment: sim.Room(4000, 3000), Frequency: 10}
+	port := &misalignedPort{Port: sim.NewPort(device)}
+	lidar := NewLidar(port, WithLinkDegradation(0.05), func(lidar *YDLidar) {
+		lidar.degradation.interval = 50 * time.Millisecond
+	})
+	require.NoError(t, lidar.StartScan())
+	go func() {
+		for range lidar.Packets {
+		}
+	}()
+	defer lidar.Close()
+
+	port.misaligned.Store(true)
+	event := nextStatus(t, lidar)
+	assert.Equal(t, DegradedLink, event.Type)
+	assert.True(t, lidar.LinkDegraded())
+	assert.NotZero(t, lidar.Stats().Desyncs)
+
+	port.misaligned.Store(false)
+	assert.Equal(t, LinkRecovered, nextStatus(t, lidar).Type)
+}
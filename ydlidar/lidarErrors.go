@@ -0,0 +1,65 @@
+package ydlidar
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Protocol errors. The errors returned by the driver wrap them with the details, match them
+// with errors.Is.
+var (
+	// ErrBadHeader is returned when a response or scan packet doesn't start with the expected
+	// header or carries an unexpected type code. The stream is out of step, usually transient.
+	ErrBadHeader = errors.New("ydlidar: bad packet header")
+
+	// ErrChecksum is returned when a scan packet doesn't match its check code. The packet is
+	// corrupted, usually by line noise, transient.
+	ErrChecksum = errors.New("ydlidar: checksum mismatch")
+
+	// ErrShortRead is returned when the device sent fewer bytes than announced. Transient.
+	ErrShortRead = errors.New("ydlidar: short read")
+
+	// ErrTimeout is returned when the device sent nothing within the read timeout, see
+	// WithTimeouts. Not transient: the device may be unplugged or its motor stopped, a
+	// supervisor would rather check the link than resynchronize.
+	ErrTimeout = errors.New("ydlidar: read timeout")
+
+	// ErrUnsupportedModel is returned when the device reports a model this driver doesn't know.
+	// Fatal, retrying won't help.
+	ErrUnsupportedModel = errors.New("ydlidar: unsupported model")
+
+	// ErrPermissionDenied is returned when the user may not open the serial port. On Linux the
+	// port belongs to a group, usually dialout, the user has to join. Fatal.
+	ErrPermissionDenied = errors.New("ydlidar: permission denied on the serial port")
+
+	// ErrPortBusy is returned when another process holds the serial port, eg. ModemManager
+	// probing a freshly plugged adapter or another instance of the driver. The former lets
+	// go after a few seconds, see WithOpenRetry.
+	ErrPortBusy = errors.New("ydlidar: serial port busy")
+)
+
+// ErrConflictingOptions is returned by InitAndConnectToDevice and ConnectTransport for options
+// that can't be combined. Fatal.
+var ErrConflictingOptions = errors.New("ydlidar: conflicting options")
+
+// HealthError is returned when the device reports a warning or an error in its health status.
+type HealthError struct {
+	Code     uint16         // Error code reported by the device.
+	Severity HealthSeverity // Status byte reported by the device.
+}
+
+// Error returns the severity and the description of the error code.
+func (e *HealthError) Error() string {
+	return fmt.Sprintf("ydlidar: device reported %v, %v", e.Severity, e.Report())
+}
+
+// Report describes the error code.
+func (e *HealthError) Report() HealthReport {
+	return NewHealthReport(e.Code)
+}
+
+// IsTransient reports whether the error is a protocol error a retry, or a resynchronization
+// of the stream, is likely to clear. Device health errors and unsupported models are not.
+func IsTransient(err error) bool {
+	return errors.Is(err, ErrBadHeader) || errors.Is(err, ErrChecksum) || errors.Is(err, ErrShortRead)
+}
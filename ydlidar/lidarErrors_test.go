@@ -0,0 +1,50 @@
+package ydlidar
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckScanPacket(t *testing.T) {
+	packet := encodeScanPacket(0x00, 0x0001, 0x0F01, [][3]byte{{100, 0xA0, 0x0F}, {7, 0x20, 0x03}})
+	header, samples := packet[:scanPacketHeaderSize], packet[scanPacketHeaderSize:]
+	require.NoError(t, checkScanPacket(header, samples, 3))
+
+	corrupted := append([]byte(nil), samples...)
+	corrupted[4] ^= 0x10
+	err := checkScanPacket(header, corrupted, 3)
+	assert.ErrorIs(t, err, ErrChecksum)
+	assert.True(t, IsTransient(err))
+
+	assert.ErrorIs(t, checkScanPacket(header, samples[:5], 3), ErrShortRead)
+
+	badHeader := append([]byte{0xA5, 0x5A}, header[2:]...)
+	assert.ErrorIs(t, checkScanPacket(badHeader, samples, 3), ErrBadHeader)
+}
+
+func TestHealthError(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port)
+	port.queue(0xA5, 0x5A, 0x03, 0x00, 0x00, 0x00, HealthTypeCode, 0x02, 0x05, 0x01)
+
+	_, err := lidar.HealthInfo()
+	var healthErr *HealthError
+	require.True(t, errors.As(err, &healthErr))
+	assert.Equal(t, uint16(0x0105), healthErr.Code)
+	assert.Equal(t, SeverityError, healthErr.Severity)
+	assert.False(t, IsTransient(err))
+}
+
+func TestUnsupportedModel(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port)
+	port.queue(0xA5, 0x5A, 0x14, 0x00, 0x00, 0x00, InfoTypeCode)
+	port.queue(make([]byte, 20)...)
+
+	_, err := lidar.DeviceInfo()
+	assert.ErrorIs(t, err, ErrUnsupportedModel)
+	assert.False(t, IsTransient(err))
+}
@@ -0,0 +1,122 @@
+package ydlidar
+
+import "ydlidarg2/ydlidar/geom"
+
+// Filter is a stage applied to every parsed packet before it is delivered.
+// It edits the packet in place, see Packet.Keep to drop samples.
+type Filter interface {
+	Filter(packet *Packet)
+}
+
+// FilterFunc adapts a function to the Filter interface.
+type FilterFunc func(packet *Packet)
+
+// Filter calls f(packet).
+func (f FilterFunc) Filter(packet *Packet) {
+	f(packet)
+}
+
+// Keep drops the samples for which keep returns false, keeping the
+// Angles, Distances, Intensities, Synthetic and Saturated slices aligned.
+func (packet *Packet) Keep(keep func(i int) bool) {
+	kept := 0
+	for i := range packet.Distances {
+		if !keep(i) {
+			continue
+		}
+		packet.Distances[kept] = packet.Distances[i]
+		if i < len(packet.Angles) {
+			packet.Angles[kept] = packet.Angles[i]
+		}
+		if i < len(packet.Intensities) {
+			packet.Intensities[kept] = packet.Intensities[i]
+		}
+		if i < len(packet.Synthetic) {
+			packet.Synthetic[kept] = packet.Synthetic[i]
+		}
+		if i < len(packet.Saturated) {
+			packet.Saturated[kept] = packet.Saturated[i]
+		}
+		kept++
+	}
+
+	packet.Distances = packet.Distances[:kept]
+	if len(packet.Angles) > kept {
+		packet.Angles = packet.Angles[:kept]
+	}
+	if len(packet.Intensities) > kept {
+		packet.Intensities = packet.Intensities[:kept]
+	}
+	if len(packet.Synthetic) > kept {
+		packet.Synthetic = packet.Synthetic[:kept]
+	}
+	if len(packet.Saturated) > kept {
+		packet.Saturated = packet.Saturated[:kept]
+	}
+	packet.NumDistanceSamples = kept
+}
+
+// angleMask is a range of angles in degrees, wrapping through 0° when from > to.
+type angleMask struct {
+	from, to float64
+}
+
+// contains reports whether the angle lies in the mask.
+func (m angleMask) contains(angle float64) bool {
+	return geom.InAngleRange(angle, m.from, m.to)
+}
+
+// packetLimits are the built in filters configured with WithRangeLimits, WithAngleMask and WithMinIntensity.
+type packetLimits struct {
+	minRange     float64
+	maxRange     float64
+	minIntensity int
+	masks        []angleMask
+}
+
+// enabled reports whether any limit is configured.
+func (l *packetLimits) enabled() bool {
+	return l.minRange > 0 || l.maxRange > 0 || l.minIntensity > 0 || len(l.masks) > 0
+}
+
+// Filter drops the samples outside the limits. The dropouts, at 0, aren't out of range, they
+// are kept for the invalid value of WithInvalidValue.
+func (l *packetLimits) Filter(packet *Packet) {
+	packet.Keep(func(i int) bool {
+		dist := packet.Distances[i]
+		if dist > 0 && (dist < l.minRange || (l.maxRange > 0 && dist > l.maxRange)) {
+			return false
+		}
+		if i < len(packet.Intensities) && packet.Intensities[i] < l.minIntensity {
+			return false
+		}
+		for _, mask := range l.masks {
+			if i < len(packet.Angles) && mask.contains(packet.Angles[i]) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// applyFilters runs the built in limits then the filter stages. The stages are cheap
+// to skip and skipped while degraded, the limits always apply as consumers rely on them.
+func (lidar *YDLidar) applyFilters(packet *Packet) {
+	if packet.Error != nil {
+		return
+	}
+	if lidar.limits.enabled() {
+		lidar.limits.Filter(packet)
+	}
+	if keep := lidar.angleRange.Load(); keep != nil {
+		packet.Keep(func(i int) bool {
+			return i >= len(packet.Angles) || keep.contains(packet.Angles[i])
+		})
+	}
+	if lidar.Degraded() {
+		return
+	}
+	for _, filter := range lidar.filters {
+		filter.Filter(packet)
+	}
+}
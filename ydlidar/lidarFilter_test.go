@@ -0,0 +1,51 @@
+package ydlidar
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterLimits(t *testing.T) {
+	lidar := NewLidar(&fakePort{}, WithRangeLimits(100, 8000), WithAngleMask(350, 10), WithMinIntensity(50))
+	packet := Packet{
+		Angles:      []float64{5, 90, 180, 270, 355},
+		Distances:   []float64{1000, 50, 9000, 1000, 1000},
+		Intensities: []int{100, 100, 100, 20, 100},
+	}
+	packet.NumDistanceSamples = len(packet.Distances)
+
+	lidar.applyFilters(&packet)
+
+	assert.Equal(t, 0, packet.NumDistanceSamples)
+	assert.Empty(t, packet.Angles)
+
+	packet = Packet{
+		Angles:      []float64{5, 90, 180},
+		Distances:   []float64{1000, 1000, 2000},
+		Intensities: []int{100, 100, 100},
+	}
+	lidar.applyFilters(&packet)
+	assert.Equal(t, []float64{90, 180}, packet.Angles)
+	assert.Equal(t, []float64{1000, 2000}, packet.Distances)
+	assert.Equal(t, 2, packet.NumDistanceSamples)
+}
+
+func TestFilterLimitsDropouts(t *testing.T) {
+	// The dropouts are below the minimum range but aren't returns, the invalid value marks them.
+	lidar := NewLidar(&fakePort{}, WithRangeLimits(100, 8000), WithInvalidValue(InvalidInf))
+	packet := Packet{
+		Angles:      []float64{5, 90, 180},
+		Distances:   []float64{0, 50, 1000},
+		Intensities: []int{0, 100, 100},
+	}
+	packet.NumDistanceSamples = len(packet.Distances)
+
+	lidar.applyFilters(&packet)
+	assert.Equal(t, []float64{5, 180}, packet.Angles)
+	assert.Equal(t, []float64{0, 1000}, packet.Distances)
+
+	lidar.markInvalidPacket(&packet)
+	assert.Equal(t, []float64{math.Inf(1), 1000}, packet.Distances)
+}
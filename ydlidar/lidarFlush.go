@@ -0,0 +1,108 @@
+package ydlidar
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// drainPoll is the read timeout while draining: the link is silent once a read gets
+	// nothing within it.
+	drainPoll = 10 * time.Millisecond
+
+	// drainLimit is how long Drain reads before giving up on a device that keeps sending.
+	drainLimit = 500 * time.Millisecond
+
+	// syncAttempts is how many device info round-trips resync tries before giving up.
+	syncAttempts = 3
+)
+
+// Flush discards the bytes not yet sent and the bytes received but not read, including the
+// ones still arriving, see Drain. Stale bytes left by a reconnection or a reboot would
+// otherwise be parsed as the next response. A running scan is paused meanwhile.
+func (lidar *YDLidar) Flush() error {
+	return lidar.command(lidar.flush)
+}
+
+// Drain reads and discards the bytes arriving until the link falls silent, for the
+// transports that can't reset their input buffer or bytes still in flight in an adapter.
+// It returns the number of bytes discarded, and an error if the device is still sending
+// after half a second. A running scan is paused meanwhile.
+func (lidar *YDLidar) Drain() (int, error) {
+	var discarded int
+	err := lidar.command(func() (err error) {
+		discarded, err = lidar.drain()
+		return err
+	})
+	return discarded, err
+}
+
+// flush resets the buffers of the transport and drains the link, the caller holds the port.
+func (lidar *YDLidar) flush() error {
+	if err := lidar.resetOutput(); err != nil {
+		return fmt.Errorf("failed to reset output buffer: %w", err)
+	}
+	if err := lidar.resetInput(); err != nil {
+		return fmt.Errorf("failed to reset input buffer: %w", err)
+	}
+	_, err := lidar.drain()
+	return err
+}
+
+// drain discards the bytes read until a read gets nothing within drainPoll.
+func (lidar *YDLidar) drain() (int, error) {
+	if err := lidar.setTimeout(drainPoll); err != nil {
+		return 0, err
+	}
+	deadline := time.Now().Add(drainLimit)
+	buf := make([]byte, 512)
+	discarded := 0
+	for {
+		n, err := lidar.read(buf)
+		discarded += n
+		if err != nil {
+			return discarded, err
+		}
+		if n == 0 {
+			if discarded > 0 {
+				log.Printf("Discarded %v stale bytes", discarded)
+			}
+			return discarded, nil
+		}
+		if time.Now().After(deadline) {
+			return discarded, fmt.Errorf("device still sending after %v, %v bytes discarded", drainLimit, discarded)
+		}
+	}
+}
+
+// connectLink gets a freshly opened link ready: a scan left running by a previous session is
+// stopped, then the link is resynchronized. The caller holds the port.
+func (lidar *YDLidar) connectLink() (*DeviceInfo, error) {
+	if _, err := lidar.SerialPort.Write([]byte{preCommand, stopScanning}); err != nil {
+		return nil, fmt.Errorf("failed to stop stale scan: %w", err)
+	}
+	return lidar.resync()
+}
+
+// resync flushes the link and checks it is in step with a device info round-trip, flushing
+// again after a garbled answer. The caller holds the port.
+func (lidar *YDLidar) resync() (*DeviceInfo, error) {
+	lidar.link.resyncs.Add(1)
+	var err error
+	for attempt := 1; attempt <= syncAttempts; attempt++ {
+		if err = lidar.flush(); err != nil {
+			return nil, err
+		}
+		var info *DeviceInfo
+		info, err = lidar.deviceInfo()
+		if err == nil {
+			return info, nil
+		}
+		if !IsTransient(err) {
+			break
+		}
+		log.Printf("Link out of step, attempt %v: %v", attempt, err)
+	}
+	return nil, fmt.Errorf("link not ready: %w", err)
+}
@@ -0,0 +1,85 @@
+package ydlidar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar/sim"
+)
+
+// garblingPort garbles its first answers to the device info command, like a stream out of
+// step.
+type garblingPort struct {
+	bootingPort
+	garbled int
+}
+
+func (p *garblingPort) Write(b []byte) (int, error) {
+	if b[1] == deviceInfo && p.garbled > 0 {
+		p.garbled--
+		p.fakePort.Write(b)
+		p.queue(0x12, 0xAA, 0x55, 0x00, 0x28, 0x51, 0x3C, 0x00)
+		return len(b), nil
+	}
+	return p.bootingPort.Write(b)
+}
+
+func TestDrain(t *testing.T) {
+	port := &fakePort{}
+	port.queue(make([]byte, 1000)...)
+	lidar := NewLidar(port)
+
+	n, err := lidar.Drain()
+	require.NoError(t, err)
+	assert.Equal(t, 1000, n)
+
+	n, err = lidar.Drain()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestDrainBusyDevice(t *testing.T) {
+	lidar := NewLidar(&fakePort{refill: func() []byte { return make([]byte, 64) }})
+
+	_, err := lidar.Drain()
+	assert.ErrorContains(t, err, "still sending")
+}
+
+func TestFlush(t *testing.T) {
+	port := &bootingPort{}
+	port.queue(0xAA, 0x55, 0x00)
+	lidar := NewLidar(port)
+
+	require.NoError(t, lidar.Flush())
+	assert.Equal(t, 1, port.resets)
+	info, err := lidar.DeviceInfo()
+	require.NoError(t, err)
+	assert.Equal(t, "G2", info.ModelName)
+}
+
+func TestResync(t *testing.T) {
+	port := &garblingPort{garbled: 2}
+	lidar := NewLidar(port)
+
+	info, err := lidar.resync()
+	require.NoError(t, err)
+	assert.Equal(t, "G2", info.ModelName)
+	assert.Equal(t, 3, port.resets)
+
+	port.garbled = syncAttempts
+	_, err = lidar.resync()
+	assert.ErrorIs(t, err, ErrBadHeader)
+}
+
+func TestConnectStopsStaleScan(t *testing.T) {
+	// Left scanning by a previous session.
+	port := sim.NewPort(&sim.Device{})
+	_, err := port.Write([]byte{preCommand, startScanning})
+	require.NoError(t, err)
+
+	lidar, err := ConnectTransport(port)
+	require.NoError(t, err)
+	defer lidar.Close()
+	assert.Equal(t, byte(15), lidar.model)
+}
@@ -0,0 +1,62 @@
+package ydlidar
+
+import "encoding/binary"
+
+// tminiPacketHeader is the PH field starting the scan packets of the T-mini series, 0xAA 0x66
+// on the wire.
+const tminiPacketHeader = 0x66AA
+
+// frameCodec is the scan packet framing of a family of models. The families share the packet
+// layout and the check code but not the PH constant nor the meaning of the CT byte, so the
+// framing is told by the header of each packet and doesn't wait for DeviceInfo.
+type frameCodec struct {
+	name    string
+	header  uint16        // PH, the first word of the scan packets.
+	decoder SampleDecoder // Sample layout until DeviceInfo selects the one of the model.
+
+	// frequencyEveryPacket is set when bits 7:1 of the CT byte carry the scan frequency in
+	// every packet rather than only in the zero packets, the point cloud packets of the other
+	// families leave them 0.
+	frequencyEveryPacket bool
+}
+
+// frameCodecs are the known framings. The G series framing is the documented protocol.
+var frameCodecs = []frameCodec{
+	{name: "G", header: scanPacketHeader, decoder: IntensityDecoder{}},
+	{name: "T-mini", header: tminiPacketHeader, decoder: TminiDecoder{}, frequencyEveryPacket: true},
+}
+
+// codecFor returns the framing of the packet starting with header, false if its PH matches
+// none.
+func codecFor(header []byte) (frameCodec, bool) {
+	if len(header) < 2 {
+		return frameCodec{}, false
+	}
+	ph := binary.LittleEndian.Uint16(header)
+	for _, codec := range frameCodecs {
+		if codec.header == ph {
+			return codec, true
+		}
+	}
+	return frameCodec{}, false
+}
+
+// TminiDecoder decodes the 3 byte samples of the T-mini series: an intensity byte followed by a
+// little endian word holding the distance in its high 14 bits and 2 flag bits in its low bits.
+type TminiDecoder struct{}
+
+// SampleSize returns 3.
+func (TminiDecoder) SampleSize() int { return 3 }
+
+// Decode decodes the 3 byte samples, the flag bits are dropped.
+func (d TminiDecoder) Decode(data []byte) ([]float64, []int) {
+	n := len(data) / d.SampleSize()
+	distances := make([]float64, n)
+	intensities := make([]int, n)
+	for i := range distances {
+		sample := data[3*i:]
+		intensities[i] = int(sample[0])
+		distances[i] = float64(binary.LittleEndian.Uint16(sample[1:]) >> 2)
+	}
+	return distances, intensities
+}
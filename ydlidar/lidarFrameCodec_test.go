@@ -0,0 +1,94 @@
+package ydlidar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeTminiPacket encodes a scan packet in the T-mini framing.
+func encodeTminiPacket(ct byte, fsa, lsa uint16, samples [][3]byte) []byte {
+	packet := encodeScanPacket(ct, fsa, lsa, samples)
+	binary.LittleEndian.PutUint16(packet, tminiPacketHeader)
+	cs := binary.LittleEndian.Uint16(packet[8:]) ^ scanPacketHeader ^ tminiPacketHeader
+	binary.LittleEndian.PutUint16(packet[8:], cs)
+	return packet
+}
+
+// tminiSample is a sample of 1000mm, intensity 200 and both flag bits set.
+var tminiSample = [3]byte{200, 1000<<2&0xFF | 0x03, 1000 << 2 >> 8}
+
+func TestTminiDecoder(t *testing.T) {
+	distances, intensities := TminiDecoder{}.Decode(append(tminiSample[:], 0x10, 0x40, 0x1F))
+	assert.Equal(t, []float64{1000, 2000}, distances)
+	assert.Equal(t, []int{200, 0x10}, intensities)
+}
+
+func TestClassifyTminiPacket(t *testing.T) {
+	assert.Equal(t, CategoryZero, ClassifyPacket(encodeTminiPacket(0xC9, 1, 1, [][3]byte{{}})))
+	assert.Equal(t, CategoryPointCloud, ClassifyPacket(encodeTminiPacket(0xC8, 1, 1, [][3]byte{tminiSample})))
+	assert.Equal(t, CategoryUnknown, ClassifyPacket([]byte{0xAA, 0x77, 0x00}))
+
+	packet := encodeTminiPacket(0xC8, 1, 1, [][3]byte{tminiSample})
+	assert.NoError(t, checkScanPacket(packet[:10], packet[10:], 3))
+}
+
+func TestTminiScan(t *testing.T) {
+	// The zero packet and the point cloud packets report 10Hz in their CT byte.
+	var b bytes.Buffer
+	b.Write(encodeTminiPacket(0xC9, 1, 1, [][3]byte{{}}))
+	samples := make([][3]byte, 40)
+	for i := range samples {
+		samples[i] = tminiSample
+	}
+	for start := 0; start < 360; start += 30 {
+		fsa := uint16(start*64)<<1 | 1
+		lsa := uint16((start+29)*64)<<1 | 1
+		ct := byte(0xC8)
+		if start >= 180 {
+			ct = 0xB4 // Slowed down to 9Hz.
+		}
+		b.Write(encodeTminiPacket(ct, fsa, lsa, samples))
+	}
+
+	port := &fakePort{}
+	port.queue(scanResponseHeader...)
+	port.queue(b.Bytes()...)
+	lidar := NewLidar(port)
+
+	// The framing is detected without DeviceInfo.
+	require.NoError(t, lidar.StartScan())
+	for i := 0; i < 12; i++ {
+		packet := <-lidar.Packets
+		require.NoError(t, packet.Error)
+		assert.Equal(t, 200, packet.Intensities[0])
+		assert.Equal(t, 1000.0, packet.Distances[0])
+		if i < 6 {
+			assert.Equal(t, 10.0, packet.Frequency)
+		} else {
+			assert.Equal(t, 9.0, packet.Frequency)
+		}
+	}
+	require.NoError(t, lidar.StopScan())
+	assert.Zero(t, lidar.Stats().ChecksumFailures)
+}
+
+func TestTminiCapabilities(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port)
+	port.queue(0xA5, 0x5A, 0x14, 0x00, 0x00, 0x00, InfoTypeCode)
+	port.queue(append([]byte{150}, make([]byte, 19)...)...)
+	info, err := lidar.DeviceInfo()
+	require.NoError(t, err)
+	assert.Equal(t, "T-mini Plus", info.ModelName)
+	assert.Equal(t, TminiDecoder{}, lidar.sampleDecoder())
+
+	caps, err := lidar.Capabilities()
+	require.NoError(t, err)
+	assert.True(t, caps.Intensity)
+	assert.False(t, caps.AdjustableScanFrequency)
+	assert.Equal(t, 12000.0, caps.MaxRange)
+}
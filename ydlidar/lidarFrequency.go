@@ -0,0 +1,135 @@
+package ydlidar
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// frequencyMonitor measures the rotation rate from the arrival of the zero packets and
+// compares it with the frequency last set on or read from the device.
+type frequencyMonitor struct {
+	tolerance float64       // Allowed deviation in Hz, 0 disables the alert.
+	last      time.Time     // Arrival of the previous zero packet, scan loop only.
+	measured  atomic.Uint64 // float64 bits of the last measured frequency.
+	commanded atomic.Uint64 // float64 bits of the frequency reported by the device.
+	deviating atomic.Bool
+
+	cadence cadenceFit                 // Fit of the zero packet arrivals, scan loop only.
+	clock   atomic.Pointer[ClockModel] // Last fit of cadence, see ClockModel.
+}
+
+// WithFrequencyAlert emits a FrequencyDeviation event on the Status channel when the measured
+// rotation rate strays more than tolerance Hz from the frequency set with SetMotorSpeed or read
+// with MotorSpeed, eg. on belt slip or a stalled motor, and FrequencyRecovered once it is back.
+func WithFrequencyAlert(tolerance float64) Option {
+	return func(lidar *YDLidar) {
+		lidar.frequency.tolerance = tolerance
+	}
+}
+
+// MeasuredFrequency returns the rotation rate in Hz measured between the last two zero packets,
+// 0 before two have been received.
+func (lidar *YDLidar) MeasuredFrequency() float64 {
+	return math.Float64frombits(lidar.frequency.measured.Load())
+}
+
+// setCommandedFrequency records the scan frequency reported by the device.
+func (lidar *YDLidar) setCommandedFrequency(hz float64) {
+	lidar.frequency.commanded.Store(math.Float64bits(hz))
+}
+
+// observeZeroPacket measures the rotation rate from a zero packet received at now.
+func (lidar *YDLidar) observeZeroPacket(now time.Time) {
+	lidar.observeClock(now)
+	f := &lidar.frequency
+	last := f.last
+	f.last = now
+	if last.IsZero() {
+		return
+	}
+	measured := 1 / now.Sub(last).Seconds()
+	f.measured.Store(math.Float64bits(measured))
+
+	commanded := math.Float64frombits(f.commanded.Load())
+	if f.tolerance <= 0 || commanded == 0 {
+		return
+	}
+	deviating := math.Abs(measured-commanded) > f.tolerance
+	if deviating == f.deviating.Swap(deviating) {
+		return
+	}
+	if deviating {
+		lidar.emitStatus(StatusEvent{Type: FrequencyDeviation,
+			Err: fmt.Errorf("measured scan frequency %.2fHz, commanded %.2fHz", measured, commanded)})
+	} else {
+		lidar.emitStatus(StatusEvent{Type: FrequencyRecovered})
+	}
+}
+
+// resetFrequency forgets the previous zero packet when a new scan starts.
+func (lidar *YDLidar) resetFrequency() {
+	lidar.frequency.last = time.Time{}
+	lidar.frequency.cadence.reset()
+	lidar.frequency.clock.Store(nil)
+}
+
+// stableRevolutions is the number of consecutive revolutions WaitForStableScan needs within
+// the tolerance.
+const stableRevolutions = 3
+
+// WaitForStableScan discards the packets and revolutions of a started scan until the measured
+// rotation rate stays within tolerance Hz of its mean over stableRevolutions consecutive
+// revolutions, as the first revolutions after the motor spins up have an unsteady rate and
+// bad angles. Emits a Stabilized event on the Status channel and returns nil once stable, the
+// packet error if the scan fails, or the context error.
+func (lidar *YDLidar) WaitForStableScan(ctx context.Context, tolerance float64) error {
+	if !lidar.IsScanning() {
+		return fmt.Errorf("ydlidar: WaitForStableScan needs a started scan")
+	}
+	var rates []float64
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-lidar.Scans:
+			// Discarded, the rate is taken from the packets.
+		case packet := <-lidar.Packets:
+			if packet.Error != nil {
+				return packet.Error
+			}
+			if !packet.IsZeroStart {
+				continue
+			}
+			hz := lidar.MeasuredFrequency()
+			if hz <= 0 {
+				continue
+			}
+			rates = append(rates, hz)
+			if len(rates) > stableRevolutions {
+				rates = rates[1:]
+			}
+			if len(rates) == stableRevolutions && withinMean(rates, tolerance) {
+				lidar.emitStatus(StatusEvent{Type: Stabilized})
+				return nil
+			}
+		}
+	}
+}
+
+// withinMean reports whether every value is within tolerance of the mean of the values.
+func withinMean(values []float64, tolerance float64) bool {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		if math.Abs(v-mean) > tolerance {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package ydlidar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasuredFrequency(t *testing.T) {
+	lidar := NewLidar(&fakePort{}, WithFrequencyAlert(0.5))
+	lidar.setCommandedFrequency(10)
+
+	t0 := time.Now()
+	lidar.observeZeroPacket(t0)
+	assert.Equal(t, 0.0, lidar.MeasuredFrequency())
+
+	lidar.observeZeroPacket(t0.Add(100 * time.Millisecond))
+	assert.InDelta(t, 10, lidar.MeasuredFrequency(), 1e-9)
+	assert.Empty(t, lidar.Status)
+
+	// The motor slows down to 8Hz, then comes back.
+	lidar.observeZeroPacket(t0.Add(225 * time.Millisecond))
+	lidar.observeZeroPacket(t0.Add(350 * time.Millisecond))
+	lidar.observeZeroPacket(t0.Add(450 * time.Millisecond))
+	assert.InDelta(t, 10, lidar.Metrics().MeasuredFrequency, 1e-9)
+
+	deviation := <-lidar.Status
+	assert.Equal(t, FrequencyDeviation, deviation.Type)
+	assert.EqualError(t, deviation.Err, "measured scan frequency 8.00Hz, commanded 10.00Hz")
+	assert.Equal(t, FrequencyRecovered, (<-lidar.Status).Type)
+	assert.Empty(t, lidar.Status)
+}
+
+func TestScanFrequency(t *testing.T) {
+	a := &scanAssembler{}
+	a.startRevolution()
+	a.add(testPacket(0, 10))
+	a.current.Start = a.current.Start.Add(-100 * time.Millisecond)
+	scan, ok := a.startRevolution()
+	assert.True(t, ok)
+	assert.InDelta(t, 10, scan.Frequency, 0.5)
+}
+
+func TestWaitForStableScan(t *testing.T) {
+	// The motor spins up over the first revolutions, then turns steadily at 25Hz.
+	periods := []time.Duration{120 * time.Millisecond, 80 * time.Millisecond, 60 * time.Millisecond}
+	revolution := revolutionBytes()
+	revolutions := 0
+	p := &fakePort{refill: func() []byte {
+		period := 40 * time.Millisecond
+		if revolutions < len(periods) {
+			period = periods[revolutions]
+		}
+		revolutions++
+		time.Sleep(period)
+		return revolution
+	}}
+	p.queue(scanResponseHeader...)
+	lidar := NewLidar(p, WithScans(1))
+	require.NoError(t, lidar.StartScan())
+	defer lidar.StopScan()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, lidar.WaitForStableScan(ctx, 5))
+	assert.InDelta(t, 25, lidar.MeasuredFrequency(), 5)
+	assert.Equal(t, Stabilized, nextStatus(t, lidar).Type)
+}
+
+func TestWaitForStableScanTimeout(t *testing.T) {
+	lidar := NewLidar(&fakePort{})
+	assert.Error(t, lidar.WaitForStableScan(context.Background(), 1), "not scanning")
+
+	// The motor never settles.
+	revolution := revolutionBytes()
+	period := 20 * time.Millisecond
+	p := &fakePort{refill: func() []byte {
+		period += 15 * time.Millisecond
+		time.Sleep(period)
+		return revolution
+	}}
+	p.queue(scanResponseHeader...)
+	lidar = NewLidar(p)
+	require.NoError(t, lidar.StartScan())
+	defer lidar.StopScan()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, lidar.WaitForStableScan(ctx, 1), context.DeadlineExceeded)
+}
+
+func TestWithinMean(t *testing.T) {
+	assert.True(t, withinMean([]float64{10, 10.2, 9.9}, 0.2))
+	assert.False(t, withinMean([]float64{8, 10, 10}, 0.5))
+}
@@ -0,0 +1,187 @@
+package ydlidar
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// healthBufferSize is the capacity of the Health channel. Reports are dropped when it is full.
+const healthBufferSize = 4
+
+// HealthSeverity is the status byte of the health response.
+type HealthSeverity byte
+
+const (
+	// SeverityOK the device is operating normally.
+	SeverityOK HealthSeverity = 0x0
+
+	// SeverityWarning the device reported a warning, it keeps working.
+	SeverityWarning HealthSeverity = 0x1
+
+	// SeverityError the device reported an error, data can't be trusted.
+	SeverityError HealthSeverity = 0x2
+)
+
+// String returns the name of the severity.
+func (s HealthSeverity) String() string {
+	switch s {
+	case SeverityOK:
+		return "OK"
+	case SeverityWarning:
+		return "Warning"
+	case SeverityError:
+		return "Error"
+	}
+	return fmt.Sprintf("HealthSeverity(%d)", byte(s))
+}
+
+// Error codes of the health response, from the error table of the YDLidar SDK. The device
+// may report several of them at once, or'ed together.
+const (
+	HealthNoError              uint16 = 0x0
+	HealthDeviceNotFound       uint16 = 0x1
+	HealthPermission           uint16 = 0x2
+	HealthUnsupportedOperation uint16 = 0x4
+	HealthUnknown              uint16 = 0x8
+	HealthTimeout              uint16 = 0x10
+	HealthNotOpen              uint16 = 0x20
+	HealthBlocked              uint16 = 0x40
+	HealthNoBuffer             uint16 = 0x80
+	HealthTremble              uint16 = 0x100
+	HealthLaserFailure         uint16 = 0x200
+)
+
+// HealthReport describes an error code of the health response.
+type HealthReport struct {
+	Code        uint16 // Error code reported by the device.
+	Name        string // Name of the code in the SDK, eg. LaserFailure.
+	Description string // Human readable description.
+	Fatal       bool   // The device needs service, neither a retry nor a reboot will clear it.
+}
+
+// String returns the name and the description of the code.
+func (r HealthReport) String() string {
+	return fmt.Sprintf("%v (%#04x): %v", r.Name, r.Code, r.Description)
+}
+
+// healthCatalog is the error table of the SDK.
+var healthCatalog = []HealthReport{
+	{Code: HealthNoError, Name: "NoError", Description: "device is operating optimally"},
+	{Code: HealthDeviceNotFound, Name: "DeviceNotFound", Description: "device not found"},
+	{Code: HealthPermission, Name: "Permission", Description: "operation not permitted"},
+	{Code: HealthUnsupportedOperation, Name: "UnsupportedOperation", Description: "operation not supported by the device"},
+	{Code: HealthUnknown, Name: "Unknown", Description: "unknown error"},
+	{Code: HealthTimeout, Name: "Timeout", Description: "operation timed out"},
+	{Code: HealthNotOpen, Name: "NotOpen", Description: "device not open"},
+	{Code: HealthBlocked, Name: "Blocked", Description: "the rotation is blocked, check nothing rubs on the head"},
+	{Code: HealthNoBuffer, Name: "NoBuffer", Description: "device failed, no data buffer"},
+	{Code: HealthTremble, Name: "Tremble", Description: "the rotation is unsteady, check the mounting for vibrations"},
+	{Code: HealthLaserFailure, Name: "LaserFailure", Description: "laser failure", Fatal: true},
+}
+
+// NewHealthReport returns the report of an error code. A combination of codes is reported
+// with the names and descriptions of each joined, fatal if any is.
+func NewHealthReport(code uint16) HealthReport {
+	if code == HealthNoError {
+		return healthCatalog[0]
+	}
+	report := HealthReport{Code: code}
+	known := uint16(0)
+	for _, entry := range healthCatalog[1:] {
+		if code&entry.Code == 0 {
+			continue
+		}
+		known |= entry.Code
+		if report.Name != "" {
+			report.Name += "|"
+			report.Description += ", "
+		}
+		report.Name += entry.Name
+		report.Description += entry.Description
+		report.Fatal = report.Fatal || entry.Fatal
+	}
+	if known != code {
+		if report.Name != "" {
+			report.Name += "|"
+			report.Description += ", "
+		}
+		report.Name += fmt.Sprintf("HealthCode(%#04x)", code&^known)
+		report.Description += "undocumented error code"
+	}
+	return report
+}
+
+// HealthStatus is one report of the health monitor.
+type HealthStatus struct {
+	Code         uint16         // Error code reported by the device, 0 when healthy.
+	Severity     HealthSeverity // Status byte reported by the device.
+	Description  string         // Human readable summary.
+	Report       HealthReport   // Description of the error code.
+	MotorRunning bool           // The scan resumed after the query, so the motor is spinning.
+	Err          error          // Set when the device could not be queried, the other fields are then unset.
+	Time         time.Time      // When the query completed.
+}
+
+// newHealthStatus decodes the health response.
+func newHealthStatus(data []byte) HealthStatus {
+	status := HealthStatus{
+		Severity: HealthSeverity(data[0]),
+		Code:     uint16(data[1]) | uint16(data[2])<<8,
+	}
+	status.Report = NewHealthReport(status.Code)
+	switch status.Severity {
+	case SeverityOK:
+		status.Description = "device is operating optimally"
+	default:
+		status.Description = fmt.Sprintf("device reported %v, %v", status.Severity, status.Report)
+	}
+	return status
+}
+
+// monitorHealth polls the device health until Close is called.
+func (lidar *YDLidar) monitorHealth() {
+	defer lidar.monitors.Done()
+	ticker := time.NewTicker(lidar.healthPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lidar.quit:
+			return
+		case <-ticker.C:
+		}
+
+		status := lidar.pollHealth()
+		if lidar.recovery.policy != nil && lidar.recovery.policy(status) {
+			status = lidar.recoverHealth(status)
+		}
+		select {
+		case lidar.Health <- status:
+		default:
+			lidar.metrics.dropped.Add(1)
+			log.Printf("Health channel full, dropping report: %v", status.Description)
+		}
+	}
+}
+
+// pollHealth queries the health, pausing the running scan, if any.
+func (lidar *YDLidar) pollHealth() HealthStatus {
+	scanning := lidar.IsScanning()
+	var data []byte
+	err := lidar.command(func() (err error) {
+		data, err = lidar.readHealth()
+		return err
+	})
+
+	var status HealthStatus
+	if data != nil {
+		// A report along with the error resuming the scan.
+		status = newHealthStatus(data)
+	}
+	status.Err = err
+	status.MotorRunning = scanning && lidar.IsScanning()
+	status.Time = time.Now()
+
+	return status
+}
@@ -0,0 +1,41 @@
+package ydlidar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHealthReport(t *testing.T) {
+	ok := NewHealthReport(HealthNoError)
+	assert.Equal(t, "NoError", ok.Name)
+	assert.False(t, ok.Fatal)
+
+	laser := NewHealthReport(HealthLaserFailure)
+	assert.Equal(t, "LaserFailure", laser.Name)
+	assert.Equal(t, "laser failure", laser.Description)
+	assert.True(t, laser.Fatal)
+	assert.False(t, NewHealthReport(HealthTremble).Fatal)
+
+	combined := NewHealthReport(HealthTremble | HealthLaserFailure | 0x8000)
+	assert.Equal(t, uint16(0x8300), combined.Code)
+	assert.Equal(t, "Tremble|LaserFailure|HealthCode(0x8000)", combined.Name)
+	assert.True(t, combined.Fatal)
+}
+
+func TestHealthInfoReport(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port)
+
+	port.queue(healthResponse(SeverityOK, 0)...)
+	report, err := lidar.HealthInfo()
+	require.NoError(t, err)
+	assert.Equal(t, HealthNoError, report.Code)
+
+	port.queue(healthResponse(SeverityWarning, HealthTremble)...)
+	report, err = lidar.HealthInfo()
+	assert.Error(t, err)
+	assert.Equal(t, "Tremble", report.Name)
+	assert.Contains(t, err.Error(), "unsteady")
+}
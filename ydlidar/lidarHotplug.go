@@ -0,0 +1,207 @@
+package ydlidar
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// HotplugEventType identifies the kind of HotplugEvent.
+type HotplugEventType int
+
+const (
+	// Attached a candidate serial port appeared, eg. a lidar was plugged in.
+	Attached HotplugEventType = iota
+
+	// Detached a serial port disappeared, eg. a lidar was unplugged.
+	Detached
+)
+
+// String returns the name of the event type.
+func (t HotplugEventType) String() string {
+	switch t {
+	case Attached:
+		return "Attached"
+	case Detached:
+		return "Detached"
+	}
+	return fmt.Sprintf("HotplugEventType(%d)", int(t))
+}
+
+// HotplugEvent reports a serial port that appeared or disappeared.
+type HotplugEvent struct {
+	Type  HotplugEventType // What happened.
+	Port  string           // Name of the port.
+	Lidar *YDLidar         // Lidar started on the port with WithAutoStart, closed already when Detached. nil otherwise.
+	Err   error            // Why the lidar couldn't be started on an Attached port.
+	Time  time.Time        // When the change was noticed.
+}
+
+// HotplugOption configures a HotplugWatcher.
+type HotplugOption func(*HotplugWatcher)
+
+// WithAutoStart connects to the lidar on every attached port with the options and starts
+// scanning. The lidar is sent in the Attached event, and closed when its port is detached.
+func WithAutoStart(opts ...Option) HotplugOption {
+	return func(w *HotplugWatcher) {
+		w.autoStart = true
+		w.lidarOptions = opts
+	}
+}
+
+// HotplugWatcher polls the serial ports of the platform and reports the candidate ports,
+// see CandidatePorts, that appear and disappear. The ports present when the watcher starts
+// are reported as attached.
+type HotplugWatcher struct {
+	Events chan HotplugEvent // Attach and detach events, in order. Must be drained.
+
+	interval     time.Duration
+	autoStart    bool
+	lidarOptions []Option
+	lidars       map[string]*YDLidar // Started by WithAutoStart, by port.
+
+	// Hooks replaced by the tests.
+	list    func() ([]string, error)
+	connect func(port string, opts ...Option) (*YDLidar, error)
+
+	quit      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// WatchPorts starts watching the serial ports, polling them every interval, 1s if 0.
+// Close stops it.
+func WatchPorts(interval time.Duration, opts ...HotplugOption) *HotplugWatcher {
+	w := newHotplugWatcher(interval, opts)
+	go w.run()
+	return w
+}
+
+// newHotplugWatcher returns a watcher not polling yet.
+func newHotplugWatcher(interval time.Duration, opts []HotplugOption) *HotplugWatcher {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	w := &HotplugWatcher{
+		Events:   make(chan HotplugEvent),
+		interval: interval,
+		lidars:   map[string]*YDLidar{},
+		list:     CandidatePorts,
+		connect: func(port string, opts ...Option) (*YDLidar, error) {
+			return InitAndConnectToDevice(&port, opts...)
+		},
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// run polls the ports until Close is called.
+func (w *HotplugWatcher) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	present := map[string]bool{}
+	for {
+		if !w.poll(present) {
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// poll compares the ports with the ones present at the previous poll and sends the events.
+// Returns false once the watcher is closed.
+func (w *HotplugWatcher) poll(present map[string]bool) bool {
+	ports, err := w.list()
+	if err != nil {
+		// Transient on some platforms while a device enumerates, the next poll retries.
+		log.Printf("Failed to list the serial ports: %v", err)
+		return true
+	}
+	now := map[string]bool{}
+	for _, port := range ports {
+		now[port] = true
+	}
+
+	for port := range present {
+		if now[port] {
+			continue
+		}
+		delete(present, port)
+		event := HotplugEvent{Type: Detached, Port: port, Lidar: w.lidars[port], Time: time.Now()}
+		if event.Lidar != nil {
+			delete(w.lidars, port)
+			if err := event.Lidar.Close(); err != nil {
+				log.Printf("Failed to close the lidar on %v: %v", port, err)
+			}
+		}
+		if !w.send(event) {
+			return false
+		}
+	}
+	for _, port := range ports {
+		if present[port] {
+			continue
+		}
+		present[port] = true
+		event := HotplugEvent{Type: Attached, Port: port, Time: time.Now()}
+		if w.autoStart {
+			event.Lidar, event.Err = w.start(port)
+		}
+		if !w.send(event) {
+			return false
+		}
+	}
+	return true
+}
+
+// start connects to the lidar on the port and starts scanning.
+func (w *HotplugWatcher) start(port string) (*YDLidar, error) {
+	lidar, err := w.connect(port, w.lidarOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if err = lidar.StartScan(); err != nil {
+		lidar.Close()
+		return nil, err
+	}
+	w.lidars[port] = lidar
+	return lidar, nil
+}
+
+// send sends the event, returns false if the watcher was closed meanwhile.
+func (w *HotplugWatcher) send(event HotplugEvent) bool {
+	select {
+	case w.Events <- event:
+		return true
+	case <-w.quit:
+		return false
+	}
+}
+
+// Close stops watching and closes the lidars started by WithAutoStart. It waits for the
+// poll in progress, if any, to finish.
+func (w *HotplugWatcher) Close() error {
+	var first error
+	w.closeOnce.Do(func() {
+		close(w.quit)
+		<-w.done
+		for port, lidar := range w.lidars {
+			if err := lidar.Close(); err != nil && first == nil {
+				first = fmt.Errorf("lidar on %v: %v", port, err)
+			}
+		}
+		w.lidars = nil
+	})
+	return first
+}
@@ -0,0 +1,96 @@
+package ydlidar
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// plugPorts is the list of ports of a test host, changed as lidars are plugged in and out.
+type plugPorts struct {
+	mu    sync.Mutex
+	ports []string
+}
+
+func (p *plugPorts) set(ports ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ports = ports
+}
+
+func (p *plugPorts) list() ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.ports...), nil
+}
+
+func nextHotplugEvent(t *testing.T, w *HotplugWatcher) HotplugEvent {
+	select {
+	case event := <-w.Events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no hotplug event")
+	}
+	return HotplugEvent{}
+}
+
+func TestWatchPorts(t *testing.T) {
+	host := &plugPorts{}
+	host.set("/dev/ttyUSB0")
+	w := newHotplugWatcher(5*time.Millisecond, nil)
+	w.list = host.list
+	go w.run()
+	defer w.Close()
+
+	event := nextHotplugEvent(t, w)
+	assert.Equal(t, Attached, event.Type, "present at start")
+	assert.Equal(t, "/dev/ttyUSB0", event.Port)
+	assert.Nil(t, event.Lidar)
+
+	host.set("/dev/ttyUSB0", "/dev/ttyUSB1")
+	event = nextHotplugEvent(t, w)
+	assert.Equal(t, Attached, event.Type)
+	assert.Equal(t, "/dev/ttyUSB1", event.Port)
+
+	host.set("/dev/ttyUSB1")
+	event = nextHotplugEvent(t, w)
+	assert.Equal(t, Detached, event.Type)
+	assert.Equal(t, "/dev/ttyUSB0", event.Port)
+	assert.Equal(t, "Detached", event.Type.String())
+}
+
+func TestWatchPortsAutoStart(t *testing.T) {
+	host := &plugPorts{}
+	host.set("/dev/ttyUSB0", "/dev/ttyUSB1")
+	revolution := revolutionBytes()
+	w := newHotplugWatcher(5*time.Millisecond, []HotplugOption{WithAutoStart(WithScans(1))})
+	w.list = host.list
+	w.connect = func(port string, opts ...Option) (*YDLidar, error) {
+		if port == "/dev/ttyUSB1" {
+			return nil, errors.New("not a lidar")
+		}
+		p := &fakePort{refill: func() []byte { return revolution }}
+		p.queue(scanResponseHeader...)
+		return NewLidar(p, opts...), nil
+	}
+	go w.run()
+
+	started := nextHotplugEvent(t, w)
+	require.NotNil(t, started.Lidar)
+	assert.True(t, started.Lidar.IsScanning())
+	failed := nextHotplugEvent(t, w)
+	assert.Equal(t, "/dev/ttyUSB1", failed.Port)
+	assert.Error(t, failed.Err)
+	assert.Nil(t, failed.Lidar)
+
+	host.set("/dev/ttyUSB1")
+	event := nextHotplugEvent(t, w)
+	assert.Equal(t, Detached, event.Type)
+	assert.Same(t, started.Lidar, event.Lidar)
+	assert.False(t, event.Lidar.IsScanning(), "closed with its port")
+	require.NoError(t, w.Close())
+}
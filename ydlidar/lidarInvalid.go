@@ -0,0 +1,105 @@
+package ydlidar
+
+import (
+	"fmt"
+	"math"
+)
+
+// InvalidValue selects the distance standing for a sample without a return, in the packets
+// and scans delivered.
+type InvalidValue int
+
+const (
+	// InvalidZero leaves the 0 the device sends. This is the default.
+	InvalidZero InvalidValue = iota
+
+	// InvalidNaN substitutes NaN, for numeric code skipping the missing values.
+	InvalidNaN
+
+	// InvalidInf substitutes +Inf, the no return of ROS LaserScan.
+	InvalidInf
+
+	// InvalidMaxRange substitutes the maximum range of the model, for consumers taking a
+	// dropout for free space. It can't be combined with ClampSaturated, whose clamped returns
+	// would be taken for dropouts.
+	InvalidMaxRange
+)
+
+// WithInvalidValue sets the distance of the samples without a return in the packets and scans
+// delivered, 0 by default. The filters and scan processors still see 0, the substitution is
+// made on delivery. Consumers tell the returns with IsReturn.
+func WithInvalidValue(value InvalidValue) Option {
+	return func(lidar *YDLidar) {
+		lidar.invalidValue = value
+	}
+}
+
+// checkOptions returns ErrConflictingOptions for the options that can't be combined.
+func (lidar *YDLidar) checkOptions() error {
+	if lidar.invalidValue == InvalidMaxRange && lidar.saturation == ClampSaturated {
+		return fmt.Errorf("%w: ClampSaturated clamps the returns to the maximum range, InvalidMaxRange makes it the distance of the dropouts", ErrConflictingOptions)
+	}
+	return nil
+}
+
+// IsReturn reports whether the distance is a return rather than the invalid value of the packet
+// or scan. 0, negative distances, NaN and infinities are never returns.
+func IsReturn(dist, invalid float64) bool {
+	return dist > 0 && !math.IsInf(dist, 1) && dist != invalid
+}
+
+// IsReturn reports whether the distance of a point of the scan is a return.
+func (s Scan) IsReturn(dist float64) bool {
+	return IsReturn(dist, s.Invalid)
+}
+
+// IsReturn reports whether a distance of the packet is a return.
+func (p Packet) IsReturn(dist float64) bool {
+	return IsReturn(dist, p.Invalid)
+}
+
+// invalidDistance returns the distance substituted for the samples without a return, in the
+// unit of the lidar. The maximum range is that of the G2 until the device info is read.
+func (lidar *YDLidar) invalidDistance() float64 {
+	switch lidar.invalidValue {
+	case InvalidNaN:
+		return math.NaN()
+	case InvalidInf:
+		return math.Inf(1)
+	case InvalidMaxRange:
+		spec, ok := models[lidar.model]
+		if !ok {
+			spec = models[15]
+		}
+		return lidar.units.FromMillimeters(spec.maxRange)
+	}
+	return 0
+}
+
+// markInvalidPacket substitutes the invalid value for the distances of the packet without a
+// return. The distances must not be shared.
+func (lidar *YDLidar) markInvalidPacket(packet *Packet) {
+	if lidar.invalidValue == InvalidZero {
+		return
+	}
+	packet.Invalid = lidar.invalidDistance()
+	for i, d := range packet.Distances {
+		if d <= 0 {
+			packet.Distances[i] = packet.Invalid
+		}
+	}
+}
+
+// markInvalidScan substitutes the invalid value for the distances of the points of the scan
+// without a return.
+func (lidar *YDLidar) markInvalidScan(scan *Scan) {
+	if lidar.invalidValue == InvalidZero {
+		return
+	}
+	scan.Invalid = lidar.invalidDistance()
+	for i, point := range scan.Points {
+		if point.Dist <= 0 {
+			scan.Points[i].Dist = scan.Invalid
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package ydlidar
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar/geom"
+	"ydlidarg2/ydlidar/sim"
+)
+
+func TestIsReturn(t *testing.T) {
+	assert.True(t, IsReturn(1000, 0))
+	assert.False(t, IsReturn(0, 0))
+	assert.False(t, IsReturn(-1, 0))
+	assert.False(t, IsReturn(math.NaN(), math.NaN()))
+	assert.False(t, IsReturn(math.Inf(1), math.Inf(1)))
+	assert.False(t, IsReturn(12000, 12000))
+	assert.True(t, IsReturn(11999, 12000))
+	// NaN and +Inf are never returns, even with another invalid value.
+	assert.False(t, IsReturn(math.NaN(), 0))
+	assert.False(t, IsReturn(math.Inf(1), 0))
+}
+
+func TestInvalidDistance(t *testing.T) {
+	lidar := NewLidar(&fakePort{})
+	assert.Equal(t, 0.0, lidar.invalidDistance())
+
+	lidar = NewLidar(&fakePort{}, WithInvalidValue(InvalidNaN))
+	assert.True(t, math.IsNaN(lidar.invalidDistance()))
+
+	lidar = NewLidar(&fakePort{}, WithInvalidValue(InvalidInf))
+	assert.True(t, math.IsInf(lidar.invalidDistance(), 1))
+
+	lidar = NewLidar(&fakePort{}, WithInvalidValue(InvalidMaxRange), WithUnits(Meters))
+	assert.Equal(t, 12.0, lidar.invalidDistance(), "G2 until the device info is read")
+	lidar.model = 5
+	assert.Equal(t, 16.0, lidar.invalidDistance())
+}
+
+func TestMarkInvalid(t *testing.T) {
+	lidar := NewLidar(&fakePort{}, WithInvalidValue(InvalidMaxRange))
+
+	packet := Packet{Distances: []float64{1000, 0, 500}}
+	lidar.markInvalidPacket(&packet)
+	assert.Equal(t, []float64{1000, 12000, 500}, packet.Distances)
+	assert.Equal(t, 12000.0, packet.Invalid)
+	assert.False(t, packet.IsReturn(packet.Distances[1]))
+
+	scan := Scan{Points: pointsAt(1, 2, 3)}
+	scan.Points[1].Dist = 0
+	lidar.markInvalidScan(&scan)
+	assert.Equal(t, []float64{1, 12000, 3}, dists(scan.Points))
+	assert.Equal(t, 2, scan.Sectors(1)[0].Points)
+	assert.Len(t, scan.Transform(geom.Pose2D{}), 2)
+
+	// The default leaves the zeros.
+	packet = Packet{Distances: []float64{0}}
+	NewLidar(&fakePort{}).markInvalidPacket(&packet)
+	assert.Equal(t, []float64{0}, packet.Distances)
+}
+
+func TestWithInvalidValue(t *testing.T) {
+	// The walls beyond the maximum range give dropouts.
+	lidar := NewLidar(sim.NewPort(&sim.Device{Environment: sim.Room(40000, 3000)}), WithScans(4), WithInvalidValue(InvalidNaN))
+	require.NoError(t, lidar.StartScan())
+	defer lidar.Close()
+
+	dropouts := 0
+	for dropouts == 0 {
+		packet := <-lidar.Packets
+		require.NoError(t, packet.Error)
+		assert.True(t, math.IsNaN(packet.Invalid))
+		for _, d := range packet.Distances {
+			assert.True(t, packet.IsReturn(d) || math.IsNaN(d), d)
+			if math.IsNaN(d) {
+				dropouts++
+			}
+		}
+	}
+	go func() {
+		for range lidar.Packets {
+		}
+	}()
+
+	scan := <-lidar.Scans
+	assert.True(t, math.IsNaN(scan.Invalid))
+	nan := 0
+	for _, point := range scan.Points {
+		if math.IsNaN(point.Dist) {
+			nan++
+		}
+	}
+	assert.NotZero(t, nan)
+	assert.Equal(t, scan.Stats.Points-scan.Stats.Valid, nan, "statistics of the returns")
+	assert.False(t, math.IsNaN(scan.Stats.MeanRange))
+}
+
+func TestConflictingOptions(t *testing.T) {
+	// A return clamped to the maximum range would be taken for a dropout.
+	device := &sim.Device{Environment: sim.Room(4000, 3000)}
+	_, err := ConnectTransport(sim.NewPort(device), WithInvalidValue(InvalidMaxRange), WithSaturation(ClampSaturated))
+	assert.ErrorIs(t, err, ErrConflictingOptions)
+
+	for _, opts := range [][]Option{
+		{WithInvalidValue(InvalidMaxRange), WithSaturation(DropSaturated)},
+		{WithInvalidValue(InvalidNaN), WithSaturation(ClampSaturated)},
+	} {
+		lidar := NewLidar(&fakePort{}, opts...)
+		assert.NoError(t, lidar.checkOptions())
+	}
+}
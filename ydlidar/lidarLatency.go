@@ -0,0 +1,178 @@
+package ydlidar
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Stage is a step of the path of a packet from the serial port to the consumers.
+type Stage int
+
+const (
+	// StageRead reading the samples of the packet once its header arrived.
+	StageRead Stage = iota
+
+	// StageParse checking and decoding the packet.
+	StageParse
+
+	// StageFilter running the filters, see WithFilters.
+	StageFilter
+
+	// StagePublish handing the packet to the subscribers and the Packets channel, which
+	// includes waiting for slow consumers.
+	StagePublish
+
+	stageCount
+)
+
+// Stages lists the stages in the order a packet goes through them.
+var Stages = []Stage{StageRead, StageParse, StageFilter, StagePublish}
+
+// String returns the name of the stage.
+func (s Stage) String() string {
+	switch s {
+	case StageRead:
+		return "read"
+	case StageParse:
+		return "parse"
+	case StageFilter:
+		return "filter"
+	case StagePublish:
+		return "publish"
+	}
+	return fmt.Sprintf("Stage(%d)", int(s))
+}
+
+// StageObserver receives the time every packet spent in every stage, eg. to record tracing
+// spans. It is called by the scan loop and must return quickly.
+type StageObserver interface {
+	ObserveStage(stage Stage, start, end time.Time)
+}
+
+// WithStageObserver sends the stage timings to the observer, see the tracing package for
+// OpenTelemetry, instead of the built-in LatencyHistogram.
+func WithStageObserver(observer StageObserver) Option {
+	return func(lidar *YDLidar) {
+		lidar.stageObserver = observer
+	}
+}
+
+// latencyBuckets are the upper bounds of the LatencyHistogram buckets.
+var latencyBuckets = [...]time.Duration{
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	time.Second,
+}
+
+// LatencyHistogram is the StageObserver used without WithStageObserver: it counts the stage
+// durations in fixed buckets from 50µs to 1s. It is safe for concurrent use.
+type LatencyHistogram struct {
+	stages [stageCount]stageHistogram
+}
+
+// stageHistogram counts the durations of a stage, the last bucket holds the ones above 1s.
+type stageHistogram struct {
+	counts [len(latencyBuckets) + 1]atomic.Uint64
+	sum    atomic.Int64 // Nanoseconds.
+}
+
+// ObserveStage counts the duration of the stage.
+func (h *LatencyHistogram) ObserveStage(stage Stage, start, end time.Time) {
+	if stage < 0 || stage >= stageCount {
+		return
+	}
+	d := end.Sub(start)
+	bucket := len(latencyBuckets)
+	for i, bound := range latencyBuckets {
+		if d <= bound {
+			bucket = i
+			break
+		}
+	}
+	s := &h.stages[stage]
+	s.counts[bucket].Add(1)
+	s.sum.Add(int64(d))
+}
+
+// Stage returns the durations counted for the stage.
+func (h *LatencyHistogram) Stage(stage Stage) StageLatency {
+	latency := StageLatency{Buckets: make([]LatencyBucket, len(latencyBuckets)+1)}
+	if stage < 0 || stage >= stageCount {
+		return latency
+	}
+	s := &h.stages[stage]
+	for i := range latency.Buckets {
+		latency.Count += s.counts[i].Load()
+		latency.Buckets[i].Count = latency.Count
+		latency.Buckets[i].UpperBound = -1
+		if i < len(latencyBuckets) {
+			latency.Buckets[i].UpperBound = latencyBuckets[i]
+		}
+	}
+	latency.Sum = time.Duration(s.sum.Load())
+	return latency
+}
+
+// StageLatency is a snapshot of the durations of a stage.
+type StageLatency struct {
+	Count   uint64          // Packets observed.
+	Sum     time.Duration   // Total time spent in the stage.
+	Buckets []LatencyBucket // Cumulative counts, the last bucket is unbounded.
+}
+
+// LatencyBucket counts the durations up to UpperBound, -1 for the unbounded last bucket.
+type LatencyBucket struct {
+	UpperBound time.Duration
+	Count      uint64 // Durations at most UpperBound, cumulative.
+}
+
+// Mean returns the mean duration, 0 without observations.
+func (l StageLatency) Mean() time.Duration {
+	if l.Count == 0 {
+		return 0
+	}
+	return l.Sum / time.Duration(l.Count)
+}
+
+// Quantile returns the upper bound of the bucket holding the q quantile, eg. 0.99, -1 if it
+// is above the last bound and 0 without observations.
+func (l StageLatency) Quantile(q float64) time.Duration {
+	if l.Count == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(l.Count))
+	if rank == 0 {
+		rank = 1
+	}
+	for _, bucket := range l.Buckets {
+		if bucket.Count >= rank {
+			return bucket.UpperBound
+		}
+	}
+	return -1
+}
+
+// Latency returns the built-in histogram of the stage durations, nil if WithStageObserver
+// replaced it.
+func (lidar *YDLidar) Latency() *LatencyHistogram {
+	histogram, _ := lidar.stageObserver.(*LatencyHistogram)
+	return histogram
+}
+
+// observeStage reports a stage of the packet being processed.
+func (lidar *YDLidar) observeStage(stage Stage, start, end time.Time) {
+	if lidar.stageObserver != nil {
+		lidar.stageObserver.ObserveStage(stage, start, end)
+	}
+}
@@ -0,0 +1,72 @@
+package ydlidar
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	h := &LatencyHistogram{}
+	t0 := time.Now()
+	h.ObserveStage(StageParse, t0, t0.Add(80*time.Microsecond))
+	h.ObserveStage(StageParse, t0, t0.Add(80*time.Microsecond))
+	h.ObserveStage(StageParse, t0, t0.Add(3*time.Millisecond))
+	h.ObserveStage(StageParse, t0, t0.Add(2*time.Second))
+
+	latency := h.Stage(StageParse)
+	assert.Equal(t, uint64(4), latency.Count)
+	assert.Equal(t, 2*time.Second+3*time.Millisecond+160*time.Microsecond, latency.Sum)
+	assert.Equal(t, latency.Sum/4, latency.Mean())
+	assert.Equal(t, 100*time.Microsecond, latency.Quantile(0.5))
+	assert.Equal(t, 5*time.Millisecond, latency.Quantile(0.75))
+	assert.Equal(t, time.Duration(-1), latency.Quantile(1))
+	assert.Equal(t, uint64(4), latency.Buckets[len(latency.Buckets)-1].Count, "cumulative")
+
+	assert.Zero(t, h.Stage(StageRead).Count)
+	assert.Zero(t, h.Stage(StageRead).Quantile(0.99))
+	assert.Equal(t, "publish", StagePublish.String())
+}
+
+// stageRecorder records the stages observed.
+type stageRecorder struct {
+	mu     sync.Mutex
+	stages []Stage
+}
+
+func (r *stageRecorder) ObserveStage(stage Stage, start, end time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !end.Before(start) {
+		r.stages = append(r.stages, stage)
+	}
+}
+
+func TestStageObserver(t *testing.T) {
+	p := &fakePort{}
+	p.queue(scanResponseHeader...)
+	p.queue(encodeScanPacket(0x00, 0x0001, 0x0001, [][3]byte{{0, 0x10, 0x10}})...)
+	lidar := NewLidar(p)
+	require.NotNil(t, lidar.Latency())
+	require.NoError(t, lidar.StartScan())
+	<-lidar.Packets
+	require.NoError(t, lidar.StopScan())
+	assert.Equal(t, uint64(1), lidar.Latency().Stage(StageParse).Count)
+
+	recorder := &stageRecorder{}
+	p = &fakePort{}
+	p.queue(scanResponseHeader...)
+	p.queue(encodeScanPacket(0x00, 0x0001, 0x0001, [][3]byte{{0, 0x10, 0x10}})...)
+	lidar = NewLidar(p, WithStageObserver(recorder))
+	assert.Nil(t, lidar.Latency())
+	require.NoError(t, lidar.StartScan())
+	<-lidar.Packets
+	require.NoError(t, lidar.StopScan())
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	assert.Equal(t, Stages, recorder.stages)
+}
@@ -0,0 +1,64 @@
+package ydlidar
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WithLatestScan keeps the last completed revolution for LatestScan, for consumers polling
+// for the latest scan instead of reading every revolution from a channel.
+func WithLatestScan() Option {
+	return func(lidar *YDLidar) {
+		lidar.latest.enabled = true
+	}
+}
+
+// ScanView is the last completed revolution returned by LatestScan.
+type ScanView struct {
+	// Scan is the revolution, nil before the first one completed. It is shared with the other
+	// readers and must not be modified.
+	Scan *Scan
+
+	// Seq counts the revolutions completed since the lidar was created, 0 before the first.
+	// Unlike Scan.Seq it keeps increasing when the scan restarts: a view with the same Seq as
+	// the previous one is stale.
+	Seq uint64
+}
+
+// Age returns the time since the revolution completed, 0 without one.
+func (v ScanView) Age() time.Duration {
+	if v.Scan == nil {
+		return 0
+	}
+	return time.Since(v.Scan.End)
+}
+
+// LatestScan returns the last completed revolution, after the scan processors ran, without
+// copying it. Partial revolutions are never returned. Only available with WithLatestScan,
+// the view is empty otherwise.
+func (lidar *YDLidar) LatestScan() ScanView {
+	if view := lidar.latest.front.Load(); view != nil {
+		return *view
+	}
+	return ScanView{}
+}
+
+// latestScan is the double buffer behind LatestScan: the scan loop assembles the next
+// revolution in the back buffer, the assembler's current scan, and swaps it to the front
+// once complete. Readers only ever see the front, which is never modified once published.
+type latestScan struct {
+	enabled bool
+	front   atomic.Pointer[ScanView]
+}
+
+// publish swaps the completed revolution to the front.
+func (l *latestScan) publish(scan *Scan) {
+	if !l.enabled {
+		return
+	}
+	view := &ScanView{Scan: scan, Seq: 1}
+	if previous := l.front.Load(); previous != nil {
+		view.Seq = previous.Seq + 1
+	}
+	l.front.Store(view)
+}
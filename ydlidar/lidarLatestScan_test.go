@@ -0,0 +1,66 @@
+package ydlidar
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scanRevolutions starts a scan of the same revolution over and over, its packets drained
+// until the returned function stops it.
+func scanRevolutions(t *testing.T, opts ...Option) (*YDLidar, func()) {
+	revolution := revolutionBytes()
+	port := &fakePort{refill: func() []byte { return revolution }}
+	port.queue(scanResponseHeader...)
+	lidar := NewLidar(port, opts...)
+	require.NoError(t, lidar.StartScan())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-lidar.Packets:
+			case <-lidar.Stop:
+				return
+			}
+		}
+	}()
+	return lidar, func() {
+		assert.NoError(t, lidar.StopScan())
+		wg.Wait()
+	}
+}
+
+func TestLatestScan(t *testing.T) {
+	lidar, stop := scanRevolutions(t, WithLatestScan())
+	assert.Nil(t, lidar.LatestScan().Scan)
+
+	require.Eventually(t, func() bool { return lidar.LatestScan().Seq >= 2 }, time.Second, time.Millisecond)
+	stop()
+
+	view := lidar.LatestScan()
+	require.NotNil(t, view.Scan)
+	assert.Len(t, view.Scan.Points, 480)
+	assert.False(t, view.Scan.Partial)
+	assert.Equal(t, view.Seq, view.Scan.Seq)
+	assert.Greater(t, view.Age(), time.Duration(0))
+	assert.Equal(t, view, lidar.LatestScan(), "stale once stopped")
+
+	// The sequence keeps increasing when the scan restarts.
+	lidar.latest.publish(&Scan{Seq: 1})
+	assert.Equal(t, view.Seq+1, lidar.LatestScan().Seq)
+}
+
+func TestLatestScanDisabled(t *testing.T) {
+	lidar, stop := scanRevolutions(t)
+	time.Sleep(20 * time.Millisecond)
+	stop()
+
+	assert.Equal(t, ScanView{}, lidar.LatestScan())
+	assert.Zero(t, lidar.LatestScan().Age())
+}
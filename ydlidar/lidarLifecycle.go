@@ -0,0 +1,61 @@
+package ydlidar
+
+import "errors"
+
+// scanState is the position of the lidar in the scan lifecycle.
+// The lidar moves Idle -> Scanning -> Stopping -> Idle.
+type scanState int
+
+const (
+	// stateIdle no scan loop is running.
+	stateIdle scanState = iota
+
+	// stateScanning the scan loop is running and sending packets.
+	stateScanning
+
+	// stateStopping StopScan is waiting for the scan loop to exit.
+	stateStopping
+)
+
+// String returns the name of the state.
+func (s scanState) String() string {
+	switch s {
+	case stateIdle:
+		return "Idle"
+	case stateScanning:
+		return "Scanning"
+	case stateStopping:
+		return "Stopping"
+	}
+	return "Unknown"
+}
+
+var (
+	// ErrAlreadyScanning is returned by StartScan when a scan is already running.
+	ErrAlreadyScanning = errors.New("ydlidar: scan already running")
+
+	// ErrStopping is returned by StartScan while a previous scan is still stopping.
+	ErrStopping = errors.New("ydlidar: scan is stopping")
+)
+
+// setState moves the lidar to the given state.
+func (lidar *YDLidar) setState(state scanState) {
+	lidar.mu.Lock()
+	defer lidar.mu.Unlock()
+	lidar.state = state
+}
+
+// IsScanning reports whether the scan loop is running.
+func (lidar *YDLidar) IsScanning() bool {
+	lidar.mu.Lock()
+	defer lidar.mu.Unlock()
+	return lidar.state == stateScanning
+}
+
+// Restart stops the running scan, if any, and starts a new one.
+func (lidar *YDLidar) Restart() error {
+	if err := lidar.StopScan(); err != nil {
+		return err
+	}
+	return lidar.StartScan()
+}
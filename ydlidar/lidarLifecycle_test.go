@@ -0,0 +1,157 @@
+package ydlidar
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.bug.st/serial"
+)
+
+// fakePort is an in-memory serial.Port. Reads drain the queued device output and
+// behave like a read timeout once it is empty.
+type fakePort struct {
+	mu      sync.Mutex
+	output  bytes.Buffer
+	written bytes.Buffer
+	readErr error         // Returned by Read once the queued output is drained.
+	refill  func() []byte // Called for more output once the queue is drained.
+}
+
+func (p *fakePort) queue(b ...byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.output.Write(b)
+}
+
+func (p *fakePort) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.output.Len() == 0 && p.refill != nil {
+		p.output.Write(p.refill())
+	}
+	if p.output.Len() == 0 && p.readErr != nil {
+		return 0, p.readErr
+	}
+	if p.output.Len() == 0 {
+		time.Sleep(time.Millisecond)
+		return 0, nil
+	}
+	return p.output.Read(b)
+}
+
+func (p *fakePort) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.written.Write(b)
+}
+
+func (p *fakePort) SetMode(*serial.Mode) error { return nil }
+func (p *fakePort) ResetInputBuffer() error    { return nil }
+func (p *fakePort) ResetOutputBuffer() error   { return nil }
+func (p *fakePort) SetDTR(bool) error          { return nil }
+func (p *fakePort) SetRTS(bool) error          { return nil }
+func (p *fakePort) GetModemStatusBits() (*serial.ModemStatusBits, error) {
+	return &serial.ModemStatusBits{}, nil
+}
+func (p *fakePort) SetReadTimeout(time.Duration) error { return nil }
+func (p *fakePort) Close() error                       { return nil }
+func (p *fakePort) Break(time.Duration) error          { return nil }
+
+// scanResponseHeader is the device answer to the start scanning command.
+var scanResponseHeader = []byte{0xA5, 0x5A, 0x05, 0x00, 0x00, 0x40, ScanTypeCode}
+
+func TestStopScanWithoutStart(t *testing.T) {
+	lidar := NewLidar(&fakePort{})
+
+	assert.NoError(t, lidar.StopScan())
+	assert.NoError(t, lidar.StopScan())
+	assert.False(t, lidar.IsScanning())
+}
+
+func TestScanLifecycle(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port)
+
+	port.queue(scanResponseHeader...)
+	assert.NoError(t, lidar.StartScan())
+	assert.True(t, lidar.IsScanning())
+	assert.ErrorIs(t, lidar.StartScan(), ErrAlreadyScanning)
+
+	port.queue(scanResponseHeader...)
+	assert.NoError(t, lidar.Restart())
+	assert.True(t, lidar.IsScanning())
+
+	assert.NoError(t, lidar.StopScan())
+	assert.NoError(t, lidar.StopScan())
+	assert.False(t, lidar.IsScanning())
+}
+
+// closingPort records the shutdown calls made on a fakePort.
+type closingPort struct {
+	fakePort
+	dtr    []bool
+	closed int
+}
+
+func (p *closingPort) SetDTR(dtr bool) error {
+	p.dtr = append(p.dtr, dtr)
+	return nil
+}
+
+func (p *closingPort) Close() error {
+	p.closed++
+	return nil
+}
+
+func TestCloseWhileScanning(t *testing.T) {
+	port := &closingPort{}
+	lidar := NewLidar(port)
+
+	port.queue(scanResponseHeader...)
+	assert.NoError(t, lidar.StartScan())
+
+	assert.NoError(t, lidar.Close())
+	assert.False(t, lidar.IsScanning())
+	assert.Equal(t, []bool{false}, port.dtr)
+	assert.Equal(t, 1, port.closed)
+	assert.Contains(t, port.written.String(), string([]byte{preCommand, stopScanning}))
+
+	// Closing again is a no-op.
+	assert.NoError(t, lidar.Close())
+	assert.Equal(t, 1, port.closed)
+}
+
+func TestConcurrentStopScan(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port)
+
+	port.queue(scanResponseHeader...)
+	assert.NoError(t, lidar.StartScan())
+	stop := lidar.Stop
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, lidar.StopScan())
+			assert.False(t, lidar.IsScanning())
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case <-stop:
+	default:
+		t.Fatal("Stop channel not closed")
+	}
+
+	// A new scan gets a new Stop channel.
+	port.queue(scanResponseHeader...)
+	assert.NoError(t, lidar.StartScan())
+	assert.NotEqual(t, stop, lidar.Stop)
+	assert.NoError(t, lidar.StopScan())
+}
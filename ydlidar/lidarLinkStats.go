@@ -0,0 +1,44 @@
+package ydlidar
+
+import "sync/atomic"
+
+// LinkStats is a snapshot of the I/O counters of the link, to monitor its quality without
+// the debug logs. Counters are totals since the lidar was created.
+type LinkStats struct {
+	BytesRead        uint64 // Bytes read from the transport, discarded ones included.
+	Frames           uint64 // Command responses and scan packets read in full and decoded.
+	Resyncs          uint64 // Flushes and device info round-trips bringing the link back in step.
+	Desyncs          uint64 // Times the scan stream was out of step and skipped bytes to a packet header.
+	ShortReads       uint64 // Packet reads getting fewer bytes than the packet has.
+	ChecksumFailures uint64 // Scan packets dropped because they failed validation.
+}
+
+// linkCounters holds the counters of LinkStats updated by the reads.
+type linkCounters struct {
+	bytesRead  atomic.Uint64
+	frames     atomic.Uint64
+	resyncs    atomic.Uint64
+	desyncs    atomic.Uint64
+	shortReads atomic.Uint64
+}
+
+// Stats returns the current value of the I/O counters.
+func (lidar *YDLidar) Stats() LinkStats {
+	return LinkStats{
+		BytesRead:        lidar.link.bytesRead.Load(),
+		Frames:           lidar.link.frames.Load(),
+		Resyncs:          lidar.link.resyncs.Load(),
+		Desyncs:          lidar.link.desyncs.Load(),
+		ShortReads:       lidar.link.shortReads.Load(),
+		ChecksumFailures: lidar.checksumFailures.Load(),
+	}
+}
+
+// read reads from the transport, counting the bytes.
+func (lidar *YDLidar) read(data []byte) (int, error) {
+	n, err := lidar.SerialPort.Read(data)
+	if n > 0 {
+		lidar.link.bytesRead.Add(uint64(n))
+	}
+	return n, err
+}
@@ -0,0 +1,94 @@
+package ydlidar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar/sim"
+)
+
+func TestStats(t *testing.T) {
+	revolution := revolutionBytes()
+	port := &fakePort{}
+	port.queue(scanResponseHeader...)
+	port.queue(revolution...)
+	lidar := NewLidar(port)
+
+	require.NoError(t, lidar.StartScan())
+	for i := 0; i < 12; i++ {
+		require.NoError(t, (<-lidar.Packets).Error)
+	}
+	require.NoError(t, lidar.StopScan())
+
+	assert.Equal(t, LinkStats{
+		BytesRead: uint64(len(scanResponseHeader) + len(revolution)),
+		Frames:    14, // The scan response, the zero packet and 12 point cloud packets.
+	}, lidar.Stats())
+}
+
+func TestStatsShortRead(t *testing.T) {
+	revolution := revolutionBytes()
+	port := &fakePort{}
+	port.queue(scanResponseHeader...)
+	// The last packet loses half its samples.
+	port.queue(revolution[:len(revolution)-60]...)
+	lidar := NewLidar(port)
+
+	require.NoError(t, lidar.StartScan())
+	for i := 0; i < 11; i++ {
+		require.NoError(t, (<-lidar.Packets).Error)
+	}
+	require.Eventually(t, func() bool { return lidar.Stats().ChecksumFailures == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, lidar.StopScan())
+
+	stats := lidar.Stats()
+	assert.Equal(t, uint64(13), stats.Frames)
+	assert.Equal(t, uint64(1), stats.ShortReads)
+	assert.Equal(t, uint64(len(scanResponseHeader)+len(revolution)-60), stats.BytesRead)
+}
+
+func TestStatsDesyncs(t *testing.T) {
+	// 3 stray bytes in front of every packet, the headers are never 10 bytes apart.
+	samples := make([][3]byte, 40)
+	for i := range samples {
+		samples[i] = [3]byte{100, byte(1000 & 0x3F << 2), byte(1000 >> 6)}
+	}
+	port := &fakePort{}
+	port.queue(scanResponseHeader...)
+	for start := 0; start < 360; start += 30 {
+		fsa := uint16(start*64)<<1 | 1
+		lsa := uint16((start+29)*64)<<1 | 1
+		port.queue(0x13, 0x37, 0x42)
+		port.queue(encodeScanPacket(0x00, fsa, lsa, samples)...)
+	}
+	lidar := NewLidar(port, WithAuxPackets(16))
+
+	require.NoError(t, lidar.StartScan())
+	for i := 0; i < 12; i++ {
+		packet := <-lidar.Packets
+		require.NoError(t, packet.Error)
+		assert.Equal(t, float64(i*30), packet.StartAngle)
+		assert.Equal(t, 1000.0, packet.Distances[0])
+	}
+	require.NoError(t, lidar.StopScan())
+
+	stats := lidar.Stats()
+	assert.Equal(t, uint64(12), stats.Desyncs)
+	assert.Zero(t, stats.ChecksumFailures)
+	assert.Zero(t, stats.ShortReads)
+	raw := <-lidar.Diagnostics
+	assert.Equal(t, []byte{0x13, 0x37, 0x42}, raw.Bytes)
+}
+
+func TestStatsResyncs(t *testing.T) {
+	lidar, err := ConnectTransport(sim.NewPort(&sim.Device{Environment: sim.Room(4000, 3000)}))
+	require.NoError(t, err)
+	defer lidar.Close()
+
+	stats := lidar.Stats()
+	assert.Equal(t, uint64(1), stats.Resyncs)
+	assert.Equal(t, uint64(2), stats.Frames, "device info and health responses")
+	assert.NotZero(t, stats.BytesRead)
+}
@@ -0,0 +1,69 @@
+package ydlidar
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// loadProbeInterval is how long the load monitor sleeps between latency probes.
+	loadProbeInterval = 10 * time.Millisecond
+
+	// loadRecoverProbes is the number of consecutive calm probes needed to leave degraded mode.
+	loadRecoverProbes = 50
+)
+
+// loadMonitor detects scheduling latency (timer slip) and switches the lidar to degraded processing.
+type loadMonitor struct {
+	enabled    bool
+	maxSlip    time.Duration
+	decimation int
+	degraded   atomic.Bool
+}
+
+// Degraded reports whether output is currently decimated because the host is overloaded.
+func (lidar *YDLidar) Degraded() bool {
+	return lidar.load.degraded.Load()
+}
+
+// shed reports whether the given frame should be dropped to lighten the load.
+func (m *loadMonitor) shed(frame int) bool {
+	return m.degraded.Load() && frame%m.decimation != 0
+}
+
+// monitorLoad measures how late a short sleep wakes up until done is closed. A slip above
+// maxSlip degrades the lidar, it recovers after loadRecoverProbes probes below half of maxSlip.
+func (lidar *YDLidar) monitorLoad(done <-chan struct{}) {
+	timer := time.NewTimer(loadProbeInterval)
+	defer timer.Stop()
+
+	calm := 0
+	for {
+		start := time.Now()
+		select {
+		case <-done:
+			lidar.load.degraded.Store(false)
+			return
+		case <-timer.C:
+		}
+		slip := time.Since(start) - loadProbeInterval
+		timer.Reset(loadProbeInterval)
+
+		switch {
+		case slip > lidar.load.maxSlip:
+			calm = 0
+			if !lidar.load.degraded.Swap(true) {
+				log.Printf("Host overloaded, scheduling slip %v. Decimating output 1/%v", slip, lidar.load.decimation)
+				lidar.emitStatus(StatusEvent{Type: LoadShed, Err: fmt.Errorf("scheduling slip %v exceeds %v", slip, lidar.load.maxSlip)})
+			}
+		case slip < lidar.load.maxSlip/2:
+			calm++
+			if calm >= loadRecoverProbes && lidar.load.degraded.Swap(false) {
+				log.Printf("Host load recovered, full output resumed")
+				lidar.emitStatus(StatusEvent{Type: LoadRecovered})
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package ydlidar
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// sharedBuffers is the buffer pool of the lidars without a dedicated decoder.
+var sharedBuffers = &bufferPool{}
+
+// bufferPool recycles the read buffers of the scan loop.
+type bufferPool struct {
+	pool sync.Pool
+}
+
+// get returns a zeroed buffer of length n.
+func (p *bufferPool) get(n int) []byte {
+	if b, ok := p.pool.Get().(*[]byte); ok && cap(*b) >= n {
+		buf := (*b)[:n]
+		for i := range buf {
+			buf[i] = 0
+		}
+		return buf
+	}
+	return make([]byte, n)
+}
+
+// put returns the buffer to the pool.
+func (p *bufferPool) put(b []byte) {
+	p.pool.Put(&b)
+}
+
+// Manager runs several lidars on one host, eg. four units around a robot.
+type Manager struct {
+	mu         sync.Mutex
+	devices    map[string]*YDLidar
+	dedicated  bool
+	pinThreads bool
+}
+
+// ManagerOption configures a Manager.
+type ManagerOption func(*Manager)
+
+// WithDedicatedDecoders gives every lidar added to the manager its own buffer pool so the
+// decoders don't contend with each other. pinThreads additionally locks every scan loop to
+// its own OS thread, letting the scheduler spread the lidars over the cores.
+func WithDedicatedDecoders(pinThreads bool) ManagerOption {
+	return func(m *Manager) {
+		m.dedicated = true
+		m.pinThreads = pinThreads
+	}
+}
+
+// NewManager returns an empty Manager.
+func NewManager(opts ...ManagerOption) *Manager {
+	m := &Manager{devices: map[string]*YDLidar{}}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Add registers the lidar under name. The lidar must not be scanning.
+func (m *Manager) Add(name string, lidar *YDLidar) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.devices[name]; ok {
+		return fmt.Errorf("lidar %q already added", name)
+	}
+	if lidar.IsScanning() {
+		return ErrScanRunning
+	}
+	if m.dedicated {
+		lidar.buffers = &bufferPool{}
+		lidar.pinThread = m.pinThreads
+	}
+	m.devices[name] = lidar
+	return nil
+}
+
+// Lidar returns the lidar registered under name, nil if there is none.
+func (m *Manager) Lidar(name string) *YDLidar {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.devices[name]
+}
+
+// Names returns the names of the registered lidars in sorted order.
+func (m *Manager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.devices))
+	for name := range m.devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StartAll starts scanning on every lidar. If one fails the lidars already started are stopped.
+func (m *Manager) StartAll() error {
+	var started []*YDLidar
+	for _, name := range m.Names() {
+		lidar := m.Lidar(name)
+		if err := lidar.StartScan(); err != nil {
+			for _, s := range started {
+				s.StopScan()
+			}
+			return fmt.Errorf("lidar %q: %v", name, err)
+		}
+		started = append(started, lidar)
+	}
+	return nil
+}
+
+// StopAll stops scanning on every lidar and returns the first error.
+func (m *Manager) StopAll() error {
+	var first error
+	for _, name := range m.Names() {
+		if err := m.Lidar(name).StopScan(); err != nil && first == nil {
+			first = fmt.Errorf("lidar %q: %v", name, err)
+		}
+	}
+	return first
+}
+
+// Close stops and closes every lidar and returns the first error.
+func (m *Manager) Close() error {
+	first := m.StopAll()
+	for _, name := range m.Names() {
+		if err := m.Lidar(name).Close(); err != nil && first == nil {
+			first = fmt.Errorf("lidar %q: %v", name, err)
+		}
+	}
+	return first
+}
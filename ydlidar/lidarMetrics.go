@@ -0,0 +1,56 @@
+package ydlidar
+
+import "sync/atomic"
+
+// Metrics is a snapshot of the lidar counters. Counters are totals since the lidar was
+// created, rates such as packets per second are derived by the monitoring system.
+type Metrics struct {
+	ScanFrequency     float64 // Rotation frequency in Hz reported by the last zero packet.
+	MeasuredFrequency float64 // Rotation frequency in Hz measured between the last two zero packets.
+	Packets           uint64  // Point cloud packets decoded.
+	Samples           uint64  // Samples in the decoded packets.
+	ChecksumFailures  uint64  // Scan packets dropped on a checksum mismatch.
+	Reconnects        uint64  // Successful reconnects after a lost link.
+	ReadErrors        uint64  // Failed serial reads.
+	Dropped           uint64  // Events and revolutions dropped because their channel was full.
+	DroppedPackets    uint64  // Packets discarded by the overflow policy, see WithPacketBuffer.
+	ZeroPackets       uint64  // Zero packets starting a revolution.
+	ResponsePackets   uint64  // Command responses found in the scan stream, see CategoryResponse.
+	UnknownPackets    uint64  // Packets of no known category, see CategoryUnknown.
+}
+
+// metrics holds the counters updated by the scan loop.
+type metrics struct {
+	frequency  atomic.Uint32 // Tenths of Hz.
+	packets    atomic.Uint64
+	samples    atomic.Uint64
+	reconnects atomic.Uint64
+	readErrors atomic.Uint64
+	dropped    atomic.Uint64
+
+	droppedPackets atomic.Uint64
+	categories     [packetCategoryCount]atomic.Uint64 // Packets read, by category.
+}
+
+// Metrics returns the current value of the counters.
+func (lidar *YDLidar) Metrics() Metrics {
+	return Metrics{
+		ScanFrequency:     float64(lidar.metrics.frequency.Load()) / 10,
+		MeasuredFrequency: lidar.MeasuredFrequency(),
+		Packets:           lidar.metrics.packets.Load(),
+		Samples:           lidar.metrics.samples.Load(),
+		ChecksumFailures:  lidar.checksumFailures.Load(),
+		Reconnects:        lidar.metrics.reconnects.Load(),
+		ReadErrors:        lidar.metrics.readErrors.Load(),
+		Dropped:           lidar.metrics.dropped.Load(),
+		DroppedPackets:    lidar.metrics.droppedPackets.Load(),
+		ZeroPackets:       lidar.metrics.categories[CategoryZero].Load(),
+		ResponsePackets:   lidar.metrics.categories[CategoryResponse].Load(),
+		UnknownPackets:    lidar.metrics.categories[CategoryUnknown].Load(),
+	}
+}
+
+// scanFrequency returns the frequency in tenths of Hz from the F&C byte of a zero packet.
+func scanFrequency(packageType uint8) uint32 {
+	return uint32(packageType>>1) & 0x7F
+}
@@ -0,0 +1,25 @@
+package ydlidar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsCountPackets(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port)
+	port.queue(scanResponseHeader...)
+	port.refill = revolutionBytes
+
+	require.NoError(t, lidar.StartScan())
+	for i := 0; i < 5; i++ {
+		<-lidar.Packets
+	}
+	require.NoError(t, lidar.StopScan())
+
+	m := lidar.Metrics()
+	assert.GreaterOrEqual(t, m.Packets, uint64(5))
+	assert.Equal(t, m.Packets*40, m.Samples)
+}
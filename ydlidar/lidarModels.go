@@ -0,0 +1,95 @@
+package ydlidar
+
+import (
+	"fmt"
+	"math"
+)
+
+// modelSpec describes a model, from its datasheet.
+type modelSpec struct {
+	name               string
+	decoder            SampleDecoder // Sample layout of the scan packets.
+	scanFrequency      bool          // Accepts the scan frequency commands.
+	minFrequency       float64       // Slowest scan frequency in Hz.
+	maxFrequency       float64       // Fastest scan frequency in Hz.
+	rangingFrequencies []int         // Ranging frequencies in kHz in device index order, nil if fixed.
+	sampleRate         int           // Default ranging frequency in kHz.
+	minRange           float64       // Minimum range in mm.
+	maxRange           float64       // Maximum range in mm.
+	motorPWM           bool          // Motor speed is set by a PWM signal on M_CTR rather than by command.
+	lowPower           bool          // Supports the low power mode stopping the motor between scans.
+}
+
+// models is the model database, keyed by the model number of the device info response.
+var models = map[byte]modelSpec{
+	5: {name: "G4", decoder: DistanceDecoder{}, scanFrequency: true, minFrequency: 5, maxFrequency: 12,
+		rangingFrequencies: []int{4, 8, 9}, sampleRate: 9, minRange: 120, maxRange: 16000, lowPower: true},
+	6: {name: "X4", decoder: DistanceDecoder{}, minFrequency: 6, maxFrequency: 12,
+		sampleRate: 5, minRange: 120, maxRange: 10000, motorPWM: true},
+	13: {name: "G6", decoder: IntensityDecoder{}, scanFrequency: true, minFrequency: 5, maxFrequency: 12,
+		rangingFrequencies: []int{8, 16, 18}, sampleRate: 18, minRange: 120, maxRange: 25000, lowPower: true},
+	15: {name: "G2", decoder: IntensityDecoder{}, scanFrequency: true, minFrequency: 5, maxFrequency: 12,
+		sampleRate: 5, minRange: 120, maxRange: 12000},
+	100: {name: "TG15", decoder: IntensityDecoder{}, scanFrequency: true, minFrequency: 3, maxFrequency: 15,
+		rangingFrequencies: []int{10, 18, 20}, sampleRate: 20, minRange: 50, maxRange: 15000},
+	101: {name: "TG30", decoder: IntensityDecoder{}, scanFrequency: true, minFrequency: 3, maxFrequency: 15,
+		rangingFrequencies: []int{10, 18, 20}, sampleRate: 20, minRange: 50, maxRange: 30000},
+	102: {name: "TG50", decoder: IntensityDecoder{}, scanFrequency: true, minFrequency: 3, maxFrequency: 15,
+		rangingFrequencies: []int{10, 18, 20}, sampleRate: 20, minRange: 50, maxRange: 50000},
+	150: {name: "T-mini Plus", decoder: TminiDecoder{}, minFrequency: 6, maxFrequency: 12,
+		sampleRate: 4, minRange: 50, maxRange: 12000},
+}
+
+// Capabilities describes what the connected model supports.
+type Capabilities struct {
+	Model                   string  // Model name, eg. G2.
+	Intensity               bool    // Samples carry an intensity.
+	AdjustableScanFrequency bool    // The scan frequency can be set with SetMotorSpeed.
+	MinScanFrequency        float64 // Slowest scan frequency in Hz.
+	MaxScanFrequency        float64 // Fastest scan frequency in Hz.
+	RangingFrequencies      []int   // Ranging frequencies in kHz accepted by SetRangingFrequency, nil if fixed.
+	SampleRate              int     // Current ranging frequency in kHz.
+	MotorPWM                bool    // The motor speed is set by a PWM signal on M_CTR.
+	LowPower                bool    // The low power mode is supported.
+	MinRange                float64 // Minimum range in the unit of the lidar, see WithUnits.
+	MaxRange                float64 // Maximum range in the unit of the lidar.
+
+	// AngularResolution is the angle in degrees between two samples at the current scan
+	// frequency, measured while scanning or last reported by the device, 0 if neither is known.
+	AngularResolution float64
+}
+
+// Capabilities returns what the connected model supports. DeviceInfo has to be called first,
+// InitAndConnectToDevice does, otherwise ErrUnsupportedModel is returned.
+func (lidar *YDLidar) Capabilities() (Capabilities, error) {
+	spec, ok := models[lidar.model]
+	if !ok {
+		return Capabilities{}, fmt.Errorf("%w: model number %v", ErrUnsupportedModel, lidar.model)
+	}
+
+	caps := Capabilities{
+		Model:                   spec.name,
+		Intensity:               lidar.sampleDecoder().SampleSize() == 3,
+		AdjustableScanFrequency: spec.scanFrequency,
+		MinScanFrequency:        spec.minFrequency,
+		MaxScanFrequency:        spec.maxFrequency,
+		RangingFrequencies:      append([]int(nil), spec.rangingFrequencies...),
+		SampleRate:              spec.sampleRate,
+		MotorPWM:                spec.motorPWM,
+		LowPower:                spec.lowPower,
+		MinRange:                lidar.units.FromMillimeters(spec.minRange),
+		MaxRange:                lidar.units.FromMillimeters(spec.maxRange),
+	}
+	if rate := lidar.rangingRate.Load(); rate > 0 {
+		caps.SampleRate = int(rate)
+	}
+
+	hz := lidar.MeasuredFrequency()
+	if hz == 0 {
+		hz = math.Float64frombits(lidar.frequency.commanded.Load())
+	}
+	if hz > 0 {
+		caps.AngularResolution = 360 * hz / float64(caps.SampleRate*1000)
+	}
+	return caps, nil
+}
@@ -0,0 +1,60 @@
+package ydlidar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilitiesUnknownModel(t *testing.T) {
+	lidar := NewLidar(&fakePort{})
+
+	_, err := lidar.Capabilities()
+	assert.ErrorIs(t, err, ErrUnsupportedModel)
+}
+
+func TestCapabilities(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port)
+	port.queue(0xA5, 0x5A, 0x14, 0x00, 0x00, 0x00, InfoTypeCode)
+	port.queue(append([]byte{15}, make([]byte, 19)...)...)
+	_, err := lidar.DeviceInfo()
+	require.NoError(t, err)
+
+	caps, err := lidar.Capabilities()
+	require.NoError(t, err)
+	assert.Equal(t, "G2", caps.Model)
+	assert.True(t, caps.Intensity)
+	assert.True(t, caps.AdjustableScanFrequency)
+	assert.Nil(t, caps.RangingFrequencies)
+	assert.Equal(t, 5, caps.SampleRate)
+	assert.Equal(t, 12000.0, caps.MaxRange)
+	assert.Zero(t, caps.AngularResolution)
+
+	// 10Hz at 5kHz is a sample every 0.72°.
+	lidar.setCommandedFrequency(10)
+	caps, err = lidar.Capabilities()
+	require.NoError(t, err)
+	assert.InDelta(t, 0.72, caps.AngularResolution, 1e-9)
+}
+
+func TestCapabilitiesRangingFrequency(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port)
+	lidar.model = 5 // G4
+	lidar.decoder = decoderFor(lidar.model)
+
+	caps, err := lidar.Capabilities()
+	require.NoError(t, err)
+	assert.False(t, caps.Intensity)
+	assert.Equal(t, []int{4, 8, 9}, caps.RangingFrequencies)
+	assert.Equal(t, 9, caps.SampleRate)
+
+	port.queue(rangingResponse(0)...)
+	_, err = lidar.RangingFrequency()
+	require.NoError(t, err)
+	caps, err = lidar.Capabilities()
+	require.NoError(t, err)
+	assert.Equal(t, 4, caps.SampleRate)
+}
@@ -0,0 +1,131 @@
+package ydlidar
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrScanRunning is returned by operations that can't be done while scanning.
+	ErrScanRunning = errors.New("ydlidar: scan is running")
+
+	// ErrUnsupportedByModel is returned by commands the connected model doesn't implement.
+	ErrUnsupportedByModel = errors.New("ydlidar: command not supported by this model")
+)
+
+// frequencyTolerance is how close SetMotorSpeed gets to the requested frequency in Hz.
+const frequencyTolerance = 0.05
+
+// StartMotor spins up the motor by raising DTR. The serial session stays open, scanning is started separately.
+func (lidar *YDLidar) StartMotor() error {
+	return lidar.setDTR(true)
+}
+
+// StopMotor spins down the motor by lowering DTR to save power. The scan has to be stopped first.
+func (lidar *YDLidar) StopMotor() error {
+	if lidar.IsScanning() {
+		return ErrScanRunning
+	}
+	return lidar.setDTR(false)
+}
+
+// MotorSpeed returns the scan frequency of the motor in Hz. A running scan is paused meanwhile.
+func (lidar *YDLidar) MotorSpeed() (hz float64, err error) {
+	if err = lidar.checkFrequencyCommand(); err != nil {
+		return 0, err
+	}
+	err = lidar.command(func() error {
+		hz, err = lidar.frequencyCommand(getScanFrequency)
+		return err
+	})
+	return hz, err
+}
+
+// SetMotorSpeed steps the scan frequency towards hz using the 1Hz and 0.1Hz adjust commands
+// and returns the frequency the device settled on. A running scan is paused meanwhile.
+func (lidar *YDLidar) SetMotorSpeed(hz float64) (current float64, err error) {
+	if err = lidar.checkFrequencyCommand(); err != nil {
+		return 0, err
+	}
+	err = lidar.command(func() error {
+		current, err = lidar.stepFrequency(hz)
+		return err
+	})
+	return current, err
+}
+
+// stepFrequency sends the adjust commands until the frequency is within frequencyTolerance of hz.
+func (lidar *YDLidar) stepFrequency(hz float64) (float64, error) {
+	current, err := lidar.frequencyCommand(getScanFrequency)
+	if err != nil {
+		return 0, err
+	}
+
+	// Every step moves the frequency closer, the bound only protects against a device ignoring the commands.
+	for steps := 0; math.Abs(hz-current) > frequencyTolerance && steps < 100; steps++ {
+		diff := hz - current
+		command := byte(decreaseFrequencySmall)
+		switch {
+		case diff >= 1:
+			command = increaseFrequencyLarge
+		case diff <= -1:
+			command = decreaseFrequencyLarge
+		case diff > 0:
+			command = increaseFrequencySmall
+		}
+
+		next, err := lidar.frequencyCommand(command)
+		if err != nil {
+			return current, err
+		}
+		if next == current {
+			// The device reached its limit.
+			break
+		}
+		current = next
+	}
+
+	return current, nil
+}
+
+// checkFrequencyCommand verifies the model supports the frequency commands.
+func (lidar *YDLidar) checkFrequencyCommand() error {
+	if spec, ok := models[lidar.model]; lidar.model != 0 && !(ok && spec.scanFrequency) {
+		return ErrUnsupportedByModel
+	}
+	return nil
+}
+
+// frequencyCommand sends a frequency command and decodes the 4 byte response, in 0.01Hz units
+// unless the firmware quirks say otherwise.
+func (lidar *YDLidar) frequencyCommand(command byte) (float64, error) {
+	if _, err := lidar.SerialPort.Write([]byte{preCommand, command}); err != nil {
+		return 0, err
+	}
+
+	sizeOfMessage, typeCode, mode, err := lidar.readInfoHeader()
+	if err != nil {
+		return 0, err
+	}
+	if typeCode != InfoTypeCode {
+		return 0, fmt.Errorf("%w: invalid type code. Expected %x, got %v. Mode: %x", ErrBadHeader, InfoTypeCode, typeCode, mode)
+	}
+	if sizeOfMessage != 4 {
+		return 0, fmt.Errorf("%w: scan frequency expected 4 bytes got %v", ErrShortRead, sizeOfMessage)
+	}
+
+	data := make([]byte, sizeOfMessage)
+	n, err := lidar.readInfo(data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read serial: %w", err)
+	}
+	if n != len(data) {
+		return 0, fmt.Errorf("%w: scan frequency expected %v bytes got %v", ErrShortRead, len(data), n)
+	}
+
+	hz := lidar.quirks.commandFrequency(binary.LittleEndian.Uint32(data))
+	lidar.setCommandedFrequency(hz)
+	return hz, nil
+}
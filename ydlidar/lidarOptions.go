@@ -0,0 +1,128 @@
+package ydlidar
+
+import "time"
+
+// Option configures optional behaviour of the lidar, see NewLidar.
+type Option func(*YDLidar)
+
+// WithReconnect enables the watchdog. When the serial port reports an error or stays
+// silent for too long the port is closed, re-opened and the scan command re-issued.
+// Attempts are spaced by an exponential backoff starting at backoff. maxRetries <= 0
+// retries forever.
+func WithReconnect(maxRetries int, backoff time.Duration) Option {
+	return func(lidar *YDLidar) {
+		lidar.reconnect.enabled = true
+		lidar.reconnect.maxRetries = maxRetries
+		if backoff > 0 {
+			lidar.reconnect.backoff = backoff
+		}
+	}
+}
+
+// WithWatchdogTimeouts sets how many consecutive empty reads (each one header timeout long)
+// are tolerated before the link is considered lost.
+func WithWatchdogTimeouts(timeouts int) Option {
+	return func(lidar *YDLidar) {
+		if timeouts > 0 {
+			lidar.reconnect.timeouts = timeouts
+		}
+	}
+}
+
+// WithLoadShedding enables the load monitor. When the scheduling latency of the host exceeds
+// maxSlip the lidar degrades and only every decimation-th packet is delivered until the latency
+// settles, so a busy host drops fidelity rather than falling behind the serial stream.
+func WithLoadShedding(maxSlip time.Duration, decimation int) Option {
+	return func(lidar *YDLidar) {
+		lidar.load.enabled = true
+		lidar.load.maxSlip = maxSlip
+		lidar.load.decimation = decimation
+		if decimation < 2 {
+			lidar.load.decimation = 2
+		}
+	}
+}
+
+// WithPacketBuffer gives the Packets channel a capacity of buffer packets and sets what happens
+// when the consumer falls behind and it fills up, see OverflowPolicy.
+func WithPacketBuffer(buffer int, policy OverflowPolicy) Option {
+	return func(lidar *YDLidar) {
+		lidar.Packets = make(chan Packet, buffer)
+		lidar.overflow = policy
+	}
+}
+
+// WithScans enables assembling the packets into full revolutions sent on the Scans channel.
+// buffer is the capacity of the channel.
+func WithScans(buffer int) Option {
+	return func(lidar *YDLidar) {
+		lidar.Scans = make(chan Scan, buffer)
+	}
+}
+
+// WithPartialScanPolicy sets what happens to the unfinished revolution when the scan is stopped.
+// Only applies when WithScans is enabled.
+func WithPartialScanPolicy(policy PartialScanPolicy) Option {
+	return func(lidar *YDLidar) {
+		lidar.partial = policy
+	}
+}
+
+// WithHealthMonitor queries the device health every period and sends the result on the Health channel.
+// A running scan is paused for the duration of the query.
+func WithHealthMonitor(period time.Duration) Option {
+	return func(lidar *YDLidar) {
+		lidar.healthPeriod = period
+		lidar.Health = make(chan HealthStatus, healthBufferSize)
+	}
+}
+
+// WithScanProcessor adds a stage run on every assembled revolution before it is sent on the Scans channel.
+// Processors run in the order they were added. Only applies when WithScans is enabled.
+func WithScanProcessor(processor ScanProcessor) Option {
+	return func(lidar *YDLidar) {
+		lidar.processors = append(lidar.processors, processor)
+	}
+}
+
+// WithRangeLimits drops the samples closer than min or further than max, in the unit set
+// with WithUnits, millimeters by default. A max of 0 means no upper limit. The samples without
+// a return are kept, see WithInvalidValue.
+func WithRangeLimits(min, max float64) Option {
+	return func(lidar *YDLidar) {
+		lidar.limits.minRange = min
+		lidar.limits.maxRange = max
+	}
+}
+
+// WithAngleMask drops the samples between from and to degrees, eg. where the robot chassis
+// blocks the view. A mask with from > to wraps through 0°. Can be given several times.
+func WithAngleMask(from, to float64) Option {
+	return func(lidar *YDLidar) {
+		lidar.limits.masks = append(lidar.limits.masks, angleMask{from: from, to: to})
+	}
+}
+
+// WithMinIntensity drops the samples with an intensity below min.
+func WithMinIntensity(min int) Option {
+	return func(lidar *YDLidar) {
+		lidar.limits.minIntensity = min
+	}
+}
+
+// WithFilter adds a filter stage run on every packet after the built in limits.
+// Filters run in the order they were added and are skipped while the lidar is degraded.
+func WithFilter(filter Filter) Option {
+	return func(lidar *YDLidar) {
+		lidar.filters = append(lidar.filters, filter)
+	}
+}
+
+// WithAuxPackets enables the Aux and Diagnostics channels, of the given capacity, receiving the
+// non-scan packets found in the scan stream instead of silently skipping them.
+func WithAuxPackets(buffer int) Option {
+	return func(lidar *YDLidar) {
+		lidar.Aux = make(chan AuxEvent, buffer)
+		lidar.Diagnostics = make(chan RawPacket, buffer)
+	}
+}
@@ -0,0 +1,62 @@
+package ydlidar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"ydlidarg2/ydlidar/protocol"
+)
+
+// parsedScanPacket is a point cloud data packet decoded by parseScanPacket.
+type parsedScanPacket struct {
+	header      pointCloudHeader
+	distances   []float64 // Millimeters.
+	intensities []int
+	angles      []float64 // Degrees, corrected.
+}
+
+// parseScanPacket decodes a point cloud data packet from its 10 byte header and the samples
+// read after it. The packet is validated with checkScanPacket first, so a truncated or
+// corrupted packet returns an error rather than garbage. The angles are corrected unless the
+// firmware quirks say the device did.
+func parseScanPacket(header, samples []byte, decoder SampleDecoder, quirks FirmwareQuirks) (parsedScanPacket, error) {
+	var parsed parsedScanPacket
+	if len(header) < scanPacketHeaderSize {
+		return parsed, fmt.Errorf("%w: scan packet header expected %v bytes got %v", ErrShortRead, scanPacketHeaderSize, len(header))
+	}
+	if err := binary.Read(bytes.NewReader(header[:scanPacketHeaderSize]), binary.LittleEndian, &parsed.header); err != nil {
+		return parsed, err
+	}
+	if parsed.header.PackageType&0x01 != 0 {
+		return parsed, fmt.Errorf("%w: zero packet %X", ErrBadHeader, header)
+	}
+	if parsed.header.SampleQuantity == 0 {
+		return parsed, fmt.Errorf("%w: scan packet without samples", ErrShortRead)
+	}
+	if err := checkScanPacket(header, samples, decoder.SampleSize()); err != nil {
+		return parsed, err
+	}
+
+	parsed.distances, parsed.intensities = decoder.Decode(samples)
+	if quirks.AngleCorrected {
+		parsed.angles = interpolateAngles(parsed.header.StartAngle, parsed.header.EndAngle, len(parsed.distances))
+	} else {
+		parsed.angles = calculateAngles(parsed.distances, parsed.header.StartAngle, parsed.header.EndAngle, parsed.header.SampleQuantity)
+	}
+	return parsed, nil
+}
+
+// parseInfoHeader decodes the 7 byte response header of a command: the start sign 0xA5 0x5A,
+// the 30 bit length and 2 bit mode, and the type code. The responses the driver reads are
+// shorter than 64 bytes, only the low 6 bits of the length are kept.
+func parseInfoHeader(header []byte) (sizeOfMessage byte, typeCode byte, mode byte, err error) {
+	if len(header) != responseHeaderSize {
+		return 0, 0, 0, fmt.Errorf("%w: response header expected %v bytes got %v", ErrShortRead, responseHeaderSize, len(header))
+	}
+	decoded, err := protocol.DecodeResponseHeader(header)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%w: %v", ErrBadHeader, err)
+	}
+	return byte(decoded.Length) & 0x3F, decoded.TypeCode, decoded.Mode, nil
+}
@@ -0,0 +1,89 @@
+package ydlidar
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScanPacket(t *testing.T) {
+	samples := [][3]byte{{100, 0xA0, 0x0F}, {7, 0x20, 0x03}}
+	packet := encodeScanPacket(0x00, 10*64<<1|1, 20*64<<1|1, samples)
+	parsed, err := parseScanPacket(packet[:scanPacketHeaderSize], packet[scanPacketHeaderSize:], IntensityDecoder{}, FirmwareQuirks{})
+	require.NoError(t, err)
+	assert.Equal(t, uint8(2), parsed.header.SampleQuantity)
+	assert.Equal(t, []float64{1000, 200}, parsed.distances)
+	assert.Equal(t, []int{100, 7}, parsed.intensities)
+	assert.Len(t, parsed.angles, 2)
+
+	_, err = parseScanPacket(packet[:scanPacketHeaderSize], packet[scanPacketHeaderSize:len(packet)-1], IntensityDecoder{}, FirmwareQuirks{})
+	assert.ErrorIs(t, err, ErrShortRead)
+	_, err = parseScanPacket(packet[:4], nil, IntensityDecoder{}, FirmwareQuirks{})
+	assert.ErrorIs(t, err, ErrShortRead)
+	zero := encodeScanPacket(0x01, 1, 1, [][3]byte{{0, 0, 0}})
+	_, err = parseScanPacket(zero[:scanPacketHeaderSize], zero[scanPacketHeaderSize:], IntensityDecoder{}, FirmwareQuirks{})
+	assert.ErrorIs(t, err, ErrBadHeader)
+}
+
+func TestCalculateAnglesShort(t *testing.T) {
+	// One sample: no step between the first and the last.
+	angles := calculateAngles([]float64{1000}, 10*64<<1, 10*64<<1, 1)
+	require.Len(t, angles, 1)
+	assert.False(t, math.IsNaN(angles[0]))
+
+	// Fewer distances than announced by the header.
+	assert.Len(t, calculateAngles([]float64{1000, 1000}, 0, 0, 40), 2)
+	assert.Empty(t, calculateAngles(nil, 0, 0, 40))
+}
+
+func FuzzParseScanPacket(f *testing.F) {
+	f.Add(encodeScanPacket(0x00, 0x6FE5, 0x79BD, [][3]byte{{100, 0xA0, 0x0F}, {7, 0x20, 0x03}}), false)
+	f.Add(encodeScanPacket(0x00, 0x0001, 0x0001, [][3]byte{{0, 0, 0}}), false)
+	f.Add(encodeScanPacket(0x01, 0x0001, 0x0001, [][3]byte{{0, 0, 0}}), false)
+	f.Add([]byte{0xAA, 0x55, 0x00, 0x02, 0x01, 0x00, 0x01, 0x00, 0xA8, 0x57, 0x10, 0x27, 0x20, 0x4E}, true)
+	f.Add([]byte{0xAA, 0x55}, false)
+
+	f.Fuzz(func(t *testing.T, data []byte, twoByte bool) {
+		var decoder SampleDecoder = IntensityDecoder{}
+		if twoByte {
+			decoder = DistanceDecoder{}
+		}
+		header, samples := data, []byte(nil)
+		if len(data) > scanPacketHeaderSize {
+			header, samples = data[:scanPacketHeaderSize], data[scanPacketHeaderSize:]
+		}
+
+		parsed, err := parseScanPacket(header, samples, decoder, FirmwareQuirks{})
+		if err != nil {
+			return
+		}
+		n := int(parsed.header.SampleQuantity)
+		if len(parsed.distances) != n || len(parsed.intensities) != n || len(parsed.angles) != n {
+			t.Fatalf("%v samples decoded into %v distances, %v intensities and %v angles", n, len(parsed.distances), len(parsed.intensities), len(parsed.angles))
+		}
+		for _, angle := range parsed.angles {
+			if math.IsNaN(angle) || math.IsInf(angle, 0) {
+				t.Fatalf("invalid angle %v", angle)
+			}
+		}
+	})
+}
+
+func FuzzParseInfoHeader(f *testing.F) {
+	f.Add([]byte{0xA5, 0x5A, 0x14, 0x00, 0x00, 0x00, InfoTypeCode})
+	f.Add([]byte{0xA5, 0x5A, 0x03, 0x00, 0x00, 0x00, HealthTypeCode})
+	f.Add(append([]byte(nil), scanResponseHeader...))
+	f.Add([]byte{0xA5})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		size, _, mode, err := parseInfoHeader(data)
+		if err != nil {
+			return
+		}
+		if size > 0x3F || mode > 3 {
+			t.Fatalf("size %v, mode %v out of range", size, mode)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package ydlidar
+
+import "time"
+
+const (
+	// maxPendingTriggers is the number of triggers waiting for a revolution, the oldest are
+	// dropped beyond, eg. while the scan is stopped.
+	maxPendingTriggers = 16
+
+	// phaseLockHistory is the number of revolutions kept to choose from.
+	phaseLockHistory = 2
+)
+
+// AlignedScan is a revolution delivered on the Aligned channel for a trigger, see
+// WithPhaseLock.
+type AlignedScan struct {
+	Scan    Scan          // The revolution whose zero crossing is the closest to the trigger.
+	Trigger time.Time     // The trigger the revolution is aligned to.
+	Offset  time.Duration // Scan.Start minus Trigger, negative if the revolution started before.
+}
+
+// phaseLockConfig is the trigger of the Aligned channel.
+type phaseLockConfig struct {
+	trigger <-chan time.Time
+	period  time.Duration // Period of the host clock boundaries, used without a trigger.
+}
+
+// WithPhaseLock enables the Aligned channel, of the given capacity, receiving for every time
+// received on trigger the revolution whose zero crossing, Scan.Start, is the closest to it:
+// the scan emission is locked to an external sync signal or timer for multi-sensor fusion. A
+// revolution is delivered once the next one started, a period or so after the trigger. The
+// same revolution is delivered for several triggers if they come faster than the rotation.
+// Aligned scans are dropped when the channel is full. The alignment stops when trigger is
+// closed.
+func WithPhaseLock(trigger <-chan time.Time, buffer int) Option {
+	return func(lidar *YDLidar) {
+		lidar.Aligned = make(chan AlignedScan, buffer)
+		lidar.phaseLock = phaseLockConfig{trigger: trigger}
+	}
+}
+
+// WithPhaseLockPeriod is WithPhaseLock triggered at every multiple of period of the host
+// clock, eg. at every 100ms boundary, see ClockTrigger.
+func WithPhaseLockPeriod(period time.Duration, buffer int) Option {
+	return func(lidar *YDLidar) {
+		lidar.Aligned = make(chan AlignedScan, buffer)
+		lidar.phaseLock = phaseLockConfig{period: period}
+	}
+}
+
+// ClockTrigger returns a channel receiving the boundaries of the host clock at every multiple
+// of period since the Unix epoch until stop is closed, then closed. Unlike a time.Ticker the
+// ticks don't depend on the start time: two processes get the same boundaries. A boundary is
+// skipped if the previous one wasn't received yet.
+func ClockTrigger(period time.Duration, stop <-chan struct{}) <-chan time.Time {
+	ticks := make(chan time.Time, 1)
+	go func() {
+		defer close(ticks)
+		for {
+			next := time.Now().Truncate(period).Add(period)
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-timer.C:
+			case <-stop:
+				timer.Stop()
+				return
+			}
+			select {
+			case ticks <- next:
+			default:
+			}
+		}
+	}()
+	return ticks
+}
+
+// startPhaseLock starts the alignment of the revolutions to the trigger, if enabled.
+func (lidar *YDLidar) startPhaseLock() {
+	if lidar.Aligned == nil {
+		return
+	}
+	trigger := lidar.phaseLock.trigger
+	if trigger == nil {
+		trigger = ClockTrigger(lidar.phaseLock.period, lidar.quit)
+	}
+	scans := lidar.ScanStream(SubscribeBuffer(phaseLockHistory, DropOldest))
+	lidar.monitors.Add(1)
+	go lidar.alignScans(trigger, scans)
+}
+
+// alignScans delivers the revolutions closest to the triggers until Close.
+func (lidar *YDLidar) alignScans(trigger <-chan time.Time, scans *Stream[Scan]) {
+	defer lidar.monitors.Done()
+	defer scans.Unsubscribe()
+
+	var locker phaseLocker
+	for {
+		select {
+		case t, ok := <-trigger:
+			if !ok {
+				return
+			}
+			locker.trigger(t)
+		case scan := <-scans.C:
+			locker.scan(scan)
+		case <-lidar.quit:
+			return
+		}
+		for _, aligned := range locker.take() {
+			select {
+			case lidar.Aligned <- aligned:
+			default:
+				lidar.metrics.dropped.Add(1)
+			}
+		}
+	}
+}
+
+// phaseLocker matches the triggers with the revolutions. A trigger is matched once a
+// revolution started after it, the closest of that revolution and the previous ones wins.
+type phaseLocker struct {
+	history []Scan      // Last completed revolutions, oldest first.
+	pending []time.Time // Triggers waiting for a revolution started after them.
+	ready   []AlignedScan
+}
+
+// trigger adds a trigger, matched at once if a revolution started after it.
+func (p *phaseLocker) trigger(t time.Time) {
+	if len(p.pending) == maxPendingTriggers {
+		p.pending = p.pending[1:]
+	}
+	p.pending = append(p.pending, t)
+	p.match()
+}
+
+// scan adds a completed revolution, matching the triggers before its start.
+func (p *phaseLocker) scan(scan Scan) {
+	if scan.Partial {
+		return
+	}
+	if len(p.history) == phaseLockHistory {
+		p.history = p.history[1:]
+	}
+	p.history = append(p.history, scan)
+	p.match()
+}
+
+// match matches the pending triggers before the start of the last revolution.
+func (p *phaseLocker) match() {
+	if len(p.history) == 0 {
+		return
+	}
+	last := p.history[len(p.history)-1]
+	for len(p.pending) > 0 && !last.Start.Before(p.pending[0]) {
+		t := p.pending[0]
+		p.pending = p.pending[1:]
+		best := last
+		for _, scan := range p.history {
+			if absDuration(scan.Start.Sub(t)) < absDuration(best.Start.Sub(t)) {
+				best = scan
+			}
+		}
+		p.ready = append(p.ready, AlignedScan{Scan: best, Trigger: t, Offset: best.Start.Sub(t)})
+	}
+}
+
+// take returns the matched triggers.
+func (p *phaseLocker) take() []AlignedScan {
+	ready := p.ready
+	p.ready = nil
+	return ready
+}
+
+// absDuration returns the absolute value of d.
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
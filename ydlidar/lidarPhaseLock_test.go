@@ -0,0 +1,85 @@
+package ydlidar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhaseLocker(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	at := func(ms int) time.Time { return base.Add(time.Duration(ms) * time.Millisecond) }
+	revolution := func(seq uint64, startMs int) Scan { return Scan{Seq: seq, Start: at(startMs)} }
+
+	// Revolutions every 140ms, triggers every 100ms.
+	var p phaseLocker
+	p.trigger(at(0))
+	p.scan(revolution(1, 10))
+	p.trigger(at(100))
+	p.scan(revolution(2, 150))
+	p.trigger(at(200))
+	p.trigger(at(300))
+	p.scan(revolution(3, 290))
+	p.scan(Scan{Seq: 4, Start: at(400), Partial: true})
+	p.scan(revolution(5, 430))
+
+	var matched []uint64
+	var offsets []time.Duration
+	for _, aligned := range p.take() {
+		matched = append(matched, aligned.Scan.Seq)
+		offsets = append(offsets, aligned.Offset)
+	}
+	assert.Equal(t, []uint64{1, 2, 2, 3}, matched)
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 50 * time.Millisecond, -50 * time.Millisecond, -10 * time.Millisecond}, offsets)
+	assert.Empty(t, p.take())
+
+	// A late trigger is matched at once, the oldest pending triggers are dropped.
+	p.trigger(at(420))
+	require.Len(t, p.ready, 1)
+	assert.Equal(t, uint64(5), p.take()[0].Scan.Seq)
+	for i := 0; i < 2*maxPendingTriggers; i++ {
+		p.trigger(at(1000 + i))
+	}
+	assert.Len(t, p.pending, maxPendingTriggers)
+	assert.Equal(t, at(1000+maxPendingTriggers), p.pending[0])
+}
+
+func TestPhaseLock(t *testing.T) {
+	trigger := make(chan time.Time)
+	port := &fakePort{}
+	port.queue(scanResponseHeader...)
+	port.refill = revolutionBytes
+	lidar := NewLidar(port, WithPhaseLock(trigger, 4))
+	require.NoError(t, lidar.StartScan())
+	go func() {
+		for range lidar.Packets {
+		}
+	}()
+
+	tick := time.Now()
+	trigger <- tick
+	select {
+	case aligned := <-lidar.Aligned:
+		assert.Equal(t, tick, aligned.Trigger)
+		assert.Equal(t, aligned.Scan.Start.Sub(tick), aligned.Offset)
+		assert.Len(t, aligned.Scan.Points, 480)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no aligned revolution")
+	}
+	require.NoError(t, lidar.Close())
+}
+
+func TestClockTrigger(t *testing.T) {
+	stop := make(chan struct{})
+	ticks := ClockTrigger(10*time.Millisecond, stop)
+	for i := 0; i < 3; i++ {
+		tick := <-ticks
+		assert.Equal(t, tick, tick.Truncate(10*time.Millisecond), "on a boundary")
+		assert.False(t, time.Now().Before(tick))
+	}
+	close(stop)
+	for range ticks {
+	}
+}
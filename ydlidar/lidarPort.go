@@ -0,0 +1,190 @@
+package ydlidar
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// ErrNoPort is returned when auto-detection finds no serial port that could be a lidar.
+var ErrNoPort = errors.New("ydlidar: no lidar serial port found")
+
+// serialMode is the line setting of the lidar: 230400 baud, 8N1.
+var serialMode = serial.Mode{
+	BaudRate: 230400,
+	DataBits: 8,
+	Parity:   serial.NoParity,
+	StopBits: serial.OneStopBit,
+}
+
+// portSetup are the modem lines set right after the port is opened. Opening a port asserts
+// DTR and RTS on every platform, but drivers differ on what happens next, so both are set
+// explicitly rather than left to the driver.
+type portSetup struct {
+	dtr bool // DTR powers the motor on the G2 adapter board.
+	rts bool // RTS is unused by the lidar.
+}
+
+// openSerial is GetSerialPort as a Transport opener, the default of YDLidar.openPort.
+func openSerial(name *string) (Transport, error) {
+	return GetSerialPort(name)
+}
+
+// openSerialPort opens the named port and applies the platform's line setup.
+func openSerialPort(name string) (serial.Port, error) {
+	return openSerialPortMode(name, &serialMode)
+}
+
+// openSerialPortMode is openSerialPort with the line setting of another device.
+func openSerialPortMode(name string, mode *serial.Mode) (serial.Port, error) {
+	port, err := serial.Open(name, mode)
+	if err != nil {
+		return nil, classifyOpenError(name, err)
+	}
+	if err = port.SetDTR(platformSetup.dtr); err != nil {
+		port.Close()
+		return nil, fmt.Errorf("failed to set DTR on %v: %w", name, err)
+	}
+	if err = port.SetRTS(platformSetup.rts); err != nil {
+		port.Close()
+		return nil, fmt.Errorf("failed to set RTS on %v: %w", name, err)
+	}
+	log.Printf("Connected to port: %v", name)
+	return port, nil
+}
+
+// classifyOpenError wraps the errors of opening the port the user can do something about in
+// ErrPermissionDenied and ErrPortBusy, with what to do.
+func classifyOpenError(name string, err error) error {
+	busy, denied := errors.Is(err, syscall.EBUSY), errors.Is(err, fs.ErrPermission)
+	var portErr *serial.PortError
+	if errors.As(err, &portErr) {
+		busy = busy || portErr.Code() == serial.PortBusy
+		denied = denied || portErr.Code() == serial.PermissionDenied
+	}
+	switch {
+	case denied:
+		return fmt.Errorf("%w: %v (%v), the user needs read and write access to it, on Linux join the group owning it, "+
+			"usually dialout: sudo usermod -aG dialout $USER then log in again", ErrPermissionDenied, name, err)
+	case busy:
+		return fmt.Errorf("%w: %v (%v), another program holds it, eg. ModemManager probing the adapter, "+
+			"which lets go after a few seconds, or another instance of the driver", ErrPortBusy, name, err)
+	}
+	return err
+}
+
+// openRetry are the retries of opening a busy port, see WithOpenRetry.
+type openRetry struct {
+	attempts int
+	backoff  time.Duration
+}
+
+// WithOpenRetry retries opening the serial port while it is busy, up to attempts times
+// spaced by an exponential backoff starting at backoff. ModemManager holds new USB serial
+// ports for a few seconds. Applies to InitAndConnectToDevice and Connect, reconnections have
+// their own backoff, see WithReconnect.
+func WithOpenRetry(attempts int, backoff time.Duration) Option {
+	return func(lidar *YDLidar) {
+		lidar.openRetry = openRetry{attempts: attempts, backoff: backoff}
+	}
+}
+
+// open calls open again while it fails with ErrPortBusy.
+func (r openRetry) open(open func() (serial.Port, error)) (serial.Port, error) {
+	backoff := r.backoff
+	for attempt := 1; ; attempt++ {
+		port, err := open()
+		if err == nil || !errors.Is(err, ErrPortBusy) || attempt > r.attempts {
+			return port, err
+		}
+		log.Printf("Port busy, retrying in %v: %v", backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// CandidatePorts returns the serial ports of the platform that could be a lidar, in name
+// order, for applications connecting several lidars.
+func CandidatePorts() ([]string, error) {
+	ports, err := serial.GetPortsList()
+	if err != nil {
+		return nil, err
+	}
+	var candidates []string
+	for _, port := range ports {
+		if candidatePort(port) {
+			candidates = append(candidates, port)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return naturalLess(candidates[i], candidates[j]) })
+	return candidates, nil
+}
+
+// selectPort picks the port to auto-connect to among the enumerated ones: the last one, in
+// name order, that the platform considers a candidate, as USB adapters plugged last get the
+// highest numbers.
+func selectPort(ports []string, candidate func(string) bool) (string, error) {
+	var candidates []string
+	for _, port := range ports {
+		if candidate(port) {
+			candidates = append(candidates, port)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("%w among %v", ErrNoPort, ports)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return naturalLess(candidates[i], candidates[j]) })
+	return candidates[len(candidates)-1], nil
+}
+
+// naturalLess orders names by their prefix then by their trailing number, so COM10 comes
+// after COM9.
+func naturalLess(a, b string) bool {
+	pa, na := splitNumber(a)
+	pb, nb := splitNumber(b)
+	if pa != pb {
+		return pa < pb
+	}
+	if len(na) != len(nb) {
+		return len(na) < len(nb)
+	}
+	return na < nb
+}
+
+// splitNumber splits the trailing digits off the name.
+func splitNumber(name string) (prefix, number string) {
+	i := len(name)
+	for i > 0 && name[i-1] >= '0' && name[i-1] <= '9' {
+		i--
+	}
+	return name[:i], strings.TrimLeft(name[i:], "0")
+}
+
+// linuxCandidate accepts the USB serial devices: ttyUSB for the CP210x and CH340 adapters
+// shipped with the lidars, ttyACM for CDC devices. Built in ttyS ports are never a lidar.
+func linuxCandidate(name string) bool {
+	return strings.HasPrefix(name, "/dev/ttyUSB") || strings.HasPrefix(name, "/dev/ttyACM")
+}
+
+// darwinCandidate accepts the USB serial callout devices. The /dev/tty.* dial-in twins block on
+// open until carrier detect, and Bluetooth ports are never a lidar.
+func darwinCandidate(name string) bool {
+	if !strings.HasPrefix(name, "/dev/cu.") {
+		return false
+	}
+	lower := strings.ToLower(name)
+	return !strings.Contains(lower, "bluetooth") && !strings.Contains(lower, "debug-console")
+}
+
+// windowsCandidate accepts the COM ports. Legacy motherboard ports, usually COM1 and COM2,
+// can't be told apart by name and lose to USB adapters, which get higher numbers.
+func windowsCandidate(name string) bool {
+	return strings.HasPrefix(strings.ToUpper(name), "COM")
+}
@@ -0,0 +1,6 @@
+package ydlidar
+
+// candidatePort tells the ports auto-detection may pick.
+var candidatePort = darwinCandidate
+
+var platformSetup = portSetup{dtr: true, rts: false}
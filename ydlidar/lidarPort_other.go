@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package ydlidar
+
+// candidatePort accepts every port on platforms without a known naming scheme.
+var candidatePort = func(string) bool { return true }
+
+var platformSetup = portSetup{dtr: true, rts: false}
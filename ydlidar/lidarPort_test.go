@@ -0,0 +1,77 @@
+package ydlidar
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.bug.st/serial"
+)
+
+func TestSelectPort(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		ports     []string
+		candidate func(string) bool
+		want      string
+	}{
+		{"linux", []string{"/dev/ttyS0", "/dev/ttyUSB1", "/dev/ttyUSB0", "/dev/ttyS4"}, linuxCandidate, "/dev/ttyUSB1"},
+		{"linux acm", []string{"/dev/ttyS0", "/dev/ttyACM0"}, linuxCandidate, "/dev/ttyACM0"},
+		{"darwin", []string{"/dev/cu.usbserial-0001", "/dev/tty.usbserial-0001", "/dev/cu.Bluetooth-Incoming-Port"}, darwinCandidate, "/dev/cu.usbserial-0001"},
+		{"windows", []string{"COM1", "COM10", "COM9"}, windowsCandidate, "COM10"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			port, err := selectPort(tc.ports, tc.candidate)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, port)
+		})
+	}
+
+	_, err := selectPort([]string{"/dev/ttyS0"}, linuxCandidate)
+	assert.ErrorIs(t, err, ErrNoPort)
+}
+
+func TestClassifyOpenError(t *testing.T) {
+	err := classifyOpenError("/dev/ttyUSB0", &fs.PathError{Op: "open", Path: "/dev/ttyUSB0", Err: syscall.EACCES})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	assert.Contains(t, err.Error(), "dialout")
+
+	err = classifyOpenError("/dev/ttyUSB0", fmt.Errorf("open: %w", syscall.EBUSY))
+	assert.ErrorIs(t, err, ErrPortBusy)
+	assert.Contains(t, err.Error(), "ModemManager")
+
+	other := errors.New("no such device")
+	assert.Same(t, other, classifyOpenError("/dev/ttyUSB0", other))
+}
+
+func TestOpenRetry(t *testing.T) {
+	calls := 0
+	open := func() (serial.Port, error) {
+		calls++
+		if calls < 3 {
+			return nil, ErrPortBusy
+		}
+		return &fakePort{}, nil
+	}
+
+	port, err := openRetry{attempts: 2, backoff: time.Millisecond}.open(open)
+	require.NoError(t, err)
+	assert.NotNil(t, port)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	_, err = openRetry{attempts: 1, backoff: time.Millisecond}.open(open)
+	assert.ErrorIs(t, err, ErrPortBusy)
+	assert.Equal(t, 2, calls)
+
+	// Only a busy port is retried.
+	calls = 0
+	_, err = openRetry{attempts: 5}.open(func() (serial.Port, error) { calls++; return nil, ErrPermissionDenied })
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	assert.Equal(t, 1, calls)
+}
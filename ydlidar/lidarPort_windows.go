@@ -0,0 +1,8 @@
+package ydlidar
+
+// candidatePort tells the ports auto-detection may pick.
+var candidatePort = windowsCandidate
+
+// The Windows serial driver enables RTS flow control by default, which the lidar doesn't
+// speak, so RTS is forced low like everywhere else.
+var platformSetup = portSetup{dtr: true, rts: false}
@@ -0,0 +1,19 @@
+package ydlidar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar/geom"
+)
+
+func TestScanTransform(t *testing.T) {
+	scan := Scan{Points: []PointCloudData{{Angle: 0, Dist: 1000}, {Angle: 90}, {Angle: 90, Dist: 500}}}
+	points := scan.Transform(geom.Pose2D{X: 2000, Y: 0, Heading: 90})
+	require.Len(t, points, 2, "the dropout is skipped")
+	assert.InDelta(t, 2000, points[0].X, 1e-9)
+	assert.InDelta(t, 1000, points[0].Y, 1e-9)
+	assert.InDelta(t, 1500, points[1].X, 1e-9)
+	assert.InDelta(t, 0, points[1].Y, 1e-9)
+}
@@ -0,0 +1,137 @@
+package ydlidar
+
+import (
+	"math"
+	"time"
+)
+
+// Buffer sizes of the small profile.
+const (
+	smallPacketBufferSize = 2 // Also the Aux, Diagnostics and RawFrames channels.
+	smallStatusBufferSize = 2
+	smallHealthBufferSize = 1
+	smallCompactScans     = 1
+)
+
+// CompactScan is a revolution in a struct-of-arrays layout using 8 bytes per point instead of 32,
+// for memory constrained devices. Distances are stored in whole millimeters whatever the unit.
+type CompactScan struct {
+	Seq         uint64
+	Start       time.Time
+	End         time.Time
+	Partial     bool
+	Angles      []float32
+	Distances   []uint16
+	Intensities []uint16
+	Units       Unit // Distance unit of Point.
+}
+
+// Len returns the number of points.
+func (s *CompactScan) Len() int {
+	return len(s.Distances)
+}
+
+// Point returns the i-th point.
+func (s *CompactScan) Point(i int) PointCloudData {
+	return PointCloudData{
+		Angle:     float64(s.Angles[i]),
+		Dist:      s.Units.FromMillimeters(float64(s.Distances[i])),
+		Intensity: int(s.Intensities[i]),
+	}
+}
+
+// WithSmallProfile caps memory use for 64MB-class devices: the channel buffers are
+// reduced to a minimum, those set with WithPacketBuffer, WithAuxPackets, WithRawFrames and
+// WithPhaseLock included, the Scans channel and the scan processors are disabled and
+// revolutions are delivered on CompactScans instead. Overrides the other options.
+func WithSmallProfile() Option {
+	return func(lidar *YDLidar) {
+		lidar.small = true
+	}
+}
+
+// applySmallProfile caps the buffers and swaps the accumulation features for the compact layout.
+func (lidar *YDLidar) applySmallProfile() {
+	lidar.Scans = nil
+	lidar.processors = nil
+	lidar.partial = DiscardPartialScan
+	lidar.CompactScans = make(chan CompactScan, smallCompactScans)
+	lidar.Status = make(chan StatusEvent, smallStatusBufferSize)
+	if lidar.Health != nil {
+		lidar.Health = make(chan HealthStatus, smallHealthBufferSize)
+	}
+	lidar.Packets = capBuffer(lidar.Packets, smallPacketBufferSize)
+	lidar.Aux = capBuffer(lidar.Aux, smallPacketBufferSize)
+	lidar.Diagnostics = capBuffer(lidar.Diagnostics, smallPacketBufferSize)
+	lidar.RawFrames = capBuffer(lidar.RawFrames, smallPacketBufferSize)
+	lidar.Aligned = capBuffer(lidar.Aligned, smallCompactScans)
+	lidar.buffers = &bufferPool{}
+	lidar.ahead.size = smallBulkReadSize
+}
+
+// capBuffer returns ch, or a channel of the given capacity if ch has a larger one. A disabled,
+// nil, channel stays disabled.
+func capBuffer[T any](ch chan T, capacity int) chan T {
+	if cap(ch) <= capacity {
+		return ch
+	}
+	return make(chan T, capacity)
+}
+
+// compactAssembler is the scanAssembler of the small profile.
+type compactAssembler struct {
+	units    Unit
+	current  CompactScan
+	started  bool
+	seq      uint64
+	capacity int // Points in the last revolution, used to size the next one in one allocation.
+}
+
+// startRevolution completes the current revolution and starts a new one.
+// Returns the completed revolution, if there was one.
+func (a *compactAssembler) startRevolution() (CompactScan, bool) {
+	completed, ok := a.current, a.started && a.current.Len() > 0
+	if ok {
+		a.capacity = completed.Len()
+	}
+
+	a.seq++
+	a.started = true
+	a.current = CompactScan{
+		Seq:         a.seq,
+		Start:       time.Now(),
+		Angles:      make([]float32, 0, a.capacity),
+		Distances:   make([]uint16, 0, a.capacity),
+		Intensities: make([]uint16, 0, a.capacity),
+		Units:       a.units,
+	}
+
+	return completed, ok
+}
+
+// add appends the samples of the packet to the current revolution.
+func (a *compactAssembler) add(packet Packet) {
+	if !a.started || packet.Error != nil {
+		return
+	}
+	for i, dist := range packet.Distances {
+		a.current.Distances = append(a.current.Distances, uint16(math.Min(a.units.ToMillimeters(dist), math.MaxUint16)))
+		a.current.Angles = append(a.current.Angles, float32(packet.Angles[i]))
+		a.current.Intensities = append(a.current.Intensities, uint16(packet.Intensities[i]))
+	}
+	a.current.End = time.Now()
+}
+
+// sendCompactScan delivers the revolution on the CompactScans channel.
+// Returns false if the scan was stopped before it could be delivered.
+func (lidar *YDLidar) sendCompactScan(scan CompactScan) bool {
+	if lidar.CompactScans == nil {
+		return true
+	}
+	select {
+	case lidar.CompactScans <- scan:
+		return true
+	case <-lidar.Stop:
+		return false
+	}
+}
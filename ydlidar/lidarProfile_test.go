@@ -0,0 +1,143 @@
+package ydlidar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeScanPacket returns a scan packet as sent by the device, samples are 3 bytes each.
+func encodeScanPacket(ct byte, fsa, lsa uint16, samples [][3]byte) []byte {
+	cs := 0x55AA ^ (uint16(len(samples))<<8 | uint16(ct)) ^ fsa ^ lsa
+	for _, sample := range samples {
+		cs ^= uint16(sample[0]) ^ (uint16(sample[2])<<8 | uint16(sample[1]))
+	}
+
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, pointCloudHeader{
+		PacketHeader:   0x55AA,
+		PackageType:    ct,
+		SampleQuantity: uint8(len(samples)),
+		StartAngle:     fsa,
+		EndAngle:       lsa,
+		CheckCode:      cs,
+	})
+	for _, sample := range samples {
+		b.Write(sample[:])
+	}
+	return b.Bytes()
+}
+
+// revolutionBytes returns a zero packet followed by packets of 40 samples covering 360°.
+func revolutionBytes() []byte {
+	var b bytes.Buffer
+	b.Write(encodeScanPacket(0x01, 0x0001, 0x0001, [][3]byte{{0, 0, 0}}))
+	samples := make([][3]byte, 40)
+	for i := range samples {
+		// 1000mm, intensity 100.
+		samples[i] = [3]byte{100, byte(1000 & 0x3F << 2), byte(1000 >> 6)}
+	}
+	for start := 0; start < 360; start += 30 {
+		fsa := uint16(start*64)<<1 | 1
+		lsa := uint16((start+29)*64)<<1 | 1
+		b.Write(encodeScanPacket(0x00, fsa, lsa, samples))
+	}
+	return b.Bytes()
+}
+
+// residentMemory returns the resident set size of the process, VmRSS of /proc/self/status.
+// Without /proc it falls back to the memory the runtime obtained from the OS, an upper bound of
+// the resident memory of the Go heap and stacks.
+func residentMemory() uint64 {
+	if status, err := os.ReadFile("/proc/self/status"); err == nil {
+		for _, line := range strings.Split(string(status), "\n") {
+			if fields := strings.Fields(line); len(fields) == 3 && fields[0] == "VmRSS:" {
+				if kb, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+					return kb << 10
+				}
+			}
+		}
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.Sys
+}
+
+// TestSmallProfileMemoryBound replays a long session through the small profile and checks the
+// resident memory grows by less than YDLIDAR_SMALL_PROFILE_MB megabytes, 4 by default: the
+// memory the lidar takes, a few buffers and a revolution, not the channels of the defaults.
+// The memory the other tests of the process left is returned to the OS first.
+func TestSmallProfileMemoryBound(t *testing.T) {
+	if testing.Short() {
+		t.Skip("long replay")
+	}
+	if raceEnabled {
+		t.Skip("the race detector multiplies the memory")
+	}
+	bound := uint64(4)
+	if env := os.Getenv("YDLIDAR_SMALL_PROFILE_MB"); env != "" {
+		mb, err := strconv.ParseUint(env, 10, 64)
+		require.NoError(t, err)
+		bound = mb
+	}
+
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(output) })
+
+	// Collect like on such a device, the default GOGC lets the garbage grow to 4MB first.
+	gcPercent := debug.SetGCPercent(20)
+	t.Cleanup(func() { debug.SetGCPercent(gcPercent) })
+	debug.FreeOSMemory()
+	baseline := residentMemory()
+	revolution := revolutionBytes()
+	port := &fakePort{refill: func() []byte { return revolution }}
+	lidar := NewLidar(port, WithSmallProfile(), WithScans(100))
+	assert.Nil(t, lidar.Scans)
+
+	port.queue(scanResponseHeader...)
+	require.NoError(t, lidar.StartScan())
+
+	var peak uint64
+	for revolutions := 0; revolutions < 3000; {
+		select {
+		case <-lidar.Packets:
+		case scan := <-lidar.CompactScans:
+			assert.Equal(t, 480, scan.Len())
+			revolutions++
+			if revolutions%500 == 0 {
+				if rss := residentMemory(); rss > peak {
+					peak = rss
+				}
+			}
+		}
+	}
+	require.NoError(t, lidar.StopScan())
+
+	growth := uint64(0)
+	if peak > baseline {
+		growth = peak - baseline
+	}
+	t.Logf("resident memory grew by %vkB", growth>>10)
+	assert.Less(t, growth, bound<<20, "resident memory grew by %v MB", growth>>20)
+}
+
+func TestSmallProfileBuffers(t *testing.T) {
+	// The small profile applies after the options setting the buffers, whatever their order.
+	lidar := NewLidar(&fakePort{}, WithSmallProfile(), WithPacketBuffer(1000, DropOldest), WithAuxPackets(100), WithRawFrames(100))
+
+	assert.Equal(t, smallPacketBufferSize, cap(lidar.Packets))
+	assert.Equal(t, smallPacketBufferSize, cap(lidar.Aux))
+	assert.Equal(t, smallPacketBufferSize, cap(lidar.Diagnostics))
+	assert.Equal(t, smallPacketBufferSize, cap(lidar.RawFrames))
+}
@@ -0,0 +1,43 @@
+package ydlidar
+
+import "fmt"
+
+// Projection selects the measurements of the points a lightweight output keeps, for the
+// consumers on a constrained link needing only one: the distances for obstacle avoidance,
+// the intensities for reflector detection. The angles are always kept. See the Distances and
+// Intensities codecs of package codec and the projection of package export.
+type Projection int
+
+const (
+	// AllFields keeps the distance and the intensity. This is the default.
+	AllFields Projection = iota
+
+	// DistanceOnly drops the intensity.
+	DistanceOnly
+
+	// IntensityOnly drops the distance.
+	IntensityOnly
+)
+
+// String returns the name of the projection.
+func (p Projection) String() string {
+	switch p {
+	case AllFields:
+		return "AllFields"
+	case DistanceOnly:
+		return "DistanceOnly"
+	case IntensityOnly:
+		return "IntensityOnly"
+	}
+	return fmt.Sprintf("Projection(%d)", int(p))
+}
+
+// KeepsDistance reports whether the projection keeps the distances.
+func (p Projection) KeepsDistance() bool {
+	return p != IntensityOnly
+}
+
+// KeepsIntensity reports whether the projection keeps the intensities.
+func (p Projection) KeepsIntensity() bool {
+	return p != DistanceOnly
+}
@@ -0,0 +1,121 @@
+package ydlidar
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// FirmwareVersion is a firmware revision as reported by DeviceInfo.
+type FirmwareVersion struct {
+	Major, Minor byte
+}
+
+// String returns the version as major.minor.
+func (v FirmwareVersion) String() string {
+	return fmt.Sprintf("%v.%v", v.Major, v.Minor)
+}
+
+// less reports whether v is older than other.
+func (v FirmwareVersion) less(other FirmwareVersion) bool {
+	return v.Major < other.Major || v.Major == other.Major && v.Minor < other.Minor
+}
+
+// FirmwareQuirks are the differences of a firmware revision from the protocol the driver
+// implements. The zero value is the documented protocol.
+type FirmwareQuirks struct {
+	// FrequencyScale is the number of units per Hz of the answers to the frequency commands,
+	// 100 for 0.01Hz if 0.
+	FrequencyScale float64
+
+	// ZeroPacketFrequencyScale is the number of units per Hz of the frequency in the zero
+	// packets, 10 for 0.1Hz if 0.
+	ZeroPacketFrequencyScale float64
+
+	// AngleCorrected is set when the firmware corrects the angles for the parallax between the
+	// laser and the sensor itself, the driver then interpolates them as sent.
+	AngleCorrected bool
+}
+
+// commandFrequency decodes the answer to a frequency command.
+func (q FirmwareQuirks) commandFrequency(raw uint32) float64 {
+	scale := q.FrequencyScale
+	if scale <= 0 {
+		scale = 100
+	}
+	return float64(raw) / scale
+}
+
+// zeroPacketFrequency decodes the frequency in the CT byte of a zero packet.
+func (q FirmwareQuirks) zeroPacketFrequency(packageType uint8) float64 {
+	scale := q.ZeroPacketFrequencyScale
+	if scale <= 0 {
+		scale = 10
+	}
+	return float64(scanFrequency(packageType)) / scale
+}
+
+// firmwareQuirk applies the quirks to the firmware revisions of a model from from to to
+// included.
+type firmwareQuirk struct {
+	model    byte
+	from, to FirmwareVersion
+	quirks   FirmwareQuirks
+}
+
+// quirkTable lists the firmware revisions departing from the protocol, searched from the end
+// so the entries registered last take precedence. It starts empty: no revision is known to
+// depart from the development manuals, an entry needs the revision and the behaviour confirmed
+// on a device or in the release notes of the firmware before it is built in. Until then
+// RegisterFirmwareQuirks adds them.
+var (
+	quirkTable []firmwareQuirk
+	quirkMu    sync.RWMutex
+)
+
+// RegisterFirmwareQuirks sets the quirks of the firmware revisions of the model from from to
+// to included. It takes precedence over the quirks registered before and applies to the lidars
+// reading their device info afterwards.
+func RegisterFirmwareQuirks(model byte, from, to FirmwareVersion, quirks FirmwareQuirks) {
+	quirkMu.Lock()
+	defer quirkMu.Unlock()
+	quirkTable = append(quirkTable, firmwareQuirk{model: model, from: from, to: to, quirks: quirks})
+}
+
+// quirksFor returns the quirks of the firmware revision of the model.
+func quirksFor(model byte, version FirmwareVersion) FirmwareQuirks {
+	quirkMu.RLock()
+	defer quirkMu.RUnlock()
+	for i := len(quirkTable) - 1; i >= 0; i-- {
+		entry := quirkTable[i]
+		if entry.model == model && !version.less(entry.from) && !entry.to.less(version) {
+			return entry.quirks
+		}
+	}
+	return FirmwareQuirks{}
+}
+
+// FirmwareQuirks returns the quirks applied to the connected firmware, selected by DeviceInfo.
+func (lidar *YDLidar) FirmwareQuirks() FirmwareQuirks {
+	return lidar.quirks
+}
+
+// interpolateAngles spreads the angles of the samples evenly from the start angle to the end
+// angle of the packet, without correction, for the firmware correcting them.
+func interpolateAngles(startAngle, endAngle uint16, sampleQuantity int) []float64 {
+	angles := make([]float64, sampleQuantity)
+	if sampleQuantity == 0 {
+		return angles
+	}
+	first := float64(startAngle>>1) / 64
+	last := float64(endAngle>>1) / 64
+	diff := math.Mod(last-first+360, 360)
+	step := 0.0
+	if sampleQuantity > 1 {
+		step = diff / float64(sampleQuantity-1)
+	}
+	for i := range angles {
+		angles[i] = math.Mod(first+step*float64(i), 360)
+	}
+	return angles
+}
@@ -0,0 +1,70 @@
+package ydlidar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuirksFor(t *testing.T) {
+	// No revision is built in.
+	assert.Empty(t, quirkTable)
+	assert.Equal(t, FirmwareQuirks{}, quirksFor(15, FirmwareVersion{0, 0}))
+
+	saved := quirkTable
+	defer func() { quirkTable = saved }()
+	RegisterFirmwareQuirks(15, FirmwareVersion{0, 0}, FirmwareVersion{0, 255}, FirmwareQuirks{FrequencyScale: 10})
+	assert.Equal(t, float64(10), quirksFor(15, FirmwareVersion{0, 0}).FrequencyScale)
+	assert.Equal(t, float64(10), quirksFor(15, FirmwareVersion{0, 9}).FrequencyScale)
+	assert.Equal(t, FirmwareQuirks{}, quirksFor(15, FirmwareVersion{1, 0}))
+	assert.Equal(t, FirmwareQuirks{}, quirksFor(5, FirmwareVersion{1, 0}))
+
+	RegisterFirmwareQuirks(15, FirmwareVersion{0, 5}, FirmwareVersion{2, 9}, FirmwareQuirks{AngleCorrected: true})
+	assert.Equal(t, FirmwareQuirks{AngleCorrected: true}, quirksFor(15, FirmwareVersion{0, 7}), "registered last, takes precedence")
+	assert.Equal(t, FirmwareQuirks{FrequencyScale: 10}, quirksFor(15, FirmwareVersion{0, 3}))
+	assert.Equal(t, FirmwareQuirks{AngleCorrected: true}, quirksFor(15, FirmwareVersion{2, 3}))
+	assert.Equal(t, FirmwareQuirks{}, quirksFor(15, FirmwareVersion{3, 0}))
+	assert.Equal(t, "2.3", FirmwareVersion{2, 3}.String())
+}
+
+func TestFirmwareQuirksFrequency(t *testing.T) {
+	assert.Equal(t, 7.5, FirmwareQuirks{}.commandFrequency(750))
+	assert.Equal(t, 7.5, FirmwareQuirks{FrequencyScale: 10}.commandFrequency(75))
+
+	zero := uint8(75<<1 | 1)
+	assert.Equal(t, 7.5, FirmwareQuirks{}.zeroPacketFrequency(zero))
+	assert.Equal(t, 3.75, FirmwareQuirks{ZeroPacketFrequencyScale: 20}.zeroPacketFrequency(zero))
+}
+
+func TestQuirksFromDeviceInfo(t *testing.T) {
+	saved := quirkTable
+	defer func() { quirkTable = saved }()
+	RegisterFirmwareQuirks(15, FirmwareVersion{0, 0}, FirmwareVersion{0, 255}, FirmwareQuirks{FrequencyScale: 10})
+
+	port := &bootingPort{}
+	lidar := NewLidar(port)
+	info, err := lidar.DeviceInfo()
+	require.NoError(t, err)
+	// The test device reports firmware 0.0 of a G2.
+	assert.Equal(t, byte(0), info.FirmwareMajor)
+	assert.Equal(t, float64(10), lidar.FirmwareQuirks().FrequencyScale)
+}
+
+func TestAngleCorrectedQuirk(t *testing.T) {
+	samples := [][3]byte{{100, 0, 4}, {100, 0, 4}, {100, 0, 4}}
+	packet := encodeScanPacket(0x00, uint16(10*64)<<1|1, uint16(12*64)<<1|1, samples)
+	header, data := packet[:scanPacketHeaderSize], packet[scanPacketHeaderSize:]
+
+	parsed, err := parseScanPacket(header, data, IntensityDecoder{}, FirmwareQuirks{AngleCorrected: true})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{10, 11, 12}, parsed.angles)
+
+	corrected, err := parseScanPacket(header, data, IntensityDecoder{}, FirmwareQuirks{})
+	require.NoError(t, err)
+	assert.NotEqual(t, parsed.angles, corrected.angles)
+
+	// Across 0°.
+	assert.Equal(t, []float64{359, 0, 1}, interpolateAngles(uint16(359*64)<<1, uint16(1*64)<<1, 3))
+	assert.Empty(t, interpolateAngles(0, 0, 0))
+}
@@ -0,0 +1,112 @@
+package ydlidar
+
+import (
+	"fmt"
+
+	"ydlidarg2/ydlidar/protocol"
+)
+
+const (
+	// getRangingFrequency is the command to get the ranging (sampling) frequency.
+	getRangingFrequency = protocol.CmdGetRangingFrequency
+
+	// setRangingFrequency is the command to step the ranging frequency to the next supported value.
+	setRangingFrequency = protocol.CmdSetRangingFrequency
+)
+
+// RangingFrequency returns the ranging frequency, the number of distance samples per second,
+// in kHz. Returns ErrUnsupportedByModel unless DeviceInfo reported a model with an adjustable
+// ranging frequency. A running scan is paused meanwhile.
+func (lidar *YDLidar) RangingFrequency() (khz int, err error) {
+	rates, err := lidar.checkRangingCommand()
+	if err != nil {
+		return 0, err
+	}
+	err = lidar.command(func() error {
+		khz, err = lidar.rangingCommand(getRangingFrequency, rates)
+		return err
+	})
+	return khz, err
+}
+
+// SetRangingFrequency sets the ranging frequency to khz, one of the values supported by the
+// model, and returns the frequency the device reports. A running scan is paused meanwhile.
+func (lidar *YDLidar) SetRangingFrequency(khz int) (current int, err error) {
+	rates, err := lidar.checkRangingCommand()
+	if err != nil {
+		return 0, err
+	}
+	supported := false
+	for _, rate := range rates {
+		supported = supported || rate == khz
+	}
+	if !supported {
+		return 0, fmt.Errorf("%w: ranging frequency %vkHz, supported %v", ErrUnsupportedByModel, khz, rates)
+	}
+
+	err = lidar.command(func() error {
+		current, err = lidar.stepRangingFrequency(khz, rates)
+		return err
+	})
+	return current, err
+}
+
+// stepRangingFrequency sends the set command until the device reports khz.
+func (lidar *YDLidar) stepRangingFrequency(khz int, rates []int) (int, error) {
+	current, err := lidar.rangingCommand(getRangingFrequency, rates)
+	if err != nil {
+		return 0, err
+	}
+	// The set command steps to the next frequency, cycling through all of them at most once.
+	for steps := 0; current != khz && steps < len(rates); steps++ {
+		if current, err = lidar.rangingCommand(setRangingFrequency, rates); err != nil {
+			return 0, err
+		}
+	}
+	if current != khz {
+		return current, fmt.Errorf("ranging frequency stuck at %vkHz, wanted %vkHz", current, khz)
+	}
+	return current, nil
+}
+
+// checkRangingCommand verifies the model supports the ranging commands and returns its
+// frequencies.
+func (lidar *YDLidar) checkRangingCommand() ([]int, error) {
+	rates := models[lidar.model].rangingFrequencies
+	if rates == nil {
+		return nil, ErrUnsupportedByModel
+	}
+	return rates, nil
+}
+
+// rangingCommand sends a ranging frequency command and decodes the 1 byte frequency index response.
+func (lidar *YDLidar) rangingCommand(command byte, rates []int) (int, error) {
+	if _, err := lidar.SerialPort.Write([]byte{preCommand, command}); err != nil {
+		return 0, err
+	}
+
+	sizeOfMessage, typeCode, mode, err := lidar.readInfoHeader()
+	if err != nil {
+		return 0, err
+	}
+	if typeCode != InfoTypeCode {
+		return 0, fmt.Errorf("%w: invalid type code. Expected %x, got %v. Mode: %x", ErrBadHeader, InfoTypeCode, typeCode, mode)
+	}
+	if sizeOfMessage != 1 {
+		return 0, fmt.Errorf("%w: ranging frequency expected 1 byte got %v", ErrShortRead, sizeOfMessage)
+	}
+
+	data := make([]byte, 1)
+	n, err := lidar.readInfo(data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read serial: %w", err)
+	}
+	if n != len(data) {
+		return 0, fmt.Errorf("%w: ranging frequency expected 1 byte got %v", ErrShortRead, n)
+	}
+	if int(data[0]) >= len(rates) {
+		return 0, fmt.Errorf("unknown ranging frequency index %v", data[0])
+	}
+	lidar.rangingRate.Store(int32(rates[data[0]]))
+	return rates[data[0]], nil
+}
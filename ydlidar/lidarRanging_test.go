@@ -0,0 +1,49 @@
+package ydlidar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rangingResponse is the device answer to a ranging frequency command.
+func rangingResponse(index byte) []byte {
+	return []byte{0xA5, 0x5A, 0x01, 0x00, 0x00, 0x00, InfoTypeCode, index}
+}
+
+func TestRangingFrequencyUnsupported(t *testing.T) {
+	lidar := NewLidar(&fakePort{})
+
+	_, err := lidar.RangingFrequency()
+	assert.ErrorIs(t, err, ErrUnsupportedByModel)
+
+	lidar.model = 15 // G2
+	_, err = lidar.SetRangingFrequency(8)
+	assert.ErrorIs(t, err, ErrUnsupportedByModel)
+}
+
+func TestRangingFrequency(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port)
+	lidar.model = 5 // G4
+
+	port.queue(rangingResponse(1)...)
+	khz, err := lidar.RangingFrequency()
+	require.NoError(t, err)
+	assert.Equal(t, 8, khz)
+	assert.Equal(t, []byte{preCommand, getRangingFrequency}, port.written.Bytes())
+
+	// 8kHz steps to 9kHz, then wraps to 4kHz.
+	port.written.Reset()
+	port.queue(rangingResponse(1)...)
+	port.queue(rangingResponse(2)...)
+	port.queue(rangingResponse(0)...)
+	khz, err = lidar.SetRangingFrequency(4)
+	require.NoError(t, err)
+	assert.Equal(t, 4, khz)
+	assert.Equal(t, []byte{preCommand, getRangingFrequency, preCommand, setRangingFrequency, preCommand, setRangingFrequency}, port.written.Bytes())
+
+	_, err = lidar.SetRangingFrequency(5)
+	assert.ErrorIs(t, err, ErrUnsupportedByModel)
+}
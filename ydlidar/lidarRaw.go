@@ -0,0 +1,46 @@
+package ydlidar
+
+import (
+	"io"
+	"log"
+)
+
+// WithRawFrames enables the RawFrames channel, of the given capacity, receiving every scan
+// packet exactly as read from the device, header and samples, before it is checked or
+// decoded. Frames are dropped when the channel is full.
+func WithRawFrames(buffer int) Option {
+	return func(lidar *YDLidar) {
+		lidar.RawFrames = make(chan []byte, buffer)
+	}
+}
+
+// WithRawTap writes every scan packet exactly as read from the device to w, eg. a capture
+// file to hexdump, along with the bytes of the packets of unknown category. Writes happen on
+// the scan loop, w must be fast.
+func WithRawTap(w io.Writer) Option {
+	return func(lidar *YDLidar) {
+		lidar.rawTap = w
+	}
+}
+
+// emitRawFrame hands the raw bytes of a scan packet to the tap and the RawFrames channel.
+func (lidar *YDLidar) emitRawFrame(header, samples []byte) {
+	if lidar.RawFrames == nil && lidar.rawTap == nil {
+		return
+	}
+	frame := make([]byte, 0, len(header)+len(samples))
+	frame = append(append(frame, header...), samples...)
+
+	if lidar.rawTap != nil {
+		if _, err := lidar.rawTap.Write(frame); err != nil {
+			log.Printf("Raw tap write failed: %v", err)
+		}
+	}
+	if lidar.RawFrames != nil {
+		select {
+		case lidar.RawFrames <- frame:
+		default:
+			lidar.metrics.dropped.Add(1)
+		}
+	}
+}
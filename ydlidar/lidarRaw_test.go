@@ -0,0 +1,30 @@
+package ydlidar
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawFrames(t *testing.T) {
+	port := &fakePort{}
+	var tap bytes.Buffer
+	lidar := NewLidar(port, WithRawFrames(64), WithRawTap(&tap))
+	port.queue(scanResponseHeader...)
+	port.refill = revolutionBytes
+
+	require.NoError(t, lidar.StartScan())
+	for i := 0; i < 3; i++ {
+		<-lidar.Packets
+	}
+	require.NoError(t, lidar.StopScan())
+
+	revolution := revolutionBytes()
+	zero := <-lidar.RawFrames
+	assert.Equal(t, revolution[:scanPacketHeaderSize+3], zero)
+	first := <-lidar.RawFrames
+	assert.Equal(t, revolution[len(zero):len(zero)+scanPacketHeaderSize+40*3], first)
+	assert.True(t, bytes.HasPrefix(tap.Bytes(), revolution[:len(zero)+len(first)]))
+}
@@ -0,0 +1,101 @@
+package ydlidar
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// bootPoll is the read timeout while waiting for the device to boot.
+	bootPoll = 20 * time.Millisecond
+
+	// bootQuiet is how long the device stays silent after its boot banner before it
+	// accepts commands.
+	bootQuiet = 100 * time.Millisecond
+)
+
+// RebootOption configures RebootContext.
+type RebootOption func(*rebootConfig)
+
+type rebootConfig struct {
+	check bool
+}
+
+// RebootCheck re-reads the health once the device booted, so a device coming back broken is
+// reported by the reboot.
+func RebootCheck() RebootOption {
+	return func(c *rebootConfig) {
+		c.check = true
+	}
+}
+
+// Reboot soft reboots the lidar and waits until it accepts commands again, see RebootContext.
+func (lidar *YDLidar) Reboot() error {
+	return lidar.RebootContext(context.Background())
+}
+
+// RebootContext soft reboots the lidar and waits until it accepts commands again: the boot
+// banner is read until the device falls silent, or for the boot time of the device if it
+// prints none, then the stale bytes are dropped and the device info read back to check the
+// link is in step. The scan has to be stopped first.
+// Cancelling ctx abandons the wait, the device may still be booting.
+func (lidar *YDLidar) RebootContext(ctx context.Context, opts ...RebootOption) error {
+	var config rebootConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if lidar.IsScanning() {
+		return ErrScanRunning
+	}
+
+	err := lidar.command(func() error {
+		if _, err := lidar.SerialPort.Write([]byte{preCommand, restartDevice}); err != nil {
+			return fmt.Errorf("failed to send reboot command: %w", err)
+		}
+		if err := lidar.waitBoot(ctx); err != nil {
+			return err
+		}
+		// Drop whatever the device printed while booting, then check the link.
+		if _, err := lidar.resync(); err != nil {
+			return fmt.Errorf("device info after reboot: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !config.check {
+		return nil
+	}
+	if _, err := lidar.HealthInfo(); err != nil {
+		return fmt.Errorf("health after reboot: %w", err)
+	}
+	return nil
+}
+
+// waitBoot reads the boot output until the device has been silent for bootQuiet after
+// printing something, or until the boot time passed.
+func (lidar *YDLidar) waitBoot(ctx context.Context) error {
+	if err := lidar.setTimeout(bootPoll); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(lidar.bootTime)
+	var lastOutput time.Time
+	buf := make([]byte, 256)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		now := time.Now()
+		if now.After(deadline) || !lastOutput.IsZero() && now.Sub(lastOutput) >= bootQuiet {
+			return nil
+		}
+		// Read errors are expected while the device restarts its serial output.
+		if n, err := lidar.read(buf); n > 0 && err == nil {
+			lastOutput = time.Now()
+		}
+	}
+}
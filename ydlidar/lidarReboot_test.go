@@ -0,0 +1,91 @@
+package ydlidar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deviceInfoResponse is the device answer to the device info command, of a G2.
+var deviceInfoResponse = append([]byte{0xA5, 0x5A, 0x14, 0x00, 0x00, 0x00, InfoTypeCode, 15}, make([]byte, 19)...)
+
+// bootingPort answers the commands like a G2 that prints a banner when it reboots, unless
+// silent.
+type bootingPort struct {
+	fakePort
+	resets int
+	silent bool
+	health [][]byte // Answers to the health command in turn, OK once used up.
+}
+
+func (p *bootingPort) Write(b []byte) (int, error) {
+	p.fakePort.Write(b)
+	switch b[1] {
+	case restartDevice:
+		if !p.silent {
+			p.queue([]byte("YDLIDAR booting\r\n")...)
+		}
+	case startScanning:
+		p.queue(scanResponseHeader...)
+	case deviceInfo:
+		p.queue(deviceInfoResponse...)
+	case healthStatus:
+		if len(p.health) > 0 {
+			p.queue(p.health[0]...)
+			p.health = p.health[1:]
+		} else {
+			p.queue(healthResponse(SeverityOK, 0)...)
+		}
+	}
+	return len(b), nil
+}
+
+func (p *bootingPort) ResetInputBuffer() error {
+	p.resets++
+	return nil
+}
+
+func TestRebootWaitsForBanner(t *testing.T) {
+	port := &bootingPort{}
+	lidar := NewLidar(port)
+	lidar.bootTime = 5 * time.Second
+
+	start := time.Now()
+	require.NoError(t, lidar.RebootContext(context.Background(), RebootCheck()))
+	// The device fell silent after the banner, well before the boot time.
+	assert.Less(t, time.Since(start), time.Second)
+	assert.GreaterOrEqual(t, time.Since(start), bootQuiet)
+	assert.Equal(t, 1, port.resets)
+	assert.Equal(t, byte(15), lidar.model)
+	assert.Equal(t, []byte{preCommand, restartDevice, preCommand, deviceInfo, preCommand, healthStatus}, port.written.Bytes())
+}
+
+func TestRebootWithoutBanner(t *testing.T) {
+	lidar := NewLidar(&bootingPort{silent: true})
+	lidar.bootTime = 50 * time.Millisecond
+
+	start := time.Now()
+	require.NoError(t, lidar.Reboot())
+	assert.GreaterOrEqual(t, time.Since(start), lidar.bootTime)
+}
+
+func TestRebootCancelled(t *testing.T) {
+	lidar := NewLidar(&fakePort{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, lidar.RebootContext(ctx), context.Canceled)
+}
+
+func TestRebootWhileScanning(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port)
+	port.queue(scanResponseHeader...)
+	require.NoError(t, lidar.StartScan())
+	defer lidar.StopScan()
+
+	assert.ErrorIs(t, lidar.Reboot(), ErrScanRunning)
+}
@@ -0,0 +1,257 @@
+package ydlidar
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// statusBufferSize is the capacity of the Status channel. Events are dropped when it is full.
+	statusBufferSize = 16
+
+	// defaultWatchdogTimeouts is the number of consecutive empty reads before the link is considered lost.
+	defaultWatchdogTimeouts = 3
+
+	// maxReconnectBackoff caps the exponential backoff between reconnect attempts.
+	maxReconnectBackoff = 30 * time.Second
+
+	// portLockPoll is how often a reconnection retries taking the port held by a command.
+	portLockPoll = 10 * time.Millisecond
+)
+
+// StatusEventType identifies the kind of StatusEvent.
+type StatusEventType int
+
+const (
+	// Disconnected the watchdog detected a lost link.
+	Disconnected StatusEventType = iota
+
+	// Reconnecting a reconnect attempt is about to start.
+	Reconnecting
+
+	// Reconnected the port was re-opened and scanning resumed.
+	Reconnected
+
+	// ReconnectFailed all reconnect attempts failed, scanning has halted.
+	ReconnectFailed
+
+	// LoadShed the host is too busy, output is decimated until it recovers.
+	LoadShed
+
+	// LoadRecovered scheduling latency is back to normal, full output resumed.
+	LoadRecovered
+
+	// Recovering the device reported a health error matching the recovery policy and is
+	// being rebooted.
+	Recovering
+
+	// Recovered the device is healthy again after a reboot, scanning resumed.
+	Recovered
+
+	// RecoveryFailed the device is still unhealthy after every recovery attempt, scanning has halted.
+	RecoveryFailed
+
+	// FrequencyDeviation the measured rotation rate strays from the commanded frequency.
+	FrequencyDeviation
+
+	// FrequencyRecovered the measured rotation rate is back within tolerance.
+	FrequencyRecovered
+
+	// Stabilized the rotation rate settled after the motor spun up, see WaitForStableScan.
+	Stabilized
+
+	// DegradedLink the checksum failures persist despite the slower scan, see
+	// WithLinkDegradation.
+	DegradedLink
+
+	// LinkRecovered the checksum failure rate is back within the limit.
+	LinkRecovered
+)
+
+// String returns the name of the event type.
+func (t StatusEventType) String() string {
+	switch t {
+	case Disconnected:
+		return "Disconnected"
+	case Reconnecting:
+		return "Reconnecting"
+	case Reconnected:
+		return "Reconnected"
+	case ReconnectFailed:
+		return "ReconnectFailed"
+	case LoadShed:
+		return "LoadShed"
+	case LoadRecovered:
+		return "LoadRecovered"
+	case Recovering:
+		return "Recovering"
+	case Recovered:
+		return "Recovered"
+	case RecoveryFailed:
+		return "RecoveryFailed"
+	case FrequencyDeviation:
+		return "FrequencyDeviation"
+	case FrequencyRecovered:
+		return "FrequencyRecovered"
+	case Stabilized:
+		return "Stabilized"
+	case DegradedLink:
+		return "DegradedLink"
+	case LinkRecovered:
+		return "LinkRecovered"
+	}
+	return fmt.Sprintf("StatusEventType(%d)", int(t))
+}
+
+// StatusEvent reports a change of the lidar connection.
+type StatusEvent struct {
+	Type    StatusEventType // What happened.
+	Attempt int             // Reconnect attempt number, 0 if not applicable.
+	Err     error           // Cause of the event, if any.
+	Time    time.Time       // When the event happened.
+}
+
+// reconnectConfig holds the watchdog and reconnect settings, see WithReconnect.
+type reconnectConfig struct {
+	enabled    bool
+	maxRetries int
+	backoff    time.Duration
+	timeouts   int
+}
+
+// watchdog counts consecutive empty reads of the scan loop.
+type watchdog struct {
+	enabled  bool
+	limit    int
+	timeouts int
+}
+
+// newWatchdog returns a watchdog for the scan loop.
+func (lidar *YDLidar) newWatchdog() *watchdog {
+	return &watchdog{enabled: lidar.reconnect.enabled, limit: lidar.reconnect.timeouts}
+}
+
+// expired reports whether the result of a read means the link is lost.
+// A read error is fatal right away, empty reads only once the limit is reached.
+func (w *watchdog) expired(n int, err error) bool {
+	if !w.enabled {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	if n > 0 {
+		w.timeouts = 0
+		return false
+	}
+	w.timeouts++
+	return w.timeouts >= w.limit
+}
+
+// reset clears the empty read count.
+func (w *watchdog) reset() {
+	w.timeouts = 0
+}
+
+// emitStatus sends the event on the Status channel, dropping it if nobody is listening.
+func (lidar *YDLidar) emitStatus(event StatusEvent) {
+	event.Time = time.Now()
+	select {
+	case lidar.Status <- event:
+	default:
+		lidar.metrics.dropped.Add(1)
+	}
+}
+
+// reconnectDevice closes the port and re-opens it with exponential backoff until the scan
+// command succeeds again. Returns false if the scan was stopped meanwhile. When every
+// attempt fails the error is sent on the Packets channel and the loop parks until stopped.
+func (lidar *YDLidar) reconnectDevice(cause error) bool {
+	if cause == nil {
+		cause = fmt.Errorf("%w: no data for %v consecutive reads", ErrTimeout, lidar.reconnect.timeouts)
+	}
+	log.Printf("Link lost: %v", cause)
+	lidar.emitStatus(StatusEvent{Type: Disconnected, Err: cause})
+
+	if err := lidar.SerialPort.Close(); err != nil {
+		log.Printf("Failed to close port: %v", err)
+	}
+
+	backoff := lidar.reconnect.backoff
+	for attempt := 1; lidar.reconnect.maxRetries <= 0 || attempt <= lidar.reconnect.maxRetries; attempt++ {
+		lidar.emitStatus(StatusEvent{Type: Reconnecting, Attempt: attempt})
+
+		select {
+		case <-time.After(backoff):
+		case <-lidar.Stop:
+			return false
+		}
+
+		cause = lidar.reopenPort()
+		if cause == nil {
+			log.Printf("Reconnected after %v attempt(s)", attempt)
+			lidar.metrics.reconnects.Add(1)
+			lidar.emitStatus(StatusEvent{Type: Reconnected, Attempt: attempt})
+			return true
+		}
+		log.Printf("Reconnect attempt %v failed: %v", attempt, cause)
+
+		backoff *= 2
+		if backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+
+	err := fmt.Errorf("reconnect failed after %v attempts: %v", lidar.reconnect.maxRetries, cause)
+	lidar.emitStatus(StatusEvent{Type: ReconnectFailed, Err: err})
+	if lidar.sendErr(err) {
+		<-lidar.Stop
+	}
+	return false
+}
+
+// reopenPort opens the configured port, falling back to enumerating the ports since
+// USB adapters are often renumbered when they come back, purges the stale bytes and
+// restarts the scan.
+func (lidar *YDLidar) reopenPort() error {
+	port, err := lidar.openPort(lidar.portName)
+	if err != nil && lidar.portName != nil {
+		port, err = lidar.openPort(nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !lidar.swapPort(port) {
+		port.Close()
+		return ErrStopping
+	}
+
+	if _, err = lidar.connectLink(); err != nil {
+		port.Close()
+		return err
+	}
+	if err = lidar.sendScanCommand(); err != nil {
+		port.Close()
+		return err
+	}
+	return nil
+}
+
+// swapPort makes port the port of the lidar, holding portMu so the commands and StopScan never
+// see it change under them. They hold portMu while they wait for the scan loop to exit, so
+// rather than blocking, the loop polls for it and gives up with false once the scan is stopped.
+func (lidar *YDLidar) swapPort(port Transport) bool {
+	for !lidar.portMu.TryLock() {
+		select {
+		case <-lidar.Stop:
+			return false
+		case <-time.After(portLockPoll):
+		}
+	}
+	defer lidar.portMu.Unlock()
+	lidar.SerialPort = port
+	lidar.portTimeout = 0
+	return true
+}
@@ -0,0 +1,91 @@
+package ydlidar
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nextStatus waits for the next event on the Status channel.
+func nextStatus(t *testing.T, lidar *YDLidar) StatusEvent {
+	t.Helper()
+	select {
+	case event := <-lidar.Status:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no status event")
+	}
+	return StatusEvent{}
+}
+
+func TestReconnectAfterEOF(t *testing.T) {
+	unplugged := &fakePort{readErr: io.EOF}
+	replugged := &bootingPort{}
+	// Left over from before the link was lost.
+	replugged.queue(0x00, 0xAA, 0x55, 0x12)
+
+	lidar := NewLidar(unplugged, WithReconnect(2, time.Millisecond))
+	lidar.openPort = func(*string) (Transport, error) { return replugged, nil }
+
+	unplugged.queue(scanResponseHeader...)
+	require.NoError(t, lidar.StartScan())
+
+	assert.Equal(t, Disconnected, nextStatus(t, lidar).Type)
+	assert.Equal(t, Reconnecting, nextStatus(t, lidar).Type)
+	event := nextStatus(t, lidar)
+	assert.Equal(t, Reconnected, event.Type)
+	assert.Equal(t, 1, event.Attempt)
+	assert.NoError(t, lidar.StopScan())
+	assert.Equal(t, []byte{preCommand, stopScanning, preCommand, deviceInfo, preCommand, startScanning}, replugged.written.Bytes()[:6])
+}
+
+func TestReconnectGivesUp(t *testing.T) {
+	unplugged := &fakePort{readErr: io.EOF}
+	lidar := NewLidar(unplugged, WithReconnect(2, time.Millisecond))
+	lidar.openPort = func(*string) (Transport, error) { return nil, io.ErrClosedPipe }
+
+	unplugged.queue(scanResponseHeader...)
+	require.NoError(t, lidar.StartScan())
+
+	packet := <-lidar.Packets
+	assert.Error(t, packet.Error)
+
+	types := []StatusEventType{}
+	for len(lidar.Status) > 0 {
+		types = append(types, (<-lidar.Status).Type)
+	}
+	assert.Equal(t, []StatusEventType{Disconnected, Reconnecting, Reconnecting, ReconnectFailed}, types)
+	assert.NoError(t, lidar.StopScan())
+}
+
+func TestSwapPortHeld(t *testing.T) {
+	old, replugged := &fakePort{}, &fakePort{}
+	lidar := NewLidar(old)
+	lidar.Stop = make(chan struct{})
+
+	// A command holds the port, the swap waits for it.
+	lidar.portMu.Lock()
+	swapped := make(chan bool)
+	go func() { swapped <- lidar.swapPort(replugged) }()
+	time.Sleep(3 * portLockPoll)
+	assert.Equal(t, Transport(old), lidar.SerialPort)
+	lidar.portMu.Unlock()
+	assert.True(t, <-swapped)
+	assert.Equal(t, Transport(replugged), lidar.SerialPort)
+
+	// StopScan holds the port while it waits for the scan loop, which gives up the swap.
+	lidar.portMu.Lock()
+	go func() { swapped <- lidar.swapPort(old) }()
+	close(lidar.Stop)
+	select {
+	case ok := <-swapped:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("the swap blocked the stopped scan loop")
+	}
+	lidar.portMu.Unlock()
+	assert.Equal(t, Transport(replugged), lidar.SerialPort)
+}
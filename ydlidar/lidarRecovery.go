@@ -0,0 +1,122 @@
+package ydlidar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// defaultBootTime is how long the device takes to come back from a soft reboot.
+const defaultBootTime = 2 * time.Second
+
+// RecoveryPolicy decides whether a health report calls for a soft reboot of the device.
+type RecoveryPolicy func(HealthStatus) bool
+
+// RebootOnCodes returns a policy rebooting the device when it reports a warning or an error
+// with one of the codes, or with any code if none are given.
+func RebootOnCodes(codes ...uint16) RecoveryPolicy {
+	return func(status HealthStatus) bool {
+		if status.Err != nil || status.Severity == SeverityOK {
+			return false
+		}
+		if len(codes) == 0 {
+			return true
+		}
+		for _, code := range codes {
+			if status.Code == code {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// recoveryConfig holds the health recovery settings, see WithHealthRecovery.
+type recoveryConfig struct {
+	policy      RecoveryPolicy
+	maxAttempts int
+}
+
+// WithHealthRecovery lets the health monitor recover the device: when a report matches the
+// policy the scan is stopped, the device soft rebooted and its health queried again once it
+// booted, up to maxAttempts times, and the scan resumed once healthy. Every attempt is
+// reported on the Status channel. Only applies with WithHealthMonitor.
+func WithHealthRecovery(policy RecoveryPolicy, maxAttempts int) Option {
+	return func(lidar *YDLidar) {
+		lidar.recovery.policy = policy
+		lidar.recovery.maxAttempts = maxAttempts
+		if maxAttempts < 1 {
+			lidar.recovery.maxAttempts = 1
+		}
+	}
+}
+
+// recoverHealth reboots the device until its health no longer matches the recovery policy.
+// It returns the last health report. The scan is resumed if it was running and the recovery
+// succeeded, it stays stopped otherwise.
+func (lidar *YDLidar) recoverHealth(status HealthStatus) HealthStatus {
+	scanning := lidar.IsScanning()
+	if scanning {
+		if err := lidar.StopScan(); err != nil {
+			return HealthStatus{Err: fmt.Errorf("failed to stop scan for recovery: %w", err), Time: time.Now()}
+		}
+	}
+
+	// Close abandons the recovery.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-lidar.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for attempt := 1; attempt <= lidar.recovery.maxAttempts; attempt++ {
+		cause := &HealthError{Code: status.Code, Severity: status.Severity}
+		log.Printf("Health recovery attempt %v: %v", attempt, cause)
+		lidar.emitStatus(StatusEvent{Type: Recovering, Attempt: attempt, Err: cause})
+
+		if err := lidar.RebootContext(ctx); errors.Is(err, context.Canceled) {
+			return HealthStatus{Err: fmt.Errorf("closed during health recovery"), Time: time.Now()}
+		} else if err != nil {
+			status = HealthStatus{Err: err}
+			continue
+		}
+
+		var data []byte
+		err := lidar.command(func() (err error) {
+			data, err = lidar.readHealth()
+			return err
+		})
+		if err != nil {
+			status = HealthStatus{Err: err}
+			continue
+		}
+		status = newHealthStatus(data)
+		if lidar.recovery.policy(status) {
+			continue
+		}
+
+		if scanning {
+			err = lidar.StartScan()
+			status.Err = err
+			status.MotorRunning = err == nil
+		}
+		log.Printf("Health recovered after %v attempt(s)", attempt)
+		lidar.emitStatus(StatusEvent{Type: Recovered, Attempt: attempt, Err: err})
+		status.Time = time.Now()
+		return status
+	}
+
+	err := status.Err
+	if err == nil {
+		err = &HealthError{Code: status.Code, Severity: status.Severity}
+	}
+	lidar.emitStatus(StatusEvent{Type: RecoveryFailed, Attempt: lidar.recovery.maxAttempts, Err: err})
+	status.Time = time.Now()
+	return status
+}
@@ -0,0 +1,53 @@
+package ydlidar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// healthResponse is the device answer to the health command.
+func healthResponse(severity HealthSeverity, code uint16) []byte {
+	return []byte{0xA5, 0x5A, 0x03, 0x00, 0x00, 0x00, HealthTypeCode, byte(severity), byte(code), byte(code >> 8)}
+}
+
+func TestRebootOnCodes(t *testing.T) {
+	assert.True(t, RebootOnCodes()(HealthStatus{Severity: SeverityError, Code: 9}))
+	assert.False(t, RebootOnCodes()(HealthStatus{Severity: SeverityOK}))
+	assert.True(t, RebootOnCodes(3, 9)(HealthStatus{Severity: SeverityWarning, Code: 9}))
+	assert.False(t, RebootOnCodes(3)(HealthStatus{Severity: SeverityError, Code: 9}))
+}
+
+func TestRecoverHealth(t *testing.T) {
+	// The first reboot doesn't help, the second does.
+	port := &bootingPort{silent: true, health: [][]byte{healthResponse(SeverityError, 4)}}
+	lidar := NewLidar(port, WithHealthRecovery(RebootOnCodes(), 3))
+	lidar.bootTime = 0
+
+	status := lidar.recoverHealth(HealthStatus{Severity: SeverityError, Code: 4})
+	require.NoError(t, status.Err)
+	assert.Equal(t, SeverityOK, status.Severity)
+	reboot := []byte{preCommand, restartDevice, preCommand, deviceInfo, preCommand, healthStatus}
+	assert.Equal(t, append(reboot, reboot...), port.written.Bytes())
+
+	var events []StatusEventType
+	for len(lidar.Status) > 0 {
+		events = append(events, (<-lidar.Status).Type)
+	}
+	assert.Equal(t, []StatusEventType{Recovering, Recovering, Recovered}, events)
+}
+
+func TestRecoverHealthFails(t *testing.T) {
+	port := &bootingPort{silent: true, health: [][]byte{healthResponse(SeverityError, 4)}}
+	lidar := NewLidar(port, WithHealthRecovery(RebootOnCodes(), 1))
+	lidar.bootTime = 0
+
+	status := lidar.recoverHealth(HealthStatus{Severity: SeverityError, Code: 4})
+	assert.Equal(t, SeverityError, status.Severity)
+
+	<-lidar.Status
+	failed := <-lidar.Status
+	assert.Equal(t, RecoveryFailed, failed.Type)
+	assert.Equal(t, &HealthError{Code: 4, Severity: SeverityError}, failed.Err)
+}
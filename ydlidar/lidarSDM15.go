@@ -0,0 +1,403 @@
+package ydlidar
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// ErrSelfCheck is returned by SDM15.SelfCheck when the module reports a fault.
+var ErrSelfCheck = errors.New("ydlidar: self check failed")
+
+// sdm15SerialMode is the line setting of the SDM15: 460800 baud, 8N1.
+var sdm15SerialMode = serial.Mode{
+	BaudRate: 460800,
+	DataBits: 8,
+	Parity:   serial.NoParity,
+	StopBits: serial.OneStopBit,
+}
+
+// SDM15 commands, the answers carry the type of their command.
+const (
+	sdm15StartRanging = 0x60
+	sdm15StopRanging  = 0x61
+	sdm15DeviceInfo   = 0x62
+	sdm15SelfCheck    = 0x63
+	sdm15SetFrequency = 0x64
+	sdm15SetFilter    = 0x65
+)
+
+const (
+	// sdm15Header starts every frame in both directions, 0xAA 0x55 on the wire.
+	sdm15Header = 0x55AA
+
+	// sdm15Overhead is the size of a frame without its data: the header, the type, the
+	// length and the checksum.
+	sdm15Overhead = 5
+
+	// sdm15ModelName is the model name reported in the device info.
+	sdm15ModelName = "SDM15"
+)
+
+// sdm15Frame is a command or answer: the header, the type, the length of the data, the data
+// and a checksum, the low byte of the sum of the bytes before it.
+type sdm15Frame struct {
+	typeCode byte
+	data     []byte
+}
+
+// encode returns the frame as sent on the link.
+func (f sdm15Frame) encode() []byte {
+	b := append([]byte{sdm15Header & 0xFF, sdm15Header >> 8, f.typeCode, byte(len(f.data))}, f.data...)
+	var sum byte
+	for _, c := range b {
+		sum += c
+	}
+	return append(b, sum)
+}
+
+// Measurement is a distance measured by a single point ranging module.
+type Measurement struct {
+	Dist         float64   // Distance in Units, 0 without a return.
+	Intensity    int       // Strength of the return, 0 to 255.
+	Interference int       // Ambient light interference, 0 to 255, the higher the noisier.
+	Units        Unit      // Unit of the distance.
+	Received     time.Time // Arrival of the measurement.
+	Error        error     // Error if the measurement couldn't be read, the other fields are then unset.
+}
+
+// SDM15 is the YDLidar SDM15, a single point time of flight ranging module, eg. an altimeter
+// or a bump sensor. It shares the link with the lidars, a Transport, and takes the same
+// options, of which WithUnits, WithTimeouts and WithOpenRetry apply: the info timeout bounds
+// the answers to the commands and the header timeout the silence while ranging.
+type SDM15 struct {
+	SerialPort   Transport
+	Measurements chan Measurement // Measurements while ranging, see StartRanging.
+
+	units      Unit
+	timeouts   Timeouts
+	buf        []byte // Bytes read and not framed yet.
+	readBuf    []byte // Read buffer, reused.
+	timeoutSet bool   // The read timeout of the transport is set.
+
+	mu   sync.Mutex    // Guards stop and done.
+	stop chan struct{} // Closed to stop ranging, nil when not ranging.
+	done chan struct{} // Closed when the ranging loop exited.
+}
+
+// NewSDM15 returns a module on the transport. The options of the lidar not listed on SDM15
+// are ignored.
+func NewSDM15(port Transport, opts ...Option) *SDM15 {
+	settings := YDLidar{timeouts: defaultTimeouts}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	return &SDM15{
+		SerialPort:   port,
+		Measurements: make(chan Measurement, 16),
+		units:        settings.units,
+		timeouts:     settings.timeouts,
+		readBuf:      make([]byte, 64),
+	}
+}
+
+// OpenSDM15 opens the serial port of the module, nil auto-detects it, stops a ranging left
+// running and checks the device info.
+func OpenSDM15(port *string, opts ...Option) (*SDM15, error) {
+	var settings YDLidar
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	serialPort, err := settings.openRetry.open(func() (serial.Port, error) {
+		name := port
+		if name == nil {
+			ports, err := serial.GetPortsList()
+			if err != nil {
+				return nil, err
+			}
+			selected, err := selectPort(ports, candidatePort)
+			if err != nil {
+				return nil, err
+			}
+			name = &selected
+		}
+		return openSerialPortMode(*name, &sdm15SerialMode)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	module := NewSDM15(serialPort, opts...)
+	if err = module.halt(); err == nil {
+		var info *DeviceInfo
+		if info, err = module.DeviceInfo(); err == nil {
+			log.Print(info)
+			return module, nil
+		}
+	}
+	serialPort.Close()
+	return nil, err
+}
+
+// DeviceInfo returns the model, the versions and the serial number of the module.
+func (s *SDM15) DeviceInfo() (*DeviceInfo, error) {
+	data, err := s.command(sdm15DeviceInfo, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 20 {
+		return nil, fmt.Errorf("%w: device info expected 20 bytes got %v", ErrShortRead, len(data))
+	}
+	info := newDeviceInfo(data)
+	info.ModelName = sdm15ModelName
+	return info, nil
+}
+
+// SelfCheck runs the self check of the module, a fault returns ErrSelfCheck with its code.
+func (s *SDM15) SelfCheck() error {
+	data, err := s.command(sdm15SelfCheck, nil)
+	if err != nil {
+		return err
+	}
+	if len(data) < 2 {
+		return fmt.Errorf("%w: self check expected 2 bytes got %v", ErrShortRead, len(data))
+	}
+	if data[0] != 0 {
+		return fmt.Errorf("%w: fault code %v", ErrSelfCheck, data[1])
+	}
+	return nil
+}
+
+// SetOutputFrequency sets the number of measurements per second while ranging, 1 to 1800.
+func (s *SDM15) SetOutputFrequency(hz int) error {
+	if hz < 1 || hz > 1800 {
+		return fmt.Errorf("ydlidar: output frequency %vHz out of 1 to 1800Hz", hz)
+	}
+	_, err := s.command(sdm15SetFrequency, []byte{byte(hz), byte(hz >> 8)})
+	return err
+}
+
+// SetFilter switches the filter of the module smoothing the measurements on or off.
+func (s *SDM15) SetFilter(on bool) error {
+	data := []byte{0}
+	if on {
+		data[0] = 1
+	}
+	_, err := s.command(sdm15SetFilter, data)
+	return err
+}
+
+// Measure returns a single measurement: ranging is started for its first one and stopped.
+func (s *SDM15) Measure() (Measurement, error) {
+	if s.ranging() {
+		return Measurement{}, ErrScanRunning
+	}
+	if err := s.send(sdm15StartRanging, nil); err != nil {
+		return Measurement{}, err
+	}
+	f, err := s.await(sdm15StartRanging, s.timeouts.Header)
+	if err != nil {
+		s.halt()
+		return Measurement{}, err
+	}
+	m := s.measurement(f.data, time.Now())
+	return m, s.halt()
+}
+
+// StartRanging starts the continuous ranging, the measurements are sent on Measurements by a
+// new goroutine. The commands return ErrScanRunning until StopRanging.
+func (s *SDM15) StartRanging() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return ErrScanRunning
+	}
+	if err := s.send(sdm15StartRanging, nil); err != nil {
+		return fmt.Errorf("failed to start ranging: %w", err)
+	}
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	go s.rangingLoop(s.stop, s.done)
+	return nil
+}
+
+// StopRanging stops the continuous ranging. Safe to call when not ranging.
+func (s *SDM15) StopRanging() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop == nil {
+		return nil
+	}
+	close(s.stop)
+	<-s.done
+	s.stop, s.done = nil, nil
+	return s.halt()
+}
+
+// Close stops ranging and closes the port.
+func (s *SDM15) Close() error {
+	stopErr := s.StopRanging()
+	if err := s.SerialPort.Close(); err != nil {
+		return err
+	}
+	return stopErr
+}
+
+// ranging reports whether the ranging loop runs.
+func (s *SDM15) ranging() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stop != nil
+}
+
+// command sends a command and returns the data of its answer. ErrScanRunning is returned
+// while ranging.
+func (s *SDM15) command(typeCode byte, data []byte) ([]byte, error) {
+	if s.ranging() {
+		return nil, ErrScanRunning
+	}
+	if err := s.send(typeCode, data); err != nil {
+		return nil, err
+	}
+	f, err := s.await(typeCode, s.timeouts.Info)
+	if err != nil {
+		return nil, err
+	}
+	return f.data, nil
+}
+
+// halt stops ranging and waits for the answer, dropping the measurements sent meanwhile.
+func (s *SDM15) halt() error {
+	if err := s.send(sdm15StopRanging, nil); err != nil {
+		return err
+	}
+	_, err := s.await(sdm15StopRanging, s.timeouts.Info)
+	s.buf = s.buf[:0]
+	return err
+}
+
+// send writes a command.
+func (s *SDM15) send(typeCode byte, data []byte) error {
+	_, err := s.SerialPort.Write(sdm15Frame{typeCode: typeCode, data: data}.encode())
+	return err
+}
+
+// await returns the next frame of the type within the timeout, skipping the others and the
+// corrupted ones.
+func (s *SDM15) await(typeCode byte, timeout time.Duration) (sdm15Frame, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := s.next(time.Until(deadline))
+		if err != nil && !IsTransient(err) {
+			return f, err
+		}
+		if err == nil && f.typeCode == typeCode {
+			return f, nil
+		}
+	}
+}
+
+// next returns the next frame within the timeout. A frame failing its checksum returns
+// ErrChecksum and is skipped.
+func (s *SDM15) next(timeout time.Duration) (sdm15Frame, error) {
+	if !s.timeoutSet {
+		// Reads are polled at the sample timeout so the ranging loop notices a stop.
+		if err := s.SerialPort.SetReadTimeout(s.timeouts.Sample); err != nil {
+			return sdm15Frame{}, err
+		}
+		s.timeoutSet = true
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		f, ok, err := s.parse()
+		if ok || err != nil {
+			return f, err
+		}
+		if !time.Now().Before(deadline) {
+			return sdm15Frame{}, fmt.Errorf("%w: no answer within %v", ErrTimeout, timeout)
+		}
+		n, err := s.SerialPort.Read(s.readBuf)
+		if err != nil {
+			return sdm15Frame{}, err
+		}
+		s.buf = append(s.buf, s.readBuf[:n]...)
+	}
+}
+
+// parse takes a frame off the buffer, ok is false if it holds none in full yet.
+func (s *SDM15) parse() (f sdm15Frame, ok bool, err error) {
+	start := 0
+	for start+1 < len(s.buf) && !(s.buf[start] == sdm15Header&0xFF && s.buf[start+1] == sdm15Header>>8) {
+		start++
+	}
+	s.buf = s.buf[start:]
+	if len(s.buf) < sdm15Overhead-1 {
+		return sdm15Frame{}, false, nil
+	}
+	size := int(s.buf[3])
+	if len(s.buf) < sdm15Overhead+size {
+		return sdm15Frame{}, false, nil
+	}
+	f = sdm15Frame{typeCode: s.buf[2], data: append([]byte(nil), s.buf[4:4+size]...)}
+	if want, got := f.encode()[sdm15Overhead-1+size], s.buf[sdm15Overhead-1+size]; want != got {
+		s.buf = s.buf[1:]
+		return sdm15Frame{}, false, fmt.Errorf("%w: expected %02X got %02X", ErrChecksum, want, got)
+	}
+	s.buf = s.buf[sdm15Overhead+size:]
+	return f, true, nil
+}
+
+// measurement decodes the data of a measurement: the distance in mm as a little endian word,
+// the intensity and the interference.
+func (s *SDM15) measurement(data []byte, received time.Time) Measurement {
+	if len(data) < 4 {
+		return Measurement{Error: fmt.Errorf("%w: measurement expected 4 bytes got %v", ErrShortRead, len(data))}
+	}
+	return Measurement{
+		Dist:         s.units.FromMillimeters(float64(uint16(data[0]) | uint16(data[1])<<8)),
+		Intensity:    int(data[2]),
+		Interference: int(data[3]),
+		Units:        s.units,
+		Received:     received,
+	}
+}
+
+// rangingLoop reads the measurements until stop is closed. A module silent for the header
+// timeout is reported with ErrTimeout.
+func (s *SDM15) rangingLoop(stop, done chan struct{}) {
+	defer close(done)
+	last := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		// Short waits keep the loop responsive to stop.
+		f, err := s.next(s.timeouts.Sample)
+		switch {
+		case errors.Is(err, ErrTimeout):
+			if time.Since(last) < s.timeouts.Header {
+				continue
+			}
+			err = fmt.Errorf("%w: no measurement within %v", ErrTimeout, s.timeouts.Header)
+		case err == nil && f.typeCode != sdm15StartRanging:
+			continue
+		}
+		last = time.Now()
+		m := Measurement{Error: err}
+		if err != nil {
+			log.Printf("SDM15 measurement: %v", err)
+		} else {
+			m = s.measurement(f.data, last)
+		}
+		select {
+		case s.Measurements <- m:
+		case <-stop:
+			return
+		}
+	}
+}
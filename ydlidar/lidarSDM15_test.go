@@ -0,0 +1,143 @@
+package ydlidar
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sdm15Port plays an SDM15 measuring 1234mm: it answers the commands and sends a measurement
+// per read while ranging.
+type sdm15Port struct {
+	fault byte // Fault code of the self check, 0 if none.
+
+	mu       sync.Mutex
+	output   bytes.Buffer
+	commands []byte
+	ranging  bool
+}
+
+func (p *sdm15Port) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.output.Len() == 0 && p.ranging {
+		p.output.Write(sdm15Frame{typeCode: sdm15StartRanging, data: []byte{0xD2, 0x04, 80, 3}}.encode())
+	}
+	if p.output.Len() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	return p.output.Read(b)
+}
+
+func (p *sdm15Port) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f, ok, err := (&SDM15{buf: append([]byte(nil), b...)}).parse()
+	if !ok || err != nil {
+		return len(b), nil
+	}
+	p.commands = append(p.commands, f.typeCode)
+	answer := sdm15Frame{typeCode: f.typeCode, data: f.data}
+	switch f.typeCode {
+	case sdm15StartRanging:
+		p.ranging = true
+		return len(b), nil
+	case sdm15StopRanging:
+		p.ranging = false
+	case sdm15DeviceInfo:
+		answer.data = append([]byte{0, 3, 1, 2}, make([]byte, 16)...)
+	case sdm15SelfCheck:
+		answer.data = []byte{0, p.fault}
+		if p.fault != 0 {
+			answer.data[0] = 1
+		}
+	}
+	p.output.Write(answer.encode())
+	return len(b), nil
+}
+
+func (p *sdm15Port) SetReadTimeout(time.Duration) error { return nil }
+
+func (p *sdm15Port) Close() error { return nil }
+
+func TestSDM15FrameEncoding(t *testing.T) {
+	encoded := sdm15Frame{typeCode: sdm15SetFrequency, data: []byte{100, 0}}.encode()
+	assert.Equal(t, []byte{0xAA, 0x55, 0x64, 0x02, 0x64, 0x00, 0xC9}, encoded)
+
+	// Noise is skipped, a corrupted frame reported and skipped.
+	corrupted := append([]byte(nil), encoded...)
+	corrupted[4]++
+	s := &SDM15{buf: append(append([]byte{0x13, 0xAA}, corrupted...), encoded...)}
+	_, _, err := s.parse()
+	assert.ErrorIs(t, err, ErrChecksum)
+	f, ok, err := s.parse()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, sdm15Frame{typeCode: sdm15SetFrequency, data: []byte{100, 0}}, f)
+}
+
+func TestSDM15Commands(t *testing.T) {
+	port := &sdm15Port{}
+	module := NewSDM15(port)
+
+	info, err := module.DeviceInfo()
+	require.NoError(t, err)
+	assert.Equal(t, "SDM15", info.ModelName)
+	assert.Equal(t, byte(1), info.FirmwareMajor)
+	assert.Equal(t, byte(3), info.FirmwareMinor)
+
+	require.NoError(t, module.SelfCheck())
+	port.fault = 7
+	assert.ErrorIs(t, module.SelfCheck(), ErrSelfCheck)
+
+	require.NoError(t, module.SetOutputFrequency(100))
+	assert.Error(t, module.SetOutputFrequency(0))
+	require.NoError(t, module.SetFilter(true))
+}
+
+func TestSDM15Measure(t *testing.T) {
+	port := &sdm15Port{}
+	module := NewSDM15(port, WithUnits(Meters))
+
+	m, err := module.Measure()
+	require.NoError(t, err)
+	assert.Equal(t, 1.234, m.Dist)
+	assert.Equal(t, Meters, m.Units)
+	assert.Equal(t, 80, m.Intensity)
+	assert.Equal(t, 3, m.Interference)
+	assert.Equal(t, []byte{sdm15StartRanging, sdm15StopRanging}, port.commands)
+
+	// The measurements sent before the stop took effect don't leak into the next answer.
+	_, err = module.DeviceInfo()
+	require.NoError(t, err)
+}
+
+func TestSDM15Ranging(t *testing.T) {
+	port := &sdm15Port{}
+	module := NewSDM15(port)
+
+	require.NoError(t, module.StartRanging())
+	assert.ErrorIs(t, module.StartRanging(), ErrScanRunning)
+	for i := 0; i < 5; i++ {
+		m := <-module.Measurements
+		require.NoError(t, m.Error)
+		assert.Equal(t, 1234.0, m.Dist)
+	}
+	_, err := module.DeviceInfo()
+	assert.ErrorIs(t, err, ErrScanRunning)
+
+	require.NoError(t, module.StopRanging())
+	require.NoError(t, module.StopRanging(), "idempotent")
+	require.NoError(t, module.SelfCheck())
+	require.NoError(t, module.Close())
+}
+
+func TestSDM15Silent(t *testing.T) {
+	module := NewSDM15(&fakePort{}, WithTimeouts(Timeouts{Info: 20 * time.Millisecond}))
+	_, err := module.DeviceInfo()
+	assert.ErrorIs(t, err, ErrTimeout)
+}
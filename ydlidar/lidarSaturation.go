@@ -0,0 +1,62 @@
+package ydlidar
+
+// SaturationPolicy decides what happens to the samples beyond the rated range of the model,
+// noise rather than returns.
+type SaturationPolicy int
+
+const (
+	// FlagSaturated keeps the distance and flags the sample Saturated. This is the default.
+	FlagSaturated SaturationPolicy = iota
+
+	// ClampSaturated clamps the distance to the rated range and flags the sample Saturated.
+	// It can't be combined with InvalidMaxRange.
+	ClampSaturated
+
+	// DropSaturated turns the sample into a dropout, flagged Saturated.
+	DropSaturated
+
+	// IgnoreSaturation leaves the samples as the device sent them.
+	IgnoreSaturation
+)
+
+// WithSaturation sets what happens to the samples beyond the maximum range of the model, see
+// Capabilities. The model is known once DeviceInfo is read, InitAndConnectToDevice does, the
+// samples are left as sent until then.
+func WithSaturation(policy SaturationPolicy) Option {
+	return func(lidar *YDLidar) {
+		lidar.saturation = policy
+	}
+}
+
+// saturationRange returns the maximum range of the model in the unit of the lidar, 0 if the
+// model is unknown or the saturation ignored.
+func (lidar *YDLidar) saturationRange() float64 {
+	spec, ok := models[lidar.model]
+	if !ok || lidar.saturation == IgnoreSaturation {
+		return 0
+	}
+	return lidar.units.FromMillimeters(spec.maxRange)
+}
+
+// markSaturated applies the saturation policy to the samples of the packet beyond maxRange,
+// nothing is done if it is 0.
+func (lidar *YDLidar) markSaturated(packet *Packet, maxRange float64) {
+	if maxRange <= 0 {
+		return
+	}
+	for i, dist := range packet.Distances {
+		if dist <= maxRange {
+			continue
+		}
+		if packet.Saturated == nil {
+			packet.Saturated = make([]bool, len(packet.Distances))
+		}
+		packet.Saturated[i] = true
+		switch lidar.saturation {
+		case ClampSaturated:
+			packet.Distances[i] = maxRange
+		case DropSaturated:
+			packet.Distances[i] = 0
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package ydlidar
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// saturatedPacket returns a packet of 4 samples, the last two beyond the 12m of the G2.
+func saturatedPacket() []byte {
+	samples := make([][3]byte, 4)
+	for i, dist := range []int{1000, 11999, 13000, 16000} {
+		samples[i] = [3]byte{100, byte(dist & 0x3F << 2), byte(dist >> 6)}
+	}
+	return encodeScanPacket(0x00, 0x0001, 0x0001|30*64<<1, samples)
+}
+
+func TestMarkSaturated(t *testing.T) {
+	for policy, want := range map[SaturationPolicy][]float64{
+		FlagSaturated:  {1000, 13000, 0},
+		ClampSaturated: {1000, 12000, 0},
+		DropSaturated:  {1000, 0, 0},
+	} {
+		lidar := NewLidar(&fakePort{}, WithSaturation(policy))
+		packet := Packet{Distances: []float64{1000, 13000, 0}}
+		lidar.markSaturated(&packet, 12000)
+		assert.Equal(t, want, packet.Distances, policy)
+		assert.Equal(t, []bool{false, true, false}, packet.Saturated, policy)
+	}
+
+	packet := Packet{Distances: []float64{1000, 11000}}
+	NewLidar(&fakePort{}).markSaturated(&packet, 12000)
+	assert.Nil(t, packet.Saturated, "nil if none were")
+}
+
+func TestSaturationRange(t *testing.T) {
+	lidar := NewLidar(&fakePort{}, WithUnits(Meters))
+	assert.Zero(t, lidar.saturationRange(), "unknown model")
+	lidar.model = 15
+	assert.Equal(t, 12.0, lidar.saturationRange())
+
+	lidar = NewLidar(&fakePort{}, WithSaturation(IgnoreSaturation))
+	lidar.model = 15
+	assert.Zero(t, lidar.saturationRange())
+}
+
+func TestSaturatedScan(t *testing.T) {
+	var b bytes.Buffer
+	b.Write(scanResponseHeader)
+	b.Write(encodeScanPacket(0x01, 0x0001, 0x0001, [][3]byte{{0, 0, 0}}))
+	b.Write(saturatedPacket())
+	b.Write(encodeScanPacket(0x01, 0x0001, 0x0001, [][3]byte{{0, 0, 0}}))
+	port := &fakePort{}
+	port.queue(b.Bytes()...)
+
+	lidar := NewLidar(port, WithScans(1), WithSaturation(ClampSaturated))
+	lidar.model = 15
+	require.NoError(t, lidar.StartScan())
+	packet := <-lidar.Packets
+	require.NoError(t, packet.Error)
+	assert.Equal(t, []float64{1000, 11999, 12000, 12000}, packet.Distances)
+	assert.Equal(t, []bool{false, false, true, true}, packet.Saturated)
+
+	scan := <-lidar.Scans
+	require.NoError(t, lidar.StopScan())
+	require.Len(t, scan.Points, 4)
+	assert.False(t, scan.Points[1].Saturated)
+	assert.True(t, scan.Points[2].Saturated)
+	assert.True(t, scan.Points[3].Saturated)
+}
+
+func TestKeepSaturated(t *testing.T) {
+	packet := Packet{Distances: []float64{1, 2, 3}, Saturated: []bool{false, true, true}}
+	packet.Keep(func(i int) bool { return i != 1 })
+	assert.Equal(t, []bool{false, true}, packet.Saturated)
+}
@@ -0,0 +1,71 @@
+package ydlidar
+
+import (
+	"math"
+	"sort"
+)
+
+// ScanOrder is the order of the points of the assembled revolutions.
+type ScanOrder int
+
+const (
+	// FirstSeenOrder keeps the points in the order they were received. This is the default.
+	FirstSeenOrder ScanOrder = iota
+
+	// AngleSortedOrder sorts the points by increasing angle, from 0° to 360°.
+	AngleSortedOrder
+)
+
+// seamWrap is the drop between the angles of consecutive points taken for the wrap from 360°
+// to 0° rather than jitter.
+const seamWrap = 180
+
+// WithScanOrder sets the order of the points of the assembled revolutions. Either way the
+// angles are within [0°, 360°) and the points past the end of the revolution, overlapping
+// its start, are dropped.
+func WithScanOrder(order ScanOrder) Option {
+	return func(lidar *YDLidar) {
+		lidar.scanOrder = order
+	}
+}
+
+// normalizeAngles wraps the angles of the revolution within [0°, 360°) and drops the points
+// seen a second time at the seam: once unwrapped, those a full turn or more past the first
+// point. The points are then sorted by angle for AngleSortedOrder, the points at the same
+// angle keeping the order they were received in.
+func normalizeAngles(points []PointCloudData, order ScanOrder) []PointCloudData {
+	if len(points) == 0 {
+		return points
+	}
+	normalized := points[:0]
+	turns := 0.0
+	first, previous := points[0].Angle, points[0].Angle
+	for _, point := range points {
+		if previous-point.Angle > seamWrap {
+			turns += 360
+		}
+		previous = point.Angle
+		if point.Angle+turns >= first+360 {
+			continue
+		}
+		point.Angle = wrapAngle(point.Angle)
+		normalized = append(normalized, point)
+	}
+	if order == AngleSortedOrder {
+		sort.SliceStable(normalized, func(i, j int) bool { return normalized[i].Angle < normalized[j].Angle })
+	}
+	return normalized
+}
+
+// wrapAngle returns the angle in degrees within [0, 360).
+func wrapAngle(angle float64) float64 {
+	angle = math.Mod(angle, 360)
+	if angle < 0 {
+		angle += 360
+	}
+	if angle >= 360 {
+		// A tiny negative angle rounds up to 360.
+		angle = 0
+	}
+	return angle
+}
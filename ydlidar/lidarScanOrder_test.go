@@ -0,0 +1,75 @@
+package ydlidar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar/sim"
+)
+
+func dists(points []PointCloudData) []float64 {
+	var out []float64
+	for _, point := range points {
+		out = append(out, point.Dist)
+	}
+	return out
+}
+
+func pointsAt(angles ...float64) []PointCloudData {
+	points := make([]PointCloudData, len(angles))
+	for i, angle := range angles {
+		points[i] = PointCloudData{Angle: angle, Dist: float64(i + 1)}
+	}
+	return points
+}
+
+func TestNormalizeAngles(t *testing.T) {
+	// The revolution starts late, wraps at 360° and overlaps its start.
+	points := pointsAt(10, 180, 359, 361, 0.5, 9.5, 10, 12)
+	assert.Equal(t, []float64{10, 180, 359, 1, 0.5, 9.5}, angles(normalizeAngles(points, FirstSeenOrder)))
+
+	points = pointsAt(10, 180, 359, 361, 0.5, 9.5, 10, 12)
+	sorted := normalizeAngles(points, AngleSortedOrder)
+	assert.Equal(t, []float64{0.5, 1, 9.5, 10, 180, 359}, angles(sorted))
+	assert.Equal(t, []float64{5, 4, 6, 1, 2, 3}, dists(sorted))
+
+	// Negative angles from the correction wrap, jitter isn't taken for the seam.
+	assert.Equal(t, []float64{359, 0, 0.8, 0.7, 2}, angles(normalizeAngles(pointsAt(-1, 0, 0.8, 0.7, 2), FirstSeenOrder)))
+	assert.Empty(t, normalizeAngles(nil, AngleSortedOrder))
+}
+
+func TestNormalizeAnglesStable(t *testing.T) {
+	sorted := normalizeAngles(pointsAt(20, 10, 20, 10), AngleSortedOrder)
+	assert.Equal(t, []float64{10, 10, 20, 20}, angles(sorted))
+	assert.Equal(t, []float64{2, 4, 1, 3}, dists(sorted))
+}
+
+func TestWrapAngle(t *testing.T) {
+	assert.Equal(t, 0.0, wrapAngle(360))
+	assert.Equal(t, 350.0, wrapAngle(-10))
+	assert.Equal(t, 10.0, wrapAngle(730))
+	assert.Equal(t, 0.0, wrapAngle(-1e-15))
+}
+
+func TestScanOrder(t *testing.T) {
+	lidar := NewLidar(sim.NewPort(&sim.Device{Environment: sim.Room(4000, 3000)}), WithScans(4), WithScanOrder(AngleSortedOrder))
+	require.NoError(t, lidar.StartScan())
+	defer lidar.Close()
+	go func() {
+		for range lidar.Packets {
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		scan := <-lidar.Scans
+		require.NotEmpty(t, scan.Points)
+		for j, point := range scan.Points {
+			assert.GreaterOrEqual(t, point.Angle, 0.0)
+			assert.Less(t, point.Angle, 360.0)
+			if j > 0 {
+				assert.GreaterOrEqual(t, point.Angle, scan.Points[j-1].Angle)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package ydlidar
+
+import "ydlidarg2/ydlidar/geom"
+
+// Sector summarizes the points of an angular slice of a revolution.
+type Sector struct {
+	From, To float64 // Bounds in degrees counter clockwise from the front, From included.
+	Min      float64 // Distance of the nearest point, in the unit of the scan. 0 without points.
+	Mean     float64 // Mean distance of the points. 0 without points.
+	Points   int     // Points with a return in the sector.
+}
+
+// Empty reports whether no point was measured in the sector.
+func (s Sector) Empty() bool {
+	return s.Points == 0
+}
+
+// Sectors splits the revolution in n equal sectors starting at the front, 0°, counter
+// clockwise, and summarizes the distances in each. The samples without a return are
+// ignored. For example with two sectors the first one is the left half:
+//
+//	if left := scan.Sectors(2)[0]; !left.Empty() && left.Min < 300 {
+//		turnRight()
+//	}
+func (s Scan) Sectors(n int) []Sector {
+	if n <= 0 {
+		return nil
+	}
+	width := 360 / float64(n)
+	sectors := make([]Sector, n)
+	sums := make([]float64, n)
+	for i := range sectors {
+		sectors[i].From = float64(i) * width
+		sectors[i].To = float64(i+1) * width
+	}
+	for _, point := range s.Points {
+		if !s.IsReturn(point.Dist) {
+			continue
+		}
+		i := int(geom.NormalizeAngle(point.Angle) / width)
+		if i >= n { // Rounding just below 360°.
+			i = n - 1
+		}
+		sector := &sectors[i]
+		if sector.Points == 0 || point.Dist < sector.Min {
+			sector.Min = point.Dist
+		}
+		sector.Points++
+		sums[i] += point.Dist
+	}
+	for i := range sectors {
+		if sectors[i].Points > 0 {
+			sectors[i].Mean = sums[i] / float64(sectors[i].Points)
+		}
+	}
+	return sectors
+}
@@ -0,0 +1,31 @@
+package ydlidar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSectors(t *testing.T) {
+	scan := Scan{Points: []PointCloudData{
+		{Angle: 10, Dist: 400},
+		{Angle: 80, Dist: 200},
+		{Angle: 100},
+		{Angle: 200, Dist: 1000},
+		{Angle: -10, Dist: 3000}, // 350°
+		{Angle: 359.9999999999999, Dist: 1000},
+	}}
+
+	sectors := scan.Sectors(4)
+	assert.Equal(t, []Sector{
+		{From: 0, To: 90, Min: 200, Mean: 300, Points: 2},
+		{From: 90, To: 180},
+		{From: 180, To: 270, Min: 1000, Mean: 1000, Points: 1},
+		{From: 270, To: 360, Min: 1000, Mean: 2000, Points: 2},
+	}, sectors)
+	assert.True(t, sectors[1].Empty(), "the dropout is ignored")
+
+	halves := scan.Sectors(2)
+	assert.Equal(t, 200.0, halves[0].Min, "the left half")
+	assert.Nil(t, scan.Sectors(0))
+}
@@ -0,0 +1,216 @@
+package ydlidar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrSelfTestFailed is returned by SelfTest when a check failed, the report tells which.
+var ErrSelfTestFailed = errors.New("ydlidar: self-test failed")
+
+// selfTestSilence is how long SelfTest waits for a revolution before failing.
+const selfTestSilence = 3 * time.Second
+
+// SelfTestLimits are the bounds checked by SelfTest. Zero fields keep their default.
+type SelfTestLimits struct {
+	Revolutions     int     // Revolutions checked, after the first one, 5 by default.
+	MinFrequency    float64 // Slowest rotation rate in Hz, that of the model by default.
+	MaxFrequency    float64 // Fastest rotation rate in Hz, that of the model by default.
+	MinValidRatio   float64 // Least share of the samples with a return, 0.5 by default.
+	MaxChecksumRate float64 // Largest share of the packets failing validation, 0.01 by default.
+}
+
+// SelfTestOption configures SelfTest.
+type SelfTestOption func(*SelfTestLimits)
+
+// SelfTestWithLimits replaces the default limits, zero fields keep their default.
+func SelfTestWithLimits(limits SelfTestLimits) SelfTestOption {
+	return func(l *SelfTestLimits) {
+		if limits.Revolutions > 0 {
+			l.Revolutions = limits.Revolutions
+		}
+		if limits.MinFrequency > 0 {
+			l.MinFrequency = limits.MinFrequency
+		}
+		if limits.MaxFrequency > 0 {
+			l.MaxFrequency = limits.MaxFrequency
+		}
+		if limits.MinValidRatio > 0 {
+			l.MinValidRatio = limits.MinValidRatio
+		}
+		if limits.MaxChecksumRate > 0 {
+			l.MaxChecksumRate = limits.MaxChecksumRate
+		}
+	}
+}
+
+// SelfTestCheck is the result of a check of SelfTest.
+type SelfTestCheck struct {
+	Name   string  // frequency, valid_returns, checksum_rate or intensity_variance.
+	Value  float64 // Measured value.
+	Min    float64 // Lower bound, 0 if none.
+	Max    float64 // Upper bound, 0 if none.
+	Passed bool
+}
+
+// SelfTestReport is the outcome of SelfTest.
+type SelfTestReport struct {
+	Revolutions int           // Revolutions checked.
+	Packets     uint64        // Packets decoded meanwhile.
+	Duration    time.Duration // Time the test took, spin up included.
+	Checks      []SelfTestCheck
+	Passed      bool
+}
+
+// failed returns the names of the failed checks.
+func (r *SelfTestReport) failed() []string {
+	var names []string
+	for _, check := range r.Checks {
+		if !check.Passed {
+			names = append(names, check.Name)
+		}
+	}
+	return names
+}
+
+// SelfTest spins the motor, scans a few revolutions and checks the rotation rate is within
+// the range of the model, enough samples have a return, few packets fail validation and the
+// intensities vary, so a faulty unit is caught before the robot moves. The first revolution,
+// taken while the motor spins up, is left out. The packets and revolutions read meanwhile
+// are discarded and the scan is stopped when done.
+//
+// The report is returned along with ErrSelfTestFailed when a check failed. The scan has to be
+// stopped first.
+func (lidar *YDLidar) SelfTest(ctx context.Context, opts ...SelfTestOption) (*SelfTestReport, error) {
+	limits := SelfTestLimits{Revolutions: 5, MinFrequency: 5, MaxFrequency: 12, MinValidRatio: 0.5, MaxChecksumRate: 0.01}
+	if spec, ok := models[lidar.model]; ok {
+		limits.MinFrequency, limits.MaxFrequency = spec.minFrequency, spec.maxFrequency
+	}
+	for _, opt := range opts {
+		opt(&limits)
+	}
+	if lidar.IsScanning() {
+		return nil, ErrScanRunning
+	}
+
+	start := time.Now()
+	if err := lidar.StartMotor(); err != nil && !errors.Is(err, ErrUnsupportedByTransport) {
+		return nil, fmt.Errorf("failed to start motor: %w", err)
+	}
+
+	// The revolutions are taken from a subscription, assembled even if the application neither
+	// enabled the Scans channel nor subscribed.
+	revolutions := make(chan Scan, limits.Revolutions+1)
+	sub := lidar.OnScan(func(scan Scan) {
+		select {
+		case revolutions <- scan:
+		default:
+		}
+	}, SubscribeBuffer(limits.Revolutions+1, Block))
+	defer sub.Unsubscribe()
+
+	before := lidar.Metrics()
+	if err := lidar.StartScan(); err != nil {
+		return nil, err
+	}
+	scans, err := lidar.collectRevolutions(ctx, revolutions, limits.Revolutions+1)
+	if stopErr := lidar.StopScan(); err == nil && stopErr != nil {
+		err = stopErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	after := lidar.Metrics()
+
+	report := &SelfTestReport{Revolutions: len(scans) - 1, Packets: after.Packets - before.Packets, Duration: time.Since(start)}
+	report.evaluate(scans[1:], after.ScanFrequency, after.ChecksumFailures-before.ChecksumFailures, limits, lidar.sampleDecoder().SampleSize() == 3)
+	if !report.Passed {
+		return report, fmt.Errorf("%w: %v", ErrSelfTestFailed, strings.Join(report.failed(), ", "))
+	}
+	return report, nil
+}
+
+// collectRevolutions drains the channels of the running scan until n revolutions came from
+// the subscription.
+func (lidar *YDLidar) collectRevolutions(ctx context.Context, revolutions chan Scan, n int) ([]Scan, error) {
+	silence := time.NewTimer(selfTestSilence)
+	defer silence.Stop()
+	var scans []Scan
+	for len(scans) < n {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-silence.C:
+			return nil, fmt.Errorf("%w: no revolution within %v, is the motor spinning?", ErrTimeout, selfTestSilence)
+		case scan := <-revolutions:
+			scans = append(scans, scan)
+			if !silence.Stop() {
+				<-silence.C
+			}
+			silence.Reset(selfTestSilence)
+		case <-lidar.Scans:
+		case packet := <-lidar.Packets:
+			if packet.Error != nil {
+				return nil, packet.Error
+			}
+		}
+	}
+	return scans, nil
+}
+
+// evaluate fills in the checks of the report. The frequency is the one reported by the zero
+// packets, else the one measured over the revolutions for the models not reporting it. The
+// intensity check only applies to the models reporting intensity.
+func (r *SelfTestReport) evaluate(scans []Scan, frequency float64, checksumFailures uint64, limits SelfTestLimits, intensity bool) {
+	var measured, valid, points float64
+	var sum, sumSquares, n float64
+	for _, scan := range scans {
+		measured += scan.Frequency
+		valid += float64(scan.Stats.Valid)
+		points += float64(scan.Stats.Points)
+		for _, point := range scan.Points {
+			if scan.IsReturn(point.Dist) {
+				i := float64(point.Intensity)
+				sum, sumSquares, n = sum+i, sumSquares+i*i, n+1
+			}
+		}
+	}
+	if frequency == 0 && len(scans) > 0 {
+		frequency = measured / float64(len(scans))
+	}
+	r.add(SelfTestCheck{Name: "frequency", Value: frequency, Min: limits.MinFrequency, Max: limits.MaxFrequency,
+		Passed: frequency >= limits.MinFrequency && frequency <= limits.MaxFrequency})
+
+	ratio := 0.0
+	if points > 0 {
+		ratio = valid / points
+	}
+	r.add(SelfTestCheck{Name: "valid_returns", Value: ratio, Min: limits.MinValidRatio, Passed: ratio >= limits.MinValidRatio})
+
+	rate := 0.0
+	if total := float64(r.Packets + checksumFailures); total > 0 {
+		rate = float64(checksumFailures) / total
+	}
+	r.add(SelfTestCheck{Name: "checksum_rate", Value: rate, Max: limits.MaxChecksumRate, Passed: r.Packets > 0 && rate <= limits.MaxChecksumRate})
+
+	if intensity {
+		variance := 0.0
+		if n > 0 {
+			mean := sum / n
+			variance = sumSquares/n - mean*mean
+		}
+		r.add(SelfTestCheck{Name: "intensity_variance", Value: variance, Passed: variance > 0})
+	}
+
+	r.Passed = true
+	for _, check := range r.Checks {
+		r.Passed = r.Passed && check.Passed
+	}
+}
+
+func (r *SelfTestReport) add(check SelfTestCheck) {
+	r.Checks = append(r.Checks, check)
+}
@@ -0,0 +1,71 @@
+package ydlidar
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar/sim"
+)
+
+// selfTestSim connects to a simulated G2 with the intensity noise.
+func selfTestSim(t *testing.T, intensityNoise float64) *YDLidar {
+	lidar := NewLidar(sim.NewPort(&sim.Device{Environment: sim.Room(4000, 3000), Noise: 5, IntensityNoise: intensityNoise}))
+	t.Cleanup(func() { lidar.Close() })
+	_, err := lidar.DeviceInfo()
+	require.NoError(t, err)
+	return lidar
+}
+
+func TestSelfTest(t *testing.T) {
+	lidar := selfTestSim(t, 40)
+
+	report, err := lidar.SelfTest(context.Background())
+	require.NoError(t, err)
+	assert.True(t, report.Passed)
+	assert.Equal(t, 5, report.Revolutions)
+	assert.NotZero(t, report.Packets)
+	assert.False(t, lidar.IsScanning())
+
+	names := make([]string, 0, len(report.Checks))
+	for _, check := range report.Checks {
+		names = append(names, check.Name)
+		assert.True(t, check.Passed, check.Name)
+	}
+	assert.Equal(t, []string{"frequency", "valid_returns", "checksum_rate", "intensity_variance"}, names)
+	assert.InDelta(t, 7, report.Checks[0].Value, 0.5)
+	assert.Equal(t, 5.0, report.Checks[0].Min)
+	assert.Equal(t, 12.0, report.Checks[0].Max)
+}
+
+func TestSelfTestFails(t *testing.T) {
+	lidar := selfTestSim(t, 0)
+
+	report, err := lidar.SelfTest(context.Background(), SelfTestWithLimits(SelfTestLimits{Revolutions: 2, MinFrequency: 8}))
+	require.ErrorIs(t, err, ErrSelfTestFailed)
+	require.NotNil(t, report)
+	assert.False(t, report.Passed)
+	assert.Equal(t, 2, report.Revolutions)
+	assert.Equal(t, []string{"frequency", "intensity_variance"}, report.failed())
+	assert.Contains(t, err.Error(), "frequency, intensity_variance")
+}
+
+func TestSelfTestScanning(t *testing.T) {
+	lidar := scanningSim(t)
+
+	report, err := lidar.SelfTest(context.Background())
+	assert.ErrorIs(t, err, ErrScanRunning)
+	assert.Nil(t, report)
+}
+
+func TestSelfTestCanceled(t *testing.T) {
+	lidar := selfTestSim(t, 40)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := lidar.SelfTest(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.False(t, lidar.IsScanning())
+}
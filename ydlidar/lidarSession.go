@@ -0,0 +1,84 @@
+package ydlidar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SessionConfig is the lidar Run connects to.
+type SessionConfig struct {
+	// Port is the name of the serial port, nil auto-detects it. Ignored when Transport is set.
+	Port *string
+
+	// Transport is an opened link to the lidar, eg. a TCP bridge or a simulated device, used
+	// instead of a serial port.
+	Transport Transport
+
+	// Options configure the lidar.
+	Options []Option
+}
+
+// Session is the scanning lidar handed to the function of Run.
+type Session struct {
+	Lidar *YDLidar
+	ctx   context.Context
+}
+
+// Context returns the context of the session, canceled when the context of Run is or once the
+// function returned.
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+// Packet waits for the next packet of the scan. The error is that of the packet, or that of
+// the context if it is canceled first.
+func (s *Session) Packet() (Packet, error) {
+	select {
+	case packet := <-s.Lidar.Packets:
+		return packet, packet.Error
+	case <-s.ctx.Done():
+		return Packet{}, s.ctx.Err()
+	}
+}
+
+// Run connects to the lidar, spins the motor, starts scanning and calls fn with the session.
+// Whatever way fn ends, by returning or panicking, the scan is stopped, the motor spun down
+// and the port closed before Run returns or the panic goes on, so a crashing program doesn't
+// leave the motor spinning.
+//
+// fn reads the packets with Session.Packet or from the channels of the lidar, the Packets
+// channel has to be drained for the scan to go on. The error of fn is returned, joined with
+// that of the cleanup if it failed too.
+func Run(ctx context.Context, config SessionConfig, fn func(s *Session) error) (err error) {
+	var lidar *YDLidar
+	if config.Transport != nil {
+		lidar, err = ConnectTransport(config.Transport, config.Options...)
+	} else {
+		lidar, err = InitAndConnectToDevice(config.Port, config.Options...)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		// Close stops the scan and the motor before closing the port, it runs on a panic too.
+		if closeErr := lidar.Close(); closeErr != nil {
+			if err == nil {
+				err = closeErr
+			} else {
+				err = fmt.Errorf("%w (cleanup: %v)", err, closeErr)
+			}
+		}
+	}()
+
+	if err = lidar.StartMotor(); err != nil && !errors.Is(err, ErrUnsupportedByTransport) {
+		return fmt.Errorf("failed to start motor: %w", err)
+	}
+	if err = lidar.StartScan(); err != nil {
+		return err
+	}
+	return fn(&Session{Lidar: lidar, ctx: ctx})
+}
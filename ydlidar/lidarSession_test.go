@@ -0,0 +1,97 @@
+package ydlidar
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar/sim"
+)
+
+// dtrPort records the DTR changes of a simulated device.
+type dtrPort struct {
+	*sim.Port
+	dtr []bool
+}
+
+func (p *dtrPort) SetDTR(dtr bool) error {
+	p.dtr = append(p.dtr, dtr)
+	return p.Port.SetDTR(dtr)
+}
+
+func sessionPort() *dtrPort {
+	return &dtrPort{Port: sim.NewPort(&sim.Device{Environment: sim.Room(4000, 3000)})}
+}
+
+func TestRun(t *testing.T) {
+	port := sessionPort()
+	var lidar *YDLidar
+	err := Run(context.Background(), SessionConfig{Transport: port}, func(s *Session) error {
+		lidar = s.Lidar
+		assert.True(t, s.Lidar.IsScanning())
+		for i := 0; i < 10; i++ {
+			packet, err := s.Packet()
+			require.NoError(t, err)
+			assert.NotEmpty(t, packet.Distances)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.False(t, lidar.IsScanning())
+	assert.Equal(t, []bool{true, false}, port.dtr, "motor started then stopped")
+	_, err = port.Write([]byte{preCommand, deviceInfo})
+	assert.ErrorIs(t, err, sim.ErrClosed)
+}
+
+func TestRunError(t *testing.T) {
+	port := sessionPort()
+	failed := errors.New("failed")
+	err := Run(context.Background(), SessionConfig{Transport: port}, func(s *Session) error {
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+	assert.Equal(t, []bool{true, false}, port.dtr)
+}
+
+func TestRunPanic(t *testing.T) {
+	port := sessionPort()
+	var lidar *YDLidar
+	assert.PanicsWithValue(t, "crash", func() {
+		_ = Run(context.Background(), SessionConfig{Transport: port}, func(s *Session) error {
+			lidar = s.Lidar
+			panic("crash")
+		})
+	})
+	assert.False(t, lidar.IsScanning())
+	assert.Equal(t, []bool{true, false}, port.dtr, "motor stopped despite the panic")
+	_, err := port.Write([]byte{preCommand, deviceInfo})
+	assert.ErrorIs(t, err, sim.ErrClosed)
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Run(ctx, SessionConfig{Transport: sessionPort()}, func(s *Session) error {
+		cancel()
+		for {
+			if _, err := s.Packet(); err != nil {
+				return err
+			}
+		}
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRunConnectFails(t *testing.T) {
+	port := sessionPort()
+	port.Close()
+	called := false
+	err := Run(context.Background(), SessionConfig{Transport: port}, func(s *Session) error {
+		called = true
+		return nil
+	})
+	assert.Error(t, err)
+	assert.False(t, called)
+}
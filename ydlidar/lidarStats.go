@@ -0,0 +1,62 @@
+package ydlidar
+
+import "sync/atomic"
+
+// ScanStats are the quality statistics of a revolution. A rising dropout ratio or a falling
+// mean intensity across scans of the same scene points at a dirty or failing optical window.
+type ScanStats struct {
+	Points           int     // Samples in the revolution.
+	Valid            int     // Samples with a return, a non zero distance.
+	DropoutRatio     float64 // Share of the samples without a return, 0 to 1.
+	MinRange         float64 // Shortest valid distance in mm.
+	MeanRange        float64 // Mean valid distance in mm.
+	MaxRange         float64 // Longest valid distance in mm.
+	MeanIntensity    float64 // Mean intensity of the valid returns.
+	ChecksumFailures uint64  // Scan packets dropped during the revolution, see ChecksumFailures.
+}
+
+// newScanStats computes the statistics of the points.
+func newScanStats(points []PointCloudData) ScanStats {
+	stats := ScanStats{Points: len(points)}
+	var sumRange float64
+	var sumIntensity int
+	for _, p := range points {
+		if p.Dist <= 0 {
+			continue
+		}
+		if stats.Valid == 0 || p.Dist < stats.MinRange {
+			stats.MinRange = p.Dist
+		}
+		if p.Dist > stats.MaxRange {
+			stats.MaxRange = p.Dist
+		}
+		stats.Valid++
+		sumRange += p.Dist
+		sumIntensity += p.Intensity
+	}
+	if stats.Points > 0 {
+		stats.DropoutRatio = float64(stats.Points-stats.Valid) / float64(stats.Points)
+	}
+	if stats.Valid > 0 {
+		stats.MeanRange = sumRange / float64(stats.Valid)
+		stats.MeanIntensity = float64(sumIntensity) / float64(stats.Valid)
+	}
+	return stats
+}
+
+// checksumCounter attributes the checksum failures to the revolutions.
+type checksumCounter struct {
+	total *atomic.Uint64 // Failures since the lidar was created, nil disables the count.
+	start uint64         // Total at the start of the current revolution.
+}
+
+// since returns the failures since the last call.
+func (c *checksumCounter) since() uint64 {
+	if c.total == nil {
+		return 0
+	}
+	total := c.total.Load()
+	n := total - c.start
+	c.start = total
+	return n
+}
@@ -0,0 +1,49 @@
+package ydlidar
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanStats(t *testing.T) {
+	stats := newScanStats([]PointCloudData{
+		{Dist: 1000, Intensity: 100},
+		{Dist: 0, Intensity: 0},
+		{Dist: 500, Intensity: 200},
+		{Dist: 3000, Intensity: 300},
+	})
+
+	assert.Equal(t, 4, stats.Points)
+	assert.Equal(t, 3, stats.Valid)
+	assert.Equal(t, 0.25, stats.DropoutRatio)
+	assert.Equal(t, float64(500), stats.MinRange)
+	assert.Equal(t, float64(1500), stats.MeanRange)
+	assert.Equal(t, float64(3000), stats.MaxRange)
+	assert.Equal(t, 200.0, stats.MeanIntensity)
+
+	assert.Equal(t, ScanStats{}, newScanStats(nil))
+	assert.Equal(t, ScanStats{Points: 1, DropoutRatio: 1}, newScanStats([]PointCloudData{{}}))
+}
+
+func TestAssemblerStats(t *testing.T) {
+	var failures atomic.Uint64
+	a := &scanAssembler{checksums: checksumCounter{total: &failures}}
+
+	failures.Add(5)
+	a.startRevolution()
+	a.add(testPacket(1, 2))
+	failures.Add(2)
+	scan, ok := a.startRevolution()
+	assert.True(t, ok)
+	assert.Equal(t, 2, scan.Stats.Valid)
+	assert.Equal(t, uint64(2), scan.Stats.ChecksumFailures)
+
+	a.add(testPacket(3))
+	failures.Add(1)
+	scan, ok = a.partial()
+	assert.True(t, ok)
+	assert.Equal(t, 1, scan.Stats.Points)
+	assert.Equal(t, uint64(1), scan.Stats.ChecksumFailures)
+}
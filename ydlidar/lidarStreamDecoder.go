@@ -0,0 +1,258 @@
+package ydlidar
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrNeedMore is returned by StreamDecoder.Next when the bytes written so far don't hold a
+// whole packet. Write more and call Next again.
+var ErrNeedMore = errors.New("ydlidar: need more bytes")
+
+// StreamDecoder decodes the scan packets of a byte stream written to it, with no I/O, for the
+// transports the driver doesn't read: DMA buffers, PTYs, network captures. The scan loop
+// decodes the stream of the device with it too. It resynchronizes on the packet header after noise or a partial packet,
+// and tracks the frequency and the start of revolution reported by the zero packets.
+//
+//	dec := ydlidar.NewStreamDecoder(ydlidar.WithUnits(ydlidar.Meters))
+//	dec.Write(chunk)
+//	for {
+//		packet, err := dec.Next()
+//		if errors.Is(err, ydlidar.ErrNeedMore) {
+//			break
+//		}
+//		...
+//	}
+//
+// A StreamDecoder is not safe for concurrent use.
+type StreamDecoder struct {
+	buf   []byte // buf[start:] is written and not decoded yet.
+	start int
+
+	decoder     SampleDecoder
+	layoutKnown bool // Set by WithSampleDecoder or SetDeviceInfo, else the framing tells it.
+	quirks      FirmwareQuirks
+	units       Unit
+
+	// detectStride is set by the scan loop unless the layout is forced, stride is then the
+	// layout the check codes told, see detectStride.
+	detectStride bool
+	stride       SampleDecoder
+
+	frequency float64 // Reported by the last zero packet.
+	zeroStart bool    // A zero packet was decoded since the last point cloud packet.
+	skipped   uint64
+	need      int // Bytes missing from the packet Next waits for.
+}
+
+// streamFrame is a packet of the stream framed by nextFrame, not checked nor decoded. Its
+// bytes are those of the buffer of the decoder, valid until the next Write.
+type streamFrame struct {
+	category PacketCategory
+	header   []byte // Scan packet header, response header, or the skipped bytes of CategoryUnknown.
+	samples  []byte // Samples of a scan packet, payload of a response.
+}
+
+// NewStreamDecoder returns a decoder of the G2 layout, or of the framing of the packets, in
+// millimeters. WithSampleDecoder and WithUnits apply, the other options are ignored.
+func NewStreamDecoder(opts ...Option) *StreamDecoder {
+	var settings YDLidar
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	return &StreamDecoder{
+		decoder:     settings.sampleDecoder(),
+		layoutKnown: settings.decoder != nil,
+		units:       settings.units,
+	}
+}
+
+// SetDeviceInfo selects the sample layout and the firmware quirks of the device, like
+// YDLidar.DeviceInfo does for the scan loop. A layout forced by WithSampleDecoder is kept.
+func (d *StreamDecoder) SetDeviceInfo(info DeviceInfo) {
+	d.quirks = quirksFor(info.Model, FirmwareVersion{Major: info.FirmwareMajor, Minor: info.FirmwareMinor})
+	if !d.layoutKnown {
+		d.decoder = decoderFor(info.Model)
+		d.layoutKnown = true
+	}
+}
+
+// Write appends p to the bytes to decode. It never fails.
+func (d *StreamDecoder) Write(p []byte) (int, error) {
+	// Drop the decoded bytes rather than growing the buffer forever.
+	if d.start > 0 && d.start >= len(d.buf)/2 {
+		d.buf = d.buf[:copy(d.buf, d.buf[d.start:])]
+		d.start = 0
+	}
+	d.buf = append(d.buf, p...)
+	return len(p), nil
+}
+
+// Buffered returns the number of bytes written and not decoded yet.
+func (d *StreamDecoder) Buffered() int {
+	return len(d.buf) - d.start
+}
+
+// Skipped returns the number of bytes skipped to resynchronize on a packet header.
+func (d *StreamDecoder) Skipped() uint64 {
+	return d.skipped
+}
+
+// Reset drops the bytes not decoded yet and the state of the revolution, eg. after a gap in
+// the stream.
+func (d *StreamDecoder) Reset() {
+	d.buf, d.start = d.buf[:0], 0
+	d.frequency, d.zeroStart = 0, false
+}
+
+// Next returns the next point cloud packet of the stream, ErrNeedMore when the bytes written
+// don't hold one. The zero packets are consumed along the way: they set the Frequency and
+// the IsZeroStart of the packets following them. A corrupted packet is consumed and reported
+// with an error wrapping ErrChecksum, decoding goes on with the next call.
+func (d *StreamDecoder) Next() (Packet, error) {
+	for {
+		frame, err := d.nextFrame()
+		if err != nil {
+			return Packet{}, err
+		}
+		switch frame.category {
+		case CategoryZero:
+			d.zeroPacket(frame.header)
+		case CategoryPointCloud:
+			return d.decodePacket(frame)
+		}
+	}
+}
+
+// nextFrame returns the next packet of the stream as it is, ErrNeedMore when the bytes written
+// don't hold a whole one. The bytes skipped to resynchronize on a packet come first, as a
+// frame of CategoryUnknown.
+func (d *StreamDecoder) nextFrame() (streamFrame, error) {
+	if skipped := d.sync(); len(skipped) > 0 {
+		return streamFrame{category: CategoryUnknown, header: skipped}, nil
+	}
+	pending := d.buf[d.start:]
+	if len(pending) >= 2 && binary.LittleEndian.Uint16(pending) == responseHeader {
+		if len(pending) < responseHeaderSize {
+			return d.needMore(responseHeaderSize)
+		}
+		// The responses the driver reads are shorter than 64 bytes, see parseInfoHeader.
+		size := responseHeaderSize + int(pending[2]&0x3F)
+		if len(pending) < size {
+			return d.needMore(size)
+		}
+		d.start += size
+		return streamFrame{category: CategoryResponse, header: pending[:responseHeaderSize], samples: pending[responseHeaderSize:size]}, nil
+	}
+
+	if len(pending) < scanPacketHeaderSize {
+		return d.needMore(scanPacketHeaderSize)
+	}
+	header := pending[:scanPacketHeaderSize]
+	codec, _ := codecFor(header)
+	if !d.layoutKnown {
+		d.decoder = codec.decoder
+	}
+	size := scanPacketHeaderSize + int(header[3])*d.decoder.SampleSize()
+	if len(pending) < size {
+		return d.needMore(size)
+	}
+	category := ClassifyPacket(header)
+	if category == CategoryPointCloud && d.detectStride {
+		decoder, strideSize, err := detectStride(pending, d.decoder.SampleSize())
+		if err != nil {
+			return d.needMore(strideSize)
+		}
+		if decoder != nil {
+			d.decoder, d.layoutKnown, d.stride = decoder, true, decoder
+			size = strideSize
+		}
+	}
+	d.start += size
+	return streamFrame{category: category, header: header, samples: pending[scanPacketHeaderSize:size]}, nil
+}
+
+// needMore records that the packet waited for is size bytes long and returns ErrNeedMore.
+func (d *StreamDecoder) needMore(size int) (streamFrame, error) {
+	d.need = size - d.Buffered()
+	return streamFrame{}, ErrNeedMore
+}
+
+// truncate drops the bytes of the packet the decoder waits the end of, eg. when the stream
+// stopped in its middle, and returns them as a frame: a scan packet or a response missing
+// bytes, or a part of a header as a frame of CategoryUnknown.
+func (d *StreamDecoder) truncate() streamFrame {
+	pending := d.buf[d.start:]
+	d.start = len(d.buf)
+	category := ClassifyPacket(pending)
+	size := scanPacketHeaderSize
+	if category == CategoryResponse {
+		size = responseHeaderSize
+	}
+	if len(pending) < size {
+		return streamFrame{category: CategoryUnknown, header: pending}
+	}
+	return streamFrame{category: category, header: pending[:size], samples: pending[size:]}
+}
+
+// zeroPacket takes the frequency and the start of revolution of a zero packet.
+func (d *StreamDecoder) zeroPacket(header []byte) {
+	d.frequency = d.quirks.zeroPacketFrequency(header[2])
+	d.zeroStart = true
+}
+
+// decodePacket checks and decodes a point cloud packet, the distances in the units of the
+// decoder.
+func (d *StreamDecoder) decodePacket(frame streamFrame) (Packet, error) {
+	parsed, err := parseScanPacket(frame.header, frame.samples, d.decoder, d.quirks)
+	if err != nil {
+		return Packet{}, err
+	}
+	if codec, _ := codecFor(frame.header); codec.frequencyEveryPacket {
+		d.frequency = d.quirks.zeroPacketFrequency(parsed.header.PackageType)
+	}
+	// The angle correction needs the distances in millimeters, convert them afterwards.
+	d.units.fromMillimeters(parsed.distances)
+	packet := Packet{
+		NumDistanceSamples: int(parsed.header.SampleQuantity),
+		Angles:             parsed.angles,
+		Distances:          parsed.distances,
+		Intensities:        parsed.intensities,
+		Units:              d.units,
+		PacketType:         parsed.header.PackageType,
+		StartAngle:         float64(parsed.header.StartAngle>>1) / 64,
+		EndAngle:           float64(parsed.header.EndAngle>>1) / 64,
+		Frequency:          d.frequency,
+		IsZeroStart:        d.zeroStart,
+	}
+	d.zeroStart = false
+	return packet, nil
+}
+
+// sync skips the bytes up to the next scan packet header or response start sign, keeping a
+// last byte that may start one, and returns them.
+func (d *StreamDecoder) sync() []byte {
+	from := d.start
+	for d.start < len(d.buf) && !mayStartPacket(d.buf[d.start:]) {
+		d.start++
+	}
+	d.skipped += uint64(d.start - from)
+	return d.buf[from:d.start]
+}
+
+// streamDecoder returns the decoder of the scan loop, with the sample layout, detected or
+// selected by DeviceInfo, the firmware quirks and the units of the lidar.
+func (lidar *YDLidar) streamDecoder() *StreamDecoder {
+	d := &StreamDecoder{
+		decoder:      lidar.sampleDecoder(),
+		layoutKnown:  lidar.decoder != nil,
+		quirks:       lidar.quirks,
+		units:        lidar.units,
+		detectStride: !lidar.fixedDecoder,
+		stride:       lidar.stride,
+	}
+	if lidar.stride != nil {
+		d.decoder, d.layoutKnown = lidar.stride, true
+	}
+	return d
+}
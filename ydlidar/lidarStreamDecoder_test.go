@@ -0,0 +1,98 @@
+package ydlidar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeAll returns the packets of the bytes written so far.
+func decodeAll(t *testing.T, d *StreamDecoder) []Packet {
+	var packets []Packet
+	for {
+		packet, err := d.Next()
+		if err == ErrNeedMore {
+			return packets
+		}
+		require.NoError(t, err)
+		packets = append(packets, packet)
+	}
+}
+
+func TestStreamDecoder(t *testing.T) {
+	// Noise, then a revolution written a byte at a time.
+	d := NewStreamDecoder(WithUnits(Meters))
+	var packets []Packet
+	for _, b := range append([]byte{0x13, 0xAA, 0x00}, revolutionBytes()...) {
+		_, err := d.Write([]byte{b})
+		require.NoError(t, err)
+		packets = append(packets, decodeAll(t, d)...)
+	}
+
+	require.Len(t, packets, 12)
+	assert.Equal(t, uint64(3), d.Skipped())
+	assert.Zero(t, d.Buffered())
+	for i, packet := range packets {
+		assert.Equal(t, i == 0, packet.IsZeroStart, "packet %v", i)
+		assert.Equal(t, Meters, packet.Units)
+		assert.Equal(t, 1.0, packet.Distances[0])
+		assert.Equal(t, 100, packet.Intensities[0])
+		assert.Len(t, packet.Angles, 40)
+		assert.Equal(t, float64(i*30), packet.StartAngle)
+	}
+}
+
+func TestStreamDecoderCorruption(t *testing.T) {
+	samples := [][3]byte{{100, byte(1000 & 0x3F << 2), byte(1000 >> 6)}}
+	corrupted := encodeScanPacket(0x00, 0x0001, 0x0201, samples)
+	corrupted[len(corrupted)-1]++
+
+	d := NewStreamDecoder()
+	d.Write(corrupted)
+	d.Write(encodeScanPacket(0x00, 0x0001, 0x0201, samples))
+	_, err := d.Next()
+	assert.ErrorIs(t, err, ErrChecksum)
+	packet, err := d.Next()
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, packet.Distances[0])
+	_, err = d.Next()
+	assert.ErrorIs(t, err, ErrNeedMore)
+}
+
+func TestStreamDecoderFraming(t *testing.T) {
+	// The T-mini framing selects its layout and reports the frequency in every packet.
+	d := NewStreamDecoder()
+	d.Write(encodeTminiPacket(0xC9, 1, 1, [][3]byte{{}}))
+	d.Write(encodeTminiPacket(0xB4, 1, 0x0201, [][3]byte{tminiSample}))
+	packets := decodeAll(t, d)
+	require.Len(t, packets, 1)
+	assert.Equal(t, 1000.0, packets[0].Distances[0])
+	assert.Equal(t, 200, packets[0].Intensities[0])
+	assert.Equal(t, 9.0, packets[0].Frequency)
+	assert.True(t, packets[0].IsZeroStart)
+
+	// The device info selects the layout, unless it is forced.
+	d = NewStreamDecoder()
+	d.SetDeviceInfo(DeviceInfo{Model: 150})
+	assert.Equal(t, TminiDecoder{}, d.decoder)
+	d = NewStreamDecoder(WithSampleDecoder(DistanceDecoder{}))
+	d.SetDeviceInfo(DeviceInfo{Model: 150})
+	assert.Equal(t, DistanceDecoder{}, d.decoder)
+}
+
+func TestStreamDecoderBuffer(t *testing.T) {
+	// The decoded bytes are dropped, the buffer doesn't grow with the stream.
+	d := NewStreamDecoder()
+	revolution := revolutionBytes()
+	for i := 0; i < 100; i++ {
+		d.Write(revolution)
+		require.Len(t, decodeAll(t, d), 12)
+	}
+	assert.LessOrEqual(t, cap(d.buf), 4*len(revolution))
+
+	d.Write(revolution[:50])
+	d.Reset()
+	d.Write(revolution)
+	assert.Len(t, decodeAll(t, d), 12)
+}
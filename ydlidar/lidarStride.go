@@ -0,0 +1,52 @@
+package ydlidar
+
+import "log"
+
+// strideDecoders are the sample layouts by bytes per sample, the scan packets don't say which
+// they carry.
+var strideDecoders = map[int]SampleDecoder{
+	2: DistanceDecoder{},
+	3: IntensityDecoder{},
+}
+
+// detectStride checks a point cloud packet failing its check code with n bytes per sample
+// against the other sample size: some clones report the model of the other layout. pending
+// holds the stream from the header of the packet on. Returns the decoder of the other size
+// and the size of the packet with it if its check code matches, a nil decoder otherwise, or
+// ErrNeedMore and the size to wait for when the packet may be longer than pending.
+//
+// The packets don't carry their length, a check code matching with the other size is what
+// tells the stride: a corrupted packet matching it by chance is one in 65536.
+func detectStride(pending []byte, n int) (SampleDecoder, int, error) {
+	header := pending[:scanPacketHeaderSize]
+	quantity := int(header[3])
+	if len(pending) < scanPacketHeaderSize+quantity*n || checkScanPacket(header, pending[scanPacketHeaderSize:scanPacketHeaderSize+quantity*n], n) == nil {
+		return nil, 0, nil
+	}
+	other := 5 - n
+	decoder, ok := strideDecoders[other]
+	if !ok {
+		return nil, 0, nil
+	}
+	size := scanPacketHeaderSize + quantity*other
+	if len(pending) < size {
+		return nil, size, ErrNeedMore
+	}
+	if checkScanPacket(header, pending[scanPacketHeaderSize:size], other) != nil {
+		return nil, 0, nil
+	}
+	return decoder, size, nil
+}
+
+// warnStride warns that the scan packets have the layout of the decoder rather than the one of
+// the model reported by the device.
+func (lidar *YDLidar) warnStride(decoder SampleDecoder) {
+	name := "unknown"
+	if spec, ok := models[lidar.model]; ok {
+		name = spec.name
+	}
+	other := decoder.SampleSize()
+	log.Printf("Warning: the scan packets have %v bytes per sample, the %v model reported by the device has %v. "+
+		"Decoding %v byte samples until the next DeviceInfo, the device may be a clone misreporting its model.",
+		other, name, 5-other, other)
+}
@@ -0,0 +1,79 @@
+package ydlidar
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// misreportingLidar returns a lidar connected to a device reporting the model, scanning the
+// packets.
+func misreportingLidar(t *testing.T, model byte, packets []byte, opts ...Option) *YDLidar {
+	port := &fakePort{}
+	lidar := NewLidar(port, opts...)
+	port.queue(0xA5, 0x5A, 0x14, 0x00, 0x00, 0x00, InfoTypeCode)
+	port.queue(append([]byte{model}, make([]byte, 19)...)...)
+	_, err := lidar.DeviceInfo()
+	require.NoError(t, err)
+
+	port.queue(scanResponseHeader...)
+	port.queue(packets...)
+	require.NoError(t, lidar.StartScan())
+	return lidar
+}
+
+func TestStrideDetection(t *testing.T) {
+	distances := make([]uint16, 40)
+	for i := range distances {
+		distances[i] = 4000 // 1000mm.
+	}
+	intensities := make([][3]byte, 40)
+	for i := range intensities {
+		intensities[i] = [3]byte{100, byte(1000 & 0x3F << 2), byte(1000 >> 6)}
+	}
+	var twoBytes, threeBytes bytes.Buffer
+	for start := 0; start < 360; start += 30 {
+		fsa := uint16(start*64)<<1 | 1
+		lsa := uint16((start+29)*64)<<1 | 1
+		twoBytes.Write(encodeDistancePacket(fsa, lsa, distances))
+		threeBytes.Write(encodeScanPacket(0x00, fsa, lsa, intensities))
+	}
+
+	for _, tc := range []struct {
+		name    string
+		model   byte // Reports the other layout.
+		packets []byte
+		want    SampleDecoder
+	}{
+		{"G2 sending 2 byte samples", 15, twoBytes.Bytes(), DistanceDecoder{}},
+		{"X4 sending 3 byte samples", 6, threeBytes.Bytes(), IntensityDecoder{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lidar := misreportingLidar(t, tc.model, tc.packets)
+			for i := 0; i < 12; i++ {
+				packet := <-lidar.Packets
+				require.NoError(t, packet.Error)
+				assert.Equal(t, 1000.0, packet.Distances[0], "packet %v", i)
+				assert.Len(t, packet.Distances, 40)
+			}
+			require.NoError(t, lidar.StopScan())
+			assert.Zero(t, lidar.Stats().ChecksumFailures)
+			assert.Equal(t, tc.want, lidar.stride)
+		})
+	}
+}
+
+func TestStrideDetectionForced(t *testing.T) {
+	// A forced layout is kept even though the packets don't match it.
+	var packets bytes.Buffer
+	for i := 0; i < 4; i++ {
+		packets.Write(encodeDistancePacket(0x0001, 0x0201, []uint16{4000, 4000, 4000}))
+	}
+	lidar := misreportingLidar(t, 15, packets.Bytes(), WithSampleDecoder(IntensityDecoder{}))
+	require.Eventually(t, func() bool { return lidar.Stats().ChecksumFailures > 0 }, time.Second, time.Millisecond)
+	require.NoError(t, lidar.StopScan())
+	assert.Nil(t, lidar.stride)
+}
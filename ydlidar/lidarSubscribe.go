@@ -0,0 +1,270 @@
+package ydlidar
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultSubscriberBuffer is the number of values queued for a subscriber unless set with
+// SubscribeBuffer.
+const defaultSubscriberBuffer = 16
+
+// Subscription is a callback registered with OnPacket or OnScan, or a stream opened with
+// PacketStream or ScanStream.
+type Subscription struct {
+	once    sync.Once
+	stop    chan struct{}
+	remove  func()
+	dropped *atomic.Uint64
+	skipped *atomic.Uint64
+}
+
+// Unsubscribe stops the deliveries. A callback already running completes, the channel of a
+// stream is closed.
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		// Stopped first, a publisher blocked on a stream nobody reads lets go of the list.
+		close(s.stop)
+		s.remove()
+	})
+}
+
+// Dropped returns the number of values the subscriber missed because its buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Skipped returns the number of values not delivered to keep the subscriber within its rate
+// limit, see SubscribeRate.
+func (s *Subscription) Skipped() uint64 {
+	return s.skipped.Load()
+}
+
+// SubscribeOption configures a subscription.
+type SubscribeOption func(*subscribeConfig)
+
+type subscribeConfig struct {
+	buffer   int
+	policy   OverflowPolicy
+	interval time.Duration // Least time between deliveries, 0 without a rate limit.
+}
+
+// SubscribeBuffer sets the number of values queued for the subscriber and what happens when
+// it falls behind and the queue fills up. The default is 16 values, dropping the oldest.
+// With Block a slow subscriber holds up the scan loop and every other consumer.
+func SubscribeBuffer(buffer int, policy OverflowPolicy) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.buffer = buffer
+		c.policy = policy
+	}
+}
+
+// SubscribeRate delivers at most perSecond values per second to the subscriber, eg. 5 scans
+// per second to a network publisher while the other subscribers get every revolution. The
+// values in between are skipped before being queued, so a slow sink neither falls behind
+// nor needs the whole lidar downsampled. 0 or less delivers every value.
+func SubscribeRate(perSecond float64) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.interval = 0
+		if perSecond > 0 {
+			c.interval = time.Duration(float64(time.Second) / perSecond)
+		}
+	}
+}
+
+// OnPacket calls fn with every packet sent on the Packets channel, from a goroutine of its
+// own. Subscribers are independent of each other and of the Packets channel, and must not
+// modify the packets they receive, which are shared.
+func (lidar *YDLidar) OnPacket(fn func(Packet), opts ...SubscribeOption) *Subscription {
+	return lidar.packetSubscribers.add(fn, opts)
+}
+
+// OnScan calls fn with every assembled revolution, from a goroutine of its own. Revolutions
+// are assembled for subscribers even when the Scans channel is disabled. Subscribers must not
+// modify the revolutions they receive, which are shared.
+func (lidar *YDLidar) OnScan(fn func(Scan), opts ...SubscribeOption) *Subscription {
+	return lidar.scanSubscribers.add(fn, opts)
+}
+
+// Stream is a subscription delivering the values on a channel, to read with a select or a
+// range, or one at a time with Next. The values are those the callback of OnPacket or OnScan
+// would get, with the same options, read on the goroutine of the caller. A stream nobody
+// reads drops values like a slow callback, or holds up the scan loop with Block.
+//
+// Callbacks, channels and Next cost differently per value on each platform, run
+// go test -bench Delivery to pick one on the target.
+type Stream[T any] struct {
+	*Subscription
+	C <-chan T // Closed by Unsubscribe.
+}
+
+// Next waits for the next value. It returns false once the stream is unsubscribed.
+func (s *Stream[T]) Next() (T, bool) {
+	v, ok := <-s.C
+	return v, ok
+}
+
+// PacketStream delivers every packet sent on the Packets channel, like OnPacket. Subscribers
+// must not modify the packets they receive, which are shared.
+func (lidar *YDLidar) PacketStream(opts ...SubscribeOption) *Stream[Packet] {
+	return lidar.packetSubscribers.stream(opts)
+}
+
+// ScanStream delivers every assembled revolution, like OnScan. Subscribers must not modify the
+// revolutions they receive, which are shared.
+func (lidar *YDLidar) ScanStream(opts ...SubscribeOption) *Stream[Scan] {
+	return lidar.scanSubscribers.stream(opts)
+}
+
+// subscriber is the queue of one subscription.
+type subscriber[T any] struct {
+	values   chan T
+	policy   OverflowPolicy
+	stop     chan struct{}
+	dropped  atomic.Uint64
+	skipped  atomic.Uint64
+	interval time.Duration // Least time between deliveries, 0 without a rate limit.
+	next     time.Time     // Earliest next delivery, only used by the publisher.
+}
+
+// subscribers is the set of subscriptions to one kind of value.
+type subscribers[T any] struct {
+	mu   sync.RWMutex
+	list []*subscriber[T]
+	n    atomic.Int32
+}
+
+// add registers fn and starts its delivery goroutine.
+func (s *subscribers[T]) add(fn func(T), opts []SubscribeOption) *Subscription {
+	sub, subscription := s.register(opts, false)
+	go func() {
+		for {
+			select {
+			case v := <-sub.values:
+				fn(v)
+			case <-sub.stop:
+				return
+			}
+		}
+	}()
+	return subscription
+}
+
+// stream registers a subscriber read by its caller.
+func (s *subscribers[T]) stream(opts []SubscribeOption) *Stream[T] {
+	sub, subscription := s.register(opts, true)
+	return &Stream[T]{Subscription: subscription, C: sub.values}
+}
+
+// register adds a subscriber configured by the options. Its queue is closed once removed if
+// closeQueue is set, no publisher sends to it anymore by then.
+func (s *subscribers[T]) register(opts []SubscribeOption, closeQueue bool) (*subscriber[T], *Subscription) {
+	config := subscribeConfig{buffer: defaultSubscriberBuffer, policy: DropOldest}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	sub := &subscriber[T]{
+		values:   make(chan T, config.buffer),
+		policy:   config.policy,
+		stop:     make(chan struct{}),
+		interval: config.interval,
+	}
+
+	s.mu.Lock()
+	s.list = append(s.list, sub)
+	s.n.Store(int32(len(s.list)))
+	s.mu.Unlock()
+
+	return sub, &Subscription{
+		stop:    sub.stop,
+		dropped: &sub.dropped,
+		skipped: &sub.skipped,
+		remove: func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			for i, other := range s.list {
+				if other == sub {
+					s.list = append(s.list[:i], s.list[i+1:]...)
+					break
+				}
+			}
+			s.n.Store(int32(len(s.list)))
+			if closeQueue {
+				close(sub.values)
+			}
+		},
+	}
+}
+
+// active reports whether there is any subscriber.
+func (s *subscribers[T]) active() bool {
+	return s.n.Load() > 0
+}
+
+// publish queues the value for every subscriber according to its overflow policy.
+// Returns false if the scan was stopped while waiting on a blocking subscriber.
+func (s *subscribers[T]) publish(v T, stop chan struct{}) bool {
+	if !s.active() {
+		return true
+	}
+	now := time.Now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sub := range s.list {
+		if !sub.allow(now) {
+			continue
+		}
+		if !sub.send(v, stop) {
+			return false
+		}
+	}
+	return true
+}
+
+// allow reports whether a value published at now is within the rate limit. The deliveries
+// follow a fixed schedule, so jitter in the arrivals doesn't lower the rate, restarted after a
+// pause so the missed deliveries don't come in a burst.
+func (sub *subscriber[T]) allow(now time.Time) bool {
+	if sub.interval <= 0 {
+		return true
+	}
+	if now.Before(sub.next) {
+		sub.skipped.Add(1)
+		return false
+	}
+	sub.next = sub.next.Add(sub.interval)
+	if sub.next.Before(now) {
+		sub.next = now.Add(sub.interval)
+	}
+	return true
+}
+
+// send queues the value. Returns false if the scan was stopped while blocked.
+func (sub *subscriber[T]) send(v T, stop chan struct{}) bool {
+	if sub.policy == Block {
+		select {
+		case sub.values <- v:
+		case <-sub.stop:
+		case <-stop:
+			return false
+		}
+		return true
+	}
+
+	for {
+		select {
+		case sub.values <- v:
+			return true
+		default:
+		}
+		sub.dropped.Add(1)
+		if sub.policy == DropNewest {
+			return true
+		}
+		select {
+		case <-sub.values:
+		default:
+		}
+	}
+}
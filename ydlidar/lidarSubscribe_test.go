@@ -0,0 +1,234 @@
+package ydlidar
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribers(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port)
+	port.queue(scanResponseHeader...)
+	port.refill = revolutionBytes
+
+	var mu sync.Mutex
+	packets, scans := 0, 0
+	gotScan := make(chan struct{}, 1)
+	packetSub := lidar.OnPacket(func(Packet) {
+		mu.Lock()
+		packets++
+		mu.Unlock()
+	})
+	lidar.OnScan(func(scan Scan) {
+		mu.Lock()
+		scans++
+		mu.Unlock()
+		assert.Len(t, scan.Points, 480)
+		select {
+		case gotScan <- struct{}{}:
+		default:
+		}
+	})
+
+	require.NoError(t, lidar.StartScan())
+	// The Packets channel has its own consumer, the subscribers don't take from it.
+	go func() {
+		for range lidar.Packets {
+		}
+	}()
+	select {
+	case <-gotScan:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no revolution delivered to the scan subscriber")
+	}
+	packetSub.Unsubscribe()
+	packetSub.Unsubscribe()
+	require.NoError(t, lidar.StopScan())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Greater(t, packets, 0)
+	assert.Greater(t, scans, 0)
+	assert.False(t, lidar.packetSubscribers.active())
+}
+
+func TestSubscriberOverflow(t *testing.T) {
+	var s subscribers[int]
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	var got []int
+	done := make(chan struct{})
+	sub := s.add(func(v int) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		got = append(got, v)
+		if v == 5 {
+			close(done)
+		}
+	}, []SubscribeOption{SubscribeBuffer(2, DropOldest)})
+
+	// The first value is taken by the blocked callback, the queue keeps the last two.
+	require.True(t, s.publish(1, nil))
+	<-started
+	for v := 2; v <= 5; v++ {
+		require.True(t, s.publish(v, nil))
+	}
+	close(release)
+	<-done
+	assert.Equal(t, []int{1, 4, 5}, got)
+	assert.Equal(t, uint64(2), sub.Dropped())
+}
+
+func TestSubscribeRate(t *testing.T) {
+	var s subscribers[int]
+	got := make(chan int, 100)
+	limited := s.add(func(v int) { got <- v }, []SubscribeOption{SubscribeRate(10)})
+	all := make(chan int, 100)
+	s.add(func(v int) { all <- v }, nil)
+
+	for v := 1; v <= 10; v++ {
+		require.True(t, s.publish(v, nil))
+	}
+	time.Sleep(110 * time.Millisecond)
+	require.True(t, s.publish(11, nil))
+
+	assert.Equal(t, 1, <-got)
+	assert.Equal(t, 11, <-got)
+	assert.Equal(t, uint64(9), limited.Skipped())
+	assert.Zero(t, limited.Dropped())
+	for v := 1; v <= 11; v++ {
+		assert.Equal(t, v, <-all)
+	}
+}
+
+func TestSubscribeRateSchedule(t *testing.T) {
+	sub := &subscriber[int]{interval: 200 * time.Millisecond}
+	start := time.Now()
+	at := func(ms int) bool { return sub.allow(start.Add(time.Duration(ms) * time.Millisecond)) }
+
+	// Arrivals every 100ms with jitter, delivered at 5 per second.
+	var delivered []int
+	for _, ms := range []int{0, 101, 199, 299, 402, 499, 601, 698, 800, 902} {
+		if at(ms) {
+			delivered = append(delivered, ms)
+		}
+	}
+	assert.Equal(t, []int{0, 299, 402, 601, 800}, delivered)
+
+	// After a pause the schedule restarts instead of catching up.
+	assert.True(t, at(5000))
+	assert.False(t, at(5100))
+	assert.True(t, at(5200))
+}
+
+func TestStream(t *testing.T) {
+	var s subscribers[int]
+	stream := s.stream([]SubscribeOption{SubscribeBuffer(1, Block)})
+	published := make(chan bool)
+	go func() {
+		for v := 1; v <= 3; v++ {
+			if !s.publish(v, nil) {
+				break
+			}
+		}
+		published <- true
+	}()
+
+	assert.Equal(t, 1, <-stream.C)
+	v, ok := stream.Next()
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+
+	// The publisher blocked on the full queue lets go, the channel is closed.
+	time.Sleep(10 * time.Millisecond)
+	stream.Unsubscribe()
+	<-published
+	for range stream.C {
+	}
+	_, ok = stream.Next()
+	assert.False(t, ok)
+	assert.False(t, s.active())
+	require.True(t, s.publish(4, nil))
+}
+
+func TestPacketStream(t *testing.T) {
+	port := &fakePort{}
+	lidar := NewLidar(port)
+	port.queue(scanResponseHeader...)
+	port.refill = revolutionBytes
+	packets := lidar.PacketStream()
+	scans := lidar.ScanStream()
+
+	require.NoError(t, lidar.StartScan())
+	go func() {
+		for range lidar.Packets {
+		}
+	}()
+	packet, ok := packets.Next()
+	require.True(t, ok)
+	assert.Equal(t, 1000.0, packet.Distances[0])
+	select {
+	case scan := <-scans.C:
+		assert.Len(t, scan.Points, 480)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no revolution on the scan stream")
+	}
+	packets.Unsubscribe()
+	scans.Unsubscribe()
+	require.NoError(t, lidar.StopScan())
+}
+
+// BenchmarkDelivery compares the delivery of packets to a callback, a channel and Next, per
+// packet published: the throughput in ns/op and the mean latency from the publication to the
+// receipt.
+func BenchmarkDelivery(b *testing.B) {
+	receive := map[string]func(*subscribers[Packet], func(Packet)) *Subscription{
+		"Callback": func(s *subscribers[Packet], got func(Packet)) *Subscription {
+			return s.add(got, []SubscribeOption{SubscribeBuffer(defaultSubscriberBuffer, Block)})
+		},
+		"Channel": func(s *subscribers[Packet], got func(Packet)) *Subscription {
+			stream := s.stream([]SubscribeOption{SubscribeBuffer(defaultSubscriberBuffer, Block)})
+			go func() {
+				for packet := range stream.C {
+					got(packet)
+				}
+			}()
+			return stream.Subscription
+		},
+		"Iterator": func(s *subscribers[Packet], got func(Packet)) *Subscription {
+			stream := s.stream([]SubscribeOption{SubscribeBuffer(defaultSubscriberBuffer, Block)})
+			go func() {
+				for packet, ok := stream.Next(); ok; packet, ok = stream.Next() {
+					got(packet)
+				}
+			}()
+			return stream.Subscription
+		},
+	}
+	for _, name := range []string{"Callback", "Channel", "Iterator"} {
+		b.Run(name, func(b *testing.B) {
+			var s subscribers[Packet]
+			var latency time.Duration
+			var wg sync.WaitGroup
+			wg.Add(b.N)
+			sub := receive[name](&s, func(packet Packet) {
+				latency += time.Since(packet.Received)
+				wg.Done()
+			})
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s.publish(Packet{Received: time.Now()}, nil)
+			}
+			wg.Wait()
+			b.StopTimer()
+			b.ReportMetric(float64(latency.Nanoseconds())/float64(b.N), "ns-latency/op")
+			sub.Unsubscribe()
+		})
+	}
+}
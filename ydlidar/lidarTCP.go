@@ -0,0 +1,117 @@
+package ydlidar
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// tcpDialTimeout bounds the connection to a serial bridge.
+const tcpDialTimeout = 5 * time.Second
+
+// Connect connects to the lidar named by target and checks the device info and health:
+//
+//	tcp://host:port     a serial to TCP bridge such as ser2net in raw mode
+//	serial:///dev/ttyUSB0, /dev/ttyUSB0 or COM3
+//	                    a local serial port
+//	""                  auto-detects a local serial port
+//
+// Every transport has the same read timeout, and reconnects to the same target, see WithReconnect.
+func Connect(target string, opts ...Option) (*YDLidar, error) {
+	if target == "" {
+		return InitAndConnectToDevice(nil, opts...)
+	}
+	if !strings.Contains(target, "://") {
+		return InitAndConnectToDevice(&target, opts...)
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lidar address %q: %w", target, err)
+	}
+	switch u.Scheme {
+	case "serial":
+		path := u.Path
+		if u.Host != "" {
+			// serial://COM3
+			path = u.Host + u.Path
+		}
+		return InitAndConnectToDevice(&path, opts...)
+	case "tcp":
+		address := u.Host
+		open := func(*string) (Transport, error) { return DialTCP(address) }
+		port, err := open(nil)
+		if err != nil {
+			return nil, err
+		}
+		return initDevice(port, &address, open, opts)
+	}
+	return nil, fmt.Errorf("unsupported lidar address scheme %q, want tcp or serial", u.Scheme)
+}
+
+// DialTCP connects to a serial to TCP bridge. The line settings and modem lines belong to the
+// bridge: the motor has to be powered by it, motor control returns ErrUnsupportedByTransport.
+func DialTCP(address string) (Transport, error) {
+	conn, err := net.DialTimeout("tcp", address, tcpDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Connected to bridge: %v", address)
+	return &tcpPort{conn: conn}, nil
+}
+
+// tcpPort is a Transport over a TCP connection.
+type tcpPort struct {
+	conn        net.Conn
+	readTimeout time.Duration
+}
+
+// Read reads like a serial port: a read timing out returns 0 bytes and no error.
+func (p *tcpPort) Read(b []byte) (int, error) {
+	deadline := time.Time{}
+	if p.readTimeout > 0 {
+		deadline = time.Now().Add(p.readTimeout)
+	}
+	if err := p.conn.SetReadDeadline(deadline); err != nil {
+		return 0, err
+	}
+	n, err := p.conn.Read(b)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return n, nil
+	}
+	return n, err
+}
+
+func (p *tcpPort) Write(b []byte) (int, error) {
+	return p.conn.Write(b)
+}
+
+// ResetInputBuffer discards the bytes already received.
+func (p *tcpPort) ResetInputBuffer() error {
+	buf := make([]byte, 4096)
+	for {
+		if err := p.conn.SetReadDeadline(time.Now().Add(time.Millisecond)); err != nil {
+			return err
+		}
+		if _, err := p.conn.Read(buf); err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (p *tcpPort) SetReadTimeout(t time.Duration) error {
+	p.readTimeout = t
+	return nil
+}
+
+func (p *tcpPort) Close() error {
+	return p.conn.Close()
+}
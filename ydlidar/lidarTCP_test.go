@@ -0,0 +1,75 @@
+package ydlidar
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bridge accepts one connection on a local listener and returns the server side of it.
+func bridge(t *testing.T) (string, <-chan net.Conn) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	conns := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			conns <- conn
+		}
+	}()
+	return listener.Addr().String(), conns
+}
+
+func TestDialTCP(t *testing.T) {
+	address, conns := bridge(t)
+
+	port, err := DialTCP(address)
+	require.NoError(t, err)
+	defer port.Close()
+	server := <-conns
+	defer server.Close()
+
+	// A read timing out returns no bytes and no error, like a serial port.
+	require.NoError(t, port.SetReadTimeout(10*time.Millisecond))
+	buf := make([]byte, 8)
+	n, err := port.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	_, err = port.Write([]byte{preCommand, healthStatus})
+	require.NoError(t, err)
+	_, err = server.Read(buf[:2])
+	require.NoError(t, err)
+	assert.Equal(t, []byte{preCommand, healthStatus}, buf[:2])
+
+	_, err = server.Write([]byte{1, 2, 3})
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, port.(InputResetter).ResetInputBuffer())
+	n, err = port.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	_, err = server.Write([]byte{4, 5})
+	require.NoError(t, err)
+	require.NoError(t, port.SetReadTimeout(time.Second))
+	n, err = port.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{4, 5}, buf[:n])
+
+	_, ok := port.(DTRSetter)
+	assert.False(t, ok)
+}
+
+func TestConnectUnsupportedScheme(t *testing.T) {
+	_, err := Connect("udp://localhost:4001")
+	assert.ErrorContains(t, err, "unsupported")
+
+	_, err = Connect("tcp://127.0.0.1:1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,64 @@
+package ydlidar
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timeouts are the read timeouts of the transport for each kind of read. A read getting no
+// byte within its timeout fails with ErrTimeout, or counts as an empty read for the watchdog
+// while scanning.
+type Timeouts struct {
+	Info   time.Duration // Responses to the device info, health and other queries.
+	Header time.Duration // Scan packet headers, the granularity of the stall detection.
+	Sample time.Duration // Samples of a scan packet, which follow their header right away.
+}
+
+// defaultTimeouts are the timeouts unless set with WithTimeouts. The device answers queries
+// within a few milliseconds and sends a packet every few milliseconds while scanning.
+var defaultTimeouts = Timeouts{
+	Info:   300 * time.Millisecond,
+	Header: 250 * time.Millisecond,
+	Sample: 100 * time.Millisecond,
+}
+
+// WithTimeouts sets the read timeouts per operation. Zero fields keep their default: 300ms
+// for the info queries, 250ms for the scan packet headers and 100ms for their samples.
+func WithTimeouts(timeouts Timeouts) Option {
+	return func(lidar *YDLidar) {
+		if timeouts.Info > 0 {
+			lidar.timeouts.Info = timeouts.Info
+		}
+		if timeouts.Header > 0 {
+			lidar.timeouts.Header = timeouts.Header
+		}
+		if timeouts.Sample > 0 {
+			lidar.timeouts.Sample = timeouts.Sample
+		}
+	}
+}
+
+// setTimeout sets the read timeout of the transport, unless it is set already.
+func (lidar *YDLidar) setTimeout(timeout time.Duration) error {
+	if lidar.portTimeout == timeout {
+		return nil
+	}
+	if err := lidar.SerialPort.SetReadTimeout(timeout); err != nil {
+		return err
+	}
+	lidar.portTimeout = timeout
+	return nil
+}
+
+// readInfo reads the response to a query within the info timeout. A read getting no byte
+// returns ErrTimeout.
+func (lidar *YDLidar) readInfo(data []byte) (int, error) {
+	if err := lidar.setTimeout(lidar.timeouts.Info); err != nil {
+		return 0, err
+	}
+	n, err := lidar.read(data)
+	if n == 0 && err == nil && len(data) > 0 {
+		return 0, fmt.Errorf("%w: no response within %v", ErrTimeout, lidar.timeouts.Info)
+	}
+	return n, err
+}
@@ -0,0 +1,52 @@
+package ydlidar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timeoutPort records the read timeouts set on a fakePort.
+type timeoutPort struct {
+	*fakePort
+	set []time.Duration
+}
+
+func (p *timeoutPort) SetReadTimeout(t time.Duration) error {
+	p.set = append(p.set, t)
+	return nil
+}
+
+func TestInfoTimeout(t *testing.T) {
+	port := &timeoutPort{fakePort: &fakePort{}}
+	lidar := NewLidar(port, WithTimeouts(Timeouts{Info: 50 * time.Millisecond}))
+	assert.Equal(t, defaultTimeouts.Header, lidar.timeouts.Header)
+
+	_, err := lidar.DeviceInfo()
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.False(t, IsTransient(err))
+	_, err = lidar.HealthInfo()
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Equal(t, []time.Duration{50 * time.Millisecond}, port.set, "set once for both queries")
+}
+
+func TestScanTimeouts(t *testing.T) {
+	port := &timeoutPort{fakePort: &fakePort{}}
+	lidar := NewLidar(port, WithTimeouts(Timeouts{Header: 20 * time.Millisecond, Sample: 5 * time.Millisecond}))
+	port.queue(scanResponseHeader...)
+	// The samples arrive after their header, read with the sample timeout.
+	revolution := revolutionBytes()
+	port.queue(revolution[:scanPacketHeaderSize]...)
+	port.refill = func() []byte {
+		rest := revolution[scanPacketHeaderSize:]
+		revolution = nil
+		return rest
+	}
+	require.NoError(t, lidar.StartScan())
+	<-lidar.Packets
+	require.NoError(t, lidar.StopScan())
+
+	assert.Equal(t, []time.Duration{defaultTimeouts.Info, 20 * time.Millisecond, 5 * time.Millisecond}, port.set[:3])
+}
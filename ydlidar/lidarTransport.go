@@ -0,0 +1,64 @@
+package ydlidar
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrUnsupportedByTransport is returned by commands the transport can't carry, eg. motor
+// control over a link without a DTR line.
+var ErrUnsupportedByTransport = errors.New("ydlidar: command not supported by this transport")
+
+// Transport is the byte link to the lidar: a local serial port, a TCP bridge, an SPI or CAN
+// gateway or a test double. A serial.Port is a Transport.
+//
+// Read returns 0 bytes and no error when the read timeout expires, like a serial port.
+type Transport interface {
+	io.Reader
+	io.Writer
+	SetReadTimeout(t time.Duration) error
+	Close() error
+}
+
+// DTRSetter is implemented by transports driving the DTR line, which enables the motor on
+// most models. Motor control returns ErrUnsupportedByTransport without it.
+type DTRSetter interface {
+	SetDTR(dtr bool) error
+}
+
+// InputResetter is implemented by transports that can discard the bytes already received.
+// Without it stale bytes are left to the scan loop to skip.
+type InputResetter interface {
+	ResetInputBuffer() error
+}
+
+// OutputResetter is implemented by transports that can discard the bytes not yet sent.
+type OutputResetter interface {
+	ResetOutputBuffer() error
+}
+
+// setDTR drives the DTR line, if the transport has one.
+func (lidar *YDLidar) setDTR(dtr bool) error {
+	setter, ok := lidar.SerialPort.(DTRSetter)
+	if !ok {
+		return ErrUnsupportedByTransport
+	}
+	return setter.SetDTR(dtr)
+}
+
+// resetInput discards the bytes already received, if the transport supports it.
+func (lidar *YDLidar) resetInput() error {
+	if resetter, ok := lidar.SerialPort.(InputResetter); ok {
+		return resetter.ResetInputBuffer()
+	}
+	return nil
+}
+
+// resetOutput discards the bytes not yet sent, if the transport supports it.
+func (lidar *YDLidar) resetOutput() error {
+	if resetter, ok := lidar.SerialPort.(OutputResetter); ok {
+		return resetter.ResetOutputBuffer()
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package ydlidar
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pipeTransport is a bare Transport without any of the optional interfaces, answering the
+// device info command.
+type pipeTransport struct {
+	input   bytes.Buffer
+	written bytes.Buffer
+}
+
+func (p *pipeTransport) Read(b []byte) (int, error) {
+	if p.input.Len() == 0 {
+		return 0, nil
+	}
+	return p.input.Read(b)
+}
+
+func (p *pipeTransport) Write(b []byte) (int, error) {
+	if len(b) == 2 && b[1] == deviceInfo {
+		p.input.Write(deviceInfoResponse)
+	}
+	return p.written.Write(b)
+}
+
+func (p *pipeTransport) SetReadTimeout(time.Duration) error { return nil }
+func (p *pipeTransport) Close() error                       { return nil }
+
+func TestBareTransport(t *testing.T) {
+	transport := &pipeTransport{}
+	lidar := NewLidar(transport)
+	lidar.bootTime = 0
+
+	assert.ErrorIs(t, lidar.StartMotor(), ErrUnsupportedByTransport)
+	assert.ErrorIs(t, lidar.StopMotor(), ErrUnsupportedByTransport)
+
+	// Buffer resets are skipped without the optional interfaces.
+	assert.NoError(t, lidar.resetInput())
+	assert.NoError(t, lidar.resetOutput())
+
+	assert.NoError(t, lidar.Reboot())
+	assert.Equal(t, []byte{preCommand, restartDevice, preCommand, deviceInfo}, transport.written.Bytes())
+}
+
+func TestSerialTransport(t *testing.T) {
+	lidar := NewLidar(&fakePort{})
+
+	assert.NoError(t, lidar.StartMotor())
+	assert.NoError(t, lidar.StopMotor())
+}
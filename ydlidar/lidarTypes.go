@@ -1,12 +1,85 @@
 package ydlidar
 
-import "go.bug.st/serial"
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ydlidarg2/ydlidar/protocol"
+)
 
 // YDLidar is the lidar object.
 type YDLidar struct {
-	SerialPort serial.Port
-	Packets    chan Packet
-	Stop       chan struct{}
+	SerialPort   Transport
+	Packets      chan Packet
+	Stop         chan struct{}     // Closed by StopScan, replaced by StartScan. Receive only.
+	Status       chan StatusEvent  // Connection events, sent without blocking the scan loop.
+	Scans        chan Scan         // Assembled revolutions, nil unless enabled with WithScans.
+	CompactScans chan CompactScan  // Assembled revolutions in the compact layout, nil unless WithSmallProfile.
+	Aux          chan AuxEvent     // Decoded auxiliary packets found in the scan stream, nil unless WithAuxPackets.
+	Diagnostics  chan RawPacket    // Unrecognized packets with their raw bytes, nil unless WithAuxPackets.
+	Health       chan HealthStatus // Periodic health reports, nil unless enabled with WithHealthMonitor.
+	RawFrames    chan []byte       // Scan packets as read from the device, nil unless enabled with WithRawFrames.
+	Aligned      chan AlignedScan  // Revolutions aligned to a trigger, nil unless enabled with WithPhaseLock.
+
+	portName   *string                          // Port passed at connect time, nil means auto-detect.
+	openPort   func(*string) (Transport, error) // Opens the port, openSerial unless connected over TCP or testing.
+	openRetry  openRetry                        // Retries of a busy port when connecting, see WithOpenRetry.
+	reconnect  reconnectConfig                  // Watchdog and reconnect settings.
+	recovery   recoveryConfig                   // Health recovery settings.
+	limits     packetLimits                     // Built in range, angle and intensity filters.
+	angleRange atomic.Pointer[angleMask]        // Kept angles set with SetAngleRange, nil keeps all.
+	filters    []Filter                         // Run on every packet before it is sent, skipped while degraded.
+	processors []ScanProcessor                  // Run on every assembled revolution before it is sent.
+	partial    PartialScanPolicy                // What happens to the unfinished revolution on stop.
+	scanOrder  ScanOrder                        // Order of the points of the assembled revolutions.
+	overflow   OverflowPolicy                   // What happens to packets when the Packets channel is full.
+	rawTap     io.Writer                        // Receives a copy of the scan packets, see WithRawTap.
+
+	packetSubscribers subscribers[Packet] // Registered with OnPacket.
+	scanSubscribers   subscribers[Scan]   // Registered with OnScan.
+	latest            latestScan          // Last completed revolution, see WithLatestScan.
+	model             byte                // Model number from the last DeviceInfo, 0 if unknown.
+	decoder           SampleDecoder       // Sample layout, selected by DeviceInfo unless fixedDecoder.
+	quirks            FirmwareQuirks      // Protocol differences of the firmware, selected by DeviceInfo.
+	units             Unit                // Distance unit of the packets and scans.
+	invalidValue      InvalidValue        // Distance of the samples without a return on delivery.
+	saturation        SaturationPolicy    // What happens to the samples beyond the rated range.
+	fixedDecoder      bool                // The decoder was set with WithSampleDecoder.
+	stride            SampleDecoder       // Sample layout detected by the scan loop, overrides decoder until DeviceInfo.
+	rangingRate       atomic.Int32        // Ranging frequency in kHz last reported by the device, 0 if unknown.
+	load              loadMonitor         // Scheduling latency detection, see WithLoadShedding.
+	frequency         frequencyMonitor    // Rotation rate measurement, see WithFrequencyAlert.
+	stageObserver     StageObserver       // Receives the stage timings, a LatencyHistogram by default.
+	phaseLock         phaseLockConfig     // Trigger of the Aligned channel, see WithPhaseLock.
+	degradation       *linkDegradation    // Checksum failure handling, nil unless enabled with WithLinkDegradation.
+
+	buffers   *bufferPool // Read buffers of the scan loop, shared by all lidars unless given a dedicated decoder.
+	ahead     readAhead   // Reads of the scan loop.
+	small     bool        // Memory constrained profile, see WithSmallProfile.
+	pinThread bool        // Lock the scan loop to its own OS thread.
+
+	checksumFailures atomic.Uint64 // Scan packets dropped by checkScanPacket.
+	metrics          metrics       // Counters exposed by Metrics.
+	link             linkCounters  // Counters exposed by Stats.
+
+	timeouts     Timeouts       // Read timeouts per operation, see WithTimeouts.
+	portTimeout  time.Duration  // Read timeout last set on the transport, 0 if unknown.
+	healthPeriod time.Duration  // Time between health queries.
+	bootTime     time.Duration  // Longest the device takes to come back from a soft reboot.
+	quit         chan struct{}  // Closed by Close to stop the background monitors.
+	monitors     sync.WaitGroup // Background monitors, waited for by Close.
+	closeOnce    sync.Once
+	closeErr     error // Result of the first Close.
+
+	commands commandQueue // Commands waiting for the port, see command.
+	portMu   sync.Mutex   // Held by the commands and by StartScan and StopScan while they use the port.
+
+	mu    sync.Mutex    // Guards state and done.
+	state scanState     // Current position in the scan lifecycle.
+	done  chan struct{} // Closed when the running scan loop exits.
 }
 
 // Models Each model has a different set of commands
@@ -37,79 +110,120 @@ type G4 struct {
 type G6 struct {
 }
 
+// Protocol constants, see package protocol.
 const (
 	// preCommand is the command to send before sending any other command.
-	preCommand = 0xA5
+	preCommand = protocol.CommandPrefix
 
 	// healthStatus is the command to get the health status.
-	healthStatus = 0x92
+	healthStatus = protocol.CmdHealth
 
 	// deviceInfo is the command to get the device information.
-	deviceInfo = 0x90
+	deviceInfo = protocol.CmdDeviceInfo
 
 	// resetDevice is the command to reset the device.
-	restartDevice = 0x40
+	restartDevice = protocol.CmdRestart
 
 	// stopScanning is the command to stop scanning.
-	stopScanning = 0x65
+	stopScanning = protocol.CmdStopScan
 
 	// startScanning is the command to start scanning.
-	startScanning = 0x60
+	startScanning = protocol.CmdStartScan
+
+	// getScanFrequency is the command to get the scan frequency.
+	getScanFrequency = protocol.CmdGetScanFrequency
+
+	// increaseFrequencyLarge is the command to increase the scan frequency by 1Hz.
+	increaseFrequencyLarge = protocol.CmdIncreaseFrequencyLarge
+
+	// decreaseFrequencyLarge is the command to decrease the scan frequency by 1Hz.
+	decreaseFrequencyLarge = protocol.CmdDecreaseFrequencyLarge
+
+	// increaseFrequencySmall is the command to increase the scan frequency by 0.1Hz.
+	increaseFrequencySmall = protocol.CmdIncreaseFrequencySmall
+
+	// decreaseFrequencySmall is the command to decrease the scan frequency by 0.1Hz.
+	decreaseFrequencySmall = protocol.CmdDecreaseFrequencySmall
 
 	// HealthTypeCode is the device response Health HealthInfo type code.
-	HealthTypeCode = 0x06
+	HealthTypeCode = protocol.TypeHealth
 
 	// InfoTypeCode is the device response Device Information type code.
-	InfoTypeCode = 0x04
+	InfoTypeCode = protocol.TypeDeviceInfo
 
 	// ScanTypeCode is the device response Scan Command type code.
-	ScanTypeCode = 0x81
+	ScanTypeCode = protocol.TypeScan
 
-	SingleResponse     = 0x0
-	ContinuousResponse = 0x1
+	SingleResponse     = protocol.ModeSingle
+	ContinuousResponse = protocol.ModeContinuous
 )
 
 // PointCloudData represents a single lidar reading.
 type PointCloudData struct {
 	Intensity int
-	Dist      float32
-	Angle     float32
+	Dist      float64 // Distance in the unit of the packet or scan, millimeters by default.
+	Angle     float64 // Angle in degrees.
+	Synthetic bool    // Interpolated over a dropout rather than measured.
+	Saturated bool    // Beyond the rated range of the model, see WithSaturation.
 }
 
 // Packet represents struct of a single sample set of readings as translated by this application
 type Packet struct {
-	FirstAngle         float32   // First/Minimum angle corresponds to first distance sample.
-	LastAngle          float32   // Last/Max angle corresponds to last distance sample.
-	DeltaAngle         float32   // Delta between Min and Max Angles.
+	FirstAngle         float64   // First/Minimum angle corresponds to first distance sample.
+	LastAngle          float64   // Last/Max angle corresponds to last distance sample.
+	DeltaAngle         float64   // Delta between Min and Max Angles.
 	NumDistanceSamples int       // Number of distance samples.
-	Distances          []float32 // Slice containing distance data.
+	Distances          []float64 // Slice containing distance data.
 	Intensities        []int     // Slice containing intensity data.
 	PacketType         uint8     // Indicates the current packet type. 0x00: Point cloud packet 0x01: Zero packet.
-	Angles             []float32 // Slice containing angle data.
+	Angles             []float64 // Slice containing angle data.
 	Error              error     // Error if any.
+	Synthetic          []bool    // Samples interpolated over a dropout, nil if none were.
+	Saturated          []bool    // Samples beyond the rated range of the model, nil if none were.
+	Units              Unit      // Unit of the distances.
+	Received           time.Time // Arrival of the packet header on the transport.
+	StartAngle         float64   // Raw start angle (FSA) of the header in degrees, before the angle correction.
+	EndAngle           float64   // Raw end angle (LSA) of the header in degrees, before the angle correction.
+	Frequency          float64   // Scan frequency in Hz reported by the last zero packet, 0 before the first one.
+	IsZeroStart        bool      // First packet of a revolution, right after the zero packet.
+	Invalid            float64   // Distance of the samples without a return, see WithInvalidValue.
+}
+
+// Scan is one revolution of the lidar, assembled from the packets received between two zero packets.
+type Scan struct {
+	Seq          uint64           // Revolution number since the scan started, starting at 1.
+	Points       []PointCloudData // Points in the order they were received, or by angle, see WithScanOrder.
+	Start        time.Time        // Arrival of the zero packet that started the revolution.
+	End          time.Time        // Arrival of the last packet of the revolution.
+	Partial      bool             // The scan was stopped before the revolution completed.
+	Labels       []string         // Per point class labels set by a ScanProcessor, nil if none ran.
+	Reflectivity []float64        // Per point normalized reflectivity set by a calibration stage, nil if none ran.
+	Frequency    float64          // Rotation rate in Hz measured over the revolution, 0 for a partial revolution.
+	Stats        ScanStats        // Quality statistics of the revolution, before any ScanProcessor ran.
+	Units        Unit             // Unit of the distances.
+	Invalid      float64          // Distance of the points without a return, see WithInvalidValue.
 }
 
 // DeviceInfo Works with G2
 // DeviceInfo contains the device model, firmware, hardware, and serial number.
 type DeviceInfo struct {
-	Model    byte     // Model number.
-	Firmware [2]byte  // Firmware version.
-	Hardware byte     // Hardware version.
-	Serial   [16]byte // Serial number.
+	Model         byte   // Model number.
+	ModelName     string // Model name, eg. G2.
+	FirmwareMajor byte   // Firmware major version.
+	FirmwareMinor byte   // Firmware minor version.
+	Hardware      byte   // Hardware version.
+	SerialNumber  string // Serial number.
 }
 
-// DeviceInfoString Works with G2
-// DeviceInfoString contains the device model, firmware, hardware, and serial number.
-type DeviceInfoString struct {
-	Model    string // Model number.
-	Firmware string // Firmware version.
-	Hardware string // Hardware version.
-	Serial   string // Serial number.
+// String returns the device info for display.
+func (info DeviceInfo) String() string {
+	return fmt.Sprintf("Device Info: Model: %v Hardware Version: %v Firmware Version: %v.%v Serial Number: %v",
+		info.ModelName, info.Hardware, info.FirmwareMajor, info.FirmwareMinor, info.SerialNumber)
 }
 
 // pointCloudHeader is the preamble for the point cloud data from the lidar
 type pointCloudHeader struct {
-	// PacketHeader 2B in length, fixed at 0x55AA, low in front, high in back
+	// PacketHeader 2B in length, fixed at 0x55AA, 0x66AA for the T-mini, low in front, high in back
 	// PH(2B)
 	PacketHeader uint16
 
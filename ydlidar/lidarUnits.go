@@ -0,0 +1,65 @@
+package ydlidar
+
+import "fmt"
+
+// Unit is the distance unit of the packets and scans, see WithUnits.
+type Unit int
+
+const (
+	// Millimeters is the unit the device measures in. This is the default.
+	Millimeters Unit = iota
+
+	// Meters matches ROS and most robotics and math libraries.
+	Meters
+)
+
+// String returns the symbol of the unit.
+func (u Unit) String() string {
+	switch u {
+	case Millimeters:
+		return "mm"
+	case Meters:
+		return "m"
+	}
+	return fmt.Sprintf("Unit(%d)", int(u))
+}
+
+// FromMillimeters converts a distance in millimeters to the unit.
+func (u Unit) FromMillimeters(mm float64) float64 {
+	if u == Meters {
+		return mm / 1000
+	}
+	return mm
+}
+
+// ToMillimeters converts a distance in the unit to millimeters.
+func (u Unit) ToMillimeters(d float64) float64 {
+	if u == Meters {
+		return d * 1000
+	}
+	return d
+}
+
+// WithUnits sets the distance unit of the packets, the scans and everything derived from
+// them, Millimeters by default. The distances given to the other options, eg. WithRangeLimits,
+// are in this unit too.
+func WithUnits(unit Unit) Option {
+	return func(lidar *YDLidar) {
+		lidar.units = unit
+	}
+}
+
+// toUnits converts the distances decoded in millimeters to the distance unit of the lidar.
+func (lidar *YDLidar) toUnits(distances []float64) {
+	lidar.units.fromMillimeters(distances)
+}
+
+// fromMillimeters converts the distances in millimeters to the unit in place.
+func (u Unit) fromMillimeters(distances []float64) {
+	if u == Millimeters {
+		return
+	}
+	for i, d := range distances {
+		distances[i] = u.FromMillimeters(d)
+	}
+}
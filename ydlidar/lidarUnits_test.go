@@ -0,0 +1,57 @@
+package ydlidar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitConversion(t *testing.T) {
+	assert.Equal(t, 1.5, Meters.FromMillimeters(1500))
+	assert.Equal(t, 1500.0, Meters.ToMillimeters(1.5))
+	assert.Equal(t, 1500.0, Millimeters.FromMillimeters(1500))
+	assert.Equal(t, "m", Meters.String())
+}
+
+func TestScanInMeters(t *testing.T) {
+	revolution := revolutionBytes()
+	port := &fakePort{refill: func() []byte { return revolution }}
+	lidar := NewLidar(port, WithUnits(Meters), WithScans(1), WithRangeLimits(0.5, 2))
+
+	port.queue(scanResponseHeader...)
+	require.NoError(t, lidar.StartScan())
+	defer lidar.StopScan()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case packet := <-lidar.Packets:
+			assert.Equal(t, Meters, packet.Units)
+		case scan := <-lidar.Scans:
+			assert.Equal(t, Meters, scan.Units)
+			require.NotEmpty(t, scan.Points)
+			// 1000mm samples, inside the range limits given in meters.
+			for _, point := range scan.Points {
+				assert.Equal(t, 1.0, point.Dist)
+			}
+			assert.Equal(t, 1.0, scan.Stats.MeanRange)
+			return
+		case <-timeout:
+			t.Fatal("no revolution")
+		}
+	}
+}
+
+func TestCompactScanUnits(t *testing.T) {
+	a := &compactAssembler{units: Meters}
+	a.startRevolution()
+	a.add(Packet{Angles: []float64{1}, Distances: []float64{1.25}, Intensities: []int{7}})
+	scan, ok := a.startRevolution()
+	require.True(t, ok)
+
+	// Stored in millimeters, returned in meters.
+	assert.Equal(t, []uint16{1250}, scan.Distances)
+	assert.Equal(t, 1.25, scan.Point(0).Dist)
+}
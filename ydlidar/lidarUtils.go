@@ -2,159 +2,193 @@
 package ydlidar
 
 import (
-	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"go.bug.st/serial"
 	"log"
 	"math"
 	"os"
 	"os/signal"
+	"runtime"
+	"strings"
 	"syscall"
 	"time"
+
+	"ydlidarg2/ydlidar/protocol"
 )
 
-var scanPacketHeaderSize = 10
+// scanPacketHeaderSize is the size of the scan packet header.
+const scanPacketHeaderSize = protocol.ScanPacketHeaderSize
+
+// scanPacketHeader is the PH field starting every scan packet, 0xAA 0x55 on the wire.
+const scanPacketHeader = protocol.ScanPacketHeader
 
 // NewLidar returns a YDLidar object.
-func NewLidar(devicePort serial.Port) *YDLidar {
-	return &YDLidar{
+func NewLidar(devicePort Transport, opts ...Option) *YDLidar {
+	lidar := &YDLidar{
 		SerialPort: devicePort,
 		Packets:    make(chan Packet),
 		Stop:       make(chan struct{}),
-	}
+		Status:     make(chan StatusEvent, statusBufferSize),
+		quit:       make(chan struct{}),
+		openPort:   openSerial,
+		buffers:    sharedBuffers,
+		ahead:      readAhead{size: bulkReadSize},
+		reconnect: reconnectConfig{
+			backoff:  time.Second,
+			timeouts: defaultWatchdogTimeouts,
+		},
+		bootTime:      defaultBootTime,
+		timeouts:      defaultTimeouts,
+		stageObserver: &LatencyHistogram{},
+	}
+	for _, opt := range opts {
+		opt(lidar)
+	}
+	if err := lidar.checkOptions(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if lidar.small {
+		lidar.applySmallProfile()
+	}
+	if lidar.Health != nil {
+		lidar.monitors.Add(1)
+		go lidar.monitorHealth()
+	}
+	if lidar.degradation != nil {
+		lidar.monitors.Add(1)
+		go lidar.monitorLink()
+	}
+	lidar.startPhaseLock()
+	return lidar
 }
 
-func InitAndConnectToDevice(port *string) (*YDLidar, error) {
-	var devicePort serial.Port
-	var err error
-
-	devicePort, err = GetSerialPort(port)
+// InitAndConnectToDevice opens the serial port, nil auto-detects it, and checks the device info and health.
+func InitAndConnectToDevice(port *string, opts ...Option) (*YDLidar, error) {
+	// The lidar is created on the opened port, read the retries from the options first.
+	var settings YDLidar
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	if err := settings.checkOptions(); err != nil {
+		return nil, err
+	}
+	devicePort, err := settings.openRetry.open(func() (serial.Port, error) { return GetSerialPort(port) })
 	if err != nil {
 		return nil, err
 	}
 
-	err = devicePort.SetReadTimeout(1000 * time.Millisecond)
-	if err != nil {
+	return initDevice(devicePort, port, openSerial, opts)
+}
+
+// ConnectTransport checks the device info and health of the lidar on an opened transport, eg.
+// a simulated device or a gateway without a dialer in this package. The transport can't be
+// re-opened, every reconnection attempt of WithReconnect fails.
+func ConnectTransport(port Transport, opts ...Option) (*YDLidar, error) {
+	var settings YDLidar
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	if err := settings.checkOptions(); err != nil {
 		return nil, err
 	}
+	open := func(*string) (Transport, error) { return nil, ErrUnsupportedByTransport }
+	return initDevice(port, nil, open, opts)
+}
 
-	lidar := NewLidar(devicePort)
-	lidar.SetupCloseHandler()
+// initDevice creates the lidar on the opened port, purges the stale bytes and checks the
+// device info and health. open re-opens the port named portName when reconnecting.
+func initDevice(devicePort Transport, portName *string, open func(*string) (Transport, error), opts []Option) (*YDLidar, error) {
+	lidar := NewLidar(devicePort, opts...)
+	lidar.portName = portName
+	lidar.openPort = open
+	if err := lidar.setTimeout(lidar.timeouts.Info); err != nil {
+		return nil, err
+	}
 
 	time.Sleep(time.Millisecond * 100)
 
-	deviceInfo, err := lidar.DeviceInfo()
+	// Bytes left by a previous session are purged, the device info answering proves the
+	// link is in step.
+	var deviceInfo *DeviceInfo
+	err := lidar.command(func() (err error) {
+		deviceInfo, err = lidar.connectLink()
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	log.Printf(*deviceInfo)
+	log.Print(deviceInfo)
 
-	healthStatus, err := lidar.HealthInfo()
+	health, err := lidar.HealthInfo()
 	if err != nil {
 		return nil, err
 	}
-	log.Printf(*healthStatus)
+	log.Printf("Health Info: %v", health)
 
 	return lidar, nil
 }
 
-// GetSerialPort returns a real serial port connection.
+// GetSerialPort opens the lidar serial port, nil auto-detects it among the USB serial ports
+// of the platform. DTR is raised, which starts the motor.
 func GetSerialPort(ttyPort *string) (serial.Port, error) {
-
-	// use ttyPort if not nil
 	if ttyPort != nil {
-
-		mode := &serial.Mode{
-			BaudRate: 230400,          // 230400 baud
-			DataBits: 8,               // 8 data bits
-			Parity:   serial.NoParity, // No parity
-			StopBits: 0,               // 0 == 1 stop bit
-		}
-
-		currentPort, err := serial.Open(*ttyPort, mode)
-		if err != nil {
-			return nil, err
-		}
-
-		//err = currentPort.SetDTR(true)
-		//if err != nil {
-		//	return nil, err
-		//}
-
-		log.Printf("Connected to port: %v", ttyPort)
-
-		return currentPort, nil
+		return openSerialPort(*ttyPort)
 	}
-	// else iterate over ports to get the correct one
+
 	ports, err := serial.GetPortsList()
 	if err != nil {
-		log.Panic(err)
-	}
-
-	if len(ports) == 0 {
-		log.Panic(fmt.Errorf("no serial ports found"))
-	}
-
-	mode := &serial.Mode{
-		BaudRate: 230400,          // 230400 baud
-		DataBits: 8,               // 8 data bits
-		Parity:   serial.NoParity, // No parity
-		StopBits: 0,               // 0 == 1 stop bit
+		return nil, err
 	}
-
-	// use last port TODO: make this more robust, its hacky and not guaranteed to work
-	port := ports[len(ports)-1]
-	log.Printf("Using port: %s", port)
-
-	currentPort, err := serial.Open(port, mode)
-
-	err = currentPort.SetDTR(true)
+	port, err := selectPort(ports, candidatePort)
 	if err != nil {
 		return nil, err
 	}
-
-	log.Print("Connected to port: ", currentPort)
-
-	return currentPort, nil
-
-	return nil, nil
+	log.Printf("Using port: %s", port)
+	return openSerialPort(port)
 }
 
-// SetupCloseHandler creates a 'listener' on a new goroutine which will notify the
-// program if it receives an interrupt from the OS. We then handle this by calling
-// our clean-up procedure and exiting the program.
+// SetupCloseHandler closes the lidar when the program receives an interrupt or SIGTERM.
+// It is opt-in, applications with their own signal handling call Close from it instead.
+// The program keeps running: once the lidar is closed the signals get their default
+// behavior back, so a second interrupt terminates it.
 func (lidar *YDLidar) SetupCloseHandler() {
-	c := make(chan os.Signal)
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
-		<-c
-		log.Println("Ctrl+C pressed in Terminal")
-		err := lidar.StopScan()
-		if err != nil {
+		defer signal.Stop(c)
+		select {
+		case <-c:
+		case <-lidar.quit:
 			return
 		}
-
-		err = lidar.Close()
-		if err != nil {
-			return
+		log.Println("Interrupted, closing the lidar")
+		if err := lidar.Close(); err != nil {
+			log.Printf("Error closing the lidar: %v", err)
 		}
-
-		os.Exit(0)
-
 	}()
 }
 
 // SetDTR enables the DTR control for serial which controls the motor enable function.
 func (lidar *YDLidar) SetDTR(s bool) {
-	err := lidar.SerialPort.SetDTR(s)
+	err := lidar.setDTR(s)
 	if err != nil {
 		return
 	}
 }
 
-// DeviceInfo returns the version information.
-func (lidar *YDLidar) DeviceInfo() (*string, error) {
+// DeviceInfo returns the version information. A running scan is paused meanwhile.
+func (lidar *YDLidar) DeviceInfo() (info *DeviceInfo, err error) {
+	err = lidar.command(func() error {
+		info, err = lidar.deviceInfo()
+		return err
+	})
+	return info, err
+}
+
+// deviceInfo sends the device info command and decodes the response.
+func (lidar *YDLidar) deviceInfo() (*DeviceInfo, error) {
 	if _, err := lidar.SerialPort.Write([]byte{preCommand, deviceInfo}); err != nil {
 		return nil, err
 	}
@@ -165,48 +199,76 @@ func (lidar *YDLidar) DeviceInfo() (*string, error) {
 	}
 
 	if typeCode != InfoTypeCode {
-		return nil, fmt.Errorf("invalid type code. Expected %x, got %v. Mode: %x", HealthTypeCode, typeCode, mode)
+		return nil, fmt.Errorf("%w: invalid type code. Expected %x, got %v. Mode: %x", ErrBadHeader, InfoTypeCode, typeCode, mode)
+	}
+	if sizeOfMessage < 20 {
+		return nil, fmt.Errorf("%w: device info message too short. Expected 20 bytes got %v", ErrShortRead, sizeOfMessage)
 	}
 
 	data := make([]byte, sizeOfMessage)
-	n, err := lidar.SerialPort.Read(data)
+	n, err := lidar.readInfo(data)
 
-	if byte(n) != sizeOfMessage {
-		return nil, fmt.Errorf("device Info: not enough bytes. Expected %v got %v", sizeOfMessage, n)
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read serial:%v", err)
+		return nil, fmt.Errorf("failed to read serial: %w", err)
+	}
+	if byte(n) != sizeOfMessage {
+		return nil, fmt.Errorf("%w: device info expected %v bytes got %v", ErrShortRead, sizeOfMessage, n)
 	}
 
-	deviceInfo := &DeviceInfo{}
+	info := newDeviceInfo(data)
+	if info.ModelName == "" {
+		return nil, fmt.Errorf("%w: model number %v", ErrUnsupportedModel, info.Model)
+	}
+	lidar.model = info.Model
+	lidar.quirks = quirksFor(info.Model, FirmwareVersion{Major: info.FirmwareMajor, Minor: info.FirmwareMinor})
+	if !lidar.fixedDecoder {
+		lidar.decoder = decoderFor(info.Model)
+	}
+	lidar.stride = nil
 
-	deviceInfo.Model = data[0]
-	copy(deviceInfo.Firmware[:], data[1:3])
-	deviceInfo.Hardware = data[3:4][0]
-	copy(deviceInfo.Serial[:], data[4:20])
+	return info, nil
+}
 
-	runes := make([]rune, len(deviceInfo.Serial))
-	for i, b := range deviceInfo.Serial {
-		runes[i] = rune(b + '0')
+// newDeviceInfo decodes the 20 byte device info response.
+func newDeviceInfo(data []byte) *DeviceInfo {
+	info := &DeviceInfo{
+		Model:         data[0],
+		ModelName:     models[data[0]].name,
+		FirmwareMajor: data[2],
+		FirmwareMinor: data[1],
+		Hardware:      data[3],
 	}
 
-	stringDeviceInfo := &DeviceInfoString{}
-
-	if deviceInfo.Model == 15 {
-		stringDeviceInfo.Model = "G2"
-		stringDeviceInfo.Firmware = fmt.Sprintf("%v.%v", deviceInfo.Firmware[0], deviceInfo.Firmware[1])
-		stringDeviceInfo.Hardware = fmt.Sprintf("%v", deviceInfo.Hardware)
-		info := fmt.Sprintf("Device Info: Model: %v Hardware Version: %v Firmware Version: %v Serial Number: %v\n", stringDeviceInfo.Model, stringDeviceInfo.Hardware, stringDeviceInfo.Firmware, string(runes))
-		return &info, nil
-	} else {
-		return nil, fmt.Errorf("unknown model: %v", deviceInfo.Model)
+	// Each serial number byte holds a single digit, printed in hex like the official SDK does.
+	var serialNumber strings.Builder
+	for _, b := range data[4:20] {
+		fmt.Fprintf(&serialNumber, "%X", b)
 	}
+	info.SerialNumber = serialNumber.String()
 
+	return info
 }
 
-// HealthInfo returns the lidar status. Returns nil if the lidar is operating optimally.
-func (lidar *YDLidar) HealthInfo() (*string, error) {
+// HealthInfo returns the report of the lidar status. A device reporting a warning or an error
+// returns its report along with a *HealthError. A running scan is paused meanwhile.
+func (lidar *YDLidar) HealthInfo() (HealthReport, error) {
+	var data []byte
+	err := lidar.command(func() (err error) {
+		data, err = lidar.readHealth()
+		return err
+	})
+	if err != nil {
+		return HealthReport{}, err
+	}
+	status := newHealthStatus(data)
+	if status.Severity != SeverityOK {
+		return status.Report, &HealthError{Code: status.Code, Severity: status.Severity}
+	}
+	return status.Report, nil
+}
 
+// readHealth sends the health command and returns the response: the status byte followed by the 2 byte error code.
+func (lidar *YDLidar) readHealth() ([]byte, error) {
 	if _, err := lidar.SerialPort.Write([]byte{preCommand, healthStatus}); err != nil {
 		return nil, err
 	}
@@ -217,74 +279,89 @@ func (lidar *YDLidar) HealthInfo() (*string, error) {
 	}
 
 	if typeCode != HealthTypeCode {
-		return nil, fmt.Errorf("invalid type code. Expected %x, got %v. Mode: %x", HealthTypeCode, typeCode, mode)
+		return nil, fmt.Errorf("%w: invalid type code. Expected %x, got %v. Mode: %x", ErrBadHeader, HealthTypeCode, typeCode, mode)
+	}
+	if sizeOfMessage < 3 {
+		return nil, fmt.Errorf("%w: health info message too short. Expected 3 bytes got %v", ErrShortRead, sizeOfMessage)
 	}
 
 	data := make([]byte, sizeOfMessage)
-	n, err := lidar.SerialPort.Read(data)
+	n, err := lidar.readInfo(data)
 
-	if byte(n) != sizeOfMessage {
-		return nil, fmt.Errorf("health Info: not enough bytes. Expected %v got %v", sizeOfMessage, n)
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read serial:%v", err)
-	}
-	if data[0] == 0x01 {
-		return nil, fmt.Errorf("device problem. Error Code:%x %x", data[1], data[2])
+		return nil, fmt.Errorf("failed to read serial: %w", err)
 	}
-	if data[0] == 0 {
-		healthInfo := "Health Info: Device is operating optimally"
-		return &healthInfo, nil
+	if byte(n) != sizeOfMessage {
+		return nil, fmt.Errorf("%w: health info expected %v bytes got %v", ErrShortRead, sizeOfMessage, n)
 	}
 
-	return nil, nil
+	return data, nil
 }
 
 // readInfoHeader reads and validate header response.
 func (lidar *YDLidar) readInfoHeader() (sizeOfMessage byte, typeCode byte, mode byte, err error) {
-	header := make([]byte, 7)
-	numBytesInHeader, err := lidar.SerialPort.Read(header)
-
+	header := make([]byte, responseHeaderSize)
+	numBytesInHeader, err := lidar.readInfo(header)
 	if err != nil {
 		return 0, 0, 0, err
 	}
 
-	if numBytesInHeader != 7 {
-		err = fmt.Errorf("read Header: not enough bytes reading header. Expected 7 bytes got %v", numBytesInHeader)
+	sizeOfMessage, typeCode, mode, err = parseInfoHeader(header[:numBytesInHeader])
+	if err != nil {
+		if numBytesInHeader < responseHeaderSize {
+			lidar.link.shortReads.Add(1)
+		}
 		return 0, 0, 0, err
 	}
-
-	startSign := int(header[1])<<8 | int(header[0])
-	if startSign != 0x5AA5 {
-		return 0, 0, 0, fmt.Errorf("invalid header. Expected startSign 0x5AA5 got %x", startSign)
-	}
-
-	// sizeOfMessage is the lower 6 bits of the 6th byte
-	sizeOfMessage = header[2] & 0x3F
+	lidar.link.frames.Add(1)
 	log.Printf("SIZE OF MESSAGE: %v", sizeOfMessage)
-
-	typeCode = header[6]
-
 	log.Printf("HEADER: %X", header)
 
-	// the last number is the position of the byte eg. "...& 0xC0 >> 6", the 6 means the 6th byte
-	responseMode := header[5] & 0xC0 >> 6
-	//log.Printf("mode: %v", mode)
-
-	return sizeOfMessage, typeCode, responseMode, nil
+	return sizeOfMessage, typeCode, mode, nil
 }
 
 // StartScan starts up the scanning and data acquisition.
-// see startScan for more details.
-func (lidar *YDLidar) StartScan() {
+// The scan command response is validated before returning, the samples are then
+// read on a new goroutine and sent on the Packets channel, see scanLoop for more details.
+func (lidar *YDLidar) StartScan() error {
+	lidar.portMu.Lock()
+	defer lidar.portMu.Unlock()
+	return lidar.startScan()
+}
 
-	// n is the number of bytes per scan sample for the YDLidar G4 (Check your lidar's datasheet)
-	n := 3
+// startScan starts scanning, the caller holds the port.
+func (lidar *YDLidar) startScan() error {
+	lidar.mu.Lock()
+	defer lidar.mu.Unlock()
+
+	switch lidar.state {
+	case stateScanning:
+		return ErrAlreadyScanning
+	case stateStopping:
+		return ErrStopping
+	}
 
+	if err := lidar.sendScanCommand(); err != nil {
+		return err
+	}
+
+	lidar.state = stateScanning
+	lidar.Stop = make(chan struct{})
+	lidar.done = make(chan struct{})
+	lidar.resetFrequency()
+	go lidar.scanLoop(lidar.done)
+	if lidar.load.enabled {
+		go lidar.monitorLoad(lidar.done)
+	}
+
+	return nil
+}
+
+// sendScanCommand sends the start scanning command and validates the device response header.
+func (lidar *YDLidar) sendScanCommand() error {
 	// Send start scanning command to device.
 	if _, err := lidar.SerialPort.Write([]byte{preCommand, startScanning}); err != nil {
-		lidar.sendErr(fmt.Errorf("failed to start scan: %v", err))
-		return
+		return fmt.Errorf("failed to start scan: %w", err)
 	}
 
 	/////////////////////////////////////////HEADER/////////////////////////////////////////////
@@ -292,254 +369,223 @@ func (lidar *YDLidar) StartScan() {
 	_, typeCode, responseMode, err := lidar.readInfoHeader()
 	switch {
 	case err != nil:
-		err = fmt.Errorf("read header failed: %v", err)
+		return fmt.Errorf("read header failed: %w", err)
 
 	case typeCode != ScanTypeCode: // 0x81
-		err = fmt.Errorf("invalid type code. Expected %x, got %X. Mode: %X", ScanTypeCode, typeCode, responseMode)
+		return fmt.Errorf("%w: invalid type code. Expected %x, got %X. Mode: %X", ErrBadHeader, ScanTypeCode, typeCode, responseMode)
 
 	case responseMode != ContinuousResponse: // 0x1
-		err = fmt.Errorf("expected continuous response mode, got %X", responseMode)
-	}
-
-	if typeCode == ScanTypeCode && responseMode == ContinuousResponse {
-		log.Print("Scan Command Response: GOOD")
-		cycles := 0
-		validFrames := 0
-		// Start loop to read distance samples.
-		for {
-			select {
-			default:
-				cycles++
-				log.Printf("revs: %v", cycles)
-
-				/////////////////////HEADER/////////////////////////////////////////////
-				var numHeaderBytesReceived int
-				var numSampleBytesReceived int
-
-				// The initial scan packet header is 10 bytes.
-				rawHeaderData := make([]byte, scanPacketHeaderSize)
-				numHeaderBytesReceived, err := lidar.SerialPort.Read(rawHeaderData)
-				if err != nil {
-					lidar.sendErr(fmt.Errorf("failed to read serial %v", err))
-				}
-
-				// if numSampleBytesReceived != 10, log the actual value
-				if numHeaderBytesReceived != scanPacketHeaderSize {
-					log.Printf("The lidar gave us %v in the header packet. Expected 10.", numHeaderBytesReceived)
-					log.Printf("The header packet is: %X ", rawHeaderData)
-					continue
-				}
-
-				pointCloud := pointCloudHeader{}
-				// Unpack the scan packet header into the pointCloudHeader struct.
-				if err = binary.Read(bytes.NewBuffer(rawHeaderData), binary.LittleEndian, &pointCloud); err != nil {
-					lidar.sendErr(fmt.Errorf("failed to pack struct: %v", err))
-					continue
-				}
-
-				// extract the pointCloud into a slice of bytes
+		return fmt.Errorf("%w: expected continuous response mode, got %X", ErrBadHeader, responseMode)
+	}
+	log.Print("Scan Command Response: GOOD")
 
-				// Returns scan data in a human readable format.
-				packetHeader, scanningFrequency, dataPacketType, sampleQuantityPackets := lidar.extractScanPacketHeader(pointCloud)
+	return nil
+}
 
-				log.Printf("Chars: %X", packetHeader)
+// scanLoop reads the point cloud packets until a value is received on the Stop channel.
+// done is closed once the loop has exited. The stream is read ahead into a StreamDecoder
+// which frames the packets, resynchronizing on the packet headers after noise.
+func (lidar *YDLidar) scanLoop(done chan struct{}) {
+	defer close(done)
+
+	if lidar.pinThread {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+	}
+
+	assembler := &scanAssembler{units: lidar.units, order: lidar.scanOrder, checksums: checksumCounter{total: &lidar.checksumFailures}}
+	defer lidar.flushPartialScan(assembler)
+	compact := &compactAssembler{units: lidar.units}
+
+	dec := lidar.streamDecoder()
+	saturationRange := lidar.saturationRange()
+
+	cycles := 0
+	validFrames := 0
+	// The last read and the one before, the header of a packet came with one of them.
+	var lastRead, previousRead time.Time
+	lastBytes := 0
+	watchdog := lidar.newWatchdog()
+	// Start loop to read distance samples.
+	for {
+		select {
+		case <-lidar.Stop:
+			return
+		default:
+		}
 
-				if packetHeader == 0 && scanningFrequency == 0 && dataPacketType == 0 && sampleQuantityPackets == 0 {
-					log.Printf("OTH PACKET, SKIPPING")
-					continue
+		frame, err := dec.nextFrame()
+		if errors.Is(err, ErrNeedMore) {
+			// Read more, a packet started within the sample timeout.
+			timeout := lidar.timeouts.Header
+			if dec.Buffered() > 0 {
+				timeout = lidar.timeouts.Sample
+			}
+			n, err := lidar.ahead.fill(lidar, dec, timeout)
+			if watchdog.expired(n, err) {
+				if !lidar.reconnectDevice(err) {
+					return
 				}
-
-				switch dataPacketType {
-				case 0x1:
-
-					//There is only one zero point of data in the zero start data packet. The sampleQuantityPackets is 1. We skip this packet.
-					log.Printf("ZERO START DATA PACKET")
-					if numSampleBytesReceived != scanPacketHeaderSize {
-						log.Printf("not enough bytes in header. Expected %v got %v", scanPacketHeaderSize, numSampleBytesReceived)
-					}
-					if sampleQuantityPackets != 1 {
-						log.Printf("sample quantity should be 1, got %v", sampleQuantityPackets)
-					}
-
-					log.Print("Scanning Frequency is invalid in this packet")
-
-				case 0x0:
-					// LOOP OVER THE POINT CLOUD SAMPLES
-					//The point cloud data packet contains the distance, angle, and luminosity data.
-					validFrames++
-					log.Printf("POINT CLOUD DATA PACKET FRAME #%v", validFrames)
-					if sampleQuantityPackets <= 0 {
-						log.Printf("sample quantity is less than 1 with a continuous response, got %v", sampleQuantityPackets)
-						continue
-					}
-					log.Printf("Scanning Frequency: %vHz", scanningFrequency)
-
-					/////////////////////////LUMINOSITY, DISTANCE, AND ANGLES/////////////////////////////////////
-					// 3 bytes per sample, ex. If sampleQuantityPackets is 5, then lengthOfSampleData is 15 because there are 5 samples and each sample is 3 bytes.
-					lengthOfSampleData := int(sampleQuantityPackets) * 3
-
-					// Make a slice to hold the raw contents, 3 bytes per sample.
-					rawSampleData := make([]byte, lengthOfSampleData)
-					numSampleBytesReceived, err = lidar.SerialPort.Read(rawSampleData)
-					if err != nil {
-						log.Print(fmt.Errorf("failed to read serial %v", err))
-					}
-
-					// if the lidar didn't provide the data we expected, let us know
-					if numSampleBytesReceived != lengthOfSampleData {
-						log.Print(fmt.Errorf("incorrect number of bytes received. Expected %v got %v", lengthOfSampleData, numSampleBytesReceived))
-					}
-
-					// Unpack the rawSampleData into the individualSampleBytes slice.
-					// the outer slice is the number of samples, the inner slice is the number of bytes per sample
-					individualSampleBytes := make([]byte, lengthOfSampleData) //
-					if err = binary.Read(bytes.NewBuffer(rawSampleData), binary.LittleEndian, &individualSampleBytes); err != nil {
-						log.Panic(fmt.Errorf("failed to pack struct: %v", err))
-					}
-
-					// Check Scan Packet Type.
-					err = checkScanPacket(rawHeaderData, individualSampleBytes, n)
-					if err != nil {
-						log.Printf(err.Error())
-						continue
-					}
-
-					samples := make([][]byte, len(individualSampleBytes)/n)
-
-					//////////////////////////////////Intensity Calculations//////////////////////////
-					intensities := calculateIntensities(individualSampleBytes, samples, n)
-					/////////////////////////////////////////////////////////////////////////////////
-
-					//////////////////////////////////Distance Calculations///////////////////////////
-					distances := calculateDistances(individualSampleBytes, samples, n)
-					/////////////////////////////////////////////////////////////////////////////////
-
-					//////////////////////////////Angle Calculations//////////////////////////////////
-					angles := calculateAngles(distances, pointCloud.StartAngle, pointCloud.EndAngle, sampleQuantityPackets)
-					/////////////////////////////////////////////////////////////////////////////////
-
-					// Send the packet to the channel.
-					lidar.Packets <- Packet{
-						NumDistanceSamples: int(sampleQuantityPackets),
-						Angles:             angles,
-						Distances:          distances,
-						Intensities:        intensities,
-						PacketType:         pointCloud.PackageType,
-						Error:              err,
-					}
+				dec.Reset()
+				watchdog.reset()
+				continue
+			}
+			if err != nil {
+				lidar.metrics.readErrors.Add(1)
+				if !lidar.sendErr(fmt.Errorf("failed to read serial: %w", err)) {
+					return
 				}
-
-			case <-lidar.Stop:
-				return
 			}
-
+			if n > 0 {
+				previousRead, lastRead, lastBytes = lastRead, time.Now(), n
+			} else if dec.Buffered() > 0 {
+				// The device stopped in the middle of a packet.
+				lidar.link.shortReads.Add(1)
+				lidar.handleTruncated(dec.truncate())
+			}
+			continue
 		}
-	}
-
-}
-
-func (lidar *YDLidar) extractScanPacketHeader(pointCloud pointCloudHeader) (uint16, uint8, uint8, uint8) {
-	packetHeader := pointCloud.PacketHeader
-
-	scanningFrequency := ((pointCloud.PackageType >> 1) & 0x7F) / 10
 
-	dataPacketType := pointCloud.PackageType & 0x01
-
-	sampleQuantity := pointCloud.SampleQuantity
-
-	return packetHeader, scanningFrequency, dataPacketType, sampleQuantity
-}
-
-// checkScanPacket validates the type of the packet.
-func checkScanPacket(headerData []byte, sampleData []byte, n int) error {
-	checkCode := byte(0)
+		cycles++
+		log.Printf("revs: %v", cycles)
+		received := lastRead
+		if dec.Buffered()+len(frame.header)+len(frame.samples) > lastBytes {
+			received = previousRead
+		}
 
-	// Make a slice big enough to hold headerData (minus the check code position) and sampleData.
+		lidar.countPacket(frame.category)
+		switch frame.category {
+		case CategoryResponse:
+			lidar.handleResponse(frame.header, frame.samples)
+
+		case CategoryUnknown:
+			// Out of step with the packets, the decoder skipped the bytes up to the next header.
+			lidar.link.desyncs.Add(1)
+			lidar.handleUnknownPacket(frame.header)
+
+		case CategoryZero:
+			//There is only one zero point of data in the zero start data packet. We skip this packet.
+			log.Printf("ZERO START DATA PACKET")
+			if frame.header[3] != 1 {
+				log.Printf("sample quantity should be 1, got %v", frame.header[3])
+			}
+			lidar.link.frames.Add(1)
+			lidar.emitRawFrame(frame.header, frame.samples)
 
-	// The check code uses a two-byte exclusive OR to verify the
-	// current data packet. The check code itself does not participate in
-	// XOR operations, and the XOR order is not strictly in byte order.
-	C1 := make([]uint16, 1)
-	bufferedC1 := bytes.NewBuffer(headerData[0:2])
-	err := binary.Read(bufferedC1, binary.LittleEndian, &C1)
-	if err != nil {
-		return fmt.Errorf("failed to pack header struct: %v", err)
-	}
+			dec.zeroPacket(frame.header)
+			lidar.metrics.frequency.Store(uint32(math.Round(dec.frequency * 10)))
+			lidar.observeZeroPacket(received)
 
-	C2 := make([]uint16, 1)
-	bufferedC2 := bytes.NewBuffer(headerData[4:6])
-	err = binary.Read(bufferedC2, binary.LittleEndian, &C2)
-	if err != nil {
-		return fmt.Errorf("failed to pack header struct: %v", err)
-	}
-
-	nextToLastC := make([]uint16, 1)
-	bufferedNextToLastC := bytes.NewBuffer(headerData[2:4])
-	err = binary.Read(bufferedNextToLastC, binary.LittleEndian, &nextToLastC)
-	if err != nil {
-		return fmt.Errorf("failed to pack header struct: %v", err)
-	}
+			// The zero packet marks the start of a new revolution.
+			if scan, ok := assembler.startRevolution(); ok && !lidar.sendScan(scan) {
+				return
+			}
+			if scan, ok := compact.startRevolution(); ok && !lidar.sendCompactScan(scan) {
+				return
+			}
 
-	lastC := make([]uint16, 1)
-	bufferedLastC := bytes.NewBuffer(headerData[6:8])
-	err = binary.Read(bufferedLastC, binary.LittleEndian, &lastC)
-	if err != nil {
-		return fmt.Errorf("failed to pack header struct: %v", err)
-	}
+		case CategoryPointCloud:
+			// LOOP OVER THE POINT CLOUD SAMPLES
+			//The point cloud data packet contains the distance, angle, and luminosity data.
+			validFrames++
+			log.Printf("POINT CLOUD DATA PACKET FRAME #%v", validFrames)
+			if frame.header[3] == 0 {
+				log.Printf("sample quantity is less than 1 with a continuous response, got %v", frame.header[3])
+				continue
+			}
+			lidar.observeStage(StageRead, received, lastRead)
+			lidar.emitRawFrame(frame.header, frame.samples)
+			if dec.stride != lidar.stride {
+				lidar.stride = dec.stride
+				lidar.warnStride(dec.stride)
+			}
 
-	// Calculate Xor of all bits.
-	for _, B := range C1 { // for each byte in the packet
-		// XOR the current byte with the previous XOR
-		checkCode ^= byte(B)
+			// Check and decode the packet. The decoded slices don't share the stream buffer.
+			read := time.Now()
+			packet, err := dec.decodePacket(frame)
+			if err != nil {
+				lidar.checksumFailures.Add(1)
+				log.Printf(err.Error())
+				continue
+			}
+			lidar.link.frames.Add(1)
+			packet.Received = received
+			parsedAt := time.Now()
+			lidar.observeStage(StageParse, read, parsedAt)
+
+			lidar.metrics.packets.Add(1)
+			lidar.metrics.samples.Add(uint64(len(packet.Distances)))
+			lidar.markSaturated(&packet, saturationRange)
+			lidar.applyFilters(&packet)
+			filtered := time.Now()
+			lidar.observeStage(StageFilter, parsedAt, filtered)
+			if lidar.assembling() {
+				assembler.add(packet)
+			}
+			if lidar.CompactScans != nil {
+				compact.add(packet)
+			}
 
-		switch B {
+			// Under load only every nth packet is delivered.
+			if lidar.load.shed(validFrames) {
+				continue
+			}
 
-		case 0:
-			// Check the first byte.
-			if B != 0x55AA && B != 0xA55A {
-				return fmt.Errorf("error: first byte of packet is not 0x55AA but %x", B)
-			} else {
-				log.Printf("First byte of header packet XOR is %x! Nice.", B)
+			// Send the packet to the subscribers and the channel.
+			lidar.markInvalidPacket(&packet)
+			if !lidar.packetSubscribers.publish(packet, lidar.Stop) || !lidar.sendPacket(packet) {
+				return
 			}
+			lidar.observeStage(StagePublish, filtered, time.Now())
 		}
 	}
+}
 
-	for _, B := range C2 { // for each byte in the packet
-		// XOR the current byte with the previous XOR
-		checkCode ^= byte(B)
+// handleTruncated handles the bytes of a packet the stream stopped in the middle of.
+func (lidar *YDLidar) handleTruncated(frame streamFrame) {
+	switch frame.category {
+	case CategoryResponse:
+		lidar.emitDiagnostic(append(frame.header[:len(frame.header):len(frame.header)], frame.samples...), "truncated response")
+	case CategoryPointCloud:
+		lidar.emitRawFrame(frame.header, frame.samples)
+		lidar.checksumFailures.Add(1)
+		log.Printf("Scan packet truncated after %v sample bytes", len(frame.samples))
+	default:
+		log.Printf("The lidar stopped in a packet header: %X", frame.header)
 	}
+}
 
-	for _, B := range nextToLastC { // for each byte in the packet
-		// XOR the current byte with the previous XOR
-		checkCode ^= byte(B)
+// checkScanPacket validates the header and the check code of a scan packet. The check code
+// is the XOR of the packet as 16 bit little endian words, the check code itself excluded, with
+// the samples of n bytes split into their intensity byte and their 2 byte distance.
+func checkScanPacket(headerData []byte, sampleData []byte, n int) error {
+	if len(headerData) < scanPacketHeaderSize {
+		return fmt.Errorf("%w: scan packet header expected %v bytes got %v", ErrShortRead, scanPacketHeaderSize, len(headerData))
 	}
-
-	for _, B := range lastC { // for each byte in the packet
-		// XOR the current byte with the previous XOR
-		checkCode ^= byte(B)
+	if _, ok := codecFor(headerData); !ok {
+		return fmt.Errorf("%w: unknown scan packet header %x", ErrBadHeader, binary.LittleEndian.Uint16(headerData))
 	}
-
-	samplePacket := make([]uint16, len(sampleData)/n)
-	bufferedSamples := bytes.NewBuffer(sampleData)
-	err = binary.Read(bufferedSamples, binary.LittleEndian, &samplePacket)
-	if err != nil {
-		return fmt.Errorf("failed to pack sample struct: %v", err)
+	if want := int(headerData[3]) * n; len(sampleData) != want {
+		return fmt.Errorf("%w: scan packet expected %v sample bytes got %v", ErrShortRead, want, len(sampleData))
 	}
-	log.Printf("Length of sample packet to XOR: %v", len(samplePacket))
 
-	// for each byte in the packet
-	for i, B := range samplePacket {
-		// check if the byte is divisible by 3
-		if i%3 == 0 {
-			//zero fill the first 8 bits of this byte
-			B = B << 8
+	// PH, CT and LSN, FSA, LSA.
+	var checkCode uint16
+	for i := 0; i < 8; i += 2 {
+		checkCode ^= binary.LittleEndian.Uint16(headerData[i:])
+	}
+	for i := 0; i+n <= len(sampleData); i += n {
+		sample := sampleData[i : i+n]
+		if n == 3 {
+			checkCode ^= uint16(sample[0])
+			sample = sample[1:]
 		}
-
-		// XOR the current byte with the previous XOR
-		checkCode ^= byte(B)
-
+		checkCode ^= binary.LittleEndian.Uint16(sample)
 	}
 
+	if want := binary.LittleEndian.Uint16(headerData[8:]); checkCode != want {
+		return fmt.Errorf("%w: expected check code %04X got %04X", ErrChecksum, want, checkCode)
+	}
 	return nil
 }
 
@@ -547,6 +593,9 @@ func checkScanPacket(headerData []byte, sampleData []byte, n int) error {
 func GetPointCloud(packet Packet) (pointClouds []PointCloudData) {
 	// Zero Point packet.
 	if packet.PacketType == 1 {
+		if len(packet.Distances) == 0 || len(packet.Angles) == 0 || len(packet.Intensities) == 0 {
+			return
+		}
 		pointClouds = append(pointClouds,
 			PointCloudData{
 				Intensity: packet.Intensities[0],
@@ -556,7 +605,15 @@ func GetPointCloud(packet Packet) (pointClouds []PointCloudData) {
 		return
 	}
 
-	for i := range packet.Distances {
+	// A malformed packet is cut to the shortest of its slices.
+	n := len(packet.Distances)
+	if len(packet.Angles) < n {
+		n = len(packet.Angles)
+	}
+	if len(packet.Intensities) < n {
+		n = len(packet.Intensities)
+	}
+	for i := 0; i < n; i++ {
 		intensity := packet.Intensities[i]
 		dist := packet.Distances[i]
 		angle := packet.Angles[i]
@@ -565,23 +622,55 @@ func GetPointCloud(packet Packet) (pointClouds []PointCloudData) {
 				Intensity: intensity,
 				Angle:     angle,
 				Dist:      dist,
+				Synthetic: i < len(packet.Synthetic) && packet.Synthetic[i],
+				Saturated: i < len(packet.Saturated) && packet.Saturated[i],
 			})
 	}
 	return
 }
 
-// StopScan stops the lidar scans, flushes the buffers and closes the serial port.
+// StopScan stops the lidar scans and flushes the buffers.
+// It is safe to call at any time and any number of times: without a running scan it does
+// nothing, while another call is stopping the scan it waits for the scan loop to exit.
+// Commands pausing the scan finish and resume it first.
 func (lidar *YDLidar) StopScan() error {
+	lidar.portMu.Lock()
+	defer lidar.portMu.Unlock()
+	return lidar.stopScan()
+}
+
+// stopScan stops scanning, the caller holds the port.
+func (lidar *YDLidar) stopScan() error {
+	lidar.mu.Lock()
+	switch lidar.state {
+	case stateIdle:
+		lidar.mu.Unlock()
+		return nil
+	case stateStopping:
+		done := lidar.done
+		lidar.mu.Unlock()
+		<-done
+		return nil
+	}
+	lidar.state = stateStopping
+	done := lidar.done
+	lidar.mu.Unlock()
+
+	defer lidar.setState(stateIdle)
+
+	// Closing never blocks, even if the scan loop already exited.
 	log.Printf("Stopping scan")
+	close(lidar.Stop)
+	<-done
+
 	if _, err := lidar.SerialPort.Write([]byte{preCommand, stopScanning}); err != nil {
 		return err
 	}
-	lidar.Stop <- struct{}{}
-	err := lidar.SerialPort.ResetOutputBuffer()
+	err := lidar.resetOutput()
 	if err != nil {
 		return err
 	}
-	err = lidar.SerialPort.ResetInputBuffer()
+	err = lidar.resetInput()
 	if err != nil {
 		return err
 	}
@@ -589,50 +678,84 @@ func (lidar *YDLidar) StopScan() error {
 
 }
 
-// sendErr sends error on channel with the packet.
-func (lidar *YDLidar) sendErr(err error) {
-	lidar.Packets <- Packet{
-		Error: err,
-	}
+// ChecksumFailures returns the number of scan packets dropped because they failed validation.
+func (lidar *YDLidar) ChecksumFailures() uint64 {
+	return lidar.checksumFailures.Load()
 }
 
-// Reboot soft reboots the lidar.
-func (lidar *YDLidar) Reboot() error {
-	if _, err := lidar.SerialPort.Write([]byte{preCommand, restartDevice}); err != nil {
-		log.Print("Error sending reboot command: ", err)
-		return err
-	}
-	return nil
+// sendErr sends error on channel with the packet.
+// Returns false if the scan was stopped before the packet could be delivered.
+func (lidar *YDLidar) sendErr(err error) bool {
+	return lidar.sendPacket(Packet{
+		Error: err,
+	})
 }
 
-// Close will shut down the connection.
+// Close shuts the lidar down in order: stops scanning, stops the background monitors, stops
+// the motor and closes the port. Packets already buffered in the channels stay readable.
+// Safe to call from the application's own signal handler, and more than once: later calls
+// return the result of the first.
 func (lidar *YDLidar) Close() error {
-	return lidar.SerialPort.Close()
+	lidar.closeOnce.Do(func() {
+		// Every step runs even if an earlier one failed, the first error is returned.
+		fail := func(step string, err error) {
+			if lidar.closeErr == nil {
+				lidar.closeErr = fmt.Errorf("%v: %w", step, err)
+			}
+		}
+		if err := lidar.StopScan(); err != nil {
+			fail("stopping scan", err)
+		}
+
+		close(lidar.quit)
+		lidar.monitors.Wait()
+
+		if err := lidar.setDTR(false); err != nil && !errors.Is(err, ErrUnsupportedByTransport) {
+			fail("stopping motor", err)
+		}
+		if err := lidar.SerialPort.Close(); err != nil {
+			fail("closing port", err)
+		}
+	})
+	return lidar.closeErr
 }
 
-// calculateAngles calculates the angles of the first and last sample.
-func calculateAngles(distances []float32, endAngle uint16, startAngle uint16, sampleQuantity uint8) []float32 {
+// calculateAngles calculates the angles of the samples as the SDK does: the first level
+// spreads them evenly from the start angle to the end angle of the packet, the second level
+// adds the correction of each sample for the offset of the laser from the lens, 0 for a
+// dropout. The angles are normalized to [0, 360).
+func calculateAngles(distances []float64, startAngle uint16, endAngle uint16, sampleQuantity uint8) []float64 {
 
-	// angleCorrect calculates the corrected angles for Lidar.
-	angleCorrect := func(dist float32) float32 {
+	// angleCorrect calculates the correction of the angle of a sample.
+	angleCorrect := func(dist float64) float64 {
 		if dist == 0 {
 			return 0
 		}
-		return float32(180 / math.Pi * math.Atan(21.8*(155.3-float64(dist))/(155.3*float64(dist))))
+		return 180 / math.Pi * math.Atan(21.8*(155.3-dist)/(155.3*dist))
 	}
 
-	angles := make([]float32, sampleQuantity)
-	angleCorFSA := angleCorrect(distances[0])
-	angleCorLSA := angleCorrect(distances[sampleQuantity-1])
-
-	angleFSA := float32(startAngle>>1)/64 + angleCorFSA
-	angleLSA := float32(endAngle>>1)/64 + angleCorLSA
+	// A short packet has fewer distances than the header announced.
+	if int(sampleQuantity) > len(distances) {
+		sampleQuantity = uint8(len(distances))
+	}
+	angles := make([]float64, sampleQuantity)
+	if sampleQuantity == 0 {
+		return angles
+	}
 
-	angleDiff := float32(math.Mod(float64(angleLSA-angleFSA), 360))
+	angleFSA := float64(startAngle>>1) / 64
+	angleLSA := float64(endAngle>>1) / 64
+	// The packet crossing 0° ends below its start.
+	angleDiff := math.Mod(angleLSA-angleFSA+360, 360)
 
-	for i := 0; i < len(distances); i++ {
-		angle := angleDiff/float32(sampleQuantity-1)*float32(i) + angleLSA + angleCorrect(distances[i])
-		angles[i] = angle
+	// A single sample has no angular step.
+	step := 0.0
+	if sampleQuantity > 1 {
+		step = angleDiff / float64(sampleQuantity-1)
+	}
+	for i := range angles {
+		angle := angleFSA + step*float64(i) + angleCorrect(distances[i])
+		angles[i] = math.Mod(angle+360, 360)
 	}
 
 	return angles
@@ -660,13 +783,13 @@ func calculateIntensities(individualSampleBytes []byte, samples [][]byte, n int)
 }
 
 // calculateDistances calculates the distances.
-func calculateDistances(individualSampleBytes []byte, samples [][]byte, n int) []float32 {
+func calculateDistances(individualSampleBytes []byte, samples [][]byte, n int) []float64 {
 	// Si represents the number of samples.
 	// Split the individualSampleBytes slice into a slice of slices.
 	// Each inner slice is 3 bytes long.
 	// The outer slice is the number of samples.
 	// The inner slice is the number of bytes per sample.
-	distances := make([]float32, len(individualSampleBytes)/n)
+	distances := make([]float64, len(individualSampleBytes)/n)
 	for Si := range samples {
 		samples[Si] = individualSampleBytes[Si*n : (Si+1)*n]
 
@@ -676,7 +799,7 @@ func calculateDistances(individualSampleBytes []byte, samples [][]byte, n int) [
 		// uint16(samples[Si][1]) >> 2 means we take the first/high 6 bits via shifting it 2 bits to the right
 		// uint16(samples[Si][2]) << 6 means we take the last/low 2 bits via shifting it 6 bits to the left
 		distance := (uint16(samples[Si][2]) << 6) + (uint16(samples[Si][1]) >> 2)
-		distances[Si] = float32(distance)
+		distances[Si] = float64(distance)
 	}
 	return distances
 }
@@ -1,92 +1,153 @@
 package ydlidar
 
 import (
-	"github.com/stretchr/testify/assert"
-	"log"
-	"math"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-// TestGetHealthStatus tests the GetHealthStatus function.
 func TestDistanceCalculation(t *testing.T) {
-
-	// Contains distance data
-	testSamplePacket := []byte{0x64, 0xE5, 0x6F}
-
-	testReadableDistance := (uint16(testSamplePacket[2]) << 6) + (uint16(testSamplePacket[1]) >> 2)
-
-	assert.Equal(t, 7161, testReadableDistance, "they should be equal")
-
+	// Distance = Si(3) << 6 + Si(2) >> 2: 0x6F << 6 + 0xE5 >> 2.
+	sample := []byte{0x64, 0xE5, 0x6F}
+	distances := calculateDistances(sample, make([][]byte, 1), 3)
+	assert.Equal(t, []float64{7161}, distances)
 }
 
 func TestIntensityCalculation(t *testing.T) {
-	// Contains intensity data
-	testSamplePacket := []byte{0x64, 0xE5, 0x6F}
-
-	intensityByte1 := testSamplePacket[0]
-	intensityByte2 := testSamplePacket[1] & 0x3
+	// Intensity = Si(1) + (Si(2) & 0x3) << 8: 0x64 + 1 * 256.
+	sample := []byte{0x64, 0xE5, 0x6F}
+	intensities := calculateIntensities(sample, make([][]byte, 1), 3)
+	assert.Equal(t, []int{356}, intensities)
+}
 
-	if intensityByte1 == 100 {
-		log.Printf("TEST INTENSITY BYTE 1: %v", intensityByte1)
-	}
+func TestAngleAnalysis(t *testing.T) {
+	// The example of the development manual: 40 samples from 223.78125° to 243.46875°, the
+	// first at 1000mm and the last at 8000mm.
+	distances := make([]float64, 40)
+	distances[0], distances[39] = 1000, 8000
+	angles := calculateAngles(distances, 0x6FE5, 0x79BD, 40)
+	require.Len(t, angles, 40)
+
+	// First level: evenly spread. Second level: corrected by -6.7622° at 1000mm and
+	// -7.8374° at 8000mm, not at all for the dropouts.
+	step := (243.46875 - 223.78125) / 39
+	assert.InDelta(t, 223.78125-6.7622, angles[0], 0.0001)
+	assert.InDelta(t, 223.78125+step, angles[1], 1e-9)
+	assert.InDelta(t, 223.78125+38*step, angles[38], 1e-9)
+	assert.InDelta(t, 243.46875-7.8374, angles[39], 0.0001)
+
+	// Crossing 0°, from 350° to 10°, the angles wrap.
+	angles = calculateAngles([]float64{0, 0, 0}, 350*64<<1|1, 10*64<<1|1, 3)
+	assert.InDeltaSlice(t, []float64{350, 0, 10}, angles, 1e-9)
+}
 
-	if intensityByte2 == 1 {
-		log.Printf("TEST INTENSITY BYTE 2: %v", intensityByte2)
-	}
+// goldenCapture is a file of testdata/golden: scan packets of a model, covering the packets
+// crossing 0°, the dropouts, the close range and the largest values of the fields, with the
+// outputs expected of them computed from the formulas of the development manual,
+// independently of the driver. The packets are encoded from the layouts of the manual rather
+// than captured, and the outputs aren't yet checked against the SDK, see the README.
+type goldenCapture struct {
+	Model   byte   `json:"model"`
+	Note    string `json:"note"`
+	Packets []struct {
+		Packet      string    `json:"packet"` // Hex, header and samples.
+		Distances   []float64 `json:"distances"`
+		Intensities []int     `json:"intensities"`
+		Angles      []float64 `json:"angles"`
+	} `json:"packets"`
+}
 
-	testReadableIntensity := uint16(testSamplePacket[0]) + uint16(testSamplePacket[1]&0x3)*256
-	if testReadableIntensity == 356 {
-		log.Printf("TEST READABLE INTENSITY: %v", testReadableIntensity)
+// TestGolden replays the packets of testdata/golden through the scan packet parser and the
+// stream decoder and checks the bit twiddling of the samples and the angle math against the
+// expected outputs. The fixtures are synthetic, see testdata/golden/README.md.
+func TestGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "golden", "*.json"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			var capture goldenCapture
+			require.NoError(t, json.Unmarshal(data, &capture))
+
+			decoder := NewStreamDecoder()
+			decoder.SetDeviceInfo(DeviceInfo{Model: capture.Model})
+			for i, golden := range capture.Packets {
+				packet, err := hex.DecodeString(golden.Packet)
+				require.NoError(t, err)
+
+				parsed, err := parseScanPacket(packet[:scanPacketHeaderSize], packet[scanPacketHeaderSize:], decoderFor(capture.Model), FirmwareQuirks{})
+				require.NoError(t, err, "packet %v", i)
+				assert.Equal(t, golden.Distances, parsed.distances, "packet %v", i)
+				assert.Equal(t, golden.Intensities, parsed.intensities, "packet %v", i)
+				assert.InDeltaSlice(t, golden.Angles, parsed.angles, 1e-6, "packet %v", i)
+
+				_, err = decoder.Write(packet)
+				require.NoError(t, err)
+				decoded, err := decoder.Next()
+				require.NoError(t, err, "packet %v", i)
+				assert.Equal(t, golden.Distances, decoded.Distances, "packet %v", i)
+				assert.Equal(t, golden.Intensities, decoded.Intensities, "packet %v", i)
+				assert.InDeltaSlice(t, golden.Angles, decoded.Angles, 1e-6, "packet %v", i)
+			}
+			assert.Zero(t, decoder.Skipped())
+		})
 	}
-
-	assert.Equal(t, 356, testReadableIntensity, "they should be equal")
 }
 
-func TestAngleAnalysis(t *testing.T) {
-	// Sample data
-	lsn := 40 // 40 samples
-
-	fsa := 0x6FE5 // 223.78125
-	lsa := 0x79BD // 243.46875
-
-	dist1 := 1000   // 1000mm
-	distLsn := 8000 // 8000mm
+func TestDeviceInfoDecode(t *testing.T) {
+	data := []byte{15, 0x06, 0x01, 0x02, 2, 0, 2, 1, 0, 3, 1, 4, 0, 0, 0, 0, 0, 0, 0x0A, 0x0F}
 
-	// First-level analysis
-	angleStart := float64(fsa>>1) / 64
-	angleEnd := float64(lsa>>1) / 64
-	angleDiff := math.Mod(angleEnd-angleStart, 360)
+	info := newDeviceInfo(data)
 
-	// Second-level analysis
-	angCorrect1 := 0.0
-	if dist1 != 0 {
-		angCorrect1 = math.Atan((21.8*(155.3-float64(dist1)))/(155.3*float64(dist1))) * 180 / math.Pi
-	}
-	angCorrectLsn := 0.0
-	if distLsn != 0 {
-		angCorrectLsn = math.Atan((21.8*(155.3-float64(distLsn)))/(155.3*float64(distLsn))) * 180 / math.Pi
-	}
+	assert.Equal(t, "G2", info.ModelName)
+	assert.Equal(t, byte(1), info.FirmwareMajor)
+	assert.Equal(t, byte(6), info.FirmwareMinor)
+	assert.Equal(t, byte(2), info.Hardware)
+	assert.Equal(t, "20210314000000AF", info.SerialNumber)
+}
 
-	// Calculate intermediate angles
-	angles := make([]float64, lsn-2)
-	for i := 2; i < lsn; i++ {
-		angles[i-2] = angleDiff/float64(lsn-1)*float64(i-1) + angleStart + angCorrect1
-		if i == lsn {
-			angles[i-2] += angCorrectLsn
+func TestPacketHeaderMetadata(t *testing.T) {
+	samples := [][3]byte{{100, 0xA0, 0x0F}, {100, 0xA0, 0x0F}}
+	var revolution bytes.Buffer
+	// 7.2Hz in the F&C byte of the zero packet, then two packets from 10° to 20° and 20° to 30°.
+	revolution.Write(encodeScanPacket(72<<1|0x01, 0x0001, 0x0001, [][3]byte{{0, 0, 0}}))
+	revolution.Write(encodeScanPacket(0x00, 10*64<<1|1, 20*64<<1|1, samples))
+	revolution.Write(encodeScanPacket(0x00, 20*64<<1|1, 30*64<<1|1, samples))
+	port := &fakePort{}
+	port.queue(scanResponseHeader...)
+	port.queue(revolution.Bytes()...)
+	lidar := NewLidar(port)
+	require.NoError(t, lidar.StartScan())
+	defer lidar.StopScan()
+
+	var packets []Packet
+	timeout := time.After(2 * time.Second)
+	for len(packets) < 2 {
+		select {
+		case packet := <-lidar.Packets:
+			require.NoError(t, packet.Error)
+			packets = append(packets, packet)
+		case <-timeout:
+			t.Fatal("no packets")
 		}
 	}
 
-	// Assert results
-	expectedStart := 223.78
-	expectedEnd := 243.47
-	expectedDiff := 19.69
-	assert.InDelta(t, expectedStart, angleStart, 0.01)
-	assert.InDelta(t, expectedEnd, angleEnd, 0.01)
-	assert.InDelta(t, expectedDiff, angleDiff, 0.01)
-	expectedAngCorrect1 := -6.7622
-	expectedAngCorrectLsn := -7.8374
-	assert.InDelta(t, expectedAngCorrect1, angCorrect1, 0.0001)
-	assert.InDelta(t, expectedAngCorrectLsn, angCorrectLsn, 0.0001)
-	expectedAngles := []float64{217.0178, 219.2851, 221.5524, 223.8197, 226.0870, 228.3543, 230.6216, 232.8889, 235.1562, 237.4235, 239.6908, 241.9581, 244.2254, 246.4927, 248.7600, 251.0273, 253.2946, 255.5619, 257.8292, 260.0965, 262.3638, 264.6311, 266.8984, 269.1657, 271.4330, 273.7003, 275.9676, 278.2349, 280.5022, 282.7695, 285.0368, 287.3041, 289.5714, 291.8387, 294.1060, 296.3733, 298.6406, 300.9079, 303.1752}
-	assert.InDeltaSlice(t, expectedAngles, angles, 0.0001)
+	assert.Equal(t, 10.0, packets[0].StartAngle)
+	assert.Equal(t, 20.0, packets[0].EndAngle)
+	assert.Equal(t, 7.2, packets[0].Frequency)
+	assert.True(t, packets[0].IsZeroStart)
+	assert.Equal(t, 20.0, packets[1].StartAngle)
+	assert.Equal(t, 30.0, packets[1].EndAngle)
+	assert.Equal(t, 7.2, packets[1].Frequency)
+	assert.False(t, packets[1].IsZeroStart)
 }
@@ -0,0 +1,117 @@
+// Package mapping stitches the revolutions of a moving lidar into a point map of the world,
+// given the pose of the lidar for each revolution, eg. from the odometry of the robot. It is
+// the minimum needed for mapping while driving: the map is as good as the poses, no scan
+// matching corrects their drift.
+//
+//	builder := mapping.NewBuilder(50)
+//	for scan := range lidar.Scans {
+//		builder.Add(odometry.Pose(), scan)
+//	}
+//	points := builder.Points(2)
+//
+// For an occupancy grid rather than points, see grid.Grid.IntegratePose.
+package mapping
+
+import (
+	"math"
+	"sync"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Builder accumulates the world frame points of the revolutions, merged into cells of
+// Resolution so the map stays the size of the area covered rather than growing with time.
+// It is safe to read the map from other goroutines while it is built.
+type Builder struct {
+	Resolution float64 // Size of a cell in the unit of the scans, eg. millimeters.
+	MinMove    float64 // Distance the lidar moves before a revolution is added. 0 adds them all.
+	MinTurn    float64 // Rotation in degrees of the lidar before a revolution is added, with MinMove.
+
+	mu       sync.Mutex
+	cells    map[[2]int]*cell
+	last     geom.Pose2D
+	added    bool
+	scans    int
+	min, max geom.Point
+}
+
+// cell is the mean of the points that fell in a cell of the map.
+type cell struct {
+	sum  geom.Point
+	hits int
+}
+
+// NewBuilder returns an empty map with cells of resolution, in the unit of the scans.
+func NewBuilder(resolution float64) *Builder {
+	return &Builder{Resolution: resolution, cells: make(map[[2]int]*cell)}
+}
+
+// Add stitches the revolution taken at the world pose of the lidar. The revolution is
+// skipped, returning false, while the lidar moved less than MinMove and turned less than
+// MinTurn since the last revolution added.
+func (b *Builder) Add(pose geom.Pose2D, scan ydlidar.Scan) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.added && pose.Position().Dist(b.last.Position()) < b.MinMove && math.Abs(geom.AngleDiff(pose.Heading, b.last.Heading)) < b.MinTurn {
+		return false
+	}
+	for _, p := range scan.Transform(pose) {
+		key := [2]int{int(math.Floor(p.X / b.Resolution)), int(math.Floor(p.Y / b.Resolution))}
+		c := b.cells[key]
+		if c == nil {
+			c = &cell{}
+			b.cells[key] = c
+			if len(b.cells) == 1 {
+				b.min, b.max = p, p
+			}
+			b.min = geom.Point{X: math.Min(b.min.X, p.X), Y: math.Min(b.min.Y, p.Y)}
+			b.max = geom.Point{X: math.Max(b.max.X, p.X), Y: math.Max(b.max.Y, p.Y)}
+		}
+		c.sum.X += p.X
+		c.sum.Y += p.Y
+		c.hits++
+	}
+	b.last, b.added = pose, true
+	b.scans++
+	return true
+}
+
+// Points returns the map, one point per cell at the mean of the points that fell in it, in no
+// particular order.
+// Cells hit fewer than minHits times, eg. people walking by, are left out.
+func (b *Builder) Points(minHits int) []geom.Point {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	points := make([]geom.Point, 0, len(b.cells))
+	for _, c := range b.cells {
+		if c.hits < minHits {
+			continue
+		}
+		points = append(points, geom.Point{X: c.sum.X / float64(c.hits), Y: c.sum.Y / float64(c.hits)})
+	}
+	return points
+}
+
+// Bounds returns the corners of the box holding the map, zero for an empty map.
+func (b *Builder) Bounds() (min, max geom.Point) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.min, b.max
+}
+
+// Scans returns the number of revolutions added.
+func (b *Builder) Scans() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.scans
+}
+
+// Reset empties the map.
+func (b *Builder) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cells = make(map[[2]int]*cell)
+	b.added, b.scans = false, 0
+	b.min, b.max = geom.Point{}, geom.Point{}
+}
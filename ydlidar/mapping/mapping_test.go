@@ -0,0 +1,78 @@
+package mapping
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// wallScan is what a lidar at pose sees of the wall x = 3025 between y = -475 and 525,
+// sampled every 100 mm in the middle of the cells of the map.
+func wallScan(pose geom.Pose2D) ydlidar.Scan {
+	var scan ydlidar.Scan
+	inverse := pose.Inverse()
+	for y := -475.0; y <= 525; y += 100 {
+		angle, dist := inverse.Apply(geom.Point{X: 3025, Y: y}).Polar()
+		scan.Points = append(scan.Points, ydlidar.PointCloudData{Angle: angle, Dist: dist})
+	}
+	return scan
+}
+
+func TestBuilderStitches(t *testing.T) {
+	b := NewBuilder(50)
+	// Driving towards the wall while turning, the wall stays in place in the map.
+	for i := 0; i < 5; i++ {
+		pose := geom.Pose2D{X: 200 * float64(i), Y: -100 * float64(i), Heading: 10 * float64(i)}
+		require.True(t, b.Add(pose, wallScan(pose)))
+	}
+	assert.Equal(t, 5, b.Scans())
+
+	points := b.Points(5)
+	require.Len(t, points, 11)
+	sort.Slice(points, func(i, j int) bool { return points[i].Y < points[j].Y })
+	for i, p := range points {
+		assert.InDelta(t, 3025, p.X, 1e-6)
+		assert.InDelta(t, -475+100*float64(i), p.Y, 1e-6)
+	}
+	min, max := b.Bounds()
+	assert.InDelta(t, -475, min.Y, 1e-6)
+	assert.InDelta(t, 525, max.Y, 1e-6)
+
+	b.Reset()
+	assert.Empty(t, b.Points(1))
+}
+
+func TestBuilderMinMove(t *testing.T) {
+	b := NewBuilder(50)
+	b.MinMove, b.MinTurn = 100, 5
+	assert.True(t, b.Add(geom.Pose2D{}, wallScan(geom.Pose2D{})))
+	assert.False(t, b.Add(geom.Pose2D{X: 50, Heading: 2}, wallScan(geom.Pose2D{X: 50, Heading: 2})))
+	assert.True(t, b.Add(geom.Pose2D{X: 50, Heading: 6}, wallScan(geom.Pose2D{X: 50, Heading: 6})), "turned")
+	assert.True(t, b.Add(geom.Pose2D{X: 200, Heading: 6}, wallScan(geom.Pose2D{X: 200, Heading: 6})), "moved")
+	assert.Equal(t, 3, b.Scans())
+}
+
+func TestBuilderConcurrent(t *testing.T) {
+	b := NewBuilder(50)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			b.Add(geom.Pose2D{}, wallScan(geom.Pose2D{}))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			assert.LessOrEqual(t, len(b.Points(1)), 11)
+		}
+	}()
+	wg.Wait()
+	assert.Len(t, b.Points(100), 11)
+}
@@ -0,0 +1,122 @@
+// Package metrics exposes the counters of one or more lidars over HTTP in the Prometheus
+// text exposition format, so fleet monitoring can alert on degrading units:
+//
+//	reg := metrics.NewRegistry()
+//	reg.Register("front", lidar)
+//	http.Handle("/metrics", reg)
+//
+// Counters are totals, use rate() for per second values, eg. rate(ydlidar_packets_total[1m]).
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"ydlidarg2/ydlidar"
+)
+
+// Source is anything reporting lidar metrics, usually a *ydlidar.YDLidar.
+type Source interface {
+	Metrics() ydlidar.Metrics
+}
+
+// Registry holds the lidars to expose, each under a name set in the lidar label.
+type Registry struct {
+	mu      sync.Mutex
+	sources map[string]Source
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{sources: make(map[string]Source)}
+}
+
+// Register exposes the source under name, replacing any source with the same name.
+func (r *Registry) Register(name string, source Source) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sources[name] = source
+}
+
+// Unregister stops exposing the source registered under name.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sources, name)
+}
+
+// metric describes one exposed metric.
+type metric struct {
+	name  string
+	kind  string
+	help  string
+	value func(ydlidar.Metrics) float64
+}
+
+var exposed = []metric{
+	{"ydlidar_scan_frequency_hertz", "gauge", "Rotation frequency reported by the last zero packet.",
+		func(m ydlidar.Metrics) float64 { return m.ScanFrequency }},
+	{"ydlidar_measured_frequency_hertz", "gauge", "Rotation frequency measured between the last two zero packets.",
+		func(m ydlidar.Metrics) float64 { return m.MeasuredFrequency }},
+	{"ydlidar_packets_total", "counter", "Point cloud packets decoded.",
+		func(m ydlidar.Metrics) float64 { return float64(m.Packets) }},
+	{"ydlidar_samples_total", "counter", "Samples in the decoded packets.",
+		func(m ydlidar.Metrics) float64 { return float64(m.Samples) }},
+	{"ydlidar_checksum_failures_total", "counter", "Scan packets dropped on a checksum mismatch.",
+		func(m ydlidar.Metrics) float64 { return float64(m.ChecksumFailures) }},
+	{"ydlidar_reconnects_total", "counter", "Successful reconnects after a lost link.",
+		func(m ydlidar.Metrics) float64 { return float64(m.Reconnects) }},
+	{"ydlidar_serial_read_errors_total", "counter", "Failed serial reads.",
+		func(m ydlidar.Metrics) float64 { return float64(m.ReadErrors) }},
+	{"ydlidar_dropped_total", "counter", "Events and revolutions dropped because their channel was full.",
+		func(m ydlidar.Metrics) float64 { return float64(m.Dropped) }},
+	{"ydlidar_packets_dropped_total", "counter", "Packets discarded by the overflow policy.",
+		func(m ydlidar.Metrics) float64 { return float64(m.DroppedPackets) }},
+	{"ydlidar_zero_packets_total", "counter", "Zero packets starting a revolution.",
+		func(m ydlidar.Metrics) float64 { return float64(m.ZeroPackets) }},
+	{"ydlidar_response_packets_total", "counter", "Command responses found in the scan stream.",
+		func(m ydlidar.Metrics) float64 { return float64(m.ResponsePackets) }},
+	{"ydlidar_unknown_packets_total", "counter", "Packets of no known category in the scan stream.",
+		func(m ydlidar.Metrics) float64 { return float64(m.UnknownPackets) }},
+}
+
+// ServeHTTP writes the metrics of every registered source.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// WriteTo writes the metrics of every registered source in the text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.sources))
+	snapshots := make(map[string]ydlidar.Metrics, len(r.sources))
+	for name, source := range r.sources {
+		names = append(names, name)
+		snapshots[name] = source.Metrics()
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	var written int64
+	for _, m := range exposed {
+		n, err := fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", m.name, m.help, m.name, m.kind)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+		for _, name := range names {
+			value := strconv.FormatFloat(m.value(snapshots[name]), 'g', -1, 64)
+			n, err = fmt.Fprintf(w, "%v{lidar=%q} %v\n", m.name, name, value)
+			written += int64(n)
+			if err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
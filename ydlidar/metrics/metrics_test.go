@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"ydlidarg2/ydlidar"
+)
+
+type fakeSource ydlidar.Metrics
+
+func (f fakeSource) Metrics() ydlidar.Metrics {
+	return ydlidar.Metrics(f)
+}
+
+func TestServeHTTP(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("rear", fakeSource{ScanFrequency: 10.5, Packets: 3})
+	reg.Register("front", fakeSource{ChecksumFailures: 2})
+	reg.Register("gone", fakeSource{})
+	reg.Unregister("gone")
+
+	rec := httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, body, "# TYPE ydlidar_packets_total counter\n"+
+		"ydlidar_packets_total{lidar=\"front\"} 0\n"+
+		"ydlidar_packets_total{lidar=\"rear\"} 3\n")
+	assert.Contains(t, body, "ydlidar_scan_frequency_hertz{lidar=\"rear\"} 10.5\n")
+	assert.Contains(t, body, "ydlidar_checksum_failures_total{lidar=\"front\"} 2\n")
+	assert.NotContains(t, body, "gone")
+}
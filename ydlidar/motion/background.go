@@ -0,0 +1,154 @@
+package motion
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Background is the static scene around the lidar: the mean range and its variance in each
+// angular bin over the revolutions it learned. Backgrounds are learned once with Learn, saved
+// and loaded at start up.
+type Background struct {
+	resolution  float64   // Width of a bin in degrees.
+	mean        []float64 // Mean range of the bin in millimeters.
+	m2          []float64 // Sum of the squared differences from the mean, see Welford's algorithm.
+	count       []int     // Returns averaged in the bin, 0 if it never saw one.
+	revolutions int
+}
+
+// NewBackground returns an empty background with bins of resolution degrees, eg. 0.5.
+func NewBackground(resolution float64) *Background {
+	if resolution <= 0 {
+		resolution = 1
+	}
+	bins := int(math.Ceil(360 / resolution))
+	return &Background{resolution: resolution, mean: make([]float64, bins), m2: make([]float64, bins), count: make([]int, bins)}
+}
+
+// bin returns the index of the bin of the angle.
+func (b *Background) bin(angle float64) int {
+	i := int(geom.NormalizeAngle(angle) / b.resolution)
+	if i >= len(b.mean) {
+		i = len(b.mean) - 1
+	}
+	return i
+}
+
+// Add learns the revolution, which must show the static scene.
+func (b *Background) Add(scan ydlidar.Scan) {
+	for _, point := range scan.Points {
+		if !scan.IsReturn(point.Dist) {
+			continue
+		}
+		i := b.bin(point.Angle)
+		dist := scan.Units.ToMillimeters(point.Dist)
+		b.count[i]++
+		delta := dist - b.mean[i]
+		b.mean[i] += delta / float64(b.count[i])
+		b.m2[i] += delta * (dist - b.mean[i])
+	}
+	b.revolutions++
+}
+
+// Revolutions returns the number of revolutions learned.
+func (b *Background) Revolutions() int {
+	return b.revolutions
+}
+
+// Range returns the static range at the angle in millimeters, false if nothing was ever
+// seen in that direction.
+func (b *Background) Range(angle float64) (float64, bool) {
+	i := b.bin(angle)
+	return b.mean[i], b.count[i] > 0
+}
+
+// StdDev returns the standard deviation in millimeters of the ranges learned at the angle,
+// the noise of the lidar on that surface. 0 with less than two returns.
+func (b *Background) StdDev(angle float64) float64 {
+	i := b.bin(angle)
+	if b.count[i] < 2 {
+		return 0
+	}
+	return math.Sqrt(b.m2[i] / float64(b.count[i]-1))
+}
+
+// Learn scans revolutions revolutions of the static scene into a new background with bins of
+// resolution degrees. The lidar must be created with ydlidar.WithScans and not be scanning.
+// Partial revolutions are skipped.
+func Learn(lidar *ydlidar.YDLidar, revolutions int, resolution float64) (*Background, error) {
+	if lidar.Scans == nil {
+		return nil, fmt.Errorf("motion: the lidar must be created WithScans")
+	}
+	if err := lidar.StartScan(); err != nil {
+		return nil, err
+	}
+	b := NewBackground(resolution)
+	for b.revolutions < revolutions {
+		select {
+		case packet := <-lidar.Packets:
+			if packet.Error != nil {
+				lidar.StopScan()
+				return nil, packet.Error
+			}
+		case scan := <-lidar.Scans:
+			if !scan.Partial {
+				b.Add(scan)
+			}
+		}
+	}
+	return b, lidar.StopScan()
+}
+
+// backgroundFile is the JSON encoding of a Background.
+type backgroundFile struct {
+	Resolution  float64   `json:"resolution"`
+	Revolutions int       `json:"revolutions"`
+	Mean        []float64 `json:"mean"`     // Millimeters.
+	Variance    []float64 `json:"variance"` // Square millimeters.
+	Count       []int     `json:"count"`
+}
+
+// Save writes the background to a JSON file.
+func (b *Background) Save(path string) error {
+	f := backgroundFile{Resolution: b.resolution, Revolutions: b.revolutions, Mean: b.mean, Count: b.count, Variance: make([]float64, len(b.m2))}
+	for i, m2 := range b.m2 {
+		if b.count[i] > 1 {
+			f.Variance[i] = m2 / float64(b.count[i]-1)
+		}
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Load reads a background from a JSON file written by Save.
+func Load(path string) (*Background, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f backgroundFile
+	if err = json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid background %v: %v", path, err)
+	}
+	b := NewBackground(f.Resolution)
+	if f.Resolution <= 0 || len(f.Mean) != len(b.mean) || len(f.Variance) != len(b.mean) || len(f.Count) != len(b.mean) {
+		return nil, fmt.Errorf("invalid background %v: %v bins of %v°", path, len(f.Mean), f.Resolution)
+	}
+	b.revolutions = f.Revolutions
+	copy(b.mean, f.Mean)
+	copy(b.count, f.Count)
+	for i, variance := range f.Variance {
+		if b.count[i] > 1 {
+			b.m2[i] = variance * float64(b.count[i]-1)
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,68 @@
+package motion
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+// noisyBackground learns a room whose wall at 90° reads 1 cm either way.
+func noisyBackground() *Background {
+	b := NewBackground(0.5)
+	for i := 0; i < 10; i++ {
+		noise := 10.0
+		if i%2 == 0 {
+			noise = -10
+		}
+		b.Add(room(func(angle float64) (float64, bool) { return 3000 + noise, angle == 90 }))
+	}
+	return b
+}
+
+func TestBackgroundVariance(t *testing.T) {
+	b := noisyBackground()
+	assert.Equal(t, 10, b.Revolutions())
+	dist, ok := b.Range(90)
+	assert.True(t, ok)
+	assert.Equal(t, 3000.0, dist)
+	assert.InDelta(t, 10.54, b.StdDev(90), 0.01)
+	assert.Equal(t, 0.0, b.StdDev(0))
+
+	scan := room(func(angle float64) (float64, bool) { return 2940, angle == 90 })
+	detector := &Detector{Background: b, Threshold: 50, MinPoints: 1}
+	assert.Len(t, detector.Detect(scan), 1)
+	detector.Sigmas = 6
+	assert.Empty(t, detector.Detect(scan), "within the noise of the wall")
+}
+
+func TestBackgroundSaveLoad(t *testing.T) {
+	b := noisyBackground()
+	path := filepath.Join(t.TempDir(), "background.json")
+	require.NoError(t, b.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, b.Revolutions(), loaded.Revolutions())
+	assert.InDelta(t, b.StdDev(90), loaded.StdDev(90), 1e-9)
+	dist, ok := loaded.Range(90)
+	assert.True(t, ok)
+	assert.Equal(t, 3000.0, dist)
+
+	// Learning goes on from the loaded state.
+	loaded.Add(room(nil))
+	assert.Equal(t, 11, loaded.Revolutions())
+}
+
+func TestBackgroundLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "background.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"resolution":1,"mean":[1,2]}`), 0o644))
+	_, err := Load(path)
+	assert.Error(t, err)
+
+	_, err = Learn(&ydlidar.YDLidar{}, 1, 1)
+	assert.Error(t, err, "the lidar needs the Scans channel")
+}
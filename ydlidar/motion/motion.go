@@ -0,0 +1,137 @@
+// Package motion detects what moved around a fixed lidar, for intrusion detection or counting
+// the people crossing a doorway.
+//
+// The Detector compares each revolution with a Background, the static scene learned while
+// nothing moved. Returns closer than the background by more than a threshold are something
+// that appeared, returns further are something of the background that went away, eg. a door
+// that opened. Neighboring moved returns are grouped into regions.
+//
+//	detector := &motion.Detector{Background: motion.NewBackground(0.5), Learn: 50, Threshold: 150, MinPoints: 3}
+//	lidar, err := ydlidar.Connect(port, ydlidar.WithScans(1), ydlidar.WithScanProcessor(detector))
+//
+// Rather than learning at every start, when the room might not be empty, the background can
+// be learned once with Learn, eg. with ydlidar-cli background, saved and loaded at start up:
+//
+//	background, err := motion.Load("background.json")
+//	detector := &motion.Detector{Background: background, Threshold: 100, Sigmas: 4, MinPoints: 3}
+package motion
+
+import (
+	"math"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Label is set in Scan.Labels on the points of the regions by ProcessScan.
+const Label = "motion"
+
+// Region is a group of neighboring returns that moved.
+type Region struct {
+	StartAngle float64    // Angle of the first point in degrees.
+	EndAngle   float64    // Angle of the last point in degrees.
+	Points     int        // Number of points that moved.
+	Nearest    float64    // Distance of the nearest point in millimeters.
+	Center     geom.Point // Mean of the points in millimeters, in the lidar frame.
+	Vacated    bool       // The returns are further than the background: something went away.
+	Indices    []int      // Indices of the points in Scan.Points.
+}
+
+// Detector is a ydlidar.ScanProcessor detecting the regions that moved.
+type Detector struct {
+	Background *Background // Static scene. Required.
+	Learn      int         // Revolutions added to the Background by ProcessScan before detecting.
+	Threshold  float64     // Range difference in millimeters from the background that counts as a move.
+	Sigmas     float64     // Raises the threshold to that many standard deviations of the background, for noisy surfaces. Optional.
+	MinPoints  int         // Minimum number of points of a region, smaller ones are noise.
+	Gap        int         // Static points tolerated inside a region, eg. between two legs. 0 by default.
+	Regions    chan Region // Detected regions, sent without blocking. Optional.
+}
+
+// ProcessScan learns the revolution while the background is still learning, then detects the
+// regions, labels their points and reports them.
+func (d *Detector) ProcessScan(scan *ydlidar.Scan) error {
+	if d.Background.Revolutions() < d.Learn {
+		d.Background.Add(*scan)
+		return nil
+	}
+	for _, region := range d.Detect(*scan) {
+		if scan.Labels == nil {
+			scan.Labels = make([]string, len(scan.Points))
+		}
+		for _, i := range region.Indices {
+			scan.Labels[i] = Label
+		}
+		if d.Regions != nil {
+			select {
+			case d.Regions <- region:
+			default:
+			}
+		}
+	}
+	return nil
+}
+
+// Detect returns the regions of the revolution that moved, in scan order.
+func (d *Detector) Detect(scan ydlidar.Scan) []Region {
+	var regions []Region
+	var current *Region
+	static := 0
+	flush := func() {
+		if current != nil && current.Points >= d.MinPoints {
+			current.Center.X /= float64(current.Points)
+			current.Center.Y /= float64(current.Points)
+			regions = append(regions, *current)
+		}
+		current = nil
+	}
+
+	for i, point := range scan.Points {
+		if !scan.IsReturn(point.Dist) {
+			continue
+		}
+		dist := scan.Units.ToMillimeters(point.Dist)
+		moved, vacated := d.moved(point.Angle, dist)
+		if !moved {
+			if current != nil {
+				if static++; static > d.Gap {
+					flush()
+				}
+			}
+			continue
+		}
+		if current != nil && current.Vacated != vacated {
+			flush()
+		}
+		static = 0
+		if current == nil {
+			current = &Region{StartAngle: point.Angle, Nearest: dist, Vacated: vacated}
+		}
+		p := geom.FromPolar(point.Angle, dist)
+		current.EndAngle = point.Angle
+		current.Points++
+		current.Nearest = math.Min(current.Nearest, dist)
+		current.Center.X += p.X
+		current.Center.Y += p.Y
+		current.Indices = append(current.Indices, i)
+	}
+	flush()
+	return regions
+}
+
+// moved compares a return with the background.
+func (d *Detector) moved(angle, dist float64) (moved, vacated bool) {
+	ref, ok := d.Background.Range(angle)
+	if !ok {
+		// Nothing was there before.
+		return true, false
+	}
+	threshold := math.Max(d.Threshold, d.Sigmas*d.Background.StdDev(angle))
+	switch {
+	case ref-dist > threshold:
+		return true, false
+	case dist-ref > threshold:
+		return true, true
+	}
+	return false, false
+}
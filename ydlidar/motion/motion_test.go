@@ -0,0 +1,103 @@
+package motion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+// room is a revolution in a round room 3 m around the lidar, with a sample every half degree.
+// change overrides the distance of some angles.
+func room(change func(angle float64) (float64, bool)) ydlidar.Scan {
+	var scan ydlidar.Scan
+	for angle := 0.0; angle < 360; angle += 0.5 {
+		point := ydlidar.PointCloudData{Angle: angle, Dist: 3000}
+		if change != nil {
+			if dist, ok := change(angle); ok {
+				point.Dist = dist
+			}
+		}
+		scan.Points = append(scan.Points, point)
+	}
+	return scan
+}
+
+func TestDetect(t *testing.T) {
+	detector := &Detector{Background: NewBackground(0.5), Learn: 3, Threshold: 150, MinPoints: 3, Regions: make(chan Region, 4)}
+	for i := 0; i < 3; i++ {
+		scan := room(nil)
+		require.NoError(t, detector.ProcessScan(&scan))
+		assert.Nil(t, scan.Labels, "learning")
+	}
+	assert.Equal(t, 3, detector.Background.Revolutions())
+
+	// A person 1 m away on the left, a door opening behind, a lone noisy sample and a dropout.
+	scan := room(func(angle float64) (float64, bool) {
+		switch {
+		case angle >= 85 && angle <= 95:
+			return 1000, true
+		case angle >= 180 && angle < 185:
+			return 5000, true
+		case angle == 270:
+			return 2000, true
+		case angle == 300:
+			return 0, true
+		}
+		return 0, false
+	})
+	require.NoError(t, detector.ProcessScan(&scan))
+
+	require.Len(t, detector.Regions, 2)
+	person := <-detector.Regions
+	assert.Equal(t, 85.0, person.StartAngle)
+	assert.Equal(t, 95.0, person.EndAngle)
+	assert.Equal(t, 21, person.Points)
+	assert.Equal(t, 1000.0, person.Nearest)
+	assert.InDelta(t, 0, person.Center.X, 1)
+	assert.InDelta(t, 998.6, person.Center.Y, 0.1)
+	assert.False(t, person.Vacated)
+	door := <-detector.Regions
+	assert.True(t, door.Vacated)
+	assert.Equal(t, 10, door.Points)
+
+	assert.Equal(t, Label, scan.Labels[180])
+	assert.Equal(t, "", scan.Labels[0])
+}
+
+func TestDetectGap(t *testing.T) {
+	background := NewBackground(1)
+	background.Add(room(nil))
+	// Two legs with the wall seen between them.
+	scan := room(func(angle float64) (float64, bool) {
+		if (angle >= 10 && angle < 12) || (angle >= 13 && angle < 15) {
+			return 1500, true
+		}
+		return 0, false
+	})
+
+	detector := &Detector{Background: background, Threshold: 150, MinPoints: 5}
+	assert.Empty(t, detector.Detect(scan), "each leg is too small")
+	detector.Gap = 2
+	regions := detector.Detect(scan)
+	require.Len(t, regions, 1)
+	assert.Equal(t, 8, regions[0].Points)
+}
+
+func TestUnseenBackground(t *testing.T) {
+	background := NewBackground(1)
+	scan := room(nil)
+	scan.Points = scan.Points[:360] // Nothing seen from 180° on.
+	background.Add(scan)
+
+	_, ok := background.Range(-1)
+	assert.False(t, ok)
+	dist, ok := background.Range(90)
+	assert.True(t, ok)
+	assert.Equal(t, 3000.0, dist)
+
+	regions := (&Detector{Background: background, Threshold: 150, MinPoints: 3}).Detect(room(nil))
+	require.Len(t, regions, 1)
+	assert.Equal(t, 180.0, regions[0].StartAngle)
+}
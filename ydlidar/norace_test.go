@@ -0,0 +1,7 @@
+//go:build !race
+
+package ydlidar
+
+// raceEnabled reports whether the tests run with the race detector, which multiplies the memory
+// they take.
+const raceEnabled = false
@@ -0,0 +1,190 @@
+// Package pb is the protobuf schema of the lidar data, scan.proto, with the generated types
+// and helpers converting them to and from the ydlidar types. Services in other languages
+// generate their own types from scan.proto and read what MarshalScan and MarshalPacket write.
+//
+// The distances of the points and samples without a return are 0 whatever the invalid value
+// of the scan or packet, see ydlidar.WithInvalidValue, as scan.proto says.
+//
+// Regenerate scan.pb.go after changing scan.proto with:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative scan.proto
+package pb
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"ydlidarg2/ydlidar"
+)
+
+// MarshalScan encodes a revolution.
+func MarshalScan(scan ydlidar.Scan) ([]byte, error) {
+	return proto.Marshal(FromScan(scan))
+}
+
+// UnmarshalScan decodes a revolution written by MarshalScan.
+func UnmarshalScan(data []byte) (ydlidar.Scan, error) {
+	var msg Scan
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return ydlidar.Scan{}, err
+	}
+	return ToScan(&msg), nil
+}
+
+// MarshalPacket encodes a packet. The error of the packet is not encoded.
+func MarshalPacket(packet ydlidar.Packet) ([]byte, error) {
+	return proto.Marshal(FromPacket(packet))
+}
+
+// UnmarshalPacket decodes a packet written by MarshalPacket.
+func UnmarshalPacket(data []byte) (ydlidar.Packet, error) {
+	var msg Packet
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return ydlidar.Packet{}, err
+	}
+	return ToPacket(&msg), nil
+}
+
+// FromScan converts a revolution to its message.
+func FromScan(scan ydlidar.Scan) *Scan {
+	msg := &Scan{
+		Seq:          scan.Seq,
+		Points:       make([]*Point, len(scan.Points)),
+		Start:        timestamp(scan.Start),
+		End:          timestamp(scan.End),
+		Partial:      scan.Partial,
+		Labels:       scan.Labels,
+		Reflectivity: scan.Reflectivity,
+		Frequency:    scan.Frequency,
+		Stats: &ScanStats{
+			Points:           int32(scan.Stats.Points),
+			Valid:            int32(scan.Stats.Valid),
+			DropoutRatio:     scan.Stats.DropoutRatio,
+			MinRange:         scan.Stats.MinRange,
+			MeanRange:        scan.Stats.MeanRange,
+			MaxRange:         scan.Stats.MaxRange,
+			MeanIntensity:    scan.Stats.MeanIntensity,
+			ChecksumFailures: scan.Stats.ChecksumFailures,
+		},
+		Units: fromUnit(scan.Units),
+	}
+	for i, point := range scan.Points {
+		msg.Points[i] = &Point{Angle: point.Angle, Intensity: int32(point.Intensity), Synthetic: point.Synthetic}
+		if scan.IsReturn(point.Dist) {
+			msg.Points[i].Distance = point.Dist
+		}
+	}
+	return msg
+}
+
+// ToScan converts a message to a revolution.
+func ToScan(msg *Scan) ydlidar.Scan {
+	scan := ydlidar.Scan{
+		Seq:          msg.GetSeq(),
+		Points:       make([]ydlidar.PointCloudData, len(msg.GetPoints())),
+		Start:        fromTimestamp(msg.GetStart()),
+		End:          fromTimestamp(msg.GetEnd()),
+		Partial:      msg.GetPartial(),
+		Labels:       msg.GetLabels(),
+		Reflectivity: msg.GetReflectivity(),
+		Frequency:    msg.GetFrequency(),
+		Units:        toUnit(msg.GetUnits()),
+	}
+	for i, point := range msg.GetPoints() {
+		scan.Points[i] = ydlidar.PointCloudData{Angle: point.GetAngle(), Dist: point.GetDistance(), Intensity: int(point.GetIntensity()), Synthetic: point.GetSynthetic()}
+	}
+	if stats := msg.GetStats(); stats != nil {
+		scan.Stats = ydlidar.ScanStats{
+			Points:           int(stats.GetPoints()),
+			Valid:            int(stats.GetValid()),
+			DropoutRatio:     stats.GetDropoutRatio(),
+			MinRange:         stats.GetMinRange(),
+			MeanRange:        stats.GetMeanRange(),
+			MaxRange:         stats.GetMaxRange(),
+			MeanIntensity:    stats.GetMeanIntensity(),
+			ChecksumFailures: stats.GetChecksumFailures(),
+		}
+	}
+	return scan
+}
+
+// FromPacket converts a packet to its message.
+func FromPacket(packet ydlidar.Packet) *Packet {
+	msg := &Packet{
+		PacketType:  uint32(packet.PacketType),
+		Angles:      packet.Angles,
+		Distances:   dropoutsToZero(packet),
+		Intensities: make([]int32, len(packet.Intensities)),
+		Synthetic:   packet.Synthetic,
+		Units:       fromUnit(packet.Units),
+		Received:    timestamp(packet.Received),
+	}
+	for i, intensity := range packet.Intensities {
+		msg.Intensities[i] = int32(intensity)
+	}
+	return msg
+}
+
+// dropoutsToZero returns the distances of the packet with 0 for the samples without a return.
+// They are shared when the invalid value is already 0.
+func dropoutsToZero(packet ydlidar.Packet) []float64 {
+	if packet.Invalid == 0 {
+		return packet.Distances
+	}
+	distances := make([]float64, len(packet.Distances))
+	for i, dist := range packet.Distances {
+		if packet.IsReturn(dist) {
+			distances[i] = dist
+		}
+	}
+	return distances
+}
+
+// ToPacket converts a message to a packet.
+func ToPacket(msg *Packet) ydlidar.Packet {
+	packet := ydlidar.Packet{
+		PacketType:         uint8(msg.GetPacketType()),
+		NumDistanceSamples: len(msg.GetDistances()),
+		Angles:             msg.GetAngles(),
+		Distances:          msg.GetDistances(),
+		Intensities:        make([]int, len(msg.GetIntensities())),
+		Synthetic:          msg.GetSynthetic(),
+		Units:              toUnit(msg.GetUnits()),
+		Received:           fromTimestamp(msg.GetReceived()),
+	}
+	for i, intensity := range msg.GetIntensities() {
+		packet.Intensities[i] = int(intensity)
+	}
+	return packet
+}
+
+// timestamp converts a time, leaving the zero time unset.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// fromTimestamp converts a timestamp, an unset one to the zero time.
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func fromUnit(unit ydlidar.Unit) Unit {
+	if unit == ydlidar.Meters {
+		return Unit_UNIT_METERS
+	}
+	return Unit_UNIT_MILLIMETERS
+}
+
+func toUnit(unit Unit) ydlidar.Unit {
+	if unit == Unit_UNIT_METERS {
+		return ydlidar.Meters
+	}
+	return ydlidar.Millimeters
+}
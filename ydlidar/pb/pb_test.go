@@ -0,0 +1,73 @@
+package pb
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+var t0 = time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+
+func TestScanRoundTrip(t *testing.T) {
+	scan := ydlidar.Scan{
+		Seq:          7,
+		Points:       []ydlidar.PointCloudData{{Angle: 1.5, Dist: 1000, Intensity: 90}, {Angle: 2, Dist: 1010, Synthetic: true}},
+		Start:        t0,
+		End:          t0.Add(100 * time.Millisecond),
+		Labels:       []string{"wall", "wall"},
+		Reflectivity: []float64{0.5, 0.25},
+		Frequency:    10,
+		Stats:        ydlidar.ScanStats{Points: 2, Valid: 2, MinRange: 1000, MeanRange: 1005, MaxRange: 1010, MeanIntensity: 45},
+		Units:        ydlidar.Meters,
+	}
+
+	data, err := MarshalScan(scan)
+	require.NoError(t, err)
+	decoded, err := UnmarshalScan(data)
+	require.NoError(t, err)
+	assert.Equal(t, scan, decoded)
+}
+
+func TestPacketRoundTrip(t *testing.T) {
+	packet := ydlidar.Packet{
+		NumDistanceSamples: 2,
+		Angles:             []float64{10, 11},
+		Distances:          []float64{500, 0},
+		Intensities:        []int{30, 0},
+		Received:           t0,
+	}
+
+	data, err := MarshalPacket(packet)
+	require.NoError(t, err)
+	decoded, err := UnmarshalPacket(data)
+	require.NoError(t, err)
+	assert.Equal(t, packet, decoded)
+}
+
+func TestUnmarshalScanInvalid(t *testing.T) {
+	_, err := UnmarshalScan([]byte{0xFF})
+	assert.Error(t, err)
+}
+
+func TestInvalidValue(t *testing.T) {
+	// The dropouts are 0 whatever the invalid value, as scan.proto says.
+	scan := ydlidar.Scan{Invalid: math.NaN(), Points: []ydlidar.PointCloudData{{Angle: 1, Dist: 1000}, {Angle: 2, Dist: math.NaN()}}}
+	data, err := MarshalScan(scan)
+	require.NoError(t, err)
+	decoded, err := UnmarshalScan(data)
+	require.NoError(t, err)
+	assert.Equal(t, []ydlidar.PointCloudData{{Angle: 1, Dist: 1000}, {Angle: 2}}, decoded.Points)
+	assert.Zero(t, decoded.Invalid)
+
+	packet := ydlidar.Packet{Invalid: 12000, Distances: []float64{500, 12000}}
+	data, err = MarshalPacket(packet)
+	require.NoError(t, err)
+	decodedPacket, err := UnmarshalPacket(data)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{500, 0}, decodedPacket.Distances)
+	assert.Equal(t, []float64{500, 12000}, packet.Distances, "the packet is left alone")
+}
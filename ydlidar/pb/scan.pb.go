@@ -0,0 +1,654 @@
+// Schema of the lidar data for services in other languages, eg. a Python SLAM or a C++
+// planner. Fields are only ever added, never renumbered or reused.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        (unknown)
+// source: scan.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Unit of the distances.
+type Unit int32
+
+const (
+	Unit_UNIT_MILLIMETERS Unit = 0
+	Unit_UNIT_METERS      Unit = 1
+)
+
+// Enum value maps for Unit.
+var (
+	Unit_name = map[int32]string{
+		0: "UNIT_MILLIMETERS",
+		1: "UNIT_METERS",
+	}
+	Unit_value = map[string]int32{
+		"UNIT_MILLIMETERS": 0,
+		"UNIT_METERS":      1,
+	}
+)
+
+func (x Unit) Enum() *Unit {
+	p := new(Unit)
+	*p = x
+	return p
+}
+
+func (x Unit) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Unit) Descriptor() protoreflect.EnumDescriptor {
+	return file_scan_proto_enumTypes[0].Descriptor()
+}
+
+func (Unit) Type() protoreflect.EnumType {
+	return &file_scan_proto_enumTypes[0]
+}
+
+func (x Unit) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Unit.Descriptor instead.
+func (Unit) EnumDescriptor() ([]byte, []int) {
+	return file_scan_proto_rawDescGZIP(), []int{0}
+}
+
+// Point is one sample of a revolution.
+type Point struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Angle     float64 `protobuf:"fixed64,1,opt,name=angle,proto3" json:"angle,omitempty"`       // Degrees.
+	Distance  float64 `protobuf:"fixed64,2,opt,name=distance,proto3" json:"distance,omitempty"` // In the unit of the scan, 0 for a dropout.
+	Intensity int32   `protobuf:"varint,3,opt,name=intensity,proto3" json:"intensity,omitempty"`
+	Synthetic bool    `protobuf:"varint,4,opt,name=synthetic,proto3" json:"synthetic,omitempty"` // Interpolated over a dropout rather than measured.
+}
+
+func (x *Point) Reset() {
+	*x = Point{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scan_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Point) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Point) ProtoMessage() {}
+
+func (x *Point) ProtoReflect() protoreflect.Message {
+	mi := &file_scan_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Point.ProtoReflect.Descriptor instead.
+func (*Point) Descriptor() ([]byte, []int) {
+	return file_scan_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Point) GetAngle() float64 {
+	if x != nil {
+		return x.Angle
+	}
+	return 0
+}
+
+func (x *Point) GetDistance() float64 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+func (x *Point) GetIntensity() int32 {
+	if x != nil {
+		return x.Intensity
+	}
+	return 0
+}
+
+func (x *Point) GetSynthetic() bool {
+	if x != nil {
+		return x.Synthetic
+	}
+	return false
+}
+
+// Packet is a point cloud packet as sent by the device, the samples stored column wise.
+type Packet struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PacketType  uint32                 `protobuf:"varint,1,opt,name=packet_type,json=packetType,proto3" json:"packet_type,omitempty"` // 0 for a point cloud packet, 1 for a zero packet.
+	Angles      []float64              `protobuf:"fixed64,2,rep,packed,name=angles,proto3" json:"angles,omitempty"`                   // Degrees.
+	Distances   []float64              `protobuf:"fixed64,3,rep,packed,name=distances,proto3" json:"distances,omitempty"`             // In units, 0 for a dropout.
+	Intensities []int32                `protobuf:"varint,4,rep,packed,name=intensities,proto3" json:"intensities,omitempty"`          // Empty for models without intensity.
+	Synthetic   []bool                 `protobuf:"varint,5,rep,packed,name=synthetic,proto3" json:"synthetic,omitempty"`              // Empty unless a sample was interpolated.
+	Units       Unit                   `protobuf:"varint,6,opt,name=units,proto3,enum=ydlidar.v1.Unit" json:"units,omitempty"`
+	Received    *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=received,proto3" json:"received,omitempty"` // Arrival on the transport.
+}
+
+func (x *Packet) Reset() {
+	*x = Packet{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scan_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Packet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Packet) ProtoMessage() {}
+
+func (x *Packet) ProtoReflect() protoreflect.Message {
+	mi := &file_scan_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Packet.ProtoReflect.Descriptor instead.
+func (*Packet) Descriptor() ([]byte, []int) {
+	return file_scan_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Packet) GetPacketType() uint32 {
+	if x != nil {
+		return x.PacketType
+	}
+	return 0
+}
+
+func (x *Packet) GetAngles() []float64 {
+	if x != nil {
+		return x.Angles
+	}
+	return nil
+}
+
+func (x *Packet) GetDistances() []float64 {
+	if x != nil {
+		return x.Distances
+	}
+	return nil
+}
+
+func (x *Packet) GetIntensities() []int32 {
+	if x != nil {
+		return x.Intensities
+	}
+	return nil
+}
+
+func (x *Packet) GetSynthetic() []bool {
+	if x != nil {
+		return x.Synthetic
+	}
+	return nil
+}
+
+func (x *Packet) GetUnits() Unit {
+	if x != nil {
+		return x.Units
+	}
+	return Unit_UNIT_MILLIMETERS
+}
+
+func (x *Packet) GetReceived() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Received
+	}
+	return nil
+}
+
+// ScanStats are the quality statistics of a revolution.
+type ScanStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Points           int32   `protobuf:"varint,1,opt,name=points,proto3" json:"points,omitempty"`
+	Valid            int32   `protobuf:"varint,2,opt,name=valid,proto3" json:"valid,omitempty"`
+	DropoutRatio     float64 `protobuf:"fixed64,3,opt,name=dropout_ratio,json=dropoutRatio,proto3" json:"dropout_ratio,omitempty"`
+	MinRange         float64 `protobuf:"fixed64,4,opt,name=min_range,json=minRange,proto3" json:"min_range,omitempty"`
+	MeanRange        float64 `protobuf:"fixed64,5,opt,name=mean_range,json=meanRange,proto3" json:"mean_range,omitempty"`
+	MaxRange         float64 `protobuf:"fixed64,6,opt,name=max_range,json=maxRange,proto3" json:"max_range,omitempty"`
+	MeanIntensity    float64 `protobuf:"fixed64,7,opt,name=mean_intensity,json=meanIntensity,proto3" json:"mean_intensity,omitempty"`
+	ChecksumFailures uint64  `protobuf:"varint,8,opt,name=checksum_failures,json=checksumFailures,proto3" json:"checksum_failures,omitempty"`
+}
+
+func (x *ScanStats) Reset() {
+	*x = ScanStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scan_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ScanStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanStats) ProtoMessage() {}
+
+func (x *ScanStats) ProtoReflect() protoreflect.Message {
+	mi := &file_scan_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanStats.ProtoReflect.Descriptor instead.
+func (*ScanStats) Descriptor() ([]byte, []int) {
+	return file_scan_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ScanStats) GetPoints() int32 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+func (x *ScanStats) GetValid() int32 {
+	if x != nil {
+		return x.Valid
+	}
+	return 0
+}
+
+func (x *ScanStats) GetDropoutRatio() float64 {
+	if x != nil {
+		return x.DropoutRatio
+	}
+	return 0
+}
+
+func (x *ScanStats) GetMinRange() float64 {
+	if x != nil {
+		return x.MinRange
+	}
+	return 0
+}
+
+func (x *ScanStats) GetMeanRange() float64 {
+	if x != nil {
+		return x.MeanRange
+	}
+	return 0
+}
+
+func (x *ScanStats) GetMaxRange() float64 {
+	if x != nil {
+		return x.MaxRange
+	}
+	return 0
+}
+
+func (x *ScanStats) GetMeanIntensity() float64 {
+	if x != nil {
+		return x.MeanIntensity
+	}
+	return 0
+}
+
+func (x *ScanStats) GetChecksumFailures() uint64 {
+	if x != nil {
+		return x.ChecksumFailures
+	}
+	return 0
+}
+
+// Scan is one revolution of the lidar.
+type Scan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Seq          uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"` // Revolution number since the scan started.
+	Points       []*Point               `protobuf:"bytes,2,rep,name=points,proto3" json:"points,omitempty"`
+	Start        *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=start,proto3" json:"start,omitempty"`                        // Arrival of the zero packet starting the revolution.
+	End          *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=end,proto3" json:"end,omitempty"`                            // Arrival of the last packet.
+	Partial      bool                   `protobuf:"varint,5,opt,name=partial,proto3" json:"partial,omitempty"`                   // The scan stopped before the revolution completed.
+	Labels       []string               `protobuf:"bytes,6,rep,name=labels,proto3" json:"labels,omitempty"`                      // Per point labels, empty if none.
+	Reflectivity []float64              `protobuf:"fixed64,7,rep,packed,name=reflectivity,proto3" json:"reflectivity,omitempty"` // Per point normalized reflectivity, empty if none.
+	Frequency    float64                `protobuf:"fixed64,8,opt,name=frequency,proto3" json:"frequency,omitempty"`              // Rotation rate in Hz, 0 for a partial revolution.
+	Stats        *ScanStats             `protobuf:"bytes,9,opt,name=stats,proto3" json:"stats,omitempty"`
+	Units        Unit                   `protobuf:"varint,10,opt,name=units,proto3,enum=ydlidar.v1.Unit" json:"units,omitempty"`
+}
+
+func (x *Scan) Reset() {
+	*x = Scan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scan_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Scan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Scan) ProtoMessage() {}
+
+func (x *Scan) ProtoReflect() protoreflect.Message {
+	mi := &file_scan_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Scan.ProtoReflect.Descriptor instead.
+func (*Scan) Descriptor() ([]byte, []int) {
+	return file_scan_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Scan) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Scan) GetPoints() []*Point {
+	if x != nil {
+		return x.Points
+	}
+	return nil
+}
+
+func (x *Scan) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *Scan) GetEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
+func (x *Scan) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+func (x *Scan) GetLabels() []string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Scan) GetReflectivity() []float64 {
+	if x != nil {
+		return x.Reflectivity
+	}
+	return nil
+}
+
+func (x *Scan) GetFrequency() float64 {
+	if x != nil {
+		return x.Frequency
+	}
+	return 0
+}
+
+func (x *Scan) GetStats() *ScanStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+func (x *Scan) GetUnits() Unit {
+	if x != nil {
+		return x.Units
+	}
+	return Unit_UNIT_MILLIMETERS
+}
+
+var File_scan_proto protoreflect.FileDescriptor
+
+var file_scan_proto_rawDesc = []byte{
+	0x0a, 0x0a, 0x73, 0x63, 0x61, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0a, 0x79, 0x64,
+	0x6c, 0x69, 0x64, 0x61, 0x72, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x75, 0x0a, 0x05, 0x50, 0x6f, 0x69,
+	0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6e, 0x67, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x05, 0x61, 0x6e, 0x67, 0x6c, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x69, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x64, 0x69, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x69, 0x6e, 0x74, 0x65, 0x6e, 0x73, 0x69, 0x74,
+	0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x69, 0x6e, 0x74, 0x65, 0x6e, 0x73, 0x69,
+	0x74, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x79, 0x6e, 0x74, 0x68, 0x65, 0x74, 0x69, 0x63, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x73, 0x79, 0x6e, 0x74, 0x68, 0x65, 0x74, 0x69, 0x63,
+	0x22, 0xff, 0x01, 0x0a, 0x06, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x70,
+	0x61, 0x63, 0x6b, 0x65, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x0a, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x61, 0x6e, 0x67, 0x6c, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x01, 0x52, 0x06, 0x61, 0x6e,
+	0x67, 0x6c, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x01, 0x52, 0x09, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63,
+	0x65, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x69, 0x6e, 0x74, 0x65, 0x6e, 0x73, 0x69, 0x74, 0x69, 0x65,
+	0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x05, 0x52, 0x0b, 0x69, 0x6e, 0x74, 0x65, 0x6e, 0x73, 0x69,
+	0x74, 0x69, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x79, 0x6e, 0x74, 0x68, 0x65, 0x74, 0x69,
+	0x63, 0x18, 0x05, 0x20, 0x03, 0x28, 0x08, 0x52, 0x09, 0x73, 0x79, 0x6e, 0x74, 0x68, 0x65, 0x74,
+	0x69, 0x63, 0x12, 0x26, 0x0a, 0x05, 0x75, 0x6e, 0x69, 0x74, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x10, 0x2e, 0x79, 0x64, 0x6c, 0x69, 0x64, 0x61, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x55,
+	0x6e, 0x69, 0x74, 0x52, 0x05, 0x75, 0x6e, 0x69, 0x74, 0x73, 0x12, 0x36, 0x0a, 0x08, 0x72, 0x65,
+	0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76,
+	0x65, 0x64, 0x22, 0x8b, 0x02, 0x0a, 0x09, 0x53, 0x63, 0x61, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x12, 0x16, 0x0a, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x69,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x12, 0x23,
+	0x0a, 0x0d, 0x64, 0x72, 0x6f, 0x70, 0x6f, 0x75, 0x74, 0x5f, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0c, 0x64, 0x72, 0x6f, 0x70, 0x6f, 0x75, 0x74, 0x52, 0x61,
+	0x74, 0x69, 0x6f, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x69, 0x6e, 0x5f, 0x72, 0x61, 0x6e, 0x67, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x6d, 0x69, 0x6e, 0x52, 0x61, 0x6e, 0x67, 0x65,
+	0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x65, 0x61, 0x6e, 0x5f, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x6d, 0x65, 0x61, 0x6e, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x12,
+	0x1b, 0x0a, 0x09, 0x6d, 0x61, 0x78, 0x5f, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x08, 0x6d, 0x61, 0x78, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x25, 0x0a, 0x0e,
+	0x6d, 0x65, 0x61, 0x6e, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x6e, 0x73, 0x69, 0x74, 0x79, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x0d, 0x6d, 0x65, 0x61, 0x6e, 0x49, 0x6e, 0x74, 0x65, 0x6e, 0x73,
+	0x69, 0x74, 0x79, 0x12, 0x2b, 0x0a, 0x11, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x5f,
+	0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x04, 0x52, 0x10,
+	0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73,
+	0x22, 0xec, 0x02, 0x0a, 0x04, 0x53, 0x63, 0x61, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x29, 0x0a, 0x06, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x79, 0x64,
+	0x6c, 0x69, 0x64, 0x61, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x06,
+	0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x30, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x2c, 0x0a, 0x03, 0x65, 0x6e, 0x64, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x03, 0x65, 0x6e, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x72, 0x74, 0x69, 0x61,
+	0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x70, 0x61, 0x72, 0x74, 0x69, 0x61, 0x6c,
+	0x12, 0x16, 0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x72, 0x65, 0x66, 0x6c,
+	0x65, 0x63, 0x74, 0x69, 0x76, 0x69, 0x74, 0x79, 0x18, 0x07, 0x20, 0x03, 0x28, 0x01, 0x52, 0x0c,
+	0x72, 0x65, 0x66, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x76, 0x69, 0x74, 0x79, 0x12, 0x1c, 0x0a, 0x09,
+	0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x2b, 0x0a, 0x05, 0x73, 0x74,
+	0x61, 0x74, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x79, 0x64, 0x6c, 0x69,
+	0x64, 0x61, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x73, 0x12, 0x26, 0x0a, 0x05, 0x75, 0x6e, 0x69, 0x74, 0x73,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x79, 0x64, 0x6c, 0x69, 0x64, 0x61, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x55, 0x6e, 0x69, 0x74, 0x52, 0x05, 0x75, 0x6e, 0x69, 0x74, 0x73, 0x2a,
+	0x2d, 0x0a, 0x04, 0x55, 0x6e, 0x69, 0x74, 0x12, 0x14, 0x0a, 0x10, 0x55, 0x4e, 0x49, 0x54, 0x5f,
+	0x4d, 0x49, 0x4c, 0x4c, 0x49, 0x4d, 0x45, 0x54, 0x45, 0x52, 0x53, 0x10, 0x00, 0x12, 0x0f, 0x0a,
+	0x0b, 0x55, 0x4e, 0x49, 0x54, 0x5f, 0x4d, 0x45, 0x54, 0x45, 0x52, 0x53, 0x10, 0x01, 0x42, 0x16,
+	0x5a, 0x14, 0x79, 0x64, 0x6c, 0x69, 0x64, 0x61, 0x72, 0x67, 0x32, 0x2f, 0x79, 0x64, 0x6c, 0x69,
+	0x64, 0x61, 0x72, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_scan_proto_rawDescOnce sync.Once
+	file_scan_proto_rawDescData = file_scan_proto_rawDesc
+)
+
+func file_scan_proto_rawDescGZIP() []byte {
+	file_scan_proto_rawDescOnce.Do(func() {
+		file_scan_proto_rawDescData = protoimpl.X.CompressGZIP(file_scan_proto_rawDescData)
+	})
+	return file_scan_proto_rawDescData
+}
+
+var file_scan_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_scan_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_scan_proto_goTypes = []interface{}{
+	(Unit)(0),                     // 0: ydlidar.v1.Unit
+	(*Point)(nil),                 // 1: ydlidar.v1.Point
+	(*Packet)(nil),                // 2: ydlidar.v1.Packet
+	(*ScanStats)(nil),             // 3: ydlidar.v1.ScanStats
+	(*Scan)(nil),                  // 4: ydlidar.v1.Scan
+	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
+}
+var file_scan_proto_depIdxs = []int32{
+	0, // 0: ydlidar.v1.Packet.units:type_name -> ydlidar.v1.Unit
+	5, // 1: ydlidar.v1.Packet.received:type_name -> google.protobuf.Timestamp
+	1, // 2: ydlidar.v1.Scan.points:type_name -> ydlidar.v1.Point
+	5, // 3: ydlidar.v1.Scan.start:type_name -> google.protobuf.Timestamp
+	5, // 4: ydlidar.v1.Scan.end:type_name -> google.protobuf.Timestamp
+	3, // 5: ydlidar.v1.Scan.stats:type_name -> ydlidar.v1.ScanStats
+	0, // 6: ydlidar.v1.Scan.units:type_name -> ydlidar.v1.Unit
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_scan_proto_init() }
+func file_scan_proto_init() {
+	if File_scan_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_scan_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Point); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_scan_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Packet); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_scan_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ScanStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_scan_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Scan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_scan_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_scan_proto_goTypes,
+		DependencyIndexes: file_scan_proto_depIdxs,
+		EnumInfos:         file_scan_proto_enumTypes,
+		MessageInfos:      file_scan_proto_msgTypes,
+	}.Build()
+	File_scan_proto = out.File
+	file_scan_proto_rawDesc = nil
+	file_scan_proto_goTypes = nil
+	file_scan_proto_depIdxs = nil
+}
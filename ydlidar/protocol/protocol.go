@@ -0,0 +1,153 @@
+// Package protocol names the commands, the response type codes and the framing constants of
+// the YDLidar serial protocol of the triangulation lidars (G2, G4, G6, X4, TG series), and
+// encodes and decodes the 7 byte response header. It has no dependency, the driver and the
+// simulator build on it.
+//
+// A command is the CommandPrefix byte followed by the command byte. The commands with an
+// answer get a response header followed by Length bytes, or by the scan packets for a
+// continuous response:
+//
+//	port.Write(protocol.Command(protocol.CmdDeviceInfo))
+//	header, err := protocol.DecodeResponseHeader(buf[:protocol.ResponseHeaderSize])
+//
+// Prefer YDLidar.RawCommand over writing to the port, it pauses a running scan meanwhile.
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// CommandPrefix starts every command.
+const CommandPrefix = 0xA5
+
+// Commands, each sent after CommandPrefix.
+const (
+	// CmdStartScan starts scanning, answered with a continuous response of type TypeScan
+	// followed by the scan packets.
+	CmdStartScan = 0x60
+
+	// CmdStopScan stops scanning, not answered.
+	CmdStopScan = 0x65
+
+	// CmdDeviceInfo asks the model, the versions and the serial number, answered with 20 bytes
+	// of type TypeDeviceInfo.
+	CmdDeviceInfo = 0x90
+
+	// CmdHealth asks the health status, answered with 3 bytes of type TypeHealth: the status
+	// and the error code.
+	CmdHealth = 0x92
+
+	// CmdRestart soft reboots the device, answered with a boot banner rather than a response.
+	CmdRestart = 0x40
+
+	// CmdGetScanFrequency asks the scan frequency, answered with 4 bytes of type
+	// TypeDeviceInfo in 0.01Hz.
+	CmdGetScanFrequency = 0x0D
+
+	// CmdIncreaseFrequencyLarge raises the scan frequency by 1Hz, answered like
+	// CmdGetScanFrequency with the new frequency.
+	CmdIncreaseFrequencyLarge = 0x0B
+
+	// CmdDecreaseFrequencyLarge lowers the scan frequency by 1Hz.
+	CmdDecreaseFrequencyLarge = 0x0C
+
+	// CmdIncreaseFrequencySmall raises the scan frequency by 0.1Hz.
+	CmdIncreaseFrequencySmall = 0x09
+
+	// CmdDecreaseFrequencySmall lowers the scan frequency by 0.1Hz.
+	CmdDecreaseFrequencySmall = 0x0A
+
+	// CmdSetRangingFrequency steps the ranging frequency to the next supported value, answered
+	// with 1 byte of type TypeDeviceInfo, the index of the new frequency. Models with an
+	// adjustable ranging frequency only.
+	CmdSetRangingFrequency = 0xD0
+
+	// CmdGetRangingFrequency asks the index of the ranging frequency, answered like
+	// CmdSetRangingFrequency.
+	CmdGetRangingFrequency = 0xD1
+)
+
+// Response type codes, the last byte of the response header.
+const (
+	// TypeDeviceInfo answers the device info, frequency and ranging commands.
+	TypeDeviceInfo = 0x04
+
+	// TypeHealth answers the health command.
+	TypeHealth = 0x06
+
+	// TypeScan answers the start scan command.
+	TypeScan = 0x81
+)
+
+// Response modes, the high 2 bits of the length field.
+const (
+	// ModeSingle a single response of Length bytes.
+	ModeSingle = 0x0
+
+	// ModeContinuous a response followed by a stream of packets, Length is that of a packet
+	// header.
+	ModeContinuous = 0x1
+)
+
+const (
+	// ResponseSign starts every response, 0xA5 0x5A on the wire.
+	ResponseSign = 0x5AA5
+
+	// ResponseHeaderSize is the size of the response header.
+	ResponseHeaderSize = 7
+
+	// ScanPacketHeader starts every scan packet, 0xAA 0x55 on the wire.
+	ScanPacketHeader = 0x55AA
+
+	// ScanPacketHeaderSize is the size of the scan packet header: PH, CT, LSN, FSA, LSA and CS.
+	ScanPacketHeaderSize = 10
+
+	// maxLength is the largest length the 30 bits of the length field hold.
+	maxLength = 1<<30 - 1
+)
+
+var (
+	// ErrShortHeader is returned by DecodeResponseHeader for fewer than ResponseHeaderSize bytes.
+	ErrShortHeader = errors.New("protocol: short response header")
+
+	// ErrBadSign is returned by DecodeResponseHeader when the bytes don't start with
+	// ResponseSign.
+	ErrBadSign = errors.New("protocol: bad response sign")
+)
+
+// Command returns the bytes of the command.
+func Command(command byte) []byte {
+	return []byte{CommandPrefix, command}
+}
+
+// ResponseHeader is the header of a response: the length of the data in 30 bits, the mode
+// in 2 bits and the type code.
+type ResponseHeader struct {
+	Length   uint32 // Length of the data following the header, of a packet header if continuous.
+	Mode     byte   // ModeSingle or ModeContinuous.
+	TypeCode byte   // TypeDeviceInfo, TypeHealth or TypeScan.
+}
+
+// Encode returns the header as sent by the device. Lengths beyond 30 bits and modes beyond 2
+// bits are truncated.
+func (h ResponseHeader) Encode() []byte {
+	b := make([]byte, ResponseHeaderSize)
+	binary.LittleEndian.PutUint16(b, ResponseSign)
+	binary.LittleEndian.PutUint32(b[2:], h.Length&maxLength|uint32(h.Mode&0x03)<<30)
+	b[6] = h.TypeCode
+	return b
+}
+
+// DecodeResponseHeader decodes the first ResponseHeaderSize bytes of b.
+func DecodeResponseHeader(b []byte) (ResponseHeader, error) {
+	if len(b) < ResponseHeaderSize {
+		return ResponseHeader{}, fmt.Errorf("%w: expected %v bytes got %v", ErrShortHeader, ResponseHeaderSize, len(b))
+	}
+	if sign := binary.LittleEndian.Uint16(b); sign != ResponseSign {
+		return ResponseHeader{}, fmt.Errorf("%w: expected %04X got %04X", ErrBadSign, ResponseSign, sign)
+	}
+	field := binary.LittleEndian.Uint32(b[2:])
+	return ResponseHeader{Length: field & maxLength, Mode: byte(field >> 30), TypeCode: b[6]}, nil
+}
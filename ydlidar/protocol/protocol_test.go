@@ -0,0 +1,34 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand(t *testing.T) {
+	assert.Equal(t, []byte{0xA5, 0x90}, Command(CmdDeviceInfo))
+}
+
+func TestResponseHeader(t *testing.T) {
+	info := ResponseHeader{Length: 20, Mode: ModeSingle, TypeCode: TypeDeviceInfo}
+	assert.Equal(t, []byte{0xA5, 0x5A, 0x14, 0x00, 0x00, 0x00, 0x04}, info.Encode())
+
+	scan := ResponseHeader{Length: 5, Mode: ModeContinuous, TypeCode: TypeScan}
+	assert.Equal(t, []byte{0xA5, 0x5A, 0x05, 0x00, 0x00, 0x40, 0x81}, scan.Encode())
+
+	for _, header := range []ResponseHeader{info, scan, {Length: maxLength, Mode: 3, TypeCode: 0xFF}} {
+		decoded, err := DecodeResponseHeader(header.Encode())
+		require.NoError(t, err)
+		assert.Equal(t, header, decoded)
+	}
+}
+
+func TestDecodeResponseHeaderErrors(t *testing.T) {
+	_, err := DecodeResponseHeader([]byte{0xA5, 0x5A, 0x14})
+	assert.ErrorIs(t, err, ErrShortHeader)
+
+	_, err = DecodeResponseHeader([]byte{0xAA, 0x55, 0x14, 0x00, 0x00, 0x00, 0x04})
+	assert.ErrorIs(t, err, ErrBadSign)
+}
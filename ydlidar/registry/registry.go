@@ -0,0 +1,265 @@
+// Package registry remembers the lidars of a robot by serial number, so each unit gets back
+// its logical role, eg. front-left, after reboots and USB port renumbering.
+//
+// An Entry holds the role of a lidar with its preferred settings: the port it was last seen
+// on, its mount pose on the robot and its intensity calibration. The registry is a JSON file,
+// in the user config directory by default:
+//
+//	reg, err := registry.Open("")
+//	reg.Put(registry.Entry{Serial: "20210314000000AF", Role: "front", Mount: geom.Pose2D{X: 300}})
+//	err = reg.Save()
+//
+// Connect then finds the registered lidars among the serial ports and adds them to a
+// Manager under their roles.
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/calib"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Entry is what the registry remembers of a lidar.
+type Entry struct {
+	Serial      string      `json:"serial"`                // Serial number reported by DeviceInfo.
+	Role        string      `json:"role"`                  // Logical role, the name of the lidar in the Manager.
+	Port        string      `json:"port,omitempty"`        // Port the lidar was last seen on, tried first.
+	Mount       geom.Pose2D `json:"mount"`                 // Pose of the lidar on the robot, in millimeters.
+	Calibration string      `json:"calibration,omitempty"` // Path of the calib.Table of the lidar, empty if uncalibrated.
+	LastSeen    time.Time   `json:"lastSeen,omitempty"`    // Last time Connect found the lidar.
+}
+
+// LoadCalibration loads the calibration table of the lidar, nil if it has none.
+func (e Entry) LoadCalibration() (*calib.Table, error) {
+	if e.Calibration == "" {
+		return nil, nil
+	}
+	return calib.Load(e.Calibration)
+}
+
+// ErrUnknownLidar is returned for a serial number the registry doesn't know.
+var ErrUnknownLidar = errors.New("registry: unknown lidar")
+
+// Registry is the set of known lidars, persisted in a JSON file. It is safe for concurrent use.
+type Registry struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]Entry // By serial number.
+
+	// Hooks replaced by the tests.
+	ports func() ([]string, error)
+	open  func(port string, opts ...ydlidar.Option) (*ydlidar.YDLidar, error)
+}
+
+// DefaultPath returns the path of the registry in the user config directory, eg.
+// ~/.config/ydlidar/registry.json on Linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ydlidar", "registry.json"), nil
+}
+
+// Open reads the registry at path, DefaultPath if empty. A missing file is an empty registry.
+func Open(path string) (*Registry, error) {
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	r := &Registry{
+		path:    path,
+		entries: map[string]Entry{},
+		ports:   ydlidar.CandidatePorts,
+		open: func(port string, opts ...ydlidar.Option) (*ydlidar.YDLidar, error) {
+			return ydlidar.InitAndConnectToDevice(&port, opts...)
+		},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid registry %v: %v", path, err)
+	}
+	for _, entry := range entries {
+		r.entries[entry.Serial] = entry
+	}
+	return r, nil
+}
+
+// Path returns the path of the registry file.
+func (r *Registry) Path() string {
+	return r.path
+}
+
+// Save writes the registry, creating its directory if needed. The file is replaced
+// atomically, a crash leaves the previous registry.
+func (r *Registry) Save() error {
+	data, err := json.MarshalIndent(r.Entries(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// Put adds or replaces the entry of a lidar. Roles are unique: the role can't be held by
+// another lidar.
+func (r *Registry) Put(entry Entry) error {
+	if entry.Serial == "" || entry.Role == "" {
+		return fmt.Errorf("registry: entry needs a serial number and a role, got %q and %q", entry.Serial, entry.Role)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for serial, other := range r.entries {
+		if serial != entry.Serial && other.Role == entry.Role {
+			return fmt.Errorf("registry: role %q is held by lidar %v", entry.Role, serial)
+		}
+	}
+	r.entries[entry.Serial] = entry
+	return nil
+}
+
+// Get returns the entry of the lidar with the serial number.
+func (r *Registry) Get(serial string) (Entry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.entries[serial]
+	return entry, ok
+}
+
+// Role returns the entry of the lidar holding the role.
+func (r *Registry) Role(role string) (Entry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, entry := range r.entries {
+		if entry.Role == role {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Remove forgets the lidar with the serial number.
+func (r *Registry) Remove(serial string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[serial]; !ok {
+		return fmt.Errorf("%w: %v", ErrUnknownLidar, serial)
+	}
+	delete(r.entries, serial)
+	return nil
+}
+
+// Entries returns the entries ordered by role.
+func (r *Registry) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]Entry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Role < entries[j].Role })
+	return entries
+}
+
+// Connect connects to the registered lidars and adds each one to the manager under its role.
+// The port each lidar was last seen on is tried first, then the other candidate ports, so a
+// lidar found on another port after a renumbering is still given its role. The ports and
+// last seen times of the entries are updated, Save persists them. Lidars the registry doesn't
+// know are closed. Returns the roles of the lidars that weren't found.
+func (r *Registry) Connect(manager *ydlidar.Manager, opts ...ydlidar.Option) (missing []string, err error) {
+	ports, err := r.ports()
+	if err != nil {
+		return nil, err
+	}
+	tried := map[string]bool{}
+	var order []string
+	for _, entry := range r.Entries() {
+		if entry.Port != "" && !tried[entry.Port] {
+			tried[entry.Port] = true
+			order = append(order, entry.Port)
+		}
+	}
+	for _, port := range ports {
+		if !tried[port] {
+			tried[port] = true
+			order = append(order, port)
+		}
+	}
+
+	found := map[string]bool{}
+	for _, port := range order {
+		if len(found) == len(r.Entries()) {
+			break
+		}
+		lidar, err := r.open(port, opts...)
+		if err != nil {
+			log.Printf("Registry: no lidar on %v: %v", port, err)
+			continue
+		}
+		if err = r.adopt(manager, port, lidar, found); err != nil {
+			log.Printf("Registry: %v", err)
+			lidar.Close()
+		}
+	}
+
+	for _, entry := range r.Entries() {
+		if !found[entry.Serial] {
+			missing = append(missing, entry.Role)
+		}
+	}
+	return missing, nil
+}
+
+// adopt adds the lidar connected on the port to the manager under its registered role.
+func (r *Registry) adopt(manager *ydlidar.Manager, port string, lidar *ydlidar.YDLidar, found map[string]bool) error {
+	info, err := lidar.DeviceInfo()
+	if err != nil {
+		return fmt.Errorf("device info on %v: %v", port, err)
+	}
+	entry, ok := r.Get(info.SerialNumber)
+	if !ok {
+		return fmt.Errorf("%w %v on %v", ErrUnknownLidar, info.SerialNumber, port)
+	}
+	if found[entry.Serial] {
+		return fmt.Errorf("lidar %v found again on %v", entry.Serial, port)
+	}
+	if err = manager.Add(entry.Role, lidar); err != nil {
+		return err
+	}
+	found[entry.Serial] = true
+	if entry.Port != port {
+		log.Printf("Registry: lidar %v (%v) moved from %v to %v", entry.Serial, entry.Role, entry.Port, port)
+	}
+	entry.Port, entry.LastSeen = port, time.Now()
+	r.mu.Lock()
+	r.entries[entry.Serial] = entry
+	r.mu.Unlock()
+	return nil
+}
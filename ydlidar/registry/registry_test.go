@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+	"ydlidarg2/ydlidar/sim"
+)
+
+func TestSaveOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ydlidar", "registry.json")
+	reg, err := Open(path)
+	require.NoError(t, err)
+	assert.Empty(t, reg.Entries(), "a missing file is an empty registry")
+
+	require.NoError(t, reg.Put(Entry{Serial: "1111", Role: "rear", Mount: geom.Pose2D{X: -300, Heading: 180}}))
+	require.NoError(t, reg.Put(Entry{Serial: "2222", Role: "front", Port: "/dev/ttyUSB0"}))
+	assert.Error(t, reg.Put(Entry{Serial: "3333", Role: "front"}), "the role is taken")
+	assert.Error(t, reg.Put(Entry{Role: "left"}), "no serial number")
+	require.NoError(t, reg.Save())
+
+	loaded, err := Open(path)
+	require.NoError(t, err)
+	entries := loaded.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "front", entries[0].Role)
+	rear, ok := loaded.Role("rear")
+	require.True(t, ok)
+	assert.Equal(t, geom.Pose2D{X: -300, Heading: 180}, rear.Mount)
+
+	require.NoError(t, loaded.Remove("1111"))
+	assert.ErrorIs(t, loaded.Remove("1111"), ErrUnknownLidar)
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+	_, err = Open(path)
+	assert.Error(t, err)
+}
+
+func TestConnect(t *testing.T) {
+	reg, err := Open(filepath.Join(t.TempDir(), "registry.json"))
+	require.NoError(t, err)
+	// The lidars swapped ports since they were registered.
+	require.NoError(t, reg.Put(Entry{Serial: "0000000000000001", Role: "front", Port: "/dev/ttyUSB0"}))
+	require.NoError(t, reg.Put(Entry{Serial: "0000000000000002", Role: "rear", Port: "/dev/ttyUSB1"}))
+	require.NoError(t, reg.Put(Entry{Serial: "0000000000000009", Role: "left"}))
+
+	devices := map[string]string{
+		"/dev/ttyUSB0": "0000000000000002",
+		"/dev/ttyUSB1": "0000000000000001",
+		"/dev/ttyUSB2": "0000000000000003", // Not registered.
+	}
+	reg.ports = func() ([]string, error) {
+		return []string{"/dev/ttyUSB0", "/dev/ttyUSB1", "/dev/ttyUSB2", "/dev/ttyUSB3"}, nil
+	}
+	reg.open = func(port string, opts ...ydlidar.Option) (*ydlidar.YDLidar, error) {
+		serial, ok := devices[port]
+		if !ok {
+			return nil, errors.New("no such device")
+		}
+		return ydlidar.ConnectTransport(sim.NewPort(&sim.Device{Serial: serial}), opts...)
+	}
+
+	manager := ydlidar.NewManager()
+	defer manager.Close()
+	missing, err := reg.Connect(manager)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"left"}, missing)
+	assert.Equal(t, []string{"front", "rear"}, manager.Names())
+
+	front, _ := reg.Role("front")
+	assert.Equal(t, "/dev/ttyUSB1", front.Port)
+	assert.False(t, front.LastSeen.IsZero())
+	info, err := manager.Lidar("front").DeviceInfo()
+	require.NoError(t, err)
+	assert.Equal(t, "0000000000000001", info.SerialNumber)
+}
+
+func TestLoadCalibration(t *testing.T) {
+	table, err := Entry{}.LoadCalibration()
+	assert.NoError(t, err)
+	assert.Nil(t, table)
+	_, err = Entry{Calibration: filepath.Join(t.TempDir(), "missing.json")}.LoadCalibration()
+	assert.Error(t, err)
+}
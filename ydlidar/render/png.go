@@ -0,0 +1,13 @@
+package render
+
+import (
+	"image/png"
+	"io"
+
+	"ydlidarg2/ydlidar"
+)
+
+// PNG draws the scans and writes the image as a PNG.
+func PNG(w io.Writer, scans []ydlidar.Scan, opts ...Option) error {
+	return png.Encode(w, Image(scans, opts...))
+}
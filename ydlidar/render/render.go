@@ -0,0 +1,222 @@
+// Package render draws scans as top-down images, PNG or SVG, for logs, reports and quick
+// debugging over SSH. The lidar is in the center, X forward is up and Y left is left, like
+// in the ydlidar-view terminal view. Several scans are drawn on top of each other, eg. the
+// revolutions of an accumulation window.
+//
+//	f, _ := os.Create("scan.png")
+//	err := render.PNG(f, []ydlidar.Scan{scan}, render.WithRange(4000), render.WithRangeRings(1000))
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Colormap maps an intensity scaled to 0-1 to a color.
+type Colormap func(t float64) color.RGBA
+
+// heatStops are the colors of Heat, from low to high intensity, matching ydlidar-view.
+var heatStops = []color.RGBA{
+	{0, 0, 255, 255},
+	{0, 128, 128, 255},
+	{0, 128, 0, 255},
+	{255, 255, 0, 255},
+	{255, 0, 0, 255},
+}
+
+// Heat colors the points from blue for low intensities to red for high ones.
+func Heat(t float64) color.RGBA {
+	t = math.Max(0, math.Min(1, t)) * float64(len(heatStops)-1)
+	i := int(t)
+	if i == len(heatStops)-1 {
+		return heatStops[i]
+	}
+	a, b, f := heatStops[i], heatStops[i+1], t-float64(i)
+	mix := func(x, y uint8) uint8 { return uint8(math.Round(float64(x) + (float64(y)-float64(x))*f)) }
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
+
+// Gray colors the points from dark gray for low intensities to white for high ones.
+func Gray(t float64) color.RGBA {
+	v := uint8(64 + math.Round(191*math.Max(0, math.Min(1, t))))
+	return color.RGBA{v, v, v, 255}
+}
+
+// Solid colors every point c, for models without intensity.
+func Solid(c color.RGBA) Colormap {
+	return func(float64) color.RGBA { return c }
+}
+
+var (
+	background = color.RGBA{0, 0, 0, 255}
+	ringColor  = color.RGBA{64, 64, 64, 255}
+	originMark = color.RGBA{255, 255, 255, 255}
+)
+
+// Option configures the rendering.
+type Option func(*config)
+
+type config struct {
+	size         int     // Width and height in pixels.
+	rangeMM      float64 // Distance from the center to the edges, 0 to fit the points.
+	ringMM       float64 // Distance between range rings, 0 for none.
+	pointSize    float64 // Diameter of the points in pixels.
+	colormap     Colormap
+	maxIntensity int // Intensity mapped to the top of the colormap.
+}
+
+func newConfig(opts []Option) config {
+	c := config{size: 800, pointSize: 2, colormap: Heat, maxIntensity: 1023}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// WithSize sets the width and height of the image in pixels, 800 by default.
+func WithSize(pixels int) Option {
+	return func(c *config) {
+		c.size = pixels
+	}
+}
+
+// WithRange sets the distance in millimeters from the lidar to the edges of the image. By
+// default the image fits the furthest point.
+func WithRange(mm float64) Option {
+	return func(c *config) {
+		c.rangeMM = mm
+	}
+}
+
+// WithRangeRings draws a circle every mm millimeters around the lidar.
+func WithRangeRings(mm float64) Option {
+	return func(c *config) {
+		c.ringMM = mm
+	}
+}
+
+// WithPointSize sets the diameter of the points in pixels, 2 by default.
+func WithPointSize(pixels float64) Option {
+	return func(c *config) {
+		c.pointSize = pixels
+	}
+}
+
+// WithColormap sets the colors of the points, Heat by default.
+func WithColormap(colormap Colormap) Option {
+	return func(c *config) {
+		c.colormap = colormap
+	}
+}
+
+// WithMaxIntensity sets the intensity mapped to the top of the colormap, 1023 by default.
+func WithMaxIntensity(max int) Option {
+	return func(c *config) {
+		c.maxIntensity = max
+	}
+}
+
+// point is a point to draw, in pixels.
+type point struct {
+	x, y  float64
+	color color.RGBA
+}
+
+// layout places the points of the scans and returns them with the scale in pixels per mm.
+func (c config) layout(scans []ydlidar.Scan) ([]point, float64) {
+	rangeMM := c.rangeMM
+	if rangeMM <= 0 {
+		for _, scan := range scans {
+			for _, p := range scan.Points {
+				rangeMM = math.Max(rangeMM, scan.Units.ToMillimeters(p.Dist))
+			}
+		}
+		rangeMM *= 1.05
+		if rangeMM == 0 {
+			rangeMM = 1000
+		}
+	}
+	scale := float64(c.size) / 2 / rangeMM
+
+	var points []point
+	for _, scan := range scans {
+		for _, p := range scan.Points {
+			if !scan.IsReturn(p.Dist) {
+				continue
+			}
+			x, y := c.project(geom.FromPolar(p.Angle, scan.Units.ToMillimeters(p.Dist)), scale)
+			t := 1.0
+			if c.maxIntensity > 0 {
+				t = float64(p.Intensity) / float64(c.maxIntensity)
+			}
+			points = append(points, point{x: x, y: y, color: c.colormap(t)})
+		}
+	}
+	return points, scale
+}
+
+// project returns the pixel of p, in millimeters in the lidar frame.
+func (c config) project(p geom.Point, scale float64) (float64, float64) {
+	// X forward is up, Y left is left.
+	center := float64(c.size) / 2
+	return center - p.Y*scale, center - p.X*scale
+}
+
+// rings returns the radius in pixels of the range rings in the image.
+func (c config) rings(scale float64) []float64 {
+	var radii []float64
+	if c.ringMM <= 0 {
+		return nil
+	}
+	for r := c.ringMM * scale; r <= float64(c.size)/2*math.Sqrt2; r += c.ringMM * scale {
+		radii = append(radii, r)
+	}
+	return radii
+}
+
+// Image draws the scans.
+func Image(scans []ydlidar.Scan, opts ...Option) *image.RGBA {
+	c := newConfig(opts)
+	img := image.NewRGBA(image.Rect(0, 0, c.size, c.size))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = background.R, background.G, background.B, background.A
+	}
+
+	points, scale := c.layout(scans)
+	center := float64(c.size) / 2
+	for _, r := range c.rings(scale) {
+		// A dot every pixel of the circumference closes the ring.
+		steps := int(2*math.Pi*r) + 1
+		for i := 0; i < steps; i++ {
+			a := 2 * math.Pi * float64(i) / float64(steps)
+			img.SetRGBA(int(center+r*math.Cos(a)), int(center+r*math.Sin(a)), ringColor)
+		}
+	}
+	for d := -3; d <= 3; d++ {
+		img.SetRGBA(int(center)+d, int(center), originMark)
+		img.SetRGBA(int(center), int(center)+d, originMark)
+	}
+	for _, p := range points {
+		dot(img, p, c.pointSize/2)
+	}
+	return img
+}
+
+// dot fills the disc of radius r pixels around the point, at least its pixel.
+func dot(img *image.RGBA, p point, r float64) {
+	if r < 1 {
+		img.SetRGBA(int(p.x), int(p.y), p.color)
+		return
+	}
+	for y := int(math.Floor(p.y - r)); y <= int(math.Ceil(p.y+r)); y++ {
+		for x := int(math.Floor(p.x - r)); x <= int(math.Ceil(p.x+r)); x++ {
+			if math.Hypot(float64(x)+0.5-p.x, float64(y)+0.5-p.y) <= r {
+				img.SetRGBA(x, y, p.color)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package render
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+)
+
+// front is a scan with a point 1m in front of the lidar and one 1m to its left.
+var front = ydlidar.Scan{Points: []ydlidar.PointCloudData{
+	{Angle: 0, Dist: 1000, Intensity: 1023},
+	{Angle: 90, Dist: 1000, Intensity: 0},
+	{Angle: 180},
+}}
+
+func TestImage(t *testing.T) {
+	img := Image([]ydlidar.Scan{front}, WithSize(200), WithRange(2000), WithPointSize(1))
+
+	// 1m is 50 pixels: in front is up, to the left is left.
+	assert.Equal(t, Heat(1), img.RGBAAt(100, 50))
+	assert.Equal(t, Heat(0), img.RGBAAt(50, 100))
+	assert.Equal(t, originMark, img.RGBAAt(100, 100))
+	assert.Equal(t, background, img.RGBAAt(100, 150), "the dropout is not drawn")
+}
+
+func TestImageRings(t *testing.T) {
+	img := Image(nil, WithSize(200), WithRange(2000), WithRangeRings(1000))
+	assert.Equal(t, ringColor, img.RGBAAt(150, 100))
+	assert.Equal(t, background, img.RGBAAt(125, 100))
+}
+
+func TestImageFits(t *testing.T) {
+	scan := ydlidar.Scan{Units: ydlidar.Meters, Points: []ydlidar.PointCloudData{{Angle: 0, Dist: 2}}}
+	img := Image([]ydlidar.Scan{scan}, WithSize(210), WithColormap(Solid(color.RGBA{1, 2, 3, 255})))
+	// The furthest point is 5% inside the edge.
+	assert.Equal(t, color.RGBA{1, 2, 3, 255}, img.RGBAAt(105, 5))
+}
+
+func TestHeat(t *testing.T) {
+	assert.Equal(t, heatStops[0], Heat(-1))
+	assert.Equal(t, heatStops[2], Heat(0.5))
+	assert.Equal(t, heatStops[4], Heat(2))
+	assert.Equal(t, color.RGBA{0, 64, 192, 255}, Heat(0.125))
+}
+
+func TestPNG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, PNG(&buf, []ydlidar.Scan{front}, WithSize(64)))
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, 64, img.Bounds().Dx())
+}
+
+func TestSVG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, SVG(&buf, []ydlidar.Scan{front}, WithSize(200), WithRange(2000), WithRangeRings(1000)))
+	svg := buf.String()
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="200"`))
+	assert.Contains(t, svg, `<circle cx="100.0" cy="50.0" r="1" fill="#ff0000"/>`)
+	assert.Contains(t, svg, `<circle cx="100.0" cy="100.0" r="50.0" fill="none" stroke="#404040"/>`)
+	assert.Equal(t, 2+2, strings.Count(svg, "<circle"), "2 points, 2 rings within the corners")
+	assert.True(t, strings.HasSuffix(svg, "</svg>\n"))
+}
@@ -0,0 +1,37 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+
+	"ydlidarg2/ydlidar"
+)
+
+// SVG draws the scans as an SVG document, one circle per point, which stays sharp when
+// zoomed in a browser.
+func SVG(w io.Writer, scans []ydlidar.Scan, opts ...Option) error {
+	c := newConfig(opts)
+	points, scale := c.layout(scans)
+	center := float64(c.size) / 2
+	r := c.pointSize / 2
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", c.size, c.size, c.size, c.size)
+	fmt.Fprintf(b, `<rect width="100%%" height="100%%" fill="%v"/>`+"\n", hex(background))
+	for _, radius := range c.rings(scale) {
+		fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="none" stroke="%v"/>`+"\n", center, center, radius, hex(ringColor))
+	}
+	fmt.Fprintf(b, `<path d="M%.1f %.1fh6M%.1f %.1fv6" stroke="%v"/>`+"\n", center-3, center, center, center-3, hex(originMark))
+	for _, p := range points {
+		fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="%.2g" fill="%v"/>`+"\n", p.x, p.y, r, hex(p.color))
+	}
+	fmt.Fprintln(b, "</svg>")
+	return b.Flush()
+}
+
+// hex returns the CSS notation of the color.
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,129 @@
+package rosbag
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"ydlidarg2/ydlidar"
+)
+
+// laserScan is a sensor_msgs/msg/LaserScan message.
+type laserScan struct {
+	stamp          time.Time
+	frameID        string
+	angleMin       float32 // Radians.
+	angleMax       float32
+	angleIncrement float32
+	timeIncrement  float32 // Seconds between two measurements.
+	scanTime       float32 // Seconds between two scans.
+	rangeMin       float32 // Meters.
+	rangeMax       float32
+	ranges         []float32 // Meters, +Inf where nothing was measured.
+	intensities    []float32
+}
+
+// newLaserScan converts a revolution. A LaserScan has evenly spaced ranges, so the points are
+// binned over a full turn from -180°, one bin per point. The lidar reports its angles
+// clockwise, seen from above, while ROS angles grow counterclockwise from the X axis, so like
+// the SDK the angles are inverted: a point at θ lands at -θ. A bin without a return holds
+// +Inf.
+func newLaserScan(scan ydlidar.Scan, frameID string, rangeMin, rangeMax float64) laserScan {
+	n := len(scan.Points)
+	msg := laserScan{
+		stamp:       scan.Start,
+		frameID:     frameID,
+		angleMin:    -math.Pi,
+		rangeMin:    float32(rangeMin),
+		rangeMax:    float32(rangeMax),
+		ranges:      make([]float32, n),
+		intensities: make([]float32, n),
+	}
+	if n == 0 {
+		return msg
+	}
+	increment := 2 * math.Pi / float64(n)
+	msg.angleIncrement = float32(increment)
+	msg.angleMax = float32(-math.Pi + float64(n-1)*increment)
+
+	switch {
+	case scan.Frequency > 0:
+		msg.scanTime = float32(1 / scan.Frequency)
+	case scan.End.After(scan.Start):
+		msg.scanTime = float32(scan.End.Sub(scan.Start).Seconds())
+	}
+	msg.timeIncrement = msg.scanTime / float32(n)
+
+	for i := range msg.ranges {
+		msg.ranges[i] = float32(math.Inf(1))
+	}
+	for _, point := range scan.Points {
+		if !scan.IsReturn(point.Dist) {
+			continue
+		}
+		angle := -point.Angle * math.Pi / 180
+		bin := int(math.Round((angle+math.Pi)/increment)) % n
+		if bin < 0 {
+			bin += n
+		}
+		// Two points landing in a bin keep the nearest, as an obstacle would.
+		meters := float32(scan.Units.ToMillimeters(point.Dist) / 1000)
+		if meters < msg.ranges[bin] {
+			msg.ranges[bin] = meters
+			msg.intensities[bin] = float32(point.Intensity)
+		}
+	}
+	return msg
+}
+
+// marshalCDR serializes the message in little endian CDR, the rosbag2 cdr serialization format.
+func (msg laserScan) marshalCDR() []byte {
+	c := &cdr{buf: []byte{0x00, 0x01, 0x00, 0x00}} // Encapsulation header: CDR little endian.
+	sec := msg.stamp.Unix()
+	nanosec := msg.stamp.Nanosecond()
+	if msg.stamp.IsZero() {
+		sec, nanosec = 0, 0
+	}
+	c.uint32(uint32(int32(sec)))
+	c.uint32(uint32(nanosec))
+	c.string(msg.frameID)
+	for _, v := range []float32{msg.angleMin, msg.angleMax, msg.angleIncrement, msg.timeIncrement, msg.scanTime, msg.rangeMin, msg.rangeMax} {
+		c.float32(v)
+	}
+	c.float32s(msg.ranges)
+	c.float32s(msg.intensities)
+	return c.buf
+}
+
+// cdr appends values aligned on their size, counted from the end of the encapsulation header.
+type cdr struct {
+	buf []byte
+}
+
+func (c *cdr) align(n int) {
+	for (len(c.buf)-4)%n != 0 {
+		c.buf = append(c.buf, 0)
+	}
+}
+
+func (c *cdr) uint32(v uint32) {
+	c.align(4)
+	c.buf = binary.LittleEndian.AppendUint32(c.buf, v)
+}
+
+func (c *cdr) float32(v float32) {
+	c.uint32(math.Float32bits(v))
+}
+
+// string writes the length including the terminating NUL, the bytes and the NUL.
+func (c *cdr) string(s string) {
+	c.uint32(uint32(len(s) + 1))
+	c.buf = append(append(c.buf, s...), 0)
+}
+
+func (c *cdr) float32s(values []float32) {
+	c.uint32(uint32(len(values)))
+	for _, v := range values {
+		c.buf = binary.LittleEndian.AppendUint32(c.buf, math.Float32bits(v))
+	}
+}
@@ -0,0 +1,63 @@
+package rosbag
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"ydlidarg2/ydlidar"
+)
+
+func TestNewLaserScan(t *testing.T) {
+	scan := ydlidar.Scan{Start: t0, Frequency: 10, Units: ydlidar.Meters, Points: []ydlidar.PointCloudData{
+		{Angle: 0, Dist: 2, Intensity: 7},
+		{Angle: 90, Dist: 1},
+		{Angle: 180},
+		{Angle: 270, Dist: 3},
+	}}
+	msg := newLaserScan(scan, "laser", 0.1, 10)
+
+	assert.InDelta(t, math.Pi/2, msg.angleIncrement, 1e-6)
+	assert.InDelta(t, 0.1, msg.scanTime, 1e-6)
+	assert.InDelta(t, 0.025, msg.timeIncrement, 1e-6)
+	// Bins from -180°: 0° is bin 2, 90° clockwise is -90° in bin 1, 270° is -270°, wrapping to
+	// 90° in bin 3, and the dropout at 180° leaves bin 0 empty.
+	inf := float32(math.Inf(1))
+	assert.Equal(t, []float32{inf, 1, 2, 3}, msg.ranges)
+	assert.Equal(t, []float32{0, 0, 7, 0}, msg.intensities)
+}
+
+func TestLaserScanCounterclockwise(t *testing.T) {
+	// The lidar turns clockwise: a point 30° to its right is at 30°, at -30° in ROS.
+	scan := ydlidar.Scan{Units: ydlidar.Meters, Points: make([]ydlidar.PointCloudData, 12)}
+	for i := range scan.Points {
+		scan.Points[i].Angle = float64(i * 30)
+	}
+	scan.Points[1].Dist = 1
+	msg := newLaserScan(scan, "laser", 0.1, 10)
+
+	for i, r := range msg.ranges {
+		if !math.IsInf(float64(r), 1) {
+			angle := float64(msg.angleMin) + float64(i)*float64(msg.angleIncrement)
+			assert.InDelta(t, -math.Pi/6, angle, 1e-6, "range %v", i)
+			return
+		}
+	}
+	t.Fatal("no range")
+}
+
+func TestMarshalCDR(t *testing.T) {
+	msg := laserScan{stamp: t0, frameID: "ab", ranges: []float32{1.5}, intensities: []float32{}}
+	data := msg.marshalCDR()
+
+	assert.Equal(t, []byte{0, 1, 0, 0}, data[:4])
+	assert.Equal(t, uint32(t0.Unix()), binary.LittleEndian.Uint32(data[4:]))
+	assert.Equal(t, uint32(3), binary.LittleEndian.Uint32(data[12:]))
+	assert.Equal(t, []byte("ab\x00\x00"), data[16:20], "the string is padded to align the floats")
+	// 7 floats, then the ranges and the empty intensities.
+	assert.Equal(t, uint32(1), binary.LittleEndian.Uint32(data[48:]))
+	assert.Equal(t, float32(1.5), math.Float32frombits(binary.LittleEndian.Uint32(data[52:])))
+	assert.Equal(t, uint32(0), binary.LittleEndian.Uint32(data[56:]))
+	assert.Len(t, data, 60)
+}
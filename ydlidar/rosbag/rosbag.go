@@ -0,0 +1,247 @@
+// Package rosbag writes revolutions to a rosbag2 bag, a directory holding a sqlite3 database
+// and its metadata.yaml, as sensor_msgs/msg/LaserScan messages, so recorded sessions can be
+// replayed in RViz or nav2 with ros2 bag play:
+//
+//	bag, err := rosbag.Create("session")
+//	for i := 0; i < log.Len(); i++ {
+//		scan, _ := log.Scan(i)
+//		bag.WriteScan(scan)
+//	}
+//	err = bag.Close()
+//
+// The bag uses the storage layout of ROS 2 Humble, which later distributions read too.
+//
+// The database is written by a small writer of the SQLite file format rather than a SQLite
+// library. The cgo bindings would make the driver need a C toolchain for every target and
+// break its plain GOOS/GOARCH cross builds to the boards it runs on, such as a Raspberry Pi
+// Zero. The pure Go translation of SQLite adds megabytes to the binaries and doesn't build
+// for all the targets of the toolchain. A bag only needs append only tables, which are a few
+// hundred lines of b-tree pages, see sqlite.go.
+package rosbag
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"ydlidarg2/ydlidar"
+)
+
+// messageType is the ROS type of the messages.
+const messageType = "sensor_msgs/msg/LaserScan"
+
+// qosProfile is the offered QoS of the topic, reliable and volatile like a sensor driver.
+const qosProfile = `- history: 3
+  depth: 0
+  reliability: 1
+  durability: 2
+  deadline:
+    sec: 2147483647
+    nsec: 4294967295
+  lifespan:
+    sec: 2147483647
+    nsec: 4294967295
+  liveliness: 1
+  liveliness_lease_duration:
+    sec: 2147483647
+    nsec: 4294967295
+  avoid_ros_namespace_conventions: false
+`
+
+// Option configures a Writer.
+type Option func(*Writer)
+
+// WithTopic sets the topic of the messages, /scan by default.
+func WithTopic(topic string) Option {
+	return func(w *Writer) {
+		w.topic = topic
+	}
+}
+
+// WithFrameID sets the frame of the messages, laser by default.
+func WithFrameID(frameID string) Option {
+	return func(w *Writer) {
+		w.frameID = frameID
+	}
+}
+
+// WithRange sets the range limits of the messages in meters, the G2 limits of 0.12m and 12m
+// by default. ROS consumers ignore the ranges outside them.
+func WithRange(min, max float64) Option {
+	return func(w *Writer) {
+		w.rangeMin, w.rangeMax = min, max
+	}
+}
+
+// Writer writes a bag. It is a ydlidar.ScanProcessor so it can record a running scan.
+type Writer struct {
+	mu       sync.Mutex
+	dir      string
+	file     *os.File
+	db       *sqliteWriter
+	messages *sqliteTable
+	closed   bool
+
+	topic    string
+	frameID  string
+	rangeMin float64
+	rangeMax float64
+
+	count       int
+	first, last time.Time
+}
+
+// Create creates the bag directory, which must not exist, and its database.
+func Create(dir string, opts ...Option) (*Writer, error) {
+	w := &Writer{dir: dir, topic: "/scan", frameID: "laser", rangeMin: 0.12, rangeMax: 12}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.Create(filepath.Join(dir, w.dbName()))
+	if err != nil {
+		return nil, err
+	}
+	w.file = file
+	w.db = newSQLiteWriter(file)
+
+	schema := w.db.table("schema", "CREATE TABLE schema(schema_version INTEGER PRIMARY KEY,ros_distro TEXT NOT NULL)")
+	topics := w.db.table("topics", "CREATE TABLE topics(id INTEGER PRIMARY KEY,name TEXT NOT NULL,type TEXT NOT NULL,"+
+		"serialization_format TEXT NOT NULL,offered_qos_profiles TEXT NOT NULL)")
+	w.messages = w.db.table("messages", "CREATE TABLE messages(id INTEGER PRIMARY KEY,topic_id INTEGER NOT NULL,"+
+		"timestamp INTEGER NOT NULL, data BLOB NOT NULL)")
+	// The INTEGER PRIMARY KEY columns are the rowid, stored as NULL in the record.
+	if err = schema.insertAt(3, nil, "humble"); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err = topics.insert(nil, w.topic, messageType, "cdr", qosProfile); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// dbName is the name of the database file, after the bag like ros2 bag record names it.
+func (w *Writer) dbName() string {
+	return filepath.Base(w.dir) + "_0.db3"
+}
+
+// ProcessScan writes the scan, see WriteScan.
+func (w *Writer) ProcessScan(scan *ydlidar.Scan) error {
+	return w.WriteScan(*scan)
+}
+
+// WriteScan appends the revolution as a LaserScan message stamped with its start.
+func (w *Writer) WriteScan(scan ydlidar.Scan) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+
+	data := newLaserScan(scan, w.frameID, w.rangeMin, w.rangeMax).marshalCDR()
+	if _, err := w.messages.insert(nil, int64(1), scan.Start.UnixNano(), data); err != nil {
+		return err
+	}
+	if w.count == 0 || scan.Start.Before(w.first) {
+		w.first = scan.Start
+	}
+	if scan.Start.After(w.last) {
+		w.last = scan.Start
+	}
+	w.count++
+	return nil
+}
+
+// Close completes the database and writes metadata.yaml.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	err := w.db.close()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(w.metadata())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(w.dir, "metadata.yaml"), data, 0o644)
+}
+
+// metadata is the content of metadata.yaml.
+func (w *Writer) metadata() bagMetadata {
+	var start, duration int64
+	if w.count > 0 {
+		start, duration = w.first.UnixNano(), w.last.Sub(w.first).Nanoseconds()
+	}
+	return bagMetadata{Info: bagInfo{
+		Version:           5,
+		StorageIdentifier: "sqlite3",
+		Duration:          nanoseconds{duration},
+		StartingTime:      sinceEpoch{start},
+		MessageCount:      w.count,
+		Topics: []topicCount{{
+			Topic:        topicMetadata{Name: w.topic, Type: messageType, SerializationFormat: "cdr", OfferedQoSProfiles: qosProfile},
+			MessageCount: w.count,
+		}},
+		RelativeFilePaths: []string{w.dbName()},
+		Files:             []bagFile{{Path: w.dbName(), StartingTime: sinceEpoch{start}, Duration: nanoseconds{duration}, MessageCount: w.count}},
+	}}
+}
+
+type bagMetadata struct {
+	Info bagInfo `yaml:"rosbag2_bagfile_information"`
+}
+
+type bagInfo struct {
+	Version           int          `yaml:"version"`
+	StorageIdentifier string       `yaml:"storage_identifier"`
+	Duration          nanoseconds  `yaml:"duration"`
+	StartingTime      sinceEpoch   `yaml:"starting_time"`
+	MessageCount      int          `yaml:"message_count"`
+	Topics            []topicCount `yaml:"topics_with_message_count"`
+	CompressionFormat string       `yaml:"compression_format"`
+	CompressionMode   string       `yaml:"compression_mode"`
+	RelativeFilePaths []string     `yaml:"relative_file_paths"`
+	Files             []bagFile    `yaml:"files"`
+}
+
+type nanoseconds struct {
+	Nanoseconds int64 `yaml:"nanoseconds"`
+}
+
+type sinceEpoch struct {
+	Nanoseconds int64 `yaml:"nanoseconds_since_epoch"`
+}
+
+type topicCount struct {
+	Topic        topicMetadata `yaml:"topic_metadata"`
+	MessageCount int           `yaml:"message_count"`
+}
+
+type topicMetadata struct {
+	Name                string `yaml:"name"`
+	Type                string `yaml:"type"`
+	SerializationFormat string `yaml:"serialization_format"`
+	OfferedQoSProfiles  string `yaml:"offered_qos_profiles"`
+}
+
+type bagFile struct {
+	Path         string      `yaml:"path"`
+	StartingTime sinceEpoch  `yaml:"starting_time"`
+	Duration     nanoseconds `yaml:"duration"`
+	MessageCount int         `yaml:"message_count"`
+}
@@ -0,0 +1,67 @@
+package rosbag
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"ydlidarg2/ydlidar"
+)
+
+var t0 = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// revolution is a revolution of 480 points 1m away.
+func revolution(seq int) ydlidar.Scan {
+	scan := ydlidar.Scan{Seq: uint64(seq), Start: t0.Add(time.Duration(seq) * 100 * time.Millisecond), Frequency: 10}
+	for i := 0; i < 480; i++ {
+		scan.Points = append(scan.Points, ydlidar.PointCloudData{Angle: float64(i) * 0.75, Dist: 1000, Intensity: i % 256})
+	}
+	return scan
+}
+
+func writeBag(t *testing.T, dir string, scans int) {
+	bag, err := Create(dir)
+	require.NoError(t, err)
+	for i := 0; i < scans; i++ {
+		require.NoError(t, bag.WriteScan(revolution(i)))
+	}
+	require.NoError(t, bag.Close())
+}
+
+func TestCreate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "session")
+	writeBag(t, dir, 50)
+
+	data, err := os.ReadFile(filepath.Join(dir, "session_0.db3"))
+	require.NoError(t, err)
+	assert.Equal(t, "SQLite format 3\x00", string(data[:16]))
+	require.Zero(t, len(data)%pageSize)
+	pages := len(data) / pageSize
+	assert.Equal(t, pages, int(data[28])<<24|int(data[29])<<16|int(data[30])<<8|int(data[31]))
+
+	var meta bagMetadata
+	data, err = os.ReadFile(filepath.Join(dir, "metadata.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, &meta))
+	assert.Equal(t, "sqlite3", meta.Info.StorageIdentifier)
+	assert.Equal(t, 50, meta.Info.MessageCount)
+	assert.Equal(t, t0.UnixNano(), meta.Info.StartingTime.Nanoseconds)
+	assert.Equal(t, (4900 * time.Millisecond).Nanoseconds(), meta.Info.Duration.Nanoseconds)
+	assert.Equal(t, "/scan", meta.Info.Topics[0].Topic.Name)
+	assert.Equal(t, []string{"session_0.db3"}, meta.Info.RelativeFilePaths)
+
+	// The bag is never overwritten.
+	_, err = Create(dir)
+	assert.Error(t, err)
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	bag, err := Create(filepath.Join(t.TempDir(), "bag"))
+	require.NoError(t, err)
+	require.NoError(t, bag.Close())
+	assert.ErrorIs(t, bag.WriteScan(revolution(0)), os.ErrClosed)
+}
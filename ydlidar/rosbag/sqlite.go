@@ -0,0 +1,339 @@
+package rosbag
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// The bag database is written directly in the SQLite file format, see
+// https://www.sqlite.org/fileformat2.html, rather than through a SQLite library: a bag is
+// only ever appended to, so rows are packed into the leaf pages of the table b-trees as they
+// come and the interior pages and the schema on page 1 are written on close. The database
+// has no free pages, no indexes and is readable by any SQLite 3.
+
+const (
+	pageSize   = 4096
+	fileHeader = 100 // Size of the database header at the start of page 1.
+
+	leafTable     = 0x0D // Page type of a table b-tree leaf page.
+	interiorTable = 0x05 // Page type of a table b-tree interior page.
+)
+
+// sqliteWriter writes a database of append only tables.
+type sqliteWriter struct {
+	w        io.WriterAt
+	nextPage uint32 // Number of the next page to allocate, page 1 is written last.
+	tables   []*sqliteTable
+}
+
+func newSQLiteWriter(w io.WriterAt) *sqliteWriter {
+	return &sqliteWriter{w: w, nextPage: 2}
+}
+
+// sqliteTable is a table being written.
+type sqliteTable struct {
+	name, sql string
+	rowid     int64
+	leaf      [][]byte  // Cells of the leaf page being filled.
+	leafSize  int       // Bytes taken by the cells and their pointers.
+	children  []pageRef // Full leaf pages.
+	root      uint32    // Root page, set on close.
+	db        *sqliteWriter
+}
+
+// pageRef is a written page and the largest rowid in it.
+type pageRef struct {
+	page   uint32
+	maxKey int64
+}
+
+// table adds a table created by the sql statement.
+func (db *sqliteWriter) table(name, sql string) *sqliteTable {
+	t := &sqliteTable{name: name, sql: sql, db: db}
+	db.tables = append(db.tables, t)
+	return t
+}
+
+// allocate returns the number of a new page.
+func (db *sqliteWriter) allocate() uint32 {
+	page := db.nextPage
+	db.nextPage++
+	return page
+}
+
+func (db *sqliteWriter) writePage(page uint32, data []byte) error {
+	_, err := db.w.WriteAt(data, int64(page-1)*pageSize)
+	return err
+}
+
+// insert appends a row of the values, the rowid is assigned in sequence and returned.
+func (t *sqliteTable) insert(values ...interface{}) (int64, error) {
+	rowid := t.rowid + 1
+	return rowid, t.insertAt(rowid, values...)
+}
+
+// insertAt appends a row of the values with the rowid, which must be larger than the
+// previous one.
+func (t *sqliteTable) insertAt(rowid int64, values ...interface{}) error {
+	if rowid <= t.rowid {
+		return fmt.Errorf("rowid %v after %v in table %v", rowid, t.rowid, t.name)
+	}
+	cell, err := t.db.leafCell(rowid, record(values))
+	if err != nil {
+		return err
+	}
+	if t.leafSize+len(cell)+2 > pageSize-8 {
+		if err = t.flushLeaf(); err != nil {
+			return err
+		}
+	}
+	t.rowid = rowid
+	t.leaf = append(t.leaf, cell)
+	t.leafSize += len(cell) + 2
+	return nil
+}
+
+// flushLeaf writes the leaf page being filled.
+func (t *sqliteTable) flushLeaf() error {
+	page := t.db.allocate()
+	if err := t.db.writePage(page, btreePage(leafTable, 0, t.leaf, 0)); err != nil {
+		return err
+	}
+	ref := pageRef{page: page}
+	if len(t.leaf) > 0 {
+		ref.maxKey = t.lastKey()
+	}
+	t.children = append(t.children, ref)
+	t.leaf, t.leafSize = nil, 0
+	return nil
+}
+
+// lastKey returns the rowid of the last cell of the leaf being filled.
+func (t *sqliteTable) lastKey() int64 {
+	// The cell starts with the payload size then the rowid.
+	cell := t.leaf[len(t.leaf)-1]
+	_, n := getVarint(cell)
+	key, _ := getVarint(cell[n:])
+	return int64(key)
+}
+
+// close writes the last leaf and the interior pages above the leaves.
+func (t *sqliteTable) close() error {
+	if len(t.leaf) > 0 || len(t.children) == 0 {
+		if err := t.flushLeaf(); err != nil {
+			return err
+		}
+	}
+	level := t.children
+	for len(level) > 1 {
+		var parents []pageRef
+		for len(level) > 0 {
+			// An interior cell is a 4 byte child page and a varint key, the last child of
+			// the page is its right-most pointer.
+			var cells [][]byte
+			size := 0
+			i := 0
+			for ; i < len(level)-1; i++ {
+				cell := binary.BigEndian.AppendUint32(nil, level[i].page)
+				cell = appendVarint(cell, uint64(level[i].maxKey))
+				if size+len(cell)+2 > pageSize-12 {
+					break
+				}
+				cells = append(cells, cell)
+				size += len(cell) + 2
+			}
+			right := level[i]
+			page := t.db.allocate()
+			if err := t.db.writePage(page, btreePage(interiorTable, 0, cells, right.page)); err != nil {
+				return err
+			}
+			parents = append(parents, pageRef{page: page, maxKey: right.maxKey})
+			level = level[i+1:]
+		}
+		level = parents
+	}
+	t.root = level[0].page
+	return nil
+}
+
+// close writes the tables and page 1: the database header and the schema table.
+func (db *sqliteWriter) close() error {
+	schema := &sqliteTable{db: db}
+	for _, t := range db.tables {
+		if err := t.close(); err != nil {
+			return err
+		}
+		schema.rowid++
+		cell, err := db.leafCell(schema.rowid, record([]interface{}{"table", t.name, t.name, int64(t.root), t.sql}))
+		if err != nil {
+			return err
+		}
+		schema.leaf = append(schema.leaf, cell)
+		schema.leafSize += len(cell) + 2
+	}
+	if schema.leafSize > pageSize-fileHeader-8 {
+		return fmt.Errorf("schema doesn't fit on the first page")
+	}
+
+	page := btreePage(leafTable, fileHeader, schema.leaf, 0)
+	header := page[:fileHeader]
+	copy(header, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(header[16:], pageSize)
+	header[18], header[19] = 1, 1 // Legacy journal mode.
+	header[21], header[22], header[23] = 64, 32, 32
+	binary.BigEndian.PutUint32(header[24:], 1) // File change counter.
+	binary.BigEndian.PutUint32(header[28:], db.nextPage-1)
+	binary.BigEndian.PutUint32(header[40:], 1) // Schema cookie.
+	binary.BigEndian.PutUint32(header[44:], 4) // Schema format.
+	binary.BigEndian.PutUint32(header[56:], 1) // UTF-8.
+	binary.BigEndian.PutUint32(header[92:], 1) // Version valid for the change counter.
+	binary.BigEndian.PutUint32(header[96:], 3031001)
+	return db.writePage(1, page)
+}
+
+// btreePage lays out a b-tree page with its header at offset, the cell pointers after it and
+// the cells packed at the end of the page.
+func btreePage(kind byte, offset int, cells [][]byte, right uint32) []byte {
+	page := make([]byte, pageSize)
+	header := page[offset:]
+	header[0] = kind
+	binary.BigEndian.PutUint16(header[3:], uint16(len(cells)))
+	pointers := header[8:]
+	if kind == interiorTable {
+		binary.BigEndian.PutUint32(header[8:], right)
+		pointers = header[12:]
+	}
+	end := pageSize
+	for i, cell := range cells {
+		end -= len(cell)
+		copy(page[end:], cell)
+		binary.BigEndian.PutUint16(pointers[2*i:], uint16(end))
+	}
+	binary.BigEndian.PutUint16(header[5:], uint16(end))
+	return page
+}
+
+// leafCell returns the table leaf cell of a row, writing the end of a large payload to
+// overflow pages.
+func (db *sqliteWriter) leafCell(rowid int64, payload []byte) ([]byte, error) {
+	cell := appendVarint(nil, uint64(len(payload)))
+	cell = appendVarint(cell, uint64(rowid))
+
+	// Thresholds from the file format, with no reserved bytes at the end of the pages.
+	const usable = pageSize
+	maxLocal := usable - 35
+	if len(payload) <= maxLocal {
+		return append(cell, payload...), nil
+	}
+	minLocal := (usable-12)*32/255 - 23
+	local := minLocal + (len(payload)-minLocal)%(usable-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+	cell = append(cell, payload[:local]...)
+
+	rest := payload[local:]
+	first := db.allocate()
+	cell = binary.BigEndian.AppendUint32(cell, first)
+	for page := first; len(rest) > 0; {
+		n := len(rest)
+		if n > usable-4 {
+			n = usable - 4
+		}
+		var next uint32
+		if n < len(rest) {
+			next = db.allocate()
+		}
+		data := make([]byte, pageSize)
+		binary.BigEndian.PutUint32(data, next)
+		copy(data[4:], rest[:n])
+		if err := db.writePage(page, data); err != nil {
+			return nil, err
+		}
+		rest, page = rest[n:], next
+	}
+	return cell, nil
+}
+
+// record encodes values, nil, int64, string or []byte, in the record format.
+func record(values []interface{}) []byte {
+	var types, body []byte
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+			types = appendVarint(types, 0)
+		case int64:
+			switch {
+			case v == 0:
+				types = appendVarint(types, 8)
+			case v == 1:
+				types = appendVarint(types, 9)
+			case v >= -1<<7 && v < 1<<7:
+				types = appendVarint(types, 1)
+				body = append(body, byte(v))
+			case v >= -1<<15 && v < 1<<15:
+				types = appendVarint(types, 2)
+				body = binary.BigEndian.AppendUint16(body, uint16(v))
+			case v >= -1<<31 && v < 1<<31:
+				types = appendVarint(types, 4)
+				body = binary.BigEndian.AppendUint32(body, uint32(v))
+			default:
+				types = appendVarint(types, 6)
+				body = binary.BigEndian.AppendUint64(body, uint64(v))
+			}
+		case string:
+			types = appendVarint(types, uint64(2*len(v)+13))
+			body = append(body, v...)
+		case []byte:
+			types = appendVarint(types, uint64(2*len(v)+12))
+			body = append(body, v...)
+		default:
+			panic(fmt.Sprintf("rosbag: unsupported record value %T", v))
+		}
+	}
+	// The header size counts itself, one byte as long as the header is under 128 bytes.
+	header := appendVarint(nil, uint64(len(types)+1))
+	if len(header) > 1 {
+		header = appendVarint(nil, uint64(len(types)+2))
+	}
+	return append(append(header, types...), body...)
+}
+
+// appendVarint appends v in the big endian variable length integer format of SQLite.
+func appendVarint(b []byte, v uint64) []byte {
+	if v > 1<<56-1 {
+		// The ninth byte holds 8 bits.
+		var buf [9]byte
+		buf[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			buf[i] = byte(v&0x7F) | 0x80
+			v >>= 7
+		}
+		return append(b, buf[:]...)
+	}
+	var buf [8]byte
+	i := len(buf) - 1
+	buf[i] = byte(v & 0x7F)
+	for v >>= 7; v > 0; v >>= 7 {
+		i--
+		buf[i] = byte(v&0x7F) | 0x80
+	}
+	return append(b, buf[i:]...)
+}
+
+// getVarint decodes a varint written by appendVarint and returns its length.
+func getVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 8 && i < len(b); i++ {
+		v = v<<7 | uint64(b[i]&0x7F)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	if len(b) < 9 {
+		return v, len(b)
+	}
+	return v<<8 | uint64(b[8]), 9
+}
@@ -0,0 +1,24 @@
+package rosbag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVarint(t *testing.T) {
+	for _, v := range []uint64{0, 127, 128, 16383, 16384, 1<<56 - 1, 1 << 56, 1<<64 - 1} {
+		b := appendVarint(nil, v)
+		got, n := getVarint(b)
+		assert.Equal(t, v, got)
+		assert.Equal(t, len(b), n)
+	}
+	assert.Equal(t, []byte{0x81, 0x00}, appendVarint(nil, 128))
+	assert.Len(t, appendVarint(nil, 1<<64-1), 9)
+}
+
+func TestRecord(t *testing.T) {
+	// Header of 5 bytes: its size, NULL, the integer 1, a 2 byte integer and a 2 byte text.
+	assert.Equal(t, []byte{5, 0, 9, 2, 17, 0x01, 0x2C, 'h', 'i'}, record([]interface{}{nil, int64(1), int64(300), "hi"}))
+	assert.Equal(t, []byte{2, 16, 0xFF, 0x00}, record([]interface{}{[]byte{0xFF, 0x00}}))
+}
@@ -0,0 +1,199 @@
+// Package safety watches zones around the robot, eg. "anything closer than 0.4 m between
+// -30° and 30°", and raises events when points violate a zone for a number of consecutive
+// scans. Plug a Monitor into the lidar with ydlidar.WithScanProcessor.
+package safety
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+	"ydlidarg2/ydlidar/motion"
+)
+
+// Zone is a sector or polygon that must stay clear.
+type Zone struct {
+	Name string
+
+	// Sector zone: points between MinAngle and MaxAngle degrees (wrapping through 0° when
+	// MinAngle > MaxAngle) closer than MaxRange millimeters. Points closer than MinRange,
+	// eg. parts of the robot, are ignored.
+	MinAngle, MaxAngle float64
+	MinRange, MaxRange float64
+
+	// Polygon zone in millimeters, x pointing at 0°. Overrides the sector when set.
+	Polygon []geom.Point
+
+	MinPoints   int // Points needed in a scan to count it as violating, 1 if zero.
+	Consecutive int // Violating scans in a row needed to raise the event, 1 if zero.
+}
+
+// contains reports whether the point lies in the zone.
+func (z *Zone) contains(point ydlidar.PointCloudData) bool {
+	if point.Dist <= 0 {
+		return false
+	}
+	if len(z.Polygon) > 0 {
+		return geom.PointInPolygon(geom.FromPolar(point.Angle, point.Dist), z.Polygon)
+	}
+	dist := point.Dist
+	return dist >= z.MinRange && dist < z.MaxRange && geom.InAngleRange(point.Angle, z.MinAngle, z.MaxAngle)
+}
+
+// Event reports a zone becoming violated or clear again.
+type Event struct {
+	Zone     string
+	Violated bool      // True when the zone became violated, false when it cleared.
+	Points   int       // Points inside the zone in the triggering scan.
+	Closest  float64   // Distance of the closest point inside the zone in millimeters.
+	Seq      uint64    // Scan.Seq of the triggering scan.
+	Time     time.Time // When the event was raised.
+}
+
+// String describes the event.
+func (e Event) String() string {
+	if e.Violated {
+		return fmt.Sprintf("zone %v violated: %v points, closest %.0fmm", e.Zone, e.Points, e.Closest)
+	}
+	return fmt.Sprintf("zone %v clear", e.Zone)
+}
+
+// zoneState is the zone and its consecutive violation count.
+type zoneState struct {
+	Zone
+	count    int
+	violated bool
+}
+
+// Monitor checks scans against the registered zones.
+type Monitor struct {
+	// Events receives the events, sent without blocking. Optional.
+	Events chan Event
+
+	// Static is the static scene of a fixed lidar, see motion.Learn. Points within
+	// StaticMargin millimeters of it, eg. furniture standing inside a zone, are ignored.
+	// Optional.
+	Static       *motion.Background
+	StaticMargin float64
+
+	mu        sync.Mutex
+	zones     []*zoneState
+	callbacks []func(Event)
+}
+
+// NewMonitor returns a monitor with an Events channel of the given capacity.
+func NewMonitor(buffer int) *Monitor {
+	return &Monitor{Events: make(chan Event, buffer)}
+}
+
+// AddZone registers a zone.
+func (m *Monitor) AddZone(zone Zone) error {
+	if len(zone.Polygon) == 0 && zone.MaxRange <= 0 {
+		return fmt.Errorf("zone %q: a sector zone needs a MaxRange", zone.Name)
+	}
+	if len(zone.Polygon) > 0 && len(zone.Polygon) < 3 {
+		return fmt.Errorf("zone %q: a polygon needs at least 3 vertices", zone.Name)
+	}
+	if zone.MinPoints <= 0 {
+		zone.MinPoints = 1
+	}
+	if zone.Consecutive <= 0 {
+		zone.Consecutive = 1
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, z := range m.zones {
+		if z.Name == zone.Name {
+			return fmt.Errorf("zone %q already registered", zone.Name)
+		}
+	}
+	m.zones = append(m.zones, &zoneState{Zone: zone})
+	return nil
+}
+
+// OnEvent registers a callback called for every event, on the goroutine checking the scan.
+func (m *Monitor) OnEvent(callback func(Event)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.callbacks = append(m.callbacks, callback)
+}
+
+// ProcessScan checks the scan and dispatches the events, implementing ydlidar.ScanProcessor.
+func (m *Monitor) ProcessScan(scan *ydlidar.Scan) error {
+	events := m.Check(*scan)
+
+	m.mu.Lock()
+	callbacks := m.callbacks
+	m.mu.Unlock()
+
+	for _, event := range events {
+		for _, callback := range callbacks {
+			callback(event)
+		}
+		if m.Events != nil {
+			select {
+			case m.Events <- event:
+			default:
+			}
+		}
+	}
+	return nil
+}
+
+// Check updates the zone states with the scan and returns the resulting events.
+func (m *Monitor) Check(scan ydlidar.Scan) []Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var events []Event
+	for _, z := range m.zones {
+		points, closest := 0, math.Inf(1)
+		for _, point := range scan.Points {
+			if scan.IsReturn(point.Dist) && z.contains(point) && !m.static(point) {
+				points++
+				closest = math.Min(closest, point.Dist)
+			}
+		}
+
+		if points < z.MinPoints {
+			z.count = 0
+			if z.violated {
+				z.violated = false
+				events = append(events, Event{Zone: z.Name, Seq: scan.Seq, Time: time.Now()})
+			}
+			continue
+		}
+
+		z.count++
+		if z.count >= z.Consecutive && !z.violated {
+			z.violated = true
+			events = append(events, Event{Zone: z.Name, Violated: true, Points: points, Closest: closest, Seq: scan.Seq, Time: time.Now()})
+		}
+	}
+	return events
+}
+
+// static reports whether the point belongs to the static scene.
+func (m *Monitor) static(point ydlidar.PointCloudData) bool {
+	if m.Static == nil {
+		return false
+	}
+	ref, ok := m.Static.Range(point.Angle)
+	return ok && math.Abs(point.Dist-ref) <= m.StaticMargin
+}
+
+// Violated reports whether the named zone is currently violated.
+func (m *Monitor) Violated(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, z := range m.zones {
+		if z.Name == name {
+			return z.violated
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package safety
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+	"ydlidarg2/ydlidar/motion"
+)
+
+func scanWith(seq uint64, points ...ydlidar.PointCloudData) *ydlidar.Scan {
+	return &ydlidar.Scan{Seq: seq, Points: points}
+}
+
+func TestSectorZone(t *testing.T) {
+	m := NewMonitor(4)
+	require.NoError(t, m.AddZone(Zone{Name: "front", MinAngle: -30, MaxAngle: 30, MaxRange: 400, Consecutive: 2}))
+	var called []Event
+	m.OnEvent(func(e Event) { called = append(called, e) })
+
+	obstacle := ydlidar.PointCloudData{Angle: 350, Dist: 300}
+	require.NoError(t, m.ProcessScan(scanWith(1, obstacle)))
+	assert.False(t, m.Violated("front"))
+
+	require.NoError(t, m.ProcessScan(scanWith(2, obstacle)))
+	assert.True(t, m.Violated("front"))
+	event := <-m.Events
+	assert.True(t, event.Violated)
+	assert.Equal(t, uint64(2), event.Seq)
+	assert.Equal(t, 300.0, event.Closest)
+
+	require.NoError(t, m.ProcessScan(scanWith(3, ydlidar.PointCloudData{Angle: 90, Dist: 300})))
+	assert.False(t, m.Violated("front"))
+	assert.False(t, (<-m.Events).Violated)
+	assert.Len(t, called, 2)
+}
+
+func TestPolygonZone(t *testing.T) {
+	m := NewMonitor(1)
+	require.NoError(t, m.AddZone(Zone{Name: "bumper", Polygon: []geom.Point{{X: 0, Y: -200}, {X: 500, Y: -200}, {X: 500, Y: 200}, {X: 0, Y: 200}}}))
+	assert.Error(t, m.AddZone(Zone{Name: "bumper", MaxRange: 1}))
+	assert.Error(t, m.AddZone(Zone{Name: "empty"}))
+
+	events := m.Check(*scanWith(1, ydlidar.PointCloudData{Angle: 10, Dist: 450}))
+	require.Len(t, events, 1)
+	assert.Equal(t, "bumper", events[0].Zone)
+}
+
+func TestStaticScene(t *testing.T) {
+	// A table leg stands in the zone of a fixed lidar.
+	static := motion.NewBackground(1)
+	static.Add(*scanWith(1, ydlidar.PointCloudData{Angle: 10, Dist: 300}))
+
+	m := NewMonitor(1)
+	m.Static, m.StaticMargin = static, 50
+	require.NoError(t, m.AddZone(Zone{Name: "front", MinAngle: -30, MaxAngle: 30, MaxRange: 400}))
+	assert.Empty(t, m.Check(*scanWith(2, ydlidar.PointCloudData{Angle: 10, Dist: 320})))
+
+	events := m.Check(*scanWith(3, ydlidar.PointCloudData{Angle: 10, Dist: 320}, ydlidar.PointCloudData{Angle: 11, Dist: 200}))
+	require.Len(t, events, 1)
+	assert.Equal(t, 1, events[0].Points)
+}
@@ -0,0 +1,202 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"strconv"
+
+	"ydlidarg2/ydlidar/geom"
+)
+
+const (
+	// Model is the model number reported by the simulated device, a G2.
+	Model = 15
+
+	// packetSamples is the largest number of samples in a scan packet.
+	packetSamples = 40
+
+	// maxDistance is the largest distance the 14 bit distance field holds.
+	maxDistance = 1<<14 - 1
+)
+
+// Device is a simulated G2. The zero value scans an empty environment, where every sample
+// is a dropout, at the default settings.
+type Device struct {
+	Environment    Environment
+	Pose           geom.Pose2D // Pose of the lidar in the environment, the heading is its 0°.
+	Frequency      float64     // Scan frequency in Hz, 7 by default.
+	SampleRate     float64     // Samples per second, 5000 by default.
+	MinRange       float64     // Closer returns are dropouts, 120mm by default.
+	MaxRange       float64     // Further returns are dropouts, 12000mm by default.
+	Noise          float64     // Standard deviation of the distances in millimeters, 0 for exact ranges.
+	Intensity      int         // Intensity of the returns, 0 to 1023, 300 by default.
+	IntensityNoise float64     // Standard deviation of the intensities, 0 for a constant intensity.
+	Health         byte        // Status byte of the health response, 0 when healthy.
+	ErrorCode      uint16      // Error code of the health response.
+	Serial         string      // Serial number of 16 hex digits, 0123456789012345 by default.
+	Seed           int64       // Seed of the noise.
+
+	rand *rand.Rand
+}
+
+// serial returns the serial number as sent by the device, a digit per byte.
+func (d *Device) serial() []byte {
+	serial := d.Serial
+	if serial == "" {
+		serial = "0123456789012345"
+	}
+	digits := make([]byte, 16)
+	for i := 0; i < len(digits) && i < len(serial); i++ {
+		if digit, err := strconv.ParseUint(serial[i:i+1], 16, 8); err == nil {
+			digits[i] = byte(digit)
+		}
+	}
+	return digits
+}
+
+// frequency returns the scan frequency in Hz.
+func (d *Device) frequency() float64 {
+	if d.Frequency <= 0 {
+		return 7
+	}
+	return d.Frequency
+}
+
+// samplesPerRevolution returns the number of samples of a revolution.
+func (d *Device) samplesPerRevolution() int {
+	rate := d.SampleRate
+	if rate <= 0 {
+		rate = 5000
+	}
+	return int(math.Round(rate / d.frequency()))
+}
+
+// Revolution returns one revolution on the wire: the zero packet followed by the scan
+// packets of up to 40 samples.
+func (d *Device) Revolution() []byte {
+	return bytes.Join(d.packets(), nil)
+}
+
+// packets returns the packets of one revolution.
+func (d *Device) packets() [][]byte {
+	// The zero packet carries the frequency in tenths of Hz in the 7 high bits of CT.
+	tenths := math.Min(math.Round(d.frequency()*10), 0x7F)
+	packets := [][]byte{encodePacket(byte(tenths)<<1|0x01, 0, 0, [][3]byte{{}})}
+
+	n := d.samplesPerRevolution()
+	step := 360 / float64(n)
+	for first := 0; first < n; first += packetSamples {
+		last := first + packetSamples - 1
+		if last >= n {
+			last = n - 1
+		}
+		samples := make([][3]byte, last-first+1)
+		for i := range samples {
+			samples[i] = d.encodeSample(float64(first+i) * step)
+		}
+		packets = append(packets, encodePacket(0x00, float64(first)*step, float64(last)*step, samples))
+	}
+	return packets
+}
+
+// encodeSample returns the sample at the raw angle: the intensity byte followed by the
+// little endian word of the distance in its 14 high bits and the 2 high bits of the intensity.
+func (d *Device) encodeSample(raw float64) [3]byte {
+	dist := d.measure(raw)
+	intensity := 0
+	if dist > 0 {
+		intensity = d.Intensity
+		if intensity <= 0 {
+			intensity = 300
+		}
+		if d.IntensityNoise > 0 {
+			intensity += int(math.Round(d.random().NormFloat64() * d.IntensityNoise))
+			if intensity < 1 {
+				intensity = 1
+			}
+		}
+		if intensity > 1023 {
+			intensity = 1023
+		}
+	}
+	word := uint16(dist)<<2 | uint16(intensity>>8)&0x3
+	return [3]byte{byte(intensity), byte(word), byte(word >> 8)}
+}
+
+// measure returns the distance in millimeters seen at the raw angle, 0 for a dropout. The
+// device reports the angle before the correction of its optics, which depends on the
+// distance: the distance is the one at the corrected angle.
+func (d *Device) measure(raw float64) int {
+	maxRange := d.MaxRange
+	if maxRange <= 0 {
+		maxRange = 12000
+	}
+	minRange := d.MinRange
+	if minRange <= 0 {
+		minRange = 120
+	}
+
+	dist, ok := 0.0, false
+	angle := raw
+	for i := 0; i < 3; i++ {
+		if dist, ok = d.Environment.Cast(d.Pose.Position(), d.Pose.Heading+angle, maxRange); !ok {
+			return 0
+		}
+		angle = raw + correction(dist)
+	}
+
+	if d.Noise > 0 {
+		dist += d.random().NormFloat64() * d.Noise
+	}
+	if dist < minRange || dist > maxRange {
+		return 0
+	}
+	if dist > maxDistance {
+		return maxDistance
+	}
+	return int(math.Round(dist))
+}
+
+// correction returns the angle correction in degrees the driver applies at the distance.
+func correction(dist float64) float64 {
+	if dist == 0 {
+		return 0
+	}
+	return 180 / math.Pi * math.Atan(21.8*(155.3-dist)/(155.3*dist))
+}
+
+// encodeAngle returns the FSA or LSA field of the angle: its 64ths of degree shifted left
+// with the check bit set.
+func encodeAngle(angle float64) uint16 {
+	return uint16(math.Round(geom.NormalizeAngle(angle)*64))<<1 | 1
+}
+
+// encodePacket returns a scan packet, 3 byte samples, with its check code.
+func encodePacket(ct byte, firstAngle, lastAngle float64, samples [][3]byte) []byte {
+	fsa, lsa := encodeAngle(firstAngle), encodeAngle(lastAngle)
+	cs := uint16(0x55AA) ^ (uint16(len(samples))<<8 | uint16(ct)) ^ fsa ^ lsa
+	for _, sample := range samples {
+		cs ^= uint16(sample[0]) ^ (uint16(sample[2])<<8 | uint16(sample[1]))
+	}
+
+	packet := make([]byte, 10, 10+3*len(samples))
+	binary.LittleEndian.PutUint16(packet, 0x55AA)
+	packet[2], packet[3] = ct, byte(len(samples))
+	binary.LittleEndian.PutUint16(packet[4:], fsa)
+	binary.LittleEndian.PutUint16(packet[6:], lsa)
+	binary.LittleEndian.PutUint16(packet[8:], cs)
+	for _, sample := range samples {
+		packet = append(packet, sample[:]...)
+	}
+	return packet
+}
+
+// random returns the source of the noise.
+func (d *Device) random() *rand.Rand {
+	if d.rand == nil {
+		d.rand = rand.New(rand.NewSource(d.Seed))
+	}
+	return d.rand
+}
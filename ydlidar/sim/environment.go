@@ -0,0 +1,63 @@
+// Package sim simulates a YDLidar G2 in a synthetic environment, for integration tests and
+// demos without hardware.
+//
+// An Environment is made of polygons, the walls of a room and the obstacles in it. A Device
+// scans the environment from its pose and encodes the revolutions in the wire protocol of the
+// G2. A Port plays the device over the Transport interface: it answers the commands written
+// to it and streams the scan packets while scanning, so the whole driver runs unchanged.
+//
+//	env := sim.Room(6000, 4000).Add(sim.Box(geom.Point{X: 1500, Y: 800}, 500, 500))
+//	lidar, err := ydlidar.ConnectTransport(sim.NewPort(&sim.Device{Environment: env}), ydlidar.WithScans(1))
+package sim
+
+import (
+	"math"
+
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Environment is the scene around the lidar, in millimeters.
+type Environment struct {
+	Polygons [][]geom.Point // Closed polygons, their last vertex connects to the first.
+}
+
+// Room returns a rectangular room of width along X and depth along Y, centered on the origin.
+func Room(width, depth float64) Environment {
+	return Environment{}.Add(Box(geom.Point{}, width, depth))
+}
+
+// Box returns the rectangle of width along X and depth along Y centered on center.
+func Box(center geom.Point, width, depth float64) []geom.Point {
+	w, d := width/2, depth/2
+	return []geom.Point{
+		{X: center.X - w, Y: center.Y - d},
+		{X: center.X + w, Y: center.Y - d},
+		{X: center.X + w, Y: center.Y + d},
+		{X: center.X - w, Y: center.Y + d},
+	}
+}
+
+// Add returns the environment with the polygons added.
+func (e Environment) Add(polygons ...[]geom.Point) Environment {
+	e.Polygons = append(append([][]geom.Point(nil), e.Polygons...), polygons...)
+	return e
+}
+
+// Cast returns the distance from origin to the nearest edge hit by the ray at angle degrees,
+// false if nothing is hit within maxRange.
+func (e Environment) Cast(origin geom.Point, angle, maxRange float64) (float64, bool) {
+	far := geom.FromPolar(angle, maxRange)
+	ray := geom.Segment{A: origin, B: geom.Point{X: origin.X + far.X, Y: origin.Y + far.Y}}
+	nearest, hit := math.Inf(1), false
+	for _, polygon := range e.Polygons {
+		for i := range polygon {
+			edge := geom.Segment{A: polygon[i], B: polygon[(i+1)%len(polygon)]}
+			if p, ok := ray.Intersect(edge); ok {
+				if dist := origin.Dist(p); dist < nearest {
+					nearest, hit = dist, true
+				}
+			}
+		}
+	}
+	return nearest, hit
+}
@@ -0,0 +1,234 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"ydlidarg2/ydlidar/protocol"
+)
+
+// Commands of the protocol answered by the Port, each sent after the 0xA5 prefix.
+const (
+	commandPrefix    = protocol.CommandPrefix
+	commandScan      = protocol.CmdStartScan
+	commandStop      = protocol.CmdStopScan
+	commandInfo      = protocol.CmdDeviceInfo
+	commandHealth    = protocol.CmdHealth
+	commandRestart   = protocol.CmdRestart
+	commandFrequency = protocol.CmdGetScanFrequency
+	commandUpLarge   = protocol.CmdIncreaseFrequencyLarge
+	commandDownLarge = protocol.CmdDecreaseFrequencyLarge
+	commandUpSmall   = protocol.CmdIncreaseFrequencySmall
+	commandDownSmall = protocol.CmdDecreaseFrequencySmall
+	typeInfo         = protocol.TypeDeviceInfo
+	typeHealth       = protocol.TypeHealth
+	typeScan         = protocol.TypeScan
+)
+
+// Scan frequency range of the G2 in Hz.
+const (
+	minFrequency = 5
+	maxFrequency = 12
+)
+
+// defaultReadTimeout is the read timeout of the Port until one is set.
+const defaultReadTimeout = 100 * time.Millisecond
+
+// bootBanner is printed by the device when it restarts.
+var bootBanner = []byte("YDLIDAR G2\r\n")
+
+// ErrClosed is returned by the Port once closed.
+var ErrClosed = errors.New("sim: port closed")
+
+// Port plays a Device as a serial port: it answers the commands written to it and streams
+// the revolutions of the device while scanning. Port implements ydlidar.Transport along
+// with the optional DTR and buffer reset interfaces.
+type Port struct {
+	Device   *Device
+	Realtime bool // Streams the packets at the pace of the device rather than as fast as they are read.
+
+	mu       sync.Mutex
+	output   bytes.Buffer  // Bytes ready to be read.
+	pending  [][]byte      // Packets of the revolution being streamed, not read yet.
+	next     time.Time     // When the next pending packet is due in realtime.
+	interval time.Duration // Time between the packets of the revolution being streamed.
+	command  []byte        // Bytes of a command split over several writes.
+	timeout  time.Duration // Read timeout.
+	scanning bool
+	motor    bool // DTR, the motor spins while it is raised.
+	closed   bool
+}
+
+// NewPort returns a port playing the device, with its motor spinning.
+func NewPort(device *Device) *Port {
+	return &Port{Device: device, timeout: defaultReadTimeout, motor: true}
+}
+
+// Read returns the queued responses, then the scan packets while scanning. Like a serial
+// port it returns 0 bytes and no error once the read timeout expires without data.
+func (p *Port) Read(b []byte) (int, error) {
+	deadline := time.Now().Add(p.readTimeout())
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return 0, ErrClosed
+		}
+		wait := p.stream()
+		if p.output.Len() > 0 {
+			n, err := p.output.Read(b)
+			p.mu.Unlock()
+			return n, err
+		}
+		p.mu.Unlock()
+
+		now := time.Now()
+		if !now.Before(deadline) {
+			return 0, nil
+		}
+		if wait <= 0 || now.Add(wait).After(deadline) {
+			wait = deadline.Sub(now)
+		}
+		time.Sleep(wait)
+	}
+}
+
+// stream moves the scan packets that are due to the output, and returns how long until
+// the next one is due, 0 if none is coming.
+func (p *Port) stream() time.Duration {
+	if !p.scanning || !p.motor {
+		return 0
+	}
+	if len(p.pending) == 0 {
+		// The packets of a revolution are spread over its period.
+		p.pending = p.Device.packets()
+		p.interval = time.Duration(float64(time.Second) / p.Device.frequency() / float64(len(p.pending)))
+	}
+	if !p.Realtime {
+		for _, packet := range p.pending {
+			p.output.Write(packet)
+		}
+		p.pending = nil
+		return 0
+	}
+
+	now := time.Now()
+	if p.next.IsZero() {
+		p.next = now
+	}
+	for len(p.pending) > 0 && !p.next.After(now) {
+		p.output.Write(p.pending[0])
+		p.pending = p.pending[1:]
+		p.next = p.next.Add(p.interval)
+	}
+	return p.next.Sub(now)
+}
+
+// Write decodes the commands and queues their responses.
+func (p *Port) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, ErrClosed
+	}
+
+	p.command = append(p.command, b...)
+	for len(p.command) >= 2 {
+		if p.command[0] != commandPrefix {
+			// Not a command, the device ignores it.
+			p.command = p.command[1:]
+			continue
+		}
+		p.handle(p.command[1])
+		p.command = p.command[2:]
+	}
+	return len(b), nil
+}
+
+// handle runs a command.
+func (p *Port) handle(command byte) {
+	switch command {
+	case commandScan:
+		p.stop()
+		p.respond(typeScan, protocol.ModeContinuous, 5, nil)
+		p.scanning = true
+	case commandStop:
+		p.stop()
+	case commandRestart:
+		p.stop()
+		p.output.Write(bootBanner)
+	case commandInfo:
+		info := make([]byte, 20)
+		info[0], info[1], info[2], info[3] = Model, 0, 1, 1
+		copy(info[4:], p.Device.serial())
+		p.respond(typeInfo, protocol.ModeSingle, len(info), info)
+	case commandHealth:
+		health := []byte{p.Device.Health, byte(p.Device.ErrorCode), byte(p.Device.ErrorCode >> 8)}
+		p.respond(typeHealth, protocol.ModeSingle, len(health), health)
+	case commandFrequency, commandUpLarge, commandDownLarge, commandUpSmall, commandDownSmall:
+		steps := map[byte]float64{commandUpLarge: 1, commandDownLarge: -1, commandUpSmall: 0.1, commandDownSmall: -0.1}
+		hz := math.Round((p.Device.frequency()+steps[command])*10) / 10
+		p.Device.Frequency = math.Max(minFrequency, math.Min(maxFrequency, hz))
+		frequency := make([]byte, 4)
+		binary.LittleEndian.PutUint32(frequency, uint32(math.Round(p.Device.Frequency*100)))
+		p.respond(typeInfo, protocol.ModeSingle, len(frequency), frequency)
+	}
+}
+
+// stop stops the scan, dropping the packets not read yet.
+func (p *Port) stop() {
+	p.scanning = false
+	p.pending = nil
+	p.next = time.Time{}
+	p.output.Reset()
+}
+
+// respond queues a response: the 7 byte header followed by the payload.
+func (p *Port) respond(typeCode, mode byte, size int, payload []byte) {
+	p.output.Write(protocol.ResponseHeader{Length: uint32(size), Mode: mode, TypeCode: typeCode}.Encode())
+	p.output.Write(payload)
+}
+
+// readTimeout returns the read timeout.
+func (p *Port) readTimeout() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.timeout
+}
+
+// SetReadTimeout sets how long Read waits for data.
+func (p *Port) SetReadTimeout(t time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timeout = t
+	return nil
+}
+
+// SetDTR raises or lowers DTR, which spins or stops the motor. The device sends no packet
+// while its motor is stopped.
+func (p *Port) SetDTR(dtr bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.motor = dtr
+	return nil
+}
+
+// ResetInputBuffer discards the bytes ready to be read.
+func (p *Port) ResetInputBuffer() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.output.Reset()
+	return nil
+}
+
+// Close closes the port, reads and writes fail afterwards.
+func (p *Port) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
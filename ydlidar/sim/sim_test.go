@@ -0,0 +1,133 @@
+package sim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+func TestCast(t *testing.T) {
+	env := Room(4000, 2000).Add(Box(geom.Point{X: -1000}, 200, 200))
+	dist, ok := env.Cast(geom.Point{}, 0, 12000)
+	assert.True(t, ok)
+	assert.InDelta(t, 2000, dist, 1e-9)
+	dist, ok = env.Cast(geom.Point{}, 90, 12000)
+	assert.True(t, ok)
+	assert.InDelta(t, 1000, dist, 1e-9)
+	dist, ok = env.Cast(geom.Point{}, 180, 12000)
+	assert.True(t, ok)
+	assert.InDelta(t, 900, dist, 1e-9, "the box is in front of the wall")
+
+	_, ok = env.Cast(geom.Point{}, 0, 1500)
+	assert.False(t, ok, "beyond the range")
+	_, ok = Environment{}.Cast(geom.Point{}, 0, 12000)
+	assert.False(t, ok)
+}
+
+func TestRevolutionPackets(t *testing.T) {
+	device := &Device{Environment: Room(4000, 4000), Frequency: 10, SampleRate: 5000}
+	packets := device.packets()
+	require.Len(t, packets, 1+500/packetSamples+1)
+
+	zero := packets[0]
+	assert.Equal(t, []byte{0xAA, 0x55}, zero[:2])
+	assert.Equal(t, byte(100<<1|1), zero[2], "10Hz in tenths of Hz")
+	assert.Equal(t, byte(1), zero[3])
+
+	samples := 0
+	for _, packet := range packets[1:] {
+		n := int(packet[3])
+		assert.Len(t, packet, 10+3*n)
+		samples += n
+	}
+	assert.Equal(t, 500, samples)
+	assert.Len(t, device.Revolution(), len(bytesOf(packets)))
+}
+
+// bytesOf concatenates the packets.
+func bytesOf(packets [][]byte) []byte {
+	var b []byte
+	for _, packet := range packets {
+		b = append(b, packet...)
+	}
+	return b
+}
+
+func TestConnect(t *testing.T) {
+	device := &Device{Environment: Room(4000, 3000), Noise: 5}
+	port := NewPort(device)
+	lidar, err := ydlidar.ConnectTransport(port, ydlidar.WithScans(2))
+	require.NoError(t, err)
+	defer lidar.Close()
+
+	info, err := lidar.DeviceInfo()
+	require.NoError(t, err)
+	assert.Equal(t, "G2", info.ModelName)
+	hz, err := lidar.SetMotorSpeed(9)
+	require.NoError(t, err)
+	assert.Equal(t, 9.0, hz)
+
+	require.NoError(t, lidar.StartScan())
+	var scan ydlidar.Scan
+	timeout := time.After(5 * time.Second)
+	for scan.Points == nil || scan.Partial {
+		select {
+		case packet := <-lidar.Packets:
+			require.NoError(t, packet.Error)
+		case scan = <-lidar.Scans:
+		case <-timeout:
+			t.Fatal("no revolution")
+		}
+	}
+	require.NoError(t, lidar.StopScan())
+
+	// 5000 samples per second at 9Hz, every one of them on a wall of the room.
+	assert.Len(t, scan.Points, 556)
+	for _, point := range scan.Points {
+		assert.True(t, point.Dist >= 1500-20 && point.Dist <= 2500+20, "%v mm at %v°", point.Dist, point.Angle)
+		assert.Equal(t, 300, point.Intensity)
+	}
+}
+
+func TestHealthAndRestart(t *testing.T) {
+	port := NewPort(&Device{Health: 2, ErrorCode: 0x200})
+	lidar := ydlidar.NewLidar(port)
+	report, err := lidar.HealthInfo()
+	assert.Error(t, err)
+	assert.True(t, report.Fatal)
+
+	port.Device.Health, port.Device.ErrorCode = 0, 0
+	_, err = lidar.HealthInfo()
+	assert.NoError(t, err)
+
+	// The boot banner ends the wait for the device.
+	start := time.Now()
+	require.NoError(t, lidar.Reboot())
+	assert.Less(t, time.Since(start), time.Second)
+
+	require.NoError(t, port.Close())
+	_, err = lidar.DeviceInfo()
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestRealtime(t *testing.T) {
+	port := NewPort(&Device{Environment: Room(4000, 4000), Frequency: 10})
+	port.Realtime = true
+	_, err := port.Write([]byte{commandPrefix, commandScan})
+	require.NoError(t, err)
+
+	// The scan response and the first packets are there right away, a revolution takes 100ms.
+	start := time.Now()
+	total := 0
+	buf := make([]byte, 4096)
+	for total < 7+len(port.Device.Revolution()) {
+		n, err := port.Read(buf)
+		require.NoError(t, err)
+		total += n
+	}
+	assert.InDelta(t, 100*time.Millisecond, time.Since(start), float64(60*time.Millisecond))
+}
@@ -0,0 +1,110 @@
+// Package tracing records the latency of the stages a lidar packet goes through, from the
+// serial read to the consumers, with OpenTelemetry:
+//
+//	observer, err := tracing.NewObserver("front")
+//	lidar, err := ydlidar.InitAndConnectToDevice(nil, ydlidar.WithStageObserver(observer))
+//
+// Every stage of every packet is a span, named after the stage, eg. ydlidar.parse, and a
+// measurement of the ydlidar.stage.duration histogram. A G2 sends about 125 packets per
+// second, so the tracer provider should sample. The global providers are used unless set
+// with WithTracerProvider and WithMeterProvider.
+//
+// Without OpenTelemetry, the lidar keeps a LatencyHistogram, see ydlidar.YDLidar.Latency.
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"ydlidarg2/ydlidar"
+)
+
+// instrumentationName identifies the spans and measurements of this package.
+const instrumentationName = "ydlidarg2/ydlidar/tracing"
+
+// Option configures an Observer.
+type Option func(*Observer)
+
+// WithTracerProvider records the spans with the provider instead of the global one.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *Observer) {
+		o.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider records the durations with the provider instead of the global one.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(o *Observer) {
+		o.meterProvider = provider
+	}
+}
+
+// Observer is a ydlidar.StageObserver recording the stages as spans and measurements.
+type Observer struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	stages   map[ydlidar.Stage]stageAttributes
+}
+
+// stageAttributes are computed once per stage, the scan loop records them for every packet.
+type stageAttributes struct {
+	name  string
+	span  trace.SpanStartEventOption
+	point metric.MeasurementOption
+}
+
+// NewObserver returns an observer for the lidar, whose name is set in the ydlidar.lidar
+// attribute of the spans and measurements.
+func NewObserver(lidar string, opts ...Option) (*Observer, error) {
+	o := &Observer{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		stages:         map[ydlidar.Stage]stageAttributes{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	o.tracer = o.tracerProvider.Tracer(instrumentationName)
+	var err error
+	o.duration, err = o.meterProvider.Meter(instrumentationName).Float64Histogram(
+		"ydlidar.stage.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Time a packet spent in a stage, from the serial read to the consumers."),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, stage := range ydlidar.Stages {
+		attributes := []attribute.KeyValue{
+			attribute.String("ydlidar.lidar", lidar),
+			attribute.String("ydlidar.stage", stage.String()),
+		}
+		o.stages[stage] = stageAttributes{
+			name:  "ydlidar." + stage.String(),
+			span:  trace.WithAttributes(attributes...),
+			point: metric.WithAttributeSet(attribute.NewSet(attributes...)),
+		}
+	}
+	return o, nil
+}
+
+// ObserveStage records the stage as a span and a measurement.
+func (o *Observer) ObserveStage(stage ydlidar.Stage, start, end time.Time) {
+	attributes, ok := o.stages[stage]
+	if !ok {
+		return
+	}
+	ctx := context.Background()
+	_, span := o.tracer.Start(ctx, attributes.name, trace.WithTimestamp(start), attributes.span)
+	span.End(trace.WithTimestamp(end))
+	o.duration.Record(ctx, end.Sub(start).Seconds(), attributes.point)
+}
@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"ydlidarg2/ydlidar"
+)
+
+func TestObserver(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	observer, err := NewObserver("front",
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	require.NoError(t, err)
+
+	t0 := time.Unix(1700000000, 0)
+	observer.ObserveStage(ydlidar.StageParse, t0, t0.Add(2*time.Millisecond))
+	observer.ObserveStage(ydlidar.StageParse, t0, t0.Add(4*time.Millisecond))
+	observer.ObserveStage(ydlidar.Stage(42), t0, t0.Add(time.Second))
+
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	assert.Equal(t, "ydlidar.parse", ended[0].Name())
+	assert.True(t, t0.Equal(ended[0].StartTime()))
+	assert.True(t, t0.Add(2*time.Millisecond).Equal(ended[0].EndTime()))
+	assert.Contains(t, ended[0].Attributes(), attribute.String("ydlidar.lidar", "front"))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+	duration := rm.ScopeMetrics[0].Metrics[0]
+	assert.Equal(t, "ydlidar.stage.duration", duration.Name)
+	points := duration.Data.(metricdata.Histogram[float64]).DataPoints
+	require.Len(t, points, 1)
+	assert.Equal(t, uint64(2), points[0].Count)
+	assert.InDelta(t, 0.006, points[0].Sum, 1e-9)
+	stage, _ := points[0].Attributes.Value("ydlidar.stage")
+	assert.Equal(t, "parse", stage.AsString())
+}
@@ -0,0 +1,255 @@
+// Package track follows people and other moving objects around the lidar: the points of each
+// revolution are clustered, the clusters matched to the tracks of the previous revolutions by
+// nearest neighbor and smoothed by a constant velocity Kalman filter, so followers and
+// greeters get stable identities, positions and velocities.
+//
+//	tracker := &track.Tracker{Clusterer: features.Clusterer{Eps: 50, MinPoints: 3}, MaxWidth: 600}
+//	for scan := range lidar.Scans {
+//		for _, t := range tracker.Update(scan) {
+//			fmt.Println(t.ID, t.Position, t.Velocity)
+//		}
+//	}
+package track
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/features"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Track is an object followed across revolutions.
+type Track struct {
+	ID       uint64     // Identity of the object, unique for the tracker.
+	Position geom.Point // Filtered position in millimeters, in the lidar frame.
+	Velocity geom.Point // Filtered velocity in millimeters per second.
+	Width    float64    // Diagonal of the bounding box of the last matched cluster in millimeters.
+	Hits     int        // Revolutions the object was seen in.
+	Missed   int        // Consecutive revolutions the object was not seen in, 0 when seen in the last one.
+}
+
+// Speed returns the norm of the velocity in millimeters per second.
+func (t Track) Speed() float64 {
+	return math.Hypot(t.Velocity.X, t.Velocity.Y)
+}
+
+// Default tuning used for the zero fields of a Tracker.
+const (
+	defaultMaxDistance  = 300   // mm
+	defaultMaxMissed    = 5     // revolutions
+	defaultMinHits      = 3     // revolutions
+	defaultAcceleration = 2000  // mm/s²
+	defaultNoise        = 30    // mm
+	defaultPeriod       = 0.1   // s, a revolution at 10 Hz
+	initialSpeed        = 1500. // mm/s, standard deviation of the velocity of a new track
+)
+
+// Tracker follows the clusters of the revolutions given to Update. The zero value of the
+// tuning fields picks a default suited to people walking around a lidar at knee height.
+type Tracker struct {
+	Clusterer features.Clusterer // Groups the points into objects.
+	MinWidth  float64            // Clusters smaller or larger in millimeters are not tracked, eg. walls. 0 for no limit.
+	MaxWidth  float64
+
+	MaxDistance  float64 // Gate in millimeters between the predicted position and a cluster, 300 by default.
+	MaxMissed    int     // Revolutions a track survives without a match, 5 by default.
+	MinHits      int     // Revolutions a track must be seen in before it is reported, 3 by default.
+	Acceleration float64 // Standard deviation of the acceleration in mm/s², 2000 by default.
+	Noise        float64 // Standard deviation of the cluster position in millimeters, 30 by default.
+
+	tracks []*filter
+	nextID uint64
+	last   time.Time
+}
+
+// filter is the state of a track: a constant velocity Kalman filter per axis.
+type filter struct {
+	Track
+	x, y axis
+}
+
+// axis is the position and velocity along an axis with their covariance.
+type axis struct {
+	p, v       float64
+	pp, pv, vv float64 // Covariance of the position and velocity.
+}
+
+// predict moves the state dt seconds ahead with an acceleration of standard deviation q.
+func (a *axis) predict(dt, q float64) {
+	a.p += a.v * dt
+	q2 := q * q
+	a.pp += dt*(2*a.pv+dt*a.vv) + q2*dt*dt*dt*dt/4
+	a.pv += dt*a.vv + q2*dt*dt*dt/2
+	a.vv += q2 * dt * dt
+}
+
+// update corrects the state with a position measurement of standard deviation r.
+func (a *axis) update(z, r float64) {
+	s := a.pp + r*r
+	kp, kv := a.pp/s, a.pv/s
+	innovation := z - a.p
+	a.p += kp * innovation
+	a.v += kv * innovation
+	a.pp, a.pv, a.vv = (1-kp)*a.pp, (1-kp)*a.pv, a.vv-kv*a.pv
+}
+
+// Update tracks the objects of the revolution and returns the confirmed tracks, seen in at
+// least MinHits revolutions, ordered by ID. Tracks not seen in this revolution are reported
+// at their predicted position until MaxMissed revolutions pass.
+func (t *Tracker) Update(scan ydlidar.Scan) []Track {
+	dt := t.period(scan)
+	for _, f := range t.tracks {
+		f.x.predict(dt, t.acceleration())
+		f.y.predict(dt, t.acceleration())
+	}
+
+	clusters := t.objects(scan)
+	matched := make([]bool, len(clusters))
+	seen := make([]bool, len(t.tracks))
+	for _, pair := range t.associate(clusters) {
+		f, c := t.tracks[pair.track], clusters[pair.cluster]
+		f.x.update(c.Centroid.X, t.noise())
+		f.y.update(c.Centroid.Y, t.noise())
+		f.Width = c.Min.Dist(c.Max)
+		f.Hits++
+		matched[pair.cluster], seen[pair.track] = true, true
+	}
+
+	kept := t.tracks[:0]
+	for i, f := range t.tracks {
+		if seen[i] {
+			f.Missed = 0
+		} else {
+			f.Missed++
+		}
+		if f.Missed <= t.maxMissed() {
+			kept = append(kept, f)
+		}
+	}
+	t.tracks = kept
+
+	for i, c := range clusters {
+		if matched[i] {
+			continue
+		}
+		t.nextID++
+		f := &filter{Track: Track{ID: t.nextID, Width: c.Min.Dist(c.Max), Hits: 1}}
+		f.x = axis{p: c.Centroid.X, pp: t.noise() * t.noise(), vv: initialSpeed * initialSpeed}
+		f.y = axis{p: c.Centroid.Y, pp: t.noise() * t.noise(), vv: initialSpeed * initialSpeed}
+		t.tracks = append(t.tracks, f)
+	}
+
+	var tracks []Track
+	for _, f := range t.tracks {
+		if f.Hits < t.minHits() {
+			continue
+		}
+		track := f.Track
+		track.Position = geom.Point{X: f.x.p, Y: f.y.p}
+		track.Velocity = geom.Point{X: f.x.v, Y: f.y.v}
+		tracks = append(tracks, track)
+	}
+	return tracks
+}
+
+// Reset forgets every track, eg. after the lidar moved.
+func (t *Tracker) Reset() {
+	t.tracks = nil
+	t.last = time.Time{}
+}
+
+// period returns the time since the previous revolution in seconds.
+func (t *Tracker) period(scan ydlidar.Scan) float64 {
+	last := t.last
+	t.last = scan.Start
+	if !last.IsZero() && scan.Start.After(last) {
+		return scan.Start.Sub(last).Seconds()
+	}
+	if scan.Frequency > 0 {
+		return 1 / scan.Frequency
+	}
+	return defaultPeriod
+}
+
+// objects returns the clusters of the revolution within the size limits.
+func (t *Tracker) objects(scan ydlidar.Scan) []features.Cluster {
+	var objects []features.Cluster
+	for _, c := range t.Clusterer.Clusters(scan) {
+		width := c.Min.Dist(c.Max)
+		if width < t.MinWidth || (t.MaxWidth > 0 && width > t.MaxWidth) {
+			continue
+		}
+		objects = append(objects, c)
+	}
+	return objects
+}
+
+// pair is a track matched to a cluster.
+type pair struct {
+	track, cluster int
+	dist           float64
+}
+
+// associate matches the tracks to the clusters, nearest pairs first, within MaxDistance.
+func (t *Tracker) associate(clusters []features.Cluster) []pair {
+	var candidates []pair
+	for i, f := range t.tracks {
+		predicted := geom.Point{X: f.x.p, Y: f.y.p}
+		for j, c := range clusters {
+			if d := predicted.Dist(c.Centroid); d <= t.maxDistance() {
+				candidates = append(candidates, pair{track: i, cluster: j, dist: d})
+			}
+		}
+	}
+	sort.Slice(candidates, func(a, b int) bool { return candidates[a].dist < candidates[b].dist })
+
+	usedTracks := make(map[int]bool)
+	usedClusters := make(map[int]bool)
+	var pairs []pair
+	for _, c := range candidates {
+		if usedTracks[c.track] || usedClusters[c.cluster] {
+			continue
+		}
+		usedTracks[c.track], usedClusters[c.cluster] = true, true
+		pairs = append(pairs, c)
+	}
+	return pairs
+}
+
+func (t *Tracker) maxDistance() float64 {
+	if t.MaxDistance > 0 {
+		return t.MaxDistance
+	}
+	return defaultMaxDistance
+}
+
+func (t *Tracker) maxMissed() int {
+	if t.MaxMissed > 0 {
+		return t.MaxMissed
+	}
+	return defaultMaxMissed
+}
+
+func (t *Tracker) minHits() int {
+	if t.MinHits > 0 {
+		return t.MinHits
+	}
+	return defaultMinHits
+}
+
+func (t *Tracker) acceleration() float64 {
+	if t.Acceleration > 0 {
+		return t.Acceleration
+	}
+	return defaultAcceleration
+}
+
+func (t *Tracker) noise() float64 {
+	if t.Noise > 0 {
+		return t.Noise
+	}
+	return defaultNoise
+}
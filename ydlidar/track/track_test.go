@@ -0,0 +1,97 @@
+package track
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/features"
+	"ydlidarg2/ydlidar/geom"
+)
+
+var t0 = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// legs returns the revolution at t seeing a leg, the near side of a 10 cm wide cylinder,
+// centered at each of the points.
+func legs(t time.Time, centers ...geom.Point) ydlidar.Scan {
+	scan := ydlidar.Scan{Start: t}
+	for _, c := range centers {
+		toward, _ := geom.Point{X: -c.X, Y: -c.Y}.Polar()
+		for k := -3; k <= 3; k++ {
+			side := geom.FromPolar(toward+float64(k)*15, 50)
+			angle, dist := geom.Point{X: c.X + side.X, Y: c.Y + side.Y}.Polar()
+			scan.Points = append(scan.Points, ydlidar.PointCloudData{Angle: angle, Dist: dist})
+		}
+	}
+	return scan
+}
+
+func newTracker() *Tracker {
+	return &Tracker{Clusterer: features.Clusterer{Eps: 50, MinPoints: 3}, MaxWidth: 300}
+}
+
+func TestTrackWalking(t *testing.T) {
+	tracker := newTracker()
+	var tracks []Track
+	for i := 0; i < 30; i++ {
+		// Walking to the left at 0.5 m/s, 1 m in front of the lidar.
+		at := time.Duration(i) * 100 * time.Millisecond
+		tracks = tracker.Update(legs(t0.Add(at), geom.Point{X: 1000, Y: -750 + 500*at.Seconds()}))
+		if i < 2 {
+			assert.Empty(t, tracks, "not confirmed yet")
+		}
+	}
+	require.Len(t, tracks, 1)
+	assert.Equal(t, uint64(1), tracks[0].ID)
+	assert.Equal(t, 30, tracks[0].Hits)
+	assert.InDelta(t, 0, tracks[0].Velocity.X, 20)
+	assert.InDelta(t, 500, tracks[0].Velocity.Y, 20)
+	assert.InDelta(t, 500, tracks[0].Speed(), 20)
+	// The near side of the leg is 4 cm closer to the lidar than its center.
+	assert.InDelta(t, 700-40*math.Sin(math.Atan2(700, 1000)), tracks[0].Position.Y, 5)
+}
+
+func TestTrackIdentities(t *testing.T) {
+	tracker := newTracker()
+	var tracks []Track
+	for i := 0; i < 10; i++ {
+		at := time.Duration(i) * 100 * time.Millisecond
+		// Two people walking towards each other 1 m apart, and a wall too wide to track.
+		scan := legs(t0.Add(at), geom.Point{X: 1000 + 300*at.Seconds(), Y: 500}, geom.Point{X: 2000 - 300*at.Seconds(), Y: -500})
+		for y := -1000.0; y <= 1000; y += 20 {
+			angle, dist := geom.Point{X: -3000, Y: y}.Polar()
+			scan.Points = append(scan.Points, ydlidar.PointCloudData{Angle: angle, Dist: dist})
+		}
+		tracks = tracker.Update(scan)
+	}
+	require.Len(t, tracks, 2)
+	assert.Equal(t, uint64(1), tracks[0].ID)
+	assert.Greater(t, tracks[0].Velocity.X, 200.0)
+	assert.Equal(t, uint64(2), tracks[1].ID)
+	assert.Less(t, tracks[1].Velocity.X, -200.0)
+
+	// A person out of sight is kept for MaxMissed revolutions.
+	for i := 0; i < 5; i++ {
+		tracks = tracker.Update(legs(t0.Add(time.Second+time.Duration(i)*100*time.Millisecond), tracks[0].Position))
+		require.Len(t, tracks, 2)
+		assert.Equal(t, i+1, tracks[1].Missed)
+	}
+	tracks = tracker.Update(legs(t0.Add(1500*time.Millisecond), tracks[0].Position))
+	require.Len(t, tracks, 1)
+	assert.Equal(t, uint64(1), tracks[0].ID)
+	assert.Equal(t, 0, tracks[0].Missed)
+}
+
+func TestAxisConverges(t *testing.T) {
+	a := axis{pp: 900, vv: 1500 * 1500}
+	for i := 1; i <= 50; i++ {
+		a.predict(0.1, 100)
+		a.update(100*float64(i)*0.1, 30)
+	}
+	assert.InDelta(t, 100, a.v, 5)
+	assert.True(t, a.pp > 0 && a.vv > 0 && a.pv*a.pv < a.pp*a.vv, "covariance stays positive definite")
+	assert.False(t, math.IsNaN(a.p))
+}
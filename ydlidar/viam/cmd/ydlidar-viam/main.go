@@ -0,0 +1,14 @@
+// Command ydlidar-viam is the Viam module serving the lidar as a camera component, see the
+// viam package. viam-server starts it with the path of the module socket.
+package main
+
+import (
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/module"
+	"go.viam.com/rdk/resource"
+	"ydlidarg2/ydlidar/viam"
+)
+
+func main() {
+	module.ModularMain(resource.APIModel{API: camera.API, Model: viam.Model})
+}
@@ -0,0 +1,189 @@
+// Package viam exposes the lidar to Viam robots as a camera component serving point clouds,
+// so a machine can use it for SLAM, obstacle detection or the 3D view without the C SDK.
+//
+// It is a module of its own, apart from the driver, so that only the users of Viam pull the
+// RDK and its dependencies. The ydlidar-viam command is the Viam module binary registering
+// the model:
+//
+//	{
+//	  "name": "lidar",
+//	  "api": "rdk:component:camera",
+//	  "model": "ydlidarg2:lidar:ydlidar",
+//	  "attributes": {"port": "/dev/ttyUSB0", "max_range_mm": 8000}
+//	}
+//
+// GetPointCloud returns the last revolution as a PCD, in millimeters as Viam expects, X
+// forward and Y to the left in the frame of the lidar, Z always 0.
+package viam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/spatialmath"
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+)
+
+// Model is the model of the camera component.
+var Model = resource.NewModel("ydlidarg2", "lidar", "ydlidar")
+
+// ErrNoImages is returned by Images, the lidar only produces point clouds.
+var ErrNoImages = errors.New("ydlidar: the lidar has no images, use GetPointCloud")
+
+func init() {
+	resource.RegisterComponent(camera.API, Model, resource.Registration[camera.Camera, *Config]{
+		Constructor: newCamera,
+	})
+}
+
+// Config is the attributes of the component.
+type Config struct {
+	// Port is the serial port, tcp://host:port, or empty to auto-detect, see ydlidar.Connect.
+	Port string `json:"port,omitempty"`
+	// MinRangeMM and MaxRangeMM drop the samples closer or further, 0 for no limit.
+	MinRangeMM float64 `json:"min_range_mm,omitempty"`
+	MaxRangeMM float64 `json:"max_range_mm,omitempty"`
+	// MinIntensity drops the samples with a lower intensity.
+	MinIntensity int `json:"min_intensity,omitempty"`
+}
+
+// Validate checks the attributes. The component has no dependencies.
+func (cfg *Config) Validate(path string) ([]string, []string, error) {
+	if cfg.MinRangeMM < 0 || cfg.MaxRangeMM < 0 {
+		return nil, nil, fmt.Errorf("%v: ranges must be positive", path)
+	}
+	if cfg.MaxRangeMM != 0 && cfg.MaxRangeMM <= cfg.MinRangeMM {
+		return nil, nil, fmt.Errorf("%v: max_range_mm must be greater than min_range_mm", path)
+	}
+	return nil, nil, nil
+}
+
+// options returns the lidar options for the attributes.
+func (cfg *Config) options() []ydlidar.Option {
+	opts := []ydlidar.Option{ydlidar.WithUnits(ydlidar.Millimeters)}
+	if cfg.MinRangeMM != 0 || cfg.MaxRangeMM != 0 {
+		opts = append(opts, ydlidar.WithRangeLimits(cfg.MinRangeMM, cfg.MaxRangeMM))
+	}
+	if cfg.MinIntensity != 0 {
+		opts = append(opts, ydlidar.WithMinIntensity(cfg.MinIntensity))
+	}
+	return opts
+}
+
+// lidarCamera is the camera component of a lidar, keeping the last revolution.
+type lidarCamera struct {
+	resource.Named
+	logger       logging.Logger
+	lidar        *ydlidar.YDLidar
+	subscription *ydlidar.Subscription
+
+	mu    sync.Mutex
+	last  *ydlidar.Scan
+	ready chan struct{} // Closed on the first revolution.
+	once  sync.Once
+}
+
+func newCamera(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger logging.Logger) (camera.Camera, error) {
+	cfg, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	lidar, err := ydlidar.Connect(cfg.Port, cfg.options()...)
+	if err != nil {
+		return nil, err
+	}
+	c := newLidarCamera(conf.ResourceName().AsNamed(), logger, lidar)
+	if err := c.start(); err != nil {
+		lidar.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// newLidarCamera returns the component of a connected lidar, not scanning yet.
+func newLidarCamera(named resource.Named, logger logging.Logger, lidar *ydlidar.YDLidar) *lidarCamera {
+	return &lidarCamera{Named: named, logger: logger, lidar: lidar, ready: make(chan struct{})}
+}
+
+// start subscribes to the revolutions and starts the scan.
+func (c *lidarCamera) start() error {
+	c.subscription = c.lidar.OnScan(c.update)
+	if err := c.lidar.StartScan(); err != nil {
+		c.subscription.Unsubscribe()
+		return err
+	}
+	return nil
+}
+
+// update keeps the revolution as the last one.
+func (c *lidarCamera) update(scan ydlidar.Scan) {
+	c.mu.Lock()
+	c.last = &scan
+	c.mu.Unlock()
+	c.once.Do(func() { close(c.ready) })
+}
+
+// NextPointCloud returns the last revolution, waiting for the first one after start up.
+func (c *lidarCamera) NextPointCloud(ctx context.Context, extra map[string]interface{}) (pointcloud.PointCloud, error) {
+	select {
+	case <-c.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	c.mu.Lock()
+	scan := c.last
+	c.mu.Unlock()
+	return ToPointCloud(*scan)
+}
+
+// ToPointCloud converts a revolution to a Viam point cloud in millimeters, with the intensity
+// of the samples. The samples without a measurement are skipped.
+func ToPointCloud(scan ydlidar.Scan) (pointcloud.PointCloud, error) {
+	cloud := pointcloud.NewBasicPointCloud(len(scan.Points))
+	for _, point := range scan.Points {
+		if !scan.IsReturn(point.Dist) {
+			continue
+		}
+		p := geom.FromPolar(point.Angle, scan.Units.ToMillimeters(point.Dist))
+		data := pointcloud.NewBasicData().SetIntensity(uint16(point.Intensity))
+		if err := cloud.Set(pointcloud.NewVector(p.X, p.Y, 0), data); err != nil {
+			return nil, err
+		}
+	}
+	return cloud, nil
+}
+
+// Images returns ErrNoImages.
+func (c *lidarCamera) Images(ctx context.Context, filterSourceNames []string, extra map[string]interface{}) ([]camera.NamedImage, resource.ResponseMetadata, error) {
+	return nil, resource.ResponseMetadata{}, ErrNoImages
+}
+
+// Properties reports the point cloud support.
+func (c *lidarCamera) Properties(ctx context.Context) (camera.Properties, error) {
+	return camera.Properties{SupportsPCD: true}, nil
+}
+
+// Geometries returns no geometry, the shape of the lidar is left to the frame configuration.
+func (c *lidarCamera) Geometries(ctx context.Context, extra map[string]interface{}) ([]spatialmath.Geometry, error) {
+	return nil, nil
+}
+
+// Close stops the scan and closes the lidar.
+func (c *lidarCamera) Close(ctx context.Context) error {
+	if c.subscription == nil {
+		return nil
+	}
+	c.subscription.Unsubscribe()
+	c.subscription = nil
+	if err := c.lidar.StopScan(); err != nil {
+		c.logger.Warnf("Failed to stop the scan: %v", err)
+	}
+	return c.lidar.Close()
+}
@@ -0,0 +1,58 @@
+package viam
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"ydlidarg2/ydlidar"
+)
+
+func TestToPointCloud(t *testing.T) {
+	scan := ydlidar.Scan{Units: ydlidar.Meters, Points: []ydlidar.PointCloudData{{Angle: 90, Dist: 2, Intensity: 9}, {Angle: 91}}}
+	cloud, err := ToPointCloud(scan)
+	require.NoError(t, err)
+	require.Equal(t, 1, cloud.Size())
+
+	// The PCD served by GetPointCloud reads back in millimeters.
+	data, err := pointcloud.ToBytes(cloud)
+	require.NoError(t, err)
+	read, err := pointcloud.ReadPCD(bytes.NewReader(data), "")
+	require.NoError(t, err)
+	read.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		assert.InDelta(t, 0, p.X, 1e-3)
+		assert.InDelta(t, 2000, p.Y, 1e-3)
+		return true
+	})
+}
+
+func TestNextPointCloud(t *testing.T) {
+	c := newLidarCamera(camera.Named("lidar").AsNamed(), logging.NewTestLogger(t), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.NextPointCloud(ctx, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "no revolution yet")
+
+	c.update(ydlidar.Scan{Points: []ydlidar.PointCloudData{{Angle: 0, Dist: 1000}, {Angle: 180, Dist: 500}}})
+	cloud, err := c.NextPointCloud(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cloud.Size())
+
+	_, _, err = c.Images(context.Background(), nil, nil)
+	assert.ErrorIs(t, err, ErrNoImages)
+}
+
+func TestValidate(t *testing.T) {
+	_, _, err := (&Config{Port: "/dev/ttyUSB0", MaxRangeMM: 8000}).Validate("lidar")
+	assert.NoError(t, err)
+	_, _, err = (&Config{MinRangeMM: 100, MaxRangeMM: 50}).Validate("lidar")
+	assert.Error(t, err)
+}
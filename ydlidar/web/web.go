@@ -0,0 +1,181 @@
+// Package web serves a live view of the lidar to browsers: an embedded page rendering the
+// point cloud on a canvas, fed by a WebSocket stream of the assembled revolutions.
+//
+//	server := web.NewServer()
+//	lidar, err := ydlidar.InitAndConnectToDevice(port, ydlidar.WithScanProcessor(server))
+//	http.ListenAndServe(":8080", server)
+//
+// Each revolution is sent as a JSON text message:
+//
+//	{"seq": 12, "time": "2024-01-02T03:04:05Z", "points": [[angle, distance, intensity], ...]}
+//
+// or, for clients on a constrained link connecting to /ws?format=cbor or /ws?format=msgpack,
+// as a binary message encoded by the codec package. Clients needing only the distances or
+// the intensities connect to /ws?format=distances or /ws?format=intensities.
+package web
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/codec"
+)
+
+//go:embed static
+var static embed.FS
+
+// clientBuffer is the number of messages queued for a client before new ones are dropped.
+const clientBuffer = 4
+
+// message is the JSON encoding of a revolution.
+type message struct {
+	Seq    uint64       `json:"seq"`
+	Time   time.Time    `json:"time"`
+	Points [][3]float32 `json:"points"` // Angle in degrees, distance in millimeters, intensity.
+}
+
+// Server serves the page and the stream. It is a ydlidar.ScanProcessor publishing every
+// revolution it processes, and an http.Handler.
+type Server struct {
+	mux     *http.ServeMux
+	mu      sync.Mutex
+	clients map[chan []byte]codec.Codec // Codec of each client, nil for JSON.
+}
+
+// NewServer returns a server with no clients.
+func NewServer() *Server {
+	s := &Server{mux: http.NewServeMux(), clients: make(map[chan []byte]codec.Codec)}
+	files, _ := fs.Sub(static, "static")
+	s.mux.Handle("/", http.FileServer(http.FS(files)))
+	s.mux.HandleFunc("/ws", s.serveWebSocket)
+	return s
+}
+
+// Handle registers an extra handler, eg. the metrics endpoint, next to the page.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// ServeHTTP serves the page on / and the stream on /ws.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ProcessScan publishes the revolution, see Publish.
+func (s *Server) ProcessScan(scan *ydlidar.Scan) error {
+	s.Publish(*scan)
+	return nil
+}
+
+// Publish sends the revolution to every connected client. Slow clients miss revolutions
+// rather than holding up the others.
+func (s *Server) Publish(scan ydlidar.Scan) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.clients) == 0 {
+		return
+	}
+
+	// Each format is encoded once, when a client first needs it.
+	encoded := make(map[codec.Codec][]byte)
+	for client, c := range s.clients {
+		data, ok := encoded[c]
+		if !ok {
+			data = encode(c, scan)
+			encoded[c] = data
+		}
+		if data == nil {
+			continue
+		}
+		select {
+		case client <- data:
+		default:
+		}
+	}
+}
+
+// encode encodes the revolution with the codec, in JSON if nil. Returns nil on failure.
+func encode(c codec.Codec, scan ydlidar.Scan) []byte {
+	if c != nil {
+		return c.Encode(scan)
+	}
+	msg := message{Seq: scan.Seq, Time: scan.Start, Points: make([][3]float32, 0, len(scan.Points))}
+	for _, point := range scan.Points {
+		if scan.IsReturn(point.Dist) {
+			msg.Points = append(msg.Points, [3]float32{float32(point.Angle), float32(scan.Units.ToMillimeters(point.Dist)), float32(point.Intensity)})
+		}
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Failed to encode revolution #%v: %v", scan.Seq, err)
+		return nil
+	}
+	return data
+}
+
+// serveWebSocket streams the revolutions to a client until it goes away.
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	var c codec.Codec
+	op := byte(opText)
+	if format := r.URL.Query().Get("format"); format != "" && format != "json" {
+		var ok bool
+		if c, ok = codec.ByName(format); !ok {
+			http.Error(w, "unknown format "+format, http.StatusBadRequest)
+			return
+		}
+		op = opBinary
+	}
+
+	conn, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	messages := make(chan []byte, clientBuffer)
+	s.mu.Lock()
+	s.clients[messages] = c
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, messages)
+		s.mu.Unlock()
+	}()
+
+	// Frames are written from this goroutine only, the read loop hands it the pongs.
+	pongs := make(chan []byte, 1)
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.readLoop(func(payload []byte) error {
+			select {
+			case pongs <- payload:
+			default:
+			}
+			return nil
+		})
+	}()
+
+	for {
+		var err error
+		select {
+		case data := <-messages:
+			err = conn.writeFrame(op, data)
+		case payload := <-pongs:
+			err = conn.writeFrame(opPong, payload)
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package web
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/codec"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// Example from RFC 6455 section 1.3.
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", acceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+}
+
+func TestPage(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewServer().ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<canvas")
+}
+
+// dial opens a WebSocket on the path and waits for the server to register the client.
+func dial(t *testing.T, server *Server, ts *httptest.Server, path string) (net.Conn, *bufio.Reader) {
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	require.NoError(t, err)
+	_, err = io.WriteString(conn, "GET "+path+" HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	require.NoError(t, err)
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+
+	deadline := time.Now().Add(time.Second)
+	for server.clientCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	return conn, r
+}
+
+func TestStream(t *testing.T) {
+	server := NewServer()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	conn, r := dial(t, server, ts, "/ws")
+	defer conn.Close()
+	scan := &ydlidar.Scan{Seq: 3, Points: []ydlidar.PointCloudData{{Angle: 10, Dist: 1000, Intensity: 7}, {Angle: 11}}}
+	require.NoError(t, server.ProcessScan(scan))
+
+	header := make([]byte, 2)
+	_, err := io.ReadFull(r, header)
+	require.NoError(t, err)
+	assert.Equal(t, byte(finBit|opText), header[0])
+	payload := make([]byte, header[1])
+	_, err = io.ReadFull(r, payload)
+	require.NoError(t, err)
+
+	var msg message
+	require.NoError(t, json.Unmarshal(payload, &msg))
+	assert.Equal(t, uint64(3), msg.Seq)
+	assert.Equal(t, [][3]float32{{10, 1000, 7}}, msg.Points)
+
+	// A masked close frame unregisters the client.
+	_, err = conn.Write([]byte{finBit | opClose, 0x80, 1, 2, 3, 4})
+	require.NoError(t, err)
+	deadline := time.Now().Add(time.Second)
+	for server.clientCount() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 0, server.clientCount())
+}
+
+func TestStreamCBOR(t *testing.T) {
+	server := NewServer()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	conn, r := dial(t, server, ts, "/ws?format=cbor")
+	defer conn.Close()
+	scan := ydlidar.Scan{Seq: 3, Points: []ydlidar.PointCloudData{{Angle: 10, Dist: 1000, Intensity: 7}, {Angle: 11}}}
+	server.Publish(scan)
+
+	header := make([]byte, 2)
+	_, err := io.ReadFull(r, header)
+	require.NoError(t, err)
+	assert.Equal(t, byte(finBit|opBinary), header[0])
+	payload := make([]byte, header[1])
+	_, err = io.ReadFull(r, payload)
+	require.NoError(t, err)
+
+	decoded, err := codec.CBOR.Decode(payload)
+	require.NoError(t, err)
+	assert.Equal(t, scan, decoded)
+}
+
+func TestStreamUnknownFormat(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewServer().ServeHTTP(rec, httptest.NewRequest("GET", "/ws?format=xml", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func (s *Server) clientCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
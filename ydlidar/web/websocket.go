@@ -0,0 +1,177 @@
+package web
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The server only needs to push text and binary frames and notice when the browser goes
+// away, so this is a minimal server side implementation of RFC 6455: no extensions, no
+// fragmented client messages, client payloads are read and discarded.
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	opText   = 0x1
+	opBinary = 0x2
+	opClose  = 0x8
+	opPing   = 0x9
+	opPong   = 0xA
+
+	finBit = 0x80
+)
+
+const writeTimeout = 5 * time.Second
+
+var errNotWebSocket = errors.New("web: not a websocket handshake")
+
+// wsConn is an established websocket connection.
+type wsConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+}
+
+// upgrade completes the websocket handshake and takes over the connection.
+func upgrade(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || key == "" ||
+		!headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "websocket handshake expected", http.StatusBadRequest)
+		return nil, errNotWebSocket
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, errNotWebSocket
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n")
+	if err = rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, rw: rw}, nil
+}
+
+// acceptKey returns the Sec-WebSocket-Accept value for the client key.
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// headerContains reports whether the comma separated header contains the token, ignoring case.
+func headerContains(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, v := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeFrame writes an unmasked frame with the whole payload.
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	header := []byte{finBit | op, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	n := 2
+	switch l := len(payload); {
+	case l < 126:
+		header[1] = byte(l)
+	case l <= 0xFFFF:
+		header[1] = 126
+		binary.BigEndian.PutUint16(header[2:], uint16(l))
+		n = 4
+	default:
+		header[1] = 127
+		binary.BigEndian.PutUint64(header[2:], uint64(l))
+		n = 10
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.rw.Write(header[:n]); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(payload); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+// readLoop reads the client frames until the connection closes, answering pings and close
+// frames. Only control frames are answered here, pongs are written under the caller's lock
+// through pong.
+func (c *wsConn) readLoop(pong func([]byte) error) error {
+	header := make([]byte, 2)
+	for {
+		if _, err := io.ReadFull(c.rw, header); err != nil {
+			return err
+		}
+		op := header[0] & 0x0F
+		length := uint64(header[1] & 0x7F)
+		switch length {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+				return err
+			}
+			length = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+				return err
+			}
+			length = binary.BigEndian.Uint64(ext[:])
+		}
+		var mask [4]byte
+		if header[1]&0x80 != 0 {
+			if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+				return err
+			}
+		}
+
+		if op < opClose {
+			// Data frames are of no interest.
+			if _, err := io.CopyN(io.Discard, c.rw, int64(length)); err != nil {
+				return err
+			}
+			continue
+		}
+		if length > 125 {
+			return errors.New("web: control frame too long")
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.rw, payload); err != nil {
+			return err
+		}
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+		switch op {
+		case opClose:
+			return io.EOF
+		case opPing:
+			if err := pong(payload); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Close closes the underlying connection.
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
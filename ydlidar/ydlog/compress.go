@@ -0,0 +1,106 @@
+package ydlog
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/klauspost/compress/zstd"
+
+	"ydlidarg2/ydlidar"
+)
+
+// Resolution of the points of compressed logs, at least the one of the G2 samples.
+const (
+	angleStep    = 0.01 // Degrees.
+	distanceStep = 0.25 // Millimeters.
+)
+
+// maxDecodedSize bounds the memory a corrupted compressed frame can claim.
+const maxDecodedSize = 64 << 20
+
+// errPacked is returned for a compressed frame that doesn't decode.
+var errPacked = errors.New("ydlog: invalid compressed frame")
+
+// packPoints appends the points to the payload header as delta encoded columns: the angles
+// in steps of angleStep, the distances in steps of distanceStep, the intensities, then the
+// point flags, each value the zigzag varint of its difference to the previous point. The
+// points without a return repeat the previous distance: the invalid distance, NaN or +Inf,
+// has no step count, the point flags tell them. The deltas of neighbouring points are small
+// and repetitive, which zstd compresses well.
+func packPoints(dst []byte, scan *ydlidar.Scan) []byte {
+	var previous int64
+	column := func(value func(ydlidar.PointCloudData) int64) {
+		previous = 0
+		for _, point := range scan.Points {
+			v := value(point)
+			dst = binary.AppendVarint(dst, v-previous)
+			previous = v
+		}
+	}
+	column(func(p ydlidar.PointCloudData) int64 { return int64(math.Round(p.Angle / angleStep)) })
+	column(func(p ydlidar.PointCloudData) int64 {
+		if !scan.IsReturn(p.Dist) {
+			return previous
+		}
+		return int64(math.Round(scan.Units.ToMillimeters(p.Dist) / distanceStep))
+	})
+	column(func(p ydlidar.PointCloudData) int64 { return int64(uint16(p.Intensity)) })
+	column(func(p ydlidar.PointCloudData) int64 { return int64(pointFlags(scan, p)) })
+	return dst
+}
+
+// unpackPoints decodes count points packed by packPoints, the points without a return at the
+// invalid distance. The points of the version 2 logs have no point flags column.
+func unpackPoints(data []byte, count int, invalid float64, legacy bool) ([]ydlidar.PointCloudData, error) {
+	if count > len(data) {
+		// Every value takes a byte at least.
+		return nil, errPacked
+	}
+	points := make([]ydlidar.PointCloudData, count)
+	column := func(set func(*ydlidar.PointCloudData, int64)) error {
+		var previous int64
+		for i := range points {
+			delta, n := binary.Varint(data)
+			if n <= 0 {
+				return errPacked
+			}
+			data = data[n:]
+			previous += delta
+			set(&points[i], previous)
+		}
+		return nil
+	}
+	if err := column(func(p *ydlidar.PointCloudData, v int64) { p.Angle = float64(v) * angleStep }); err != nil {
+		return nil, err
+	}
+	if err := column(func(p *ydlidar.PointCloudData, v int64) { p.Dist = float64(v) * distanceStep }); err != nil {
+		return nil, err
+	}
+	if err := column(func(p *ydlidar.PointCloudData, v int64) { p.Intensity = int(v) }); err != nil {
+		return nil, err
+	}
+	if !legacy {
+		if err := column(func(p *ydlidar.PointCloudData, v int64) { setPointFlags(p, byte(v), invalid) }); err != nil {
+			return nil, err
+		}
+	}
+	if len(data) != 0 {
+		return nil, errPacked
+	}
+	return points, nil
+}
+
+// newEncoder returns the zstd encoder of the frames. Each frame is compressed on its own so
+// the log stays seekable.
+func newEncoder() *zstd.Encoder {
+	// Only fails on invalid options.
+	encoder, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	return encoder
+}
+
+// newDecoder returns the zstd decoder of the frames.
+func newDecoder() *zstd.Decoder {
+	decoder, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(maxDecodedSize))
+	return decoder
+}
@@ -0,0 +1,208 @@
+package ydlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"ydlidarg2/ydlidar"
+)
+
+// Reader gives random access to the frames of a log, compressed or not.
+type Reader struct {
+	r       io.ReadSeeker
+	index   []Entry
+	decoder *zstd.Decoder // Decompresses the frames of a compressed log, nil otherwise.
+	legacy  bool          // Version 1 or 2 log, without the invalid distance and the point flags.
+}
+
+// NewReader reads the header and index of the log.
+func NewReader(r io.ReadSeeker) (*Reader, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrNotLog
+	}
+	if !bytes.Equal(header[:4], headerMagic[:]) {
+		return nil, ErrNotLog
+	}
+	version := binary.LittleEndian.Uint16(header[4:])
+	if version > Version {
+		return nil, versionError(version)
+	}
+
+	reader := &Reader{r: r, legacy: version < 3}
+	if binary.LittleEndian.Uint16(header[6:])&headerCompressed != 0 {
+		reader.decoder = newDecoder()
+	}
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := reader.readIndex(size); err != nil || ok {
+		return reader, err
+	}
+	return reader, reader.rebuildIndex()
+}
+
+// Len returns the number of frames.
+func (r *Reader) Len() int {
+	return len(r.index)
+}
+
+// Index returns the index entries of the frames.
+func (r *Reader) Index() []Entry {
+	return r.index
+}
+
+// Search returns the number of the first frame starting at or after t, Len if there is none.
+func (r *Reader) Search(t time.Time) int {
+	return sort.Search(len(r.index), func(i int) bool {
+		return r.index[i].Start >= t.UnixNano()
+	})
+}
+
+// Scan reads the frame i.
+func (r *Reader) Scan(i int) (ydlidar.Scan, error) {
+	if _, err := r.r.Seek(r.index[i].Offset, io.SeekStart); err != nil {
+		return ydlidar.Scan{}, err
+	}
+	scan, _, err := r.readFrame()
+	return scan, err
+}
+
+// readIndex reads the footer of a log of size bytes. It returns false if the log has none.
+func (r *Reader) readIndex(size int64) (bool, error) {
+	if size < headerSize+trailerSize {
+		return false, nil
+	}
+	trailer := make([]byte, trailerSize)
+	if _, err := r.r.Seek(size-trailerSize, io.SeekStart); err != nil {
+		return false, err
+	}
+	if _, err := io.ReadFull(r.r, trailer); err != nil {
+		return false, err
+	}
+	if !bytes.Equal(trailer[12:], trailerMagic[:]) {
+		return false, nil
+	}
+
+	offset := int64(binary.LittleEndian.Uint64(trailer))
+	count := int64(binary.LittleEndian.Uint32(trailer[8:]))
+	if offset+count*indexEntrySize+trailerSize != size {
+		return false, nil
+	}
+	data := make([]byte, count*indexEntrySize)
+	if _, err := r.r.Seek(offset, io.SeekStart); err != nil {
+		return false, err
+	}
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return false, err
+	}
+	r.index = make([]Entry, count)
+	for i := range r.index {
+		e := data[i*indexEntrySize:]
+		r.index[i] = Entry{
+			Seq:    binary.LittleEndian.Uint64(e),
+			Start:  int64(binary.LittleEndian.Uint64(e[8:])),
+			Offset: int64(binary.LittleEndian.Uint64(e[16:])),
+		}
+	}
+	return true, nil
+}
+
+// rebuildIndex walks the frames of a log without footer, stopping at the first bad one.
+func (r *Reader) rebuildIndex() error {
+	offset, err := r.r.Seek(headerSize, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	for {
+		scan, size, err := r.readFrame()
+		if err != nil {
+			return nil
+		}
+		r.index = append(r.index, Entry{Seq: scan.Seq, Start: scan.Start.UnixNano(), Offset: offset})
+		offset += size
+	}
+}
+
+// readFrame reads the frame at the current offset and returns its size.
+func (r *Reader) readFrame() (ydlidar.Scan, int64, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r.r, length[:]); err != nil {
+		return ydlidar.Scan{}, 0, err
+	}
+	headerLen, pointLen := payloadHeader, pointSize
+	if r.legacy {
+		headerLen, pointLen = legacyPayloadHeader, legacyPointSize
+	}
+	size := binary.LittleEndian.Uint32(length[:])
+	if size < uint32(headerLen) && r.decoder == nil {
+		return ydlidar.Scan{}, 0, ErrChecksum
+	}
+	data := make([]byte, size+4)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return ydlidar.Scan{}, 0, err
+	}
+	payload := data[:size]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(data[size:]) {
+		return ydlidar.Scan{}, 0, ErrChecksum
+	}
+	if r.decoder != nil {
+		var err error
+		if payload, err = r.decoder.DecodeAll(payload, nil); err != nil || len(payload) < headerLen {
+			return ydlidar.Scan{}, 0, errPacked
+		}
+	}
+
+	count := binary.LittleEndian.Uint32(payload[25:])
+	scan := ydlidar.Scan{
+		Seq:     binary.LittleEndian.Uint64(payload),
+		Start:   time.Unix(0, int64(binary.LittleEndian.Uint64(payload[8:]))),
+		End:     time.Unix(0, int64(binary.LittleEndian.Uint64(payload[16:]))),
+		Partial: payload[24]&flagPartial != 0,
+	}
+	if !r.legacy {
+		scan.Invalid = math.Float64frombits(binary.LittleEndian.Uint64(payload[29:]))
+	}
+	if r.decoder != nil {
+		var err error
+		if scan.Points, err = unpackPoints(payload[headerLen:], int(count), scan.Invalid, r.legacy); err != nil {
+			return ydlidar.Scan{}, 0, err
+		}
+		return scan, int64(size) + frameOverhead, nil
+	}
+
+	if len(payload) != headerLen+int(count)*pointLen {
+		return ydlidar.Scan{}, 0, ErrChecksum
+	}
+	scan.Points = make([]ydlidar.PointCloudData, count)
+	for i := range scan.Points {
+		p := payload[headerLen+i*pointLen:]
+		scan.Points[i] = ydlidar.PointCloudData{
+			Angle:     float64(math.Float32frombits(binary.LittleEndian.Uint32(p))),
+			Dist:      float64(math.Float32frombits(binary.LittleEndian.Uint32(p[4:]))),
+			Intensity: int(binary.LittleEndian.Uint16(p[8:])),
+		}
+		if !r.legacy {
+			setPointFlags(&scan.Points[i], p[10], scan.Invalid)
+		}
+	}
+	return scan, int64(size) + frameOverhead, nil
+}
+
+// setPointFlags sets the flags of a point read back, and the invalid distance if it has no
+// return.
+func setPointFlags(point *ydlidar.PointCloudData, flags byte, invalid float64) {
+	if flags&pointDropout != 0 {
+		point.Dist = invalid
+	}
+	point.Synthetic = flags&pointSynthetic != 0
+	point.Saturated = flags&pointSaturated != 0
+}
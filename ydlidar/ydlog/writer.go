@@ -0,0 +1,180 @@
+package ydlog
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+
+	"ydlidarg2/ydlidar"
+)
+
+// Option configures a Writer.
+type Option func(*Writer)
+
+// WithCompression compresses the frames, see the package documentation. Angles are rounded to
+// 0.01° and distances to 0.25mm.
+func WithCompression() Option {
+	return func(w *Writer) {
+		w.encoder = newEncoder()
+	}
+}
+
+// Writer writes scans to a log. It is a ydlidar.ScanProcessor so it can record a running scan.
+type Writer struct {
+	mu     sync.Mutex
+	w      io.Writer
+	offset int64
+	index  []Entry
+	buf    []byte
+	closed bool
+
+	encoder *zstd.Encoder // Compresses the frames, nil without compression.
+	packed  []byte        // Payload to compress.
+}
+
+// NewWriter writes the log header to w and returns the writer.
+func NewWriter(w io.Writer, opts ...Option) (*Writer, error) {
+	writer := &Writer{w: w, offset: headerSize}
+	for _, opt := range opts {
+		opt(writer)
+	}
+
+	header := make([]byte, headerSize)
+	copy(header, headerMagic[:])
+	binary.LittleEndian.PutUint16(header[4:], Version)
+	if writer.encoder != nil {
+		binary.LittleEndian.PutUint16(header[6:], headerCompressed)
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// ProcessScan writes the scan, see WriteScan.
+func (w *Writer) ProcessScan(scan *ydlidar.Scan) error {
+	return w.WriteScan(*scan)
+}
+
+// WriteScan appends the scan as a frame.
+func (w *Writer) WriteScan(scan ydlidar.Scan) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return io.ErrClosedPipe
+	}
+
+	var frame []byte
+	if w.encoder != nil {
+		frame = w.compressedFrame(&scan)
+	} else {
+		frame = w.frame(&scan)
+	}
+
+	if _, err := w.w.Write(frame); err != nil {
+		return err
+	}
+	w.index = append(w.index, Entry{Seq: scan.Seq, Start: scan.Start.UnixNano(), Offset: w.offset})
+	w.offset += int64(len(frame))
+	return nil
+}
+
+// frame encodes the scan as a plain frame.
+func (w *Writer) frame(scan *ydlidar.Scan) []byte {
+	size := payloadHeader + len(scan.Points)*pointSize
+	frame := w.buffer(size + frameOverhead)
+	binary.LittleEndian.PutUint32(frame, uint32(size))
+	payload := frame[4 : 4+size]
+	putPayloadHeader(payload, scan)
+	for i, point := range scan.Points {
+		p := payload[payloadHeader+i*pointSize:]
+		binary.LittleEndian.PutUint32(p, math.Float32bits(float32(point.Angle)))
+		binary.LittleEndian.PutUint32(p[4:], math.Float32bits(float32(scan.Units.ToMillimeters(point.Dist))))
+		binary.LittleEndian.PutUint16(p[8:], uint16(point.Intensity))
+		p[10] = pointFlags(scan, point)
+	}
+	binary.LittleEndian.PutUint32(frame[4+size:], crc32.ChecksumIEEE(payload))
+	return frame
+}
+
+// compressedFrame encodes the scan as a compressed frame.
+func (w *Writer) compressedFrame(scan *ydlidar.Scan) []byte {
+	w.packed = append(w.packed[:0], make([]byte, payloadHeader)...)
+	putPayloadHeader(w.packed, scan)
+	w.packed = packPoints(w.packed, scan)
+
+	frame := append(w.buf[:0], 0, 0, 0, 0)
+	frame = w.encoder.EncodeAll(w.packed, frame)
+	size := len(frame) - 4
+	binary.LittleEndian.PutUint32(frame, uint32(size))
+	frame = binary.LittleEndian.AppendUint32(frame, crc32.ChecksumIEEE(frame[4:]))
+	w.buf = frame
+	return frame
+}
+
+// putPayloadHeader encodes the seq, times, flags, point count and invalid distance of the scan.
+func putPayloadHeader(payload []byte, scan *ydlidar.Scan) {
+	binary.LittleEndian.PutUint64(payload, scan.Seq)
+	binary.LittleEndian.PutUint64(payload[8:], uint64(scan.Start.UnixNano()))
+	binary.LittleEndian.PutUint64(payload[16:], uint64(scan.End.UnixNano()))
+	payload[24] = 0
+	if scan.Partial {
+		payload[24] = flagPartial
+	}
+	binary.LittleEndian.PutUint32(payload[25:], uint32(len(scan.Points)))
+	binary.LittleEndian.PutUint64(payload[29:], math.Float64bits(scan.Units.ToMillimeters(scan.Invalid)))
+}
+
+// pointFlags returns the point flags of a point of the scan.
+func pointFlags(scan *ydlidar.Scan, point ydlidar.PointCloudData) byte {
+	var flags byte
+	if !scan.IsReturn(point.Dist) {
+		flags |= pointDropout
+	}
+	if point.Synthetic {
+		flags |= pointSynthetic
+	}
+	if point.Saturated {
+		flags |= pointSaturated
+	}
+	return flags
+}
+
+// Close writes the index and trailer. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	footer := make([]byte, len(w.index)*indexEntrySize+trailerSize)
+	for i, entry := range w.index {
+		e := footer[i*indexEntrySize:]
+		binary.LittleEndian.PutUint64(e, entry.Seq)
+		binary.LittleEndian.PutUint64(e[8:], uint64(entry.Start))
+		binary.LittleEndian.PutUint64(e[16:], uint64(entry.Offset))
+	}
+	trailer := footer[len(w.index)*indexEntrySize:]
+	binary.LittleEndian.PutUint64(trailer, uint64(w.offset))
+	binary.LittleEndian.PutUint32(trailer[8:], uint32(len(w.index)))
+	copy(trailer[12:], trailerMagic[:])
+	if w.encoder != nil {
+		w.encoder.Close()
+	}
+	_, err := w.w.Write(footer)
+	return err
+}
+
+// buffer returns the frame buffer grown to size.
+func (w *Writer) buffer(size int) []byte {
+	if cap(w.buf) < size {
+		w.buf = make([]byte, size)
+	}
+	return w.buf[:size]
+}
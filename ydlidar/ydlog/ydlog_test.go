@@ -0,0 +1,252 @@
+package ydlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ydlidarg2/ydlidar"
+	"ydlidarg2/ydlidar/geom"
+	"ydlidarg2/ydlidar/sim"
+)
+
+var t0 = time.Unix(1700000000, 0)
+
+func testScans() []ydlidar.Scan {
+	var scans []ydlidar.Scan
+	for seq := uint64(1); seq <= 3; seq++ {
+		start := t0.Add(time.Duration(seq) * 100 * time.Millisecond)
+		scans = append(scans, ydlidar.Scan{
+			Seq:    seq,
+			Start:  start,
+			End:    start.Add(90 * time.Millisecond),
+			Points: []ydlidar.PointCloudData{{Angle: 1.5, Dist: 1000, Intensity: 300}, {Angle: 2.5}},
+		})
+	}
+	scans[2].Partial = true
+	return scans
+}
+
+func writeLog(t *testing.T, close bool) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	require.NoError(t, err)
+	for _, scan := range testScans() {
+		require.NoError(t, w.ProcessScan(&scan))
+	}
+	if close {
+		require.NoError(t, w.Close())
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	r, err := NewReader(bytes.NewReader(writeLog(t, true)))
+	require.NoError(t, err)
+	require.Equal(t, 3, r.Len())
+
+	for i, want := range testScans() {
+		scan, err := r.Scan(i)
+		require.NoError(t, err)
+		assert.Equal(t, want.Seq, scan.Seq)
+		assert.True(t, want.Start.Equal(scan.Start))
+		assert.True(t, want.End.Equal(scan.End))
+		assert.Equal(t, want.Partial, scan.Partial)
+		assert.Equal(t, want.Points, scan.Points)
+	}
+	assert.Equal(t, 1, r.Search(t0.Add(150*time.Millisecond)))
+	assert.Equal(t, 3, r.Search(t0.Add(time.Second)))
+}
+
+func TestUnclosedAndTruncated(t *testing.T) {
+	data := writeLog(t, false)
+	r, err := NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 3, r.Len())
+	assert.Equal(t, r.Index()[1].Offset, int64(headerSize+frameOverhead+payloadHeader+2*pointSize))
+
+	r, err = NewReader(bytes.NewReader(data[:len(data)-5]))
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.Len())
+}
+
+func TestCorruption(t *testing.T) {
+	data := writeLog(t, true)
+	data[headerSize+frameOverhead] ^= 0xFF
+	r, err := NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	_, err = r.Scan(0)
+	assert.ErrorIs(t, err, ErrChecksum)
+
+	_, err = NewReader(bytes.NewReader([]byte("not a log file")))
+	assert.ErrorIs(t, err, ErrNotLog)
+}
+
+func TestCompressedRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, WithCompression())
+	require.NoError(t, err)
+	for _, scan := range testScans() {
+		require.NoError(t, w.ProcessScan(&scan))
+	}
+	require.NoError(t, w.Close())
+	data := buf.Bytes()
+
+	r, err := NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, 3, r.Len())
+	for i, want := range testScans() {
+		scan, err := r.Scan(i)
+		require.NoError(t, err)
+		assert.Equal(t, want.Seq, scan.Seq)
+		assert.True(t, want.Start.Equal(scan.Start))
+		assert.Equal(t, want.Partial, scan.Partial)
+		assert.Equal(t, want.Points, scan.Points)
+	}
+
+	// Unclosed, the index is rebuilt from the compressed frames.
+	r, err = NewReader(bytes.NewReader(data[:r.Index()[2].Offset+3]))
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.Len())
+
+	data[r.Index()[1].Offset+frameOverhead] ^= 0xFF
+	_, err = r.Scan(1)
+	assert.ErrorIs(t, err, ErrChecksum)
+}
+
+func TestCompressionRatio(t *testing.T) {
+	device := &sim.Device{
+		Environment: sim.Room(6000, 4000).Add(sim.Box(geom.Point{X: 1500, Y: 1000}, 600, 400)),
+		Pose:        geom.Pose2D{X: -500, Y: 200},
+		Noise:       5,
+	}
+	lidar, err := ydlidar.ConnectTransport(sim.NewPort(device), ydlidar.WithScans(1))
+	require.NoError(t, err)
+	defer lidar.Close()
+	require.NoError(t, lidar.StartScan())
+	go func() {
+		for range lidar.Packets {
+		}
+	}()
+
+	var plain, compressed bytes.Buffer
+	pw, err := NewWriter(&plain)
+	require.NoError(t, err)
+	cw, err := NewWriter(&compressed, WithCompression())
+	require.NoError(t, err)
+	var scans []ydlidar.Scan
+	for len(scans) < 20 {
+		scan := <-lidar.Scans
+		if scan.Partial {
+			continue
+		}
+		scans = append(scans, scan)
+		require.NoError(t, pw.WriteScan(scan))
+		require.NoError(t, cw.WriteScan(scan))
+	}
+	require.NoError(t, pw.Close())
+	require.NoError(t, cw.Close())
+
+	ratio := float64(plain.Len()) / float64(compressed.Len())
+	t.Logf("%v bytes compressed to %v, %.1fx", plain.Len(), compressed.Len(), ratio)
+	assert.Greater(t, ratio, 5.0)
+
+	r, err := NewReader(bytes.NewReader(compressed.Bytes()))
+	require.NoError(t, err)
+	scan, err := r.Scan(10)
+	require.NoError(t, err)
+	require.Len(t, scan.Points, len(scans[10].Points))
+	for i, point := range scan.Points {
+		assert.InDelta(t, scans[10].Points[i].Angle, point.Angle, angleStep)
+		assert.InDelta(t, scans[10].Points[i].Dist, point.Dist, distanceStep)
+		assert.Equal(t, scans[10].Points[i].Intensity, point.Intensity)
+	}
+}
+
+func TestInvalidRoundTrip(t *testing.T) {
+	for _, invalid := range []float64{0, math.NaN(), math.Inf(1), 12000} {
+		scan := ydlidar.Scan{Seq: 1, Start: t0, End: t0, Invalid: invalid, Points: []ydlidar.PointCloudData{
+			{Angle: 1, Dist: 1000, Intensity: 300},
+			{Angle: 2, Dist: invalid},
+			{Angle: 3, Dist: 1200, Intensity: 20, Synthetic: true},
+			{Angle: 4, Dist: 11000, Intensity: 5, Saturated: true},
+			{Angle: 5, Dist: invalid, Saturated: true},
+		}}
+		for _, opts := range [][]Option{nil, {WithCompression()}} {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, opts...)
+			require.NoError(t, err)
+			require.NoError(t, w.WriteScan(scan))
+			require.NoError(t, w.Close())
+
+			r, err := NewReader(bytes.NewReader(buf.Bytes()))
+			require.NoError(t, err)
+			decoded, err := r.Scan(0)
+			require.NoError(t, err)
+			name := fmt.Sprintf("invalid %v, compressed %v", invalid, opts != nil)
+			if math.IsNaN(invalid) {
+				assert.True(t, math.IsNaN(decoded.Invalid), name)
+			} else {
+				assert.Equal(t, invalid, decoded.Invalid, name)
+			}
+			require.Len(t, decoded.Points, len(scan.Points), name)
+			for i, point := range decoded.Points {
+				want := scan.Points[i]
+				assert.Equal(t, scan.IsReturn(want.Dist), decoded.IsReturn(point.Dist), "%v, point %v", name, i)
+				if scan.IsReturn(want.Dist) {
+					assert.Equal(t, want.Dist, point.Dist, "%v, point %v", name, i)
+				}
+				assert.Equal(t, want.Synthetic, point.Synthetic, "%v, point %v", name, i)
+				assert.Equal(t, want.Saturated, point.Saturated, "%v, point %v", name, i)
+				assert.Equal(t, want.Intensity, point.Intensity, "%v, point %v", name, i)
+			}
+		}
+	}
+}
+
+func TestInvalidMeters(t *testing.T) {
+	// The distances are written in millimeters, the invalid distance too.
+	scan := ydlidar.Scan{Units: ydlidar.Meters, Invalid: 12, Points: []ydlidar.PointCloudData{{Angle: 1, Dist: 1.5}, {Angle: 2, Dist: 12}}}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, WithCompression())
+	require.NoError(t, err)
+	require.NoError(t, w.WriteScan(scan))
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	decoded, err := r.Scan(0)
+	require.NoError(t, err)
+	assert.Equal(t, 12000.0, decoded.Invalid)
+	assert.Equal(t, []ydlidar.PointCloudData{{Angle: 1, Dist: 1500}, {Angle: 2, Dist: 12000}}, decoded.Points)
+}
+
+func TestLegacyVersion(t *testing.T) {
+	// A version 1 log: the payload has no invalid distance, the points no flags.
+	payload := make([]byte, legacyPayloadHeader+legacyPointSize)
+	binary.LittleEndian.PutUint64(payload, 7)
+	binary.LittleEndian.PutUint32(payload[25:], 1)
+	point := payload[legacyPayloadHeader:]
+	binary.LittleEndian.PutUint32(point, math.Float32bits(1.5))
+	binary.LittleEndian.PutUint32(point[4:], math.Float32bits(1000))
+	binary.LittleEndian.PutUint16(point[8:], 300)
+
+	data := append([]byte("YDLG"), 1, 0, 0, 0)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(payload)))
+	data = append(data, payload...)
+	data = binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(payload))
+
+	r, err := NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, 1, r.Len())
+	scan, err := r.Scan(0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), scan.Seq)
+	assert.Equal(t, []ydlidar.PointCloudData{{Angle: 1.5, Dist: 1000, Intensity: 300}}, scan.Points)
+}